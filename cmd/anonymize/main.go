@@ -0,0 +1,272 @@
+// Command anonymize writes a copy of the database with party names, phone
+// numbers, UPI addresses and account numbers replaced by pseudonyms, to share
+// when reporting a parser or matcher bug. Pseudonyms are consistent, so a
+// party's narrations still carry its identifiers and match it, and keep the
+// shape of what they replace. Notes, shared statement links, the offline
+// sync log and parties' addresses, GSTINs and drug licenses are removed.
+// Archived years' files are not copied.
+//
+// Usage:
+//
+//	anonymize -db suspense.db -out shared.db
+//	anonymize -db suspense.db -out shared.db -key secret  # same pseudonyms as last time
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	_ "modernc.org/sqlite"
+
+	"suspense.durgadawaghar.com/internal/anonymize"
+	"suspense.durgadawaghar.com/internal/parser"
+)
+
+// keepWords are words of names that say what a business is rather than who
+var keepWords = []string{
+	"AND", "THE", "NEW", "SHRI", "SHREE", "GENERAL", "MED", "MEDICINE", "MEDICINES", "AGENCIES",
+	"ENTERPRISES", "PHARMACY", "HOSPITAL", "CLINIC", "NURSING", "HOME", "DISTRIBUTORS", "SURGICAL",
+	"CASH", "CARD", "POS", "BANK", "LTD", "LIMITED", "PVT", "SONS", "BROTHERS", "BROS",
+}
+
+// update rewrites one column of every row of a table
+type update struct {
+	table  string
+	column string
+	where  string
+	fn     func(string) string
+}
+
+func main() {
+	dbPath := flag.String("db", "suspense.db", "SQLite database path")
+	out := flag.String("out", "anonymized.db", "Path to write the anonymized copy to")
+	key := flag.String("key", "", "Key the pseudonyms are derived from; a random one is used when empty")
+	flag.Parse()
+	ctx := context.Background()
+
+	if _, err := os.Stat(*out); err == nil {
+		log.Fatalf("%s already exists", *out)
+	}
+	if *key == "" {
+		b := make([]byte, 16)
+		rand.Read(b)
+		*key = hex.EncodeToString(b)
+	}
+
+	src, err := sql.Open("sqlite", "file:"+*dbPath+"?mode=ro")
+	if err != nil {
+		log.Fatalf("Opening database: %v", err)
+	}
+	if _, err := src.ExecContext(ctx, "VACUUM INTO ?", *out); err != nil {
+		log.Fatalf("Copying database: %v", err)
+	}
+	src.Close()
+
+	db, err := sql.Open("sqlite", *out)
+	if err != nil {
+		log.Fatalf("Opening copy: %v", err)
+	}
+	defer db.Close()
+	if err := run(ctx, db, []byte(*key)); err != nil {
+		db.Close()
+		os.Remove(*out)
+		log.Fatalf("Anonymizing: %v", err)
+	}
+	fmt.Printf("Wrote %s\nKey: %s (pass -key to get the same pseudonyms again)\n", *out, *key)
+}
+
+// run pseudonymizes the copy in place
+func run(ctx context.Context, db *sql.DB, key []byte) error {
+	p := anonymize.New(key, keepWords)
+	for _, words := range [][]string{parser.DefaultVocabulary.Locations, parser.DefaultVocabulary.NonLocationWords} {
+		for _, w := range words {
+			p.Keep(w)
+		}
+	}
+	// The shop's own name and the places parties are in are not personal
+	for _, q := range []string{"SELECT name FROM firms", "SELECT COALESCE(location, '') FROM parties"} {
+		if err := eachString(ctx, db, q, p.Keep); err != nil {
+			return err
+		}
+	}
+	for _, q := range []string{
+		"SELECT name FROM parties",
+		"SELECT party_name FROM sale_bills",
+		"SELECT alias FROM party_aliases",
+		"SELECT value FROM identifiers WHERE type IN ('imps_name', 'neft_name', 'from_name')",
+		"SELECT value FROM identifiers WHERE type = 'upi_vpa' AND value NOT LIKE '%@%'",
+	} {
+		if err := eachString(ctx, db, q, p.AddName); err != nil {
+			return err
+		}
+	}
+
+	// Identifier conflicts and history name an identifier by the same type
+	// and value
+	var updates []update
+	for _, table := range []string{"identifiers", "identifier_conflicts", "identifier_history"} {
+		identifier := func(kind string, fn func(string) string) update {
+			return update{table, "value", "WHERE type = '" + kind + "'", fn}
+		}
+		updates = append(updates,
+			identifier("upi_vpa", p.VPA),
+			identifier("phone", p.Digits),
+			identifier("account_number", p.Digits),
+			identifier("from_account", p.Digits),
+			identifier("imps_name", p.Name),
+			identifier("neft_name", p.Name),
+			identifier("from_name", p.Name),
+		)
+	}
+	updates = append(updates, []update{
+		{"parties", "name", "", p.Name},
+		{"parties", "phone", "", p.Digits},
+		{"sale_bills", "party_name", "", p.Name},
+		{"party_aliases", "alias", "", p.Name},
+		{"identifier_conflicts", "narration", "", p.Narration},
+		{"identifier_history", "detail", "", p.Narration},
+		{"transactions", "narration", "WHERE narration IS NOT NULL", p.Narration},
+		{"search_history", "narration", "", p.Narration},
+		{"search_history", "top_party_name", "WHERE top_party_name IS NOT NULL", p.Name},
+		{"saved_searches", "narration", "", p.Narration},
+		{"saved_searches", "name", "", p.Name},
+		{"accounts", "account_number", "", p.Digits},
+	}...)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Entries of closed years cannot be updated, so the triggers guarding
+	// them are put back once the copy is rewritten
+	triggers, err := dropTriggers(ctx, tx)
+	if err != nil {
+		return err
+	}
+	for _, u := range updates {
+		n, err := apply(ctx, tx, u)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", u.table, u.column, err)
+		}
+		fmt.Printf("%s.%s %s: %d rows\n", u.table, u.column, u.where, n)
+	}
+	for _, stmt := range []string{
+		"DELETE FROM party_notes",
+		"DELETE FROM statement_links",
+		"DELETE FROM sync_log",
+		"UPDATE transactions SET note = ''",
+		"UPDATE cheques SET notes = NULL",
+		"UPDATE firms SET gstin = ''",
+		"UPDATE parties SET address = '', gstin = '', drug_license = ''",
+		"DELETE FROM identifiers WHERE type = 'gstin'",
+		"DELETE FROM identifier_conflicts WHERE type = 'gstin'",
+		"DELETE FROM identifier_history WHERE type = 'gstin'",
+	} {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("%s: %w", stmt, err)
+		}
+	}
+	for _, t := range triggers {
+		if _, err := tx.ExecContext(ctx, t); err != nil {
+			return fmt.Errorf("restoring trigger: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	// Replaced values stay in free pages until the file is rebuilt
+	_, err = db.ExecContext(ctx, "VACUUM")
+	return err
+}
+
+// apply rewrites a column, returning how many rows changed
+func apply(ctx context.Context, tx *sql.Tx, u update) (int, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT id, "+u.column+" FROM "+u.table+" "+u.where)
+	if err != nil {
+		return 0, err
+	}
+	type row struct {
+		id    int64
+		value string
+	}
+	var changed []row
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.id, &r.value); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if v := u.fn(r.value); v != r.value {
+			changed = append(changed, row{r.id, v})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, r := range changed {
+		if _, err := tx.ExecContext(ctx, "UPDATE "+u.table+" SET "+u.column+" = ? WHERE id = ?", r.value, r.id); err != nil {
+			return 0, err
+		}
+	}
+	return len(changed), nil
+}
+
+// dropTriggers drops the triggers on transactions and sale bills, returning
+// the statements that create them again
+func dropTriggers(ctx context.Context, tx *sql.Tx) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT name, sql FROM sqlite_master WHERE type = 'trigger' AND tbl_name IN ('transactions', 'sale_bills')")
+	if err != nil {
+		return nil, err
+	}
+	var names, triggers []string
+	for rows.Next() {
+		var name, stmt string
+		if err := rows.Scan(&name, &stmt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		names = append(names, name)
+		triggers = append(triggers, stmt)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		if _, err := tx.ExecContext(ctx, "DROP TRIGGER "+name); err != nil {
+			return nil, err
+		}
+	}
+	return triggers, nil
+}
+
+// eachString calls fn with each non-empty value of a one-column query
+func eachString(ctx context.Context, db *sql.DB, query string, fn func(string)) error {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("%s: %w", query, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return err
+		}
+		if s = strings.TrimSpace(s); s != "" {
+			fn(s)
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,72 @@
+// Command check looks for inconsistent rows in the database, such as
+// identifiers and transactions of parties that no longer exist and near
+// duplicates the unique indexes let through, and prints what it found with
+// the SQL or steps that fix each kind. It opens the database read-only.
+//
+// Usage:
+//
+//	check -db suspense.db       # report problems, exit 1 if any
+//	check -db suspense.db -v    # also list checks that passed
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	_ "modernc.org/sqlite"
+
+	"suspense.durgadawaghar.com/internal/integrity"
+)
+
+func main() {
+	dbPath := flag.String("db", "suspense.db", "SQLite database path")
+	verbose := flag.Bool("v", false, "Print a line for every check, not only failed ones")
+	flag.Parse()
+
+	if _, err := os.Stat(*dbPath); err != nil {
+		log.Fatalf("Opening database: %v", err)
+	}
+	db, err := sql.Open("sqlite", "file:"+*dbPath+"?mode=ro")
+	if err != nil {
+		log.Fatalf("Opening database: %v", err)
+	}
+	defer db.Close()
+
+	results, err := integrity.Run(context.Background(), db)
+	if err != nil {
+		log.Fatalf("Checking: %v", err)
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.Count == 0 {
+			if *verbose {
+				fmt.Printf("OK       %s\n", r.Name)
+			}
+			continue
+		}
+		failed++
+		fmt.Printf("PROBLEM  %s: %d\n", r.Name, r.Count)
+		for _, row := range r.Rows {
+			fmt.Printf("         %s\n", row)
+		}
+		if r.Count > len(r.Rows) {
+			fmt.Printf("         ... and %d more\n", r.Count-len(r.Rows))
+		}
+		if r.Fix != "" {
+			fmt.Printf("  fix:   %s\n", r.Fix)
+		} else {
+			fmt.Printf("  fix:   %s\n", r.Hint)
+		}
+	}
+
+	if failed > 0 {
+		fmt.Printf("%d of %d checks found %d problems; back up the database before fixing\n", failed, len(results), integrity.Problems(results))
+		os.Exit(1)
+	}
+	fmt.Printf("All %d checks passed\n", len(results))
+}
@@ -0,0 +1,191 @@
+// Command parsecheck replays the receipt book parser over a directory of saved
+// receipt book files and compares the results with stored expectations, so that
+// parser changes which alter how old months parse are caught.
+//
+// Usage:
+//
+//	parsecheck -dir testdata/receipts          # report drift
+//	parsecheck -dir testdata/receipts -update  # accept current results
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"suspense.durgadawaghar.com/internal/parser"
+)
+
+// expectationsFile holds the expected summary of every corpus file
+const expectationsFile = "expectations.json"
+
+// summary is what is compared between runs for one receipt book file
+type summary struct {
+	Transactions    int            `json:"transactions"`
+	Total           float64        `json:"total"`
+	Parties         int            `json:"parties"`
+	WithoutLocation int            `json:"without_location"`
+	Modes           map[string]int `json:"modes"`
+}
+
+func main() {
+	dir := flag.String("dir", "testdata/receipts", "Directory of saved receipt book .txt files")
+	update := flag.Bool("update", false, "Write current results as the new expectations")
+	defaultYear := flag.Int("year", time.Now().Year(), "Year for files without a date range header")
+	verbose := flag.Bool("v", false, "Print a line for every file, not only drifted ones")
+	flag.Parse()
+
+	files, err := filepath.Glob(filepath.Join(*dir, "*.txt"))
+	if err != nil {
+		log.Fatalf("Listing corpus: %v", err)
+	}
+	if len(files) == 0 {
+		log.Fatalf("No .txt files found in %s", *dir)
+	}
+	sort.Strings(files)
+
+	actual := make(map[string]summary, len(files))
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatalf("Reading %s: %v", path, err)
+		}
+		actual[filepath.Base(path)] = summarize(string(data), *defaultYear)
+	}
+
+	expectationsPath := filepath.Join(*dir, expectationsFile)
+	if *update {
+		if err := writeExpectations(expectationsPath, actual); err != nil {
+			log.Fatalf("Writing expectations: %v", err)
+		}
+		fmt.Printf("Wrote expectations for %d files to %s\n", len(actual), expectationsPath)
+		return
+	}
+
+	expected, err := readExpectations(expectationsPath)
+	if err != nil {
+		log.Fatalf("Reading expectations (run with -update to create them): %v", err)
+	}
+
+	problems := 0
+	for _, path := range files {
+		name := filepath.Base(path)
+		got := actual[name]
+		want, ok := expected[name]
+		if !ok {
+			fmt.Printf("NEW      %s: %d transactions, total %.2f (no expectation, run with -update)\n", name, got.Transactions, got.Total)
+			problems++
+			continue
+		}
+		if diffs := compare(want, got); len(diffs) > 0 {
+			fmt.Printf("DRIFT    %s: %s\n", name, strings.Join(diffs, "; "))
+			problems++
+		} else if *verbose {
+			fmt.Printf("OK       %s: %d transactions, total %.2f\n", name, got.Transactions, got.Total)
+		}
+	}
+
+	var missing []string
+	for name := range expected {
+		if _, ok := actual[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	for _, name := range missing {
+		fmt.Printf("MISSING  %s: expected file not found\n", name)
+		problems++
+	}
+
+	if problems > 0 {
+		fmt.Printf("%d of %d files changed\n", problems, len(files)+len(missing))
+		os.Exit(1)
+	}
+	fmt.Printf("All %d files match expectations\n", len(files))
+}
+
+// summarize parses one receipt book and summarizes the result
+func summarize(data string, defaultYear int) summary {
+	year := parser.ExtractYearFromHeader(data)
+	if year == 0 {
+		year = defaultYear
+	}
+
+	s := summary{Modes: make(map[string]int)}
+	parties := make(map[string]bool)
+	for _, tx := range parser.Parse(data, year) {
+		s.Transactions++
+		s.Total += tx.Amount
+		parties[tx.PartyName] = true
+		if tx.Location == "" {
+			s.WithoutLocation++
+		}
+		s.Modes[tx.PaymentMode]++
+	}
+	s.Total = math.Round(s.Total*100) / 100
+	s.Parties = len(parties)
+	return s
+}
+
+// compare describes the differences between an expected and actual summary
+func compare(want, got summary) []string {
+	var diffs []string
+	if want.Transactions != got.Transactions {
+		diffs = append(diffs, fmt.Sprintf("transactions %d -> %d", want.Transactions, got.Transactions))
+	}
+	if math.Abs(want.Total-got.Total) > 0.005 {
+		diffs = append(diffs, fmt.Sprintf("total %.2f -> %.2f", want.Total, got.Total))
+	}
+	if want.Parties != got.Parties {
+		diffs = append(diffs, fmt.Sprintf("parties %d -> %d", want.Parties, got.Parties))
+	}
+	if want.WithoutLocation != got.WithoutLocation {
+		diffs = append(diffs, fmt.Sprintf("without location %d -> %d", want.WithoutLocation, got.WithoutLocation))
+	}
+
+	modes := make(map[string]bool)
+	for mode := range want.Modes {
+		modes[mode] = true
+	}
+	for mode := range got.Modes {
+		modes[mode] = true
+	}
+	sorted := make([]string, 0, len(modes))
+	for mode := range modes {
+		sorted = append(sorted, mode)
+	}
+	sort.Strings(sorted)
+	for _, mode := range sorted {
+		if want.Modes[mode] != got.Modes[mode] {
+			diffs = append(diffs, fmt.Sprintf("%s %d -> %d", mode, want.Modes[mode], got.Modes[mode]))
+		}
+	}
+	return diffs
+}
+
+func readExpectations(path string) (map[string]summary, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var expected map[string]summary
+	if err := json.Unmarshal(data, &expected); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return expected, nil
+}
+
+func writeExpectations(path string, actual map[string]summary) error {
+	data, err := json.MarshalIndent(actual, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
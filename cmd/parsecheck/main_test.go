@@ -0,0 +1,58 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// book is a month of three receipts, the last of which is lastReceipt
+const book = `Dec 26 BABA MEDICAL AND GENERAL STOR SHAMBHUA 11744.00
+ICICI 192105002017 11744.00
+Chq.704339 Dt. 26-12-2025 Ag. DDG024782
+
+Dec 26 SANDHYA MEDICAL STORE LUCKNOW 5000.00
+UPI/9450852076@YBL 5000.00` + lastReceipt
+
+const lastReceipt = `
+
+Dec 27 SANDHYA MEDICAL STORE LUCKNOW 250.50
+UPI/9450852076@YBL 250.50`
+
+func TestSummarize(t *testing.T) {
+	want := summary{Transactions: 3, Total: 16994.5, Parties: 2, Modes: map[string]int{"CHEQUE": 1, "UPI": 2}}
+	if got := summarize(book, 2025); !reflect.DeepEqual(got, want) {
+		t.Errorf("summary = %+v, want %+v", got, want)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	want := summarize(book, 2025)
+	if diffs := compare(want, summarize(book, 2025)); len(diffs) != 0 {
+		t.Errorf("the same book drifted: %v", diffs)
+	}
+
+	// The parser losing the last receipt changes the count, total and modes
+	// but not the parties
+	got := summarize(strings.TrimSuffix(book, lastReceipt), 2025)
+	wantDiffs := []string{"transactions 3 -> 2", "total 16994.50 -> 16744.00", "UPI 2 -> 1"}
+	if diffs := compare(want, got); !reflect.DeepEqual(diffs, wantDiffs) {
+		t.Errorf("drift = %q, want %q", diffs, wantDiffs)
+	}
+}
+
+func TestExpectationsRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), expectationsFile)
+	want := map[string]summary{"2025-12.txt": summarize(book, 2025)}
+	if err := writeExpectations(path, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readExpectations(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("read %+v, want %+v", got, want)
+	}
+}
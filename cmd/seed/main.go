@@ -0,0 +1,416 @@
+// Command seed generates a synthetic but realistic dataset for demos and UI
+// work: monthly receipt books with the narration styles banks use for UPI,
+// IMPS, NEFT, cash and cheque receipts, monthly sale bill registers with
+// credit, cash and card sales, and a list of suspense narrations to search
+// for. Every name, phone and account number is made up. The files are in
+// the formats the import pages read, and can be imported into a running
+// server directly.
+//
+// Usage:
+//
+//	seed -out demo                                 # write the files
+//	seed -out demo -server http://localhost:8005   # and import them
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Shop accounts that receipts are credited to
+var shopAccounts = []string{"ICICI 000105001234", "PNB 0999002100100001"}
+
+var (
+	nameStarts = []string{
+		"SHREE RAM", "MAA DURGA", "JAI HIND", "SAHU", "GUPTA", "RADHA", "KRISHNA", "NEW LIFE",
+		"SANJEEVANI", "AROGYA", "AGARWAL", "SHARMA", "BALAJI", "GANGA", "OM SAI", "PRAGATI",
+		"JANTA", "VERMA", "MAHADEV", "LAXMI", "SIDDHI", "VINAYAK", "SHIV SHAKTI", "ANAND",
+	}
+	nameEnds = []string{
+		"MEDICAL STORE", "MED STORE", "MEDICOS", "PHARMA", "DRUG HOUSE", "MEDICAL AGENCIES",
+		"MEDICAL HALL", "CHEMIST", "MEDICAL AND GENERAL STORE",
+	}
+	locations = []string{
+		"LUCKNOW", "KANPUR", "UNNAO", "HARDOI", "ETAWAH", "ORAI", "AURAIYA", "PUKHRAYA", "TIRWA",
+		"BAKEWAR", "CHIBRAMAU", "FATEHPUR", "SITAPUR", "JHANSI", "BANDA", "AKBARPUR", "GHATAMPUR",
+	}
+	firstNames = []string{
+		"RAMESH", "SURESH", "ANURAG", "PRASHANT", "KULDEEP", "AYUSH", "MANISHA", "DEEPAK",
+		"SANJAY", "VIKAS", "ALOK", "NEHA", "ROHIT", "AMIT", "POOJA", "GYANENDRA",
+	}
+	surnames = []string{"YADAV", "SHARMA", "GUPTA", "SAHU", "VERMA", "SINGH", "MISHRA", "TIWARI", "PANDEY", "KATIYAR"}
+	banks    = []struct{ Name, IFSC string }{
+		{"STATE BANK OF I", "SBIN"}, {"PUNJAB NATIONAL", "PUNB"}, {"BANK OF BARODA", "BARB"},
+		{"CANARA BANK", "CNRB"}, {"HDFC BANK LTD", "HDFC"}, {"AXIS BANK", "UTIB"}, {"UNION BANKOF I", "UBIN"},
+	}
+	upiHandles = []string{"@YBL", "@OKSBI", "@IBL", "@AXL", "@PAYTM", "@OKICICI"}
+	walkIns    = []string{"RAJU", "SEEMA", "MOHAN", "GEETA", "SUNIL", "REKHA", "KAMAL", "ASHA"}
+)
+
+// Ways a party pays, each with its own narration style
+const (
+	styleUPI    = "upi"
+	styleIMPS   = "imps"
+	styleNEFT   = "neft"
+	styleCash   = "cash"
+	styleCheque = "cheque"
+)
+
+var styles = []string{styleUPI, styleUPI, styleIMPS, styleNEFT, styleCash}
+
+// Cheque narrations carry no identifier, so each cheque-only payer would
+// look like a new party; cheques are only ever a party's second way to pay
+var otherStyles = append([]string{styleCheque}, styles...)
+
+// party is a made-up customer with the identifiers its payments carry
+type party struct {
+	Name     string
+	Location string
+	Owner    string
+	Phone    string
+	VPA      string
+	// ShortUPI is set for parties whose UPI narrations name the payer
+	// without the handle
+	ShortUPI bool
+	Account  string
+	Bank     int
+	CashCode string
+	Agent    string
+	Styles   []string
+	// Average is the usual bill amount, and PayRate the share of what is
+	// due the party pays each month
+	Average float64
+	PayRate float64
+}
+
+// receipt is one receipt book entry
+type receipt struct {
+	Date      time.Time
+	Party     string
+	Amount    float64
+	Narration string
+}
+
+// bill is one sale bill register line
+type bill struct {
+	Number string
+	Date   time.Time
+	Party  string
+	Amount float64
+}
+
+type generator struct {
+	rng *rand.Rand
+}
+
+func main() {
+	out := flag.String("out", "demo", "Directory to write the generated files to")
+	numParties := flag.Int("parties", 40, "Number of credit customers")
+	months := flag.Int("months", 6, "Number of past months to generate")
+	seed := flag.Uint64("seed", 1, "Random seed; the same seed gives the same data")
+	server := flag.String("server", "", "Import the files into the server at this URL, e.g. http://localhost:8005")
+	flag.Parse()
+
+	g := generator{rng: rand.New(rand.NewPCG(*seed, *seed))}
+	parties := g.parties(*numParties)
+
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		log.Fatalf("Creating %s: %v", *out, err)
+	}
+
+	end := time.Date(time.Now().Year(), time.Now().Month(), 1, 0, 0, 0, 0, time.UTC)
+	due := make([]float64, len(parties))
+	var receiptFiles, billFiles []string
+	for m := *months; m > 0; m-- {
+		month := end.AddDate(0, -m, 0)
+		bills, receipts := g.month(month, parties, due)
+
+		name := filepath.Join(*out, "receipts-"+month.Format("2006-01")+".txt")
+		if err := os.WriteFile(name, []byte(receiptBook(month, receipts)), 0o644); err != nil {
+			log.Fatalf("Writing %s: %v", name, err)
+		}
+		receiptFiles = append(receiptFiles, name)
+
+		name = filepath.Join(*out, "sale-bills-"+month.Format("2006-01")+".txt")
+		if err := os.WriteFile(name, []byte(saleRegister(month, bills)), 0o644); err != nil {
+			log.Fatalf("Writing %s: %v", name, err)
+		}
+		billFiles = append(billFiles, name)
+		fmt.Printf("%s: %d receipts, %d sale bills\n", month.Format("Jan 2006"), len(receipts), len(bills))
+	}
+
+	name := filepath.Join(*out, "suspense.txt")
+	if err := os.WriteFile(name, []byte(strings.Join(g.suspense(end, parties), "\n")+"\n"), 0o644); err != nil {
+		log.Fatalf("Writing %s: %v", name, err)
+	}
+	fmt.Printf("Wrote %d parties' data to %s; suspense narrations to search for are in %s\n", len(parties), *out, name)
+
+	if *server == "" {
+		return
+	}
+	// Receipts first, so the parties exist when their bills are linked
+	for _, f := range receiptFiles {
+		if err := post(*server+"/import/confirm", f); err != nil {
+			log.Fatalf("Importing %s: %v", f, err)
+		}
+	}
+	for _, f := range billFiles {
+		if err := post(*server+"/sale-bills/import/confirm", f); err != nil {
+			log.Fatalf("Importing %s: %v", f, err)
+		}
+	}
+	fmt.Printf("Imported %d receipt books and %d sale bill registers into %s\n", len(receiptFiles), len(billFiles), *server)
+}
+
+// parties makes up n customers with distinct names
+func (g generator) parties(n int) []party {
+	seen := make(map[string]bool)
+	var parties []party
+	for len(parties) < n && len(seen) < len(nameStarts)*len(nameEnds) {
+		name := pick(g.rng, nameStarts) + " " + pick(g.rng, nameEnds)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		first, last := pick(g.rng, firstNames), pick(g.rng, surnames)
+		p := party{
+			Name:     name,
+			Location: pick(g.rng, locations),
+			Owner:    first + " " + last,
+			Phone:    fmt.Sprintf("%d%09d", 6+g.rng.IntN(4), g.rng.IntN(1e9)),
+			Account:  fmt.Sprintf("%d%011d", 1+g.rng.IntN(9), g.rng.Int64N(1e11)),
+			Bank:     g.rng.IntN(len(banks)),
+			CashCode: fmt.Sprintf("%06d", g.rng.IntN(1e6)),
+			Agent:    fmt.Sprintf("DDG%06d", g.rng.IntN(1e5)),
+			Average:  float64(500 + g.rng.IntN(40)*500),
+			PayRate:  0.5 + g.rng.Float64()/2,
+		}
+		if g.rng.IntN(2) == 0 {
+			p.VPA = p.Phone + pick(g.rng, upiHandles)
+		} else {
+			p.VPA = fmt.Sprintf("%s%s%d%s", first, last[:1], g.rng.IntN(100), pick(g.rng, upiHandles))
+		}
+		p.ShortUPI = g.rng.IntN(2) == 0
+		p.Styles = []string{pick(g.rng, styles)}
+		if g.rng.IntN(3) == 0 {
+			p.Styles = append(p.Styles, pick(g.rng, otherStyles))
+		}
+		parties = append(parties, p)
+	}
+	return parties
+}
+
+// month generates a month's sale bills and receipts. due carries what each
+// party owes from month to month.
+func (g generator) month(month time.Time, parties []party, due []float64) ([]bill, []receipt) {
+	days := month.AddDate(0, 1, -1).Day()
+	day := func() time.Time { return month.AddDate(0, 0, g.rng.IntN(days)) }
+	var bills []bill
+	var receipts []receipt
+
+	for i, p := range parties {
+		for n := g.rng.IntN(4); n >= 0; n-- {
+			amount := paise(p.Average * (0.5 + g.rng.Float64()))
+			bills = append(bills, bill{Date: day(), Party: p.Name, Amount: amount})
+			due[i] += amount
+		}
+		pay := math.Round(due[i] * p.PayRate)
+		if pay < 100 {
+			continue
+		}
+		// Larger amounts are often paid in two parts
+		parts := []float64{pay}
+		if pay > 20000 && g.rng.IntN(2) == 0 {
+			first := math.Round(pay * (0.3 + g.rng.Float64()*0.4))
+			parts = []float64{first, pay - first}
+		}
+		for _, amount := range parts {
+			date := day()
+			receipts = append(receipts, receipt{
+				Date:      date,
+				Party:     p.Name + " " + p.Location,
+				Amount:    amount,
+				Narration: g.narration(p, pick(g.rng, p.Styles), date),
+			})
+		}
+		due[i] -= pay
+	}
+
+	// Counter sales each day: cash deposited weekly, card collections
+	// settled by the card machine the next day less its 1% MDR
+	var cash float64
+	for d := 0; d < days; d++ {
+		date := month.AddDate(0, 0, d)
+		var card float64
+		for n := 3 + g.rng.IntN(4); n > 0; n-- {
+			amount := paise(float64(50 + g.rng.IntN(1500)))
+			bills = append(bills, bill{Date: date, Party: "CASH (" + pick(g.rng, walkIns) + ")", Amount: amount})
+			cash += amount
+		}
+		for n := g.rng.IntN(3); n > 0; n-- {
+			amount := paise(float64(200 + g.rng.IntN(3000)))
+			bills = append(bills, bill{Date: date, Party: "CARD (" + pick(g.rng, walkIns) + ")", Amount: amount})
+			card += amount
+		}
+		if card > 0 && d+1 < days {
+			settled := date.AddDate(0, 0, 1)
+			receipts = append(receipts, receipt{
+				Date:      settled,
+				Party:     "ICICI POS MACHINE",
+				Amount:    paise(card * 0.99),
+				Narration: fmt.Sprintf("FT-MESPOS SET 10XX%06d %s", g.rng.IntN(1e6), settled.Format("020106")),
+			})
+		}
+		if date.Weekday() == time.Saturday && cash > 0 {
+			receipts = append(receipts, receipt{
+				Date:      date,
+				Party:     "CASH",
+				Amount:    math.Floor(cash/100) * 100,
+				Narration: "BY CASH - KANPUR - BIRHANA ROAD",
+			})
+			cash -= math.Floor(cash/100) * 100
+		}
+	}
+
+	sort.SliceStable(bills, func(i, j int) bool { return bills[i].Date.Before(bills[j].Date) })
+	for i := range bills {
+		bills[i].Number = fmt.Sprintf("A%s%05d", month.Format("0601"), i+1)
+	}
+	sort.SliceStable(receipts, func(i, j int) bool { return receipts[i].Date.Before(receipts[j].Date) })
+	return bills, receipts
+}
+
+// narration writes the bank narration of a party's payment in one style
+func (g generator) narration(p party, style string, date time.Time) string {
+	bank := banks[p.Bank]
+	ref := fmt.Sprintf("%d%011d", 1+g.rng.IntN(9), g.rng.Int64N(1e11))
+	switch style {
+	case styleUPI:
+		if !p.ShortUPI {
+			return fmt.Sprintf("UPI/%s/PAYMENT FROM PH/%s/%s/%s", ref, p.VPA, bank.Name, bank.IFSC[:3]+ref[:8])
+		}
+		return fmt.Sprintf("UPI/%s/UPI/%s/%s/%s", ref, strings.ToUpper(p.VPA[:min(16, strings.Index(p.VPA, "@"))]), bank.Name, bank.IFSC[:3]+ref[:8])
+	case styleIMPS:
+		owner := strings.ReplaceAll(p.Owner, " ", "")
+		return fmt.Sprintf("MMT/IMPS/%s/OK/%s/%s", ref, owner[:min(10, len(owner))], bank.Name)
+	case styleNEFT:
+		name := p.Name
+		if len(name) > 22 {
+			name = name[:22]
+		}
+		return fmt.Sprintf("NEFT-%sN5%s%07d-%s--%s-%s0%06d", bank.IFSC, date.Format("20060102"), g.rng.IntN(1e7), name, p.Account, bank.IFSC, g.rng.IntN(1e6))
+	case styleCash:
+		return fmt.Sprintf("BY CASH -%s %s Ag. %s", p.CashCode, p.Location, p.Agent)
+	default:
+		return fmt.Sprintf("Chq.%06d Dt. %s Ag. %s", g.rng.IntN(1e6), date.Format("02-01-2006"), p.Agent)
+	}
+}
+
+// suspense makes up credits received since the last month generated whose
+// party is not yet known: mostly known parties paying in their usual way, and
+// a few strangers
+func (g generator) suspense(date time.Time, parties []party) []string {
+	var lines []string
+	for i := 0; i < 15; i++ {
+		p := parties[g.rng.IntN(len(parties))]
+		lines = append(lines, g.narration(p, pick(g.rng, p.Styles), date))
+	}
+	for i := 0; i < 3; i++ {
+		stranger := party{
+			Name:     "UNKNOWN TRADERS",
+			Owner:    pick(g.rng, firstNames) + " " + pick(g.rng, surnames),
+			VPA:      fmt.Sprintf("%d%09d%s", 6+g.rng.IntN(4), g.rng.IntN(1e9), pick(g.rng, upiHandles)),
+			Account:  fmt.Sprintf("%012d", g.rng.Int64N(1e12)),
+			Bank:     g.rng.IntN(len(banks)),
+			CashCode: fmt.Sprintf("%06d", g.rng.IntN(1e6)),
+			Location: pick(g.rng, locations),
+			Agent:    fmt.Sprintf("DDG%06d", g.rng.IntN(1e5)),
+		}
+		lines = append(lines, g.narration(stranger, pick(g.rng, otherStyles), date))
+	}
+	return lines
+}
+
+// receiptBook formats a month of receipts as a receipt book printout
+func receiptBook(month time.Time, receipts []receipt) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "RECEIPT BOOK\n%s - %s Page No..1\n", month.Format("02-01-2006"), month.AddDate(0, 1, -1).Format("02-01-2006"))
+	b.WriteString(strings.Repeat("-", 78) + "\nDATE PARTICULARS DEBIT CREDIT\n" + strings.Repeat("-", 78) + "\n")
+	var total float64
+	for i, r := range receipts {
+		account := shopAccounts[i%len(shopAccounts)]
+		fmt.Fprintf(&b, "%s %s %.2f\n%s %.2f\n%s\n", r.Date.Format("Jan 2"), r.Party, r.Amount, account, r.Amount, r.Narration)
+		fmt.Fprintf(&b, "%s\n%.2f %.2f\n%s\n", strings.Repeat("-", 34), r.Amount, r.Amount, strings.Repeat("=", 34))
+		total += r.Amount
+	}
+	fmt.Fprintf(&b, "%s\nSUB TOTAL %.2f %.2f\n%s\n", strings.Repeat("-", 78), total, total, strings.Repeat("-", 78))
+	return b.String()
+}
+
+// saleRegister formats a month of sale bills as the sale register printout
+func saleRegister(month time.Time, bills []bill) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "SALE FROM %s TO %s\n", month.Format("02-01-2006"), month.AddDate(0, 1, -1).Format("02-01-2006"))
+	b.WriteString("BILL NO DATE PARTY NAME AMOUNT\n")
+	for _, bl := range bills {
+		fmt.Fprintf(&b, "%s %s %s %s\n", bl.Number, bl.Date.Format("02-01"), bl.Party, commas(bl.Amount))
+	}
+	return b.String()
+}
+
+// commas formats an amount with thousands separators, as the register does
+func commas(amount float64) string {
+	s := fmt.Sprintf("%.2f", amount)
+	whole, frac := s[:len(s)-3], s[len(s)-3:]
+	for i := len(whole) - 3; i > 0; i -= 3 {
+		whole = whole[:i] + "," + whole[i:]
+	}
+	return whole + frac
+}
+
+// post imports a file through a server's import confirm endpoint
+func post(endpoint, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	year := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	year = year[len(year)-7 : len(year)-3]
+	resp, err := http.PostForm(endpoint, url.Values{"data": {string(data)}, "year": {year}})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server returned %s", resp.Status)
+	}
+	if i := bytes.Index(body, []byte(`class="error"`)); i >= 0 {
+		return fmt.Errorf("server reported an error: %s", body[i:min(len(body), i+200)])
+	}
+	return nil
+}
+
+func paise(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+func pick(rng *rand.Rand, list []string) string {
+	return list[rng.IntN(len(list))]
+}
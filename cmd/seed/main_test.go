@@ -0,0 +1,79 @@
+package main
+
+import (
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"suspense.durgadawaghar.com/internal/parser"
+)
+
+func TestMonthParses(t *testing.T) {
+	g := generator{rng: rand.New(rand.NewPCG(1, 1))}
+	parties := g.parties(20)
+	month := time.Date(2025, time.April, 1, 0, 0, 0, 0, time.UTC)
+	bills, receipts := g.month(month, parties, make([]float64, len(parties)))
+
+	// The receipt book reads back as the receipts generated
+	txs := parser.Parse(receiptBook(month, receipts), 2025)
+	if len(txs) != len(receipts) {
+		t.Fatalf("receipt book of %d receipts parses to %d", len(receipts), len(txs))
+	}
+	for i, tx := range txs {
+		r := receipts[i]
+		if !tx.Date.Equal(r.Date) || math.Abs(tx.Amount-r.Amount) > 0.005 {
+			t.Errorf("receipt %d: parsed %s %.2f, generated %s %.2f", i, tx.Date.Format("2006-01-02"), tx.Amount, r.Date.Format("2006-01-02"), r.Amount)
+		}
+	}
+
+	// The sale register reads back as the bills, counter sales as cash or card
+	parsed := parser.ParseSaleBills(saleRegister(month, bills), 2024)
+	if len(parsed) != len(bills) {
+		t.Fatalf("sale register of %d bills parses to %d", len(bills), len(parsed))
+	}
+	for i, b := range parsed {
+		want := bills[i]
+		if b.BillNumber != want.Number || b.Date.Year() != 2025 || math.Abs(b.Amount-want.Amount) > 0.005 ||
+			b.IsCashSale != strings.HasPrefix(want.Party, "CASH (") || b.IsCardSale != strings.HasPrefix(want.Party, "CARD (") {
+			t.Errorf("bill %d: parsed %+v, generated %+v", i, b, want)
+		}
+	}
+}
+
+func TestSameSeedSameData(t *testing.T) {
+	generate := func(seed uint64) ([]party, []string) {
+		g := generator{rng: rand.New(rand.NewPCG(seed, seed))}
+		parties := g.parties(10)
+		return parties, g.suspense(time.Date(2025, time.May, 1, 0, 0, 0, 0, time.UTC), parties)
+	}
+	parties, suspense := generate(7)
+	again, suspenseAgain := generate(7)
+	if !reflect.DeepEqual(parties, again) || !reflect.DeepEqual(suspense, suspenseAgain) {
+		t.Error("the same seed generated different data")
+	}
+	if other, _ := generate(8); reflect.DeepEqual(parties, other) {
+		t.Error("another seed generated the same parties")
+	}
+
+	names := make(map[string]bool)
+	for _, p := range parties {
+		if names[p.Name] {
+			t.Errorf("party %s generated twice", p.Name)
+		}
+		names[p.Name] = true
+	}
+	if len(suspense) != 18 {
+		t.Errorf("got %d suspense narrations, want 18", len(suspense))
+	}
+}
+
+func TestCommas(t *testing.T) {
+	for amount, want := range map[float64]string{0.5: "0.50", 999: "999.00", 1234.5: "1,234.50", 1234567.89: "1,234,567.89"} {
+		if got := commas(amount); got != want {
+			t.Errorf("commas(%v) = %q, want %q", amount, got, want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// summaries lists the rows of party_balances and receipt_month_totals that
+// are not all zero, rounded to the paisa
+func summaries(t *testing.T, db *sql.DB) string {
+	t.Helper()
+	var b strings.Builder
+	for _, query := range []string{
+		`SELECT party_id, 'party', receipt_count, ROUND(billed, 2), ROUND(received, 2) FROM party_balances
+		WHERE receipt_count != 0 OR ROUND(billed, 2) != 0 OR ROUND(received, 2) != 0 ORDER BY party_id`,
+		`SELECT firm_id, month || ' ' || payment_mode, receipt_count, ROUND(amount, 2), '' FROM receipt_month_totals
+		WHERE receipt_count != 0 OR ROUND(amount, 2) != 0 ORDER BY 1, 2`,
+	} {
+		rows, err := db.Query(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for rows.Next() {
+			var a, c, d, e any
+			var k string
+			if err := rows.Scan(&a, &k, &c, &d, &e); err != nil {
+				rows.Close()
+				t.Fatal(err)
+			}
+			b.WriteString(strings.TrimSpace(fmt.Sprintf("%v %s %v %v %v", a, k, c, d, e)) + "\n")
+		}
+		rows.Close()
+	}
+	return b.String()
+}
+
+func TestSummaryTriggersMatchRebuild(t *testing.T) {
+	db, err := initDB(filepath.Join(t.TempDir(), "suspense.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for _, stmt := range []string{
+		`INSERT INTO firms (id, name) VALUES (2, 'Durga Pharma')`,
+		`INSERT INTO parties (id, name, firm_id) VALUES (1, 'SHARMA MEDICAL', 1), (2, 'GUPTA STORES', 1), (3, 'VERMA AGENCIES', 2)`,
+		`INSERT INTO transactions (id, party_id, amount, transaction_date, payment_mode, narration, firm_id, category) VALUES
+			(1, 1, 1000.10, '2025-04-02 00:00:00 +0000 UTC', 'UPI', 'UPI/1', 1, 'receipt'),
+			(2, 1, 500, '2025-04-20 00:00:00 +0000 UTC', 'CHEQUE', 'CHQ 2', 1, 'receipt'),
+			(3, 2, 750.25, '2025-05-03 00:00:00 +0000 UTC', 'NEFT', 'NEFT/3', 1, 'receipt'),
+			(4, 2, 30, '2025-05-03 00:00:00 +0000 UTC', 'NEFT', 'CHARGES', 1, 'bank_charge'),
+			(5, 3, 900, '2025-05-10 00:00:00 +0000 UTC', NULL, 'CASH', 2, 'receipt'),
+			(6, 2, 1200, '2025-05-11 00:00:00 +0000 UTC', 'CHEQUE', 'CHQ 6', 1, 'receipt')`,
+		// A-2 has no cash sale flag, as older imports stored it, and is a
+		// credit bill
+		`INSERT INTO sale_bills (bill_number, bill_date, party_name, amount, is_cash_sale, is_card_sale, party_id, firm_id) VALUES
+			('A-1', '2025-04-01 00:00:00 +0000 UTC', 'SHARMA MEDICAL', 2000, FALSE, FALSE, 1, 1),
+			('A-2', '2025-04-02 00:00:00 +0000 UTC', 'SHARMA MEDICAL', 300, NULL, FALSE, 1, 1),
+			('A-3', '2025-04-03 00:00:00 +0000 UTC', 'CASH', 400, TRUE, FALSE, NULL, 1),
+			('A-4', '2025-04-04 00:00:00 +0000 UTC', 'GUPTA STORES', 650, FALSE, TRUE, 2, 1),
+			('A-5', '2025-04-05 00:00:00 +0000 UTC', 'GUPTA STORES', 800, FALSE, FALSE, 2, 1),
+			('B-1', '2025-05-01 00:00:00 +0000 UTC', 'VERMA AGENCIES', 1500, FALSE, FALSE, 3, 2)`,
+		`INSERT INTO cheques (transaction_id, cheque_number, status, received_date) VALUES
+			(2, '000123', 'received', '2025-04-20 00:00:00 +0000 UTC'),
+			(6, '000124', 'bounced', '2025-05-11 00:00:00 +0000 UTC')`,
+		`UPDATE cheques SET status = 'bounced' WHERE transaction_id = 2`,
+		`UPDATE cheques SET status = 'cleared' WHERE transaction_id = 6`,
+		`INSERT INTO cheques (transaction_id, status, received_date) VALUES (3, 'bounced', '2025-05-03 00:00:00 +0000 UTC')`,
+		`DELETE FROM cheques WHERE transaction_id = 3`,
+		`UPDATE transactions SET amount = 1100.10 WHERE id = 1`,
+		`UPDATE transactions SET party_id = 1, payment_mode = 'RTGS', transaction_date = '2025-06-01 00:00:00 +0000 UTC' WHERE id = 3`,
+		`UPDATE transactions SET category = 'receipt' WHERE id = 4`,
+		`UPDATE transactions SET category = 'other' WHERE id = 5`,
+		`DELETE FROM transactions WHERE id = 2`,
+		`UPDATE sale_bills SET party_id = 2 WHERE bill_number = 'A-1'`,
+		`UPDATE sale_bills SET amount = 350 WHERE bill_number = 'A-2'`,
+		`UPDATE sale_bills SET is_card_sale = FALSE WHERE bill_number = 'A-4'`,
+		`UPDATE sale_bills SET is_cash_sale = TRUE WHERE bill_number = 'A-5'`,
+		`DELETE FROM sale_bills WHERE bill_number = 'B-1'`,
+		`DELETE FROM parties WHERE id = 3`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+
+	kept := summaries(t, db)
+	want := `1 party 2 350 1850.35
+2 party 2 2650 1230
+1 2025-04 UPI 1 1100.1
+1 2025-05 CHEQUE 1 1200
+1 2025-05 NEFT 1 30
+1 2025-06 RTGS 1 750.25
+`
+	if kept != want {
+		t.Errorf("summaries kept by the triggers:\n%s\nwant\n%s", kept, want)
+	}
+	if err := rebuildSummaries(db); err != nil {
+		t.Fatal(err)
+	}
+	if rebuilt := summaries(t, db); rebuilt != kept {
+		t.Errorf("summaries kept by the triggers:\n%s\ndiffer from the rebuilt:\n%s", kept, rebuilt)
+	}
+}
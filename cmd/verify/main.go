@@ -0,0 +1,77 @@
+// Command verify compares the receipts and card settlements recorded for each
+// imported receipt book period with the book's closing SUB TOTAL, less its
+// SUSPENSE A/C entries, and flags the periods that don't balance: more
+// recorded than the book means a book imported twice or entries added by hand,
+// less means pages that were not imported. It opens the database read-only.
+//
+// Usage:
+//
+//	verify -db suspense.db       # report unbalanced periods, exit 1 if any
+//	verify -db suspense.db -v    # also list periods that balance
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	_ "modernc.org/sqlite"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+)
+
+func main() {
+	dbPath := flag.String("db", "suspense.db", "SQLite database path")
+	verbose := flag.Bool("v", false, "Print a line for every period, not only unbalanced ones")
+	flag.Parse()
+	ctx := context.Background()
+
+	if _, err := os.Stat(*dbPath); err != nil {
+		log.Fatalf("Opening database: %v", err)
+	}
+	db, err := sql.Open("sqlite", "file:"+*dbPath+"?mode=ro")
+	if err != nil {
+		log.Fatalf("Opening database: %v", err)
+	}
+	defer db.Close()
+	queries := sqlc.New(db)
+
+	firms, err := queries.ListFirms(ctx)
+	if err != nil {
+		log.Fatalf("Loading firms: %v", err)
+	}
+
+	periods, unbalanced := 0, 0
+	for _, firm := range firms {
+		checks, err := queries.ListReceiptBookChecks(ctx, firm.ID)
+		if err != nil {
+			log.Fatalf("Loading book totals of %s: %v", firm.Name, err)
+		}
+		for _, c := range checks {
+			periods++
+			period := fmt.Sprintf("%s %s to %s", firm.Name, c.StartDate.Format("02-01-2006"), c.EndDate.Format("02-01-2006"))
+			if c.Difference == 0 {
+				if *verbose {
+					fmt.Printf("OK       %s: %.2f\n", period, c.Total-c.Suspense)
+				}
+				continue
+			}
+			unbalanced++
+			fmt.Printf("MISMATCH %s: book %.2f less suspense %.2f, recorded %.2f receipts and %.2f card settlements, difference %+.2f\n",
+				period, c.Total, c.Suspense, c.Receipts, c.PosSettlements, c.Difference)
+		}
+	}
+
+	if periods == 0 {
+		fmt.Println("No receipt book with a SUB TOTAL has been imported")
+		return
+	}
+	if unbalanced > 0 {
+		fmt.Printf("%d of %d periods don't balance\n", unbalanced, periods)
+		os.Exit(1)
+	}
+	fmt.Printf("All %d periods balance\n", periods)
+}
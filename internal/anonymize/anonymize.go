@@ -0,0 +1,182 @@
+// Package anonymize replaces the names, phone numbers, UPI addresses and
+// account numbers in receipt data with pseudonyms, so data can be shared to
+// reproduce parser and matcher bugs. Under one key the same value always gets
+// the same pseudonym, so a party's entries still match each other, and a
+// pseudonym keeps the shape of what it replaces (length, letters, digits,
+// case and punctuation) so the parser and extractor treat it alike.
+package anonymize
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// minDigits is the shortest run of digits replaced in free text: phone and
+// account numbers are replaced, while bank branch codes, cheque numbers and
+// dates are kept
+const minDigits = 9
+
+var (
+	vpaPattern    = regexp.MustCompile(`([A-Za-z0-9.\-_]+)@([A-Za-z]+)`)
+	digitsPattern = regexp.MustCompile(`\d+`)
+	wordPattern   = regexp.MustCompile(`[A-Za-z][A-Za-z0-9]*`)
+)
+
+const (
+	consonants = "BCDFGHJKLMNPRSTVWYZ"
+	vowels     = "AEIOU"
+)
+
+// Pseudonymizer makes the pseudonyms of one dataset
+type Pseudonymizer struct {
+	key   []byte
+	keep  map[string]bool
+	names map[string]bool
+}
+
+// New returns a pseudonymizer whose pseudonyms are derived from key. Words in
+// keep, such as places and words like MEDICAL or STORE, are never replaced.
+func New(key []byte, keep []string) *Pseudonymizer {
+	p := &Pseudonymizer{key: key, keep: make(map[string]bool), names: make(map[string]bool)}
+	for _, w := range keep {
+		p.Keep(w)
+	}
+	return p
+}
+
+// Keep adds the words of s to those never replaced
+func (p *Pseudonymizer) Keep(s string) {
+	for _, w := range strings.Fields(strings.ToUpper(s)) {
+		p.keep[w] = true
+	}
+}
+
+// AddName registers the words of a name, so they are also replaced where they
+// appear in narrations
+func (p *Pseudonymizer) AddName(name string) {
+	for _, w := range wordPattern.FindAllString(strings.ToUpper(name), -1) {
+		if p.replaces(w) {
+			p.names[w] = true
+		}
+	}
+}
+
+// replaces reports whether a word of a name is replaced: initials and words
+// kept are not
+func (p *Pseudonymizer) replaces(word string) bool {
+	return len(word) > 2 && !p.keep[strings.ToUpper(word)]
+}
+
+// Name replaces the words of a party or account holder name
+func (p *Pseudonymizer) Name(name string) string {
+	return wordPattern.ReplaceAllStringFunc(name, func(w string) string {
+		if !p.replaces(w) {
+			return w
+		}
+		return p.Shape(w)
+	})
+}
+
+// VPA replaces the part of a UPI address before the handle. Some banks
+// narrate only the payer's name or part of the address, which is replaced as
+// in a narration, so it should be registered with AddName.
+func (p *Pseudonymizer) VPA(vpa string) string {
+	if !strings.Contains(vpa, "@") {
+		return p.narrationText(vpa)
+	}
+	return vpaPattern.ReplaceAllStringFunc(vpa, func(m string) string {
+		at := strings.LastIndex(m, "@")
+		return p.Shape(m[:at]) + m[at:]
+	})
+}
+
+// Digits replaces a phone or account number
+func (p *Pseudonymizer) Digits(s string) string {
+	return digitsPattern.ReplaceAllStringFunc(s, func(d string) string {
+		if len(d) < minDigits {
+			return d
+		}
+		return p.Shape(d)
+	})
+}
+
+// Narration replaces the UPI addresses, long numbers and registered name
+// words in free text such as a bank narration. Other words are kept, so
+// names that are in no party name or identifier are left as they are.
+func (p *Pseudonymizer) Narration(s string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range vpaPattern.FindAllStringIndex(s, -1) {
+		b.WriteString(p.narrationText(s[last:loc[0]]))
+		b.WriteString(p.VPA(s[loc[0]:loc[1]]))
+		last = loc[1]
+	}
+	b.WriteString(p.narrationText(s[last:]))
+	return b.String()
+}
+
+// narrationText replaces the numbers and name words of text without UPI
+// addresses
+func (p *Pseudonymizer) narrationText(s string) string {
+	s = p.Digits(s)
+	return wordPattern.ReplaceAllStringFunc(s, func(w string) string {
+		if !p.names[strings.ToUpper(w)] {
+			return w
+		}
+		return p.Shape(w)
+	})
+}
+
+// Shape returns the pseudonym of s: letters become letters, alternating
+// consonants and vowels so the result reads like a word, and digits become
+// digits, keeping case and other characters. The first digit of each number
+// is kept, so phone numbers still start with 6 to 9.
+func (p *Pseudonymizer) Shape(s string) string {
+	stream := p.stream(strings.ToUpper(s))
+	in := []rune(s)
+	out := make([]rune, len(in))
+	letters := 0
+	for i, r := range in {
+		n := stream(i)
+		switch {
+		case unicode.IsLetter(r):
+			set := consonants
+			if letters%2 == 1 {
+				set = vowels
+			}
+			letters++
+			out[i] = rune(set[n%uint32(len(set))])
+			if unicode.IsLower(r) {
+				out[i] = unicode.ToLower(out[i])
+			}
+		case unicode.IsDigit(r) && i > 0 && unicode.IsDigit(in[i-1]):
+			out[i] = rune('0' + n%10)
+		default:
+			out[i] = r
+			if !unicode.IsDigit(r) {
+				letters = 0
+			}
+		}
+	}
+	return string(out)
+}
+
+// stream returns pseudo-random numbers for each position of s, derived from
+// the key and s
+func (p *Pseudonymizer) stream(s string) func(i int) uint32 {
+	var blocks [][]byte
+	return func(i int) uint32 {
+		for len(blocks)*8 <= i {
+			mac := hmac.New(sha256.New, p.key)
+			binary.Write(mac, binary.BigEndian, uint32(len(blocks)))
+			mac.Write([]byte(s))
+			blocks = append(blocks, mac.Sum(nil))
+		}
+		block := blocks[i/8]
+		return binary.BigEndian.Uint32(block[(i%8)*4:])
+	}
+}
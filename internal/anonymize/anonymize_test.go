@@ -0,0 +1,133 @@
+package anonymize
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func TestShape(t *testing.T) {
+	p := New([]byte("key"), nil)
+
+	tests := []string{"SANDHYA", "Gupta", "9450852076", "ANURAGS95", "SG81818282-8", "kuldeep.84"}
+	for _, in := range tests {
+		got := p.Shape(in)
+		if got == in {
+			t.Errorf("Shape(%q) was not replaced", in)
+		}
+		if len(got) != len(in) {
+			t.Errorf("Shape(%q) = %q, length changed", in, got)
+			continue
+		}
+		for i, r := range in {
+			g := rune(got[i])
+			switch {
+			case unicode.IsUpper(r) && !unicode.IsUpper(g),
+				unicode.IsLower(r) && !unicode.IsLower(g),
+				unicode.IsDigit(r) && !unicode.IsDigit(g),
+				!unicode.IsLetter(r) && !unicode.IsDigit(r) && g != r:
+				t.Errorf("Shape(%q) = %q, character %d changed kind", in, got, i)
+			}
+		}
+	}
+
+	if got := p.Shape("9450852076"); got[0] != '9' {
+		t.Errorf("Shape kept first digit of phone: got %q", got)
+	}
+	if p.Shape("SANDHYA") != p.Shape("SANDHYA") {
+		t.Error("Shape is not consistent")
+	}
+	if p.Shape("SANDHYA") != strings.ToUpper(p.Shape("sandhya")) {
+		t.Error("Shape depends on case")
+	}
+	if New([]byte("other"), nil).Shape("SANDHYA") == p.Shape("SANDHYA") {
+		t.Error("Shape does not depend on the key")
+	}
+}
+
+func TestName(t *testing.T) {
+	p := New([]byte("key"), []string{"MEDICAL", "STORE", "LUCKNOW"})
+
+	got := p.Name("J K SANDHYA MEDICAL STORE LUCKNOW")
+	words := strings.Fields(got)
+	if len(words) != 6 {
+		t.Fatalf("Name changed the number of words: %q", got)
+	}
+	if words[0] != "J" || words[1] != "K" {
+		t.Errorf("Name replaced initials: %q", got)
+	}
+	if words[2] == "SANDHYA" {
+		t.Errorf("Name kept SANDHYA: %q", got)
+	}
+	if strings.Join(words[3:], " ") != "MEDICAL STORE LUCKNOW" {
+		t.Errorf("Name replaced kept words: %q", got)
+	}
+}
+
+func TestNarration(t *testing.T) {
+	p := New([]byte("key"), []string{"MEDICAL", "STORE"})
+	p.AddName("SANDHYA MEDICAL STORE")
+	p.AddName("AGNIHOTRIM")
+
+	tests := []struct {
+		narration string
+		replaced  []string
+		kept      []string
+	}{
+		{
+			narration: "UPI/564064611301/PAID VIA NAVI U/8953247523@NAVI/HDFC BANK LTD",
+			replaced:  []string{"8953247523", "564064611301"},
+			kept:      []string{"UPI/", "/PAID VIA NAVI U/", "@NAVI/HDFC BANK LTD"},
+		},
+		{
+			narration: "MMT/IMPS/527412932576/DURGA/AGNIHOTRIM/UNION BANKOF I",
+			replaced:  []string{"AGNIHOTRIM", "527412932576"},
+			kept:      []string{"MMT/IMPS/", "/DURGA/", "/UNION BANKOF I"},
+		},
+		{
+			narration: "NEFT-BARBN52025070146956385-SANDHYA MEDICAL STORE--54220200000128-BARB0BUPGBX",
+			replaced:  []string{"SANDHYA", "54220200000128"},
+			kept:      []string{"MEDICAL STORE", "NEFT-BARBN"},
+		},
+		{
+			narration: "BY CASH -733300 TIRWA (UP) Ag. DDG000201",
+			kept:      []string{"BY CASH -733300 TIRWA (UP) Ag. DDG000201"},
+		},
+	}
+	for _, tt := range tests {
+		got := p.Narration(tt.narration)
+		if len(got) != len(tt.narration) {
+			t.Errorf("Narration(%q) = %q, length changed", tt.narration, got)
+		}
+		for _, s := range tt.replaced {
+			if strings.Contains(got, s) {
+				t.Errorf("Narration(%q) = %q, kept %q", tt.narration, got, s)
+			}
+		}
+		for _, s := range tt.kept {
+			if !strings.Contains(got, s) {
+				t.Errorf("Narration(%q) = %q, replaced %q", tt.narration, got, s)
+			}
+		}
+	}
+}
+
+func TestNarrationMatchesIdentifiers(t *testing.T) {
+	p := New([]byte("key"), nil)
+	p.AddName("AGNIHOTRIM")
+
+	narration := p.Narration("UPI/391925883994/PAYMENT FROM PH/8858510560@AXL/STATE BANK OF I MMT/IMPS/527412932576/OK/AGNIHOTRIM/UNION BANK")
+	for _, id := range []string{p.VPA("8858510560@AXL"), p.Name("AGNIHOTRIM")} {
+		if !strings.Contains(narration, id) {
+			t.Errorf("narration %q does not contain identifier pseudonym %q", narration, id)
+		}
+	}
+	p.AddName("ROHITK85")
+	narration = p.Narration("UPI/906193847736/UPI/ROHITK85/BANK OF BARODA/BAR90619384")
+	if got := p.VPA("ROHITK85"); got == "ROHITK85" || !strings.Contains(narration, got) {
+		t.Errorf("narration %q does not contain UPI name pseudonym %q", narration, got)
+	}
+	if !strings.Contains(p.VPA("8858510560@axl"), p.Digits("8858510560")) {
+		t.Errorf("phone VPA %q and phone %q differ", p.VPA("8858510560@axl"), p.Digits("8858510560"))
+	}
+}
@@ -0,0 +1,201 @@
+// Package billcheck looks through the sale bills for the data entry errors of
+// the billing side: the same sale billed twice under different numbers, bills
+// of no amount or less, and bill numbers missing from a series or dated out of
+// order
+package billcheck
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DuplicateWindow is how many days apart two bills to a party may be dated
+// and still be suspected of being the same sale billed twice
+const DuplicateWindow = 1
+
+// Bill is a sale bill to check. PartyID is 0 for a bill not linked to a
+// party, which is told by its name.
+type Bill struct {
+	ID      int64
+	Number  string
+	Date    time.Time
+	PartyID int64
+	Party   string
+	Amount  float64
+}
+
+// Duplicate is a pair of bills to the same party for the same amount, dated
+// at most DuplicateWindow days apart. Bill is the earlier.
+type Duplicate struct {
+	Bill  Bill
+	Other Bill
+}
+
+// RunningDigits is the most of a bill number's last digits taken as its
+// running number; digits before them, such as a year or month code, are part
+// of its series. A250900055 is number 55 of series A2509.
+const RunningDigits = 5
+
+// Gap is a run of numbers missing from a series, between two bills
+type Gap struct {
+	Series  string
+	First   string // the first and last bill numbers missing
+	Last    string
+	Missing int
+	After   Bill
+	Before  Bill
+}
+
+// OutOfOrder is a bill dated before the bill numbered before it in its series
+type OutOfOrder struct {
+	Bill     Bill
+	Previous Bill
+}
+
+// Report is what the check found, by date, or for the bill number series by
+// series and number
+type Report struct {
+	Duplicates  []Duplicate
+	NonPositive []Bill // bills of zero or a negative amount
+	Gaps        []Gap
+	OutOfOrder  []OutOfOrder
+}
+
+// Check finds the suspected duplicates, non-positive bills, gaps in the bill
+// number series and bills dated out of order among bills. Counter sales to
+// no one by name (CASH or CARD) are not paired, as the same amount is often
+// sold over the counter on consecutive days. Only gaps between the bills given
+// are found: numbers before the first or after the last of a series are not
+// known to be missing.
+func Check(bills []Bill) Report {
+	var rep Report
+	rep.Gaps, rep.OutOfOrder = checkSeries(bills)
+	byParty := make(map[string][]Bill)
+	for _, b := range bills {
+		if b.Amount <= 0 {
+			rep.NonPositive = append(rep.NonPositive, b)
+			continue
+		}
+		if key := partyKey(b); key != "" {
+			byParty[key] = append(byParty[key], b)
+		}
+	}
+
+	for _, party := range byParty {
+		sort.Slice(party, func(i, j int) bool {
+			if !party[i].Date.Equal(party[j].Date) {
+				return party[i].Date.Before(party[j].Date)
+			}
+			return party[i].ID < party[j].ID
+		})
+		for i, b := range party {
+			last := b.Date.AddDate(0, 0, DuplicateWindow)
+			for _, o := range party[i+1:] {
+				if o.Date.After(last) {
+					break
+				}
+				if math.Abs(o.Amount-b.Amount) < 0.005 && normalize(o.Number) != normalize(b.Number) {
+					rep.Duplicates = append(rep.Duplicates, Duplicate{Bill: b, Other: o})
+				}
+			}
+		}
+	}
+
+	sort.Slice(rep.Duplicates, func(i, j int) bool {
+		a, b := rep.Duplicates[i], rep.Duplicates[j]
+		if !a.Bill.Date.Equal(b.Bill.Date) {
+			return a.Bill.Date.Before(b.Bill.Date)
+		}
+		if a.Bill.ID != b.Bill.ID {
+			return a.Bill.ID < b.Bill.ID
+		}
+		return a.Other.ID < b.Other.ID
+	})
+	sort.SliceStable(rep.NonPositive, func(i, j int) bool { return rep.NonPositive[i].Date.Before(rep.NonPositive[j].Date) })
+	return rep
+}
+
+// Split splits a bill number into its series and running number, and the
+// running number's width with its leading zeros; ok is false for a number
+// not ending in a digit
+func Split(number string) (series string, running, width int, ok bool) {
+	number = strings.ToUpper(strings.TrimSpace(number))
+	start := len(number)
+	for start > 0 && len(number)-start < RunningDigits && number[start-1] >= '0' && number[start-1] <= '9' {
+		start--
+	}
+	if start == len(number) {
+		return "", 0, 0, false
+	}
+	running, err := strconv.Atoi(number[start:])
+	if err != nil {
+		return "", 0, 0, false
+	}
+	return number[:start], running, len(number) - start, true
+}
+
+// checkSeries finds the gaps and bills out of date order in each bill
+// number series
+func checkSeries(bills []Bill) ([]Gap, []OutOfOrder) {
+	type numbered struct {
+		bill    Bill
+		running int
+		width   int
+	}
+	bySeries := make(map[string][]numbered)
+	for _, b := range bills {
+		if series, running, width, ok := Split(b.Number); ok {
+			bySeries[series] = append(bySeries[series], numbered{b, running, width})
+		}
+	}
+	names := make([]string, 0, len(bySeries))
+	for series := range bySeries {
+		names = append(names, series)
+	}
+	sort.Strings(names)
+
+	var gaps []Gap
+	var outOfOrder []OutOfOrder
+	for _, series := range names {
+		list := bySeries[series]
+		sort.Slice(list, func(i, j int) bool { return list[i].running < list[j].running })
+		for i := 1; i < len(list); i++ {
+			prev, cur := list[i-1], list[i]
+			if cur.running > prev.running+1 {
+				gaps = append(gaps, Gap{
+					Series:  series,
+					First:   fmt.Sprintf("%s%0*d", series, cur.width, prev.running+1),
+					Last:    fmt.Sprintf("%s%0*d", series, cur.width, cur.running-1),
+					Missing: cur.running - prev.running - 1,
+					After:   prev.bill,
+					Before:  cur.bill,
+				})
+			}
+			if cur.bill.Date.Before(prev.bill.Date) {
+				outOfOrder = append(outOfOrder, OutOfOrder{Bill: cur.bill, Previous: prev.bill})
+			}
+		}
+	}
+	return gaps, outOfOrder
+}
+
+// partyKey is who a bill is to: its party when linked, else its name
+// ignoring case and spacing. It is empty for counter sales to no one by name.
+func partyKey(b Bill) string {
+	if b.PartyID != 0 {
+		return "#" + strconv.FormatInt(b.PartyID, 10)
+	}
+	name := normalize(b.Party)
+	if name == "" || name == "CASH" || name == "CARD" {
+		return ""
+	}
+	return name
+}
+
+func normalize(s string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(s), " "))
+}
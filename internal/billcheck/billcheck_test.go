@@ -0,0 +1,95 @@
+package billcheck
+
+import (
+	"testing"
+	"time"
+)
+
+func date(d int) time.Time {
+	return time.Date(2026, 9, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestCheck(t *testing.T) {
+	bills := []Bill{
+		{ID: 1, Number: "A1", Date: date(1), PartyID: 7, Party: "SHARMA MEDICAL", Amount: 1200},
+		{ID: 2, Number: "A2", Date: date(2), PartyID: 7, Party: "SHARMA MEDICALS", Amount: 1200}, // billed again next day
+		{ID: 3, Number: "A3", Date: date(4), PartyID: 7, Party: "SHARMA MEDICAL", Amount: 1200},  // two days on
+		{ID: 4, Number: "A4", Date: date(1), Party: "baba  medical store", Amount: 450.50},       // not linked
+		{ID: 5, Number: "A5", Date: date(1), Party: "BABA MEDICAL STORE", Amount: 450.50},        // same day, by name
+		{ID: 6, Number: "A6", Date: date(2), Party: "BABA MEDICAL STORE", Amount: 450.00},        // another amount
+		{ID: 7, Number: "C1", Date: date(3), Party: "CASH", Amount: 90},                          // counter sales
+		{ID: 8, Number: "C2", Date: date(3), Party: "CASH", Amount: 90},
+		{ID: 9, Number: "A9", Date: date(5), PartyID: 8, Party: "GUPTA PHARMA", Amount: 0},
+		{ID: 10, Number: "A10", Date: date(3), PartyID: 8, Party: "GUPTA PHARMA", Amount: -300},
+		{ID: 11, Number: "A11", Date: date(5), PartyID: 9, Party: "GUPTA PHARMA", Amount: 1200}, // another party
+	}
+	rep := Check(bills)
+
+	want := [][2]int64{{1, 2}, {4, 5}}
+	if len(rep.Duplicates) != len(want) {
+		t.Fatalf("Duplicates = %+v, want pairs %v", rep.Duplicates, want)
+	}
+	for i, w := range want {
+		if d := rep.Duplicates[i]; d.Bill.ID != w[0] || d.Other.ID != w[1] {
+			t.Errorf("Duplicate %d = %d, %d, want %d, %d", i, d.Bill.ID, d.Other.ID, w[0], w[1])
+		}
+	}
+	if len(rep.NonPositive) != 2 || rep.NonPositive[0].ID != 10 || rep.NonPositive[1].ID != 9 {
+		t.Errorf("NonPositive = %+v, want bills 10 and 9", rep.NonPositive)
+	}
+}
+
+func TestCheckNone(t *testing.T) {
+	rep := Check([]Bill{
+		{ID: 1, Number: "A1", Date: date(1), PartyID: 7, Amount: 100},
+		{ID: 2, Number: "A2", Date: date(3), PartyID: 7, Amount: 100},
+	})
+	if len(rep.Duplicates) != 0 || len(rep.NonPositive) != 0 {
+		t.Errorf("Check() = %+v, want nothing", rep)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	for _, tt := range []struct {
+		number  string
+		series  string
+		running int
+		width   int
+		ok      bool
+	}{
+		{"A260900055", "A2609", 55, 5, true},
+		{"C/25-26/0123", "C/25-26/", 123, 4, true},
+		{" inv12 ", "INV", 12, 2, true},
+		{"ABC", "", 0, 0, false},
+	} {
+		series, running, width, ok := Split(tt.number)
+		if series != tt.series || running != tt.running || width != tt.width || ok != tt.ok {
+			t.Errorf("Split(%q) = %q, %d, %d, %v, want %q, %d, %d, %v", tt.number, series, running, width, ok, tt.series, tt.running, tt.width, tt.ok)
+		}
+	}
+}
+
+func TestCheckSeries(t *testing.T) {
+	rep := Check([]Bill{
+		{ID: 1, Number: "A260900001", Date: date(1), Party: "P", Amount: 100},
+		{ID: 2, Number: "A260900002", Date: date(1), Party: "Q", Amount: 200},
+		{ID: 3, Number: "A260900006", Date: date(3), Party: "R", Amount: 300}, // 3 to 5 missing
+		{ID: 4, Number: "A260900007", Date: date(2), Party: "S", Amount: 400}, // dated before 6
+		{ID: 5, Number: "A260900008", Date: date(4), Party: "T", Amount: 500},
+		{ID: 6, Number: "C260900010", Date: date(1), Party: "CASH", Amount: 50}, // another series
+		{ID: 7, Number: "C260900012", Date: date(2), Party: "CASH", Amount: 60}, // 11 missing
+		{ID: 8, Number: "A261000001", Date: date(30), Party: "U", Amount: 70},   // the next month's series
+	})
+	if len(rep.Gaps) != 2 {
+		t.Fatalf("Gaps = %+v, want 2", rep.Gaps)
+	}
+	if g := rep.Gaps[0]; g.Series != "A2609" || g.First != "A260900003" || g.Last != "A260900005" || g.Missing != 3 || g.After.ID != 2 || g.Before.ID != 3 {
+		t.Errorf("Gap 0 = %+v", g)
+	}
+	if g := rep.Gaps[1]; g.Series != "C2609" || g.First != "C260900011" || g.Last != "C260900011" || g.Missing != 1 {
+		t.Errorf("Gap 1 = %+v", g)
+	}
+	if len(rep.OutOfOrder) != 1 || rep.OutOfOrder[0].Bill.ID != 4 || rep.OutOfOrder[0].Previous.ID != 3 {
+		t.Errorf("OutOfOrder = %+v, want bill 4 after 3", rep.OutOfOrder)
+	}
+}
@@ -0,0 +1,121 @@
+// Package cashchain ties counter cash deposits in the bank to the days of
+// cash sales they banked, so cash can be traced from the sale bill to the
+// drawer to the bank statement
+package cashchain
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// MaxLagDays is how many days after a sale its cash may be banked. Cash from
+// older sales is not tied to a deposit, and is left as a shortfall.
+const MaxLagDays = 7
+
+// Day is a day's cash sales
+type Day struct {
+	Date  time.Time
+	Sales float64
+}
+
+// Deposit is cash deposited in the bank. Point is where it was deposited
+// from: the depositing agent's code, or else the bank branch's location.
+type Deposit struct {
+	ID     int64
+	Date   time.Time
+	Amount float64
+	Point  string
+}
+
+// Link is the part of a deposit that banked a day's cash sales
+type Link struct {
+	DepositID int64
+	SaleDate  time.Time
+	Amount    float64
+}
+
+// Result is how deposits were tied to sale days. Unbanked is each sale day's
+// cash no deposit was tied to, and Unmatched each deposit's amount left over
+// when it banked more than the sales before it, both by sale date or deposit
+// ID; days and deposits fully tied are left out.
+type Result struct {
+	Links     []Link
+	Unbanked  map[string]float64 // by sale date, 2006-01-02
+	Unmatched map[int64]float64
+}
+
+// DateKey is how a sale date is keyed in Result.Unbanked
+func DateKey(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+// CounterPoints are the points the counter's own deposits were made from,
+// so other cash deposits from them can be counted as counter cash too
+func CounterPoints(counter []Deposit) map[string]bool {
+	points := make(map[string]bool)
+	for _, d := range counter {
+		if d.Point != "" {
+			points[d.Point] = true
+		}
+	}
+	return points
+}
+
+// Match ties each deposit, in date order, to the oldest sale days on or
+// before it whose cash is not yet banked, going back at most MaxLagDays.
+// Amounts are matched in paise, so rounding leaves nothing over.
+func Match(days []Day, deposits []Deposit) Result {
+	days = append([]Day(nil), days...)
+	sort.Slice(days, func(i, j int) bool { return days[i].Date.Before(days[j].Date) })
+	deposits = append([]Deposit(nil), deposits...)
+	sort.SliceStable(deposits, func(i, j int) bool {
+		if !deposits[i].Date.Equal(deposits[j].Date) {
+			return deposits[i].Date.Before(deposits[j].Date)
+		}
+		return deposits[i].ID < deposits[j].ID
+	})
+
+	left := make([]int64, len(days))
+	for i, d := range days {
+		left[i] = paise(d.Sales)
+	}
+	result := Result{Unbanked: make(map[string]float64), Unmatched: make(map[int64]float64)}
+	first := 0 // days before first are fully banked or too old for later deposits
+	for _, dep := range deposits {
+		amount := paise(dep.Amount)
+		earliest := dep.Date.AddDate(0, 0, -MaxLagDays)
+		for i := first; i < len(days) && amount > 0; i++ {
+			if days[i].Date.After(dep.Date) {
+				break
+			}
+			if left[i] == 0 || days[i].Date.Before(earliest) {
+				continue
+			}
+			n := min(amount, left[i])
+			left[i] -= n
+			amount -= n
+			result.Links = append(result.Links, Link{DepositID: dep.ID, SaleDate: days[i].Date, Amount: rupees(n)})
+		}
+		for first < len(days) && (left[first] == 0 || days[first].Date.Before(earliest)) {
+			first++
+		}
+		if amount > 0 {
+			result.Unmatched[dep.ID] = rupees(amount)
+		}
+	}
+	for i, d := range days {
+		if left[i] > 0 {
+			result.Unbanked[DateKey(d.Date)] = rupees(left[i])
+		}
+	}
+	return result
+}
+
+func paise(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+func rupees(paise int64) float64 {
+	return float64(paise) / 100
+}
@@ -0,0 +1,102 @@
+package cashchain
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		days      []Day
+		deposits  []Deposit
+		links     []Link
+		unbanked  map[string]float64
+		unmatched map[int64]float64
+	}{
+		{
+			name:     "next day deposit banks the day before",
+			days:     []Day{{date("2025-04-01"), 5000}},
+			deposits: []Deposit{{ID: 1, Date: date("2025-04-02"), Amount: 5000}},
+			links:    []Link{{1, date("2025-04-01"), 5000}},
+		},
+		{
+			name: "one deposit banks several days oldest first",
+			days: []Day{{date("2025-04-02"), 3000}, {date("2025-04-01"), 2000.50}},
+			deposits: []Deposit{
+				{ID: 7, Date: date("2025-04-03"), Amount: 4000},
+			},
+			links:    []Link{{7, date("2025-04-01"), 2000.50}, {7, date("2025-04-02"), 1999.50}},
+			unbanked: map[string]float64{"2025-04-02": 1000.50},
+		},
+		{
+			name: "a day's cash banked by two deposits",
+			days: []Day{{date("2025-04-01"), 6000}},
+			deposits: []Deposit{
+				{ID: 2, Date: date("2025-04-02"), Amount: 2500},
+				{ID: 1, Date: date("2025-04-02"), Amount: 3500},
+			},
+			links: []Link{{1, date("2025-04-01"), 3500}, {2, date("2025-04-01"), 2500}},
+		},
+		{
+			name:      "deposit does not bank later sales",
+			days:      []Day{{date("2025-04-05"), 1000}},
+			deposits:  []Deposit{{ID: 1, Date: date("2025-04-04"), Amount: 1000}},
+			unbanked:  map[string]float64{"2025-04-05": 1000},
+			unmatched: map[int64]float64{1: 1000},
+		},
+		{
+			name:      "sales older than the lag are left unbanked",
+			days:      []Day{{date("2025-04-01"), 1000}, {date("2025-04-09"), 500}},
+			deposits:  []Deposit{{ID: 1, Date: date("2025-04-10"), Amount: 1500}},
+			links:     []Link{{1, date("2025-04-09"), 500}},
+			unbanked:  map[string]float64{"2025-04-01": 1000},
+			unmatched: map[int64]float64{1: 1000},
+		},
+		{
+			name:      "same day deposit counts",
+			days:      []Day{{date("2025-04-01"), 800}},
+			deposits:  []Deposit{{ID: 1, Date: date("2025-04-01"), Amount: 1000}},
+			links:     []Link{{1, date("2025-04-01"), 800}},
+			unmatched: map[int64]float64{1: 200},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Match(tt.days, tt.deposits)
+			if !reflect.DeepEqual(got.Links, tt.links) {
+				t.Errorf("Links = %v, want %v", got.Links, tt.links)
+			}
+			if tt.unbanked == nil {
+				tt.unbanked = map[string]float64{}
+			}
+			if !reflect.DeepEqual(got.Unbanked, tt.unbanked) {
+				t.Errorf("Unbanked = %v, want %v", got.Unbanked, tt.unbanked)
+			}
+			if tt.unmatched == nil {
+				tt.unmatched = map[int64]float64{}
+			}
+			if !reflect.DeepEqual(got.Unmatched, tt.unmatched) {
+				t.Errorf("Unmatched = %v, want %v", got.Unmatched, tt.unmatched)
+			}
+		})
+	}
+}
+
+func TestCounterPoints(t *testing.T) {
+	got := CounterPoints([]Deposit{{Point: "DDG000201"}, {Point: ""}, {Point: "KANPUR"}, {Point: "DDG000201"}})
+	want := map[string]bool{"DDG000201": true, "KANPUR": true}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CounterPoints() = %v, want %v", got, want)
+	}
+}
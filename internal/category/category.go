@@ -0,0 +1,42 @@
+package category
+
+// Category classifies an imported receipt book entry
+type Category string
+
+const (
+	Receipt          Category = "receipt"
+	BankCharge       Category = "bank_charge"
+	Interest         Category = "interest"
+	InternalTransfer Category = "internal_transfer"
+	Other            Category = "other"
+)
+
+// All lists the categories in display order
+var All = []Category{Receipt, BankCharge, Interest, InternalTransfer, Other}
+
+// Label returns a human readable name for the category
+func (c Category) Label() string {
+	switch c {
+	case Receipt:
+		return "Receipt"
+	case BankCharge:
+		return "Bank Charge"
+	case Interest:
+		return "Interest"
+	case InternalTransfer:
+		return "Internal Transfer"
+	case Other:
+		return "Other"
+	}
+	return string(c)
+}
+
+// Valid reports whether c is a known category
+func (c Category) Valid() bool {
+	for _, known := range All {
+		if c == known {
+			return true
+		}
+	}
+	return false
+}
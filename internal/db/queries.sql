@@ -1,25 +1,53 @@
 -- name: CreateParty :one
-INSERT INTO parties (name, location)
-VALUES (?, ?)
+INSERT INTO parties (name, location, firm_id)
+VALUES (?, ?, ?)
 RETURNING *;
 
 -- name: GetPartyByID :one
 SELECT * FROM parties WHERE id = ?;
 
 -- name: GetPartyByName :one
-SELECT * FROM parties WHERE name = ? LIMIT 1;
+SELECT * FROM parties WHERE name = ? AND firm_id = ? LIMIT 1;
 
 -- name: ListParties :many
-SELECT * FROM parties ORDER BY name;
+SELECT * FROM parties WHERE firm_id = ? ORDER BY name;
 
 -- name: CreateIdentifier :one
-INSERT INTO identifiers (party_id, type, value)
-VALUES (?, ?, ?)
-ON CONFLICT (type, value) DO UPDATE SET party_id = excluded.party_id
+INSERT INTO identifiers (party_id, type, value, firm_id)
+VALUES (?, ?, ?, ?)
 RETURNING *;
 
+-- name: UpdateIdentifierSighting :exec
+UPDATE identifiers SET first_seen = ?, last_seen = ?, hit_count = ? WHERE id = ?;
+
+-- name: ListIdentifiersForExport :many
+SELECT i.type, i.value, i.party_id, p.name as party_name, p.location as party_location,
+    i.first_seen, i.last_seen, i.hit_count
+FROM identifiers i
+JOIN parties p ON p.id = i.party_id
+WHERE i.firm_id = ?
+ORDER BY p.name, i.type, i.value;
+
+-- name: ListReceiptNarrations :many
+SELECT t.party_id, p.name as party_name, t.transaction_date, t.narration
+FROM transactions t
+JOIN parties p ON p.id = t.party_id
+WHERE t.firm_id = ? AND t.category = 'receipt' AND NOT t.is_internal AND t.narration IS NOT NULL
+ORDER BY t.transaction_date;
+
+-- name: CountParties :one
+SELECT COUNT(*) FROM parties WHERE firm_id = ?;
+
+-- name: ListPartiesWithoutIdentifiers :many
+SELECT p.id, p.name, p.location, CAST(COUNT(t.id) AS INTEGER) as receipt_count
+FROM parties p
+LEFT JOIN transactions t ON t.party_id = p.id AND t.category = 'receipt'
+WHERE p.firm_id = ? AND NOT EXISTS (SELECT 1 FROM identifiers i WHERE i.party_id = p.id)
+GROUP BY p.id
+ORDER BY receipt_count DESC, p.name;
+
 -- name: GetIdentifierByTypeValue :one
-SELECT * FROM identifiers WHERE type = ? AND value = ? LIMIT 1;
+SELECT * FROM identifiers WHERE type = ? AND value = ? AND firm_id = ? LIMIT 1;
 
 -- name: GetIdentifiersByPartyID :many
 SELECT * FROM identifiers WHERE party_id = ?;
@@ -28,17 +56,57 @@ SELECT * FROM identifiers WHERE party_id = ?;
 SELECT DISTINCT p.*, i.type as match_type, i.value as match_value
 FROM parties p
 JOIN identifiers i ON p.id = i.party_id
-WHERE i.value = ?;
+WHERE i.value = ? AND i.firm_id = ?;
 
 -- name: FindPartiesByIdentifierValues :many
 SELECT DISTINCT p.*, i.type as match_type, i.value as match_value
 FROM parties p
 JOIN identifiers i ON p.id = i.party_id
-WHERE i.value IN (sqlc.slice('values'));
+WHERE i.firm_id = ? AND i.value IN (sqlc.slice('values'));
+
+-- name: AddIdentifierConflict :exec
+INSERT INTO identifier_conflicts (firm_id, type, value, claimed_party_id, narration)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (firm_id, type, value, claimed_party_id) DO NOTHING;
+
+-- name: ListIdentifierConflicts :many
+SELECT c.id, c.type, c.value, c.narration, c.found_at,
+    i.party_id, p.name as party_name, c.claimed_party_id, cp.name as claimed_party_name
+FROM identifier_conflicts c
+JOIN identifiers i ON i.firm_id = c.firm_id AND i.type = c.type AND i.value = c.value
+JOIN parties p ON p.id = i.party_id
+JOIN parties cp ON cp.id = c.claimed_party_id
+WHERE c.firm_id = ? AND c.resolution IS NULL AND i.party_id != c.claimed_party_id
+ORDER BY c.type, c.value, c.found_at;
+
+-- name: GetIdentifierConflict :one
+SELECT * FROM identifier_conflicts WHERE id = ? AND firm_id = ?;
+
+-- name: ResolveIdentifierConflict :exec
+UPDATE identifier_conflicts SET resolution = ?, resolved_at = CURRENT_TIMESTAMP WHERE id = ?;
+
+-- name: MoveIdentifier :exec
+UPDATE identifiers SET party_id = ? WHERE firm_id = ? AND type = ? AND value = ?;
+
+-- name: AddIdentifierHistory :exec
+INSERT INTO identifier_history (firm_id, type, value, party_id, previous_party_id, cause, detail)
+VALUES (?, ?, ?, ?, ?, ?, ?);
+
+-- name: ListIdentifierHistoryByPartyID :many
+SELECT h.id, h.type, h.value, h.party_id, p.name as party_name,
+    h.previous_party_id, pp.name as previous_party_name, h.cause, h.detail, h.assigned_at
+FROM identifier_history h
+LEFT JOIN parties p ON p.id = h.party_id
+LEFT JOIN parties pp ON pp.id = h.previous_party_id
+WHERE h.firm_id = ? AND EXISTS (
+    SELECT 1 FROM identifier_history o
+    WHERE o.firm_id = h.firm_id AND o.type = h.type AND o.value = h.value AND o.party_id = ?
+)
+ORDER BY h.assigned_at DESC, h.id DESC;
 
 -- name: CreateTransaction :one
-INSERT INTO transactions (party_id, amount, transaction_date, payment_mode, narration, cash_bank_code, cash_bank_location)
-VALUES (?, ?, ?, ?, ?, ?, ?)
+INSERT INTO transactions (party_id, amount, transaction_date, payment_mode, narration, cash_bank_code, cash_bank_location, category, is_internal, account_id, firm_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 RETURNING *;
 
 -- name: GetTransactionsByPartyID :many
@@ -58,14 +126,31 @@ LIMIT ?;
 -- name: GetPartyWithTransactionCount :one
 SELECT p.*, COUNT(t.id) as transaction_count, SUM(t.amount) as total_amount
 FROM parties p
-LEFT JOIN transactions t ON p.id = t.party_id
+LEFT JOIN transactions t ON p.id = t.party_id AND t.category = 'receipt'
+    AND NOT EXISTS (SELECT 1 FROM cheques c WHERE c.transaction_id = t.id AND c.status = 'bounced')
 WHERE p.id = ?
 GROUP BY p.id;
 
+-- name: GetPartyPaymentModes :many
+SELECT COALESCE(t.payment_mode, '') as payment_mode, COUNT(*) as receipts
+FROM transactions t
+WHERE t.party_id = ? AND t.category = 'receipt'
+    AND NOT EXISTS (SELECT 1 FROM cheques c WHERE c.transaction_id = t.id AND c.status = 'bounced')
+GROUP BY COALESCE(t.payment_mode, '');
+
+-- name: GetPartyReceiptAmounts :many
+SELECT t.amount FROM transactions t
+WHERE t.party_id = ? AND t.category = 'receipt' AND t.amount > 0
+    AND NOT EXISTS (SELECT 1 FROM cheques c WHERE c.transaction_id = t.id AND c.status = 'bounced')
+ORDER BY t.transaction_date DESC
+LIMIT ?;
+
 -- name: GetAllPartiesWithStats :many
 SELECT p.*, COUNT(t.id) as transaction_count, COALESCE(SUM(t.amount), 0) as total_amount
 FROM parties p
-LEFT JOIN transactions t ON p.id = t.party_id
+LEFT JOIN transactions t ON p.id = t.party_id AND t.category = 'receipt'
+    AND NOT EXISTS (SELECT 1 FROM cheques c WHERE c.transaction_id = t.id AND c.status = 'bounced')
+WHERE p.firm_id = ?
 GROUP BY p.id
 ORDER BY transaction_count DESC;
 
@@ -73,22 +158,1003 @@ ORDER BY transaction_count DESC;
 SELECT DISTINCT p.*, t.narration as match_narration
 FROM parties p
 JOIN transactions t ON p.id = t.party_id
-WHERE t.narration LIKE ?
+WHERE t.narration LIKE ? AND p.firm_id = ?
 LIMIT 50;
 
 -- name: CreateSaleBill :one
-INSERT INTO sale_bills (bill_number, bill_date, party_name, amount, is_cash_sale)
-VALUES (?, ?, ?, ?, ?)
+INSERT INTO sale_bills (
+    bill_number, bill_date, party_name, amount, is_cash_sale, is_card_sale, party_id, firm_id,
+    irn, ack_number, ack_date, taxable_value, cgst, sgst, igst
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 RETURNING *;
 
+-- name: SetSaleBillEInvoice :execrows
+UPDATE sale_bills SET irn = ?, ack_number = ?, ack_date = ?
+WHERE firm_id = ? AND bill_number = ? AND bill_date = ? AND party_name = ? AND amount = ? AND irn = '';
+
+-- name: SetSaleBillTaxes :execrows
+UPDATE sale_bills SET taxable_value = ?, cgst = ?, sgst = ?, igst = ?
+WHERE firm_id = ? AND bill_number = ? AND bill_date = ? AND party_name = ? AND amount = ?
+  AND taxable_value = 0 AND cgst = 0 AND sgst = 0 AND igst = 0;
+
+-- name: SearchSaleBillsByIRN :many
+SELECT * FROM sale_bills
+WHERE firm_id = ? AND (irn LIKE ? OR ack_number = ?)
+ORDER BY bill_date DESC, id DESC
+LIMIT 50;
+
+-- name: GetSaleBillByID :one
+SELECT * FROM sale_bills WHERE id = ?;
+
+-- name: GetPartyBySaleBillID :one
+SELECT p.* FROM parties p
+JOIN sale_bills b ON b.party_id = p.id
+WHERE b.id = ?;
+
 -- name: SearchSaleBillsByAmountRange :many
 SELECT * FROM sale_bills
 WHERE amount >= ? AND amount <= ?
   AND bill_date >= ? AND bill_date <= ?
+  AND firm_id = ?
 ORDER BY bill_date DESC, amount DESC
 LIMIT 100;
 
+-- name: GetTransactionByID :one
+SELECT * FROM transactions WHERE id = ?;
+
+-- name: SplitTransaction :exec
+UPDATE transactions SET party_id = ?, amount = ? WHERE id = ?;
+
+-- name: CreateTransactionSplit :exec
+INSERT INTO transaction_splits (firm_id, source_transaction_id, transaction_id, original_party_id, original_amount)
+VALUES (?, ?, ?, ?, ?);
+
+-- name: GetTransactionSplit :one
+SELECT * FROM transaction_splits WHERE transaction_id = ?;
+
+-- name: ListTransactionSplitParts :many
+SELECT s.transaction_id, t.party_id, p.name as party_name, t.amount
+FROM transaction_splits s
+JOIN transactions t ON t.id = s.transaction_id
+JOIN parties p ON p.id = t.party_id
+WHERE s.source_transaction_id = ?
+ORDER BY s.id;
+
+-- name: ListSplitTransactionIDsByParty :many
+SELECT s.transaction_id FROM transaction_splits s
+JOIN transactions t ON t.id = s.transaction_id
+WHERE t.party_id = ?;
+
+-- name: GetSplitTransactionByDetails :one
+SELECT s.source_transaction_id FROM transaction_splits s
+JOIN transactions t ON t.id = s.source_transaction_id
+WHERE s.original_amount = ? AND t.transaction_date = ? AND t.narration = ? AND t.firm_id = ?
+LIMIT 1;
+
+-- name: CreateTransactionMerge :exec
+INSERT INTO transaction_merges (firm_id, transaction_id, superseded_transaction_id, superseded_party_id, superseded_amount, superseded_date, superseded_payment_mode, superseded_narration)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: ListTransactionMerges :many
+SELECT * FROM transaction_merges WHERE transaction_id = ? ORDER BY merged_at DESC, id DESC;
+
+-- name: ListMergedTransactionIDsByParty :many
+SELECT DISTINCT m.transaction_id FROM transaction_merges m
+JOIN transactions t ON t.id = m.transaction_id
+WHERE t.party_id = ?;
+
+-- name: GetMergedTransactionByDetails :one
+SELECT transaction_id FROM transaction_merges
+WHERE superseded_amount = ? AND superseded_date = ? AND superseded_narration = ? AND firm_id = ?
+LIMIT 1;
+
+-- name: ListTransactionMergeCandidates :many
+SELECT t.id, t.party_id, p.name as party_name, t.amount, t.transaction_date, t.payment_mode, t.narration
+FROM transactions t
+JOIN parties p ON p.id = t.party_id
+WHERE t.firm_id = ? AND t.party_id = ? AND t.amount = ? AND t.transaction_date BETWEEN ? AND ? AND t.id != ?
+    AND t.id NOT IN (SELECT transaction_id FROM transaction_splits)
+ORDER BY t.transaction_date, t.id;
+
+-- name: CreateAgent :one
+INSERT INTO agents (firm_id, name, code, location, commission_percent)
+VALUES (?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: UpdateAgent :exec
+UPDATE agents SET name = ?, code = ?, location = ?, commission_percent = ? WHERE id = ? AND firm_id = ?;
+
+-- name: DeleteAgent :exec
+DELETE FROM agents WHERE id = ? AND firm_id = ?;
+
+-- name: DeleteAgentTransactions :exec
+DELETE FROM transaction_agents
+WHERE agent_id IN (SELECT id FROM agents WHERE id = ? AND firm_id = ?);
+
+-- name: ListAgents :many
+SELECT * FROM agents WHERE firm_id = ? ORDER BY name;
+
+-- name: AssignTransactionAgent :exec
+INSERT OR IGNORE INTO transaction_agents (transaction_id, agent_id, source)
+VALUES (?, ?, ?);
+
+-- name: SetTransactionAgent :exec
+INSERT INTO transaction_agents (transaction_id, agent_id, source)
+VALUES (?, ?, 'manual')
+ON CONFLICT (transaction_id) DO UPDATE SET agent_id = excluded.agent_id, source = excluded.source;
+
+-- name: ClearTransactionAgent :exec
+DELETE FROM transaction_agents WHERE transaction_id = ?;
+
+-- name: GetTransactionAgent :one
+SELECT * FROM transaction_agents WHERE transaction_id = ?;
+
+-- name: ListTransactionAgentsByParty :many
+SELECT ta.transaction_id, ta.agent_id, ta.source, a.name as agent_name
+FROM transaction_agents ta
+JOIN agents a ON a.id = ta.agent_id
+JOIN transactions t ON t.id = ta.transaction_id
+WHERE t.party_id = ?;
+
+-- name: ListUnassignedAgentReceipts :many
+SELECT id, narration, cash_bank_location FROM transactions
+WHERE firm_id = ? AND is_internal = 0 AND payment_mode IS NOT 'OPENING'
+    AND (narration IS NOT NULL OR cash_bank_location IS NOT NULL)
+    AND id NOT IN (SELECT transaction_id FROM transaction_agents);
+
+-- name: ListAgentSummaries :many
+SELECT a.id, a.name, a.code, a.location,
+    COUNT(t.id) as receipt_count,
+    CAST(COALESCE(SUM(t.amount), 0) AS REAL) as total
+FROM agents a
+LEFT JOIN transaction_agents ta ON ta.agent_id = a.id
+LEFT JOIN transactions t ON t.id = ta.transaction_id AND t.transaction_date BETWEEN ? AND ?
+WHERE a.firm_id = ?
+GROUP BY a.id
+ORDER BY a.name;
+
+-- name: ListAgentCollections :many
+SELECT ta.agent_id, COALESCE(t.payment_mode, '') as payment_mode,
+    COUNT(*) as receipt_count,
+    CAST(SUM(t.amount) AS REAL) as total
+FROM transaction_agents ta
+JOIN agents a ON a.id = ta.agent_id
+JOIN transactions t ON t.id = ta.transaction_id
+WHERE t.transaction_date BETWEEN ? AND ? AND a.firm_id = ?
+GROUP BY ta.agent_id, t.payment_mode
+ORDER BY ta.agent_id, t.payment_mode;
+
+-- name: ListAgentReceipts :many
+SELECT t.id, t.party_id, p.name as party_name, t.amount, t.transaction_date, t.payment_mode, t.narration, ta.source
+FROM transaction_agents ta
+JOIN transactions t ON t.id = ta.transaction_id
+JOIN parties p ON p.id = t.party_id
+WHERE ta.agent_id = ? AND t.transaction_date BETWEEN ? AND ?
+ORDER BY t.transaction_date DESC, t.id DESC;
+
+-- name: CreateRoute :exec
+INSERT INTO routes (firm_id, name) VALUES (?, ?);
+
+-- name: DeleteRoute :exec
+DELETE FROM routes WHERE id = ? AND firm_id = ?;
+
+-- name: DeleteRouteLocations :exec
+DELETE FROM route_locations WHERE route_id = ? AND firm_id = ?;
+
+-- name: ListRoutes :many
+SELECT * FROM routes WHERE firm_id = ? ORDER BY name;
+
+-- name: ListRouteLocations :many
+SELECT * FROM route_locations WHERE firm_id = ? ORDER BY location;
+
+-- name: SetRouteLocation :exec
+INSERT INTO route_locations (firm_id, route_id, location)
+VALUES (?, ?, ?)
+ON CONFLICT (firm_id, location) DO UPDATE SET route_id = excluded.route_id;
+
+-- name: DeleteRouteLocation :exec
+DELETE FROM route_locations WHERE id = ? AND firm_id = ?;
+
+-- name: ListUnroutedLocations :many
+SELECT CAST(UPPER(TRIM(location)) AS TEXT) as location, COUNT(*) as party_count
+FROM parties
+WHERE firm_id = ? AND TRIM(COALESCE(location, '')) != ''
+    AND UPPER(TRIM(location)) NOT IN (SELECT location FROM route_locations WHERE firm_id = ?)
+GROUP BY UPPER(TRIM(location))
+ORDER BY party_count DESC, location;
+
+-- name: ListReceiptsInPeriod :many
+SELECT t.party_id, t.transaction_date, t.amount
+FROM transactions t
+WHERE t.firm_id = ? AND t.category = 'receipt' AND t.transaction_date BETWEEN ? AND ?
+    AND NOT EXISTS (SELECT 1 FROM cheques c WHERE c.transaction_id = t.id AND c.status = 'bounced')
+ORDER BY t.transaction_date;
+
+-- name: ListBillAllocationsByParty :many
+SELECT ba.* FROM bill_allocations ba
+JOIN transactions t ON t.id = ba.transaction_id
+WHERE t.party_id = ?
+ORDER BY ba.id;
+
+-- name: AllocateBillReceipt :exec
+INSERT INTO bill_allocations (sale_bill_id, transaction_id, amount)
+VALUES (?, ?, ?)
+ON CONFLICT (sale_bill_id, transaction_id) DO UPDATE SET amount = bill_allocations.amount + excluded.amount;
+
+-- name: DeleteBillAllocation :exec
+DELETE FROM bill_allocations
+WHERE id = ? AND transaction_id IN (SELECT id FROM transactions WHERE firm_id = ?);
+
+-- name: ClearTransactionAllocations :exec
+DELETE FROM bill_allocations WHERE transaction_id = ?;
+
 -- name: GetTransactionByDetails :one
 SELECT * FROM transactions
-WHERE amount = ? AND transaction_date = ? AND narration = ?
+WHERE amount = ? AND transaction_date = ? AND narration = ? AND firm_id = ?
 LIMIT 1;
+
+-- name: CreatePOSSettlement :one
+INSERT INTO pos_settlements (credit_date, settlement_date, terminal_id, batch_number, amount, narration, account_id, firm_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: ListPOSSettlements :many
+SELECT * FROM pos_settlements
+WHERE settlement_date >= ? AND settlement_date <= ? AND firm_id = ?
+ORDER BY settlement_date DESC, id DESC;
+
+-- name: GetDailyPOSCollections :many
+SELECT settlement_date, COUNT(*) as settlement_count, SUM(amount) as total_amount
+FROM pos_settlements
+WHERE settlement_date >= ? AND settlement_date <= ? AND firm_id = ?
+GROUP BY settlement_date
+ORDER BY settlement_date DESC;
+
+-- name: ListRules :many
+SELECT * FROM rules ORDER BY priority, id;
+
+-- name: ListEnabledRules :many
+SELECT * FROM rules WHERE enabled = TRUE ORDER BY priority, id;
+
+-- name: GetRule :one
+SELECT * FROM rules WHERE id = ?;
+
+-- name: CreateRule :one
+INSERT INTO rules (name, narration_pattern, party_pattern, min_amount, max_amount, set_category, set_internal, set_party_name, priority, enabled)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: UpdateRule :exec
+UPDATE rules
+SET name = ?, narration_pattern = ?, party_pattern = ?, min_amount = ?, max_amount = ?,
+    set_category = ?, set_internal = ?, set_party_name = ?, priority = ?, enabled = ?
+WHERE id = ?;
+
+-- name: DeleteRule :exec
+DELETE FROM rules WHERE id = ?;
+
+-- name: ListParserVocabulary :many
+SELECT * FROM parser_vocabulary ORDER BY kind, id;
+
+-- name: DeleteParserVocabularyKind :exec
+DELETE FROM parser_vocabulary WHERE kind = ?;
+
+-- name: AddParserVocabulary :exec
+INSERT INTO parser_vocabulary (kind, value) VALUES (?, ?);
+
+-- name: ListPaymentModeRules :many
+SELECT * FROM payment_mode_rules ORDER BY priority, id;
+
+-- name: GetPaymentModeRule :one
+SELECT * FROM payment_mode_rules WHERE id = ?;
+
+-- name: CreatePaymentModeRule :one
+INSERT INTO payment_mode_rules (pattern, mode, priority)
+VALUES (?, ?, ?)
+RETURNING *;
+
+-- name: UpdatePaymentModeRule :exec
+UPDATE payment_mode_rules SET pattern = ?, mode = ?, priority = ? WHERE id = ?;
+
+-- name: DeletePaymentModeRule :exec
+DELETE FROM payment_mode_rules WHERE id = ?;
+
+-- name: CreateCheque :one
+INSERT INTO cheques (transaction_id, cheque_number, cheque_date, received_date)
+VALUES (?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetCheque :one
+SELECT * FROM cheques WHERE id = ?;
+
+-- name: UpdateChequeStatus :exec
+UPDATE cheques
+SET status = ?, deposited_date = ?, cleared_date = ?, bounced_date = ?, notes = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: ListChequesByStatus :many
+SELECT c.*, t.amount, p.id as party_id, p.name as party_name, p.location as party_location
+FROM cheques c
+JOIN transactions t ON t.id = c.transaction_id
+JOIN parties p ON p.id = t.party_id
+WHERE p.firm_id = ? AND c.status IN (sqlc.slice('statuses'))
+ORDER BY c.received_date, c.id
+LIMIT 500;
+
+-- name: GetChequesByPartyID :many
+SELECT c.* FROM cheques c
+JOIN transactions t ON t.id = c.transaction_id
+WHERE t.party_id = ?;
+
+-- name: UpdatePartyCreditLimit :exec
+UPDATE parties SET credit_limit = ? WHERE id = ?;
+
+-- name: UpdatePartyEmail :exec
+UPDATE parties SET email = ? WHERE id = ? AND firm_id = ?;
+
+-- name: UpdatePartyPhone :exec
+UPDATE parties SET phone = ? WHERE id = ? AND firm_id = ?;
+
+-- name: UpdatePartyContact :exec
+UPDATE parties SET phone = ?, address = ?, gstin = ?, drug_license = ? WHERE id = ? AND firm_id = ?;
+
+-- name: ListPartyBalances :many
+SELECT p.*, CAST(COALESCE(pb.receipt_count, 0) AS INTEGER) as receipt_count,
+    CAST(ROUND(COALESCE(pb.billed, 0), 2) AS REAL) as billed, CAST(ROUND(COALESCE(pb.received, 0), 2) AS REAL) as received
+FROM parties p
+LEFT JOIN party_balances pb ON pb.party_id = p.id
+WHERE p.firm_id = ?
+ORDER BY p.name;
+
+-- name: GetPartyBalance :one
+SELECT p.*, CAST(COALESCE(r.receipt_count, 0) AS INTEGER) as receipt_count,
+    CAST(COALESCE(b.billed, 0) AS REAL) as billed, CAST(COALESCE(r.received, 0) AS REAL) as received
+FROM parties p
+LEFT JOIN (
+    SELECT party_id, SUM(amount) as billed
+    FROM sale_bills
+    WHERE party_id IS NOT NULL AND COALESCE(is_cash_sale, FALSE) = FALSE AND is_card_sale = FALSE
+    GROUP BY party_id
+) b ON b.party_id = p.id
+LEFT JOIN (
+    SELECT t.party_id, COUNT(*) as receipt_count, SUM(t.amount) as received
+    FROM transactions t
+    WHERE t.category = 'receipt'
+      AND NOT EXISTS (SELECT 1 FROM cheques c WHERE c.transaction_id = t.id AND c.status = 'bounced')
+    GROUP BY t.party_id
+) r ON r.party_id = p.id
+WHERE p.id = ?;
+
+-- name: ListCreditLimitBreaches :many
+SELECT p.*, CAST(COALESCE(pb.receipt_count, 0) AS INTEGER) as receipt_count,
+    CAST(ROUND(COALESCE(pb.billed, 0), 2) AS REAL) as billed, CAST(ROUND(COALESCE(pb.received, 0), 2) AS REAL) as received
+FROM parties p
+LEFT JOIN party_balances pb ON pb.party_id = p.id
+WHERE p.firm_id = ? AND p.credit_limit > 0
+  AND ROUND(COALESCE(pb.billed, 0) - COALESCE(pb.received, 0), 2) > p.credit_limit
+ORDER BY COALESCE(pb.billed, 0) - COALESCE(pb.received, 0) - p.credit_limit DESC;
+
+-- name: GetOutstandingSummary :one
+SELECT CAST(COUNT(CASE WHEN pb.billed - pb.received > 0.005 THEN 1 END) AS INTEGER) as owing,
+    CAST(ROUND(COALESCE(SUM(CASE WHEN pb.billed - pb.received > 0.005 THEN pb.billed - pb.received END), 0), 2) AS REAL) as outstanding,
+    CAST(ROUND(COALESCE(SUM(CASE WHEN pb.received - pb.billed > 0.005 THEN pb.received - pb.billed END), 0), 2) AS REAL) as advances
+FROM party_balances pb
+JOIN parties p ON p.id = pb.party_id
+WHERE p.firm_id = ?;
+
+-- name: ListReceiptMonthTotals :many
+SELECT month, payment_mode, receipt_count, CAST(ROUND(amount, 2) AS REAL) as amount
+FROM receipt_month_totals
+WHERE firm_id = ? AND month >= ? AND payment_mode <> 'OPENING' AND receipt_count > 0
+ORDER BY month DESC, payment_mode;
+
+-- name: GetDailyCashSales :many
+SELECT bill_date, COUNT(*) as bill_count, SUM(amount) as total_amount
+FROM sale_bills
+WHERE is_cash_sale = TRUE AND bill_date >= ? AND bill_date <= ? AND firm_id = ?
+GROUP BY bill_date
+ORDER BY bill_date;
+
+-- name: GetDailyCashDeposits :many
+SELECT transaction_date, COUNT(*) as deposit_count, SUM(amount) as total_amount
+FROM transactions
+WHERE is_internal = TRUE AND payment_mode = 'CASH' AND transaction_date >= ? AND transaction_date <= ? AND firm_id = ?
+GROUP BY transaction_date
+ORDER BY transaction_date;
+
+-- name: ListCashDeposits :many
+SELECT id, amount, transaction_date, is_internal, cash_bank_location, narration, account_id
+FROM transactions
+WHERE payment_mode = 'CASH' AND transaction_date >= ? AND transaction_date <= ? AND firm_id = ?
+ORDER BY transaction_date, id;
+
+-- name: GetDailyCardSales :many
+SELECT bill_date, COUNT(*) as bill_count, SUM(amount) as total_amount
+FROM sale_bills
+WHERE is_card_sale = TRUE AND bill_date >= ? AND bill_date <= ? AND firm_id = ?
+GROUP BY bill_date
+ORDER BY bill_date DESC;
+
+-- name: UpsertAccount :one
+INSERT INTO accounts (bank, account_number, firm_id)
+VALUES (?, ?, ?)
+ON CONFLICT (firm_id, bank, account_number) DO UPDATE SET bank = excluded.bank
+RETURNING *;
+
+-- name: ListAccounts :many
+SELECT * FROM accounts WHERE firm_id = ? ORDER BY bank, account_number;
+
+-- name: UpdateAccountStatement :exec
+UPDATE accounts SET statement_balance = ?, statement_date = ? WHERE id = ?;
+
+-- name: SumAccountCreditsAfter :one
+SELECT CAST(COALESCE(SUM(amount), 0) AS REAL) as total
+FROM transactions
+WHERE account_id = ? AND transaction_date > ?;
+
+-- name: SumAccountPOSCreditsAfter :one
+SELECT CAST(COALESCE(SUM(amount), 0) AS REAL) as total
+FROM pos_settlements
+WHERE account_id = ? AND credit_date > ?;
+
+-- name: GetLatestAccountTransaction :one
+SELECT * FROM transactions
+WHERE account_id = ?
+ORDER BY transaction_date DESC, id DESC
+LIMIT 1;
+
+-- name: GetSaleBillsByPartyID :many
+SELECT * FROM sale_bills
+WHERE party_id = ?
+ORDER BY bill_date DESC, id DESC;
+
+-- name: GetCreditSaleBillsByPartyID :many
+SELECT * FROM sale_bills
+WHERE party_id = ? AND COALESCE(is_cash_sale, FALSE) = FALSE AND is_card_sale = FALSE
+ORDER BY bill_date, id;
+
+-- name: CreateStatementLink :one
+INSERT INTO statement_links (party_id, token, expires_at)
+VALUES (?, ?, ?)
+RETURNING *;
+
+-- name: GetStatementLinkByToken :one
+SELECT * FROM statement_links WHERE token = ?;
+
+-- name: ListActiveStatementLinks :many
+SELECT * FROM statement_links
+WHERE party_id = ? AND expires_at > ?
+ORDER BY expires_at DESC;
+
+-- name: DeleteStatementLink :exec
+DELETE FROM statement_links WHERE id = ?;
+
+-- name: CreateStatementEmail :exec
+INSERT INTO statement_emails (firm_id, party_id, email, from_date, till_date, error)
+VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: ListStatementEmailsByParty :many
+SELECT * FROM statement_emails
+WHERE party_id = ?
+ORDER BY sent_at DESC, id DESC
+LIMIT 20;
+
+-- name: QueueSMSAcknowledgement :exec
+INSERT OR IGNORE INTO sms_acknowledgements (firm_id, party_id, transaction_id, phone)
+VALUES (?, ?, ?, ?);
+
+-- name: ListPendingSMSAcknowledgements :many
+SELECT a.id, a.firm_id, a.party_id, a.transaction_id, a.phone, t.amount, t.transaction_date
+FROM sms_acknowledgements a
+JOIN transactions t ON t.id = a.transaction_id
+WHERE a.sent_at IS NULL AND a.attempts < ?
+ORDER BY a.id
+LIMIT 50;
+
+-- name: MarkSMSAcknowledgementSent :exec
+UPDATE sms_acknowledgements SET message = ?, error = '', sent_at = CURRENT_TIMESTAMP, attempts = attempts + 1
+WHERE id = ?;
+
+-- name: MarkSMSAcknowledgementFailed :exec
+UPDATE sms_acknowledgements SET message = ?, error = ?, attempts = attempts + 1
+WHERE id = ?;
+
+-- name: RetrySMSAcknowledgement :exec
+UPDATE sms_acknowledgements SET attempts = 0
+WHERE id = ? AND firm_id = ? AND sent_at IS NULL;
+
+-- name: ListSMSAcknowledgementsByParty :many
+SELECT * FROM sms_acknowledgements
+WHERE party_id = ?
+ORDER BY created_at DESC, id DESC
+LIMIT 20;
+
+-- name: GetLatestSearch :one
+SELECT * FROM search_history WHERE firm_id = ? ORDER BY searched_at DESC, id DESC LIMIT 1;
+
+-- name: RecordSearch :exec
+INSERT INTO search_history (narration, top_party_id, top_party_name, top_confidence, result_count, firm_id)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT (firm_id, narration) DO UPDATE SET
+    top_party_id = excluded.top_party_id,
+    top_party_name = excluded.top_party_name,
+    top_confidence = excluded.top_confidence,
+    result_count = excluded.result_count,
+    searched_at = CURRENT_TIMESTAMP;
+
+-- name: UpdateSearch :exec
+UPDATE search_history
+SET narration = ?, top_party_id = ?, top_party_name = ?, top_confidence = ?, result_count = ?, searched_at = CURRENT_TIMESTAMP
+WHERE id = ? AND firm_id = ?;
+
+-- name: ListRecentSearches :many
+SELECT * FROM search_history WHERE firm_id = ? ORDER BY searched_at DESC, id DESC LIMIT ?;
+
+-- name: CreateSavedSearch :one
+INSERT INTO saved_searches (name, kind, narration, amount, variation, from_date, till_date, firm_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: ListSavedSearches :many
+SELECT * FROM saved_searches WHERE firm_id = ? ORDER BY name, id;
+
+-- name: DeleteSavedSearch :exec
+DELETE FROM saved_searches WHERE id = ? AND firm_id = ?;
+
+-- name: CreatePartyNote :one
+INSERT INTO party_notes (party_id, note)
+VALUES (?, ?)
+RETURNING *;
+
+-- name: GetNotesByPartyID :many
+SELECT * FROM party_notes WHERE party_id = ? ORDER BY created_at DESC, id DESC;
+
+-- name: DeletePartyNote :exec
+DELETE FROM party_notes WHERE id = ?;
+
+-- name: ListTransactionPaymentModes :many
+SELECT t.id, t.party_id, p.name as party_name, t.transaction_date, t.payment_mode, t.narration
+FROM transactions t
+JOIN parties p ON p.id = t.party_id
+WHERE t.firm_id = ? AND t.narration IS NOT NULL
+ORDER BY t.transaction_date, t.id;
+
+-- name: UpdateTransactionPaymentMode :execrows
+UPDATE OR IGNORE transactions SET payment_mode = ? WHERE id = ?;
+
+-- name: UpdateTransactionNote :exec
+UPDATE transactions SET note = ? WHERE id = ?;
+
+-- name: DeleteTransactionTags :exec
+DELETE FROM transaction_tags WHERE transaction_id = ?;
+
+-- name: AddTransactionTag :exec
+INSERT INTO transaction_tags (transaction_id, tag)
+VALUES (?, ?)
+ON CONFLICT (transaction_id, tag) DO NOTHING;
+
+-- name: GetTransactionTagsByPartyID :many
+SELECT tt.* FROM transaction_tags tt
+JOIN transactions t ON t.id = tt.transaction_id
+WHERE t.party_id = ?
+ORDER BY tt.tag;
+
+-- name: GetTransactionTagsByTag :many
+SELECT other.* FROM transaction_tags tt
+JOIN transaction_tags other ON other.transaction_id = tt.transaction_id
+WHERE tt.tag = ?
+ORDER BY other.tag;
+
+-- name: ListTagSummaries :many
+SELECT tt.tag, COUNT(*) AS transaction_count, CAST(COALESCE(SUM(t.amount), 0) AS REAL) AS total
+FROM transaction_tags tt
+JOIN transactions t ON t.id = tt.transaction_id
+WHERE t.firm_id = ?
+GROUP BY tt.tag
+ORDER BY tt.tag;
+
+-- name: ListTransactionsByTag :many
+SELECT t.*, p.name AS party_name FROM transactions t
+JOIN transaction_tags tt ON tt.transaction_id = t.id
+JOIN parties p ON p.id = t.party_id
+WHERE tt.tag = ? AND t.firm_id = ?
+ORDER BY t.transaction_date DESC, t.id DESC;
+
+-- name: CreatePartyMerge :exec
+INSERT INTO party_merges (firm_id, merged_party_id, merged_name, survivor_party_id)
+VALUES (?, ?, ?, ?);
+
+-- name: GetPartyMerge :one
+SELECT * FROM party_merges WHERE merged_party_id = ? LIMIT 1;
+
+-- name: GetPartyMergeByName :one
+SELECT * FROM party_merges WHERE merged_name = ? AND firm_id = ? ORDER BY id DESC LIMIT 1;
+
+-- name: ListPartyMergesBySurvivor :many
+SELECT * FROM party_merges WHERE survivor_party_id = ? ORDER BY merged_at DESC, id DESC;
+
+-- name: ListIdentifierLinks :many
+SELECT DISTINCT i.party_id, c.claimed_party_id, c.type, c.value
+FROM identifier_conflicts c
+JOIN identifiers i ON i.firm_id = c.firm_id AND i.type = c.type AND i.value = c.value
+WHERE c.firm_id = ? AND i.party_id != c.claimed_party_id;
+
+-- name: UpsertMergeSuggestion :exec
+INSERT INTO merge_suggestions (firm_id, party_id, other_party_id, score, reasons)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (party_id, other_party_id) DO UPDATE SET score = excluded.score, reasons = excluded.reasons
+WHERE merge_suggestions.dismissed_at IS NULL;
+
+-- name: ListMergeSuggestions :many
+SELECT s.id, s.score, s.reasons, s.found_at,
+    p.id as party_id, p.name as party_name, p.location as party_location,
+    o.id as other_party_id, o.name as other_party_name, o.location as other_party_location
+FROM merge_suggestions s
+JOIN parties p ON p.id = s.party_id
+JOIN parties o ON o.id = s.other_party_id
+WHERE s.firm_id = ? AND s.dismissed_at IS NULL
+ORDER BY s.score DESC, s.id;
+
+-- name: DismissMergeSuggestion :exec
+UPDATE merge_suggestions SET dismissed_at = CURRENT_TIMESTAMP WHERE id = ? AND firm_id = ?;
+
+-- name: ListPartyAliases :many
+SELECT * FROM party_aliases WHERE firm_id = ? ORDER BY alias;
+
+-- name: UpsertPartyAlias :exec
+INSERT INTO party_aliases (party_id, alias, firm_id)
+VALUES (?, ?, ?)
+ON CONFLICT (firm_id, alias) DO UPDATE SET party_id = excluded.party_id;
+
+-- name: ListUnlinkedSaleBills :many
+SELECT * FROM sale_bills
+WHERE party_id IS NULL AND COALESCE(is_cash_sale, FALSE) = FALSE AND is_card_sale = FALSE AND firm_id = ?
+  AND NOT EXISTS (
+    SELECT 1 FROM financial_years fy
+    WHERE fy.firm_id = sale_bills.firm_id AND sale_bills.bill_date BETWEEN fy.start_date AND fy.end_date)
+ORDER BY party_name, bill_date;
+
+-- name: LinkSaleBill :exec
+UPDATE sale_bills SET party_id = ? WHERE id = ?;
+
+-- name: GetSyncLogByClientID :one
+SELECT * FROM sync_log WHERE client_id = ?;
+
+-- name: CreateSyncLog :exec
+INSERT INTO sync_log (client_id, kind, summary)
+VALUES (?, ?, ?);
+
+-- name: ListFirms :many
+SELECT * FROM firms ORDER BY id;
+
+-- name: GetFirm :one
+SELECT * FROM firms WHERE id = ?;
+
+-- name: CreateFirm :one
+INSERT INTO firms (name, gstin, rounding_tolerance)
+VALUES (?, ?, ?)
+RETURNING *;
+
+-- name: UpdateFirm :exec
+UPDATE firms SET name = ?, gstin = ?, rounding_tolerance = ? WHERE id = ?;
+
+-- name: GetPartyAccountActivity :one
+SELECT COUNT(*) as transaction_count, CAST(COALESCE(SUM(amount), 0) AS REAL) as total_amount
+FROM transactions t
+WHERE t.party_id = ? AND t.account_id = ? AND t.category = 'receipt'
+    AND NOT EXISTS (SELECT 1 FROM cheques c WHERE c.transaction_id = t.id AND c.status = 'bounced');
+
+-- name: SearchSaleBillsByAmountRangeForAccount :many
+SELECT * FROM sale_bills
+WHERE amount >= ? AND amount <= ?
+  AND bill_date >= ? AND bill_date <= ?
+  AND firm_id = ?
+  AND party_id IN (SELECT t.party_id FROM transactions t WHERE t.account_id = ?)
+ORDER BY bill_date DESC, amount DESC
+LIMIT 100;
+
+-- name: GetDailyCashDepositsByAccount :many
+SELECT transaction_date, COUNT(*) as deposit_count, SUM(amount) as total_amount
+FROM transactions
+WHERE is_internal = TRUE AND payment_mode = 'CASH' AND transaction_date >= ? AND transaction_date <= ? AND firm_id = ? AND account_id = ?
+GROUP BY transaction_date
+ORDER BY transaction_date;
+
+-- name: ListPOSSettlementsByAccount :many
+SELECT * FROM pos_settlements
+WHERE settlement_date >= ? AND settlement_date <= ? AND firm_id = ? AND account_id = ?
+ORDER BY settlement_date DESC, id DESC;
+
+-- name: GetDailyPOSCollectionsByAccount :many
+SELECT settlement_date, COUNT(*) as settlement_count, SUM(amount) as total_amount
+FROM pos_settlements
+WHERE settlement_date >= ? AND settlement_date <= ? AND firm_id = ? AND account_id = ?
+GROUP BY settlement_date
+ORDER BY settlement_date DESC;
+
+-- name: ListChequesByStatusAndAccount :many
+SELECT c.*, t.amount, p.id as party_id, p.name as party_name, p.location as party_location
+FROM cheques c
+JOIN transactions t ON t.id = c.transaction_id
+JOIN parties p ON p.id = t.party_id
+WHERE p.firm_id = ? AND t.account_id = ? AND c.status IN (sqlc.slice('statuses'))
+ORDER BY c.received_date, c.id
+LIMIT 500;
+
+-- name: ListReceiptsForExport :many
+SELECT t.transaction_date, t.amount, t.payment_mode, t.narration, t.category, t.account_id,
+    p.name as party_name, p.location as party_location
+FROM transactions t
+JOIN parties p ON p.id = t.party_id
+WHERE t.firm_id = ? AND t.transaction_date >= ? AND t.transaction_date <= ?
+ORDER BY t.transaction_date, t.id;
+
+-- name: ListReceiptsForExportByAccount :many
+SELECT t.transaction_date, t.amount, t.payment_mode, t.narration, t.category, t.account_id,
+    p.name as party_name, p.location as party_location
+FROM transactions t
+JOIN parties p ON p.id = t.party_id
+WHERE t.firm_id = ? AND t.transaction_date >= ? AND t.transaction_date <= ? AND t.account_id = ?
+ORDER BY t.transaction_date, t.id;
+
+-- name: ListBookedNarrations :many
+SELECT transaction_date AS credit_date, narration FROM transactions
+WHERE firm_id = ? AND transaction_date >= ? AND transaction_date <= ? AND narration IS NOT NULL
+UNION ALL
+SELECT credit_date, narration FROM pos_settlements
+WHERE firm_id = ? AND credit_date >= ? AND credit_date <= ? AND narration IS NOT NULL;
+
+-- name: ListReceiptsForTally :many
+SELECT t.id, t.party_id, t.transaction_date, t.amount, t.narration, t.account_id,
+    p.name as party_name
+FROM transactions t
+JOIN parties p ON p.id = t.party_id
+WHERE t.firm_id = ? AND t.category = 'receipt' AND t.transaction_date >= ? AND t.transaction_date <= ?
+  AND NOT EXISTS (SELECT 1 FROM cheques c WHERE c.transaction_id = t.id AND c.status = 'bounced')
+ORDER BY t.transaction_date, t.id;
+
+-- name: RecordBackup :one
+INSERT INTO backups (filename, size_bytes)
+VALUES (?, ?)
+RETURNING *;
+
+-- name: GetLatestBackup :one
+SELECT * FROM backups ORDER BY created_at DESC, id DESC LIMIT 1;
+
+-- name: RecordOffsiteBackup :exec
+INSERT INTO offsite_backups (object_key, size_bytes, error)
+VALUES (?, ?, ?);
+
+-- name: ListOffsiteBackups :many
+SELECT * FROM offsite_backups ORDER BY created_at DESC, id DESC LIMIT 20;
+
+-- name: ListFinancialYears :many
+SELECT * FROM financial_years WHERE firm_id = ? ORDER BY start_date DESC;
+
+-- name: GetFinancialYear :one
+SELECT * FROM financial_years WHERE id = ? AND firm_id = ?;
+
+-- name: GetFinancialYearForDate :one
+SELECT * FROM financial_years WHERE firm_id = ? AND start_date <= ? AND end_date >= ?;
+
+-- name: CloseFinancialYear :one
+INSERT INTO financial_years (firm_id, label, start_date, end_date)
+VALUES (?, ?, ?, ?)
+RETURNING *;
+
+-- name: ReopenFinancialYear :exec
+DELETE FROM financial_years WHERE id = ? AND firm_id = ? AND archived_at IS NULL;
+
+-- name: SetFinancialYearArchived :exec
+UPDATE financial_years SET archive_path = ?, archived_at = CURRENT_TIMESTAMP WHERE id = ?;
+
+-- name: ListPartyMovements :many
+SELECT p.id, p.name, p.location, p.credit_limit,
+    CAST(COALESCE(b.billed, 0) AS REAL) as billed, CAST(COALESCE(r.received, 0) AS REAL) as received
+FROM parties p
+LEFT JOIN (
+    SELECT party_id, SUM(amount) as billed
+    FROM sale_bills
+    WHERE party_id IS NOT NULL AND COALESCE(is_cash_sale, FALSE) = FALSE AND is_card_sale = FALSE
+      AND bill_date >= ? AND bill_date <= ?
+    GROUP BY party_id
+) b ON b.party_id = p.id
+LEFT JOIN (
+    SELECT t.party_id, SUM(t.amount) as received
+    FROM transactions t
+    WHERE t.category = 'receipt' AND t.transaction_date >= ? AND t.transaction_date <= ?
+      AND NOT EXISTS (SELECT 1 FROM cheques c WHERE c.transaction_id = t.id AND c.status = 'bounced')
+    GROUP BY t.party_id
+) r ON r.party_id = p.id
+WHERE p.firm_id = ? AND (b.billed IS NOT NULL OR r.received IS NOT NULL)
+ORDER BY p.name;
+
+-- name: ListOpeningEntries :many
+SELECT party_id, amount FROM sale_bills
+WHERE firm_id = ? AND bill_number = ? AND bill_date = ? AND party_id IS NOT NULL
+UNION ALL
+SELECT party_id, -amount FROM transactions
+WHERE firm_id = ? AND payment_mode = 'OPENING' AND transaction_date = ?;
+
+-- name: AddFinancialYearBalance :exec
+INSERT INTO financial_year_balances (financial_year_id, party_id, billed, received, opening, year_billed, year_received)
+VALUES (?, ?, ?, ?, ?, ?, ?);
+
+-- name: ListFinancialYearBalances :many
+SELECT p.id, p.name, p.location, p.credit_limit, b.billed, b.received, b.opening, b.year_billed, b.year_received
+FROM financial_year_balances b
+JOIN parties p ON p.id = b.party_id
+WHERE b.financial_year_id = ?
+ORDER BY p.name;
+
+-- name: SearchReceipts :many
+SELECT t.id, t.transaction_date, t.amount, t.payment_mode, t.narration,
+    p.name as party_name, p.location as party_location
+FROM transactions t
+JOIN parties p ON p.id = t.party_id
+WHERE t.firm_id = ? AND (t.narration LIKE ? OR p.name LIKE ?)
+ORDER BY t.transaction_date DESC, t.id DESC
+LIMIT 100;
+
+-- name: SearchSaleBills :many
+SELECT * FROM sale_bills
+WHERE firm_id = ? AND (party_name LIKE ? OR bill_number LIKE ?)
+ORDER BY bill_date DESC, id DESC
+LIMIT 100;
+
+-- name: PurgeTransactionTags :execrows
+DELETE FROM transaction_tags
+WHERE transaction_id IN (SELECT id FROM transactions WHERE firm_id = ? AND transaction_date < ?);
+
+-- name: PurgeTransactionSplits :exec
+DELETE FROM transaction_splits
+WHERE transaction_id IN (SELECT id FROM transactions WHERE firm_id = ? AND transaction_date < ?)
+    OR source_transaction_id IN (SELECT id FROM transactions WHERE firm_id = ? AND transaction_date < ?);
+
+-- name: PurgeTransactionMerges :exec
+DELETE FROM transaction_merges
+WHERE transaction_id IN (SELECT id FROM transactions WHERE firm_id = ? AND transaction_date < ?);
+
+-- name: PurgeTransactionAgents :exec
+DELETE FROM transaction_agents
+WHERE transaction_id IN (SELECT id FROM transactions WHERE firm_id = ? AND transaction_date < ?);
+
+-- name: PurgeBillAllocations :exec
+DELETE FROM bill_allocations
+WHERE transaction_id IN (SELECT id FROM transactions WHERE firm_id = ? AND transaction_date < ?)
+    OR sale_bill_id IN (SELECT id FROM sale_bills WHERE firm_id = ? AND bill_date < ?);
+
+-- name: PurgeCheques :execrows
+DELETE FROM cheques
+WHERE transaction_id IN (SELECT id FROM transactions WHERE firm_id = ? AND transaction_date < ?);
+
+-- name: PurgeTransactions :execrows
+DELETE FROM transactions WHERE firm_id = ? AND transaction_date < ?;
+
+-- name: PurgeSaleBills :execrows
+DELETE FROM sale_bills WHERE firm_id = ? AND bill_date < ?;
+
+-- name: PurgePOSSettlements :execrows
+DELETE FROM pos_settlements WHERE firm_id = ? AND credit_date < ?;
+
+-- name: PurgeReceiptBookTotals :exec
+DELETE FROM receipt_book_totals WHERE firm_id = ? AND end_date < ?;
+
+-- name: UpsertReceiptBookTotal :exec
+INSERT INTO receipt_book_totals (firm_id, start_date, end_date, total, suspense)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (firm_id, start_date, end_date) DO UPDATE SET
+    total = excluded.total,
+    suspense = excluded.suspense,
+    imported_at = CURRENT_TIMESTAMP;
+
+-- name: ListReceiptBookChecks :many
+SELECT id, start_date, end_date, total, suspense, imported_at, receipts, pos_settlements,
+    CAST(ROUND(receipts + pos_settlements - (total - suspense), 2) AS REAL) AS difference
+FROM (
+    SELECT b.id, b.start_date, b.end_date, b.total, b.suspense, b.imported_at,
+        CAST((SELECT COALESCE(SUM(t.amount), 0) FROM transactions t
+            WHERE t.firm_id = b.firm_id AND t.payment_mode IS NOT 'OPENING'
+              AND t.transaction_date BETWEEN b.start_date AND b.end_date) AS REAL) AS receipts,
+        CAST((SELECT COALESCE(SUM(s.amount), 0) FROM pos_settlements s
+            WHERE s.firm_id = b.firm_id
+              AND s.credit_date BETWEEN b.start_date AND b.end_date) AS REAL) AS pos_settlements
+    FROM receipt_book_totals b
+    WHERE b.firm_id = ? AND NOT EXISTS (
+        SELECT 1 FROM financial_years fy
+        WHERE fy.firm_id = b.firm_id AND fy.archived_at IS NOT NULL
+          AND b.end_date BETWEEN fy.start_date AND fy.end_date)
+)
+ORDER BY start_date DESC;
+
+-- name: CreateImportBatch :one
+INSERT INTO import_batches (
+    firm_id, lines, transactions, page_headers, skip_patterns, suspense, unattached,
+    identifiers, imported, duplicates, errors
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id;
+
+-- name: SetTransactionImportBatch :exec
+UPDATE transactions SET import_batch_id = ? WHERE id = ?;
+
+-- name: ListImportBatches :many
+SELECT * FROM import_batches
+WHERE firm_id = ?
+ORDER BY id DESC
+LIMIT ?;
+
+-- name: ListTransactionsInPeriod :many
+SELECT * FROM transactions
+WHERE firm_id = ? AND transaction_date BETWEEN ? AND ?
+ORDER BY transaction_date DESC, id DESC
+LIMIT ? OFFSET ?;
+
+-- name: ListSaleBillsInPeriod :many
+SELECT * FROM sale_bills
+WHERE firm_id = ? AND bill_date BETWEEN ? AND ?
+ORDER BY bill_date DESC, id DESC
+LIMIT ? OFFSET ?;
+
+-- name: DeleteGSTR1Return :exec
+DELETE FROM gstr1_invoices WHERE firm_id = ? AND return_period = ?;
+
+-- name: CreateGSTR1Invoice :exec
+INSERT INTO gstr1_invoices (
+    firm_id, return_period, invoice_number, invoice_date, value, receiver_gstin, receiver_name
+) VALUES (?, ?, ?, ?, ?, ?, ?);
+
+-- name: ListGSTR1Invoices :many
+SELECT * FROM gstr1_invoices
+WHERE firm_id = ? AND invoice_date BETWEEN ? AND ?
+ORDER BY invoice_date, id;
+
+-- name: ListGSTR1Returns :many
+SELECT return_period, COUNT(*) as invoices, CAST(COALESCE(SUM(value), 0) AS REAL) as total
+FROM gstr1_invoices
+WHERE firm_id = ?
+GROUP BY return_period
+ORDER BY return_period DESC;
+
+-- name: ListSaleBillsBetween :many
+SELECT * FROM sale_bills
+WHERE firm_id = ? AND bill_date BETWEEN ? AND ?
+ORDER BY bill_date, id;
+
+-- name: SearchChequesByNumber :many
+SELECT c.*, t.amount, t.transaction_date, t.payment_mode, p.id as party_id, p.name as party_name, p.location as party_location
+FROM cheques c
+JOIN transactions t ON t.id = c.transaction_id
+JOIN parties p ON p.id = t.party_id
+WHERE p.firm_id = ? AND ltrim(c.cheque_number, '0') LIKE ?
+ORDER BY c.received_date DESC, c.id DESC
+LIMIT 50;
+
+-- name: SearchTransactionsByReference :many
+SELECT t.*, p.name AS party_name, p.location AS party_location, b.imported_at AS batch_imported_at
+FROM transactions t
+JOIN parties p ON p.id = t.party_id
+LEFT JOIN import_batches b ON b.id = t.import_batch_id
+WHERE t.firm_id = ? AND replace(upper(t.narration), ' ', '') LIKE ?
+ORDER BY t.transaction_date DESC, t.id DESC
+LIMIT 50;
+
+-- name: CreatePendingImport :execrows
+INSERT INTO pending_imports (kind, filename, content, sender, subject, message_id, received_at, entries)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (message_id, filename) DO NOTHING;
+
+-- name: ListPendingImports :many
+SELECT id, kind, filename, sender, subject, received_at, entries
+FROM pending_imports
+WHERE status = 'pending'
+ORDER BY received_at, id;
+
+-- name: CountPendingImports :one
+SELECT COUNT(*) FROM pending_imports WHERE status = 'pending';
+
+-- name: GetPendingImport :one
+SELECT * FROM pending_imports WHERE id = ?;
+
+-- name: ResolvePendingImport :exec
+UPDATE pending_imports SET status = ?, resolved_at = CURRENT_TIMESTAMP
+WHERE id = ? AND status = 'pending';
@@ -3,17 +3,29 @@ CREATE TABLE parties (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     name TEXT NOT NULL,
     location TEXT,
-    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+    credit_limit REAL NOT NULL DEFAULT 0,
+    firm_id INTEGER NOT NULL DEFAULT 1 REFERENCES firms(id),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    email TEXT NOT NULL DEFAULT '',
+    phone TEXT NOT NULL DEFAULT '',
+    address TEXT NOT NULL DEFAULT '',
+    gstin TEXT NOT NULL DEFAULT '',
+    drug_license TEXT NOT NULL DEFAULT ''
 );
 
--- identifiers: normalized storage for UPI VPAs, phones, account numbers
+-- identifiers: normalized storage for UPI VPAs, phones, account numbers, with
+-- the dates of the first and last imported entries they appeared in
 CREATE TABLE identifiers (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     party_id INTEGER NOT NULL REFERENCES parties(id) ON DELETE CASCADE,
-    type TEXT NOT NULL CHECK (type IN ('upi_vpa', 'phone', 'account_number', 'ifsc', 'imps_name', 'bank_name', 'neft_name', 'cash_bank_code', 'cash_location', 'cash_agent_code', 'from_account', 'from_name', 'actcdep')),
+    type TEXT NOT NULL CHECK (type IN ('upi_vpa', 'phone', 'account_number', 'ifsc', 'imps_name', 'bank_name', 'neft_name', 'cash_bank_code', 'cash_location', 'cash_agent_code', 'from_account', 'from_name', 'actcdep', 'nach_mandate', 'gstin')),
     value TEXT NOT NULL,
+    firm_id INTEGER NOT NULL DEFAULT 1 REFERENCES firms(id),
+    first_seen DATE,
+    last_seen DATE,
+    hit_count INTEGER NOT NULL DEFAULT 0,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-    UNIQUE(type, value)
+    UNIQUE(firm_id, type, value)
 );
 
 -- transactions: imported receipt book entries
@@ -26,12 +38,22 @@ CREATE TABLE transactions (
     narration TEXT,
     cash_bank_code TEXT,
     cash_bank_location TEXT,
-    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+    category TEXT NOT NULL DEFAULT 'receipt' CHECK (category IN ('receipt', 'bank_charge', 'interest', 'internal_transfer', 'other')),
+    is_internal BOOLEAN NOT NULL DEFAULT FALSE,
+    account_id INTEGER REFERENCES accounts(id),
+    note TEXT NOT NULL DEFAULT '',
+    firm_id INTEGER NOT NULL DEFAULT 1 REFERENCES firms(id),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    import_batch_id INTEGER REFERENCES import_batches(id)
 );
 
 CREATE INDEX idx_identifiers_value ON identifiers(value);
 CREATE INDEX idx_identifiers_type_value ON identifiers(type, value);
 CREATE INDEX idx_transactions_party_id ON transactions(party_id);
+CREATE INDEX idx_transactions_category ON transactions(category);
+CREATE INDEX idx_transactions_account_id ON transactions(account_id);
+CREATE INDEX idx_transactions_firm_id ON transactions(firm_id);
+CREATE INDEX idx_parties_firm_id ON parties(firm_id);
 
 -- Unique constraint to prevent duplicate transactions
 CREATE UNIQUE INDEX idx_transactions_unique
@@ -45,10 +67,698 @@ CREATE TABLE sale_bills (
     party_name TEXT NOT NULL,
     amount REAL NOT NULL,
     is_cash_sale BOOLEAN DEFAULT FALSE,
-    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+    is_card_sale BOOLEAN NOT NULL DEFAULT FALSE,
+    party_id INTEGER REFERENCES parties(id) ON DELETE SET NULL,
+    firm_id INTEGER NOT NULL DEFAULT 1 REFERENCES firms(id),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    -- e-invoice Invoice Reference Number and acknowledgement, for bills
+    -- reported to the IRP; blank otherwise
+    irn TEXT NOT NULL DEFAULT '',
+    ack_number TEXT NOT NULL DEFAULT '',
+    ack_date DATE,
+    -- tax breakup of amount, which is gross; zero when the register has none
+    taxable_value REAL NOT NULL DEFAULT 0,
+    cgst REAL NOT NULL DEFAULT 0,
+    sgst REAL NOT NULL DEFAULT 0,
+    igst REAL NOT NULL DEFAULT 0
 );
 
 CREATE INDEX idx_sale_bills_amount ON sale_bills(amount);
 CREATE INDEX idx_sale_bills_date ON sale_bills(bill_date);
 CREATE INDEX idx_sale_bills_amount_date ON sale_bills(amount, bill_date);
-CREATE UNIQUE INDEX idx_sale_bills_unique ON sale_bills(bill_number, bill_date, party_name, amount);
+CREATE UNIQUE INDEX idx_sale_bills_unique ON sale_bills(firm_id, bill_number, bill_date, party_name, amount);
+CREATE INDEX idx_sale_bills_party_id ON sale_bills(party_id);
+CREATE INDEX idx_sale_bills_firm_id ON sale_bills(firm_id);
+CREATE INDEX idx_sale_bills_irn ON sale_bills(irn);
+
+-- pos_settlements: card machine settlements credited by the bank (FT-MESPOS)
+CREATE TABLE pos_settlements (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    credit_date DATE NOT NULL,
+    settlement_date DATE NOT NULL,
+    terminal_id TEXT,
+    batch_number TEXT,
+    amount REAL NOT NULL,
+    narration TEXT,
+    account_id INTEGER REFERENCES accounts(id),
+    firm_id INTEGER NOT NULL DEFAULT 1 REFERENCES firms(id),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_pos_settlements_settlement_date ON pos_settlements(settlement_date);
+CREATE UNIQUE INDEX idx_pos_settlements_unique ON pos_settlements(firm_id, credit_date, amount, narration);
+
+-- rules: user-editable classification rules applied during import
+CREATE TABLE rules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    narration_pattern TEXT NOT NULL DEFAULT '',
+    party_pattern TEXT NOT NULL DEFAULT '',
+    min_amount REAL NOT NULL DEFAULT 0,
+    max_amount REAL NOT NULL DEFAULT 0,
+    set_category TEXT NOT NULL DEFAULT '',
+    set_internal BOOLEAN NOT NULL DEFAULT FALSE,
+    set_party_name TEXT NOT NULL DEFAULT '',
+    priority INTEGER NOT NULL DEFAULT 100,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- parser_vocabulary: admin-editable word lists and patterns of the receipt book parser
+CREATE TABLE parser_vocabulary (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind TEXT NOT NULL CHECK (kind IN ('location', 'non_location_word', 'skip_pattern', 'narration_prefix')),
+    value TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- payment_mode_rules: admin-editable patterns that set an entry's payment mode from its narration, first match by priority wins
+CREATE TABLE payment_mode_rules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    pattern TEXT NOT NULL,
+    mode TEXT NOT NULL,
+    priority INTEGER NOT NULL DEFAULT 100,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- cheques: lifecycle of cheques received in the receipt book
+CREATE TABLE cheques (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    transaction_id INTEGER NOT NULL UNIQUE REFERENCES transactions(id) ON DELETE CASCADE,
+    cheque_number TEXT,
+    cheque_date DATE,
+    status TEXT NOT NULL DEFAULT 'received' CHECK (status IN ('received', 'deposited', 'cleared', 'bounced')),
+    received_date DATE NOT NULL,
+    deposited_date DATE,
+    cleared_date DATE,
+    bounced_date DATE,
+    notes TEXT,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_cheques_status ON cheques(status);
+
+-- accounts: the shop's bank accounts that receipts are credited to, with the
+-- balance from the last statement checked
+CREATE TABLE accounts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    bank TEXT NOT NULL,
+    account_number TEXT NOT NULL,
+    statement_balance REAL NOT NULL DEFAULT 0,
+    statement_date DATE,
+    firm_id INTEGER NOT NULL DEFAULT 1 REFERENCES firms(id),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(firm_id, bank, account_number)
+);
+
+-- statement_links: time-limited public links to a party's statement
+CREATE TABLE statement_links (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    party_id INTEGER NOT NULL REFERENCES parties(id) ON DELETE CASCADE,
+    token TEXT NOT NULL UNIQUE,
+    expires_at DATETIME NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_statement_links_party_id ON statement_links(party_id);
+
+-- search_history: recent narration searches with their top result
+CREATE TABLE search_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    narration TEXT NOT NULL,
+    top_party_id INTEGER REFERENCES parties(id) ON DELETE SET NULL,
+    top_party_name TEXT,
+    top_confidence REAL NOT NULL DEFAULT 0,
+    result_count INTEGER NOT NULL DEFAULT 0,
+    searched_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    firm_id INTEGER NOT NULL DEFAULT 1 REFERENCES firms(id),
+    UNIQUE(firm_id, narration)
+);
+
+CREATE INDEX idx_search_history_searched_at ON search_history(searched_at);
+
+-- saved_searches: named narration and sale bill amount searches listed on the home page
+CREATE TABLE saved_searches (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('narration', 'sale_bill')),
+    narration TEXT NOT NULL DEFAULT '',
+    amount REAL NOT NULL DEFAULT 0,
+    variation REAL NOT NULL DEFAULT 0,
+    from_date DATE,
+    till_date DATE,
+    firm_id INTEGER NOT NULL DEFAULT 1 REFERENCES firms(id),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- party_notes: free-text notes about a party, such as how they usually pay
+CREATE TABLE party_notes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    party_id INTEGER NOT NULL REFERENCES parties(id) ON DELETE CASCADE,
+    note TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_party_notes_party_id ON party_notes(party_id);
+
+-- transaction_tags: free-form labels on transactions such as advance or disputed
+CREATE TABLE transaction_tags (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    transaction_id INTEGER NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    tag TEXT NOT NULL,
+    UNIQUE(transaction_id, tag)
+);
+
+CREATE INDEX idx_transaction_tags_tag ON transaction_tags(tag);
+
+-- party_aliases: other names a party goes by in the sale bill register, stored upper-cased
+CREATE TABLE party_aliases (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    party_id INTEGER NOT NULL REFERENCES parties(id) ON DELETE CASCADE,
+    alias TEXT NOT NULL,
+    firm_id INTEGER NOT NULL DEFAULT 1 REFERENCES firms(id),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(firm_id, alias)
+);
+
+-- sync_log: offline queue items already applied, by the id the browser gave them, so a replayed item is not applied twice
+CREATE TABLE sync_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    client_id TEXT NOT NULL UNIQUE,
+    kind TEXT NOT NULL,
+    summary TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- firms: the GST registrations run from the shop; parties, transactions and bills belong to one
+CREATE TABLE firms (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    gstin TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    rounding_tolerance REAL NOT NULL DEFAULT 0
+);
+
+-- backups: database snapshots downloaded from the dashboard
+CREATE TABLE backups (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    filename TEXT NOT NULL,
+    size_bytes INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- offsite_backups: encrypted snapshots uploaded to the off-site bucket, and
+-- uploads that failed with their error
+CREATE TABLE offsite_backups (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    object_key TEXT NOT NULL DEFAULT '',
+    size_bytes INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- gstr1_invoices: B2B invoices filed in GSTR-1 returns, imported to check
+-- them against the sale bills
+CREATE TABLE gstr1_invoices (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    firm_id INTEGER NOT NULL REFERENCES firms(id),
+    return_period TEXT NOT NULL,
+    invoice_number TEXT NOT NULL,
+    invoice_date DATE NOT NULL,
+    value REAL NOT NULL,
+    receiver_gstin TEXT NOT NULL DEFAULT '',
+    receiver_name TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(firm_id, return_period, invoice_number)
+);
+
+CREATE INDEX idx_gstr1_invoices_date ON gstr1_invoices(firm_id, invoice_date);
+
+-- financial_years: closed April to March financial years of a firm. Their
+-- receipts and sale bills are read-only; archived years were moved to a
+-- separate database file and replaced by opening balance entries.
+CREATE TABLE financial_years (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    firm_id INTEGER NOT NULL REFERENCES firms(id),
+    label TEXT NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    closed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    archive_path TEXT NOT NULL DEFAULT '',
+    archived_at DATETIME,
+    UNIQUE(firm_id, label)
+);
+
+-- financial_year_balances: each party's credit sales and receipts up to the
+-- end of a financial year, as they stood when it was closed, and for the
+-- closing report its balance brought into the year and its credit sales and
+-- receipts in the year (NULL for years closed before the closing report)
+CREATE TABLE financial_year_balances (
+    financial_year_id INTEGER NOT NULL REFERENCES financial_years(id) ON DELETE CASCADE,
+    party_id INTEGER NOT NULL REFERENCES parties(id) ON DELETE CASCADE,
+    billed REAL NOT NULL,
+    received REAL NOT NULL,
+    opening REAL,
+    year_billed REAL,
+    year_received REAL,
+    PRIMARY KEY (financial_year_id, party_id)
+);
+
+-- receipt_book_totals: the closing SUB TOTAL of each imported receipt book
+-- period, to check the entries imported from it against. SUSPENSE A/C
+-- entries are in the total but are not imported.
+CREATE TABLE receipt_book_totals (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    firm_id INTEGER NOT NULL REFERENCES firms(id),
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    total REAL NOT NULL,
+    suspense REAL NOT NULL DEFAULT 0,
+    imported_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(firm_id, start_date, end_date)
+);
+
+-- import_batches: how the lines of each imported receipt book were parsed and
+-- how many identifiers its entries gave, to notice a narration format change
+CREATE TABLE import_batches (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    firm_id INTEGER NOT NULL REFERENCES firms(id),
+    lines INTEGER NOT NULL,
+    transactions INTEGER NOT NULL,
+    page_headers INTEGER NOT NULL,
+    skip_patterns INTEGER NOT NULL,
+    suspense INTEGER NOT NULL,
+    unattached INTEGER NOT NULL,
+    identifiers INTEGER NOT NULL,
+    imported INTEGER NOT NULL,
+    duplicates INTEGER NOT NULL,
+    errors INTEGER NOT NULL,
+    imported_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- identifier_conflicts: an identifier found in an entry of a party other than
+-- the one it is linked to, kept linked until someone decides which party it
+-- belongs to. Each claiming party is reported once per identifier.
+CREATE TABLE identifier_conflicts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    firm_id INTEGER NOT NULL REFERENCES firms(id),
+    type TEXT NOT NULL,
+    value TEXT NOT NULL,
+    claimed_party_id INTEGER NOT NULL REFERENCES parties(id) ON DELETE CASCADE,
+    narration TEXT NOT NULL DEFAULT '',
+    resolution TEXT CHECK (resolution IN ('kept', 'moved')),
+    found_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    resolved_at DATETIME,
+    UNIQUE(firm_id, type, value, claimed_party_id)
+);
+
+-- identifier_history: each time an identifier was linked to a party and why,
+-- to reconstruct how an identifier came to match the party it does. Party
+-- IDs are kept as they were, so rows outlive a merged party.
+CREATE TABLE identifier_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    firm_id INTEGER NOT NULL REFERENCES firms(id),
+    type TEXT NOT NULL,
+    value TEXT NOT NULL,
+    party_id INTEGER NOT NULL,
+    previous_party_id INTEGER,
+    cause TEXT NOT NULL CHECK (cause IN ('import', 'seed', 'manual', 'merge', 'assign')),
+    detail TEXT NOT NULL DEFAULT '',
+    assigned_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_identifier_history_value ON identifier_history(firm_id, type, value);
+
+-- party_merges: parties merged into another, so links, exports and rules that
+-- name the merged party reach the party that survived
+CREATE TABLE party_merges (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    firm_id INTEGER NOT NULL REFERENCES firms(id),
+    merged_party_id INTEGER NOT NULL UNIQUE,
+    merged_name TEXT NOT NULL,
+    survivor_party_id INTEGER NOT NULL,
+    merged_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_party_merges_survivor ON party_merges(survivor_party_id);
+
+-- merge_suggestions: pairs of parties the duplicate scan found likely to be
+-- the same customer, for someone to merge or dismiss. A dismissed pair is not
+-- suggested again.
+CREATE TABLE merge_suggestions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    firm_id INTEGER NOT NULL REFERENCES firms(id),
+    party_id INTEGER NOT NULL REFERENCES parties(id) ON DELETE CASCADE,
+    other_party_id INTEGER NOT NULL REFERENCES parties(id) ON DELETE CASCADE,
+    score REAL NOT NULL,
+    reasons TEXT NOT NULL,
+    dismissed_at DATETIME,
+    found_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(party_id, other_party_id)
+);
+
+-- transaction_splits: receipts divided across parties after import, one row
+-- per share. The source transaction keeps the first share and each other
+-- share is a new transaction. The source's party and amount from before the
+-- split are kept, so it can be traced and a re-imported book still skips it.
+CREATE TABLE transaction_splits (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    firm_id INTEGER NOT NULL REFERENCES firms(id),
+    source_transaction_id INTEGER NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    transaction_id INTEGER NOT NULL UNIQUE REFERENCES transactions(id) ON DELETE CASCADE,
+    original_party_id INTEGER NOT NULL,
+    original_amount REAL NOT NULL,
+    split_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_transaction_splits_source ON transaction_splits(source_transaction_id);
+
+-- transaction_merges: transactions found to be the same payment as another,
+-- such as a receipt entered from the receipt book and again from the bank
+-- statement. The duplicate is removed and kept here as it was, narration and
+-- all, under the transaction it was merged into, so a re-imported book still
+-- skips it.
+CREATE TABLE transaction_merges (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    firm_id INTEGER NOT NULL REFERENCES firms(id),
+    transaction_id INTEGER NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    superseded_transaction_id INTEGER NOT NULL UNIQUE,
+    superseded_party_id INTEGER NOT NULL,
+    superseded_amount REAL NOT NULL,
+    superseded_date DATE NOT NULL,
+    superseded_payment_mode TEXT,
+    superseded_narration TEXT,
+    merged_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_transaction_merges_transaction ON transaction_merges(transaction_id);
+
+-- agents: field agents who collect receipts. A cash deposit whose narration
+-- carries an agent's code, or else made at an agent's deposit location, is
+-- assigned to the agent on import. Agents are paid commission_percent of
+-- what they collect.
+CREATE TABLE agents (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    firm_id INTEGER NOT NULL DEFAULT 1 REFERENCES firms(id),
+    name TEXT NOT NULL,
+    code TEXT NOT NULL DEFAULT '',
+    location TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    commission_percent REAL NOT NULL DEFAULT 0,
+    UNIQUE(firm_id, name)
+);
+
+-- transaction_agents: the agent who collected a receipt, and how it was
+-- assigned: by the agent code or deposit location, or by hand
+CREATE TABLE transaction_agents (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    transaction_id INTEGER NOT NULL UNIQUE REFERENCES transactions(id) ON DELETE CASCADE,
+    agent_id INTEGER NOT NULL REFERENCES agents(id) ON DELETE CASCADE,
+    source TEXT NOT NULL CHECK (source IN ('code', 'location', 'manual')),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_transaction_agents_agent ON transaction_agents(agent_id);
+
+-- routes: the delivery and collection routes parties are visited on
+CREATE TABLE routes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    firm_id INTEGER NOT NULL DEFAULT 1 REFERENCES firms(id),
+    name TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(firm_id, name)
+);
+
+-- route_locations: the party locations on each route, in upper case. A
+-- location is on one route; parties are on the route of their location.
+CREATE TABLE route_locations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    firm_id INTEGER NOT NULL DEFAULT 1 REFERENCES firms(id),
+    route_id INTEGER NOT NULL REFERENCES routes(id) ON DELETE CASCADE,
+    location TEXT NOT NULL,
+    UNIQUE(firm_id, location)
+);
+
+CREATE INDEX idx_route_locations_route ON route_locations(route_id);
+
+-- bill_allocations: receipt amounts applied to credit bills by hand. They
+-- never exceed the bill or the receipt; what is left of each receipt settles
+-- the oldest bills still due.
+CREATE TABLE bill_allocations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    sale_bill_id INTEGER NOT NULL REFERENCES sale_bills(id) ON DELETE CASCADE,
+    transaction_id INTEGER NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    amount REAL NOT NULL CHECK (amount > 0),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(sale_bill_id, transaction_id)
+);
+
+CREATE INDEX idx_bill_allocations_transaction ON bill_allocations(transaction_id);
+
+-- statement_emails: the send log of statements emailed to parties, with the
+-- period each covered. A send that failed keeps its error.
+CREATE TABLE statement_emails (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    firm_id INTEGER NOT NULL DEFAULT 1 REFERENCES firms(id),
+    party_id INTEGER NOT NULL REFERENCES parties(id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    from_date DATE NOT NULL,
+    till_date DATE NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    sent_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_statement_emails_party ON statement_emails(party_id);
+
+-- sms_acknowledgements: text messages acknowledging a party's receipt, queued
+-- when the receipt is imported and sent in the background. One not yet sent
+-- has no sent_at; a failed attempt keeps its error.
+CREATE TABLE sms_acknowledgements (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    firm_id INTEGER NOT NULL DEFAULT 1 REFERENCES firms(id),
+    party_id INTEGER NOT NULL REFERENCES parties(id) ON DELETE CASCADE,
+    transaction_id INTEGER NOT NULL UNIQUE REFERENCES transactions(id) ON DELETE CASCADE,
+    phone TEXT NOT NULL,
+    message TEXT NOT NULL DEFAULT '',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    sent_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_sms_acknowledgements_party ON sms_acknowledgements(party_id);
+
+-- pending_imports: statements and bill exports attached to email read from
+-- the inbox, waiting to be previewed and imported, or dismissed. kind is
+-- 'receipts' or 'sale-bills', and entries how many the parser read when the
+-- email came in.
+CREATE TABLE pending_imports (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind TEXT NOT NULL,
+    filename TEXT NOT NULL,
+    content BLOB NOT NULL,
+    sender TEXT NOT NULL DEFAULT '',
+    subject TEXT NOT NULL DEFAULT '',
+    message_id TEXT NOT NULL,
+    received_at DATETIME NOT NULL,
+    entries INTEGER NOT NULL DEFAULT 0,
+    status TEXT NOT NULL DEFAULT 'pending',
+    resolved_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(message_id, filename)
+);
+
+-- party_balances: each party's credit billed and receipts received, kept by
+-- the summary triggers below so balance reports need not add up every entry
+CREATE TABLE party_balances (
+    party_id INTEGER PRIMARY KEY,
+    receipt_count INTEGER NOT NULL DEFAULT 0,
+    billed REAL NOT NULL DEFAULT 0,
+    received REAL NOT NULL DEFAULT 0
+);
+
+-- receipt_month_totals: receipts by firm, month (YYYY-MM) and payment mode,
+-- kept by the summary triggers below
+CREATE TABLE receipt_month_totals (
+    firm_id INTEGER NOT NULL REFERENCES firms(id),
+    month TEXT NOT NULL,
+    payment_mode TEXT NOT NULL,
+    receipt_count INTEGER NOT NULL DEFAULT 0,
+    amount REAL NOT NULL DEFAULT 0,
+    PRIMARY KEY (firm_id, month, payment_mode)
+);
+
+-- Receipts and sale bills of closed years are read-only; opening balance
+-- entries are added when an earlier year is archived, and an archived year's
+-- entries are deleted once copied out
+CREATE TRIGGER transactions_closed_year_insert BEFORE INSERT ON transactions
+WHEN NEW.payment_mode IS NOT 'OPENING' AND EXISTS (
+    SELECT 1 FROM financial_years fy
+    WHERE fy.firm_id = NEW.firm_id AND NEW.transaction_date BETWEEN fy.start_date AND fy.end_date)
+BEGIN
+    SELECT RAISE(ABORT, 'entry is in a closed financial year');
+END;
+
+CREATE TRIGGER transactions_closed_year_update BEFORE UPDATE ON transactions
+WHEN EXISTS (
+    SELECT 1 FROM financial_years fy
+    WHERE (fy.firm_id = OLD.firm_id AND OLD.transaction_date BETWEEN fy.start_date AND fy.end_date)
+       OR (fy.firm_id = NEW.firm_id AND NEW.transaction_date BETWEEN fy.start_date AND fy.end_date))
+BEGIN
+    SELECT RAISE(ABORT, 'entry is in a closed financial year');
+END;
+
+CREATE TRIGGER transactions_closed_year_delete BEFORE DELETE ON transactions
+WHEN EXISTS (
+    SELECT 1 FROM financial_years fy
+    WHERE fy.firm_id = OLD.firm_id AND OLD.transaction_date BETWEEN fy.start_date AND fy.end_date
+      AND fy.archived_at IS NULL)
+BEGIN
+    SELECT RAISE(ABORT, 'entry is in a closed financial year');
+END;
+
+CREATE TRIGGER sale_bills_closed_year_insert BEFORE INSERT ON sale_bills
+WHEN NEW.bill_number NOT LIKE 'OPENING %' AND EXISTS (
+    SELECT 1 FROM financial_years fy
+    WHERE fy.firm_id = NEW.firm_id AND NEW.bill_date BETWEEN fy.start_date AND fy.end_date)
+BEGIN
+    SELECT RAISE(ABORT, 'bill is in a closed financial year');
+END;
+
+CREATE TRIGGER sale_bills_closed_year_update BEFORE UPDATE ON sale_bills
+WHEN EXISTS (
+    SELECT 1 FROM financial_years fy
+    WHERE (fy.firm_id = OLD.firm_id AND OLD.bill_date BETWEEN fy.start_date AND fy.end_date)
+       OR (fy.firm_id = NEW.firm_id AND NEW.bill_date BETWEEN fy.start_date AND fy.end_date))
+BEGIN
+    SELECT RAISE(ABORT, 'bill is in a closed financial year');
+END;
+
+CREATE TRIGGER sale_bills_closed_year_delete BEFORE DELETE ON sale_bills
+WHEN EXISTS (
+    SELECT 1 FROM financial_years fy
+    WHERE fy.firm_id = OLD.firm_id AND OLD.bill_date BETWEEN fy.start_date AND fy.end_date
+      AND fy.archived_at IS NULL)
+BEGIN
+    SELECT RAISE(ABORT, 'bill is in a closed financial year');
+END;
+
+-- Summary triggers: receipts (less bounced cheques) and credit sale bills are
+-- added to and taken off party_balances and receipt_month_totals as they are
+-- written
+CREATE TRIGGER summary_transactions_insert AFTER INSERT ON transactions
+WHEN NEW.category = 'receipt' AND NOT EXISTS (SELECT 1 FROM cheques WHERE transaction_id = NEW.id AND status = 'bounced')
+BEGIN
+    INSERT INTO party_balances (party_id, receipt_count, received) VALUES (NEW.party_id, 1, NEW.amount)
+        ON CONFLICT (party_id) DO UPDATE SET receipt_count = receipt_count + excluded.receipt_count, received = received + excluded.received;
+    INSERT INTO receipt_month_totals (firm_id, month, payment_mode, receipt_count, amount)
+        VALUES (NEW.firm_id, substr(NEW.transaction_date, 1, 7), COALESCE(NEW.payment_mode, ''), 1, NEW.amount)
+        ON CONFLICT (firm_id, month, payment_mode) DO UPDATE SET receipt_count = receipt_count + excluded.receipt_count, amount = amount + excluded.amount;
+END;
+
+CREATE TRIGGER summary_transactions_delete AFTER DELETE ON transactions
+WHEN OLD.category = 'receipt' AND NOT EXISTS (SELECT 1 FROM cheques WHERE transaction_id = OLD.id AND status = 'bounced')
+BEGIN
+    INSERT INTO party_balances (party_id, receipt_count, received) VALUES (OLD.party_id, -1, -OLD.amount)
+        ON CONFLICT (party_id) DO UPDATE SET receipt_count = receipt_count + excluded.receipt_count, received = received + excluded.received;
+    INSERT INTO receipt_month_totals (firm_id, month, payment_mode, receipt_count, amount)
+        VALUES (OLD.firm_id, substr(OLD.transaction_date, 1, 7), COALESCE(OLD.payment_mode, ''), -1, -OLD.amount)
+        ON CONFLICT (firm_id, month, payment_mode) DO UPDATE SET receipt_count = receipt_count + excluded.receipt_count, amount = amount + excluded.amount;
+END;
+
+CREATE TRIGGER summary_transactions_update_old AFTER UPDATE OF party_id, firm_id, amount, transaction_date, payment_mode, category ON transactions
+WHEN OLD.category = 'receipt' AND NOT EXISTS (SELECT 1 FROM cheques WHERE transaction_id = OLD.id AND status = 'bounced')
+BEGIN
+    INSERT INTO party_balances (party_id, receipt_count, received) VALUES (OLD.party_id, -1, -OLD.amount)
+        ON CONFLICT (party_id) DO UPDATE SET receipt_count = receipt_count + excluded.receipt_count, received = received + excluded.received;
+    INSERT INTO receipt_month_totals (firm_id, month, payment_mode, receipt_count, amount)
+        VALUES (OLD.firm_id, substr(OLD.transaction_date, 1, 7), COALESCE(OLD.payment_mode, ''), -1, -OLD.amount)
+        ON CONFLICT (firm_id, month, payment_mode) DO UPDATE SET receipt_count = receipt_count + excluded.receipt_count, amount = amount + excluded.amount;
+END;
+
+CREATE TRIGGER summary_transactions_update_new AFTER UPDATE OF party_id, firm_id, amount, transaction_date, payment_mode, category ON transactions
+WHEN NEW.category = 'receipt' AND NOT EXISTS (SELECT 1 FROM cheques WHERE transaction_id = NEW.id AND status = 'bounced')
+BEGIN
+    INSERT INTO party_balances (party_id, receipt_count, received) VALUES (NEW.party_id, 1, NEW.amount)
+        ON CONFLICT (party_id) DO UPDATE SET receipt_count = receipt_count + excluded.receipt_count, received = received + excluded.received;
+    INSERT INTO receipt_month_totals (firm_id, month, payment_mode, receipt_count, amount)
+        VALUES (NEW.firm_id, substr(NEW.transaction_date, 1, 7), COALESCE(NEW.payment_mode, ''), 1, NEW.amount)
+        ON CONFLICT (firm_id, month, payment_mode) DO UPDATE SET receipt_count = receipt_count + excluded.receipt_count, amount = amount + excluded.amount;
+END;
+
+CREATE TRIGGER summary_cheques_insert AFTER INSERT ON cheques
+WHEN NEW.status = 'bounced'
+BEGIN
+    INSERT INTO party_balances (party_id, receipt_count, received)
+        SELECT t.party_id, -1, -t.amount FROM transactions t WHERE t.id = NEW.transaction_id AND t.category = 'receipt'
+        ON CONFLICT (party_id) DO UPDATE SET receipt_count = receipt_count + excluded.receipt_count, received = received + excluded.received;
+    INSERT INTO receipt_month_totals (firm_id, month, payment_mode, receipt_count, amount)
+        SELECT t.firm_id, substr(t.transaction_date, 1, 7), COALESCE(t.payment_mode, ''), -1, -t.amount FROM transactions t WHERE t.id = NEW.transaction_id AND t.category = 'receipt'
+        ON CONFLICT (firm_id, month, payment_mode) DO UPDATE SET receipt_count = receipt_count + excluded.receipt_count, amount = amount + excluded.amount;
+END;
+
+CREATE TRIGGER summary_cheques_delete AFTER DELETE ON cheques
+WHEN OLD.status = 'bounced'
+BEGIN
+    INSERT INTO party_balances (party_id, receipt_count, received)
+        SELECT t.party_id, 1, t.amount FROM transactions t WHERE t.id = OLD.transaction_id AND t.category = 'receipt'
+        ON CONFLICT (party_id) DO UPDATE SET receipt_count = receipt_count + excluded.receipt_count, received = received + excluded.received;
+    INSERT INTO receipt_month_totals (firm_id, month, payment_mode, receipt_count, amount)
+        SELECT t.firm_id, substr(t.transaction_date, 1, 7), COALESCE(t.payment_mode, ''), 1, t.amount FROM transactions t WHERE t.id = OLD.transaction_id AND t.category = 'receipt'
+        ON CONFLICT (firm_id, month, payment_mode) DO UPDATE SET receipt_count = receipt_count + excluded.receipt_count, amount = amount + excluded.amount;
+END;
+
+CREATE TRIGGER summary_cheques_update_old AFTER UPDATE OF status, transaction_id ON cheques
+WHEN OLD.status = 'bounced'
+BEGIN
+    INSERT INTO party_balances (party_id, receipt_count, received)
+        SELECT t.party_id, 1, t.amount FROM transactions t WHERE t.id = OLD.transaction_id AND t.category = 'receipt'
+        ON CONFLICT (party_id) DO UPDATE SET receipt_count = receipt_count + excluded.receipt_count, received = received + excluded.received;
+    INSERT INTO receipt_month_totals (firm_id, month, payment_mode, receipt_count, amount)
+        SELECT t.firm_id, substr(t.transaction_date, 1, 7), COALESCE(t.payment_mode, ''), 1, t.amount FROM transactions t WHERE t.id = OLD.transaction_id AND t.category = 'receipt'
+        ON CONFLICT (firm_id, month, payment_mode) DO UPDATE SET receipt_count = receipt_count + excluded.receipt_count, amount = amount + excluded.amount;
+END;
+
+CREATE TRIGGER summary_cheques_update_new AFTER UPDATE OF status, transaction_id ON cheques
+WHEN NEW.status = 'bounced'
+BEGIN
+    INSERT INTO party_balances (party_id, receipt_count, received)
+        SELECT t.party_id, -1, -t.amount FROM transactions t WHERE t.id = NEW.transaction_id AND t.category = 'receipt'
+        ON CONFLICT (party_id) DO UPDATE SET receipt_count = receipt_count + excluded.receipt_count, received = received + excluded.received;
+    INSERT INTO receipt_month_totals (firm_id, month, payment_mode, receipt_count, amount)
+        SELECT t.firm_id, substr(t.transaction_date, 1, 7), COALESCE(t.payment_mode, ''), -1, -t.amount FROM transactions t WHERE t.id = NEW.transaction_id AND t.category = 'receipt'
+        ON CONFLICT (firm_id, month, payment_mode) DO UPDATE SET receipt_count = receipt_count + excluded.receipt_count, amount = amount + excluded.amount;
+END;
+
+CREATE TRIGGER summary_sale_bills_insert AFTER INSERT ON sale_bills
+WHEN NEW.party_id IS NOT NULL AND COALESCE(NEW.is_cash_sale, FALSE) = FALSE AND NEW.is_card_sale = FALSE
+BEGIN
+    INSERT INTO party_balances (party_id, billed) VALUES (NEW.party_id, NEW.amount)
+        ON CONFLICT (party_id) DO UPDATE SET billed = billed + excluded.billed;
+END;
+
+CREATE TRIGGER summary_sale_bills_delete AFTER DELETE ON sale_bills
+WHEN OLD.party_id IS NOT NULL AND COALESCE(OLD.is_cash_sale, FALSE) = FALSE AND OLD.is_card_sale = FALSE
+BEGIN
+    INSERT INTO party_balances (party_id, billed) VALUES (OLD.party_id, -OLD.amount)
+        ON CONFLICT (party_id) DO UPDATE SET billed = billed + excluded.billed;
+END;
+
+CREATE TRIGGER summary_sale_bills_update_old AFTER UPDATE OF party_id, amount, is_cash_sale, is_card_sale ON sale_bills
+WHEN OLD.party_id IS NOT NULL AND COALESCE(OLD.is_cash_sale, FALSE) = FALSE AND OLD.is_card_sale = FALSE
+BEGIN
+    INSERT INTO party_balances (party_id, billed) VALUES (OLD.party_id, -OLD.amount)
+        ON CONFLICT (party_id) DO UPDATE SET billed = billed + excluded.billed;
+END;
+
+CREATE TRIGGER summary_sale_bills_update_new AFTER UPDATE OF party_id, amount, is_cash_sale, is_card_sale ON sale_bills
+WHEN NEW.party_id IS NOT NULL AND COALESCE(NEW.is_cash_sale, FALSE) = FALSE AND NEW.is_card_sale = FALSE
+BEGIN
+    INSERT INTO party_balances (party_id, billed) VALUES (NEW.party_id, NEW.amount)
+        ON CONFLICT (party_id) DO UPDATE SET billed = billed + excluded.billed;
+END;
+
+CREATE TRIGGER summary_parties_delete AFTER DELETE ON parties
+BEGIN
+    DELETE FROM party_balances WHERE party_id = OLD.id;
+END;
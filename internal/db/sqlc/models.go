@@ -9,31 +9,371 @@ import (
 	"time"
 )
 
+type Account struct {
+	ID               int64
+	Bank             string
+	AccountNumber    string
+	StatementBalance float64
+	StatementDate    sql.NullTime
+	FirmID           int64
+	CreatedAt        sql.NullTime
+}
+
+type Agent struct {
+	ID                int64
+	FirmID            int64
+	Name              string
+	Code              string
+	Location          string
+	CreatedAt         sql.NullTime
+	CommissionPercent float64
+}
+
+type Backup struct {
+	ID        int64
+	Filename  string
+	SizeBytes int64
+	CreatedAt sql.NullTime
+}
+
+type BillAllocation struct {
+	ID            int64
+	SaleBillID    int64
+	TransactionID int64
+	Amount        float64
+	CreatedAt     sql.NullTime
+}
+
+type Cheque struct {
+	ID            int64
+	TransactionID int64
+	ChequeNumber  sql.NullString
+	ChequeDate    sql.NullTime
+	Status        string
+	ReceivedDate  time.Time
+	DepositedDate sql.NullTime
+	ClearedDate   sql.NullTime
+	BouncedDate   sql.NullTime
+	Notes         sql.NullString
+	UpdatedAt     sql.NullTime
+}
+
+type Firm struct {
+	ID                int64
+	Name              string
+	Gstin             string
+	CreatedAt         sql.NullTime
+	RoundingTolerance float64
+}
+
+type FinancialYear struct {
+	ID          int64
+	FirmID      int64
+	Label       string
+	StartDate   time.Time
+	EndDate     time.Time
+	ClosedAt    sql.NullTime
+	ArchivePath string
+	ArchivedAt  sql.NullTime
+}
+
+type FinancialYearBalance struct {
+	FinancialYearID int64
+	PartyID         int64
+	Billed          float64
+	Received        float64
+	Opening         sql.NullFloat64
+	YearBilled      sql.NullFloat64
+	YearReceived    sql.NullFloat64
+}
+
+type Gstr1Invoice struct {
+	ID            int64
+	FirmID        int64
+	ReturnPeriod  string
+	InvoiceNumber string
+	InvoiceDate   time.Time
+	Value         float64
+	ReceiverGstin string
+	ReceiverName  string
+	CreatedAt     sql.NullTime
+}
+
 type Identifier struct {
 	ID        int64
 	PartyID   int64
 	Type      string
 	Value     string
+	FirmID    int64
+	FirstSeen sql.NullTime
+	LastSeen  sql.NullTime
+	HitCount  int64
+	CreatedAt sql.NullTime
+}
+
+type IdentifierConflict struct {
+	ID             int64
+	FirmID         int64
+	Type           string
+	Value          string
+	ClaimedPartyID int64
+	Narration      string
+	Resolution     sql.NullString
+	FoundAt        sql.NullTime
+	ResolvedAt     sql.NullTime
+}
+
+type IdentifierHistory struct {
+	ID              int64
+	FirmID          int64
+	Type            string
+	Value           string
+	PartyID         int64
+	PreviousPartyID sql.NullInt64
+	Cause           string
+	Detail          string
+	AssignedAt      sql.NullTime
+}
+
+type ImportBatch struct {
+	ID           int64
+	FirmID       int64
+	Lines        int64
+	Transactions int64
+	PageHeaders  int64
+	SkipPatterns int64
+	Suspense     int64
+	Unattached   int64
+	Identifiers  int64
+	Imported     int64
+	Duplicates   int64
+	Errors       int64
+	ImportedAt   sql.NullTime
+}
+
+type MergeSuggestion struct {
+	ID           int64
+	FirmID       int64
+	PartyID      int64
+	OtherPartyID int64
+	Score        float64
+	Reasons      string
+	DismissedAt  sql.NullTime
+	FoundAt      sql.NullTime
+}
+
+type OffsiteBackup struct {
+	ID        int64
+	ObjectKey string
+	SizeBytes int64
+	Error     string
+	CreatedAt sql.NullTime
+}
+
+type ParserVocabulary struct {
+	ID        int64
+	Kind      string
+	Value     string
 	CreatedAt sql.NullTime
 }
 
 type Party struct {
+	ID          int64
+	Name        string
+	Location    sql.NullString
+	CreditLimit float64
+	FirmID      int64
+	CreatedAt   sql.NullTime
+	Email       string
+	Phone       string
+	Address     string
+	Gstin       string
+	DrugLicense string
+}
+
+type PartyAliase struct {
 	ID        int64
-	Name      string
-	Location  sql.NullString
+	PartyID   int64
+	Alias     string
+	FirmID    int64
 	CreatedAt sql.NullTime
 }
 
-type SaleBill struct {
+type PartyMerge struct {
+	ID              int64
+	FirmID          int64
+	MergedPartyID   int64
+	MergedName      string
+	SurvivorPartyID int64
+	MergedAt        sql.NullTime
+}
+
+type PartyNote struct {
+	ID        int64
+	PartyID   int64
+	Note      string
+	CreatedAt time.Time
+}
+
+type PaymentModeRule struct {
+	ID        int64
+	Pattern   string
+	Mode      string
+	Priority  int64
+	CreatedAt sql.NullTime
+}
+
+type PendingImport struct {
 	ID         int64
-	BillNumber string
-	BillDate   time.Time
-	PartyName  string
-	Amount     float64
-	IsCashSale sql.NullBool
+	Kind       string
+	Filename   string
+	Content    []byte
+	Sender     string
+	Subject    string
+	MessageID  string
+	ReceivedAt time.Time
+	Entries    int64
+	Status     string
+	ResolvedAt sql.NullTime
 	CreatedAt  sql.NullTime
 }
 
+type PosSettlement struct {
+	ID             int64
+	CreditDate     time.Time
+	SettlementDate time.Time
+	TerminalID     sql.NullString
+	BatchNumber    sql.NullString
+	Amount         float64
+	Narration      sql.NullString
+	AccountID      sql.NullInt64
+	FirmID         int64
+	CreatedAt      sql.NullTime
+}
+
+type ReceiptBookTotal struct {
+	ID         int64
+	FirmID     int64
+	StartDate  time.Time
+	EndDate    time.Time
+	Total      float64
+	Suspense   float64
+	ImportedAt sql.NullTime
+}
+
+type Route struct {
+	ID        int64
+	FirmID    int64
+	Name      string
+	CreatedAt sql.NullTime
+}
+
+type RouteLocation struct {
+	ID       int64
+	FirmID   int64
+	RouteID  int64
+	Location string
+}
+
+type Rule struct {
+	ID               int64
+	Name             string
+	NarrationPattern string
+	PartyPattern     string
+	MinAmount        float64
+	MaxAmount        float64
+	SetCategory      string
+	SetInternal      bool
+	SetPartyName     string
+	Priority         int64
+	Enabled          bool
+	CreatedAt        sql.NullTime
+}
+
+type SaleBill struct {
+	ID           int64
+	BillNumber   string
+	BillDate     time.Time
+	PartyName    string
+	Amount       float64
+	IsCashSale   sql.NullBool
+	IsCardSale   bool
+	PartyID      sql.NullInt64
+	FirmID       int64
+	CreatedAt    sql.NullTime
+	Irn          string
+	AckNumber    string
+	AckDate      sql.NullTime
+	TaxableValue float64
+	Cgst         float64
+	Sgst         float64
+	Igst         float64
+}
+
+type SavedSearche struct {
+	ID        int64
+	Name      string
+	Kind      string
+	Narration string
+	Amount    float64
+	Variation float64
+	FromDate  sql.NullTime
+	TillDate  sql.NullTime
+	FirmID    int64
+	CreatedAt sql.NullTime
+}
+
+type SearchHistory struct {
+	ID            int64
+	Narration     string
+	TopPartyID    sql.NullInt64
+	TopPartyName  sql.NullString
+	TopConfidence float64
+	ResultCount   int64
+	SearchedAt    time.Time
+	FirmID        int64
+}
+
+type SmsAcknowledgement struct {
+	ID            int64
+	FirmID        int64
+	PartyID       int64
+	TransactionID int64
+	Phone         string
+	Message       string
+	Attempts      int64
+	Error         string
+	SentAt        sql.NullTime
+	CreatedAt     sql.NullTime
+}
+
+type StatementEmail struct {
+	ID       int64
+	FirmID   int64
+	PartyID  int64
+	Email    string
+	FromDate time.Time
+	TillDate time.Time
+	Error    string
+	SentAt   sql.NullTime
+}
+
+type StatementLink struct {
+	ID        int64
+	PartyID   int64
+	Token     string
+	ExpiresAt time.Time
+	CreatedAt sql.NullTime
+}
+
+type SyncLog struct {
+	ID        int64
+	ClientID  string
+	Kind      string
+	Summary   string
+	CreatedAt sql.NullTime
+}
+
 type Transaction struct {
 	ID               int64
 	PartyID          int64
@@ -43,5 +383,48 @@ type Transaction struct {
 	Narration        sql.NullString
 	CashBankCode     sql.NullString
 	CashBankLocation sql.NullString
+	Category         string
+	IsInternal       bool
+	AccountID        sql.NullInt64
+	Note             string
+	FirmID           int64
 	CreatedAt        sql.NullTime
+	ImportBatchID    sql.NullInt64
+}
+
+type TransactionAgent struct {
+	ID            int64
+	TransactionID int64
+	AgentID       int64
+	Source        string
+	CreatedAt     sql.NullTime
+}
+
+type TransactionMerge struct {
+	ID                      int64
+	FirmID                  int64
+	TransactionID           int64
+	SupersededTransactionID int64
+	SupersededPartyID       int64
+	SupersededAmount        float64
+	SupersededDate          time.Time
+	SupersededPaymentMode   sql.NullString
+	SupersededNarration     sql.NullString
+	MergedAt                sql.NullTime
+}
+
+type TransactionSplit struct {
+	ID                  int64
+	FirmID              int64
+	SourceTransactionID int64
+	TransactionID       int64
+	OriginalPartyID     int64
+	OriginalAmount      float64
+	SplitAt             sql.NullTime
+}
+
+type TransactionTag struct {
+	ID            int64
+	TransactionID int64
+	Tag           string
 }
@@ -12,6 +12,224 @@ import (
 	"time"
 )
 
+const addFinancialYearBalance = `-- name: AddFinancialYearBalance :exec
+INSERT INTO financial_year_balances (financial_year_id, party_id, billed, received, opening, year_billed, year_received)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+`
+
+type AddFinancialYearBalanceParams struct {
+	FinancialYearID int64
+	PartyID         int64
+	Billed          float64
+	Received        float64
+	Opening         sql.NullFloat64
+	YearBilled      sql.NullFloat64
+	YearReceived    sql.NullFloat64
+}
+
+func (q *Queries) AddFinancialYearBalance(ctx context.Context, arg AddFinancialYearBalanceParams) error {
+	_, err := q.db.ExecContext(ctx, addFinancialYearBalance,
+		arg.FinancialYearID,
+		arg.PartyID,
+		arg.Billed,
+		arg.Received,
+		arg.Opening,
+		arg.YearBilled,
+		arg.YearReceived,
+	)
+	return err
+}
+
+const addIdentifierConflict = `-- name: AddIdentifierConflict :exec
+INSERT INTO identifier_conflicts (firm_id, type, value, claimed_party_id, narration)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (firm_id, type, value, claimed_party_id) DO NOTHING
+`
+
+type AddIdentifierConflictParams struct {
+	FirmID         int64
+	Type           string
+	Value          string
+	ClaimedPartyID int64
+	Narration      string
+}
+
+func (q *Queries) AddIdentifierConflict(ctx context.Context, arg AddIdentifierConflictParams) error {
+	_, err := q.db.ExecContext(ctx, addIdentifierConflict,
+		arg.FirmID,
+		arg.Type,
+		arg.Value,
+		arg.ClaimedPartyID,
+		arg.Narration,
+	)
+	return err
+}
+
+const addIdentifierHistory = `-- name: AddIdentifierHistory :exec
+INSERT INTO identifier_history (firm_id, type, value, party_id, previous_party_id, cause, detail)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+`
+
+type AddIdentifierHistoryParams struct {
+	FirmID          int64
+	Type            string
+	Value           string
+	PartyID         int64
+	PreviousPartyID sql.NullInt64
+	Cause           string
+	Detail          string
+}
+
+func (q *Queries) AddIdentifierHistory(ctx context.Context, arg AddIdentifierHistoryParams) error {
+	_, err := q.db.ExecContext(ctx, addIdentifierHistory,
+		arg.FirmID,
+		arg.Type,
+		arg.Value,
+		arg.PartyID,
+		arg.PreviousPartyID,
+		arg.Cause,
+		arg.Detail,
+	)
+	return err
+}
+
+const addParserVocabulary = `-- name: AddParserVocabulary :exec
+INSERT INTO parser_vocabulary (kind, value) VALUES (?, ?)
+`
+
+type AddParserVocabularyParams struct {
+	Kind  string
+	Value string
+}
+
+func (q *Queries) AddParserVocabulary(ctx context.Context, arg AddParserVocabularyParams) error {
+	_, err := q.db.ExecContext(ctx, addParserVocabulary, arg.Kind, arg.Value)
+	return err
+}
+
+const addTransactionTag = `-- name: AddTransactionTag :exec
+INSERT INTO transaction_tags (transaction_id, tag)
+VALUES (?, ?)
+ON CONFLICT (transaction_id, tag) DO NOTHING
+`
+
+type AddTransactionTagParams struct {
+	TransactionID int64
+	Tag           string
+}
+
+func (q *Queries) AddTransactionTag(ctx context.Context, arg AddTransactionTagParams) error {
+	_, err := q.db.ExecContext(ctx, addTransactionTag, arg.TransactionID, arg.Tag)
+	return err
+}
+
+const allocateBillReceipt = `-- name: AllocateBillReceipt :exec
+INSERT INTO bill_allocations (sale_bill_id, transaction_id, amount)
+VALUES (?, ?, ?)
+ON CONFLICT (sale_bill_id, transaction_id) DO UPDATE SET amount = bill_allocations.amount + excluded.amount
+`
+
+type AllocateBillReceiptParams struct {
+	SaleBillID    int64
+	TransactionID int64
+	Amount        float64
+}
+
+func (q *Queries) AllocateBillReceipt(ctx context.Context, arg AllocateBillReceiptParams) error {
+	_, err := q.db.ExecContext(ctx, allocateBillReceipt, arg.SaleBillID, arg.TransactionID, arg.Amount)
+	return err
+}
+
+const assignTransactionAgent = `-- name: AssignTransactionAgent :exec
+INSERT OR IGNORE INTO transaction_agents (transaction_id, agent_id, source)
+VALUES (?, ?, ?)
+`
+
+type AssignTransactionAgentParams struct {
+	TransactionID int64
+	AgentID       int64
+	Source        string
+}
+
+func (q *Queries) AssignTransactionAgent(ctx context.Context, arg AssignTransactionAgentParams) error {
+	_, err := q.db.ExecContext(ctx, assignTransactionAgent, arg.TransactionID, arg.AgentID, arg.Source)
+	return err
+}
+
+const clearTransactionAgent = `-- name: ClearTransactionAgent :exec
+DELETE FROM transaction_agents WHERE transaction_id = ?
+`
+
+func (q *Queries) ClearTransactionAgent(ctx context.Context, transactionID int64) error {
+	_, err := q.db.ExecContext(ctx, clearTransactionAgent, transactionID)
+	return err
+}
+
+const clearTransactionAllocations = `-- name: ClearTransactionAllocations :exec
+DELETE FROM bill_allocations WHERE transaction_id = ?
+`
+
+func (q *Queries) ClearTransactionAllocations(ctx context.Context, transactionID int64) error {
+	_, err := q.db.ExecContext(ctx, clearTransactionAllocations, transactionID)
+	return err
+}
+
+const closeFinancialYear = `-- name: CloseFinancialYear :one
+INSERT INTO financial_years (firm_id, label, start_date, end_date)
+VALUES (?, ?, ?, ?)
+RETURNING id, firm_id, label, start_date, end_date, closed_at, archive_path, archived_at
+`
+
+type CloseFinancialYearParams struct {
+	FirmID    int64
+	Label     string
+	StartDate time.Time
+	EndDate   time.Time
+}
+
+func (q *Queries) CloseFinancialYear(ctx context.Context, arg CloseFinancialYearParams) (FinancialYear, error) {
+	row := q.db.QueryRowContext(ctx, closeFinancialYear,
+		arg.FirmID,
+		arg.Label,
+		arg.StartDate,
+		arg.EndDate,
+	)
+	var i FinancialYear
+	err := row.Scan(
+		&i.ID,
+		&i.FirmID,
+		&i.Label,
+		&i.StartDate,
+		&i.EndDate,
+		&i.ClosedAt,
+		&i.ArchivePath,
+		&i.ArchivedAt,
+	)
+	return i, err
+}
+
+const countParties = `-- name: CountParties :one
+SELECT COUNT(*) FROM parties WHERE firm_id = ?
+`
+
+func (q *Queries) CountParties(ctx context.Context, firmID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countParties, firmID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countPendingImports = `-- name: CountPendingImports :one
+SELECT COUNT(*) FROM pending_imports WHERE status = 'pending'
+`
+
+func (q *Queries) CountPendingImports(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countPendingImports)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const countTransactionsByPartyID = `-- name: CountTransactionsByPartyID :one
 SELECT COUNT(*) as count FROM transactions WHERE party_id = ?
 `
@@ -23,67 +241,475 @@ func (q *Queries) CountTransactionsByPartyID(ctx context.Context, partyID int64)
 	return count, err
 }
 
-const createIdentifier = `-- name: CreateIdentifier :one
-INSERT INTO identifiers (party_id, type, value)
+const createAgent = `-- name: CreateAgent :one
+INSERT INTO agents (firm_id, name, code, location, commission_percent)
+VALUES (?, ?, ?, ?, ?)
+RETURNING id, firm_id, name, code, location, created_at, commission_percent
+`
+
+type CreateAgentParams struct {
+	FirmID            int64
+	Name              string
+	Code              string
+	Location          string
+	CommissionPercent float64
+}
+
+func (q *Queries) CreateAgent(ctx context.Context, arg CreateAgentParams) (Agent, error) {
+	row := q.db.QueryRowContext(ctx, createAgent,
+		arg.FirmID,
+		arg.Name,
+		arg.Code,
+		arg.Location,
+		arg.CommissionPercent,
+	)
+	var i Agent
+	err := row.Scan(
+		&i.ID,
+		&i.FirmID,
+		&i.Name,
+		&i.Code,
+		&i.Location,
+		&i.CreatedAt,
+		&i.CommissionPercent,
+	)
+	return i, err
+}
+
+const createCheque = `-- name: CreateCheque :one
+INSERT INTO cheques (transaction_id, cheque_number, cheque_date, received_date)
+VALUES (?, ?, ?, ?)
+RETURNING id, transaction_id, cheque_number, cheque_date, status, received_date, deposited_date, cleared_date, bounced_date, notes, updated_at
+`
+
+type CreateChequeParams struct {
+	TransactionID int64
+	ChequeNumber  sql.NullString
+	ChequeDate    sql.NullTime
+	ReceivedDate  time.Time
+}
+
+func (q *Queries) CreateCheque(ctx context.Context, arg CreateChequeParams) (Cheque, error) {
+	row := q.db.QueryRowContext(ctx, createCheque,
+		arg.TransactionID,
+		arg.ChequeNumber,
+		arg.ChequeDate,
+		arg.ReceivedDate,
+	)
+	var i Cheque
+	err := row.Scan(
+		&i.ID,
+		&i.TransactionID,
+		&i.ChequeNumber,
+		&i.ChequeDate,
+		&i.Status,
+		&i.ReceivedDate,
+		&i.DepositedDate,
+		&i.ClearedDate,
+		&i.BouncedDate,
+		&i.Notes,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createFirm = `-- name: CreateFirm :one
+INSERT INTO firms (name, gstin, rounding_tolerance)
 VALUES (?, ?, ?)
-ON CONFLICT (type, value) DO UPDATE SET party_id = excluded.party_id
-RETURNING id, party_id, type, value, created_at
+RETURNING id, name, gstin, created_at, rounding_tolerance
+`
+
+type CreateFirmParams struct {
+	Name              string
+	Gstin             string
+	RoundingTolerance float64
+}
+
+func (q *Queries) CreateFirm(ctx context.Context, arg CreateFirmParams) (Firm, error) {
+	row := q.db.QueryRowContext(ctx, createFirm, arg.Name, arg.Gstin, arg.RoundingTolerance)
+	var i Firm
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Gstin,
+		&i.CreatedAt,
+		&i.RoundingTolerance,
+	)
+	return i, err
+}
+
+const createGSTR1Invoice = `-- name: CreateGSTR1Invoice :exec
+INSERT INTO gstr1_invoices (
+    firm_id, return_period, invoice_number, invoice_date, value, receiver_gstin, receiver_name
+) VALUES (?, ?, ?, ?, ?, ?, ?)
+`
+
+type CreateGSTR1InvoiceParams struct {
+	FirmID        int64
+	ReturnPeriod  string
+	InvoiceNumber string
+	InvoiceDate   time.Time
+	Value         float64
+	ReceiverGstin string
+	ReceiverName  string
+}
+
+func (q *Queries) CreateGSTR1Invoice(ctx context.Context, arg CreateGSTR1InvoiceParams) error {
+	_, err := q.db.ExecContext(ctx, createGSTR1Invoice,
+		arg.FirmID,
+		arg.ReturnPeriod,
+		arg.InvoiceNumber,
+		arg.InvoiceDate,
+		arg.Value,
+		arg.ReceiverGstin,
+		arg.ReceiverName,
+	)
+	return err
+}
+
+const createIdentifier = `-- name: CreateIdentifier :one
+INSERT INTO identifiers (party_id, type, value, firm_id)
+VALUES (?, ?, ?, ?)
+RETURNING id, party_id, type, value, firm_id, first_seen, last_seen, hit_count, created_at
 `
 
 type CreateIdentifierParams struct {
 	PartyID int64
 	Type    string
 	Value   string
+	FirmID  int64
 }
 
 func (q *Queries) CreateIdentifier(ctx context.Context, arg CreateIdentifierParams) (Identifier, error) {
-	row := q.db.QueryRowContext(ctx, createIdentifier, arg.PartyID, arg.Type, arg.Value)
+	row := q.db.QueryRowContext(ctx, createIdentifier,
+		arg.PartyID,
+		arg.Type,
+		arg.Value,
+		arg.FirmID,
+	)
 	var i Identifier
 	err := row.Scan(
 		&i.ID,
 		&i.PartyID,
 		&i.Type,
 		&i.Value,
+		&i.FirmID,
+		&i.FirstSeen,
+		&i.LastSeen,
+		&i.HitCount,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createImportBatch = `-- name: CreateImportBatch :one
+INSERT INTO import_batches (
+    firm_id, lines, transactions, page_headers, skip_patterns, suspense, unattached,
+    identifiers, imported, duplicates, errors
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id
+`
+
+type CreateImportBatchParams struct {
+	FirmID       int64
+	Lines        int64
+	Transactions int64
+	PageHeaders  int64
+	SkipPatterns int64
+	Suspense     int64
+	Unattached   int64
+	Identifiers  int64
+	Imported     int64
+	Duplicates   int64
+	Errors       int64
+}
+
+func (q *Queries) CreateImportBatch(ctx context.Context, arg CreateImportBatchParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, createImportBatch,
+		arg.FirmID,
+		arg.Lines,
+		arg.Transactions,
+		arg.PageHeaders,
+		arg.SkipPatterns,
+		arg.Suspense,
+		arg.Unattached,
+		arg.Identifiers,
+		arg.Imported,
+		arg.Duplicates,
+		arg.Errors,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createPOSSettlement = `-- name: CreatePOSSettlement :one
+INSERT INTO pos_settlements (credit_date, settlement_date, terminal_id, batch_number, amount, narration, account_id, firm_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, credit_date, settlement_date, terminal_id, batch_number, amount, narration, account_id, firm_id, created_at
+`
+
+type CreatePOSSettlementParams struct {
+	CreditDate     time.Time
+	SettlementDate time.Time
+	TerminalID     sql.NullString
+	BatchNumber    sql.NullString
+	Amount         float64
+	Narration      sql.NullString
+	AccountID      sql.NullInt64
+	FirmID         int64
+}
+
+func (q *Queries) CreatePOSSettlement(ctx context.Context, arg CreatePOSSettlementParams) (PosSettlement, error) {
+	row := q.db.QueryRowContext(ctx, createPOSSettlement,
+		arg.CreditDate,
+		arg.SettlementDate,
+		arg.TerminalID,
+		arg.BatchNumber,
+		arg.Amount,
+		arg.Narration,
+		arg.AccountID,
+		arg.FirmID,
+	)
+	var i PosSettlement
+	err := row.Scan(
+		&i.ID,
+		&i.CreditDate,
+		&i.SettlementDate,
+		&i.TerminalID,
+		&i.BatchNumber,
+		&i.Amount,
+		&i.Narration,
+		&i.AccountID,
+		&i.FirmID,
 		&i.CreatedAt,
 	)
 	return i, err
 }
 
 const createParty = `-- name: CreateParty :one
-INSERT INTO parties (name, location)
-VALUES (?, ?)
-RETURNING id, name, location, created_at
+INSERT INTO parties (name, location, firm_id)
+VALUES (?, ?, ?)
+RETURNING id, name, location, credit_limit, firm_id, created_at, email, phone, address, gstin, drug_license
 `
 
 type CreatePartyParams struct {
 	Name     string
 	Location sql.NullString
+	FirmID   int64
 }
 
 func (q *Queries) CreateParty(ctx context.Context, arg CreatePartyParams) (Party, error) {
-	row := q.db.QueryRowContext(ctx, createParty, arg.Name, arg.Location)
+	row := q.db.QueryRowContext(ctx, createParty, arg.Name, arg.Location, arg.FirmID)
 	var i Party
 	err := row.Scan(
 		&i.ID,
 		&i.Name,
 		&i.Location,
+		&i.CreditLimit,
+		&i.FirmID,
+		&i.CreatedAt,
+		&i.Email,
+		&i.Phone,
+		&i.Address,
+		&i.Gstin,
+		&i.DrugLicense,
+	)
+	return i, err
+}
+
+const createPartyMerge = `-- name: CreatePartyMerge :exec
+INSERT INTO party_merges (firm_id, merged_party_id, merged_name, survivor_party_id)
+VALUES (?, ?, ?, ?)
+`
+
+type CreatePartyMergeParams struct {
+	FirmID          int64
+	MergedPartyID   int64
+	MergedName      string
+	SurvivorPartyID int64
+}
+
+func (q *Queries) CreatePartyMerge(ctx context.Context, arg CreatePartyMergeParams) error {
+	_, err := q.db.ExecContext(ctx, createPartyMerge,
+		arg.FirmID,
+		arg.MergedPartyID,
+		arg.MergedName,
+		arg.SurvivorPartyID,
+	)
+	return err
+}
+
+const createPartyNote = `-- name: CreatePartyNote :one
+INSERT INTO party_notes (party_id, note)
+VALUES (?, ?)
+RETURNING id, party_id, note, created_at
+`
+
+type CreatePartyNoteParams struct {
+	PartyID int64
+	Note    string
+}
+
+func (q *Queries) CreatePartyNote(ctx context.Context, arg CreatePartyNoteParams) (PartyNote, error) {
+	row := q.db.QueryRowContext(ctx, createPartyNote, arg.PartyID, arg.Note)
+	var i PartyNote
+	err := row.Scan(
+		&i.ID,
+		&i.PartyID,
+		&i.Note,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createPaymentModeRule = `-- name: CreatePaymentModeRule :one
+INSERT INTO payment_mode_rules (pattern, mode, priority)
+VALUES (?, ?, ?)
+RETURNING id, pattern, mode, priority, created_at
+`
+
+type CreatePaymentModeRuleParams struct {
+	Pattern  string
+	Mode     string
+	Priority int64
+}
+
+func (q *Queries) CreatePaymentModeRule(ctx context.Context, arg CreatePaymentModeRuleParams) (PaymentModeRule, error) {
+	row := q.db.QueryRowContext(ctx, createPaymentModeRule, arg.Pattern, arg.Mode, arg.Priority)
+	var i PaymentModeRule
+	err := row.Scan(
+		&i.ID,
+		&i.Pattern,
+		&i.Mode,
+		&i.Priority,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createPendingImport = `-- name: CreatePendingImport :execrows
+INSERT INTO pending_imports (kind, filename, content, sender, subject, message_id, received_at, entries)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (message_id, filename) DO NOTHING
+`
+
+type CreatePendingImportParams struct {
+	Kind       string
+	Filename   string
+	Content    []byte
+	Sender     string
+	Subject    string
+	MessageID  string
+	ReceivedAt time.Time
+	Entries    int64
+}
+
+func (q *Queries) CreatePendingImport(ctx context.Context, arg CreatePendingImportParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, createPendingImport,
+		arg.Kind,
+		arg.Filename,
+		arg.Content,
+		arg.Sender,
+		arg.Subject,
+		arg.MessageID,
+		arg.ReceivedAt,
+		arg.Entries,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const createRoute = `-- name: CreateRoute :exec
+INSERT INTO routes (firm_id, name) VALUES (?, ?)
+`
+
+type CreateRouteParams struct {
+	FirmID int64
+	Name   string
+}
+
+func (q *Queries) CreateRoute(ctx context.Context, arg CreateRouteParams) error {
+	_, err := q.db.ExecContext(ctx, createRoute, arg.FirmID, arg.Name)
+	return err
+}
+
+const createRule = `-- name: CreateRule :one
+INSERT INTO rules (name, narration_pattern, party_pattern, min_amount, max_amount, set_category, set_internal, set_party_name, priority, enabled)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, name, narration_pattern, party_pattern, min_amount, max_amount, set_category, set_internal, set_party_name, priority, enabled, created_at
+`
+
+type CreateRuleParams struct {
+	Name             string
+	NarrationPattern string
+	PartyPattern     string
+	MinAmount        float64
+	MaxAmount        float64
+	SetCategory      string
+	SetInternal      bool
+	SetPartyName     string
+	Priority         int64
+	Enabled          bool
+}
+
+func (q *Queries) CreateRule(ctx context.Context, arg CreateRuleParams) (Rule, error) {
+	row := q.db.QueryRowContext(ctx, createRule,
+		arg.Name,
+		arg.NarrationPattern,
+		arg.PartyPattern,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.SetCategory,
+		arg.SetInternal,
+		arg.SetPartyName,
+		arg.Priority,
+		arg.Enabled,
+	)
+	var i Rule
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.NarrationPattern,
+		&i.PartyPattern,
+		&i.MinAmount,
+		&i.MaxAmount,
+		&i.SetCategory,
+		&i.SetInternal,
+		&i.SetPartyName,
+		&i.Priority,
+		&i.Enabled,
 		&i.CreatedAt,
 	)
 	return i, err
 }
 
 const createSaleBill = `-- name: CreateSaleBill :one
-INSERT INTO sale_bills (bill_number, bill_date, party_name, amount, is_cash_sale)
-VALUES (?, ?, ?, ?, ?)
-RETURNING id, bill_number, bill_date, party_name, amount, is_cash_sale, created_at
+INSERT INTO sale_bills (
+    bill_number, bill_date, party_name, amount, is_cash_sale, is_card_sale, party_id, firm_id,
+    irn, ack_number, ack_date, taxable_value, cgst, sgst, igst
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, bill_number, bill_date, party_name, amount, is_cash_sale, is_card_sale, party_id, firm_id, created_at, irn, ack_number, ack_date, taxable_value, cgst, sgst, igst
 `
 
 type CreateSaleBillParams struct {
-	BillNumber string
-	BillDate   time.Time
-	PartyName  string
-	Amount     float64
-	IsCashSale sql.NullBool
+	BillNumber   string
+	BillDate     time.Time
+	PartyName    string
+	Amount       float64
+	IsCashSale   sql.NullBool
+	IsCardSale   bool
+	PartyID      sql.NullInt64
+	FirmID       int64
+	Irn          string
+	AckNumber    string
+	AckDate      sql.NullTime
+	TaxableValue float64
+	Cgst         float64
+	Sgst         float64
+	Igst         float64
 }
 
 func (q *Queries) CreateSaleBill(ctx context.Context, arg CreateSaleBillParams) (SaleBill, error) {
@@ -93,6 +719,16 @@ func (q *Queries) CreateSaleBill(ctx context.Context, arg CreateSaleBillParams)
 		arg.PartyName,
 		arg.Amount,
 		arg.IsCashSale,
+		arg.IsCardSale,
+		arg.PartyID,
+		arg.FirmID,
+		arg.Irn,
+		arg.AckNumber,
+		arg.AckDate,
+		arg.TaxableValue,
+		arg.Cgst,
+		arg.Sgst,
+		arg.Igst,
 	)
 	var i SaleBill
 	err := row.Scan(
@@ -102,70 +738,453 @@ func (q *Queries) CreateSaleBill(ctx context.Context, arg CreateSaleBillParams)
 		&i.PartyName,
 		&i.Amount,
 		&i.IsCashSale,
+		&i.IsCardSale,
+		&i.PartyID,
+		&i.FirmID,
 		&i.CreatedAt,
+		&i.Irn,
+		&i.AckNumber,
+		&i.AckDate,
+		&i.TaxableValue,
+		&i.Cgst,
+		&i.Sgst,
+		&i.Igst,
 	)
 	return i, err
 }
 
-const createTransaction = `-- name: CreateTransaction :one
-INSERT INTO transactions (party_id, amount, transaction_date, payment_mode, narration, cash_bank_code, cash_bank_location)
-VALUES (?, ?, ?, ?, ?, ?, ?)
-RETURNING id, party_id, amount, transaction_date, payment_mode, narration, cash_bank_code, cash_bank_location, created_at
+const createSavedSearch = `-- name: CreateSavedSearch :one
+INSERT INTO saved_searches (name, kind, narration, amount, variation, from_date, till_date, firm_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, name, kind, narration, amount, variation, from_date, till_date, firm_id, created_at
 `
 
-type CreateTransactionParams struct {
-	PartyID          int64
-	Amount           float64
-	TransactionDate  time.Time
-	PaymentMode      sql.NullString
-	Narration        sql.NullString
-	CashBankCode     sql.NullString
-	CashBankLocation sql.NullString
+type CreateSavedSearchParams struct {
+	Name      string
+	Kind      string
+	Narration string
+	Amount    float64
+	Variation float64
+	FromDate  sql.NullTime
+	TillDate  sql.NullTime
+	FirmID    int64
 }
 
-func (q *Queries) CreateTransaction(ctx context.Context, arg CreateTransactionParams) (Transaction, error) {
-	row := q.db.QueryRowContext(ctx, createTransaction,
-		arg.PartyID,
-		arg.Amount,
-		arg.TransactionDate,
-		arg.PaymentMode,
+func (q *Queries) CreateSavedSearch(ctx context.Context, arg CreateSavedSearchParams) (SavedSearche, error) {
+	row := q.db.QueryRowContext(ctx, createSavedSearch,
+		arg.Name,
+		arg.Kind,
 		arg.Narration,
-		arg.CashBankCode,
-		arg.CashBankLocation,
+		arg.Amount,
+		arg.Variation,
+		arg.FromDate,
+		arg.TillDate,
+		arg.FirmID,
 	)
-	var i Transaction
+	var i SavedSearche
 	err := row.Scan(
 		&i.ID,
-		&i.PartyID,
-		&i.Amount,
-		&i.TransactionDate,
-		&i.PaymentMode,
+		&i.Name,
+		&i.Kind,
+		&i.Narration,
+		&i.Amount,
+		&i.Variation,
+		&i.FromDate,
+		&i.TillDate,
+		&i.FirmID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createStatementEmail = `-- name: CreateStatementEmail :exec
+INSERT INTO statement_emails (firm_id, party_id, email, from_date, till_date, error)
+VALUES (?, ?, ?, ?, ?, ?)
+`
+
+type CreateStatementEmailParams struct {
+	FirmID   int64
+	PartyID  int64
+	Email    string
+	FromDate time.Time
+	TillDate time.Time
+	Error    string
+}
+
+func (q *Queries) CreateStatementEmail(ctx context.Context, arg CreateStatementEmailParams) error {
+	_, err := q.db.ExecContext(ctx, createStatementEmail,
+		arg.FirmID,
+		arg.PartyID,
+		arg.Email,
+		arg.FromDate,
+		arg.TillDate,
+		arg.Error,
+	)
+	return err
+}
+
+const createStatementLink = `-- name: CreateStatementLink :one
+INSERT INTO statement_links (party_id, token, expires_at)
+VALUES (?, ?, ?)
+RETURNING id, party_id, token, expires_at, created_at
+`
+
+type CreateStatementLinkParams struct {
+	PartyID   int64
+	Token     string
+	ExpiresAt time.Time
+}
+
+func (q *Queries) CreateStatementLink(ctx context.Context, arg CreateStatementLinkParams) (StatementLink, error) {
+	row := q.db.QueryRowContext(ctx, createStatementLink, arg.PartyID, arg.Token, arg.ExpiresAt)
+	var i StatementLink
+	err := row.Scan(
+		&i.ID,
+		&i.PartyID,
+		&i.Token,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createSyncLog = `-- name: CreateSyncLog :exec
+INSERT INTO sync_log (client_id, kind, summary)
+VALUES (?, ?, ?)
+`
+
+type CreateSyncLogParams struct {
+	ClientID string
+	Kind     string
+	Summary  string
+}
+
+func (q *Queries) CreateSyncLog(ctx context.Context, arg CreateSyncLogParams) error {
+	_, err := q.db.ExecContext(ctx, createSyncLog, arg.ClientID, arg.Kind, arg.Summary)
+	return err
+}
+
+const createTransaction = `-- name: CreateTransaction :one
+INSERT INTO transactions (party_id, amount, transaction_date, payment_mode, narration, cash_bank_code, cash_bank_location, category, is_internal, account_id, firm_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, party_id, amount, transaction_date, payment_mode, narration, cash_bank_code, cash_bank_location, category, is_internal, account_id, note, firm_id, created_at, import_batch_id
+`
+
+type CreateTransactionParams struct {
+	PartyID          int64
+	Amount           float64
+	TransactionDate  time.Time
+	PaymentMode      sql.NullString
+	Narration        sql.NullString
+	CashBankCode     sql.NullString
+	CashBankLocation sql.NullString
+	Category         string
+	IsInternal       bool
+	AccountID        sql.NullInt64
+	FirmID           int64
+}
+
+func (q *Queries) CreateTransaction(ctx context.Context, arg CreateTransactionParams) (Transaction, error) {
+	row := q.db.QueryRowContext(ctx, createTransaction,
+		arg.PartyID,
+		arg.Amount,
+		arg.TransactionDate,
+		arg.PaymentMode,
+		arg.Narration,
+		arg.CashBankCode,
+		arg.CashBankLocation,
+		arg.Category,
+		arg.IsInternal,
+		arg.AccountID,
+		arg.FirmID,
+	)
+	var i Transaction
+	err := row.Scan(
+		&i.ID,
+		&i.PartyID,
+		&i.Amount,
+		&i.TransactionDate,
+		&i.PaymentMode,
 		&i.Narration,
 		&i.CashBankCode,
 		&i.CashBankLocation,
+		&i.Category,
+		&i.IsInternal,
+		&i.AccountID,
+		&i.Note,
+		&i.FirmID,
 		&i.CreatedAt,
+		&i.ImportBatchID,
 	)
 	return i, err
 }
 
+const createTransactionMerge = `-- name: CreateTransactionMerge :exec
+INSERT INTO transaction_merges (firm_id, transaction_id, superseded_transaction_id, superseded_party_id, superseded_amount, superseded_date, superseded_payment_mode, superseded_narration)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+type CreateTransactionMergeParams struct {
+	FirmID                  int64
+	TransactionID           int64
+	SupersededTransactionID int64
+	SupersededPartyID       int64
+	SupersededAmount        float64
+	SupersededDate          time.Time
+	SupersededPaymentMode   sql.NullString
+	SupersededNarration     sql.NullString
+}
+
+func (q *Queries) CreateTransactionMerge(ctx context.Context, arg CreateTransactionMergeParams) error {
+	_, err := q.db.ExecContext(ctx, createTransactionMerge,
+		arg.FirmID,
+		arg.TransactionID,
+		arg.SupersededTransactionID,
+		arg.SupersededPartyID,
+		arg.SupersededAmount,
+		arg.SupersededDate,
+		arg.SupersededPaymentMode,
+		arg.SupersededNarration,
+	)
+	return err
+}
+
+const createTransactionSplit = `-- name: CreateTransactionSplit :exec
+INSERT INTO transaction_splits (firm_id, source_transaction_id, transaction_id, original_party_id, original_amount)
+VALUES (?, ?, ?, ?, ?)
+`
+
+type CreateTransactionSplitParams struct {
+	FirmID              int64
+	SourceTransactionID int64
+	TransactionID       int64
+	OriginalPartyID     int64
+	OriginalAmount      float64
+}
+
+func (q *Queries) CreateTransactionSplit(ctx context.Context, arg CreateTransactionSplitParams) error {
+	_, err := q.db.ExecContext(ctx, createTransactionSplit,
+		arg.FirmID,
+		arg.SourceTransactionID,
+		arg.TransactionID,
+		arg.OriginalPartyID,
+		arg.OriginalAmount,
+	)
+	return err
+}
+
+const deleteAgent = `-- name: DeleteAgent :exec
+DELETE FROM agents WHERE id = ? AND firm_id = ?
+`
+
+type DeleteAgentParams struct {
+	ID     int64
+	FirmID int64
+}
+
+func (q *Queries) DeleteAgent(ctx context.Context, arg DeleteAgentParams) error {
+	_, err := q.db.ExecContext(ctx, deleteAgent, arg.ID, arg.FirmID)
+	return err
+}
+
+const deleteAgentTransactions = `-- name: DeleteAgentTransactions :exec
+DELETE FROM transaction_agents
+WHERE agent_id IN (SELECT id FROM agents WHERE id = ? AND firm_id = ?)
+`
+
+type DeleteAgentTransactionsParams struct {
+	ID     int64
+	FirmID int64
+}
+
+func (q *Queries) DeleteAgentTransactions(ctx context.Context, arg DeleteAgentTransactionsParams) error {
+	_, err := q.db.ExecContext(ctx, deleteAgentTransactions, arg.ID, arg.FirmID)
+	return err
+}
+
+const deleteBillAllocation = `-- name: DeleteBillAllocation :exec
+DELETE FROM bill_allocations
+WHERE id = ? AND transaction_id IN (SELECT id FROM transactions WHERE firm_id = ?)
+`
+
+type DeleteBillAllocationParams struct {
+	ID     int64
+	FirmID int64
+}
+
+func (q *Queries) DeleteBillAllocation(ctx context.Context, arg DeleteBillAllocationParams) error {
+	_, err := q.db.ExecContext(ctx, deleteBillAllocation, arg.ID, arg.FirmID)
+	return err
+}
+
+const deleteGSTR1Return = `-- name: DeleteGSTR1Return :exec
+DELETE FROM gstr1_invoices WHERE firm_id = ? AND return_period = ?
+`
+
+type DeleteGSTR1ReturnParams struct {
+	FirmID       int64
+	ReturnPeriod string
+}
+
+func (q *Queries) DeleteGSTR1Return(ctx context.Context, arg DeleteGSTR1ReturnParams) error {
+	_, err := q.db.ExecContext(ctx, deleteGSTR1Return, arg.FirmID, arg.ReturnPeriod)
+	return err
+}
+
+const deleteParserVocabularyKind = `-- name: DeleteParserVocabularyKind :exec
+DELETE FROM parser_vocabulary WHERE kind = ?
+`
+
+func (q *Queries) DeleteParserVocabularyKind(ctx context.Context, kind string) error {
+	_, err := q.db.ExecContext(ctx, deleteParserVocabularyKind, kind)
+	return err
+}
+
+const deletePartyNote = `-- name: DeletePartyNote :exec
+DELETE FROM party_notes WHERE id = ?
+`
+
+func (q *Queries) DeletePartyNote(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deletePartyNote, id)
+	return err
+}
+
+const deletePaymentModeRule = `-- name: DeletePaymentModeRule :exec
+DELETE FROM payment_mode_rules WHERE id = ?
+`
+
+func (q *Queries) DeletePaymentModeRule(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deletePaymentModeRule, id)
+	return err
+}
+
+const deleteRoute = `-- name: DeleteRoute :exec
+DELETE FROM routes WHERE id = ? AND firm_id = ?
+`
+
+type DeleteRouteParams struct {
+	ID     int64
+	FirmID int64
+}
+
+func (q *Queries) DeleteRoute(ctx context.Context, arg DeleteRouteParams) error {
+	_, err := q.db.ExecContext(ctx, deleteRoute, arg.ID, arg.FirmID)
+	return err
+}
+
+const deleteRouteLocation = `-- name: DeleteRouteLocation :exec
+DELETE FROM route_locations WHERE id = ? AND firm_id = ?
+`
+
+type DeleteRouteLocationParams struct {
+	ID     int64
+	FirmID int64
+}
+
+func (q *Queries) DeleteRouteLocation(ctx context.Context, arg DeleteRouteLocationParams) error {
+	_, err := q.db.ExecContext(ctx, deleteRouteLocation, arg.ID, arg.FirmID)
+	return err
+}
+
+const deleteRouteLocations = `-- name: DeleteRouteLocations :exec
+DELETE FROM route_locations WHERE route_id = ? AND firm_id = ?
+`
+
+type DeleteRouteLocationsParams struct {
+	RouteID int64
+	FirmID  int64
+}
+
+func (q *Queries) DeleteRouteLocations(ctx context.Context, arg DeleteRouteLocationsParams) error {
+	_, err := q.db.ExecContext(ctx, deleteRouteLocations, arg.RouteID, arg.FirmID)
+	return err
+}
+
+const deleteRule = `-- name: DeleteRule :exec
+DELETE FROM rules WHERE id = ?
+`
+
+func (q *Queries) DeleteRule(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteRule, id)
+	return err
+}
+
+const deleteSavedSearch = `-- name: DeleteSavedSearch :exec
+DELETE FROM saved_searches WHERE id = ? AND firm_id = ?
+`
+
+type DeleteSavedSearchParams struct {
+	ID     int64
+	FirmID int64
+}
+
+func (q *Queries) DeleteSavedSearch(ctx context.Context, arg DeleteSavedSearchParams) error {
+	_, err := q.db.ExecContext(ctx, deleteSavedSearch, arg.ID, arg.FirmID)
+	return err
+}
+
+const deleteStatementLink = `-- name: DeleteStatementLink :exec
+DELETE FROM statement_links WHERE id = ?
+`
+
+func (q *Queries) DeleteStatementLink(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteStatementLink, id)
+	return err
+}
+
+const deleteTransactionTags = `-- name: DeleteTransactionTags :exec
+DELETE FROM transaction_tags WHERE transaction_id = ?
+`
+
+func (q *Queries) DeleteTransactionTags(ctx context.Context, transactionID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteTransactionTags, transactionID)
+	return err
+}
+
+const dismissMergeSuggestion = `-- name: DismissMergeSuggestion :exec
+UPDATE merge_suggestions SET dismissed_at = CURRENT_TIMESTAMP WHERE id = ? AND firm_id = ?
+`
+
+type DismissMergeSuggestionParams struct {
+	ID     int64
+	FirmID int64
+}
+
+func (q *Queries) DismissMergeSuggestion(ctx context.Context, arg DismissMergeSuggestionParams) error {
+	_, err := q.db.ExecContext(ctx, dismissMergeSuggestion, arg.ID, arg.FirmID)
+	return err
+}
+
 const findPartiesByIdentifierValue = `-- name: FindPartiesByIdentifierValue :many
-SELECT DISTINCT p.id, p.name, p.location, p.created_at, i.type as match_type, i.value as match_value
+SELECT DISTINCT p.id, p.name, p.location, p.credit_limit, p.firm_id, p.created_at, p.email, p.phone, p.address, p.gstin, p.drug_license, i.type as match_type, i.value as match_value
 FROM parties p
 JOIN identifiers i ON p.id = i.party_id
-WHERE i.value = ?
+WHERE i.value = ? AND i.firm_id = ?
 `
 
+type FindPartiesByIdentifierValueParams struct {
+	Value  string
+	FirmID int64
+}
+
 type FindPartiesByIdentifierValueRow struct {
-	ID         int64
-	Name       string
-	Location   sql.NullString
-	CreatedAt  sql.NullTime
-	MatchType  string
-	MatchValue string
+	ID          int64
+	Name        string
+	Location    sql.NullString
+	CreditLimit float64
+	FirmID      int64
+	CreatedAt   sql.NullTime
+	Email       string
+	Phone       string
+	Address     string
+	Gstin       string
+	DrugLicense string
+	MatchType   string
+	MatchValue  string
 }
 
-func (q *Queries) FindPartiesByIdentifierValue(ctx context.Context, value string) ([]FindPartiesByIdentifierValueRow, error) {
-	rows, err := q.db.QueryContext(ctx, findPartiesByIdentifierValue, value)
+func (q *Queries) FindPartiesByIdentifierValue(ctx context.Context, arg FindPartiesByIdentifierValueParams) ([]FindPartiesByIdentifierValueRow, error) {
+	rows, err := q.db.QueryContext(ctx, findPartiesByIdentifierValue, arg.Value, arg.FirmID)
 	if err != nil {
 		return nil, err
 	}
@@ -177,7 +1196,14 @@ func (q *Queries) FindPartiesByIdentifierValue(ctx context.Context, value string
 			&i.ID,
 			&i.Name,
 			&i.Location,
+			&i.CreditLimit,
+			&i.FirmID,
 			&i.CreatedAt,
+			&i.Email,
+			&i.Phone,
+			&i.Address,
+			&i.Gstin,
+			&i.DrugLicense,
 			&i.MatchType,
 			&i.MatchValue,
 		); err != nil {
@@ -195,29 +1221,42 @@ func (q *Queries) FindPartiesByIdentifierValue(ctx context.Context, value string
 }
 
 const findPartiesByIdentifierValues = `-- name: FindPartiesByIdentifierValues :many
-SELECT DISTINCT p.id, p.name, p.location, p.created_at, i.type as match_type, i.value as match_value
+SELECT DISTINCT p.id, p.name, p.location, p.credit_limit, p.firm_id, p.created_at, p.email, p.phone, p.address, p.gstin, p.drug_license, i.type as match_type, i.value as match_value
 FROM parties p
 JOIN identifiers i ON p.id = i.party_id
-WHERE i.value IN (/*SLICE:values*/?)
+WHERE i.firm_id = ? AND i.value IN (/*SLICE:values*/?)
 `
 
+type FindPartiesByIdentifierValuesParams struct {
+	FirmID int64
+	Values []string
+}
+
 type FindPartiesByIdentifierValuesRow struct {
-	ID         int64
-	Name       string
-	Location   sql.NullString
-	CreatedAt  sql.NullTime
-	MatchType  string
-	MatchValue string
+	ID          int64
+	Name        string
+	Location    sql.NullString
+	CreditLimit float64
+	FirmID      int64
+	CreatedAt   sql.NullTime
+	Email       string
+	Phone       string
+	Address     string
+	Gstin       string
+	DrugLicense string
+	MatchType   string
+	MatchValue  string
 }
 
-func (q *Queries) FindPartiesByIdentifierValues(ctx context.Context, values []string) ([]FindPartiesByIdentifierValuesRow, error) {
+func (q *Queries) FindPartiesByIdentifierValues(ctx context.Context, arg FindPartiesByIdentifierValuesParams) ([]FindPartiesByIdentifierValuesRow, error) {
 	query := findPartiesByIdentifierValues
 	var queryParams []interface{}
-	if len(values) > 0 {
-		for _, v := range values {
+	queryParams = append(queryParams, arg.FirmID)
+	if len(arg.Values) > 0 {
+		for _, v := range arg.Values {
 			queryParams = append(queryParams, v)
 		}
-		query = strings.Replace(query, "/*SLICE:values*/?", strings.Repeat(",?", len(values))[1:], 1)
+		query = strings.Replace(query, "/*SLICE:values*/?", strings.Repeat(",?", len(arg.Values))[1:], 1)
 	} else {
 		query = strings.Replace(query, "/*SLICE:values*/?", "NULL", 1)
 	}
@@ -233,7 +1272,14 @@ func (q *Queries) FindPartiesByIdentifierValues(ctx context.Context, values []st
 			&i.ID,
 			&i.Name,
 			&i.Location,
+			&i.CreditLimit,
+			&i.FirmID,
 			&i.CreatedAt,
+			&i.Email,
+			&i.Phone,
+			&i.Address,
+			&i.Gstin,
+			&i.DrugLicense,
 			&i.MatchType,
 			&i.MatchValue,
 		); err != nil {
@@ -251,23 +1297,35 @@ func (q *Queries) FindPartiesByIdentifierValues(ctx context.Context, values []st
 }
 
 const findPartiesByNarrationPattern = `-- name: FindPartiesByNarrationPattern :many
-SELECT DISTINCT p.id, p.name, p.location, p.created_at, t.narration as match_narration
+SELECT DISTINCT p.id, p.name, p.location, p.credit_limit, p.firm_id, p.created_at, p.email, p.phone, p.address, p.gstin, p.drug_license, t.narration as match_narration
 FROM parties p
 JOIN transactions t ON p.id = t.party_id
-WHERE t.narration LIKE ?
+WHERE t.narration LIKE ? AND p.firm_id = ?
 LIMIT 50
 `
 
+type FindPartiesByNarrationPatternParams struct {
+	Narration sql.NullString
+	FirmID    int64
+}
+
 type FindPartiesByNarrationPatternRow struct {
 	ID             int64
 	Name           string
 	Location       sql.NullString
+	CreditLimit    float64
+	FirmID         int64
 	CreatedAt      sql.NullTime
+	Email          string
+	Phone          string
+	Address        string
+	Gstin          string
+	DrugLicense    string
 	MatchNarration sql.NullString
 }
 
-func (q *Queries) FindPartiesByNarrationPattern(ctx context.Context, narration sql.NullString) ([]FindPartiesByNarrationPatternRow, error) {
-	rows, err := q.db.QueryContext(ctx, findPartiesByNarrationPattern, narration)
+func (q *Queries) FindPartiesByNarrationPattern(ctx context.Context, arg FindPartiesByNarrationPatternParams) ([]FindPartiesByNarrationPatternRow, error) {
+	rows, err := q.db.QueryContext(ctx, findPartiesByNarrationPattern, arg.Narration, arg.FirmID)
 	if err != nil {
 		return nil, err
 	}
@@ -279,7 +1337,14 @@ func (q *Queries) FindPartiesByNarrationPattern(ctx context.Context, narration s
 			&i.ID,
 			&i.Name,
 			&i.Location,
+			&i.CreditLimit,
+			&i.FirmID,
 			&i.CreatedAt,
+			&i.Email,
+			&i.Phone,
+			&i.Address,
+			&i.Gstin,
+			&i.DrugLicense,
 			&i.MatchNarration,
 		); err != nil {
 			return nil, err
@@ -296,9 +1361,11 @@ func (q *Queries) FindPartiesByNarrationPattern(ctx context.Context, narration s
 }
 
 const getAllPartiesWithStats = `-- name: GetAllPartiesWithStats :many
-SELECT p.id, p.name, p.location, p.created_at, COUNT(t.id) as transaction_count, COALESCE(SUM(t.amount), 0) as total_amount
+SELECT p.id, p.name, p.location, p.credit_limit, p.firm_id, p.created_at, p.email, p.phone, p.address, p.gstin, p.drug_license, COUNT(t.id) as transaction_count, COALESCE(SUM(t.amount), 0) as total_amount
 FROM parties p
-LEFT JOIN transactions t ON p.id = t.party_id
+LEFT JOIN transactions t ON p.id = t.party_id AND t.category = 'receipt'
+    AND NOT EXISTS (SELECT 1 FROM cheques c WHERE c.transaction_id = t.id AND c.status = 'bounced')
+WHERE p.firm_id = ?
 GROUP BY p.id
 ORDER BY transaction_count DESC
 `
@@ -307,13 +1374,20 @@ type GetAllPartiesWithStatsRow struct {
 	ID               int64
 	Name             string
 	Location         sql.NullString
+	CreditLimit      float64
+	FirmID           int64
 	CreatedAt        sql.NullTime
+	Email            string
+	Phone            string
+	Address          string
+	Gstin            string
+	DrugLicense      string
 	TransactionCount int64
 	TotalAmount      interface{}
 }
 
-func (q *Queries) GetAllPartiesWithStats(ctx context.Context) ([]GetAllPartiesWithStatsRow, error) {
-	rows, err := q.db.QueryContext(ctx, getAllPartiesWithStats)
+func (q *Queries) GetAllPartiesWithStats(ctx context.Context, firmID int64) ([]GetAllPartiesWithStatsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getAllPartiesWithStats, firmID)
 	if err != nil {
 		return nil, err
 	}
@@ -325,7 +1399,14 @@ func (q *Queries) GetAllPartiesWithStats(ctx context.Context) ([]GetAllPartiesWi
 			&i.ID,
 			&i.Name,
 			&i.Location,
+			&i.CreditLimit,
+			&i.FirmID,
 			&i.CreatedAt,
+			&i.Email,
+			&i.Phone,
+			&i.Address,
+			&i.Gstin,
+			&i.DrugLicense,
 			&i.TransactionCount,
 			&i.TotalAmount,
 		); err != nil {
@@ -342,47 +1423,56 @@ func (q *Queries) GetAllPartiesWithStats(ctx context.Context) ([]GetAllPartiesWi
 	return items, nil
 }
 
-const getIdentifierByTypeValue = `-- name: GetIdentifierByTypeValue :one
-SELECT id, party_id, type, value, created_at FROM identifiers WHERE type = ? AND value = ? LIMIT 1
+const getCheque = `-- name: GetCheque :one
+SELECT id, transaction_id, cheque_number, cheque_date, status, received_date, deposited_date, cleared_date, bounced_date, notes, updated_at FROM cheques WHERE id = ?
 `
 
-type GetIdentifierByTypeValueParams struct {
-	Type  string
-	Value string
-}
-
-func (q *Queries) GetIdentifierByTypeValue(ctx context.Context, arg GetIdentifierByTypeValueParams) (Identifier, error) {
-	row := q.db.QueryRowContext(ctx, getIdentifierByTypeValue, arg.Type, arg.Value)
-	var i Identifier
+func (q *Queries) GetCheque(ctx context.Context, id int64) (Cheque, error) {
+	row := q.db.QueryRowContext(ctx, getCheque, id)
+	var i Cheque
 	err := row.Scan(
 		&i.ID,
-		&i.PartyID,
-		&i.Type,
-		&i.Value,
-		&i.CreatedAt,
+		&i.TransactionID,
+		&i.ChequeNumber,
+		&i.ChequeDate,
+		&i.Status,
+		&i.ReceivedDate,
+		&i.DepositedDate,
+		&i.ClearedDate,
+		&i.BouncedDate,
+		&i.Notes,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const getIdentifiersByPartyID = `-- name: GetIdentifiersByPartyID :many
-SELECT id, party_id, type, value, created_at FROM identifiers WHERE party_id = ?
+const getChequesByPartyID = `-- name: GetChequesByPartyID :many
+SELECT c.id, c.transaction_id, c.cheque_number, c.cheque_date, c.status, c.received_date, c.deposited_date, c.cleared_date, c.bounced_date, c.notes, c.updated_at FROM cheques c
+JOIN transactions t ON t.id = c.transaction_id
+WHERE t.party_id = ?
 `
 
-func (q *Queries) GetIdentifiersByPartyID(ctx context.Context, partyID int64) ([]Identifier, error) {
-	rows, err := q.db.QueryContext(ctx, getIdentifiersByPartyID, partyID)
+func (q *Queries) GetChequesByPartyID(ctx context.Context, partyID int64) ([]Cheque, error) {
+	rows, err := q.db.QueryContext(ctx, getChequesByPartyID, partyID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Identifier
+	var items []Cheque
 	for rows.Next() {
-		var i Identifier
+		var i Cheque
 		if err := rows.Scan(
 			&i.ID,
-			&i.PartyID,
-			&i.Type,
-			&i.Value,
-			&i.CreatedAt,
+			&i.TransactionID,
+			&i.ChequeNumber,
+			&i.ChequeDate,
+			&i.Status,
+			&i.ReceivedDate,
+			&i.DepositedDate,
+			&i.ClearedDate,
+			&i.BouncedDate,
+			&i.Notes,
+			&i.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -397,100 +1487,133 @@ func (q *Queries) GetIdentifiersByPartyID(ctx context.Context, partyID int64) ([
 	return items, nil
 }
 
-const getPartyByID = `-- name: GetPartyByID :one
-SELECT id, name, location, created_at FROM parties WHERE id = ?
+const getCreditSaleBillsByPartyID = `-- name: GetCreditSaleBillsByPartyID :many
+SELECT id, bill_number, bill_date, party_name, amount, is_cash_sale, is_card_sale, party_id, firm_id, created_at, irn, ack_number, ack_date, taxable_value, cgst, sgst, igst FROM sale_bills
+WHERE party_id = ? AND COALESCE(is_cash_sale, FALSE) = FALSE AND is_card_sale = FALSE
+ORDER BY bill_date, id
 `
 
-func (q *Queries) GetPartyByID(ctx context.Context, id int64) (Party, error) {
-	row := q.db.QueryRowContext(ctx, getPartyByID, id)
-	var i Party
-	err := row.Scan(
-		&i.ID,
-		&i.Name,
-		&i.Location,
-		&i.CreatedAt,
-	)
-	return i, err
+func (q *Queries) GetCreditSaleBillsByPartyID(ctx context.Context, partyID sql.NullInt64) ([]SaleBill, error) {
+	rows, err := q.db.QueryContext(ctx, getCreditSaleBillsByPartyID, partyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SaleBill
+	for rows.Next() {
+		var i SaleBill
+		if err := rows.Scan(
+			&i.ID,
+			&i.BillNumber,
+			&i.BillDate,
+			&i.PartyName,
+			&i.Amount,
+			&i.IsCashSale,
+			&i.IsCardSale,
+			&i.PartyID,
+			&i.FirmID,
+			&i.CreatedAt,
+			&i.Irn,
+			&i.AckNumber,
+			&i.AckDate,
+			&i.TaxableValue,
+			&i.Cgst,
+			&i.Sgst,
+			&i.Igst,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const getPartyByName = `-- name: GetPartyByName :one
-SELECT id, name, location, created_at FROM parties WHERE name = ? LIMIT 1
+const getDailyCardSales = `-- name: GetDailyCardSales :many
+SELECT bill_date, COUNT(*) as bill_count, SUM(amount) as total_amount
+FROM sale_bills
+WHERE is_card_sale = TRUE AND bill_date >= ? AND bill_date <= ? AND firm_id = ?
+GROUP BY bill_date
+ORDER BY bill_date DESC
 `
 
-func (q *Queries) GetPartyByName(ctx context.Context, name string) (Party, error) {
-	row := q.db.QueryRowContext(ctx, getPartyByName, name)
-	var i Party
-	err := row.Scan(
-		&i.ID,
-		&i.Name,
-		&i.Location,
-		&i.CreatedAt,
-	)
-	return i, err
+type GetDailyCardSalesParams struct {
+	BillDate   time.Time
+	BillDate_2 time.Time
+	FirmID     int64
 }
 
-const getPartyWithTransactionCount = `-- name: GetPartyWithTransactionCount :one
-SELECT p.id, p.name, p.location, p.created_at, COUNT(t.id) as transaction_count, SUM(t.amount) as total_amount
-FROM parties p
-LEFT JOIN transactions t ON p.id = t.party_id
-WHERE p.id = ?
-GROUP BY p.id
-`
-
-type GetPartyWithTransactionCountRow struct {
-	ID               int64
-	Name             string
-	Location         sql.NullString
-	CreatedAt        sql.NullTime
-	TransactionCount int64
-	TotalAmount      sql.NullFloat64
+type GetDailyCardSalesRow struct {
+	BillDate    time.Time
+	BillCount   int64
+	TotalAmount sql.NullFloat64
 }
 
-func (q *Queries) GetPartyWithTransactionCount(ctx context.Context, id int64) (GetPartyWithTransactionCountRow, error) {
-	row := q.db.QueryRowContext(ctx, getPartyWithTransactionCount, id)
-	var i GetPartyWithTransactionCountRow
-	err := row.Scan(
-		&i.ID,
-		&i.Name,
-		&i.Location,
-		&i.CreatedAt,
-		&i.TransactionCount,
-		&i.TotalAmount,
-	)
-	return i, err
+func (q *Queries) GetDailyCardSales(ctx context.Context, arg GetDailyCardSalesParams) ([]GetDailyCardSalesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getDailyCardSales, arg.BillDate, arg.BillDate_2, arg.FirmID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDailyCardSalesRow
+	for rows.Next() {
+		var i GetDailyCardSalesRow
+		if err := rows.Scan(
+			&i.BillDate,
+			&i.BillCount,
+			&i.TotalAmount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const getRecentTransactionsByPartyID = `-- name: GetRecentTransactionsByPartyID :many
-SELECT id, party_id, amount, transaction_date, payment_mode, narration, cash_bank_code, cash_bank_location, created_at FROM transactions
-WHERE party_id = ?
-ORDER BY transaction_date DESC
-LIMIT ?
+const getDailyCashDeposits = `-- name: GetDailyCashDeposits :many
+SELECT transaction_date, COUNT(*) as deposit_count, SUM(amount) as total_amount
+FROM transactions
+WHERE is_internal = TRUE AND payment_mode = 'CASH' AND transaction_date >= ? AND transaction_date <= ? AND firm_id = ?
+GROUP BY transaction_date
+ORDER BY transaction_date
 `
 
-type GetRecentTransactionsByPartyIDParams struct {
-	PartyID int64
-	Limit   int64
+type GetDailyCashDepositsParams struct {
+	TransactionDate   time.Time
+	TransactionDate_2 time.Time
+	FirmID            int64
 }
 
-func (q *Queries) GetRecentTransactionsByPartyID(ctx context.Context, arg GetRecentTransactionsByPartyIDParams) ([]Transaction, error) {
-	rows, err := q.db.QueryContext(ctx, getRecentTransactionsByPartyID, arg.PartyID, arg.Limit)
+type GetDailyCashDepositsRow struct {
+	TransactionDate time.Time
+	DepositCount    int64
+	TotalAmount     sql.NullFloat64
+}
+
+func (q *Queries) GetDailyCashDeposits(ctx context.Context, arg GetDailyCashDepositsParams) ([]GetDailyCashDepositsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getDailyCashDeposits, arg.TransactionDate, arg.TransactionDate_2, arg.FirmID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Transaction
+	var items []GetDailyCashDepositsRow
 	for rows.Next() {
-		var i Transaction
+		var i GetDailyCashDepositsRow
 		if err := rows.Scan(
-			&i.ID,
-			&i.PartyID,
-			&i.Amount,
 			&i.TransactionDate,
-			&i.PaymentMode,
-			&i.Narration,
-			&i.CashBankCode,
-			&i.CashBankLocation,
-			&i.CreatedAt,
+			&i.DepositCount,
+			&i.TotalAmount,
 		); err != nil {
 			return nil, err
 		}
@@ -505,60 +1628,92 @@ func (q *Queries) GetRecentTransactionsByPartyID(ctx context.Context, arg GetRec
 	return items, nil
 }
 
-const getTransactionByDetails = `-- name: GetTransactionByDetails :one
-SELECT id, party_id, amount, transaction_date, payment_mode, narration, cash_bank_code, cash_bank_location, created_at FROM transactions
-WHERE amount = ? AND transaction_date = ? AND narration = ?
-LIMIT 1
+const getDailyCashDepositsByAccount = `-- name: GetDailyCashDepositsByAccount :many
+SELECT transaction_date, COUNT(*) as deposit_count, SUM(amount) as total_amount
+FROM transactions
+WHERE is_internal = TRUE AND payment_mode = 'CASH' AND transaction_date >= ? AND transaction_date <= ? AND firm_id = ? AND account_id = ?
+GROUP BY transaction_date
+ORDER BY transaction_date
 `
 
-type GetTransactionByDetailsParams struct {
-	Amount          float64
+type GetDailyCashDepositsByAccountParams struct {
+	TransactionDate   time.Time
+	TransactionDate_2 time.Time
+	FirmID            int64
+	AccountID         sql.NullInt64
+}
+
+type GetDailyCashDepositsByAccountRow struct {
 	TransactionDate time.Time
-	Narration       sql.NullString
+	DepositCount    int64
+	TotalAmount     sql.NullFloat64
 }
 
-func (q *Queries) GetTransactionByDetails(ctx context.Context, arg GetTransactionByDetailsParams) (Transaction, error) {
-	row := q.db.QueryRowContext(ctx, getTransactionByDetails, arg.Amount, arg.TransactionDate, arg.Narration)
-	var i Transaction
-	err := row.Scan(
-		&i.ID,
-		&i.PartyID,
-		&i.Amount,
-		&i.TransactionDate,
-		&i.PaymentMode,
-		&i.Narration,
-		&i.CashBankCode,
-		&i.CashBankLocation,
-		&i.CreatedAt,
+func (q *Queries) GetDailyCashDepositsByAccount(ctx context.Context, arg GetDailyCashDepositsByAccountParams) ([]GetDailyCashDepositsByAccountRow, error) {
+	rows, err := q.db.QueryContext(ctx, getDailyCashDepositsByAccount,
+		arg.TransactionDate,
+		arg.TransactionDate_2,
+		arg.FirmID,
+		arg.AccountID,
 	)
-	return i, err
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDailyCashDepositsByAccountRow
+	for rows.Next() {
+		var i GetDailyCashDepositsByAccountRow
+		if err := rows.Scan(
+			&i.TransactionDate,
+			&i.DepositCount,
+			&i.TotalAmount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const getTransactionsByPartyID = `-- name: GetTransactionsByPartyID :many
-SELECT id, party_id, amount, transaction_date, payment_mode, narration, cash_bank_code, cash_bank_location, created_at FROM transactions
-WHERE party_id = ?
-ORDER BY transaction_date DESC
+const getDailyCashSales = `-- name: GetDailyCashSales :many
+SELECT bill_date, COUNT(*) as bill_count, SUM(amount) as total_amount
+FROM sale_bills
+WHERE is_cash_sale = TRUE AND bill_date >= ? AND bill_date <= ? AND firm_id = ?
+GROUP BY bill_date
+ORDER BY bill_date
 `
 
-func (q *Queries) GetTransactionsByPartyID(ctx context.Context, partyID int64) ([]Transaction, error) {
-	rows, err := q.db.QueryContext(ctx, getTransactionsByPartyID, partyID)
+type GetDailyCashSalesParams struct {
+	BillDate   time.Time
+	BillDate_2 time.Time
+	FirmID     int64
+}
+
+type GetDailyCashSalesRow struct {
+	BillDate    time.Time
+	BillCount   int64
+	TotalAmount sql.NullFloat64
+}
+
+func (q *Queries) GetDailyCashSales(ctx context.Context, arg GetDailyCashSalesParams) ([]GetDailyCashSalesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getDailyCashSales, arg.BillDate, arg.BillDate_2, arg.FirmID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Transaction
+	var items []GetDailyCashSalesRow
 	for rows.Next() {
-		var i Transaction
+		var i GetDailyCashSalesRow
 		if err := rows.Scan(
-			&i.ID,
-			&i.PartyID,
-			&i.Amount,
-			&i.TransactionDate,
-			&i.PaymentMode,
-			&i.Narration,
-			&i.CashBankCode,
-			&i.CashBankLocation,
-			&i.CreatedAt,
+			&i.BillDate,
+			&i.BillCount,
+			&i.TotalAmount,
 		); err != nil {
 			return nil, err
 		}
@@ -573,24 +1728,39 @@ func (q *Queries) GetTransactionsByPartyID(ctx context.Context, partyID int64) (
 	return items, nil
 }
 
-const listParties = `-- name: ListParties :many
-SELECT id, name, location, created_at FROM parties ORDER BY name
+const getDailyPOSCollections = `-- name: GetDailyPOSCollections :many
+SELECT settlement_date, COUNT(*) as settlement_count, SUM(amount) as total_amount
+FROM pos_settlements
+WHERE settlement_date >= ? AND settlement_date <= ? AND firm_id = ?
+GROUP BY settlement_date
+ORDER BY settlement_date DESC
 `
 
-func (q *Queries) ListParties(ctx context.Context) ([]Party, error) {
-	rows, err := q.db.QueryContext(ctx, listParties)
+type GetDailyPOSCollectionsParams struct {
+	SettlementDate   time.Time
+	SettlementDate_2 time.Time
+	FirmID           int64
+}
+
+type GetDailyPOSCollectionsRow struct {
+	SettlementDate  time.Time
+	SettlementCount int64
+	TotalAmount     sql.NullFloat64
+}
+
+func (q *Queries) GetDailyPOSCollections(ctx context.Context, arg GetDailyPOSCollectionsParams) ([]GetDailyPOSCollectionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getDailyPOSCollections, arg.SettlementDate, arg.SettlementDate_2, arg.FirmID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Party
+	var items []GetDailyPOSCollectionsRow
 	for rows.Next() {
-		var i Party
+		var i GetDailyPOSCollectionsRow
 		if err := rows.Scan(
-			&i.ID,
-			&i.Name,
-			&i.Location,
-			&i.CreatedAt,
+			&i.SettlementDate,
+			&i.SettlementCount,
+			&i.TotalAmount,
 		); err != nil {
 			return nil, err
 		}
@@ -605,42 +1775,202 @@ func (q *Queries) ListParties(ctx context.Context) ([]Party, error) {
 	return items, nil
 }
 
-const searchSaleBillsByAmountRange = `-- name: SearchSaleBillsByAmountRange :many
-SELECT id, bill_number, bill_date, party_name, amount, is_cash_sale, created_at FROM sale_bills
-WHERE amount >= ? AND amount <= ?
-  AND bill_date >= ? AND bill_date <= ?
-ORDER BY bill_date DESC, amount DESC
-LIMIT 100
+const getDailyPOSCollectionsByAccount = `-- name: GetDailyPOSCollectionsByAccount :many
+SELECT settlement_date, COUNT(*) as settlement_count, SUM(amount) as total_amount
+FROM pos_settlements
+WHERE settlement_date >= ? AND settlement_date <= ? AND firm_id = ? AND account_id = ?
+GROUP BY settlement_date
+ORDER BY settlement_date DESC
 `
 
-type SearchSaleBillsByAmountRangeParams struct {
-	Amount     float64
-	Amount_2   float64
-	BillDate   time.Time
-	BillDate_2 time.Time
+type GetDailyPOSCollectionsByAccountParams struct {
+	SettlementDate   time.Time
+	SettlementDate_2 time.Time
+	FirmID           int64
+	AccountID        sql.NullInt64
 }
 
-func (q *Queries) SearchSaleBillsByAmountRange(ctx context.Context, arg SearchSaleBillsByAmountRangeParams) ([]SaleBill, error) {
-	rows, err := q.db.QueryContext(ctx, searchSaleBillsByAmountRange,
-		arg.Amount,
-		arg.Amount_2,
-		arg.BillDate,
-		arg.BillDate_2,
+type GetDailyPOSCollectionsByAccountRow struct {
+	SettlementDate  time.Time
+	SettlementCount int64
+	TotalAmount     sql.NullFloat64
+}
+
+func (q *Queries) GetDailyPOSCollectionsByAccount(ctx context.Context, arg GetDailyPOSCollectionsByAccountParams) ([]GetDailyPOSCollectionsByAccountRow, error) {
+	rows, err := q.db.QueryContext(ctx, getDailyPOSCollectionsByAccount,
+		arg.SettlementDate,
+		arg.SettlementDate_2,
+		arg.FirmID,
+		arg.AccountID,
 	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []SaleBill
+	var items []GetDailyPOSCollectionsByAccountRow
 	for rows.Next() {
-		var i SaleBill
+		var i GetDailyPOSCollectionsByAccountRow
+		if err := rows.Scan(
+			&i.SettlementDate,
+			&i.SettlementCount,
+			&i.TotalAmount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFinancialYear = `-- name: GetFinancialYear :one
+SELECT id, firm_id, label, start_date, end_date, closed_at, archive_path, archived_at FROM financial_years WHERE id = ? AND firm_id = ?
+`
+
+type GetFinancialYearParams struct {
+	ID     int64
+	FirmID int64
+}
+
+func (q *Queries) GetFinancialYear(ctx context.Context, arg GetFinancialYearParams) (FinancialYear, error) {
+	row := q.db.QueryRowContext(ctx, getFinancialYear, arg.ID, arg.FirmID)
+	var i FinancialYear
+	err := row.Scan(
+		&i.ID,
+		&i.FirmID,
+		&i.Label,
+		&i.StartDate,
+		&i.EndDate,
+		&i.ClosedAt,
+		&i.ArchivePath,
+		&i.ArchivedAt,
+	)
+	return i, err
+}
+
+const getFinancialYearForDate = `-- name: GetFinancialYearForDate :one
+SELECT id, firm_id, label, start_date, end_date, closed_at, archive_path, archived_at FROM financial_years WHERE firm_id = ? AND start_date <= ? AND end_date >= ?
+`
+
+type GetFinancialYearForDateParams struct {
+	FirmID    int64
+	StartDate time.Time
+	EndDate   time.Time
+}
+
+func (q *Queries) GetFinancialYearForDate(ctx context.Context, arg GetFinancialYearForDateParams) (FinancialYear, error) {
+	row := q.db.QueryRowContext(ctx, getFinancialYearForDate, arg.FirmID, arg.StartDate, arg.EndDate)
+	var i FinancialYear
+	err := row.Scan(
+		&i.ID,
+		&i.FirmID,
+		&i.Label,
+		&i.StartDate,
+		&i.EndDate,
+		&i.ClosedAt,
+		&i.ArchivePath,
+		&i.ArchivedAt,
+	)
+	return i, err
+}
+
+const getFirm = `-- name: GetFirm :one
+SELECT id, name, gstin, created_at, rounding_tolerance FROM firms WHERE id = ?
+`
+
+func (q *Queries) GetFirm(ctx context.Context, id int64) (Firm, error) {
+	row := q.db.QueryRowContext(ctx, getFirm, id)
+	var i Firm
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Gstin,
+		&i.CreatedAt,
+		&i.RoundingTolerance,
+	)
+	return i, err
+}
+
+const getIdentifierByTypeValue = `-- name: GetIdentifierByTypeValue :one
+SELECT id, party_id, type, value, firm_id, first_seen, last_seen, hit_count, created_at FROM identifiers WHERE type = ? AND value = ? AND firm_id = ? LIMIT 1
+`
+
+type GetIdentifierByTypeValueParams struct {
+	Type   string
+	Value  string
+	FirmID int64
+}
+
+func (q *Queries) GetIdentifierByTypeValue(ctx context.Context, arg GetIdentifierByTypeValueParams) (Identifier, error) {
+	row := q.db.QueryRowContext(ctx, getIdentifierByTypeValue, arg.Type, arg.Value, arg.FirmID)
+	var i Identifier
+	err := row.Scan(
+		&i.ID,
+		&i.PartyID,
+		&i.Type,
+		&i.Value,
+		&i.FirmID,
+		&i.FirstSeen,
+		&i.LastSeen,
+		&i.HitCount,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getIdentifierConflict = `-- name: GetIdentifierConflict :one
+SELECT id, firm_id, type, value, claimed_party_id, narration, resolution, found_at, resolved_at FROM identifier_conflicts WHERE id = ? AND firm_id = ?
+`
+
+type GetIdentifierConflictParams struct {
+	ID     int64
+	FirmID int64
+}
+
+func (q *Queries) GetIdentifierConflict(ctx context.Context, arg GetIdentifierConflictParams) (IdentifierConflict, error) {
+	row := q.db.QueryRowContext(ctx, getIdentifierConflict, arg.ID, arg.FirmID)
+	var i IdentifierConflict
+	err := row.Scan(
+		&i.ID,
+		&i.FirmID,
+		&i.Type,
+		&i.Value,
+		&i.ClaimedPartyID,
+		&i.Narration,
+		&i.Resolution,
+		&i.FoundAt,
+		&i.ResolvedAt,
+	)
+	return i, err
+}
+
+const getIdentifiersByPartyID = `-- name: GetIdentifiersByPartyID :many
+SELECT id, party_id, type, value, firm_id, first_seen, last_seen, hit_count, created_at FROM identifiers WHERE party_id = ?
+`
+
+func (q *Queries) GetIdentifiersByPartyID(ctx context.Context, partyID int64) ([]Identifier, error) {
+	rows, err := q.db.QueryContext(ctx, getIdentifiersByPartyID, partyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Identifier
+	for rows.Next() {
+		var i Identifier
 		if err := rows.Scan(
 			&i.ID,
-			&i.BillNumber,
-			&i.BillDate,
-			&i.PartyName,
-			&i.Amount,
-			&i.IsCashSale,
+			&i.PartyID,
+			&i.Type,
+			&i.Value,
+			&i.FirmID,
+			&i.FirstSeen,
+			&i.LastSeen,
+			&i.HitCount,
 			&i.CreatedAt,
 		); err != nil {
 			return nil, err
@@ -655,3 +1985,5580 @@ func (q *Queries) SearchSaleBillsByAmountRange(ctx context.Context, arg SearchSa
 	}
 	return items, nil
 }
+
+const getLatestAccountTransaction = `-- name: GetLatestAccountTransaction :one
+SELECT id, party_id, amount, transaction_date, payment_mode, narration, cash_bank_code, cash_bank_location, category, is_internal, account_id, note, firm_id, created_at, import_batch_id FROM transactions
+WHERE account_id = ?
+ORDER BY transaction_date DESC, id DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLatestAccountTransaction(ctx context.Context, accountID sql.NullInt64) (Transaction, error) {
+	row := q.db.QueryRowContext(ctx, getLatestAccountTransaction, accountID)
+	var i Transaction
+	err := row.Scan(
+		&i.ID,
+		&i.PartyID,
+		&i.Amount,
+		&i.TransactionDate,
+		&i.PaymentMode,
+		&i.Narration,
+		&i.CashBankCode,
+		&i.CashBankLocation,
+		&i.Category,
+		&i.IsInternal,
+		&i.AccountID,
+		&i.Note,
+		&i.FirmID,
+		&i.CreatedAt,
+		&i.ImportBatchID,
+	)
+	return i, err
+}
+
+const getLatestBackup = `-- name: GetLatestBackup :one
+SELECT id, filename, size_bytes, created_at FROM backups ORDER BY created_at DESC, id DESC LIMIT 1
+`
+
+func (q *Queries) GetLatestBackup(ctx context.Context) (Backup, error) {
+	row := q.db.QueryRowContext(ctx, getLatestBackup)
+	var i Backup
+	err := row.Scan(
+		&i.ID,
+		&i.Filename,
+		&i.SizeBytes,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getLatestSearch = `-- name: GetLatestSearch :one
+SELECT id, narration, top_party_id, top_party_name, top_confidence, result_count, searched_at, firm_id FROM search_history WHERE firm_id = ? ORDER BY searched_at DESC, id DESC LIMIT 1
+`
+
+func (q *Queries) GetLatestSearch(ctx context.Context, firmID int64) (SearchHistory, error) {
+	row := q.db.QueryRowContext(ctx, getLatestSearch, firmID)
+	var i SearchHistory
+	err := row.Scan(
+		&i.ID,
+		&i.Narration,
+		&i.TopPartyID,
+		&i.TopPartyName,
+		&i.TopConfidence,
+		&i.ResultCount,
+		&i.SearchedAt,
+		&i.FirmID,
+	)
+	return i, err
+}
+
+const getMergedTransactionByDetails = `-- name: GetMergedTransactionByDetails :one
+SELECT transaction_id FROM transaction_merges
+WHERE superseded_amount = ? AND superseded_date = ? AND superseded_narration = ? AND firm_id = ?
+LIMIT 1
+`
+
+type GetMergedTransactionByDetailsParams struct {
+	SupersededAmount    float64
+	SupersededDate      time.Time
+	SupersededNarration sql.NullString
+	FirmID              int64
+}
+
+func (q *Queries) GetMergedTransactionByDetails(ctx context.Context, arg GetMergedTransactionByDetailsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getMergedTransactionByDetails,
+		arg.SupersededAmount,
+		arg.SupersededDate,
+		arg.SupersededNarration,
+		arg.FirmID,
+	)
+	var transaction_id int64
+	err := row.Scan(&transaction_id)
+	return transaction_id, err
+}
+
+const getNotesByPartyID = `-- name: GetNotesByPartyID :many
+SELECT id, party_id, note, created_at FROM party_notes WHERE party_id = ? ORDER BY created_at DESC, id DESC
+`
+
+func (q *Queries) GetNotesByPartyID(ctx context.Context, partyID int64) ([]PartyNote, error) {
+	rows, err := q.db.QueryContext(ctx, getNotesByPartyID, partyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PartyNote
+	for rows.Next() {
+		var i PartyNote
+		if err := rows.Scan(
+			&i.ID,
+			&i.PartyID,
+			&i.Note,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOutstandingSummary = `-- name: GetOutstandingSummary :one
+SELECT CAST(COUNT(CASE WHEN pb.billed - pb.received > 0.005 THEN 1 END) AS INTEGER) as owing,
+    CAST(ROUND(COALESCE(SUM(CASE WHEN pb.billed - pb.received > 0.005 THEN pb.billed - pb.received END), 0), 2) AS REAL) as outstanding,
+    CAST(ROUND(COALESCE(SUM(CASE WHEN pb.received - pb.billed > 0.005 THEN pb.received - pb.billed END), 0), 2) AS REAL) as advances
+FROM party_balances pb
+JOIN parties p ON p.id = pb.party_id
+WHERE p.firm_id = ?
+`
+
+type GetOutstandingSummaryRow struct {
+	Owing       int64
+	Outstanding float64
+	Advances    float64
+}
+
+func (q *Queries) GetOutstandingSummary(ctx context.Context, firmID int64) (GetOutstandingSummaryRow, error) {
+	row := q.db.QueryRowContext(ctx, getOutstandingSummary, firmID)
+	var i GetOutstandingSummaryRow
+	err := row.Scan(
+		&i.Owing,
+		&i.Outstanding,
+		&i.Advances,
+	)
+	return i, err
+}
+
+const getPartyAccountActivity = `-- name: GetPartyAccountActivity :one
+SELECT COUNT(*) as transaction_count, CAST(COALESCE(SUM(amount), 0) AS REAL) as total_amount
+FROM transactions t
+WHERE t.party_id = ? AND t.account_id = ? AND t.category = 'receipt'
+    AND NOT EXISTS (SELECT 1 FROM cheques c WHERE c.transaction_id = t.id AND c.status = 'bounced')
+`
+
+type GetPartyAccountActivityParams struct {
+	PartyID   int64
+	AccountID sql.NullInt64
+}
+
+type GetPartyAccountActivityRow struct {
+	TransactionCount int64
+	TotalAmount      float64
+}
+
+func (q *Queries) GetPartyAccountActivity(ctx context.Context, arg GetPartyAccountActivityParams) (GetPartyAccountActivityRow, error) {
+	row := q.db.QueryRowContext(ctx, getPartyAccountActivity, arg.PartyID, arg.AccountID)
+	var i GetPartyAccountActivityRow
+	err := row.Scan(
+		&i.TransactionCount,
+		&i.TotalAmount,
+	)
+	return i, err
+}
+
+const getPartyBalance = `-- name: GetPartyBalance :one
+SELECT p.id, p.name, p.location, p.credit_limit, p.firm_id, p.created_at, p.email, p.phone, p.address, p.gstin, p.drug_license, CAST(COALESCE(r.receipt_count, 0) AS INTEGER) as receipt_count,
+    CAST(COALESCE(b.billed, 0) AS REAL) as billed, CAST(COALESCE(r.received, 0) AS REAL) as received
+FROM parties p
+LEFT JOIN (
+    SELECT party_id, SUM(amount) as billed
+    FROM sale_bills
+    WHERE party_id IS NOT NULL AND COALESCE(is_cash_sale, FALSE) = FALSE AND is_card_sale = FALSE
+    GROUP BY party_id
+) b ON b.party_id = p.id
+LEFT JOIN (
+    SELECT t.party_id, COUNT(*) as receipt_count, SUM(t.amount) as received
+    FROM transactions t
+    WHERE t.category = 'receipt'
+      AND NOT EXISTS (SELECT 1 FROM cheques c WHERE c.transaction_id = t.id AND c.status = 'bounced')
+    GROUP BY t.party_id
+) r ON r.party_id = p.id
+WHERE p.id = ?
+`
+
+type GetPartyBalanceRow struct {
+	ID           int64
+	Name         string
+	Location     sql.NullString
+	CreditLimit  float64
+	FirmID       int64
+	CreatedAt    sql.NullTime
+	Email        string
+	Phone        string
+	Address      string
+	Gstin        string
+	DrugLicense  string
+	ReceiptCount int64
+	Billed       float64
+	Received     float64
+}
+
+func (q *Queries) GetPartyBalance(ctx context.Context, id int64) (GetPartyBalanceRow, error) {
+	row := q.db.QueryRowContext(ctx, getPartyBalance, id)
+	var i GetPartyBalanceRow
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Location,
+		&i.CreditLimit,
+		&i.FirmID,
+		&i.CreatedAt,
+		&i.Email,
+		&i.Phone,
+		&i.Address,
+		&i.Gstin,
+		&i.DrugLicense,
+		&i.ReceiptCount,
+		&i.Billed,
+		&i.Received,
+	)
+	return i, err
+}
+
+const getPartyByID = `-- name: GetPartyByID :one
+SELECT id, name, location, credit_limit, firm_id, created_at, email, phone, address, gstin, drug_license FROM parties WHERE id = ?
+`
+
+func (q *Queries) GetPartyByID(ctx context.Context, id int64) (Party, error) {
+	row := q.db.QueryRowContext(ctx, getPartyByID, id)
+	var i Party
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Location,
+		&i.CreditLimit,
+		&i.FirmID,
+		&i.CreatedAt,
+		&i.Email,
+		&i.Phone,
+		&i.Address,
+		&i.Gstin,
+		&i.DrugLicense,
+	)
+	return i, err
+}
+
+const getPartyByName = `-- name: GetPartyByName :one
+SELECT id, name, location, credit_limit, firm_id, created_at, email, phone, address, gstin, drug_license FROM parties WHERE name = ? AND firm_id = ? LIMIT 1
+`
+
+type GetPartyByNameParams struct {
+	Name   string
+	FirmID int64
+}
+
+func (q *Queries) GetPartyByName(ctx context.Context, arg GetPartyByNameParams) (Party, error) {
+	row := q.db.QueryRowContext(ctx, getPartyByName, arg.Name, arg.FirmID)
+	var i Party
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Location,
+		&i.CreditLimit,
+		&i.FirmID,
+		&i.CreatedAt,
+		&i.Email,
+		&i.Phone,
+		&i.Address,
+		&i.Gstin,
+		&i.DrugLicense,
+	)
+	return i, err
+}
+
+const getPartyBySaleBillID = `-- name: GetPartyBySaleBillID :one
+SELECT p.id, p.name, p.location, p.credit_limit, p.firm_id, p.created_at, p.email, p.phone, p.address, p.gstin, p.drug_license FROM parties p
+JOIN sale_bills b ON b.party_id = p.id
+WHERE b.id = ?
+`
+
+func (q *Queries) GetPartyBySaleBillID(ctx context.Context, id int64) (Party, error) {
+	row := q.db.QueryRowContext(ctx, getPartyBySaleBillID, id)
+	var i Party
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Location,
+		&i.CreditLimit,
+		&i.FirmID,
+		&i.CreatedAt,
+		&i.Email,
+		&i.Phone,
+		&i.Address,
+		&i.Gstin,
+		&i.DrugLicense,
+	)
+	return i, err
+}
+
+const getPartyMerge = `-- name: GetPartyMerge :one
+SELECT id, firm_id, merged_party_id, merged_name, survivor_party_id, merged_at FROM party_merges WHERE merged_party_id = ? LIMIT 1
+`
+
+func (q *Queries) GetPartyMerge(ctx context.Context, mergedPartyID int64) (PartyMerge, error) {
+	row := q.db.QueryRowContext(ctx, getPartyMerge, mergedPartyID)
+	var i PartyMerge
+	err := row.Scan(
+		&i.ID,
+		&i.FirmID,
+		&i.MergedPartyID,
+		&i.MergedName,
+		&i.SurvivorPartyID,
+		&i.MergedAt,
+	)
+	return i, err
+}
+
+const getPartyMergeByName = `-- name: GetPartyMergeByName :one
+SELECT id, firm_id, merged_party_id, merged_name, survivor_party_id, merged_at FROM party_merges WHERE merged_name = ? AND firm_id = ? ORDER BY id DESC LIMIT 1
+`
+
+type GetPartyMergeByNameParams struct {
+	MergedName string
+	FirmID     int64
+}
+
+func (q *Queries) GetPartyMergeByName(ctx context.Context, arg GetPartyMergeByNameParams) (PartyMerge, error) {
+	row := q.db.QueryRowContext(ctx, getPartyMergeByName, arg.MergedName, arg.FirmID)
+	var i PartyMerge
+	err := row.Scan(
+		&i.ID,
+		&i.FirmID,
+		&i.MergedPartyID,
+		&i.MergedName,
+		&i.SurvivorPartyID,
+		&i.MergedAt,
+	)
+	return i, err
+}
+
+const getPartyPaymentModes = `-- name: GetPartyPaymentModes :many
+SELECT COALESCE(t.payment_mode, '') as payment_mode, COUNT(*) as receipts
+FROM transactions t
+WHERE t.party_id = ? AND t.category = 'receipt'
+    AND NOT EXISTS (SELECT 1 FROM cheques c WHERE c.transaction_id = t.id AND c.status = 'bounced')
+GROUP BY COALESCE(t.payment_mode, '')
+`
+
+type GetPartyPaymentModesRow struct {
+	PaymentMode string
+	Receipts    int64
+}
+
+func (q *Queries) GetPartyPaymentModes(ctx context.Context, partyID int64) ([]GetPartyPaymentModesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPartyPaymentModes, partyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPartyPaymentModesRow
+	for rows.Next() {
+		var i GetPartyPaymentModesRow
+		if err := rows.Scan(&i.PaymentMode, &i.Receipts); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPartyReceiptAmounts = `-- name: GetPartyReceiptAmounts :many
+SELECT t.amount FROM transactions t
+WHERE t.party_id = ? AND t.category = 'receipt' AND t.amount > 0
+    AND NOT EXISTS (SELECT 1 FROM cheques c WHERE c.transaction_id = t.id AND c.status = 'bounced')
+ORDER BY t.transaction_date DESC
+LIMIT ?
+`
+
+type GetPartyReceiptAmountsParams struct {
+	PartyID int64
+	Limit   int64
+}
+
+func (q *Queries) GetPartyReceiptAmounts(ctx context.Context, arg GetPartyReceiptAmountsParams) ([]float64, error) {
+	rows, err := q.db.QueryContext(ctx, getPartyReceiptAmounts, arg.PartyID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []float64
+	for rows.Next() {
+		var amount float64
+		if err := rows.Scan(&amount); err != nil {
+			return nil, err
+		}
+		items = append(items, amount)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPartyWithTransactionCount = `-- name: GetPartyWithTransactionCount :one
+SELECT p.id, p.name, p.location, p.credit_limit, p.firm_id, p.created_at, p.email, p.phone, p.address, p.gstin, p.drug_license, COUNT(t.id) as transaction_count, SUM(t.amount) as total_amount
+FROM parties p
+LEFT JOIN transactions t ON p.id = t.party_id AND t.category = 'receipt'
+    AND NOT EXISTS (SELECT 1 FROM cheques c WHERE c.transaction_id = t.id AND c.status = 'bounced')
+WHERE p.id = ?
+GROUP BY p.id
+`
+
+type GetPartyWithTransactionCountRow struct {
+	ID               int64
+	Name             string
+	Location         sql.NullString
+	CreditLimit      float64
+	FirmID           int64
+	CreatedAt        sql.NullTime
+	Email            string
+	Phone            string
+	Address          string
+	Gstin            string
+	DrugLicense      string
+	TransactionCount int64
+	TotalAmount      sql.NullFloat64
+}
+
+func (q *Queries) GetPartyWithTransactionCount(ctx context.Context, id int64) (GetPartyWithTransactionCountRow, error) {
+	row := q.db.QueryRowContext(ctx, getPartyWithTransactionCount, id)
+	var i GetPartyWithTransactionCountRow
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Location,
+		&i.CreditLimit,
+		&i.FirmID,
+		&i.CreatedAt,
+		&i.Email,
+		&i.Phone,
+		&i.Address,
+		&i.Gstin,
+		&i.DrugLicense,
+		&i.TransactionCount,
+		&i.TotalAmount,
+	)
+	return i, err
+}
+
+const getPaymentModeRule = `-- name: GetPaymentModeRule :one
+SELECT id, pattern, mode, priority, created_at FROM payment_mode_rules WHERE id = ?
+`
+
+func (q *Queries) GetPaymentModeRule(ctx context.Context, id int64) (PaymentModeRule, error) {
+	row := q.db.QueryRowContext(ctx, getPaymentModeRule, id)
+	var i PaymentModeRule
+	err := row.Scan(
+		&i.ID,
+		&i.Pattern,
+		&i.Mode,
+		&i.Priority,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getPendingImport = `-- name: GetPendingImport :one
+SELECT id, kind, filename, content, sender, subject, message_id, received_at, entries, status, resolved_at, created_at FROM pending_imports WHERE id = ?
+`
+
+func (q *Queries) GetPendingImport(ctx context.Context, id int64) (PendingImport, error) {
+	row := q.db.QueryRowContext(ctx, getPendingImport, id)
+	var i PendingImport
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.Filename,
+		&i.Content,
+		&i.Sender,
+		&i.Subject,
+		&i.MessageID,
+		&i.ReceivedAt,
+		&i.Entries,
+		&i.Status,
+		&i.ResolvedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getRecentTransactionsByPartyID = `-- name: GetRecentTransactionsByPartyID :many
+SELECT id, party_id, amount, transaction_date, payment_mode, narration, cash_bank_code, cash_bank_location, category, is_internal, account_id, note, firm_id, created_at, import_batch_id FROM transactions
+WHERE party_id = ?
+ORDER BY transaction_date DESC
+LIMIT ?
+`
+
+type GetRecentTransactionsByPartyIDParams struct {
+	PartyID int64
+	Limit   int64
+}
+
+func (q *Queries) GetRecentTransactionsByPartyID(ctx context.Context, arg GetRecentTransactionsByPartyIDParams) ([]Transaction, error) {
+	rows, err := q.db.QueryContext(ctx, getRecentTransactionsByPartyID, arg.PartyID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Transaction
+	for rows.Next() {
+		var i Transaction
+		if err := rows.Scan(
+			&i.ID,
+			&i.PartyID,
+			&i.Amount,
+			&i.TransactionDate,
+			&i.PaymentMode,
+			&i.Narration,
+			&i.CashBankCode,
+			&i.CashBankLocation,
+			&i.Category,
+			&i.IsInternal,
+			&i.AccountID,
+			&i.Note,
+			&i.FirmID,
+			&i.CreatedAt,
+			&i.ImportBatchID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRule = `-- name: GetRule :one
+SELECT id, name, narration_pattern, party_pattern, min_amount, max_amount, set_category, set_internal, set_party_name, priority, enabled, created_at FROM rules WHERE id = ?
+`
+
+func (q *Queries) GetRule(ctx context.Context, id int64) (Rule, error) {
+	row := q.db.QueryRowContext(ctx, getRule, id)
+	var i Rule
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.NarrationPattern,
+		&i.PartyPattern,
+		&i.MinAmount,
+		&i.MaxAmount,
+		&i.SetCategory,
+		&i.SetInternal,
+		&i.SetPartyName,
+		&i.Priority,
+		&i.Enabled,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getSaleBillByID = `-- name: GetSaleBillByID :one
+SELECT id, bill_number, bill_date, party_name, amount, is_cash_sale, is_card_sale, party_id, firm_id, created_at, irn, ack_number, ack_date, taxable_value, cgst, sgst, igst FROM sale_bills WHERE id = ?
+`
+
+func (q *Queries) GetSaleBillByID(ctx context.Context, id int64) (SaleBill, error) {
+	row := q.db.QueryRowContext(ctx, getSaleBillByID, id)
+	var i SaleBill
+	err := row.Scan(
+		&i.ID,
+		&i.BillNumber,
+		&i.BillDate,
+		&i.PartyName,
+		&i.Amount,
+		&i.IsCashSale,
+		&i.IsCardSale,
+		&i.PartyID,
+		&i.FirmID,
+		&i.CreatedAt,
+		&i.Irn,
+		&i.AckNumber,
+		&i.AckDate,
+		&i.TaxableValue,
+		&i.Cgst,
+		&i.Sgst,
+		&i.Igst,
+	)
+	return i, err
+}
+
+const getSaleBillsByPartyID = `-- name: GetSaleBillsByPartyID :many
+SELECT id, bill_number, bill_date, party_name, amount, is_cash_sale, is_card_sale, party_id, firm_id, created_at, irn, ack_number, ack_date, taxable_value, cgst, sgst, igst FROM sale_bills
+WHERE party_id = ?
+ORDER BY bill_date DESC, id DESC
+`
+
+func (q *Queries) GetSaleBillsByPartyID(ctx context.Context, partyID sql.NullInt64) ([]SaleBill, error) {
+	rows, err := q.db.QueryContext(ctx, getSaleBillsByPartyID, partyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SaleBill
+	for rows.Next() {
+		var i SaleBill
+		if err := rows.Scan(
+			&i.ID,
+			&i.BillNumber,
+			&i.BillDate,
+			&i.PartyName,
+			&i.Amount,
+			&i.IsCashSale,
+			&i.IsCardSale,
+			&i.PartyID,
+			&i.FirmID,
+			&i.CreatedAt,
+			&i.Irn,
+			&i.AckNumber,
+			&i.AckDate,
+			&i.TaxableValue,
+			&i.Cgst,
+			&i.Sgst,
+			&i.Igst,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSplitTransactionByDetails = `-- name: GetSplitTransactionByDetails :one
+SELECT s.source_transaction_id FROM transaction_splits s
+JOIN transactions t ON t.id = s.source_transaction_id
+WHERE s.original_amount = ? AND t.transaction_date = ? AND t.narration = ? AND t.firm_id = ?
+LIMIT 1
+`
+
+type GetSplitTransactionByDetailsParams struct {
+	OriginalAmount  float64
+	TransactionDate time.Time
+	Narration       sql.NullString
+	FirmID          int64
+}
+
+func (q *Queries) GetSplitTransactionByDetails(ctx context.Context, arg GetSplitTransactionByDetailsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getSplitTransactionByDetails,
+		arg.OriginalAmount,
+		arg.TransactionDate,
+		arg.Narration,
+		arg.FirmID,
+	)
+	var source_transaction_id int64
+	err := row.Scan(&source_transaction_id)
+	return source_transaction_id, err
+}
+
+const getStatementLinkByToken = `-- name: GetStatementLinkByToken :one
+SELECT id, party_id, token, expires_at, created_at FROM statement_links WHERE token = ?
+`
+
+func (q *Queries) GetStatementLinkByToken(ctx context.Context, token string) (StatementLink, error) {
+	row := q.db.QueryRowContext(ctx, getStatementLinkByToken, token)
+	var i StatementLink
+	err := row.Scan(
+		&i.ID,
+		&i.PartyID,
+		&i.Token,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getSyncLogByClientID = `-- name: GetSyncLogByClientID :one
+SELECT id, client_id, kind, summary, created_at FROM sync_log WHERE client_id = ?
+`
+
+func (q *Queries) GetSyncLogByClientID(ctx context.Context, clientID string) (SyncLog, error) {
+	row := q.db.QueryRowContext(ctx, getSyncLogByClientID, clientID)
+	var i SyncLog
+	err := row.Scan(
+		&i.ID,
+		&i.ClientID,
+		&i.Kind,
+		&i.Summary,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getTransactionAgent = `-- name: GetTransactionAgent :one
+SELECT id, transaction_id, agent_id, source, created_at FROM transaction_agents WHERE transaction_id = ?
+`
+
+func (q *Queries) GetTransactionAgent(ctx context.Context, transactionID int64) (TransactionAgent, error) {
+	row := q.db.QueryRowContext(ctx, getTransactionAgent, transactionID)
+	var i TransactionAgent
+	err := row.Scan(
+		&i.ID,
+		&i.TransactionID,
+		&i.AgentID,
+		&i.Source,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getTransactionByDetails = `-- name: GetTransactionByDetails :one
+SELECT id, party_id, amount, transaction_date, payment_mode, narration, cash_bank_code, cash_bank_location, category, is_internal, account_id, note, firm_id, created_at, import_batch_id FROM transactions
+WHERE amount = ? AND transaction_date = ? AND narration = ? AND firm_id = ?
+LIMIT 1
+`
+
+type GetTransactionByDetailsParams struct {
+	Amount          float64
+	TransactionDate time.Time
+	Narration       sql.NullString
+	FirmID          int64
+}
+
+func (q *Queries) GetTransactionByDetails(ctx context.Context, arg GetTransactionByDetailsParams) (Transaction, error) {
+	row := q.db.QueryRowContext(ctx, getTransactionByDetails,
+		arg.Amount,
+		arg.TransactionDate,
+		arg.Narration,
+		arg.FirmID,
+	)
+	var i Transaction
+	err := row.Scan(
+		&i.ID,
+		&i.PartyID,
+		&i.Amount,
+		&i.TransactionDate,
+		&i.PaymentMode,
+		&i.Narration,
+		&i.CashBankCode,
+		&i.CashBankLocation,
+		&i.Category,
+		&i.IsInternal,
+		&i.AccountID,
+		&i.Note,
+		&i.FirmID,
+		&i.CreatedAt,
+		&i.ImportBatchID,
+	)
+	return i, err
+}
+
+const getTransactionByID = `-- name: GetTransactionByID :one
+SELECT id, party_id, amount, transaction_date, payment_mode, narration, cash_bank_code, cash_bank_location, category, is_internal, account_id, note, firm_id, created_at, import_batch_id FROM transactions WHERE id = ?
+`
+
+func (q *Queries) GetTransactionByID(ctx context.Context, id int64) (Transaction, error) {
+	row := q.db.QueryRowContext(ctx, getTransactionByID, id)
+	var i Transaction
+	err := row.Scan(
+		&i.ID,
+		&i.PartyID,
+		&i.Amount,
+		&i.TransactionDate,
+		&i.PaymentMode,
+		&i.Narration,
+		&i.CashBankCode,
+		&i.CashBankLocation,
+		&i.Category,
+		&i.IsInternal,
+		&i.AccountID,
+		&i.Note,
+		&i.FirmID,
+		&i.CreatedAt,
+		&i.ImportBatchID,
+	)
+	return i, err
+}
+
+const getTransactionSplit = `-- name: GetTransactionSplit :one
+SELECT id, firm_id, source_transaction_id, transaction_id, original_party_id, original_amount, split_at FROM transaction_splits WHERE transaction_id = ?
+`
+
+func (q *Queries) GetTransactionSplit(ctx context.Context, transactionID int64) (TransactionSplit, error) {
+	row := q.db.QueryRowContext(ctx, getTransactionSplit, transactionID)
+	var i TransactionSplit
+	err := row.Scan(
+		&i.ID,
+		&i.FirmID,
+		&i.SourceTransactionID,
+		&i.TransactionID,
+		&i.OriginalPartyID,
+		&i.OriginalAmount,
+		&i.SplitAt,
+	)
+	return i, err
+}
+
+const getTransactionTagsByPartyID = `-- name: GetTransactionTagsByPartyID :many
+SELECT tt.id, tt.transaction_id, tt.tag FROM transaction_tags tt
+JOIN transactions t ON t.id = tt.transaction_id
+WHERE t.party_id = ?
+ORDER BY tt.tag
+`
+
+func (q *Queries) GetTransactionTagsByPartyID(ctx context.Context, partyID int64) ([]TransactionTag, error) {
+	rows, err := q.db.QueryContext(ctx, getTransactionTagsByPartyID, partyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TransactionTag
+	for rows.Next() {
+		var i TransactionTag
+		if err := rows.Scan(
+			&i.ID,
+			&i.TransactionID,
+			&i.Tag,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTransactionTagsByTag = `-- name: GetTransactionTagsByTag :many
+SELECT other.id, other.transaction_id, other.tag FROM transaction_tags tt
+JOIN transaction_tags other ON other.transaction_id = tt.transaction_id
+WHERE tt.tag = ?
+ORDER BY other.tag
+`
+
+func (q *Queries) GetTransactionTagsByTag(ctx context.Context, tag string) ([]TransactionTag, error) {
+	rows, err := q.db.QueryContext(ctx, getTransactionTagsByTag, tag)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TransactionTag
+	for rows.Next() {
+		var i TransactionTag
+		if err := rows.Scan(
+			&i.ID,
+			&i.TransactionID,
+			&i.Tag,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTransactionsByPartyID = `-- name: GetTransactionsByPartyID :many
+SELECT id, party_id, amount, transaction_date, payment_mode, narration, cash_bank_code, cash_bank_location, category, is_internal, account_id, note, firm_id, created_at, import_batch_id FROM transactions
+WHERE party_id = ?
+ORDER BY transaction_date DESC
+`
+
+func (q *Queries) GetTransactionsByPartyID(ctx context.Context, partyID int64) ([]Transaction, error) {
+	rows, err := q.db.QueryContext(ctx, getTransactionsByPartyID, partyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Transaction
+	for rows.Next() {
+		var i Transaction
+		if err := rows.Scan(
+			&i.ID,
+			&i.PartyID,
+			&i.Amount,
+			&i.TransactionDate,
+			&i.PaymentMode,
+			&i.Narration,
+			&i.CashBankCode,
+			&i.CashBankLocation,
+			&i.Category,
+			&i.IsInternal,
+			&i.AccountID,
+			&i.Note,
+			&i.FirmID,
+			&i.CreatedAt,
+			&i.ImportBatchID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const linkSaleBill = `-- name: LinkSaleBill :exec
+UPDATE sale_bills SET party_id = ? WHERE id = ?
+`
+
+type LinkSaleBillParams struct {
+	PartyID sql.NullInt64
+	ID      int64
+}
+
+func (q *Queries) LinkSaleBill(ctx context.Context, arg LinkSaleBillParams) error {
+	_, err := q.db.ExecContext(ctx, linkSaleBill, arg.PartyID, arg.ID)
+	return err
+}
+
+const listAccounts = `-- name: ListAccounts :many
+SELECT id, bank, account_number, statement_balance, statement_date, firm_id, created_at FROM accounts WHERE firm_id = ? ORDER BY bank, account_number
+`
+
+func (q *Queries) ListAccounts(ctx context.Context, firmID int64) ([]Account, error) {
+	rows, err := q.db.QueryContext(ctx, listAccounts, firmID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Account
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Bank,
+			&i.AccountNumber,
+			&i.StatementBalance,
+			&i.StatementDate,
+			&i.FirmID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listActiveStatementLinks = `-- name: ListActiveStatementLinks :many
+SELECT id, party_id, token, expires_at, created_at FROM statement_links
+WHERE party_id = ? AND expires_at > ?
+ORDER BY expires_at DESC
+`
+
+type ListActiveStatementLinksParams struct {
+	PartyID   int64
+	ExpiresAt time.Time
+}
+
+func (q *Queries) ListActiveStatementLinks(ctx context.Context, arg ListActiveStatementLinksParams) ([]StatementLink, error) {
+	rows, err := q.db.QueryContext(ctx, listActiveStatementLinks, arg.PartyID, arg.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []StatementLink
+	for rows.Next() {
+		var i StatementLink
+		if err := rows.Scan(
+			&i.ID,
+			&i.PartyID,
+			&i.Token,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAgentCollections = `-- name: ListAgentCollections :many
+SELECT ta.agent_id, COALESCE(t.payment_mode, '') as payment_mode,
+    COUNT(*) as receipt_count,
+    CAST(SUM(t.amount) AS REAL) as total
+FROM transaction_agents ta
+JOIN agents a ON a.id = ta.agent_id
+JOIN transactions t ON t.id = ta.transaction_id
+WHERE t.transaction_date BETWEEN ? AND ? AND a.firm_id = ?
+GROUP BY ta.agent_id, t.payment_mode
+ORDER BY ta.agent_id, t.payment_mode
+`
+
+type ListAgentCollectionsParams struct {
+	TransactionDate   time.Time
+	TransactionDate_2 time.Time
+	FirmID            int64
+}
+
+type ListAgentCollectionsRow struct {
+	AgentID      int64
+	PaymentMode  string
+	ReceiptCount int64
+	Total        float64
+}
+
+func (q *Queries) ListAgentCollections(ctx context.Context, arg ListAgentCollectionsParams) ([]ListAgentCollectionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAgentCollections, arg.TransactionDate, arg.TransactionDate_2, arg.FirmID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAgentCollectionsRow
+	for rows.Next() {
+		var i ListAgentCollectionsRow
+		if err := rows.Scan(
+			&i.AgentID,
+			&i.PaymentMode,
+			&i.ReceiptCount,
+			&i.Total,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAgentReceipts = `-- name: ListAgentReceipts :many
+SELECT t.id, t.party_id, p.name as party_name, t.amount, t.transaction_date, t.payment_mode, t.narration, ta.source
+FROM transaction_agents ta
+JOIN transactions t ON t.id = ta.transaction_id
+JOIN parties p ON p.id = t.party_id
+WHERE ta.agent_id = ? AND t.transaction_date BETWEEN ? AND ?
+ORDER BY t.transaction_date DESC, t.id DESC
+`
+
+type ListAgentReceiptsParams struct {
+	AgentID           int64
+	TransactionDate   time.Time
+	TransactionDate_2 time.Time
+}
+
+type ListAgentReceiptsRow struct {
+	ID              int64
+	PartyID         int64
+	PartyName       string
+	Amount          float64
+	TransactionDate time.Time
+	PaymentMode     sql.NullString
+	Narration       sql.NullString
+	Source          string
+}
+
+func (q *Queries) ListAgentReceipts(ctx context.Context, arg ListAgentReceiptsParams) ([]ListAgentReceiptsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAgentReceipts, arg.AgentID, arg.TransactionDate, arg.TransactionDate_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAgentReceiptsRow
+	for rows.Next() {
+		var i ListAgentReceiptsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.PartyID,
+			&i.PartyName,
+			&i.Amount,
+			&i.TransactionDate,
+			&i.PaymentMode,
+			&i.Narration,
+			&i.Source,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAgentSummaries = `-- name: ListAgentSummaries :many
+SELECT a.id, a.name, a.code, a.location,
+    COUNT(t.id) as receipt_count,
+    CAST(COALESCE(SUM(t.amount), 0) AS REAL) as total
+FROM agents a
+LEFT JOIN transaction_agents ta ON ta.agent_id = a.id
+LEFT JOIN transactions t ON t.id = ta.transaction_id AND t.transaction_date BETWEEN ? AND ?
+WHERE a.firm_id = ?
+GROUP BY a.id
+ORDER BY a.name
+`
+
+type ListAgentSummariesParams struct {
+	TransactionDate   time.Time
+	TransactionDate_2 time.Time
+	FirmID            int64
+}
+
+type ListAgentSummariesRow struct {
+	ID           int64
+	Name         string
+	Code         string
+	Location     string
+	ReceiptCount int64
+	Total        float64
+}
+
+func (q *Queries) ListAgentSummaries(ctx context.Context, arg ListAgentSummariesParams) ([]ListAgentSummariesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAgentSummaries, arg.TransactionDate, arg.TransactionDate_2, arg.FirmID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAgentSummariesRow
+	for rows.Next() {
+		var i ListAgentSummariesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Code,
+			&i.Location,
+			&i.ReceiptCount,
+			&i.Total,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAgents = `-- name: ListAgents :many
+SELECT id, firm_id, name, code, location, created_at, commission_percent FROM agents WHERE firm_id = ? ORDER BY name
+`
+
+func (q *Queries) ListAgents(ctx context.Context, firmID int64) ([]Agent, error) {
+	rows, err := q.db.QueryContext(ctx, listAgents, firmID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Agent
+	for rows.Next() {
+		var i Agent
+		if err := rows.Scan(
+			&i.ID,
+			&i.FirmID,
+			&i.Name,
+			&i.Code,
+			&i.Location,
+			&i.CreatedAt,
+			&i.CommissionPercent,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBillAllocationsByParty = `-- name: ListBillAllocationsByParty :many
+SELECT ba.id, ba.sale_bill_id, ba.transaction_id, ba.amount, ba.created_at FROM bill_allocations ba
+JOIN transactions t ON t.id = ba.transaction_id
+WHERE t.party_id = ?
+ORDER BY ba.id
+`
+
+func (q *Queries) ListBillAllocationsByParty(ctx context.Context, partyID int64) ([]BillAllocation, error) {
+	rows, err := q.db.QueryContext(ctx, listBillAllocationsByParty, partyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []BillAllocation
+	for rows.Next() {
+		var i BillAllocation
+		if err := rows.Scan(
+			&i.ID,
+			&i.SaleBillID,
+			&i.TransactionID,
+			&i.Amount,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBookedNarrations = `-- name: ListBookedNarrations :many
+SELECT transaction_date AS credit_date, narration FROM transactions
+WHERE firm_id = ? AND transaction_date >= ? AND transaction_date <= ? AND narration IS NOT NULL
+UNION ALL
+SELECT credit_date, narration FROM pos_settlements
+WHERE firm_id = ? AND credit_date >= ? AND credit_date <= ? AND narration IS NOT NULL
+`
+
+type ListBookedNarrationsParams struct {
+	FirmID            int64
+	TransactionDate   time.Time
+	TransactionDate_2 time.Time
+	FirmID_2          int64
+	CreditDate        time.Time
+	CreditDate_2      time.Time
+}
+
+type ListBookedNarrationsRow struct {
+	CreditDate time.Time
+	Narration  sql.NullString
+}
+
+func (q *Queries) ListBookedNarrations(ctx context.Context, arg ListBookedNarrationsParams) ([]ListBookedNarrationsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listBookedNarrations,
+		arg.FirmID,
+		arg.TransactionDate,
+		arg.TransactionDate_2,
+		arg.FirmID_2,
+		arg.CreditDate,
+		arg.CreditDate_2,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListBookedNarrationsRow
+	for rows.Next() {
+		var i ListBookedNarrationsRow
+		if err := rows.Scan(&i.CreditDate, &i.Narration); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCashDeposits = `-- name: ListCashDeposits :many
+SELECT id, amount, transaction_date, is_internal, cash_bank_location, narration, account_id
+FROM transactions
+WHERE payment_mode = 'CASH' AND transaction_date >= ? AND transaction_date <= ? AND firm_id = ?
+ORDER BY transaction_date, id
+`
+
+type ListCashDepositsParams struct {
+	TransactionDate   time.Time
+	TransactionDate_2 time.Time
+	FirmID            int64
+}
+
+type ListCashDepositsRow struct {
+	ID               int64
+	Amount           float64
+	TransactionDate  time.Time
+	IsInternal       bool
+	CashBankLocation sql.NullString
+	Narration        sql.NullString
+	AccountID        sql.NullInt64
+}
+
+func (q *Queries) ListCashDeposits(ctx context.Context, arg ListCashDepositsParams) ([]ListCashDepositsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listCashDeposits, arg.TransactionDate, arg.TransactionDate_2, arg.FirmID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCashDepositsRow
+	for rows.Next() {
+		var i ListCashDepositsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Amount,
+			&i.TransactionDate,
+			&i.IsInternal,
+			&i.CashBankLocation,
+			&i.Narration,
+			&i.AccountID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChequesByStatus = `-- name: ListChequesByStatus :many
+SELECT c.id, c.transaction_id, c.cheque_number, c.cheque_date, c.status, c.received_date, c.deposited_date, c.cleared_date, c.bounced_date, c.notes, c.updated_at, t.amount, p.id as party_id, p.name as party_name, p.location as party_location
+FROM cheques c
+JOIN transactions t ON t.id = c.transaction_id
+JOIN parties p ON p.id = t.party_id
+WHERE p.firm_id = ? AND c.status IN (/*SLICE:statuses*/?)
+ORDER BY c.received_date, c.id
+LIMIT 500
+`
+
+type ListChequesByStatusParams struct {
+	FirmID   int64
+	Statuses []string
+}
+
+type ListChequesByStatusRow struct {
+	ID            int64
+	TransactionID int64
+	ChequeNumber  sql.NullString
+	ChequeDate    sql.NullTime
+	Status        string
+	ReceivedDate  time.Time
+	DepositedDate sql.NullTime
+	ClearedDate   sql.NullTime
+	BouncedDate   sql.NullTime
+	Notes         sql.NullString
+	UpdatedAt     sql.NullTime
+	Amount        float64
+	PartyID       int64
+	PartyName     string
+	PartyLocation sql.NullString
+}
+
+func (q *Queries) ListChequesByStatus(ctx context.Context, arg ListChequesByStatusParams) ([]ListChequesByStatusRow, error) {
+	query := listChequesByStatus
+	var queryParams []interface{}
+	queryParams = append(queryParams, arg.FirmID)
+	if len(arg.Statuses) > 0 {
+		for _, v := range arg.Statuses {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:statuses*/?", strings.Repeat(",?", len(arg.Statuses))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:statuses*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListChequesByStatusRow
+	for rows.Next() {
+		var i ListChequesByStatusRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TransactionID,
+			&i.ChequeNumber,
+			&i.ChequeDate,
+			&i.Status,
+			&i.ReceivedDate,
+			&i.DepositedDate,
+			&i.ClearedDate,
+			&i.BouncedDate,
+			&i.Notes,
+			&i.UpdatedAt,
+			&i.Amount,
+			&i.PartyID,
+			&i.PartyName,
+			&i.PartyLocation,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChequesByStatusAndAccount = `-- name: ListChequesByStatusAndAccount :many
+SELECT c.id, c.transaction_id, c.cheque_number, c.cheque_date, c.status, c.received_date, c.deposited_date, c.cleared_date, c.bounced_date, c.notes, c.updated_at, t.amount, p.id as party_id, p.name as party_name, p.location as party_location
+FROM cheques c
+JOIN transactions t ON t.id = c.transaction_id
+JOIN parties p ON p.id = t.party_id
+WHERE p.firm_id = ? AND t.account_id = ? AND c.status IN (/*SLICE:statuses*/?)
+ORDER BY c.received_date, c.id
+LIMIT 500
+`
+
+type ListChequesByStatusAndAccountParams struct {
+	FirmID    int64
+	AccountID sql.NullInt64
+	Statuses  []string
+}
+
+type ListChequesByStatusAndAccountRow struct {
+	ID            int64
+	TransactionID int64
+	ChequeNumber  sql.NullString
+	ChequeDate    sql.NullTime
+	Status        string
+	ReceivedDate  time.Time
+	DepositedDate sql.NullTime
+	ClearedDate   sql.NullTime
+	BouncedDate   sql.NullTime
+	Notes         sql.NullString
+	UpdatedAt     sql.NullTime
+	Amount        float64
+	PartyID       int64
+	PartyName     string
+	PartyLocation sql.NullString
+}
+
+func (q *Queries) ListChequesByStatusAndAccount(ctx context.Context, arg ListChequesByStatusAndAccountParams) ([]ListChequesByStatusAndAccountRow, error) {
+	query := listChequesByStatusAndAccount
+	var queryParams []interface{}
+	queryParams = append(queryParams, arg.FirmID)
+	queryParams = append(queryParams, arg.AccountID)
+	if len(arg.Statuses) > 0 {
+		for _, v := range arg.Statuses {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:statuses*/?", strings.Repeat(",?", len(arg.Statuses))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:statuses*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListChequesByStatusAndAccountRow
+	for rows.Next() {
+		var i ListChequesByStatusAndAccountRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TransactionID,
+			&i.ChequeNumber,
+			&i.ChequeDate,
+			&i.Status,
+			&i.ReceivedDate,
+			&i.DepositedDate,
+			&i.ClearedDate,
+			&i.BouncedDate,
+			&i.Notes,
+			&i.UpdatedAt,
+			&i.Amount,
+			&i.PartyID,
+			&i.PartyName,
+			&i.PartyLocation,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCreditLimitBreaches = `-- name: ListCreditLimitBreaches :many
+SELECT p.id, p.name, p.location, p.credit_limit, p.firm_id, p.created_at, p.email, p.phone, p.address, p.gstin, p.drug_license, CAST(COALESCE(pb.receipt_count, 0) AS INTEGER) as receipt_count,
+    CAST(ROUND(COALESCE(pb.billed, 0), 2) AS REAL) as billed, CAST(ROUND(COALESCE(pb.received, 0), 2) AS REAL) as received
+FROM parties p
+LEFT JOIN party_balances pb ON pb.party_id = p.id
+WHERE p.firm_id = ? AND p.credit_limit > 0
+  AND ROUND(COALESCE(pb.billed, 0) - COALESCE(pb.received, 0), 2) > p.credit_limit
+ORDER BY COALESCE(pb.billed, 0) - COALESCE(pb.received, 0) - p.credit_limit DESC
+`
+
+type ListCreditLimitBreachesRow struct {
+	ID           int64
+	Name         string
+	Location     sql.NullString
+	CreditLimit  float64
+	FirmID       int64
+	CreatedAt    sql.NullTime
+	Email        string
+	Phone        string
+	Address      string
+	Gstin        string
+	DrugLicense  string
+	ReceiptCount int64
+	Billed       float64
+	Received     float64
+}
+
+func (q *Queries) ListCreditLimitBreaches(ctx context.Context, firmID int64) ([]ListCreditLimitBreachesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listCreditLimitBreaches, firmID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCreditLimitBreachesRow
+	for rows.Next() {
+		var i ListCreditLimitBreachesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Location,
+			&i.CreditLimit,
+			&i.FirmID,
+			&i.CreatedAt,
+			&i.Email,
+			&i.Phone,
+			&i.Address,
+			&i.Gstin,
+			&i.DrugLicense,
+			&i.ReceiptCount,
+			&i.Billed,
+			&i.Received,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEnabledRules = `-- name: ListEnabledRules :many
+SELECT id, name, narration_pattern, party_pattern, min_amount, max_amount, set_category, set_internal, set_party_name, priority, enabled, created_at FROM rules WHERE enabled = TRUE ORDER BY priority, id
+`
+
+func (q *Queries) ListEnabledRules(ctx context.Context) ([]Rule, error) {
+	rows, err := q.db.QueryContext(ctx, listEnabledRules)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Rule
+	for rows.Next() {
+		var i Rule
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.NarrationPattern,
+			&i.PartyPattern,
+			&i.MinAmount,
+			&i.MaxAmount,
+			&i.SetCategory,
+			&i.SetInternal,
+			&i.SetPartyName,
+			&i.Priority,
+			&i.Enabled,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFinancialYearBalances = `-- name: ListFinancialYearBalances :many
+SELECT p.id, p.name, p.location, p.credit_limit, b.billed, b.received, b.opening, b.year_billed, b.year_received
+FROM financial_year_balances b
+JOIN parties p ON p.id = b.party_id
+WHERE b.financial_year_id = ?
+ORDER BY p.name
+`
+
+type ListFinancialYearBalancesRow struct {
+	ID           int64
+	Name         string
+	Location     sql.NullString
+	CreditLimit  float64
+	Billed       float64
+	Received     float64
+	Opening      sql.NullFloat64
+	YearBilled   sql.NullFloat64
+	YearReceived sql.NullFloat64
+}
+
+func (q *Queries) ListFinancialYearBalances(ctx context.Context, financialYearID int64) ([]ListFinancialYearBalancesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listFinancialYearBalances, financialYearID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListFinancialYearBalancesRow
+	for rows.Next() {
+		var i ListFinancialYearBalancesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Location,
+			&i.CreditLimit,
+			&i.Billed,
+			&i.Received,
+			&i.Opening,
+			&i.YearBilled,
+			&i.YearReceived,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFinancialYears = `-- name: ListFinancialYears :many
+SELECT id, firm_id, label, start_date, end_date, closed_at, archive_path, archived_at FROM financial_years WHERE firm_id = ? ORDER BY start_date DESC
+`
+
+func (q *Queries) ListFinancialYears(ctx context.Context, firmID int64) ([]FinancialYear, error) {
+	rows, err := q.db.QueryContext(ctx, listFinancialYears, firmID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FinancialYear
+	for rows.Next() {
+		var i FinancialYear
+		if err := rows.Scan(
+			&i.ID,
+			&i.FirmID,
+			&i.Label,
+			&i.StartDate,
+			&i.EndDate,
+			&i.ClosedAt,
+			&i.ArchivePath,
+			&i.ArchivedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFirms = `-- name: ListFirms :many
+SELECT id, name, gstin, created_at, rounding_tolerance FROM firms ORDER BY id
+`
+
+func (q *Queries) ListFirms(ctx context.Context) ([]Firm, error) {
+	rows, err := q.db.QueryContext(ctx, listFirms)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Firm
+	for rows.Next() {
+		var i Firm
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Gstin,
+			&i.CreatedAt,
+			&i.RoundingTolerance,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listGSTR1Invoices = `-- name: ListGSTR1Invoices :many
+SELECT id, firm_id, return_period, invoice_number, invoice_date, value, receiver_gstin, receiver_name, created_at FROM gstr1_invoices
+WHERE firm_id = ? AND invoice_date BETWEEN ? AND ?
+ORDER BY invoice_date, id
+`
+
+type ListGSTR1InvoicesParams struct {
+	FirmID        int64
+	InvoiceDate   time.Time
+	InvoiceDate_2 time.Time
+}
+
+func (q *Queries) ListGSTR1Invoices(ctx context.Context, arg ListGSTR1InvoicesParams) ([]Gstr1Invoice, error) {
+	rows, err := q.db.QueryContext(ctx, listGSTR1Invoices, arg.FirmID, arg.InvoiceDate, arg.InvoiceDate_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Gstr1Invoice
+	for rows.Next() {
+		var i Gstr1Invoice
+		if err := rows.Scan(
+			&i.ID,
+			&i.FirmID,
+			&i.ReturnPeriod,
+			&i.InvoiceNumber,
+			&i.InvoiceDate,
+			&i.Value,
+			&i.ReceiverGstin,
+			&i.ReceiverName,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listGSTR1Returns = `-- name: ListGSTR1Returns :many
+SELECT return_period, COUNT(*) as invoices, CAST(COALESCE(SUM(value), 0) AS REAL) as total
+FROM gstr1_invoices
+WHERE firm_id = ?
+GROUP BY return_period
+ORDER BY return_period DESC
+`
+
+type ListGSTR1ReturnsRow struct {
+	ReturnPeriod string
+	Invoices     int64
+	Total        float64
+}
+
+func (q *Queries) ListGSTR1Returns(ctx context.Context, firmID int64) ([]ListGSTR1ReturnsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listGSTR1Returns, firmID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListGSTR1ReturnsRow
+	for rows.Next() {
+		var i ListGSTR1ReturnsRow
+		if err := rows.Scan(
+			&i.ReturnPeriod,
+			&i.Invoices,
+			&i.Total,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listIdentifierConflicts = `-- name: ListIdentifierConflicts :many
+SELECT c.id, c.type, c.value, c.narration, c.found_at,
+    i.party_id, p.name as party_name, c.claimed_party_id, cp.name as claimed_party_name
+FROM identifier_conflicts c
+JOIN identifiers i ON i.firm_id = c.firm_id AND i.type = c.type AND i.value = c.value
+JOIN parties p ON p.id = i.party_id
+JOIN parties cp ON cp.id = c.claimed_party_id
+WHERE c.firm_id = ? AND c.resolution IS NULL AND i.party_id != c.claimed_party_id
+ORDER BY c.type, c.value, c.found_at
+`
+
+type ListIdentifierConflictsRow struct {
+	ID               int64
+	Type             string
+	Value            string
+	Narration        string
+	FoundAt          sql.NullTime
+	PartyID          int64
+	PartyName        string
+	ClaimedPartyID   int64
+	ClaimedPartyName string
+}
+
+func (q *Queries) ListIdentifierConflicts(ctx context.Context, firmID int64) ([]ListIdentifierConflictsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listIdentifierConflicts, firmID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListIdentifierConflictsRow
+	for rows.Next() {
+		var i ListIdentifierConflictsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.Value,
+			&i.Narration,
+			&i.FoundAt,
+			&i.PartyID,
+			&i.PartyName,
+			&i.ClaimedPartyID,
+			&i.ClaimedPartyName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listIdentifierHistoryByPartyID = `-- name: ListIdentifierHistoryByPartyID :many
+SELECT h.id, h.type, h.value, h.party_id, p.name as party_name,
+    h.previous_party_id, pp.name as previous_party_name, h.cause, h.detail, h.assigned_at
+FROM identifier_history h
+LEFT JOIN parties p ON p.id = h.party_id
+LEFT JOIN parties pp ON pp.id = h.previous_party_id
+WHERE h.firm_id = ? AND EXISTS (
+    SELECT 1 FROM identifier_history o
+    WHERE o.firm_id = h.firm_id AND o.type = h.type AND o.value = h.value AND o.party_id = ?
+)
+ORDER BY h.assigned_at DESC, h.id DESC
+`
+
+type ListIdentifierHistoryByPartyIDParams struct {
+	FirmID  int64
+	PartyID int64
+}
+
+type ListIdentifierHistoryByPartyIDRow struct {
+	ID                int64
+	Type              string
+	Value             string
+	PartyID           int64
+	PartyName         sql.NullString
+	PreviousPartyID   sql.NullInt64
+	PreviousPartyName sql.NullString
+	Cause             string
+	Detail            string
+	AssignedAt        sql.NullTime
+}
+
+func (q *Queries) ListIdentifierHistoryByPartyID(ctx context.Context, arg ListIdentifierHistoryByPartyIDParams) ([]ListIdentifierHistoryByPartyIDRow, error) {
+	rows, err := q.db.QueryContext(ctx, listIdentifierHistoryByPartyID, arg.FirmID, arg.PartyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListIdentifierHistoryByPartyIDRow
+	for rows.Next() {
+		var i ListIdentifierHistoryByPartyIDRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.Value,
+			&i.PartyID,
+			&i.PartyName,
+			&i.PreviousPartyID,
+			&i.PreviousPartyName,
+			&i.Cause,
+			&i.Detail,
+			&i.AssignedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listIdentifierLinks = `-- name: ListIdentifierLinks :many
+SELECT DISTINCT i.party_id, c.claimed_party_id, c.type, c.value
+FROM identifier_conflicts c
+JOIN identifiers i ON i.firm_id = c.firm_id AND i.type = c.type AND i.value = c.value
+WHERE c.firm_id = ? AND i.party_id != c.claimed_party_id
+`
+
+type ListIdentifierLinksRow struct {
+	PartyID        int64
+	ClaimedPartyID int64
+	Type           string
+	Value          string
+}
+
+func (q *Queries) ListIdentifierLinks(ctx context.Context, firmID int64) ([]ListIdentifierLinksRow, error) {
+	rows, err := q.db.QueryContext(ctx, listIdentifierLinks, firmID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListIdentifierLinksRow
+	for rows.Next() {
+		var i ListIdentifierLinksRow
+		if err := rows.Scan(
+			&i.PartyID,
+			&i.ClaimedPartyID,
+			&i.Type,
+			&i.Value,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listIdentifiersForExport = `-- name: ListIdentifiersForExport :many
+SELECT i.type, i.value, i.party_id, p.name as party_name, p.location as party_location,
+    i.first_seen, i.last_seen, i.hit_count
+FROM identifiers i
+JOIN parties p ON p.id = i.party_id
+WHERE i.firm_id = ?
+ORDER BY p.name, i.type, i.value
+`
+
+type ListIdentifiersForExportRow struct {
+	Type          string
+	Value         string
+	PartyID       int64
+	PartyName     string
+	PartyLocation sql.NullString
+	FirstSeen     sql.NullTime
+	LastSeen      sql.NullTime
+	HitCount      int64
+}
+
+func (q *Queries) ListIdentifiersForExport(ctx context.Context, firmID int64) ([]ListIdentifiersForExportRow, error) {
+	rows, err := q.db.QueryContext(ctx, listIdentifiersForExport, firmID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListIdentifiersForExportRow
+	for rows.Next() {
+		var i ListIdentifiersForExportRow
+		if err := rows.Scan(
+			&i.Type,
+			&i.Value,
+			&i.PartyID,
+			&i.PartyName,
+			&i.PartyLocation,
+			&i.FirstSeen,
+			&i.LastSeen,
+			&i.HitCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listImportBatches = `-- name: ListImportBatches :many
+SELECT id, firm_id, lines, transactions, page_headers, skip_patterns, suspense, unattached, identifiers, imported, duplicates, errors, imported_at FROM import_batches
+WHERE firm_id = ?
+ORDER BY id DESC
+LIMIT ?
+`
+
+type ListImportBatchesParams struct {
+	FirmID int64
+	Limit  int64
+}
+
+func (q *Queries) ListImportBatches(ctx context.Context, arg ListImportBatchesParams) ([]ImportBatch, error) {
+	rows, err := q.db.QueryContext(ctx, listImportBatches, arg.FirmID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ImportBatch
+	for rows.Next() {
+		var i ImportBatch
+		if err := rows.Scan(
+			&i.ID,
+			&i.FirmID,
+			&i.Lines,
+			&i.Transactions,
+			&i.PageHeaders,
+			&i.SkipPatterns,
+			&i.Suspense,
+			&i.Unattached,
+			&i.Identifiers,
+			&i.Imported,
+			&i.Duplicates,
+			&i.Errors,
+			&i.ImportedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMergeSuggestions = `-- name: ListMergeSuggestions :many
+SELECT s.id, s.score, s.reasons, s.found_at,
+    p.id as party_id, p.name as party_name, p.location as party_location,
+    o.id as other_party_id, o.name as other_party_name, o.location as other_party_location
+FROM merge_suggestions s
+JOIN parties p ON p.id = s.party_id
+JOIN parties o ON o.id = s.other_party_id
+WHERE s.firm_id = ? AND s.dismissed_at IS NULL
+ORDER BY s.score DESC, s.id
+`
+
+type ListMergeSuggestionsRow struct {
+	ID                 int64
+	Score              float64
+	Reasons            string
+	FoundAt            sql.NullTime
+	PartyID            int64
+	PartyName          string
+	PartyLocation      sql.NullString
+	OtherPartyID       int64
+	OtherPartyName     string
+	OtherPartyLocation sql.NullString
+}
+
+func (q *Queries) ListMergeSuggestions(ctx context.Context, firmID int64) ([]ListMergeSuggestionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listMergeSuggestions, firmID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListMergeSuggestionsRow
+	for rows.Next() {
+		var i ListMergeSuggestionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Score,
+			&i.Reasons,
+			&i.FoundAt,
+			&i.PartyID,
+			&i.PartyName,
+			&i.PartyLocation,
+			&i.OtherPartyID,
+			&i.OtherPartyName,
+			&i.OtherPartyLocation,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMergedTransactionIDsByParty = `-- name: ListMergedTransactionIDsByParty :many
+SELECT DISTINCT m.transaction_id FROM transaction_merges m
+JOIN transactions t ON t.id = m.transaction_id
+WHERE t.party_id = ?
+`
+
+func (q *Queries) ListMergedTransactionIDsByParty(ctx context.Context, partyID int64) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, listMergedTransactionIDsByParty, partyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var transaction_id int64
+		if err := rows.Scan(&transaction_id); err != nil {
+			return nil, err
+		}
+		items = append(items, transaction_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOffsiteBackups = `-- name: ListOffsiteBackups :many
+SELECT id, object_key, size_bytes, error, created_at FROM offsite_backups ORDER BY created_at DESC, id DESC LIMIT 20
+`
+
+func (q *Queries) ListOffsiteBackups(ctx context.Context) ([]OffsiteBackup, error) {
+	rows, err := q.db.QueryContext(ctx, listOffsiteBackups)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OffsiteBackup
+	for rows.Next() {
+		var i OffsiteBackup
+		if err := rows.Scan(
+			&i.ID,
+			&i.ObjectKey,
+			&i.SizeBytes,
+			&i.Error,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOpeningEntries = `-- name: ListOpeningEntries :many
+SELECT party_id, amount FROM sale_bills
+WHERE firm_id = ? AND bill_number = ? AND bill_date = ? AND party_id IS NOT NULL
+UNION ALL
+SELECT party_id, -amount FROM transactions
+WHERE firm_id = ? AND payment_mode = 'OPENING' AND transaction_date = ?
+`
+
+type ListOpeningEntriesParams struct {
+	FirmID          int64
+	BillNumber      string
+	BillDate        time.Time
+	FirmID_2        int64
+	TransactionDate time.Time
+}
+
+type ListOpeningEntriesRow struct {
+	PartyID sql.NullInt64
+	Amount  float64
+}
+
+func (q *Queries) ListOpeningEntries(ctx context.Context, arg ListOpeningEntriesParams) ([]ListOpeningEntriesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listOpeningEntries,
+		arg.FirmID,
+		arg.BillNumber,
+		arg.BillDate,
+		arg.FirmID_2,
+		arg.TransactionDate,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListOpeningEntriesRow
+	for rows.Next() {
+		var i ListOpeningEntriesRow
+		if err := rows.Scan(&i.PartyID, &i.Amount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPOSSettlements = `-- name: ListPOSSettlements :many
+SELECT id, credit_date, settlement_date, terminal_id, batch_number, amount, narration, account_id, firm_id, created_at FROM pos_settlements
+WHERE settlement_date >= ? AND settlement_date <= ? AND firm_id = ?
+ORDER BY settlement_date DESC, id DESC
+`
+
+type ListPOSSettlementsParams struct {
+	SettlementDate   time.Time
+	SettlementDate_2 time.Time
+	FirmID           int64
+}
+
+func (q *Queries) ListPOSSettlements(ctx context.Context, arg ListPOSSettlementsParams) ([]PosSettlement, error) {
+	rows, err := q.db.QueryContext(ctx, listPOSSettlements, arg.SettlementDate, arg.SettlementDate_2, arg.FirmID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PosSettlement
+	for rows.Next() {
+		var i PosSettlement
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreditDate,
+			&i.SettlementDate,
+			&i.TerminalID,
+			&i.BatchNumber,
+			&i.Amount,
+			&i.Narration,
+			&i.AccountID,
+			&i.FirmID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPOSSettlementsByAccount = `-- name: ListPOSSettlementsByAccount :many
+SELECT id, credit_date, settlement_date, terminal_id, batch_number, amount, narration, account_id, firm_id, created_at FROM pos_settlements
+WHERE settlement_date >= ? AND settlement_date <= ? AND firm_id = ? AND account_id = ?
+ORDER BY settlement_date DESC, id DESC
+`
+
+type ListPOSSettlementsByAccountParams struct {
+	SettlementDate   time.Time
+	SettlementDate_2 time.Time
+	FirmID           int64
+	AccountID        sql.NullInt64
+}
+
+func (q *Queries) ListPOSSettlementsByAccount(ctx context.Context, arg ListPOSSettlementsByAccountParams) ([]PosSettlement, error) {
+	rows, err := q.db.QueryContext(ctx, listPOSSettlementsByAccount,
+		arg.SettlementDate,
+		arg.SettlementDate_2,
+		arg.FirmID,
+		arg.AccountID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PosSettlement
+	for rows.Next() {
+		var i PosSettlement
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreditDate,
+			&i.SettlementDate,
+			&i.TerminalID,
+			&i.BatchNumber,
+			&i.Amount,
+			&i.Narration,
+			&i.AccountID,
+			&i.FirmID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listParserVocabulary = `-- name: ListParserVocabulary :many
+SELECT id, kind, value, created_at FROM parser_vocabulary ORDER BY kind, id
+`
+
+func (q *Queries) ListParserVocabulary(ctx context.Context) ([]ParserVocabulary, error) {
+	rows, err := q.db.QueryContext(ctx, listParserVocabulary)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ParserVocabulary
+	for rows.Next() {
+		var i ParserVocabulary
+		if err := rows.Scan(
+			&i.ID,
+			&i.Kind,
+			&i.Value,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listParties = `-- name: ListParties :many
+SELECT id, name, location, credit_limit, firm_id, created_at, email, phone, address, gstin, drug_license FROM parties WHERE firm_id = ? ORDER BY name
+`
+
+func (q *Queries) ListParties(ctx context.Context, firmID int64) ([]Party, error) {
+	rows, err := q.db.QueryContext(ctx, listParties, firmID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Party
+	for rows.Next() {
+		var i Party
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Location,
+			&i.CreditLimit,
+			&i.FirmID,
+			&i.CreatedAt,
+			&i.Email,
+			&i.Phone,
+			&i.Address,
+			&i.Gstin,
+			&i.DrugLicense,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPartiesWithoutIdentifiers = `-- name: ListPartiesWithoutIdentifiers :many
+SELECT p.id, p.name, p.location, CAST(COUNT(t.id) AS INTEGER) as receipt_count
+FROM parties p
+LEFT JOIN transactions t ON t.party_id = p.id AND t.category = 'receipt'
+WHERE p.firm_id = ? AND NOT EXISTS (SELECT 1 FROM identifiers i WHERE i.party_id = p.id)
+GROUP BY p.id
+ORDER BY receipt_count DESC, p.name
+`
+
+type ListPartiesWithoutIdentifiersRow struct {
+	ID           int64
+	Name         string
+	Location     sql.NullString
+	ReceiptCount int64
+}
+
+func (q *Queries) ListPartiesWithoutIdentifiers(ctx context.Context, firmID int64) ([]ListPartiesWithoutIdentifiersRow, error) {
+	rows, err := q.db.QueryContext(ctx, listPartiesWithoutIdentifiers, firmID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPartiesWithoutIdentifiersRow
+	for rows.Next() {
+		var i ListPartiesWithoutIdentifiersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Location,
+			&i.ReceiptCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPartyAliases = `-- name: ListPartyAliases :many
+SELECT id, party_id, alias, firm_id, created_at FROM party_aliases WHERE firm_id = ? ORDER BY alias
+`
+
+func (q *Queries) ListPartyAliases(ctx context.Context, firmID int64) ([]PartyAliase, error) {
+	rows, err := q.db.QueryContext(ctx, listPartyAliases, firmID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PartyAliase
+	for rows.Next() {
+		var i PartyAliase
+		if err := rows.Scan(
+			&i.ID,
+			&i.PartyID,
+			&i.Alias,
+			&i.FirmID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPartyBalances = `-- name: ListPartyBalances :many
+SELECT p.id, p.name, p.location, p.credit_limit, p.firm_id, p.created_at, p.email, p.phone, p.address, p.gstin, p.drug_license, CAST(COALESCE(pb.receipt_count, 0) AS INTEGER) as receipt_count,
+    CAST(ROUND(COALESCE(pb.billed, 0), 2) AS REAL) as billed, CAST(ROUND(COALESCE(pb.received, 0), 2) AS REAL) as received
+FROM parties p
+LEFT JOIN party_balances pb ON pb.party_id = p.id
+WHERE p.firm_id = ?
+ORDER BY p.name
+`
+
+type ListPartyBalancesRow struct {
+	ID           int64
+	Name         string
+	Location     sql.NullString
+	CreditLimit  float64
+	FirmID       int64
+	CreatedAt    sql.NullTime
+	Email        string
+	Phone        string
+	Address      string
+	Gstin        string
+	DrugLicense  string
+	ReceiptCount int64
+	Billed       float64
+	Received     float64
+}
+
+func (q *Queries) ListPartyBalances(ctx context.Context, firmID int64) ([]ListPartyBalancesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listPartyBalances, firmID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPartyBalancesRow
+	for rows.Next() {
+		var i ListPartyBalancesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Location,
+			&i.CreditLimit,
+			&i.FirmID,
+			&i.CreatedAt,
+			&i.Email,
+			&i.Phone,
+			&i.Address,
+			&i.Gstin,
+			&i.DrugLicense,
+			&i.ReceiptCount,
+			&i.Billed,
+			&i.Received,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPartyMergesBySurvivor = `-- name: ListPartyMergesBySurvivor :many
+SELECT id, firm_id, merged_party_id, merged_name, survivor_party_id, merged_at FROM party_merges WHERE survivor_party_id = ? ORDER BY merged_at DESC, id DESC
+`
+
+func (q *Queries) ListPartyMergesBySurvivor(ctx context.Context, survivorPartyID int64) ([]PartyMerge, error) {
+	rows, err := q.db.QueryContext(ctx, listPartyMergesBySurvivor, survivorPartyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PartyMerge
+	for rows.Next() {
+		var i PartyMerge
+		if err := rows.Scan(
+			&i.ID,
+			&i.FirmID,
+			&i.MergedPartyID,
+			&i.MergedName,
+			&i.SurvivorPartyID,
+			&i.MergedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPartyMovements = `-- name: ListPartyMovements :many
+SELECT p.id, p.name, p.location, p.credit_limit,
+    CAST(COALESCE(b.billed, 0) AS REAL) as billed, CAST(COALESCE(r.received, 0) AS REAL) as received
+FROM parties p
+LEFT JOIN (
+    SELECT party_id, SUM(amount) as billed
+    FROM sale_bills
+    WHERE party_id IS NOT NULL AND COALESCE(is_cash_sale, FALSE) = FALSE AND is_card_sale = FALSE
+      AND bill_date >= ? AND bill_date <= ?
+    GROUP BY party_id
+) b ON b.party_id = p.id
+LEFT JOIN (
+    SELECT t.party_id, SUM(t.amount) as received
+    FROM transactions t
+    WHERE t.category = 'receipt' AND t.transaction_date >= ? AND t.transaction_date <= ?
+      AND NOT EXISTS (SELECT 1 FROM cheques c WHERE c.transaction_id = t.id AND c.status = 'bounced')
+    GROUP BY t.party_id
+) r ON r.party_id = p.id
+WHERE p.firm_id = ? AND (b.billed IS NOT NULL OR r.received IS NOT NULL)
+ORDER BY p.name
+`
+
+type ListPartyMovementsParams struct {
+	BillDate          time.Time
+	BillDate_2        time.Time
+	TransactionDate   time.Time
+	TransactionDate_2 time.Time
+	FirmID            int64
+}
+
+type ListPartyMovementsRow struct {
+	ID          int64
+	Name        string
+	Location    sql.NullString
+	CreditLimit float64
+	Billed      float64
+	Received    float64
+}
+
+func (q *Queries) ListPartyMovements(ctx context.Context, arg ListPartyMovementsParams) ([]ListPartyMovementsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listPartyMovements,
+		arg.BillDate,
+		arg.BillDate_2,
+		arg.TransactionDate,
+		arg.TransactionDate_2,
+		arg.FirmID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPartyMovementsRow
+	for rows.Next() {
+		var i ListPartyMovementsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Location,
+			&i.CreditLimit,
+			&i.Billed,
+			&i.Received,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPaymentModeRules = `-- name: ListPaymentModeRules :many
+SELECT id, pattern, mode, priority, created_at FROM payment_mode_rules ORDER BY priority, id
+`
+
+func (q *Queries) ListPaymentModeRules(ctx context.Context) ([]PaymentModeRule, error) {
+	rows, err := q.db.QueryContext(ctx, listPaymentModeRules)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PaymentModeRule
+	for rows.Next() {
+		var i PaymentModeRule
+		if err := rows.Scan(
+			&i.ID,
+			&i.Pattern,
+			&i.Mode,
+			&i.Priority,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPendingImports = `-- name: ListPendingImports :many
+SELECT id, kind, filename, sender, subject, received_at, entries
+FROM pending_imports
+WHERE status = 'pending'
+ORDER BY received_at, id
+`
+
+type ListPendingImportsRow struct {
+	ID         int64
+	Kind       string
+	Filename   string
+	Sender     string
+	Subject    string
+	ReceivedAt time.Time
+	Entries    int64
+}
+
+func (q *Queries) ListPendingImports(ctx context.Context) ([]ListPendingImportsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listPendingImports)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPendingImportsRow
+	for rows.Next() {
+		var i ListPendingImportsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Kind,
+			&i.Filename,
+			&i.Sender,
+			&i.Subject,
+			&i.ReceivedAt,
+			&i.Entries,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPendingSMSAcknowledgements = `-- name: ListPendingSMSAcknowledgements :many
+SELECT a.id, a.firm_id, a.party_id, a.transaction_id, a.phone, t.amount, t.transaction_date
+FROM sms_acknowledgements a
+JOIN transactions t ON t.id = a.transaction_id
+WHERE a.sent_at IS NULL AND a.attempts < ?
+ORDER BY a.id
+LIMIT 50
+`
+
+type ListPendingSMSAcknowledgementsRow struct {
+	ID              int64
+	FirmID          int64
+	PartyID         int64
+	TransactionID   int64
+	Phone           string
+	Amount          float64
+	TransactionDate time.Time
+}
+
+func (q *Queries) ListPendingSMSAcknowledgements(ctx context.Context, attempts int64) ([]ListPendingSMSAcknowledgementsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listPendingSMSAcknowledgements, attempts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPendingSMSAcknowledgementsRow
+	for rows.Next() {
+		var i ListPendingSMSAcknowledgementsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.FirmID,
+			&i.PartyID,
+			&i.TransactionID,
+			&i.Phone,
+			&i.Amount,
+			&i.TransactionDate,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReceiptBookChecks = `-- name: ListReceiptBookChecks :many
+SELECT id, start_date, end_date, total, suspense, imported_at, receipts, pos_settlements,
+    CAST(ROUND(receipts + pos_settlements - (total - suspense), 2) AS REAL) AS difference
+FROM (
+    SELECT b.id, b.start_date, b.end_date, b.total, b.suspense, b.imported_at,
+        CAST((SELECT COALESCE(SUM(t.amount), 0) FROM transactions t
+            WHERE t.firm_id = b.firm_id AND t.payment_mode IS NOT 'OPENING'
+              AND t.transaction_date BETWEEN b.start_date AND b.end_date) AS REAL) AS receipts,
+        CAST((SELECT COALESCE(SUM(s.amount), 0) FROM pos_settlements s
+            WHERE s.firm_id = b.firm_id
+              AND s.credit_date BETWEEN b.start_date AND b.end_date) AS REAL) AS pos_settlements
+    FROM receipt_book_totals b
+    WHERE b.firm_id = ? AND NOT EXISTS (
+        SELECT 1 FROM financial_years fy
+        WHERE fy.firm_id = b.firm_id AND fy.archived_at IS NOT NULL
+          AND b.end_date BETWEEN fy.start_date AND fy.end_date)
+)
+ORDER BY start_date DESC
+`
+
+type ListReceiptBookChecksRow struct {
+	ID             int64
+	StartDate      time.Time
+	EndDate        time.Time
+	Total          float64
+	Suspense       float64
+	ImportedAt     sql.NullTime
+	Receipts       float64
+	PosSettlements float64
+	Difference     float64
+}
+
+func (q *Queries) ListReceiptBookChecks(ctx context.Context, firmID int64) ([]ListReceiptBookChecksRow, error) {
+	rows, err := q.db.QueryContext(ctx, listReceiptBookChecks, firmID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListReceiptBookChecksRow
+	for rows.Next() {
+		var i ListReceiptBookChecksRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.StartDate,
+			&i.EndDate,
+			&i.Total,
+			&i.Suspense,
+			&i.ImportedAt,
+			&i.Receipts,
+			&i.PosSettlements,
+			&i.Difference,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReceiptMonthTotals = `-- name: ListReceiptMonthTotals :many
+SELECT month, payment_mode, receipt_count, CAST(ROUND(amount, 2) AS REAL) as amount
+FROM receipt_month_totals
+WHERE firm_id = ? AND month >= ? AND payment_mode <> 'OPENING' AND receipt_count > 0
+ORDER BY month DESC, payment_mode
+`
+
+type ListReceiptMonthTotalsParams struct {
+	FirmID int64
+	Month  string
+}
+
+type ListReceiptMonthTotalsRow struct {
+	Month        string
+	PaymentMode  string
+	ReceiptCount int64
+	Amount       float64
+}
+
+func (q *Queries) ListReceiptMonthTotals(ctx context.Context, arg ListReceiptMonthTotalsParams) ([]ListReceiptMonthTotalsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listReceiptMonthTotals, arg.FirmID, arg.Month)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListReceiptMonthTotalsRow
+	for rows.Next() {
+		var i ListReceiptMonthTotalsRow
+		if err := rows.Scan(
+			&i.Month,
+			&i.PaymentMode,
+			&i.ReceiptCount,
+			&i.Amount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReceiptNarrations = `-- name: ListReceiptNarrations :many
+SELECT t.party_id, p.name as party_name, t.transaction_date, t.narration
+FROM transactions t
+JOIN parties p ON p.id = t.party_id
+WHERE t.firm_id = ? AND t.category = 'receipt' AND NOT t.is_internal AND t.narration IS NOT NULL
+ORDER BY t.transaction_date
+`
+
+type ListReceiptNarrationsRow struct {
+	PartyID         int64
+	PartyName       string
+	TransactionDate time.Time
+	Narration       sql.NullString
+}
+
+func (q *Queries) ListReceiptNarrations(ctx context.Context, firmID int64) ([]ListReceiptNarrationsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listReceiptNarrations, firmID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListReceiptNarrationsRow
+	for rows.Next() {
+		var i ListReceiptNarrationsRow
+		if err := rows.Scan(
+			&i.PartyID,
+			&i.PartyName,
+			&i.TransactionDate,
+			&i.Narration,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReceiptsForExport = `-- name: ListReceiptsForExport :many
+SELECT t.transaction_date, t.amount, t.payment_mode, t.narration, t.category, t.account_id,
+    p.name as party_name, p.location as party_location
+FROM transactions t
+JOIN parties p ON p.id = t.party_id
+WHERE t.firm_id = ? AND t.transaction_date >= ? AND t.transaction_date <= ?
+ORDER BY t.transaction_date, t.id
+`
+
+type ListReceiptsForExportParams struct {
+	FirmID            int64
+	TransactionDate   time.Time
+	TransactionDate_2 time.Time
+}
+
+type ListReceiptsForExportRow struct {
+	TransactionDate time.Time
+	Amount          float64
+	PaymentMode     sql.NullString
+	Narration       sql.NullString
+	Category        string
+	AccountID       sql.NullInt64
+	PartyName       string
+	PartyLocation   sql.NullString
+}
+
+func (q *Queries) ListReceiptsForExport(ctx context.Context, arg ListReceiptsForExportParams) ([]ListReceiptsForExportRow, error) {
+	rows, err := q.db.QueryContext(ctx, listReceiptsForExport, arg.FirmID, arg.TransactionDate, arg.TransactionDate_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListReceiptsForExportRow
+	for rows.Next() {
+		var i ListReceiptsForExportRow
+		if err := rows.Scan(
+			&i.TransactionDate,
+			&i.Amount,
+			&i.PaymentMode,
+			&i.Narration,
+			&i.Category,
+			&i.AccountID,
+			&i.PartyName,
+			&i.PartyLocation,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReceiptsForExportByAccount = `-- name: ListReceiptsForExportByAccount :many
+SELECT t.transaction_date, t.amount, t.payment_mode, t.narration, t.category, t.account_id,
+    p.name as party_name, p.location as party_location
+FROM transactions t
+JOIN parties p ON p.id = t.party_id
+WHERE t.firm_id = ? AND t.transaction_date >= ? AND t.transaction_date <= ? AND t.account_id = ?
+ORDER BY t.transaction_date, t.id
+`
+
+type ListReceiptsForExportByAccountParams struct {
+	FirmID            int64
+	TransactionDate   time.Time
+	TransactionDate_2 time.Time
+	AccountID         sql.NullInt64
+}
+
+type ListReceiptsForExportByAccountRow struct {
+	TransactionDate time.Time
+	Amount          float64
+	PaymentMode     sql.NullString
+	Narration       sql.NullString
+	Category        string
+	AccountID       sql.NullInt64
+	PartyName       string
+	PartyLocation   sql.NullString
+}
+
+func (q *Queries) ListReceiptsForExportByAccount(ctx context.Context, arg ListReceiptsForExportByAccountParams) ([]ListReceiptsForExportByAccountRow, error) {
+	rows, err := q.db.QueryContext(ctx, listReceiptsForExportByAccount,
+		arg.FirmID,
+		arg.TransactionDate,
+		arg.TransactionDate_2,
+		arg.AccountID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListReceiptsForExportByAccountRow
+	for rows.Next() {
+		var i ListReceiptsForExportByAccountRow
+		if err := rows.Scan(
+			&i.TransactionDate,
+			&i.Amount,
+			&i.PaymentMode,
+			&i.Narration,
+			&i.Category,
+			&i.AccountID,
+			&i.PartyName,
+			&i.PartyLocation,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReceiptsForTally = `-- name: ListReceiptsForTally :many
+SELECT t.id, t.party_id, t.transaction_date, t.amount, t.narration, t.account_id,
+    p.name as party_name
+FROM transactions t
+JOIN parties p ON p.id = t.party_id
+WHERE t.firm_id = ? AND t.category = 'receipt' AND t.transaction_date >= ? AND t.transaction_date <= ?
+  AND NOT EXISTS (SELECT 1 FROM cheques c WHERE c.transaction_id = t.id AND c.status = 'bounced')
+ORDER BY t.transaction_date, t.id
+`
+
+type ListReceiptsForTallyParams struct {
+	FirmID            int64
+	TransactionDate   time.Time
+	TransactionDate_2 time.Time
+}
+
+type ListReceiptsForTallyRow struct {
+	ID              int64
+	PartyID         int64
+	TransactionDate time.Time
+	Amount          float64
+	Narration       sql.NullString
+	AccountID       sql.NullInt64
+	PartyName       string
+}
+
+func (q *Queries) ListReceiptsForTally(ctx context.Context, arg ListReceiptsForTallyParams) ([]ListReceiptsForTallyRow, error) {
+	rows, err := q.db.QueryContext(ctx, listReceiptsForTally, arg.FirmID, arg.TransactionDate, arg.TransactionDate_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListReceiptsForTallyRow
+	for rows.Next() {
+		var i ListReceiptsForTallyRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.PartyID,
+			&i.TransactionDate,
+			&i.Amount,
+			&i.Narration,
+			&i.AccountID,
+			&i.PartyName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReceiptsInPeriod = `-- name: ListReceiptsInPeriod :many
+SELECT t.party_id, t.transaction_date, t.amount
+FROM transactions t
+WHERE t.firm_id = ? AND t.category = 'receipt' AND t.transaction_date BETWEEN ? AND ?
+    AND NOT EXISTS (SELECT 1 FROM cheques c WHERE c.transaction_id = t.id AND c.status = 'bounced')
+ORDER BY t.transaction_date
+`
+
+type ListReceiptsInPeriodParams struct {
+	FirmID            int64
+	TransactionDate   time.Time
+	TransactionDate_2 time.Time
+}
+
+type ListReceiptsInPeriodRow struct {
+	PartyID         int64
+	TransactionDate time.Time
+	Amount          float64
+}
+
+func (q *Queries) ListReceiptsInPeriod(ctx context.Context, arg ListReceiptsInPeriodParams) ([]ListReceiptsInPeriodRow, error) {
+	rows, err := q.db.QueryContext(ctx, listReceiptsInPeriod, arg.FirmID, arg.TransactionDate, arg.TransactionDate_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListReceiptsInPeriodRow
+	for rows.Next() {
+		var i ListReceiptsInPeriodRow
+		if err := rows.Scan(
+			&i.PartyID,
+			&i.TransactionDate,
+			&i.Amount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRecentSearches = `-- name: ListRecentSearches :many
+SELECT id, narration, top_party_id, top_party_name, top_confidence, result_count, searched_at, firm_id FROM search_history WHERE firm_id = ? ORDER BY searched_at DESC, id DESC LIMIT ?
+`
+
+type ListRecentSearchesParams struct {
+	FirmID int64
+	Limit  int64
+}
+
+func (q *Queries) ListRecentSearches(ctx context.Context, arg ListRecentSearchesParams) ([]SearchHistory, error) {
+	rows, err := q.db.QueryContext(ctx, listRecentSearches, arg.FirmID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchHistory
+	for rows.Next() {
+		var i SearchHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.Narration,
+			&i.TopPartyID,
+			&i.TopPartyName,
+			&i.TopConfidence,
+			&i.ResultCount,
+			&i.SearchedAt,
+			&i.FirmID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRouteLocations = `-- name: ListRouteLocations :many
+SELECT id, firm_id, route_id, location FROM route_locations WHERE firm_id = ? ORDER BY location
+`
+
+func (q *Queries) ListRouteLocations(ctx context.Context, firmID int64) ([]RouteLocation, error) {
+	rows, err := q.db.QueryContext(ctx, listRouteLocations, firmID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RouteLocation
+	for rows.Next() {
+		var i RouteLocation
+		if err := rows.Scan(
+			&i.ID,
+			&i.FirmID,
+			&i.RouteID,
+			&i.Location,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRoutes = `-- name: ListRoutes :many
+SELECT id, firm_id, name, created_at FROM routes WHERE firm_id = ? ORDER BY name
+`
+
+func (q *Queries) ListRoutes(ctx context.Context, firmID int64) ([]Route, error) {
+	rows, err := q.db.QueryContext(ctx, listRoutes, firmID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Route
+	for rows.Next() {
+		var i Route
+		if err := rows.Scan(
+			&i.ID,
+			&i.FirmID,
+			&i.Name,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRules = `-- name: ListRules :many
+SELECT id, name, narration_pattern, party_pattern, min_amount, max_amount, set_category, set_internal, set_party_name, priority, enabled, created_at FROM rules ORDER BY priority, id
+`
+
+func (q *Queries) ListRules(ctx context.Context) ([]Rule, error) {
+	rows, err := q.db.QueryContext(ctx, listRules)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Rule
+	for rows.Next() {
+		var i Rule
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.NarrationPattern,
+			&i.PartyPattern,
+			&i.MinAmount,
+			&i.MaxAmount,
+			&i.SetCategory,
+			&i.SetInternal,
+			&i.SetPartyName,
+			&i.Priority,
+			&i.Enabled,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSMSAcknowledgementsByParty = `-- name: ListSMSAcknowledgementsByParty :many
+SELECT id, firm_id, party_id, transaction_id, phone, message, attempts, error, sent_at, created_at FROM sms_acknowledgements
+WHERE party_id = ?
+ORDER BY created_at DESC, id DESC
+LIMIT 20
+`
+
+func (q *Queries) ListSMSAcknowledgementsByParty(ctx context.Context, partyID int64) ([]SmsAcknowledgement, error) {
+	rows, err := q.db.QueryContext(ctx, listSMSAcknowledgementsByParty, partyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SmsAcknowledgement
+	for rows.Next() {
+		var i SmsAcknowledgement
+		if err := rows.Scan(
+			&i.ID,
+			&i.FirmID,
+			&i.PartyID,
+			&i.TransactionID,
+			&i.Phone,
+			&i.Message,
+			&i.Attempts,
+			&i.Error,
+			&i.SentAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSaleBillsBetween = `-- name: ListSaleBillsBetween :many
+SELECT id, bill_number, bill_date, party_name, amount, is_cash_sale, is_card_sale, party_id, firm_id, created_at, irn, ack_number, ack_date, taxable_value, cgst, sgst, igst FROM sale_bills
+WHERE firm_id = ? AND bill_date BETWEEN ? AND ?
+ORDER BY bill_date, id
+`
+
+type ListSaleBillsBetweenParams struct {
+	FirmID     int64
+	BillDate   time.Time
+	BillDate_2 time.Time
+}
+
+func (q *Queries) ListSaleBillsBetween(ctx context.Context, arg ListSaleBillsBetweenParams) ([]SaleBill, error) {
+	rows, err := q.db.QueryContext(ctx, listSaleBillsBetween, arg.FirmID, arg.BillDate, arg.BillDate_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SaleBill
+	for rows.Next() {
+		var i SaleBill
+		if err := rows.Scan(
+			&i.ID,
+			&i.BillNumber,
+			&i.BillDate,
+			&i.PartyName,
+			&i.Amount,
+			&i.IsCashSale,
+			&i.IsCardSale,
+			&i.PartyID,
+			&i.FirmID,
+			&i.CreatedAt,
+			&i.Irn,
+			&i.AckNumber,
+			&i.AckDate,
+			&i.TaxableValue,
+			&i.Cgst,
+			&i.Sgst,
+			&i.Igst,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSaleBillsInPeriod = `-- name: ListSaleBillsInPeriod :many
+SELECT id, bill_number, bill_date, party_name, amount, is_cash_sale, is_card_sale, party_id, firm_id, created_at, irn, ack_number, ack_date, taxable_value, cgst, sgst, igst FROM sale_bills
+WHERE firm_id = ? AND bill_date BETWEEN ? AND ?
+ORDER BY bill_date DESC, id DESC
+LIMIT ? OFFSET ?
+`
+
+type ListSaleBillsInPeriodParams struct {
+	FirmID     int64
+	BillDate   time.Time
+	BillDate_2 time.Time
+	Limit      int64
+	Offset     int64
+}
+
+func (q *Queries) ListSaleBillsInPeriod(ctx context.Context, arg ListSaleBillsInPeriodParams) ([]SaleBill, error) {
+	rows, err := q.db.QueryContext(ctx, listSaleBillsInPeriod,
+		arg.FirmID,
+		arg.BillDate,
+		arg.BillDate_2,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SaleBill
+	for rows.Next() {
+		var i SaleBill
+		if err := rows.Scan(
+			&i.ID,
+			&i.BillNumber,
+			&i.BillDate,
+			&i.PartyName,
+			&i.Amount,
+			&i.IsCashSale,
+			&i.IsCardSale,
+			&i.PartyID,
+			&i.FirmID,
+			&i.CreatedAt,
+			&i.Irn,
+			&i.AckNumber,
+			&i.AckDate,
+			&i.TaxableValue,
+			&i.Cgst,
+			&i.Sgst,
+			&i.Igst,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSavedSearches = `-- name: ListSavedSearches :many
+SELECT id, name, kind, narration, amount, variation, from_date, till_date, firm_id, created_at FROM saved_searches WHERE firm_id = ? ORDER BY name, id
+`
+
+func (q *Queries) ListSavedSearches(ctx context.Context, firmID int64) ([]SavedSearche, error) {
+	rows, err := q.db.QueryContext(ctx, listSavedSearches, firmID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SavedSearche
+	for rows.Next() {
+		var i SavedSearche
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Kind,
+			&i.Narration,
+			&i.Amount,
+			&i.Variation,
+			&i.FromDate,
+			&i.TillDate,
+			&i.FirmID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSplitTransactionIDsByParty = `-- name: ListSplitTransactionIDsByParty :many
+SELECT s.transaction_id FROM transaction_splits s
+JOIN transactions t ON t.id = s.transaction_id
+WHERE t.party_id = ?
+`
+
+func (q *Queries) ListSplitTransactionIDsByParty(ctx context.Context, partyID int64) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, listSplitTransactionIDsByParty, partyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var transaction_id int64
+		if err := rows.Scan(&transaction_id); err != nil {
+			return nil, err
+		}
+		items = append(items, transaction_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStatementEmailsByParty = `-- name: ListStatementEmailsByParty :many
+SELECT id, firm_id, party_id, email, from_date, till_date, error, sent_at FROM statement_emails
+WHERE party_id = ?
+ORDER BY sent_at DESC, id DESC
+LIMIT 20
+`
+
+func (q *Queries) ListStatementEmailsByParty(ctx context.Context, partyID int64) ([]StatementEmail, error) {
+	rows, err := q.db.QueryContext(ctx, listStatementEmailsByParty, partyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []StatementEmail
+	for rows.Next() {
+		var i StatementEmail
+		if err := rows.Scan(
+			&i.ID,
+			&i.FirmID,
+			&i.PartyID,
+			&i.Email,
+			&i.FromDate,
+			&i.TillDate,
+			&i.Error,
+			&i.SentAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTagSummaries = `-- name: ListTagSummaries :many
+SELECT tt.tag, COUNT(*) AS transaction_count, CAST(COALESCE(SUM(t.amount), 0) AS REAL) AS total
+FROM transaction_tags tt
+JOIN transactions t ON t.id = tt.transaction_id
+WHERE t.firm_id = ?
+GROUP BY tt.tag
+ORDER BY tt.tag
+`
+
+type ListTagSummariesRow struct {
+	Tag              string
+	TransactionCount int64
+	Total            float64
+}
+
+func (q *Queries) ListTagSummaries(ctx context.Context, firmID int64) ([]ListTagSummariesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTagSummaries, firmID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTagSummariesRow
+	for rows.Next() {
+		var i ListTagSummariesRow
+		if err := rows.Scan(
+			&i.Tag,
+			&i.TransactionCount,
+			&i.Total,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransactionAgentsByParty = `-- name: ListTransactionAgentsByParty :many
+SELECT ta.transaction_id, ta.agent_id, ta.source, a.name as agent_name
+FROM transaction_agents ta
+JOIN agents a ON a.id = ta.agent_id
+JOIN transactions t ON t.id = ta.transaction_id
+WHERE t.party_id = ?
+`
+
+type ListTransactionAgentsByPartyRow struct {
+	TransactionID int64
+	AgentID       int64
+	Source        string
+	AgentName     string
+}
+
+func (q *Queries) ListTransactionAgentsByParty(ctx context.Context, partyID int64) ([]ListTransactionAgentsByPartyRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTransactionAgentsByParty, partyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTransactionAgentsByPartyRow
+	for rows.Next() {
+		var i ListTransactionAgentsByPartyRow
+		if err := rows.Scan(
+			&i.TransactionID,
+			&i.AgentID,
+			&i.Source,
+			&i.AgentName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransactionMergeCandidates = `-- name: ListTransactionMergeCandidates :many
+SELECT t.id, t.party_id, p.name as party_name, t.amount, t.transaction_date, t.payment_mode, t.narration
+FROM transactions t
+JOIN parties p ON p.id = t.party_id
+WHERE t.firm_id = ? AND t.party_id = ? AND t.amount = ? AND t.transaction_date BETWEEN ? AND ? AND t.id != ?
+    AND t.id NOT IN (SELECT transaction_id FROM transaction_splits)
+ORDER BY t.transaction_date, t.id
+`
+
+type ListTransactionMergeCandidatesParams struct {
+	FirmID            int64
+	PartyID           int64
+	Amount            float64
+	TransactionDate   time.Time
+	TransactionDate_2 time.Time
+	ID                int64
+}
+
+type ListTransactionMergeCandidatesRow struct {
+	ID              int64
+	PartyID         int64
+	PartyName       string
+	Amount          float64
+	TransactionDate time.Time
+	PaymentMode     sql.NullString
+	Narration       sql.NullString
+}
+
+func (q *Queries) ListTransactionMergeCandidates(ctx context.Context, arg ListTransactionMergeCandidatesParams) ([]ListTransactionMergeCandidatesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTransactionMergeCandidates,
+		arg.FirmID,
+		arg.PartyID,
+		arg.Amount,
+		arg.TransactionDate,
+		arg.TransactionDate_2,
+		arg.ID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTransactionMergeCandidatesRow
+	for rows.Next() {
+		var i ListTransactionMergeCandidatesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.PartyID,
+			&i.PartyName,
+			&i.Amount,
+			&i.TransactionDate,
+			&i.PaymentMode,
+			&i.Narration,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransactionMerges = `-- name: ListTransactionMerges :many
+SELECT id, firm_id, transaction_id, superseded_transaction_id, superseded_party_id, superseded_amount, superseded_date, superseded_payment_mode, superseded_narration, merged_at FROM transaction_merges WHERE transaction_id = ? ORDER BY merged_at DESC, id DESC
+`
+
+func (q *Queries) ListTransactionMerges(ctx context.Context, transactionID int64) ([]TransactionMerge, error) {
+	rows, err := q.db.QueryContext(ctx, listTransactionMerges, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TransactionMerge
+	for rows.Next() {
+		var i TransactionMerge
+		if err := rows.Scan(
+			&i.ID,
+			&i.FirmID,
+			&i.TransactionID,
+			&i.SupersededTransactionID,
+			&i.SupersededPartyID,
+			&i.SupersededAmount,
+			&i.SupersededDate,
+			&i.SupersededPaymentMode,
+			&i.SupersededNarration,
+			&i.MergedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransactionPaymentModes = `-- name: ListTransactionPaymentModes :many
+SELECT t.id, t.party_id, p.name as party_name, t.transaction_date, t.payment_mode, t.narration
+FROM transactions t
+JOIN parties p ON p.id = t.party_id
+WHERE t.firm_id = ? AND t.narration IS NOT NULL
+ORDER BY t.transaction_date, t.id
+`
+
+type ListTransactionPaymentModesRow struct {
+	ID              int64
+	PartyID         int64
+	PartyName       string
+	TransactionDate time.Time
+	PaymentMode     sql.NullString
+	Narration       sql.NullString
+}
+
+func (q *Queries) ListTransactionPaymentModes(ctx context.Context, firmID int64) ([]ListTransactionPaymentModesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTransactionPaymentModes, firmID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTransactionPaymentModesRow
+	for rows.Next() {
+		var i ListTransactionPaymentModesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.PartyID,
+			&i.PartyName,
+			&i.TransactionDate,
+			&i.PaymentMode,
+			&i.Narration,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransactionSplitParts = `-- name: ListTransactionSplitParts :many
+SELECT s.transaction_id, t.party_id, p.name as party_name, t.amount
+FROM transaction_splits s
+JOIN transactions t ON t.id = s.transaction_id
+JOIN parties p ON p.id = t.party_id
+WHERE s.source_transaction_id = ?
+ORDER BY s.id
+`
+
+type ListTransactionSplitPartsRow struct {
+	TransactionID int64
+	PartyID       int64
+	PartyName     string
+	Amount        float64
+}
+
+func (q *Queries) ListTransactionSplitParts(ctx context.Context, sourceTransactionID int64) ([]ListTransactionSplitPartsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTransactionSplitParts, sourceTransactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTransactionSplitPartsRow
+	for rows.Next() {
+		var i ListTransactionSplitPartsRow
+		if err := rows.Scan(
+			&i.TransactionID,
+			&i.PartyID,
+			&i.PartyName,
+			&i.Amount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransactionsByTag = `-- name: ListTransactionsByTag :many
+SELECT t.id, t.party_id, t.amount, t.transaction_date, t.payment_mode, t.narration, t.cash_bank_code, t.cash_bank_location, t.category, t.is_internal, t.account_id, t.note, t.firm_id, t.created_at, t.import_batch_id, p.name AS party_name FROM transactions t
+JOIN transaction_tags tt ON tt.transaction_id = t.id
+JOIN parties p ON p.id = t.party_id
+WHERE tt.tag = ? AND t.firm_id = ?
+ORDER BY t.transaction_date DESC, t.id DESC
+`
+
+type ListTransactionsByTagParams struct {
+	Tag    string
+	FirmID int64
+}
+
+type ListTransactionsByTagRow struct {
+	ID               int64
+	PartyID          int64
+	Amount           float64
+	TransactionDate  time.Time
+	PaymentMode      sql.NullString
+	Narration        sql.NullString
+	CashBankCode     sql.NullString
+	CashBankLocation sql.NullString
+	Category         string
+	IsInternal       bool
+	AccountID        sql.NullInt64
+	Note             string
+	FirmID           int64
+	CreatedAt        sql.NullTime
+	ImportBatchID    sql.NullInt64
+	PartyName        string
+}
+
+func (q *Queries) ListTransactionsByTag(ctx context.Context, arg ListTransactionsByTagParams) ([]ListTransactionsByTagRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTransactionsByTag, arg.Tag, arg.FirmID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTransactionsByTagRow
+	for rows.Next() {
+		var i ListTransactionsByTagRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.PartyID,
+			&i.Amount,
+			&i.TransactionDate,
+			&i.PaymentMode,
+			&i.Narration,
+			&i.CashBankCode,
+			&i.CashBankLocation,
+			&i.Category,
+			&i.IsInternal,
+			&i.AccountID,
+			&i.Note,
+			&i.FirmID,
+			&i.CreatedAt,
+			&i.ImportBatchID,
+			&i.PartyName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransactionsInPeriod = `-- name: ListTransactionsInPeriod :many
+SELECT id, party_id, amount, transaction_date, payment_mode, narration, cash_bank_code, cash_bank_location, category, is_internal, account_id, note, firm_id, created_at, import_batch_id FROM transactions
+WHERE firm_id = ? AND transaction_date BETWEEN ? AND ?
+ORDER BY transaction_date DESC, id DESC
+LIMIT ? OFFSET ?
+`
+
+type ListTransactionsInPeriodParams struct {
+	FirmID            int64
+	TransactionDate   time.Time
+	TransactionDate_2 time.Time
+	Limit             int64
+	Offset            int64
+}
+
+func (q *Queries) ListTransactionsInPeriod(ctx context.Context, arg ListTransactionsInPeriodParams) ([]Transaction, error) {
+	rows, err := q.db.QueryContext(ctx, listTransactionsInPeriod,
+		arg.FirmID,
+		arg.TransactionDate,
+		arg.TransactionDate_2,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Transaction
+	for rows.Next() {
+		var i Transaction
+		if err := rows.Scan(
+			&i.ID,
+			&i.PartyID,
+			&i.Amount,
+			&i.TransactionDate,
+			&i.PaymentMode,
+			&i.Narration,
+			&i.CashBankCode,
+			&i.CashBankLocation,
+			&i.Category,
+			&i.IsInternal,
+			&i.AccountID,
+			&i.Note,
+			&i.FirmID,
+			&i.CreatedAt,
+			&i.ImportBatchID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnassignedAgentReceipts = `-- name: ListUnassignedAgentReceipts :many
+SELECT id, narration, cash_bank_location FROM transactions
+WHERE firm_id = ? AND is_internal = 0 AND payment_mode IS NOT 'OPENING'
+    AND (narration IS NOT NULL OR cash_bank_location IS NOT NULL)
+    AND id NOT IN (SELECT transaction_id FROM transaction_agents)
+`
+
+type ListUnassignedAgentReceiptsRow struct {
+	ID               int64
+	Narration        sql.NullString
+	CashBankLocation sql.NullString
+}
+
+func (q *Queries) ListUnassignedAgentReceipts(ctx context.Context, firmID int64) ([]ListUnassignedAgentReceiptsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUnassignedAgentReceipts, firmID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUnassignedAgentReceiptsRow
+	for rows.Next() {
+		var i ListUnassignedAgentReceiptsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Narration,
+			&i.CashBankLocation,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnlinkedSaleBills = `-- name: ListUnlinkedSaleBills :many
+SELECT id, bill_number, bill_date, party_name, amount, is_cash_sale, is_card_sale, party_id, firm_id, created_at, irn, ack_number, ack_date, taxable_value, cgst, sgst, igst FROM sale_bills
+WHERE party_id IS NULL AND COALESCE(is_cash_sale, FALSE) = FALSE AND is_card_sale = FALSE AND firm_id = ?
+  AND NOT EXISTS (
+    SELECT 1 FROM financial_years fy
+    WHERE fy.firm_id = sale_bills.firm_id AND sale_bills.bill_date BETWEEN fy.start_date AND fy.end_date)
+ORDER BY party_name, bill_date
+`
+
+func (q *Queries) ListUnlinkedSaleBills(ctx context.Context, firmID int64) ([]SaleBill, error) {
+	rows, err := q.db.QueryContext(ctx, listUnlinkedSaleBills, firmID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SaleBill
+	for rows.Next() {
+		var i SaleBill
+		if err := rows.Scan(
+			&i.ID,
+			&i.BillNumber,
+			&i.BillDate,
+			&i.PartyName,
+			&i.Amount,
+			&i.IsCashSale,
+			&i.IsCardSale,
+			&i.PartyID,
+			&i.FirmID,
+			&i.CreatedAt,
+			&i.Irn,
+			&i.AckNumber,
+			&i.AckDate,
+			&i.TaxableValue,
+			&i.Cgst,
+			&i.Sgst,
+			&i.Igst,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnroutedLocations = `-- name: ListUnroutedLocations :many
+SELECT CAST(UPPER(TRIM(location)) AS TEXT) as location, COUNT(*) as party_count
+FROM parties
+WHERE firm_id = ? AND TRIM(COALESCE(location, '')) != ''
+    AND UPPER(TRIM(location)) NOT IN (SELECT location FROM route_locations WHERE firm_id = ?)
+GROUP BY UPPER(TRIM(location))
+ORDER BY party_count DESC, location
+`
+
+type ListUnroutedLocationsParams struct {
+	FirmID   int64
+	FirmID_2 int64
+}
+
+type ListUnroutedLocationsRow struct {
+	Location   string
+	PartyCount int64
+}
+
+func (q *Queries) ListUnroutedLocations(ctx context.Context, arg ListUnroutedLocationsParams) ([]ListUnroutedLocationsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUnroutedLocations, arg.FirmID, arg.FirmID_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUnroutedLocationsRow
+	for rows.Next() {
+		var i ListUnroutedLocationsRow
+		if err := rows.Scan(&i.Location, &i.PartyCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markSMSAcknowledgementFailed = `-- name: MarkSMSAcknowledgementFailed :exec
+UPDATE sms_acknowledgements SET message = ?, error = ?, attempts = attempts + 1
+WHERE id = ?
+`
+
+type MarkSMSAcknowledgementFailedParams struct {
+	Message string
+	Error   string
+	ID      int64
+}
+
+func (q *Queries) MarkSMSAcknowledgementFailed(ctx context.Context, arg MarkSMSAcknowledgementFailedParams) error {
+	_, err := q.db.ExecContext(ctx, markSMSAcknowledgementFailed, arg.Message, arg.Error, arg.ID)
+	return err
+}
+
+const markSMSAcknowledgementSent = `-- name: MarkSMSAcknowledgementSent :exec
+UPDATE sms_acknowledgements SET message = ?, error = '', sent_at = CURRENT_TIMESTAMP, attempts = attempts + 1
+WHERE id = ?
+`
+
+type MarkSMSAcknowledgementSentParams struct {
+	Message string
+	ID      int64
+}
+
+func (q *Queries) MarkSMSAcknowledgementSent(ctx context.Context, arg MarkSMSAcknowledgementSentParams) error {
+	_, err := q.db.ExecContext(ctx, markSMSAcknowledgementSent, arg.Message, arg.ID)
+	return err
+}
+
+const moveIdentifier = `-- name: MoveIdentifier :exec
+UPDATE identifiers SET party_id = ? WHERE firm_id = ? AND type = ? AND value = ?
+`
+
+type MoveIdentifierParams struct {
+	PartyID int64
+	FirmID  int64
+	Type    string
+	Value   string
+}
+
+func (q *Queries) MoveIdentifier(ctx context.Context, arg MoveIdentifierParams) error {
+	_, err := q.db.ExecContext(ctx, moveIdentifier,
+		arg.PartyID,
+		arg.FirmID,
+		arg.Type,
+		arg.Value,
+	)
+	return err
+}
+
+const purgeBillAllocations = `-- name: PurgeBillAllocations :exec
+DELETE FROM bill_allocations
+WHERE transaction_id IN (SELECT id FROM transactions WHERE firm_id = ? AND transaction_date < ?)
+    OR sale_bill_id IN (SELECT id FROM sale_bills WHERE firm_id = ? AND bill_date < ?)
+`
+
+type PurgeBillAllocationsParams struct {
+	FirmID          int64
+	TransactionDate time.Time
+	FirmID_2        int64
+	BillDate        time.Time
+}
+
+func (q *Queries) PurgeBillAllocations(ctx context.Context, arg PurgeBillAllocationsParams) error {
+	_, err := q.db.ExecContext(ctx, purgeBillAllocations,
+		arg.FirmID,
+		arg.TransactionDate,
+		arg.FirmID_2,
+		arg.BillDate,
+	)
+	return err
+}
+
+const purgeCheques = `-- name: PurgeCheques :execrows
+DELETE FROM cheques
+WHERE transaction_id IN (SELECT id FROM transactions WHERE firm_id = ? AND transaction_date < ?)
+`
+
+type PurgeChequesParams struct {
+	FirmID          int64
+	TransactionDate time.Time
+}
+
+func (q *Queries) PurgeCheques(ctx context.Context, arg PurgeChequesParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeCheques, arg.FirmID, arg.TransactionDate)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const purgePOSSettlements = `-- name: PurgePOSSettlements :execrows
+DELETE FROM pos_settlements WHERE firm_id = ? AND credit_date < ?
+`
+
+type PurgePOSSettlementsParams struct {
+	FirmID     int64
+	CreditDate time.Time
+}
+
+func (q *Queries) PurgePOSSettlements(ctx context.Context, arg PurgePOSSettlementsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgePOSSettlements, arg.FirmID, arg.CreditDate)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const purgeReceiptBookTotals = `-- name: PurgeReceiptBookTotals :exec
+DELETE FROM receipt_book_totals WHERE firm_id = ? AND end_date < ?
+`
+
+type PurgeReceiptBookTotalsParams struct {
+	FirmID  int64
+	EndDate time.Time
+}
+
+func (q *Queries) PurgeReceiptBookTotals(ctx context.Context, arg PurgeReceiptBookTotalsParams) error {
+	_, err := q.db.ExecContext(ctx, purgeReceiptBookTotals, arg.FirmID, arg.EndDate)
+	return err
+}
+
+const purgeSaleBills = `-- name: PurgeSaleBills :execrows
+DELETE FROM sale_bills WHERE firm_id = ? AND bill_date < ?
+`
+
+type PurgeSaleBillsParams struct {
+	FirmID   int64
+	BillDate time.Time
+}
+
+func (q *Queries) PurgeSaleBills(ctx context.Context, arg PurgeSaleBillsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeSaleBills, arg.FirmID, arg.BillDate)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const purgeTransactionAgents = `-- name: PurgeTransactionAgents :exec
+DELETE FROM transaction_agents
+WHERE transaction_id IN (SELECT id FROM transactions WHERE firm_id = ? AND transaction_date < ?)
+`
+
+type PurgeTransactionAgentsParams struct {
+	FirmID          int64
+	TransactionDate time.Time
+}
+
+func (q *Queries) PurgeTransactionAgents(ctx context.Context, arg PurgeTransactionAgentsParams) error {
+	_, err := q.db.ExecContext(ctx, purgeTransactionAgents, arg.FirmID, arg.TransactionDate)
+	return err
+}
+
+const purgeTransactionMerges = `-- name: PurgeTransactionMerges :exec
+DELETE FROM transaction_merges
+WHERE transaction_id IN (SELECT id FROM transactions WHERE firm_id = ? AND transaction_date < ?)
+`
+
+type PurgeTransactionMergesParams struct {
+	FirmID          int64
+	TransactionDate time.Time
+}
+
+func (q *Queries) PurgeTransactionMerges(ctx context.Context, arg PurgeTransactionMergesParams) error {
+	_, err := q.db.ExecContext(ctx, purgeTransactionMerges, arg.FirmID, arg.TransactionDate)
+	return err
+}
+
+const purgeTransactionSplits = `-- name: PurgeTransactionSplits :exec
+DELETE FROM transaction_splits
+WHERE transaction_id IN (SELECT id FROM transactions WHERE firm_id = ? AND transaction_date < ?)
+    OR source_transaction_id IN (SELECT id FROM transactions WHERE firm_id = ? AND transaction_date < ?)
+`
+
+type PurgeTransactionSplitsParams struct {
+	FirmID            int64
+	TransactionDate   time.Time
+	FirmID_2          int64
+	TransactionDate_2 time.Time
+}
+
+func (q *Queries) PurgeTransactionSplits(ctx context.Context, arg PurgeTransactionSplitsParams) error {
+	_, err := q.db.ExecContext(ctx, purgeTransactionSplits,
+		arg.FirmID,
+		arg.TransactionDate,
+		arg.FirmID_2,
+		arg.TransactionDate_2,
+	)
+	return err
+}
+
+const purgeTransactionTags = `-- name: PurgeTransactionTags :execrows
+DELETE FROM transaction_tags
+WHERE transaction_id IN (SELECT id FROM transactions WHERE firm_id = ? AND transaction_date < ?)
+`
+
+type PurgeTransactionTagsParams struct {
+	FirmID          int64
+	TransactionDate time.Time
+}
+
+func (q *Queries) PurgeTransactionTags(ctx context.Context, arg PurgeTransactionTagsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeTransactionTags, arg.FirmID, arg.TransactionDate)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const purgeTransactions = `-- name: PurgeTransactions :execrows
+DELETE FROM transactions WHERE firm_id = ? AND transaction_date < ?
+`
+
+type PurgeTransactionsParams struct {
+	FirmID          int64
+	TransactionDate time.Time
+}
+
+func (q *Queries) PurgeTransactions(ctx context.Context, arg PurgeTransactionsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeTransactions, arg.FirmID, arg.TransactionDate)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const queueSMSAcknowledgement = `-- name: QueueSMSAcknowledgement :exec
+INSERT OR IGNORE INTO sms_acknowledgements (firm_id, party_id, transaction_id, phone)
+VALUES (?, ?, ?, ?)
+`
+
+type QueueSMSAcknowledgementParams struct {
+	FirmID        int64
+	PartyID       int64
+	TransactionID int64
+	Phone         string
+}
+
+func (q *Queries) QueueSMSAcknowledgement(ctx context.Context, arg QueueSMSAcknowledgementParams) error {
+	_, err := q.db.ExecContext(ctx, queueSMSAcknowledgement,
+		arg.FirmID,
+		arg.PartyID,
+		arg.TransactionID,
+		arg.Phone,
+	)
+	return err
+}
+
+const recordBackup = `-- name: RecordBackup :one
+INSERT INTO backups (filename, size_bytes)
+VALUES (?, ?)
+RETURNING id, filename, size_bytes, created_at
+`
+
+type RecordBackupParams struct {
+	Filename  string
+	SizeBytes int64
+}
+
+func (q *Queries) RecordBackup(ctx context.Context, arg RecordBackupParams) (Backup, error) {
+	row := q.db.QueryRowContext(ctx, recordBackup, arg.Filename, arg.SizeBytes)
+	var i Backup
+	err := row.Scan(
+		&i.ID,
+		&i.Filename,
+		&i.SizeBytes,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const recordOffsiteBackup = `-- name: RecordOffsiteBackup :exec
+INSERT INTO offsite_backups (object_key, size_bytes, error)
+VALUES (?, ?, ?)
+`
+
+type RecordOffsiteBackupParams struct {
+	ObjectKey string
+	SizeBytes int64
+	Error     string
+}
+
+func (q *Queries) RecordOffsiteBackup(ctx context.Context, arg RecordOffsiteBackupParams) error {
+	_, err := q.db.ExecContext(ctx, recordOffsiteBackup, arg.ObjectKey, arg.SizeBytes, arg.Error)
+	return err
+}
+
+const recordSearch = `-- name: RecordSearch :exec
+INSERT INTO search_history (narration, top_party_id, top_party_name, top_confidence, result_count, firm_id)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT (firm_id, narration) DO UPDATE SET
+    top_party_id = excluded.top_party_id,
+    top_party_name = excluded.top_party_name,
+    top_confidence = excluded.top_confidence,
+    result_count = excluded.result_count,
+    searched_at = CURRENT_TIMESTAMP
+`
+
+type RecordSearchParams struct {
+	Narration     string
+	TopPartyID    sql.NullInt64
+	TopPartyName  sql.NullString
+	TopConfidence float64
+	ResultCount   int64
+	FirmID        int64
+}
+
+func (q *Queries) RecordSearch(ctx context.Context, arg RecordSearchParams) error {
+	_, err := q.db.ExecContext(ctx, recordSearch,
+		arg.Narration,
+		arg.TopPartyID,
+		arg.TopPartyName,
+		arg.TopConfidence,
+		arg.ResultCount,
+		arg.FirmID,
+	)
+	return err
+}
+
+const reopenFinancialYear = `-- name: ReopenFinancialYear :exec
+DELETE FROM financial_years WHERE id = ? AND firm_id = ? AND archived_at IS NULL
+`
+
+type ReopenFinancialYearParams struct {
+	ID     int64
+	FirmID int64
+}
+
+func (q *Queries) ReopenFinancialYear(ctx context.Context, arg ReopenFinancialYearParams) error {
+	_, err := q.db.ExecContext(ctx, reopenFinancialYear, arg.ID, arg.FirmID)
+	return err
+}
+
+const resolveIdentifierConflict = `-- name: ResolveIdentifierConflict :exec
+UPDATE identifier_conflicts SET resolution = ?, resolved_at = CURRENT_TIMESTAMP WHERE id = ?
+`
+
+type ResolveIdentifierConflictParams struct {
+	Resolution sql.NullString
+	ID         int64
+}
+
+func (q *Queries) ResolveIdentifierConflict(ctx context.Context, arg ResolveIdentifierConflictParams) error {
+	_, err := q.db.ExecContext(ctx, resolveIdentifierConflict, arg.Resolution, arg.ID)
+	return err
+}
+
+const resolvePendingImport = `-- name: ResolvePendingImport :exec
+UPDATE pending_imports SET status = ?, resolved_at = CURRENT_TIMESTAMP
+WHERE id = ? AND status = 'pending'
+`
+
+type ResolvePendingImportParams struct {
+	Status string
+	ID     int64
+}
+
+func (q *Queries) ResolvePendingImport(ctx context.Context, arg ResolvePendingImportParams) error {
+	_, err := q.db.ExecContext(ctx, resolvePendingImport, arg.Status, arg.ID)
+	return err
+}
+
+const retrySMSAcknowledgement = `-- name: RetrySMSAcknowledgement :exec
+UPDATE sms_acknowledgements SET attempts = 0
+WHERE id = ? AND firm_id = ? AND sent_at IS NULL
+`
+
+type RetrySMSAcknowledgementParams struct {
+	ID     int64
+	FirmID int64
+}
+
+func (q *Queries) RetrySMSAcknowledgement(ctx context.Context, arg RetrySMSAcknowledgementParams) error {
+	_, err := q.db.ExecContext(ctx, retrySMSAcknowledgement, arg.ID, arg.FirmID)
+	return err
+}
+
+const searchChequesByNumber = `-- name: SearchChequesByNumber :many
+SELECT c.id, c.transaction_id, c.cheque_number, c.cheque_date, c.status, c.received_date, c.deposited_date, c.cleared_date, c.bounced_date, c.notes, c.updated_at, t.amount, t.transaction_date, t.payment_mode, p.id as party_id, p.name as party_name, p.location as party_location
+FROM cheques c
+JOIN transactions t ON t.id = c.transaction_id
+JOIN parties p ON p.id = t.party_id
+WHERE p.firm_id = ? AND ltrim(c.cheque_number, '0') LIKE ?
+ORDER BY c.received_date DESC, c.id DESC
+LIMIT 50
+`
+
+type SearchChequesByNumberParams struct {
+	FirmID       int64
+	ChequeNumber sql.NullString
+}
+
+type SearchChequesByNumberRow struct {
+	ID              int64
+	TransactionID   int64
+	ChequeNumber    sql.NullString
+	ChequeDate      sql.NullTime
+	Status          string
+	ReceivedDate    time.Time
+	DepositedDate   sql.NullTime
+	ClearedDate     sql.NullTime
+	BouncedDate     sql.NullTime
+	Notes           sql.NullString
+	UpdatedAt       sql.NullTime
+	Amount          float64
+	TransactionDate time.Time
+	PaymentMode     sql.NullString
+	PartyID         int64
+	PartyName       string
+	PartyLocation   sql.NullString
+}
+
+func (q *Queries) SearchChequesByNumber(ctx context.Context, arg SearchChequesByNumberParams) ([]SearchChequesByNumberRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchChequesByNumber, arg.FirmID, arg.ChequeNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchChequesByNumberRow
+	for rows.Next() {
+		var i SearchChequesByNumberRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TransactionID,
+			&i.ChequeNumber,
+			&i.ChequeDate,
+			&i.Status,
+			&i.ReceivedDate,
+			&i.DepositedDate,
+			&i.ClearedDate,
+			&i.BouncedDate,
+			&i.Notes,
+			&i.UpdatedAt,
+			&i.Amount,
+			&i.TransactionDate,
+			&i.PaymentMode,
+			&i.PartyID,
+			&i.PartyName,
+			&i.PartyLocation,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchReceipts = `-- name: SearchReceipts :many
+SELECT t.id, t.transaction_date, t.amount, t.payment_mode, t.narration,
+    p.name as party_name, p.location as party_location
+FROM transactions t
+JOIN parties p ON p.id = t.party_id
+WHERE t.firm_id = ? AND (t.narration LIKE ? OR p.name LIKE ?)
+ORDER BY t.transaction_date DESC, t.id DESC
+LIMIT 100
+`
+
+type SearchReceiptsParams struct {
+	FirmID    int64
+	Narration sql.NullString
+	Name      string
+}
+
+type SearchReceiptsRow struct {
+	ID              int64
+	TransactionDate time.Time
+	Amount          float64
+	PaymentMode     sql.NullString
+	Narration       sql.NullString
+	PartyName       string
+	PartyLocation   sql.NullString
+}
+
+func (q *Queries) SearchReceipts(ctx context.Context, arg SearchReceiptsParams) ([]SearchReceiptsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchReceipts, arg.FirmID, arg.Narration, arg.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchReceiptsRow
+	for rows.Next() {
+		var i SearchReceiptsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TransactionDate,
+			&i.Amount,
+			&i.PaymentMode,
+			&i.Narration,
+			&i.PartyName,
+			&i.PartyLocation,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchSaleBills = `-- name: SearchSaleBills :many
+SELECT id, bill_number, bill_date, party_name, amount, is_cash_sale, is_card_sale, party_id, firm_id, created_at, irn, ack_number, ack_date, taxable_value, cgst, sgst, igst FROM sale_bills
+WHERE firm_id = ? AND (party_name LIKE ? OR bill_number LIKE ?)
+ORDER BY bill_date DESC, id DESC
+LIMIT 100
+`
+
+type SearchSaleBillsParams struct {
+	FirmID     int64
+	PartyName  string
+	BillNumber string
+}
+
+func (q *Queries) SearchSaleBills(ctx context.Context, arg SearchSaleBillsParams) ([]SaleBill, error) {
+	rows, err := q.db.QueryContext(ctx, searchSaleBills, arg.FirmID, arg.PartyName, arg.BillNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SaleBill
+	for rows.Next() {
+		var i SaleBill
+		if err := rows.Scan(
+			&i.ID,
+			&i.BillNumber,
+			&i.BillDate,
+			&i.PartyName,
+			&i.Amount,
+			&i.IsCashSale,
+			&i.IsCardSale,
+			&i.PartyID,
+			&i.FirmID,
+			&i.CreatedAt,
+			&i.Irn,
+			&i.AckNumber,
+			&i.AckDate,
+			&i.TaxableValue,
+			&i.Cgst,
+			&i.Sgst,
+			&i.Igst,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchSaleBillsByAmountRange = `-- name: SearchSaleBillsByAmountRange :many
+SELECT id, bill_number, bill_date, party_name, amount, is_cash_sale, is_card_sale, party_id, firm_id, created_at, irn, ack_number, ack_date, taxable_value, cgst, sgst, igst FROM sale_bills
+WHERE amount >= ? AND amount <= ?
+  AND bill_date >= ? AND bill_date <= ?
+  AND firm_id = ?
+ORDER BY bill_date DESC, amount DESC
+LIMIT 100
+`
+
+type SearchSaleBillsByAmountRangeParams struct {
+	Amount     float64
+	Amount_2   float64
+	BillDate   time.Time
+	BillDate_2 time.Time
+	FirmID     int64
+}
+
+func (q *Queries) SearchSaleBillsByAmountRange(ctx context.Context, arg SearchSaleBillsByAmountRangeParams) ([]SaleBill, error) {
+	rows, err := q.db.QueryContext(ctx, searchSaleBillsByAmountRange,
+		arg.Amount,
+		arg.Amount_2,
+		arg.BillDate,
+		arg.BillDate_2,
+		arg.FirmID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SaleBill
+	for rows.Next() {
+		var i SaleBill
+		if err := rows.Scan(
+			&i.ID,
+			&i.BillNumber,
+			&i.BillDate,
+			&i.PartyName,
+			&i.Amount,
+			&i.IsCashSale,
+			&i.IsCardSale,
+			&i.PartyID,
+			&i.FirmID,
+			&i.CreatedAt,
+			&i.Irn,
+			&i.AckNumber,
+			&i.AckDate,
+			&i.TaxableValue,
+			&i.Cgst,
+			&i.Sgst,
+			&i.Igst,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchSaleBillsByAmountRangeForAccount = `-- name: SearchSaleBillsByAmountRangeForAccount :many
+SELECT id, bill_number, bill_date, party_name, amount, is_cash_sale, is_card_sale, party_id, firm_id, created_at, irn, ack_number, ack_date, taxable_value, cgst, sgst, igst FROM sale_bills
+WHERE amount >= ? AND amount <= ?
+  AND bill_date >= ? AND bill_date <= ?
+  AND firm_id = ?
+  AND party_id IN (SELECT t.party_id FROM transactions t WHERE t.account_id = ?)
+ORDER BY bill_date DESC, amount DESC
+LIMIT 100
+`
+
+type SearchSaleBillsByAmountRangeForAccountParams struct {
+	Amount     float64
+	Amount_2   float64
+	BillDate   time.Time
+	BillDate_2 time.Time
+	FirmID     int64
+	AccountID  sql.NullInt64
+}
+
+func (q *Queries) SearchSaleBillsByAmountRangeForAccount(ctx context.Context, arg SearchSaleBillsByAmountRangeForAccountParams) ([]SaleBill, error) {
+	rows, err := q.db.QueryContext(ctx, searchSaleBillsByAmountRangeForAccount,
+		arg.Amount,
+		arg.Amount_2,
+		arg.BillDate,
+		arg.BillDate_2,
+		arg.FirmID,
+		arg.AccountID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SaleBill
+	for rows.Next() {
+		var i SaleBill
+		if err := rows.Scan(
+			&i.ID,
+			&i.BillNumber,
+			&i.BillDate,
+			&i.PartyName,
+			&i.Amount,
+			&i.IsCashSale,
+			&i.IsCardSale,
+			&i.PartyID,
+			&i.FirmID,
+			&i.CreatedAt,
+			&i.Irn,
+			&i.AckNumber,
+			&i.AckDate,
+			&i.TaxableValue,
+			&i.Cgst,
+			&i.Sgst,
+			&i.Igst,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchSaleBillsByIRN = `-- name: SearchSaleBillsByIRN :many
+SELECT id, bill_number, bill_date, party_name, amount, is_cash_sale, is_card_sale, party_id, firm_id, created_at, irn, ack_number, ack_date, taxable_value, cgst, sgst, igst FROM sale_bills
+WHERE firm_id = ? AND (irn LIKE ? OR ack_number = ?)
+ORDER BY bill_date DESC, id DESC
+LIMIT 50
+`
+
+type SearchSaleBillsByIRNParams struct {
+	FirmID    int64
+	Irn       string
+	AckNumber string
+}
+
+func (q *Queries) SearchSaleBillsByIRN(ctx context.Context, arg SearchSaleBillsByIRNParams) ([]SaleBill, error) {
+	rows, err := q.db.QueryContext(ctx, searchSaleBillsByIRN, arg.FirmID, arg.Irn, arg.AckNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SaleBill
+	for rows.Next() {
+		var i SaleBill
+		if err := rows.Scan(
+			&i.ID,
+			&i.BillNumber,
+			&i.BillDate,
+			&i.PartyName,
+			&i.Amount,
+			&i.IsCashSale,
+			&i.IsCardSale,
+			&i.PartyID,
+			&i.FirmID,
+			&i.CreatedAt,
+			&i.Irn,
+			&i.AckNumber,
+			&i.AckDate,
+			&i.TaxableValue,
+			&i.Cgst,
+			&i.Sgst,
+			&i.Igst,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchTransactionsByReference = `-- name: SearchTransactionsByReference :many
+SELECT t.id, t.party_id, t.amount, t.transaction_date, t.payment_mode, t.narration, t.cash_bank_code, t.cash_bank_location, t.category, t.is_internal, t.account_id, t.note, t.firm_id, t.created_at, t.import_batch_id, p.name AS party_name, p.location AS party_location, b.imported_at AS batch_imported_at
+FROM transactions t
+JOIN parties p ON p.id = t.party_id
+LEFT JOIN import_batches b ON b.id = t.import_batch_id
+WHERE t.firm_id = ? AND replace(upper(t.narration), ' ', '') LIKE ?
+ORDER BY t.transaction_date DESC, t.id DESC
+LIMIT 50
+`
+
+type SearchTransactionsByReferenceParams struct {
+	FirmID    int64
+	Narration sql.NullString
+}
+
+type SearchTransactionsByReferenceRow struct {
+	ID               int64
+	PartyID          int64
+	Amount           float64
+	TransactionDate  time.Time
+	PaymentMode      sql.NullString
+	Narration        sql.NullString
+	CashBankCode     sql.NullString
+	CashBankLocation sql.NullString
+	Category         string
+	IsInternal       bool
+	AccountID        sql.NullInt64
+	Note             string
+	FirmID           int64
+	CreatedAt        sql.NullTime
+	ImportBatchID    sql.NullInt64
+	PartyName        string
+	PartyLocation    sql.NullString
+	BatchImportedAt  sql.NullTime
+}
+
+func (q *Queries) SearchTransactionsByReference(ctx context.Context, arg SearchTransactionsByReferenceParams) ([]SearchTransactionsByReferenceRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchTransactionsByReference, arg.FirmID, arg.Narration)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchTransactionsByReferenceRow
+	for rows.Next() {
+		var i SearchTransactionsByReferenceRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.PartyID,
+			&i.Amount,
+			&i.TransactionDate,
+			&i.PaymentMode,
+			&i.Narration,
+			&i.CashBankCode,
+			&i.CashBankLocation,
+			&i.Category,
+			&i.IsInternal,
+			&i.AccountID,
+			&i.Note,
+			&i.FirmID,
+			&i.CreatedAt,
+			&i.ImportBatchID,
+			&i.PartyName,
+			&i.PartyLocation,
+			&i.BatchImportedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setFinancialYearArchived = `-- name: SetFinancialYearArchived :exec
+UPDATE financial_years SET archive_path = ?, archived_at = CURRENT_TIMESTAMP WHERE id = ?
+`
+
+type SetFinancialYearArchivedParams struct {
+	ArchivePath string
+	ID          int64
+}
+
+func (q *Queries) SetFinancialYearArchived(ctx context.Context, arg SetFinancialYearArchivedParams) error {
+	_, err := q.db.ExecContext(ctx, setFinancialYearArchived, arg.ArchivePath, arg.ID)
+	return err
+}
+
+const setRouteLocation = `-- name: SetRouteLocation :exec
+INSERT INTO route_locations (firm_id, route_id, location)
+VALUES (?, ?, ?)
+ON CONFLICT (firm_id, location) DO UPDATE SET route_id = excluded.route_id
+`
+
+type SetRouteLocationParams struct {
+	FirmID   int64
+	RouteID  int64
+	Location string
+}
+
+func (q *Queries) SetRouteLocation(ctx context.Context, arg SetRouteLocationParams) error {
+	_, err := q.db.ExecContext(ctx, setRouteLocation, arg.FirmID, arg.RouteID, arg.Location)
+	return err
+}
+
+const setSaleBillEInvoice = `-- name: SetSaleBillEInvoice :execrows
+UPDATE sale_bills SET irn = ?, ack_number = ?, ack_date = ?
+WHERE firm_id = ? AND bill_number = ? AND bill_date = ? AND party_name = ? AND amount = ? AND irn = ''
+`
+
+type SetSaleBillEInvoiceParams struct {
+	Irn        string
+	AckNumber  string
+	AckDate    sql.NullTime
+	FirmID     int64
+	BillNumber string
+	BillDate   time.Time
+	PartyName  string
+	Amount     float64
+}
+
+func (q *Queries) SetSaleBillEInvoice(ctx context.Context, arg SetSaleBillEInvoiceParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setSaleBillEInvoice,
+		arg.Irn,
+		arg.AckNumber,
+		arg.AckDate,
+		arg.FirmID,
+		arg.BillNumber,
+		arg.BillDate,
+		arg.PartyName,
+		arg.Amount,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setSaleBillTaxes = `-- name: SetSaleBillTaxes :execrows
+UPDATE sale_bills SET taxable_value = ?, cgst = ?, sgst = ?, igst = ?
+WHERE firm_id = ? AND bill_number = ? AND bill_date = ? AND party_name = ? AND amount = ?
+  AND taxable_value = 0 AND cgst = 0 AND sgst = 0 AND igst = 0
+`
+
+type SetSaleBillTaxesParams struct {
+	TaxableValue float64
+	Cgst         float64
+	Sgst         float64
+	Igst         float64
+	FirmID       int64
+	BillNumber   string
+	BillDate     time.Time
+	PartyName    string
+	Amount       float64
+}
+
+func (q *Queries) SetSaleBillTaxes(ctx context.Context, arg SetSaleBillTaxesParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setSaleBillTaxes,
+		arg.TaxableValue,
+		arg.Cgst,
+		arg.Sgst,
+		arg.Igst,
+		arg.FirmID,
+		arg.BillNumber,
+		arg.BillDate,
+		arg.PartyName,
+		arg.Amount,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setTransactionAgent = `-- name: SetTransactionAgent :exec
+INSERT INTO transaction_agents (transaction_id, agent_id, source)
+VALUES (?, ?, 'manual')
+ON CONFLICT (transaction_id) DO UPDATE SET agent_id = excluded.agent_id, source = excluded.source
+`
+
+type SetTransactionAgentParams struct {
+	TransactionID int64
+	AgentID       int64
+}
+
+func (q *Queries) SetTransactionAgent(ctx context.Context, arg SetTransactionAgentParams) error {
+	_, err := q.db.ExecContext(ctx, setTransactionAgent, arg.TransactionID, arg.AgentID)
+	return err
+}
+
+const setTransactionImportBatch = `-- name: SetTransactionImportBatch :exec
+UPDATE transactions SET import_batch_id = ? WHERE id = ?
+`
+
+type SetTransactionImportBatchParams struct {
+	ImportBatchID sql.NullInt64
+	ID            int64
+}
+
+func (q *Queries) SetTransactionImportBatch(ctx context.Context, arg SetTransactionImportBatchParams) error {
+	_, err := q.db.ExecContext(ctx, setTransactionImportBatch, arg.ImportBatchID, arg.ID)
+	return err
+}
+
+const splitTransaction = `-- name: SplitTransaction :exec
+UPDATE transactions SET party_id = ?, amount = ? WHERE id = ?
+`
+
+type SplitTransactionParams struct {
+	PartyID int64
+	Amount  float64
+	ID      int64
+}
+
+func (q *Queries) SplitTransaction(ctx context.Context, arg SplitTransactionParams) error {
+	_, err := q.db.ExecContext(ctx, splitTransaction, arg.PartyID, arg.Amount, arg.ID)
+	return err
+}
+
+const sumAccountCreditsAfter = `-- name: SumAccountCreditsAfter :one
+SELECT CAST(COALESCE(SUM(amount), 0) AS REAL) as total
+FROM transactions
+WHERE account_id = ? AND transaction_date > ?
+`
+
+type SumAccountCreditsAfterParams struct {
+	AccountID       sql.NullInt64
+	TransactionDate time.Time
+}
+
+func (q *Queries) SumAccountCreditsAfter(ctx context.Context, arg SumAccountCreditsAfterParams) (float64, error) {
+	row := q.db.QueryRowContext(ctx, sumAccountCreditsAfter, arg.AccountID, arg.TransactionDate)
+	var total float64
+	err := row.Scan(&total)
+	return total, err
+}
+
+const sumAccountPOSCreditsAfter = `-- name: SumAccountPOSCreditsAfter :one
+SELECT CAST(COALESCE(SUM(amount), 0) AS REAL) as total
+FROM pos_settlements
+WHERE account_id = ? AND credit_date > ?
+`
+
+type SumAccountPOSCreditsAfterParams struct {
+	AccountID  sql.NullInt64
+	CreditDate time.Time
+}
+
+func (q *Queries) SumAccountPOSCreditsAfter(ctx context.Context, arg SumAccountPOSCreditsAfterParams) (float64, error) {
+	row := q.db.QueryRowContext(ctx, sumAccountPOSCreditsAfter, arg.AccountID, arg.CreditDate)
+	var total float64
+	err := row.Scan(&total)
+	return total, err
+}
+
+const updateAccountStatement = `-- name: UpdateAccountStatement :exec
+UPDATE accounts SET statement_balance = ?, statement_date = ? WHERE id = ?
+`
+
+type UpdateAccountStatementParams struct {
+	StatementBalance float64
+	StatementDate    sql.NullTime
+	ID               int64
+}
+
+func (q *Queries) UpdateAccountStatement(ctx context.Context, arg UpdateAccountStatementParams) error {
+	_, err := q.db.ExecContext(ctx, updateAccountStatement, arg.StatementBalance, arg.StatementDate, arg.ID)
+	return err
+}
+
+const updateAgent = `-- name: UpdateAgent :exec
+UPDATE agents SET name = ?, code = ?, location = ?, commission_percent = ? WHERE id = ? AND firm_id = ?
+`
+
+type UpdateAgentParams struct {
+	Name              string
+	Code              string
+	Location          string
+	CommissionPercent float64
+	ID                int64
+	FirmID            int64
+}
+
+func (q *Queries) UpdateAgent(ctx context.Context, arg UpdateAgentParams) error {
+	_, err := q.db.ExecContext(ctx, updateAgent,
+		arg.Name,
+		arg.Code,
+		arg.Location,
+		arg.CommissionPercent,
+		arg.ID,
+		arg.FirmID,
+	)
+	return err
+}
+
+const updateChequeStatus = `-- name: UpdateChequeStatus :exec
+UPDATE cheques
+SET status = ?, deposited_date = ?, cleared_date = ?, bounced_date = ?, notes = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type UpdateChequeStatusParams struct {
+	Status        string
+	DepositedDate sql.NullTime
+	ClearedDate   sql.NullTime
+	BouncedDate   sql.NullTime
+	Notes         sql.NullString
+	ID            int64
+}
+
+func (q *Queries) UpdateChequeStatus(ctx context.Context, arg UpdateChequeStatusParams) error {
+	_, err := q.db.ExecContext(ctx, updateChequeStatus,
+		arg.Status,
+		arg.DepositedDate,
+		arg.ClearedDate,
+		arg.BouncedDate,
+		arg.Notes,
+		arg.ID,
+	)
+	return err
+}
+
+const updateFirm = `-- name: UpdateFirm :exec
+UPDATE firms SET name = ?, gstin = ?, rounding_tolerance = ? WHERE id = ?
+`
+
+type UpdateFirmParams struct {
+	Name              string
+	Gstin             string
+	RoundingTolerance float64
+	ID                int64
+}
+
+func (q *Queries) UpdateFirm(ctx context.Context, arg UpdateFirmParams) error {
+	_, err := q.db.ExecContext(ctx, updateFirm,
+		arg.Name,
+		arg.Gstin,
+		arg.RoundingTolerance,
+		arg.ID,
+	)
+	return err
+}
+
+const updateIdentifierSighting = `-- name: UpdateIdentifierSighting :exec
+UPDATE identifiers SET first_seen = ?, last_seen = ?, hit_count = ? WHERE id = ?
+`
+
+type UpdateIdentifierSightingParams struct {
+	FirstSeen sql.NullTime
+	LastSeen  sql.NullTime
+	HitCount  int64
+	ID        int64
+}
+
+func (q *Queries) UpdateIdentifierSighting(ctx context.Context, arg UpdateIdentifierSightingParams) error {
+	_, err := q.db.ExecContext(ctx, updateIdentifierSighting,
+		arg.FirstSeen,
+		arg.LastSeen,
+		arg.HitCount,
+		arg.ID,
+	)
+	return err
+}
+
+const updatePartyContact = `-- name: UpdatePartyContact :exec
+UPDATE parties SET phone = ?, address = ?, gstin = ?, drug_license = ? WHERE id = ? AND firm_id = ?
+`
+
+type UpdatePartyContactParams struct {
+	Phone       string
+	Address     string
+	Gstin       string
+	DrugLicense string
+	ID          int64
+	FirmID      int64
+}
+
+func (q *Queries) UpdatePartyContact(ctx context.Context, arg UpdatePartyContactParams) error {
+	_, err := q.db.ExecContext(ctx, updatePartyContact,
+		arg.Phone,
+		arg.Address,
+		arg.Gstin,
+		arg.DrugLicense,
+		arg.ID,
+		arg.FirmID,
+	)
+	return err
+}
+
+const updatePartyCreditLimit = `-- name: UpdatePartyCreditLimit :exec
+UPDATE parties SET credit_limit = ? WHERE id = ?
+`
+
+type UpdatePartyCreditLimitParams struct {
+	CreditLimit float64
+	ID          int64
+}
+
+func (q *Queries) UpdatePartyCreditLimit(ctx context.Context, arg UpdatePartyCreditLimitParams) error {
+	_, err := q.db.ExecContext(ctx, updatePartyCreditLimit, arg.CreditLimit, arg.ID)
+	return err
+}
+
+const updatePartyEmail = `-- name: UpdatePartyEmail :exec
+UPDATE parties SET email = ? WHERE id = ? AND firm_id = ?
+`
+
+type UpdatePartyEmailParams struct {
+	Email  string
+	ID     int64
+	FirmID int64
+}
+
+func (q *Queries) UpdatePartyEmail(ctx context.Context, arg UpdatePartyEmailParams) error {
+	_, err := q.db.ExecContext(ctx, updatePartyEmail, arg.Email, arg.ID, arg.FirmID)
+	return err
+}
+
+const updatePartyPhone = `-- name: UpdatePartyPhone :exec
+UPDATE parties SET phone = ? WHERE id = ? AND firm_id = ?
+`
+
+type UpdatePartyPhoneParams struct {
+	Phone  string
+	ID     int64
+	FirmID int64
+}
+
+func (q *Queries) UpdatePartyPhone(ctx context.Context, arg UpdatePartyPhoneParams) error {
+	_, err := q.db.ExecContext(ctx, updatePartyPhone, arg.Phone, arg.ID, arg.FirmID)
+	return err
+}
+
+const updatePaymentModeRule = `-- name: UpdatePaymentModeRule :exec
+UPDATE payment_mode_rules SET pattern = ?, mode = ?, priority = ? WHERE id = ?
+`
+
+type UpdatePaymentModeRuleParams struct {
+	Pattern  string
+	Mode     string
+	Priority int64
+	ID       int64
+}
+
+func (q *Queries) UpdatePaymentModeRule(ctx context.Context, arg UpdatePaymentModeRuleParams) error {
+	_, err := q.db.ExecContext(ctx, updatePaymentModeRule,
+		arg.Pattern,
+		arg.Mode,
+		arg.Priority,
+		arg.ID,
+	)
+	return err
+}
+
+const updateRule = `-- name: UpdateRule :exec
+UPDATE rules
+SET name = ?, narration_pattern = ?, party_pattern = ?, min_amount = ?, max_amount = ?,
+    set_category = ?, set_internal = ?, set_party_name = ?, priority = ?, enabled = ?
+WHERE id = ?
+`
+
+type UpdateRuleParams struct {
+	Name             string
+	NarrationPattern string
+	PartyPattern     string
+	MinAmount        float64
+	MaxAmount        float64
+	SetCategory      string
+	SetInternal      bool
+	SetPartyName     string
+	Priority         int64
+	Enabled          bool
+	ID               int64
+}
+
+func (q *Queries) UpdateRule(ctx context.Context, arg UpdateRuleParams) error {
+	_, err := q.db.ExecContext(ctx, updateRule,
+		arg.Name,
+		arg.NarrationPattern,
+		arg.PartyPattern,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.SetCategory,
+		arg.SetInternal,
+		arg.SetPartyName,
+		arg.Priority,
+		arg.Enabled,
+		arg.ID,
+	)
+	return err
+}
+
+const updateSearch = `-- name: UpdateSearch :exec
+UPDATE search_history
+SET narration = ?, top_party_id = ?, top_party_name = ?, top_confidence = ?, result_count = ?, searched_at = CURRENT_TIMESTAMP
+WHERE id = ? AND firm_id = ?
+`
+
+type UpdateSearchParams struct {
+	Narration     string
+	TopPartyID    sql.NullInt64
+	TopPartyName  sql.NullString
+	TopConfidence float64
+	ResultCount   int64
+	ID            int64
+	FirmID        int64
+}
+
+func (q *Queries) UpdateSearch(ctx context.Context, arg UpdateSearchParams) error {
+	_, err := q.db.ExecContext(ctx, updateSearch,
+		arg.Narration,
+		arg.TopPartyID,
+		arg.TopPartyName,
+		arg.TopConfidence,
+		arg.ResultCount,
+		arg.ID,
+		arg.FirmID,
+	)
+	return err
+}
+
+const updateTransactionNote = `-- name: UpdateTransactionNote :exec
+UPDATE transactions SET note = ? WHERE id = ?
+`
+
+type UpdateTransactionNoteParams struct {
+	Note string
+	ID   int64
+}
+
+func (q *Queries) UpdateTransactionNote(ctx context.Context, arg UpdateTransactionNoteParams) error {
+	_, err := q.db.ExecContext(ctx, updateTransactionNote, arg.Note, arg.ID)
+	return err
+}
+
+const updateTransactionPaymentMode = `-- name: UpdateTransactionPaymentMode :execrows
+UPDATE OR IGNORE transactions SET payment_mode = ? WHERE id = ?
+`
+
+type UpdateTransactionPaymentModeParams struct {
+	PaymentMode sql.NullString
+	ID          int64
+}
+
+func (q *Queries) UpdateTransactionPaymentMode(ctx context.Context, arg UpdateTransactionPaymentModeParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateTransactionPaymentMode, arg.PaymentMode, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const upsertAccount = `-- name: UpsertAccount :one
+INSERT INTO accounts (bank, account_number, firm_id)
+VALUES (?, ?, ?)
+ON CONFLICT (firm_id, bank, account_number) DO UPDATE SET bank = excluded.bank
+RETURNING id, bank, account_number, statement_balance, statement_date, firm_id, created_at
+`
+
+type UpsertAccountParams struct {
+	Bank          string
+	AccountNumber string
+	FirmID        int64
+}
+
+func (q *Queries) UpsertAccount(ctx context.Context, arg UpsertAccountParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, upsertAccount, arg.Bank, arg.AccountNumber, arg.FirmID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Bank,
+		&i.AccountNumber,
+		&i.StatementBalance,
+		&i.StatementDate,
+		&i.FirmID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const upsertMergeSuggestion = `-- name: UpsertMergeSuggestion :exec
+INSERT INTO merge_suggestions (firm_id, party_id, other_party_id, score, reasons)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (party_id, other_party_id) DO UPDATE SET score = excluded.score, reasons = excluded.reasons
+WHERE merge_suggestions.dismissed_at IS NULL
+`
+
+type UpsertMergeSuggestionParams struct {
+	FirmID       int64
+	PartyID      int64
+	OtherPartyID int64
+	Score        float64
+	Reasons      string
+}
+
+func (q *Queries) UpsertMergeSuggestion(ctx context.Context, arg UpsertMergeSuggestionParams) error {
+	_, err := q.db.ExecContext(ctx, upsertMergeSuggestion,
+		arg.FirmID,
+		arg.PartyID,
+		arg.OtherPartyID,
+		arg.Score,
+		arg.Reasons,
+	)
+	return err
+}
+
+const upsertPartyAlias = `-- name: UpsertPartyAlias :exec
+INSERT INTO party_aliases (party_id, alias, firm_id)
+VALUES (?, ?, ?)
+ON CONFLICT (firm_id, alias) DO UPDATE SET party_id = excluded.party_id
+`
+
+type UpsertPartyAliasParams struct {
+	PartyID int64
+	Alias   string
+	FirmID  int64
+}
+
+func (q *Queries) UpsertPartyAlias(ctx context.Context, arg UpsertPartyAliasParams) error {
+	_, err := q.db.ExecContext(ctx, upsertPartyAlias, arg.PartyID, arg.Alias, arg.FirmID)
+	return err
+}
+
+const upsertReceiptBookTotal = `-- name: UpsertReceiptBookTotal :exec
+INSERT INTO receipt_book_totals (firm_id, start_date, end_date, total, suspense)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (firm_id, start_date, end_date) DO UPDATE SET
+    total = excluded.total,
+    suspense = excluded.suspense,
+    imported_at = CURRENT_TIMESTAMP
+`
+
+type UpsertReceiptBookTotalParams struct {
+	FirmID    int64
+	StartDate time.Time
+	EndDate   time.Time
+	Total     float64
+	Suspense  float64
+}
+
+func (q *Queries) UpsertReceiptBookTotal(ctx context.Context, arg UpsertReceiptBookTotalParams) error {
+	_, err := q.db.ExecContext(ctx, upsertReceiptBookTotal,
+		arg.FirmID,
+		arg.StartDate,
+		arg.EndDate,
+		arg.Total,
+		arg.Suspense,
+	)
+	return err
+}
@@ -0,0 +1,202 @@
+// Package dump writes the whole database, every firm and table, as one JSON
+// document for offsite archival and for loading into analytical tools
+package dump
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// Format names the document so loaders can tell a dump from other JSON
+const Format = "suspense-dump"
+
+// SchemaVersion is the version of the tables a dump holds. Bump it with
+// every migration that adds, renames or drops a table or column.
+const SchemaVersion = 20
+
+// Header is the start of a dump, before its tables
+type Header struct {
+	Format        string    `json:"format"`
+	SchemaVersion int       `json:"schema_version"`
+	ExportedAt    time.Time `json:"exported_at"`
+}
+
+// Table is one table of a dump: its CREATE statement, its columns in order,
+// and its rows as column to value objects
+type Table struct {
+	Name    string           `json:"name"`
+	SQL     string           `json:"sql"`
+	Columns []string         `json:"columns"`
+	Rows    []map[string]any `json:"rows"`
+}
+
+// Write writes every table of db to w as
+//
+//	{"format": ..., "schema_version": ..., "exported_at": ..., "tables": [Table, ...]}
+//
+// Tables come before the tables that reference them, so they can be loaded in
+// order. All tables are read in one transaction, so writes meanwhile are
+// wholly in the dump or not at all. Rows are streamed, not held in memory.
+func Write(ctx context.Context, db *sql.DB, w io.Writer) error {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	tables, err := tableOrder(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	header, err := json.Marshal(Header{Format: Format, SchemaVersion: SchemaVersion, ExportedAt: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+	// Open the header object to append the tables array to it
+	if _, err := fmt.Fprintf(w, "%s,\"tables\":[", header[:len(header)-1]); err != nil {
+		return err
+	}
+	for i, t := range tables {
+		if i > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		if err := writeTable(ctx, tx, w, t); err != nil {
+			return fmt.Errorf("dumping %s: %w", t.name, err)
+		}
+	}
+	_, err = io.WriteString(w, "]}\n")
+	return err
+}
+
+// table is a table of the database and its CREATE statement
+type table struct {
+	name string
+	sql  string
+}
+
+// tableOrder lists the tables of the database, SQLite's own left out, with
+// each table after the tables its foreign keys reference
+func tableOrder(ctx context.Context, tx *sql.Tx) ([]table, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT name, sql FROM sqlite_master
+		WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	var all []table
+	for rows.Next() {
+		var t table
+		if err := rows.Scan(&t.name, &t.sql); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		all = append(all, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	references := make(map[string][]string, len(all))
+	for _, t := range all {
+		rows, err := tx.QueryContext(ctx, `SELECT DISTINCT "table" FROM pragma_foreign_key_list(?)`, t.name)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var parent string
+			if err := rows.Scan(&parent); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			if parent != t.name {
+				references[t.name] = append(references[t.name], parent)
+			}
+		}
+		rows.Close()
+		sort.Strings(references[t.name])
+	}
+
+	var ordered []table
+	byName := make(map[string]table, len(all))
+	for _, t := range all {
+		byName[t.name] = t
+	}
+	// visiting guards against a reference cycle, which would otherwise recurse
+	// forever; the cycle's tables are then ordered by name
+	done, visiting := make(map[string]bool), make(map[string]bool)
+	var visit func(name string)
+	visit = func(name string) {
+		t, ok := byName[name]
+		if !ok || done[name] || visiting[name] {
+			return
+		}
+		visiting[name] = true
+		for _, parent := range references[name] {
+			visit(parent)
+		}
+		done[name] = true
+		ordered = append(ordered, t)
+	}
+	for _, t := range all {
+		visit(t.name)
+	}
+	return ordered, nil
+}
+
+// writeTable writes one table as a Table object, a row at a time
+func writeTable(ctx context.Context, tx *sql.Tx, w io.Writer, t table) error {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`SELECT * FROM "%s"`, t.name))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	name, _ := json.Marshal(t.name)
+	createSQL, _ := json.Marshal(t.sql)
+	cols, _ := json.Marshal(columns)
+	if _, err := fmt.Fprintf(w, `{"name":%s,"sql":%s,"columns":%s,"rows":[`, name, createSQL, cols); err != nil {
+		return err
+	}
+
+	values := make([]any, len(columns))
+	pointers := make([]any, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	row := make(map[string]any, len(columns))
+	for n := 0; rows.Next(); n++ {
+		if err := rows.Scan(pointers...); err != nil {
+			return err
+		}
+		for i, c := range columns {
+			row[c] = values[i]
+		}
+		line, err := json.Marshal(row)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			line = append([]byte{','}, line...)
+		}
+		if _, err := w.Write(line); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "]}")
+	return err
+}
@@ -0,0 +1,62 @@
+package dump
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	db := testDB(t, source...)
+	var doc struct {
+		Header
+		Tables []Table `json:"tables"`
+	}
+	if err := json.Unmarshal(dumpOf(t, db), &doc); err != nil {
+		t.Fatalf("the dump does not parse: %v", err)
+	}
+	if doc.Format != Format || doc.SchemaVersion != SchemaVersion || doc.ExportedAt.IsZero() {
+		t.Errorf("header = %+v", doc.Header)
+	}
+
+	dumped := make(map[string]Table)
+	for _, table := range doc.Tables {
+		dumped[table.Name] = table
+	}
+	for _, name := range tableNames(t, db) {
+		table, ok := dumped[name]
+		if !ok {
+			t.Errorf("table %s is missing from the dump", name)
+			continue
+		}
+		if n := countRows(t, db, `SELECT COUNT(*) FROM "`+name+`"`); len(table.Rows) != n {
+			t.Errorf("%s: dumped %d rows, want %d", name, len(table.Rows), n)
+		}
+		if len(table.Columns) == 0 || table.SQL == "" {
+			t.Errorf("%s: dumped without its columns or CREATE statement", name)
+		}
+	}
+
+	// tables come after the tables they reference
+	seen := make(map[string]bool)
+	for _, table := range doc.Tables {
+		for _, parent := range []string{"firms", "parties", "transactions", "sale_bills"} {
+			if table.Name != parent && strings.Contains(table.SQL, "REFERENCES "+parent+"(") && !seen[parent] {
+				t.Errorf("%s comes before %s, which it references", table.Name, parent)
+			}
+		}
+		seen[table.Name] = true
+	}
+}
+
+func TestWriteCancelled(t *testing.T) {
+	db := testDB(t, source...)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := Write(ctx, db, io.Discard); !errors.Is(err, context.Canceled) {
+		t.Errorf("Write() = %v, want it cancelled", err)
+	}
+}
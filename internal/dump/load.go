@@ -0,0 +1,441 @@
+package dump
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// keys are the columns that tell a row of each table apart from the rows
+// already in the database, after its references are remapped. A row with the
+// same key is a duplicate: the existing row is kept and the dump's references
+// to it lead there. Tables missing here are not loaded, so add each new table.
+var keys = map[string][]string{
+	"accounts":                {"firm_id", "bank", "account_number"},
+	"agents":                  {"firm_id", "name"},
+	"backups":                 {"filename"},
+	"bill_allocations":        {"sale_bill_id", "transaction_id"},
+	"cheques":                 {"transaction_id"},
+	"financial_year_balances": {"financial_year_id", "party_id"},
+	"financial_years":         {"firm_id", "label"},
+	"firms":                   {"name"},
+	"gstr1_invoices":          {"firm_id", "return_period", "invoice_number"},
+	"identifier_conflicts":    {"firm_id", "type", "value", "claimed_party_id"},
+	"identifier_history":      {"firm_id", "type", "value", "party_id", "cause", "detail"},
+	"identifiers":             {"firm_id", "type", "value"},
+	"import_batches":          {"firm_id", "imported_at"},
+	"merge_suggestions":       {"party_id", "other_party_id"},
+	"offsite_backups":         {"object_key", "created_at"},
+	"parser_vocabulary":       {"kind", "value"},
+	"parties":                 {"firm_id", "name"},
+	"party_aliases":           {"firm_id", "alias"},
+	"party_notes":             {"party_id", "note"},
+	"payment_mode_rules":      {"pattern", "mode"},
+	"pos_settlements":         {"firm_id", "credit_date", "amount", "narration"},
+	"receipt_book_totals":     {"firm_id", "start_date", "end_date"},
+	"route_locations":         {"firm_id", "location"},
+	"routes":                  {"firm_id", "name"},
+	"rules":                   {"name"},
+	"sale_bills":              {"firm_id", "bill_number", "bill_date", "party_name", "amount"},
+	"saved_searches":          {"firm_id", "name", "kind"},
+	"search_history":          {"firm_id", "narration"},
+	"sms_acknowledgements":    {"transaction_id"},
+	"statement_emails":        {"party_id", "sent_at"},
+	"statement_links":         {"token"},
+	"sync_log":                {"client_id"},
+	"transaction_agents":      {"transaction_id"},
+	"transaction_merges":      {"superseded_transaction_id"},
+	"transaction_splits":      {"transaction_id"},
+	"transaction_tags":        {"transaction_id", "tag"},
+	"transactions":            {"party_id", "amount", "transaction_date", "payment_mode", "narration"},
+}
+
+// notLoaded are tables left out of a load on purpose, with the reason
+var notLoaded = map[string]string{
+	"party_merges":         "merged parties no longer exist, so their IDs cannot be remapped; their names are kept as aliases",
+	"party_balances":       "rebuilt by triggers from the loaded receipts and bills",
+	"receipt_month_totals": "rebuilt by triggers from the loaded receipts",
+	"pending_imports":      "email attachments waiting for review; import or dismiss them on the server that read them",
+}
+
+// undeclaredReferences are columns holding the ID of a row of another table
+// without a foreign key, since the row may since have been deleted
+var undeclaredReferences = map[string]map[string]string{
+	"identifier_history": {"party_id": "parties", "previous_party_id": "parties"},
+	"transaction_merges": {"superseded_party_id": "parties"},
+	"transaction_splits": {"original_party_id": "parties"},
+}
+
+// loadedLast are loaded after every other table: a closed financial year
+// makes its receipts and sale bills read-only, so they must be in first
+var loadedLast = map[string]bool{"financial_years": true, "financial_year_balances": true}
+
+// Summary counts what a load did to each table
+type Summary struct {
+	Tables  []TableSummary
+	Skipped []string // tables not loaded, with the reason
+}
+
+// TableSummary counts the rows of one table of a dump
+type TableSummary struct {
+	Name     string
+	Added    int // rows new to the database
+	Existing int // duplicates of rows already in it
+	Skipped  int // rows referencing a row that is not in the dump
+}
+
+// column is a column of a table in the database
+type column struct {
+	name    string
+	typ     string
+	notNull bool
+	parent  string // table the column references, if any
+}
+
+// loader loads the tables of a dump in one transaction, remapping the dump's
+// IDs to the IDs of the same rows in the database
+type loader struct {
+	tx  *sql.Tx
+	ids map[string]map[int64]int64 // table to dump ID to database ID
+}
+
+// Load adds the rows of the dump read from r to db, which may be empty or
+// hold data of its own, such as a dump of the laptop loaded into the office
+// database. Rows get new IDs and references to them are remapped; rows that
+// duplicate one already in the database, by the columns in keys, are not
+// added again. The load is one transaction: on an error nothing is added.
+func Load(ctx context.Context, db *sql.DB, r io.Reader) (Summary, error) {
+	var summary Summary
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return summary, err
+	}
+	defer tx.Rollback()
+	l := &loader{tx: tx, ids: make(map[string]map[int64]int64)}
+
+	if err := expectDelim(dec, '{'); err != nil {
+		return summary, err
+	}
+	var header Header
+	var last []Table
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return summary, fmt.Errorf("reading dump: %w", err)
+		}
+		switch token {
+		case "format":
+			err = dec.Decode(&header.Format)
+		case "schema_version":
+			err = dec.Decode(&header.SchemaVersion)
+		case "exported_at":
+			err = dec.Decode(&header.ExportedAt)
+		case "tables":
+			if header.Format != Format {
+				return summary, fmt.Errorf("not a dump of this application")
+			}
+			if header.SchemaVersion > SchemaVersion {
+				return summary, fmt.Errorf("the dump is of schema version %d, newer than this version's %d; upgrade first", header.SchemaVersion, SchemaVersion)
+			}
+			if err := expectDelim(dec, '['); err != nil {
+				return summary, err
+			}
+			for dec.More() {
+				var t Table
+				if err := dec.Decode(&t); err != nil {
+					return summary, fmt.Errorf("reading dump: %w", err)
+				}
+				if loadedLast[t.Name] {
+					last = append(last, t)
+					continue
+				}
+				if err := l.load(ctx, t, &summary); err != nil {
+					return summary, err
+				}
+			}
+			err = expectDelim(dec, ']')
+		default:
+			var skip json.RawMessage
+			err = dec.Decode(&skip)
+		}
+		if err != nil {
+			return summary, fmt.Errorf("reading dump: %w", err)
+		}
+	}
+	if header.Format != Format {
+		return summary, fmt.Errorf("not a dump of this application")
+	}
+	for _, t := range last {
+		if err := l.load(ctx, t, &summary); err != nil {
+			return summary, err
+		}
+	}
+	return summary, tx.Commit()
+}
+
+// expectDelim reads the JSON delimiter want
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("reading dump: %w", err)
+	}
+	if token != want {
+		return fmt.Errorf("not a dump of this application")
+	}
+	return nil
+}
+
+// load adds the rows of one table of a dump and counts them into summary
+func (l *loader) load(ctx context.Context, t Table, summary *Summary) error {
+	if reason, ok := notLoaded[t.Name]; ok {
+		if len(t.Rows) > 0 {
+			summary.Skipped = append(summary.Skipped, fmt.Sprintf("%s: %s", t.Name, reason))
+		}
+		return nil
+	}
+	key, ok := keys[t.Name]
+	if !ok {
+		summary.Skipped = append(summary.Skipped, fmt.Sprintf("%s: not a table this version loads", t.Name))
+		return nil
+	}
+	columns, hasID, err := l.columns(ctx, t.Name)
+	if err != nil {
+		return fmt.Errorf("loading %s: %w", t.Name, err)
+	}
+	if len(columns) == 0 {
+		summary.Skipped = append(summary.Skipped, fmt.Sprintf("%s: no such table in this database", t.Name))
+		return nil
+	}
+
+	ids := make(map[int64]int64, len(t.Rows))
+	l.ids[t.Name] = ids
+	taken := make(map[int64]bool, len(t.Rows))
+	result := TableSummary{Name: t.Name}
+	for _, row := range t.Rows {
+		values, ok, err := l.values(columns, row)
+		if err != nil {
+			return fmt.Errorf("loading %s: %w", t.Name, err)
+		}
+		if !ok {
+			result.Skipped++
+			continue
+		}
+
+		existing, found, err := l.find(ctx, t.Name, key, hasID, values, taken)
+		if err != nil {
+			return fmt.Errorf("loading %s: %w", t.Name, err)
+		}
+		if !found {
+			if existing, err = l.insert(ctx, t.Name, values); err != nil {
+				return fmt.Errorf("loading %s: %w", t.Name, err)
+			}
+			result.Added++
+		} else {
+			result.Existing++
+		}
+		if hasID {
+			taken[existing] = true
+			if id, err := toInt(row["id"]); err == nil {
+				ids[id] = existing
+			}
+		}
+	}
+	summary.Tables = append(summary.Tables, result)
+	return nil
+}
+
+// columns returns the columns of table in the database, its ID column left
+// out, and whether it has one
+func (l *loader) columns(ctx context.Context, table string) ([]column, bool, error) {
+	rows, err := l.tx.QueryContext(ctx, "SELECT name, type, \"notnull\", pk FROM pragma_table_info(?)", table)
+	if err != nil {
+		return nil, false, err
+	}
+	var columns []column
+	hasID := false
+	for rows.Next() {
+		var c column
+		var pk int
+		if err := rows.Scan(&c.name, &c.typ, &c.notNull, &pk); err != nil {
+			rows.Close()
+			return nil, false, err
+		}
+		if c.name == "id" && pk == 1 {
+			hasID = true
+			continue
+		}
+		c.typ = strings.ToUpper(c.typ)
+		c.parent = undeclaredReferences[table][c.name]
+		columns = append(columns, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	rows, err = l.tx.QueryContext(ctx, `SELECT "from", "table" FROM pragma_foreign_key_list(?)`, table)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var from, parent string
+		if err := rows.Scan(&from, &parent); err != nil {
+			return nil, false, err
+		}
+		for i := range columns {
+			if columns[i].name == from {
+				columns[i].parent = parent
+			}
+		}
+	}
+	return columns, hasID, rows.Err()
+}
+
+// values converts a row of the dump to values of columns, with references
+// remapped. A column missing from the row, from a dump of an older schema,
+// takes its default. It reports false for a row referencing a row that is
+// not in the dump through a column that cannot be left empty.
+func (l *loader) values(columns []column, row map[string]any) (map[string]any, bool, error) {
+	values := make(map[string]any, len(columns))
+	for _, c := range columns {
+		v, ok := row[c.name]
+		if !ok {
+			continue
+		}
+		v, err := convert(v, c.typ)
+		if err != nil {
+			return nil, false, fmt.Errorf("column %s: %w", c.name, err)
+		}
+		if c.parent != "" && v != nil {
+			id, err := toInt(v)
+			if err != nil {
+				return nil, false, fmt.Errorf("column %s: %w", c.name, err)
+			}
+			mapped, ok := l.ids[c.parent][id]
+			if !ok {
+				if c.notNull {
+					return nil, false, nil
+				}
+				v = nil
+			} else {
+				v = mapped
+			}
+		}
+		values[c.name] = v
+	}
+	return values, true, nil
+}
+
+// convert turns a JSON value of a dump back into the value of a column of type
+// typ: whole numbers of integer columns to int64, and dates to time.Time so
+// they are stored as the application stores them
+func convert(v any, typ string) (any, error) {
+	switch v := v.(type) {
+	case json.Number:
+		if strings.Contains(typ, "INT") || typ == "BOOLEAN" {
+			return v.Int64()
+		}
+		if i, err := v.Int64(); err == nil && typ != "REAL" {
+			return i, nil
+		}
+		return v.Float64()
+	case string:
+		if typ == "DATE" || typ == "DATETIME" || typ == "TIMESTAMP" {
+			if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+				return t, nil
+			}
+		}
+		return v, nil
+	case nil, bool:
+		return v, nil
+	}
+	return nil, fmt.Errorf("unexpected value %v", v)
+}
+
+// toInt reads an ID of a dump
+func toInt(v any) (int64, error) {
+	switch v := v.(type) {
+	case int64:
+		return v, nil
+	case json.Number:
+		return v.Int64()
+	}
+	return 0, fmt.Errorf("not an ID: %v", v)
+}
+
+// find looks for a row of table with the same key as values, other than the
+// rows taken by earlier rows of the dump, and returns its ID when the table
+// has one. A dump may hold two parties of the same name and place, and each
+// must keep its own.
+func (l *loader) find(ctx context.Context, table string, key []string, hasID bool, values map[string]any, taken map[int64]bool) (int64, bool, error) {
+	var where []string
+	var args []any
+	for _, c := range key {
+		v := values[c]
+		// Times written by SQLite itself, such as CURRENT_TIMESTAMP
+		// defaults, have no zone
+		if t, ok := v.(time.Time); ok {
+			where = append(where, fmt.Sprintf(`("%s" IS ? OR "%s" IS ?)`, c, c))
+			args = append(args, t, t.UTC().Format("2006-01-02 15:04:05"))
+			continue
+		}
+		where = append(where, fmt.Sprintf(`"%s" IS ?`, c))
+		args = append(args, v)
+	}
+
+	if !hasID {
+		query := fmt.Sprintf(`SELECT 1 FROM "%s" WHERE %s LIMIT 1`, table, strings.Join(where, " AND "))
+		var one int
+		err := l.tx.QueryRowContext(ctx, query, args...).Scan(&one)
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, nil
+		}
+		return 0, err == nil, err
+	}
+
+	query := fmt.Sprintf(`SELECT id FROM "%s" WHERE %s ORDER BY id`, table, strings.Join(where, " AND "))
+	rows, err := l.tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, false, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return 0, false, err
+		}
+		if !taken[id] {
+			return id, true, nil
+		}
+	}
+	return 0, false, rows.Err()
+}
+
+// insert adds a row to table and returns its ID
+func (l *loader) insert(ctx context.Context, table string, values map[string]any) (int64, error) {
+	var names, marks []string
+	var args []any
+	for name, v := range values {
+		names = append(names, fmt.Sprintf(`"%s"`, name))
+		marks = append(marks, "?")
+		args = append(args, v)
+	}
+	query := fmt.Sprintf(`INSERT INTO "%s" (%s) VALUES (%s)`, table, strings.Join(names, ", "), strings.Join(marks, ", "))
+	if len(names) == 0 {
+		query = fmt.Sprintf(`INSERT INTO "%s" DEFAULT VALUES`, table)
+	}
+	result, err := l.tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
@@ -0,0 +1,282 @@
+package dump
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+// testDB returns a new database with the schema of internal/db/schema.sql
+// and the rows of setup
+func testDB(t *testing.T, setup ...string) *sql.DB {
+	t.Helper()
+	schema, err := os.ReadFile("../db/schema.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "suspense.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.SetMaxOpenConns(1)
+	for _, query := range append([]string{string(schema)}, setup...) {
+		if _, err := db.Exec(query); err != nil {
+			t.Fatalf("%.60s: %v", query, err)
+		}
+	}
+	return db
+}
+
+// source is a database of two firms, with a party name in both, two parties
+// of the same name in one, a closed year's receipt, a bill of a deleted party
+// and the tag of a deleted receipt
+var source = []string{
+	`INSERT INTO firms (id, name) VALUES (1, 'Durga Dawa Ghar'), (2, 'Durga Pharma')`,
+	`INSERT INTO parties (id, name, location, firm_id) VALUES
+		(1, 'SHARMA MEDICAL', 'KANPUR', 1), (2, 'GUPTA STORES', 'UNNAO', 1),
+		(3, 'SHARMA MEDICAL', 'KANPUR', 2), (4, 'GUPTA STORES', 'LUCKNOW', 1)`,
+	`INSERT INTO transactions (id, party_id, amount, transaction_date, payment_mode, narration, firm_id) VALUES
+		(1, 1, 1000, '2024-05-10 00:00:00 +0000 UTC', 'UPI', 'UPI/1/SHARMA', 1),
+		(2, 2, 500, '2024-06-01 00:00:00 +0000 UTC', 'NEFT', 'NEFT/2/GUPTA', 1),
+		(3, 3, 700, '2025-05-01 00:00:00 +0000 UTC', 'UPI', 'UPI/3/SHARMA', 2),
+		(4, 1, 2000, '2023-06-01 00:00:00 +0000 UTC', 'CHEQUE', 'CHQ 4', 1),
+		(5, 4, 300, '2024-06-01 00:00:00 +0000 UTC', 'NEFT', 'NEFT/5/GUPTA', 1)`,
+	`INSERT INTO sale_bills (id, bill_number, bill_date, party_name, amount, party_id, firm_id) VALUES
+		(1, 'A-1', '2024-05-01 00:00:00 +0000 UTC', 'SHARMA MEDICAL', 1000, 1, 1),
+		(2, 'A-2', '2024-05-02 00:00:00 +0000 UTC', 'GONE MEDICAL', 250, 99, 1)`,
+	`INSERT INTO bill_allocations (sale_bill_id, transaction_id, amount) VALUES (1, 1, 1000)`,
+	`INSERT INTO identifiers (party_id, type, value, firm_id) VALUES (1, 'upi_vpa', 'sharma@okicici', 1), (3, 'upi_vpa', 'sharma@okicici', 2)`,
+	`INSERT INTO transaction_tags (transaction_id, tag) VALUES (1, 'checked'), (999, 'orphan')`,
+	`INSERT INTO financial_years (firm_id, label, start_date, end_date) VALUES (1, '2023-24', '2023-04-01 00:00:00 +0000 UTC', '2024-03-31 00:00:00 +0000 UTC')`,
+}
+
+func dumpOf(t *testing.T, db *sql.DB) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	if err := Write(context.Background(), db, &b); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+// receipts lists the receipts of db as firm, party, location and amount, by
+// following their references
+func receipts(t *testing.T, db *sql.DB) []string {
+	t.Helper()
+	rows, err := db.Query(`SELECT f.name || ' / ' || p.name || ' ' || p.location || ' / ' || t.amount
+		FROM transactions t JOIN parties p ON p.id = t.party_id JOIN firms f ON f.id = t.firm_id AND f.id = p.firm_id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var list []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			t.Fatal(err)
+		}
+		list = append(list, s)
+	}
+	sort.Strings(list)
+	return list
+}
+
+func countRows(t *testing.T, db *sql.DB, query string) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow(query).Scan(&n); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	return n
+}
+
+func tableSummary(s Summary, name string) TableSummary {
+	for _, t := range s.Tables {
+		if t.Name == name {
+			return t
+		}
+	}
+	return TableSummary{}
+}
+
+func TestLoadIntoEmptyDatabase(t *testing.T) {
+	from := testDB(t, source...)
+	to := testDB(t)
+	summary, err := Load(context.Background(), to, bytes.NewReader(dumpOf(t, from)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, table := range []string{"firms", "parties", "transactions", "sale_bills", "bill_allocations", "identifiers", "financial_years"} {
+		query := "SELECT COUNT(*) FROM " + table
+		if got, want := countRows(t, to, query), countRows(t, from, query); got != want {
+			t.Errorf("%s: loaded %d rows, want %d", table, got, want)
+		}
+	}
+	if got, want := strings.Join(receipts(t, to), "\n"), strings.Join(receipts(t, from), "\n"); got != want {
+		t.Errorf("receipts:\n%s\nwant\n%s", got, want)
+	}
+	// the tag of the deleted receipt cannot be loaded, and the bill of the
+	// deleted party loses its party
+	if s := tableSummary(summary, "transaction_tags"); s.Added != 1 || s.Skipped != 1 {
+		t.Errorf("transaction_tags = %+v, want 1 added and 1 skipped", s)
+	}
+	if n := countRows(t, to, "SELECT COUNT(*) FROM sale_bills WHERE bill_number = 'A-2' AND party_id IS NULL"); n != 1 {
+		t.Error("the bill of a party missing from the dump kept a reference")
+	}
+	// the closed year is loaded after its receipt, which it makes read-only
+	if n := countRows(t, to, "SELECT COUNT(*) FROM transactions WHERE transaction_date LIKE '2023-06-01%'"); n != 1 {
+		t.Error("the closed year's receipt was not loaded")
+	}
+	if b := countRows(t, to, "SELECT CAST(SUM(received) AS INTEGER) FROM party_balances"); b != 4500 {
+		t.Errorf("party balances received %d, want 4500", b)
+	}
+}
+
+func TestLoadIntoOverlappingDatabase(t *testing.T) {
+	from := testDB(t, source...)
+	// the firms have each other's IDs, and one party and its receipt are
+	// already here
+	to := testDB(t,
+		`INSERT INTO firms (id, name) VALUES (1, 'Durga Pharma'), (2, 'Durga Dawa Ghar')`,
+		`INSERT INTO parties (id, name, location, firm_id) VALUES (1, 'GUPTA STORES', 'UNNAO', 2), (2, 'NEW PARTY', 'KANPUR', 1)`,
+		`INSERT INTO transactions (party_id, amount, transaction_date, payment_mode, narration, firm_id) VALUES
+			(1, 500, '2024-06-01 00:00:00 +0000 UTC', 'NEFT', 'NEFT/2/GUPTA', 2)`,
+	)
+	summary, err := Load(context.Background(), to, bytes.NewReader(dumpOf(t, from)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s := tableSummary(summary, "firms"); s.Added != 0 || s.Existing != 2 {
+		t.Errorf("firms = %+v, want both existing", s)
+	}
+	// the second GUPTA STORES of the dump is a party of its own
+	if s := tableSummary(summary, "parties"); s.Added != 3 || s.Existing != 1 {
+		t.Errorf("parties = %+v, want 3 added and 1 existing", s)
+	}
+	if s := tableSummary(summary, "transactions"); s.Added != 4 || s.Existing != 1 {
+		t.Errorf("transactions = %+v, want 4 added and 1 existing", s)
+	}
+	want := []string{
+		"Durga Dawa Ghar / GUPTA STORES LUCKNOW / 300.0",
+		"Durga Dawa Ghar / GUPTA STORES UNNAO / 500.0",
+		"Durga Dawa Ghar / SHARMA MEDICAL KANPUR / 1000.0",
+		"Durga Dawa Ghar / SHARMA MEDICAL KANPUR / 2000.0",
+		"Durga Pharma / SHARMA MEDICAL KANPUR / 700.0",
+	}
+	if got := receipts(t, to); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("receipts:\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if n := countRows(t, to, `SELECT COUNT(*) FROM bill_allocations a
+		JOIN sale_bills b ON b.id = a.sale_bill_id JOIN transactions t ON t.id = a.transaction_id
+		WHERE b.party_id = t.party_id AND b.firm_id = t.firm_id AND t.amount = 1000`); n != 1 {
+		t.Error("the allocation does not join its bill to its receipt")
+	}
+	if n := countRows(t, to, `SELECT COUNT(*) FROM identifiers i JOIN parties p ON p.id = i.party_id
+		WHERE p.name = 'SHARMA MEDICAL' AND p.firm_id = i.firm_id`); n != 2 {
+		t.Errorf("%d identifiers lead to their party, want 2", n)
+	}
+	if n := countRows(t, to, `SELECT COUNT(*) FROM financial_years fy JOIN firms f ON f.id = fy.firm_id
+		WHERE f.name = 'Durga Dawa Ghar'`); n != 1 {
+		t.Error("the closed year is not under its firm")
+	}
+
+	// loading the same dump again adds nothing
+	again, err := Load(context.Background(), to, bytes.NewReader(dumpOf(t, from)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range again.Tables {
+		if s.Added != 0 {
+			t.Errorf("loading again added %d rows to %s", s.Added, s.Name)
+		}
+	}
+}
+
+func TestLoadClosedYearLast(t *testing.T) {
+	// a dump with the closed year before its receipts, which the closed year
+	// would turn away if it were loaded first
+	var doc struct {
+		Header
+		Tables []Table `json:"tables"`
+	}
+	if err := json.Unmarshal(dumpOf(t, testDB(t, source...)), &doc); err != nil {
+		t.Fatal(err)
+	}
+	var tables []Table
+	for _, table := range doc.Tables {
+		switch table.Name {
+		case "financial_years":
+		case "transactions":
+			i := slices.IndexFunc(doc.Tables, func(t Table) bool { return t.Name == "financial_years" })
+			tables = append(tables, doc.Tables[i], table)
+		default:
+			tables = append(tables, table)
+		}
+	}
+	doc.Tables = tables
+	b, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	to := testDB(t)
+	if _, err := Load(context.Background(), to, bytes.NewReader(b)); err != nil {
+		t.Fatal(err)
+	}
+	if n := countRows(t, to, "SELECT COUNT(*) FROM transactions WHERE transaction_date LIKE '2023-06-01%'"); n != 1 {
+		t.Error("the closed year's receipt was not loaded")
+	}
+	if n := countRows(t, to, "SELECT COUNT(*) FROM financial_years"); n != 1 {
+		t.Error("the closed year was not loaded")
+	}
+}
+
+func TestLoadRejectsOtherJSON(t *testing.T) {
+	to := testDB(t)
+	for _, doc := range []string{`{"tables": []}`, `[]`, `{"format": "suspense-dump", "schema_version": 999, "tables": []}`} {
+		if _, err := Load(context.Background(), to, strings.NewReader(doc)); err == nil {
+			t.Errorf("Load(%s) succeeded", doc)
+		}
+	}
+}
+
+// tableNames lists the tables of db, SQLite's own left out
+func tableNames(t *testing.T, db *sql.DB) []string {
+	t.Helper()
+	rows, err := db.Query(`SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestKeysCoverSchema(t *testing.T) {
+	for _, name := range tableNames(t, testDB(t)) {
+		_, loaded := keys[name]
+		_, skipped := notLoaded[name]
+		if loaded == skipped {
+			t.Errorf("table %s must be in exactly one of keys and notLoaded", name)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+// Package dupes finds parties that are likely the same customer entered twice:
+// parties with nearly the same name in the same place, and parties whose
+// receipts carry each other's identifiers
+package dupes
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// NameThreshold is the name similarity, from 0 to 1, at which two parties in
+// the same place are suggested as duplicates
+const NameThreshold = 0.85
+
+// maxTokenParties skips name words shared by so many parties that they say
+// nothing about a pair, such as a common surname
+const maxTokenParties = 50
+
+// sharedIdentifierScore is the score of a pair that shares an identifier but
+// not a similar name
+const sharedIdentifierScore = 0.9
+
+// Party is a party to compare
+type Party struct {
+	ID       int64
+	Name     string
+	Location string
+}
+
+// Link is an identifier of one party found in the receipts of another
+type Link struct {
+	PartyID      int64
+	OtherPartyID int64
+	Identifier   string // e.g. "upi_vpa 9450852076@ybl"
+}
+
+// Suggestion is a pair of parties that may be duplicates. PartyID is the
+// lower ID. Score is from 0 to 1.
+type Suggestion struct {
+	PartyID      int64
+	OtherPartyID int64
+	Score        float64
+	Reasons      []string
+}
+
+// genericWords are words of shop names that don't tell shops apart
+var genericWords = map[string]bool{
+	"M": true, "S": true, "MS": true, "THE": true, "AND": true,
+	"MED": true, "MEDICAL": true, "MEDICALS": true, "MEDICOS": true, "MEDICOSE": true, "MEDICINE": true,
+	"STORE": true, "STORES": true, "HALL": true, "CENTRE": true, "CENTER": true,
+	"PHARMA": true, "PHARMACY": true, "CHEMIST": true, "CHEMISTS": true, "DRUG": true, "DRUGS": true,
+	"AGENCY": true, "AGENCIES": true, "ENTERPRISES": true, "TRADERS": true,
+}
+
+// Words returns the words of a party name that tell it apart, uppercased,
+// without punctuation and generic shop words. A name of only generic words
+// keeps them all.
+func Words(name string) []string {
+	fields := strings.FieldsFunc(strings.ToUpper(name), func(r rune) bool {
+		return !(r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	})
+	var words []string
+	for _, f := range fields {
+		if !genericWords[f] {
+			words = append(words, f)
+		}
+	}
+	if len(words) == 0 {
+		return fields
+	}
+	return words
+}
+
+// NameSimilarity compares the distinctive words of two party names by edit
+// distance, from 0 (nothing alike) to 1 (the same), so "SHARMA MEDICAL STORE"
+// and "SHARMA MEDICALS" are the same and a misspelt word is close
+func NameSimilarity(a, b string) float64 {
+	x, y := strings.Join(Words(a), " "), strings.Join(Words(b), " ")
+	if x == "" && y == "" {
+		return 1
+	}
+	longest := max(len(x), len(y))
+	return 1 - float64(levenshtein(x, y))/float64(longest)
+}
+
+// levenshtein counts the single byte edits turning a into b
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// sameLocation reports whether two locations may be the same place; a party
+// without one may be anywhere
+func sameLocation(a, b string) bool {
+	a, b = strings.ToUpper(strings.TrimSpace(a)), strings.ToUpper(strings.TrimSpace(b))
+	return a == "" || b == "" || a == b
+}
+
+// Find suggests the pairs of parties that are likely duplicates, best first:
+// names at least NameThreshold alike in the same place, and parties linked by
+// a shared identifier. Only parties with a distinctive name word in common
+// are compared by name.
+func Find(parties []Party, links []Link) []Suggestion {
+	type pair struct{ a, b int64 }
+	key := func(a, b int64) pair {
+		if a > b {
+			a, b = b, a
+		}
+		return pair{a, b}
+	}
+	found := make(map[pair]*Suggestion)
+	suggest := func(a, b int64, score float64, reason string) {
+		k := key(a, b)
+		s, ok := found[k]
+		if !ok {
+			s = &Suggestion{PartyID: k.a, OtherPartyID: k.b}
+			found[k] = s
+		}
+		s.Score = max(s.Score, score)
+		if !slices.Contains(s.Reasons, reason) {
+			s.Reasons = append(s.Reasons, reason)
+		}
+	}
+
+	byWord := make(map[string][]int)
+	for i, p := range parties {
+		for _, w := range Words(p.Name) {
+			if n := len(byWord[w]); n == 0 || byWord[w][n-1] != i {
+				byWord[w] = append(byWord[w], i)
+			}
+		}
+	}
+	compared := make(map[pair]bool)
+	for _, idx := range byWord {
+		if len(idx) > maxTokenParties {
+			continue
+		}
+		for x := 0; x < len(idx); x++ {
+			for y := x + 1; y < len(idx); y++ {
+				p, q := parties[idx[x]], parties[idx[y]]
+				k := key(p.ID, q.ID)
+				if compared[k] {
+					continue
+				}
+				compared[k] = true
+				if !sameLocation(p.Location, q.Location) {
+					continue
+				}
+				if sim := NameSimilarity(p.Name, q.Name); sim >= NameThreshold {
+					suggest(p.ID, q.ID, sim, fmt.Sprintf("similar names (%.0f%%)", sim*100))
+				}
+			}
+		}
+	}
+
+	for _, l := range links {
+		if l.PartyID == l.OtherPartyID {
+			continue
+		}
+		suggest(l.PartyID, l.OtherPartyID, sharedIdentifierScore, "shares "+l.Identifier)
+	}
+
+	suggestions := make([]Suggestion, 0, len(found))
+	for _, s := range found {
+		// Each further reason makes the pair likelier
+		if len(s.Reasons) > 1 {
+			s.Score = min(1, s.Score+0.05)
+		}
+		suggestions = append(suggestions, *s)
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		if suggestions[i].PartyID != suggestions[j].PartyID {
+			return suggestions[i].PartyID < suggestions[j].PartyID
+		}
+		return suggestions[i].OtherPartyID < suggestions[j].OtherPartyID
+	})
+	return suggestions
+}
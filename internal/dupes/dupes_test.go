@@ -0,0 +1,77 @@
+package dupes
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected []string
+	}{
+		{"M/S SHARMA MEDICAL STORE", []string{"SHARMA"}},
+		{"Balaji Medicos & Surgicals", []string{"BALAJI", "SURGICALS"}},
+		{"MEDICAL STORE", []string{"MEDICAL", "STORE"}},
+	}
+
+	for _, tt := range tests {
+		if got := Words(tt.name); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Words(%q) = %q, expected %q", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestNameSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		min  float64
+		max  float64
+	}{
+		{"SHARMA MEDICAL STORE", "Sharma Medicals", 1, 1},
+		{"MAA DURGA MEDICAL STORE", "MAA DURGA MED STORE", 1, 1},
+		{"GUPTA PHARMA", "GUPTAA PHARMA", 0.8, 0.9},
+		{"SHARMA MEDICAL STORE", "VERMA MEDICAL STORE", 0, 0.7},
+	}
+
+	for _, tt := range tests {
+		got := NameSimilarity(tt.a, tt.b)
+		if got < tt.min || got > tt.max {
+			t.Errorf("NameSimilarity(%q, %q) = %.2f, expected %.2f to %.2f", tt.a, tt.b, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	parties := []Party{
+		{ID: 1, Name: "SHARMA MEDICAL STORE", Location: "TIRWA"},
+		{ID: 2, Name: "SHARMA MEDICALS", Location: ""},
+		{ID: 3, Name: "SHARMA MEDICAL STORE", Location: "KANNAUJ"},
+		{ID: 4, Name: "BALAJI MEDICOS"},
+		{ID: 5, Name: "SHRI BALAJI AGENCY"},
+	}
+	links := []Link{
+		{PartyID: 5, OtherPartyID: 4, Identifier: "phone 9450852076"},
+		{PartyID: 2, OtherPartyID: 1, Identifier: "upi_vpa SHARMA@YBL"},
+		{PartyID: 4, OtherPartyID: 4, Identifier: "phone 9450852076"},
+	}
+
+	got := Find(parties, links)
+	type pair struct{ a, b int64 }
+	var pairs []pair
+	for _, s := range got {
+		pairs = append(pairs, pair{s.PartyID, s.OtherPartyID})
+	}
+	// 1 and 3 are the same name in different places; 2 has no place so may be
+	// either. 1 and 2 also share an identifier, so come first.
+	expected := []pair{{1, 2}, {2, 3}, {4, 5}}
+	if !reflect.DeepEqual(pairs, expected) {
+		t.Fatalf("Find() pairs = %v, expected %v", pairs, expected)
+	}
+	if len(got[0].Reasons) != 2 || got[0].Score != 1 {
+		t.Errorf("Find()[0] = %+v, expected two reasons scoring 1", got[0])
+	}
+	if got[2].Score != sharedIdentifierScore {
+		t.Errorf("Find()[2].Score = %.2f, expected %.2f", got[2].Score, sharedIdentifierScore)
+	}
+}
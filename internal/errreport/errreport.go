@@ -0,0 +1,280 @@
+// Package errreport sends panics and server errors to a Sentry-compatible
+// error tracker (Sentry, GlitchTip, ...) given its DSN, with the request they
+// happened in
+package errreport
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxMessage limits how much of an error response is sent as the message
+const maxMessage = 1024
+
+// Reporter sends events to the project of a DSN
+type Reporter struct {
+	endpoint string
+	auth     string
+	server   string
+	client   *http.Client
+	pending  sync.WaitGroup
+}
+
+// New returns a reporter for a DSN of the form
+// https://<public key>@<host>/<project id>
+func New(dsn string) (*Reporter, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("parsing DSN: %w", err)
+	}
+	key := u.User.Username()
+	slash := strings.LastIndex(u.Path, "/")
+	if key == "" || slash < 0 || u.Path[slash+1:] == "" {
+		return nil, fmt.Errorf("DSN %q has no key or project ID", u.Redacted())
+	}
+	path, project := u.Path[:slash], u.Path[slash+1:]
+	host, _ := os.Hostname()
+	return &Reporter{
+		endpoint: fmt.Sprintf("%s://%s%s/api/%s/envelope/", u.Scheme, u.Host, path, project),
+		auth:     fmt.Sprintf("Sentry sentry_version=7, sentry_client=suspense/1.0, sentry_key=%s", key),
+		server:   host,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// event is the part of the Sentry event payload that is sent
+type event struct {
+	EventID    string            `json:"event_id"`
+	Timestamp  string            `json:"timestamp"`
+	Platform   string            `json:"platform"`
+	Level      string            `json:"level"`
+	ServerName string            `json:"server_name,omitempty"`
+	Message    string            `json:"message,omitempty"`
+	Exception  []exception       `json:"exception,omitempty"`
+	Request    *request          `json:"request,omitempty"`
+	Tags       map[string]string `json:"tags,omitempty"`
+}
+
+type exception struct {
+	Type       string      `json:"type"`
+	Value      string      `json:"value"`
+	Stacktrace *stacktrace `json:"stacktrace,omitempty"`
+}
+
+type stacktrace struct {
+	Frames []frame `json:"frames"`
+}
+
+type frame struct {
+	Function string `json:"function"`
+	Filename string `json:"filename"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+type request struct {
+	URL         string            `json:"url"`
+	Method      string            `json:"method"`
+	QueryString string            `json:"query_string,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+}
+
+// omittedHeaders carry credentials and are not sent
+var omittedHeaders = map[string]bool{"Cookie": true, "Authorization": true}
+
+// newEvent starts an event about r
+func (rep *Reporter) newEvent(r *http.Request, level string) *event {
+	id := make([]byte, 16)
+	rand.Read(id)
+	ev := &event{
+		EventID:    hex.EncodeToString(id),
+		Timestamp:  time.Now().UTC().Format(time.RFC3339Nano),
+		Platform:   "go",
+		Level:      level,
+		ServerName: rep.server,
+	}
+	if r != nil {
+		headers := make(map[string]string)
+		for k, v := range r.Header {
+			if !omittedHeaders[k] {
+				headers[k] = strings.Join(v, ", ")
+			}
+		}
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		ev.Request = &request{
+			URL:         scheme + "://" + r.Host + r.URL.Path,
+			Method:      r.Method,
+			QueryString: r.URL.RawQuery,
+			Headers:     headers,
+		}
+		ev.Tags = map[string]string{"route": r.Method + " " + r.URL.Path}
+		if sc := trace.SpanContextFromContext(r.Context()); sc.HasTraceID() {
+			ev.Tags["trace_id"] = sc.TraceID().String()
+		}
+	}
+	return ev
+}
+
+// CapturePanic reports a recovered panic value with the stack it was raised
+// from
+func (rep *Reporter) CapturePanic(r *http.Request, value interface{}) {
+	ev := rep.newEvent(r, "fatal")
+	ev.Exception = []exception{{
+		Type:       "panic",
+		Value:      fmt.Sprint(value),
+		Stacktrace: callers(4),
+	}}
+	rep.send(ev)
+}
+
+// CaptureMessage reports an error message, such as the text of a 500 response
+func (rep *Reporter) CaptureMessage(r *http.Request, message string) {
+	ev := rep.newEvent(r, "error")
+	ev.Message = message
+	rep.send(ev)
+}
+
+// callers returns the stack above skip frames, outermost first as Sentry
+// expects
+func callers(skip int) *stacktrace {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	var st stacktrace
+	for {
+		f, more := frames.Next()
+		st.Frames = append([]frame{{
+			Function: f.Function,
+			Filename: f.File,
+			Lineno:   f.Line,
+			InApp:    strings.HasPrefix(f.Function, "suspense.durgadawaghar.com/"),
+		}}, st.Frames...)
+		if !more {
+			break
+		}
+	}
+	return &st
+}
+
+// send posts an event in the background; failures are only logged
+func (rep *Reporter) send(ev *event) {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		log.Printf("Error report: encoding event: %v", err)
+		return
+	}
+	var body bytes.Buffer
+	fmt.Fprintf(&body, `{"event_id":%q,"sent_at":%q}`+"\n", ev.EventID, ev.Timestamp)
+	fmt.Fprintf(&body, `{"type":"event","length":%d}`+"\n", len(payload))
+	body.Write(payload)
+	body.WriteString("\n")
+
+	rep.pending.Add(1)
+	go func() {
+		defer rep.pending.Done()
+		req, err := http.NewRequest(http.MethodPost, rep.endpoint, &body)
+		if err != nil {
+			log.Printf("Error report: %v", err)
+			return
+		}
+		req.Header.Set("Content-Type", "application/x-sentry-envelope")
+		req.Header.Set("X-Sentry-Auth", rep.auth)
+		resp, err := rep.client.Do(req)
+		if err != nil {
+			log.Printf("Error report: sending event: %v", err)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			log.Printf("Error report: sending event: %s", resp.Status)
+		}
+	}()
+}
+
+// Flush waits up to timeout for events being sent
+func (rep *Reporter) Flush(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		rep.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+}
+
+// Middleware reports panics in next, answering them with a 500, and
+// responses with a 5xx status, with the start of their body as the message
+func (rep *Reporter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &responseWriter{ResponseWriter: w}
+		defer func() {
+			if v := recover(); v != nil {
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+				log.Printf("panic serving %s %s: %v", r.Method, r.URL.Path, v)
+				rep.CapturePanic(r, v)
+				if rw.status == 0 {
+					http.Error(w, "Internal server error", http.StatusInternalServerError)
+				}
+				return
+			}
+			if rw.status >= 500 {
+				msg := strings.TrimSpace(rw.body.String())
+				if msg == "" {
+					msg = http.StatusText(rw.status)
+				}
+				rep.CaptureMessage(r, fmt.Sprintf("%d %s %s: %s", rw.status, r.Method, r.URL.Path, msg))
+			}
+		}()
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// responseWriter records the status of a response and the start of an error
+// response's body
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.status >= 500 && w.body.Len() < maxMessage {
+		w.body.Write(b[:min(len(b), maxMessage-w.body.Len())])
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,99 @@
+package errreport
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	rep, err := New("https://abc123@errors.example.com/sentry/42")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if rep.endpoint != "https://errors.example.com/sentry/api/42/envelope/" {
+		t.Errorf("endpoint = %q", rep.endpoint)
+	}
+	if !strings.Contains(rep.auth, "sentry_key=abc123") {
+		t.Errorf("auth = %q", rep.auth)
+	}
+
+	for _, dsn := range []string{"https://errors.example.com/42", "https://abc123@errors.example.com/", "::"} {
+		if _, err := New(dsn); err == nil {
+			t.Errorf("New(%q) accepted an invalid DSN", dsn)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	events := make(chan event, 4)
+	tracker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Envelope: header line, item header line, event payload
+		lines := bufio.NewScanner(r.Body)
+		for i := 0; i < 3 && lines.Scan(); i++ {
+			if i == 2 {
+				var ev event
+				if err := json.Unmarshal(lines.Bytes(), &ev); err != nil {
+					t.Errorf("decoding event: %v", err)
+				}
+				events <- ev
+			}
+		}
+		io.Copy(io.Discard, r.Body)
+	}))
+	defer tracker.Close()
+
+	rep, err := New(strings.Replace(tracker.URL, "http://", "http://key@", 1) + "/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("fine")) })
+	mux.HandleFunc("/fail", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Error loading parties: disk I/O error", http.StatusInternalServerError)
+	})
+	mux.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) { panic("nil party") })
+	handler := rep.Middleware(mux)
+
+	for _, path := range []string{"/ok", "/fail?q=1", "/panic"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Cookie", "firm=1")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if path == "/panic" && rec.Code != http.StatusInternalServerError {
+			t.Errorf("panic answered %d, want 500", rec.Code)
+		}
+	}
+	rep.Flush(5 * time.Second)
+	close(events)
+
+	var got []event
+	for ev := range events {
+		got = append(got, ev)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d events, want 2 (the error and the panic)", len(got))
+	}
+	for _, ev := range got {
+		if ev.Request == nil || ev.Request.Headers["Cookie"] != "" {
+			t.Errorf("event request = %+v, want request without cookies", ev.Request)
+		}
+		switch ev.Level {
+		case "error":
+			if !strings.Contains(ev.Message, "disk I/O error") || ev.Request.QueryString != "q=1" {
+				t.Errorf("error event = %+v", ev)
+			}
+		case "fatal":
+			if len(ev.Exception) != 1 || ev.Exception[0].Value != "nil party" || len(ev.Exception[0].Stacktrace.Frames) == 0 {
+				t.Errorf("panic event = %+v", ev)
+			}
+		default:
+			t.Errorf("unexpected event level %q", ev.Level)
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package extractor
 
 import (
+	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 )
 
@@ -22,6 +24,8 @@ const (
 	TypeFromAccount   IdentifierType = "from_account"    // Masked account from From: field (e.g., XXXX8723)
 	TypeFromName      IdentifierType = "from_name"       // Sender name from From: field
 	TypeActcdep       IdentifierType = "actcdep"         // ACTCDEP from TRTR transactions
+	TypeNACHMandate   IdentifierType = "nach_mandate"    // Mandate reference (UMRN) from NACH/ACH/ECS debits
+	TypeGSTIN         IdentifierType = "gstin"           // GST registration of the payer, from its party or a narration
 )
 
 // Identifier represents an extracted identifier from a narration
@@ -61,6 +65,9 @@ var (
 	// Account Number: 9-18 digits in NEFT/RTGS refs (pattern like -ACCOUNTNUMBER- or -ACCOUNTNUMBER at end)
 	accountPattern = regexp.MustCompile(`-(\d{9,18})(?:-|$)`)
 
+	// A whole value that is an account number, as entered by hand
+	accountNumberPattern = regexp.MustCompile(`^\d{9,18}$`)
+
 	// Additional account pattern for standalone account numbers in specific contexts
 	accountPatternAlt = regexp.MustCompile(`(?:A/C|ACCT?|Account)\s*(?:No\.?|#)?\s*(\d{9,18})`)
 
@@ -138,6 +145,18 @@ var (
 	// TRTR/ACTCDEP pattern: TRTR/ACTCDEP/<ref>/<code>
 	// Example: "TRTR/ACTCDEP/512916237776/FIK"
 	trtrActcdepPattern = regexp.MustCompile(`TRTR/ACTCDEP/`)
+
+	// NACH/ACH/ECS pattern: the mandate debits some customers pay by
+	// Example: "ACH/CR/HDFC7021807230034209/ABC PHARMA LTD"
+	nachPattern = regexp.MustCompile(`\b(?:NACH|ACH|ECS)[/-]`)
+
+	// NACH mandate reference (UMRN): 4 letter bank code + 16 digits
+	// Example: "NACH-CR-ICIC7021807230012345-SUN PHARMA" -> "ICIC7021807230012345"
+	nachMandatePattern = regexp.MustCompile(`\b([A-Z]{4}\d{16})\b`)
+
+	// GSTIN: 2 digit state code + PAN + entity number + Z + check character
+	// Example: "NEFT-SBIN0001234-GUPTA PHARMA GST 09AAACG1234K1Z5" -> "09AAACG1234K1Z5"
+	gstinPattern = regexp.MustCompile(`\b(\d{2}[A-Z]{5}\d{4}[A-Z][1-9A-Z]Z[0-9A-Z])\b`)
 )
 
 // bankNormalization maps truncated bank names to full names
@@ -631,6 +650,21 @@ func Extract(narration string) []Identifier {
 		}
 	}
 
+	// Extract NACH mandate reference
+	if nachPattern.MatchString(upperNarration) {
+		if mandateMatches := nachMandatePattern.FindStringSubmatch(upperNarration); len(mandateMatches) > 1 {
+			value := mandateMatches[1]
+			key := string(TypeNACHMandate) + ":" + value
+			if !seen[key] {
+				seen[key] = true
+				identifiers = append(identifiers, Identifier{
+					Type:  TypeNACHMandate,
+					Value: value,
+				})
+			}
+		}
+	}
+
 	// Extract From: field data (masked account and sender name)
 	if fromMatches := fromPattern.FindStringSubmatch(upperNarration); len(fromMatches) > 2 {
 		// Extract masked account number (e.g., XXXX8723)
@@ -660,6 +694,18 @@ func Extract(narration string) []Identifier {
 		}
 	}
 
+	// Extract GSTINs quoted by payers
+	for _, match := range gstinPattern.FindAllStringSubmatch(upperNarration, -1) {
+		key := string(TypeGSTIN) + ":" + match[1]
+		if !seen[key] {
+			seen[key] = true
+			identifiers = append(identifiers, Identifier{
+				Type:  TypeGSTIN,
+				Value: match[1],
+			})
+		}
+	}
+
 	return identifiers
 }
 
@@ -684,3 +730,151 @@ func ExtractByType(narration string, idType IdentifierType) []string {
 	}
 	return values
 }
+
+// typeAliases maps the names an identifier type is written as in seed files to
+// the type, in addition to the type names themselves
+var typeAliases = map[string]IdentifierType{
+	"UPI":     TypeUPIVPA,
+	"VPA":     TypeUPIVPA,
+	"UPI ID":  TypeUPIVPA,
+	"MOBILE":  TypePhone,
+	"PHONE":   TypePhone,
+	"ACCOUNT": TypeAccountNumber,
+	"A/C":     TypeAccountNumber,
+	"UMRN":    TypeNACHMandate,
+	"MANDATE": TypeNACHMandate,
+	"GST":     TypeGSTIN,
+	"GST NO":  TypeGSTIN,
+}
+
+// allTypes lists every identifier type
+var allTypes = []IdentifierType{
+	TypeUPIVPA, TypePhone, TypeAccountNumber, TypeIFSC, TypeIMPSName, TypeBankName, TypeNEFTName,
+	TypeCashBankCode, TypeCashLocation, TypeCashAgentCode, TypeFromAccount, TypeFromName, TypeActcdep,
+	TypeNACHMandate, TypeGSTIN,
+}
+
+// Unique reports whether a value of the type belongs to a single payer, so
+// finding it in another party's entry is a conflict. Names, banks, branches
+// and masked account digits are shared by unrelated payers.
+func (t IdentifierType) Unique() bool {
+	switch t {
+	case TypeUPIVPA, TypePhone, TypeAccountNumber, TypeCashAgentCode, TypeNACHMandate, TypeGSTIN:
+		return true
+	}
+	return false
+}
+
+// ParseType reads an identifier type by name (e.g. "upi_vpa") or a common
+// alias (e.g. "UPI", "Mobile"), ignoring case
+func ParseType(s string) (IdentifierType, bool) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if t, ok := typeAliases[s]; ok {
+		return t, true
+	}
+	for _, t := range allTypes {
+		if s == strings.ToUpper(string(t)) {
+			return t, true
+		}
+	}
+	return "", false
+}
+
+// Normalize puts a hand-entered identifier value into the form Extract finds
+// it in narrations, so it matches later entries: uppercase, and phone numbers
+// as 10 digits without the country code
+func Normalize(t IdentifierType, value string) (string, error) {
+	value = strings.ToUpper(strings.Join(strings.Fields(value), " "))
+	switch t {
+	case TypePhone:
+		digits := strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return r
+			}
+			return -1
+		}, value)
+		if len(digits) == 12 && strings.HasPrefix(digits, "91") {
+			digits = digits[2:]
+		} else if len(digits) == 11 && strings.HasPrefix(digits, "0") {
+			digits = digits[1:]
+		}
+		if !phonePattern.MatchString(digits) || len(digits) != 10 {
+			return "", fmt.Errorf("%q is not a 10-digit mobile number", value)
+		}
+		return digits, nil
+	case TypeAccountNumber:
+		value = strings.ReplaceAll(value, " ", "")
+		if !accountNumberPattern.MatchString(value) {
+			return "", fmt.Errorf("%q is not a 9 to 18 digit account number", value)
+		}
+	case TypeIFSC:
+		if len(value) != 11 || !ifscPattern.MatchString(value) {
+			return "", fmt.Errorf("%q is not an IFSC code", value)
+		}
+	case TypeGSTIN:
+		value = strings.ReplaceAll(value, " ", "")
+		if len(value) != 15 || !gstinPattern.MatchString(value) {
+			return "", fmt.Errorf("%q is not a 15 character GSTIN", value)
+		}
+	case TypeUPIVPA:
+		if strings.ContainsAny(value, " /") {
+			return "", fmt.Errorf("%q is not a UPI ID", value)
+		}
+	}
+	if value == "" {
+		return "", fmt.Errorf("empty %s", t)
+	}
+	return value, nil
+}
+
+var (
+	// The 12-digit reference (RRN) of a UPI, IMPS or internal transfer, as a
+	// segment of its narration
+	// Examples: UPI/634366982596/UPI/..., MMT/IMPS/895920987429/OK/...,
+	//           UPI/JAYANT SIN/JAYANTSINGH246/DURGA/KOTAK MAHI/564648156111/ICI7B61D9D2074F4
+	rrnPattern = regexp.MustCompile(`/(\d{12})(?:/|\s|$)`)
+
+	// The UTR of a NEFT or RTGS credit: the sending bank's code, a letter and
+	// up to 17 digits
+	// Examples: NEFT-PUNBN5202607159897063-SANJEEVANI MEDICAL..., NEFT_IN:null//SBINN52025042334823235/...
+	utrPattern = regexp.MustCompile(`\b(?:NEFT|RTGS)(?:_IN:NULL/)?[-/:]+([A-Z]{4}[A-Z0-9]\d{10,17})\b`)
+
+	// The reference of an internal transfer or bill payment
+	// Example: BIL/INFT/EDC0857581/ SANJIT KUMAR
+	inftRefPattern = regexp.MustCompile(`\bINFT/([A-Z0-9]{8,16})/`)
+
+	// UPI, IMPS and INFT narrations, whose 12-digit segments are references
+	rrnModePattern = regexp.MustCompile(`\b(?:UPI|IMPS|INFT)/`)
+)
+
+// References extracts the bank references of a payment from its narration:
+// the UTR of a NEFT or RTGS credit, or the RRN of a UPI or IMPS one, as a
+// customer disputing a payment quotes it. They identify one payment, not a
+// payer, so they are not identifiers.
+func References(narration string) []string {
+	narration = strings.ToUpper(narration)
+	var refs []string
+	add := func(ref string) {
+		if !slices.Contains(refs, ref) {
+			refs = append(refs, ref)
+		}
+	}
+	for _, m := range utrPattern.FindAllStringSubmatch(narration, -1) {
+		add(m[1])
+	}
+	for _, m := range inftRefPattern.FindAllStringSubmatch(narration, -1) {
+		add(m[1])
+	}
+	if rrnModePattern.MatchString(narration) {
+		for _, m := range rrnPattern.FindAllStringSubmatch(narration, -1) {
+			add(m[1])
+		}
+	}
+	return refs
+}
+
+// NormalizeReference writes a bank reference as References gives it, without
+// the spaces and case it is often quoted with
+func NormalizeReference(ref string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(ref), ""))
+}
@@ -1,6 +1,7 @@
 package extractor
 
 import (
+	"slices"
 	"testing"
 )
 
@@ -806,6 +807,83 @@ func TestExtractActcdep(t *testing.T) {
 	}
 }
 
+func TestExtractNACHMandate(t *testing.T) {
+	tests := []struct {
+		name      string
+		narration string
+		want      []string
+	}{
+		{
+			name:      "ACH credit with UMRN",
+			narration: "ACH/CR/HDFC7021807230034209/ABC PHARMA LTD",
+			want:      []string{"HDFC7021807230034209"},
+		},
+		{
+			name:      "NACH with hyphens",
+			narration: "NACH-CR-ICIC7021807230012345-SUN PHARMA",
+			want:      []string{"ICIC7021807230012345"},
+		},
+		{
+			name:      "ECS without a mandate reference",
+			narration: "ECS/MANKIND PHARMA/000000123456",
+			want:      nil,
+		},
+		{
+			name:      "UMRN-like value outside a NACH narration",
+			narration: "NEFT-HDFC7021807230034209-SHRI SHYAM AGENCY-",
+			want:      nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ExtractByType(tt.narration, TypeNACHMandate)
+			if len(got) != len(tt.want) {
+				t.Errorf("ExtractByType() got %d values %v, want %d values %v", len(got), got, len(tt.want), tt.want)
+				return
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("ExtractByType()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestExtractGSTIN(t *testing.T) {
+	tests := []struct {
+		name      string
+		narration string
+		want      []string
+	}{
+		{
+			name:      "NEFT quoting the payer's GSTIN",
+			narration: "NEFT-SBIN0001234-GUPTA PHARMA GST 09AAACG1234K1Z5",
+			want:      []string{"09AAACG1234K1Z5"},
+		},
+		{
+			name:      "Lowercase in a UPI remark",
+			narration: "UPI/634366982596/GSTIN 09aaacg1234k1z5/YBL",
+			want:      []string{"09AAACG1234K1Z5"},
+		},
+		{
+			name:      "PAN alone",
+			narration: "NEFT-SBIN0001234-GUPTA PHARMA AAACG1234K",
+			want:      nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ExtractByType(tt.narration, TypeGSTIN)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ExtractByType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestExtractFromName(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -859,3 +937,109 @@ func TestExtractFromName(t *testing.T) {
 		})
 	}
 }
+
+func TestParseType(t *testing.T) {
+	tests := []struct {
+		name string
+		want IdentifierType
+		ok   bool
+	}{
+		{"upi_vpa", TypeUPIVPA, true},
+		{"UPI", TypeUPIVPA, true},
+		{" Mobile ", TypePhone, true},
+		{"account_number", TypeAccountNumber, true},
+		{"IFSC", TypeIFSC, true},
+		{"UMRN", TypeNACHMandate, true},
+		{"GST", TypeGSTIN, true},
+		{"email", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseType(tt.name)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("ParseType(%q) = %v, %v, want %v, %v", tt.name, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestUnique(t *testing.T) {
+	unique := map[IdentifierType]bool{
+		TypeUPIVPA:        true,
+		TypePhone:         true,
+		TypeAccountNumber: true,
+		TypeCashAgentCode: true,
+		TypeNACHMandate:   true,
+		TypeGSTIN:         true,
+	}
+	for _, idType := range allTypes {
+		if got := idType.Unique(); got != unique[idType] {
+			t.Errorf("%s.Unique() = %v, want %v", idType, got, unique[idType])
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		idType  IdentifierType
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"UPI lowercased", TypeUPIVPA, "9450852076@ybl", "9450852076@YBL", false},
+		{"Phone with country code", TypePhone, "+91 94508 52076", "9450852076", false},
+		{"Phone with leading zero", TypePhone, "09450852076", "9450852076", false},
+		{"Phone too short", TypePhone, "945085207", "", true},
+		{"Landline", TypePhone, "0522123456", "", true},
+		{"Account with spaces", TypeAccountNumber, "1921 0500 2017", "192105002017", false},
+		{"Account with letters", TypeAccountNumber, "ABC123456789", "", true},
+		{"IFSC", TypeIFSC, "icic0001921", "ICIC0001921", false},
+		{"Bad IFSC", TypeIFSC, "ICIC1921", "", true},
+		{"GSTIN with spaces", TypeGSTIN, "09 aaacg1234k 1z5", "09AAACG1234K1Z5", false},
+		{"GSTIN without the Z", TypeGSTIN, "09AAACG1234K1A5", "", true},
+		{"Name spacing", TypeIMPSName, "  ram  kumar ", "RAM KUMAR", false},
+		{"Empty", TypeNEFTName, " ", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Normalize(tt.idType, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Normalize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Normalize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReferences(t *testing.T) {
+	tests := []struct {
+		name      string
+		narration string
+		want      []string
+	}{
+		{"UPI", "UPI/634366982596/UPI/7554777574/UNION BANKOF I/UBI63436698", []string{"634366982596"}},
+		{"UPI with the reference later", "UPI/JAYANT SIN/JAYANTSINGH246/DURGA/KOTAK MAHI/564648156111/ICI7B61D9D2074F4", []string{"564648156111"}},
+		{"IMPS", "MMT/IMPS/895920987429/OK/GYANENDRAG/HDFC BANK LTD", []string{"895920987429"}},
+		{"NEFT", "NEFT-PUNBN5202607159897063-SANJEEVANI MEDICAL AND--817601875955-PUNB0137466", []string{"PUNBN5202607159897063"}},
+		{"NEFT short UTR", "NEFT-CBINH25360482077-M S VISHNOI MEDICAL STORE-0000000364324", []string{"CBINH25360482077"}},
+		{"NEFT_IN", "NEFT_IN:null//SBINN52025042334823235/VIJAY MEDICAL STORE Ag. DDG000516", []string{"SBINN52025042334823235"}},
+		{"RTGS", "RTGS-UTIBR52025061812345678-GUPTA PHARMA-917020012345678", []string{"UTIBR52025061812345678"}},
+		{"Bill payment", "BIL/INFT/EDC0857581/ SANJIT KUMAR", []string{"EDC0857581"}},
+		{"Internal transfer", "INF/INFT/039939724801/DURGAKNP /S S PHARMA", []string{"039939724801"}},
+		{"Account number beside a cash deposit", "PNB 0999002100100001 12300.00 BY CASH - KANPUR", nil},
+		{"Cheque", "Chq.450061 Dt. 02-07-2026", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := References(tt.narration); !slices.Equal(got, tt.want) {
+				t.Errorf("References() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+// Package fy works with Indian financial years, which run from 1 April to
+// 31 March and are labelled by both calendar years, e.g. 2023-24
+package fy
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Year is the financial year starting on 1 April of StartYear
+type Year struct {
+	StartYear int
+}
+
+// Of returns the financial year t falls in
+func Of(t time.Time) Year {
+	if t.Month() < time.April {
+		return Year{StartYear: t.Year() - 1}
+	}
+	return Year{StartYear: t.Year()}
+}
+
+// Parse reads a label such as 2023-24 or 2023-2024
+func Parse(label string) (Year, error) {
+	var start, end int
+	if _, err := fmt.Sscanf(label, "%d-%d", &start, &end); err != nil {
+		return Year{}, fmt.Errorf("financial year %q should look like 2023-24", label)
+	}
+	if end != start+1 && end != (start+1)%100 {
+		return Year{}, fmt.Errorf("financial year %q should end the year after it starts", label)
+	}
+	return Year{StartYear: start}, nil
+}
+
+// Label names the year as shown on reports, e.g. 2023-24
+func (y Year) Label() string {
+	return strconv.Itoa(y.StartYear) + "-" + fmt.Sprintf("%02d", (y.StartYear+1)%100)
+}
+
+// Start returns 1 April, the first day of the year
+func (y Year) Start() time.Time {
+	return time.Date(y.StartYear, time.April, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// End returns 31 March, the last day of the year
+func (y Year) End() time.Time {
+	return time.Date(y.StartYear+1, time.March, 31, 0, 0, 0, 0, time.UTC)
+}
+
+// Next returns the following financial year
+func (y Year) Next() Year {
+	return Year{StartYear: y.StartYear + 1}
+}
+
+// Prev returns the preceding financial year
+func (y Year) Prev() Year {
+	return Year{StartYear: y.StartYear - 1}
+}
@@ -0,0 +1,70 @@
+package fy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOf(t *testing.T) {
+	tests := []struct {
+		date     time.Time
+		expected string
+	}{
+		{time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC), "2023-24"},
+		{time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), "2024-25"},
+		{time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC), "2024-25"},
+		{time.Date(2099, 12, 31, 0, 0, 0, 0, time.UTC), "2099-00"},
+	}
+
+	for _, tt := range tests {
+		if got := Of(tt.date).Label(); got != tt.expected {
+			t.Errorf("Of(%s) = %s, expected %s", tt.date.Format("2006-01-02"), got, tt.expected)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		label    string
+		expected int
+		wantErr  bool
+	}{
+		{"2023-24", 2023, false},
+		{"2023-2024", 2023, false},
+		{"2099-00", 2099, false},
+		{"2023-25", 0, true},
+		{"2023", 0, true},
+		{"FY23", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			y, err := Parse(tt.label)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error, got %d", y.StartYear)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if y.StartYear != tt.expected {
+				t.Errorf("Expected start year %d, got %d", tt.expected, y.StartYear)
+			}
+		})
+	}
+}
+
+func TestBounds(t *testing.T) {
+	y := Year{StartYear: 2023}
+	if got := y.Start().Format("2006-01-02"); got != "2023-04-01" {
+		t.Errorf("Start = %s", got)
+	}
+	if got := y.End().Format("2006-01-02"); got != "2024-03-31" {
+		t.Errorf("End = %s", got)
+	}
+	if Of(y.End()) != y || Of(y.Next().Start()) != y.Next() {
+		t.Errorf("Bounds of %s fall outside it", y.Label())
+	}
+}
@@ -0,0 +1,634 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+)
+
+// Request is a query as clients post it
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// Response is the result of a query: the data, unless the query was not
+// valid, and any errors
+type Response struct {
+	Data   *orderedMap `json:"data,omitempty"`
+	Errors []*Error    `json:"errors,omitempty"`
+}
+
+// Error is an error in a query or in resolving one of its fields, with where
+// in the query it is and, for a field, the path to it in the data
+type Error struct {
+	Message   string     `json:"message"`
+	Locations []Location `json:"locations,omitempty"`
+	Path      []any      `json:"path,omitempty"`
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// enumLiteral is an enum value written in a query, which unlike a string
+// only an enum accepts
+type enumLiteral string
+
+// Execute runs the query of a request. Only queries are supported, not
+// mutations or subscriptions.
+func (s *Schema) Execute(ctx context.Context, req Request) *Response {
+	doc, err := parse(req.Query)
+	if err != nil {
+		return &Response{Errors: []*Error{err.(*Error)}}
+	}
+
+	var op *operation
+	for _, o := range doc.operations {
+		if o.name == req.OperationName || req.OperationName == "" && len(doc.operations) == 1 {
+			op = o
+		}
+	}
+	switch {
+	case len(doc.operations) == 0:
+		return errorResponse("the query has only fragments, and no operation to run")
+	case op == nil && req.OperationName == "":
+		return errorResponse("the query has more than one operation, so operationName is needed")
+	case op == nil:
+		return errorResponse(fmt.Sprintf("there is no operation named %q", req.OperationName))
+	case op.kind != "query":
+		return &Response{Errors: []*Error{{Message: "only queries are supported; this API cannot change data", Locations: []Location{op.loc}}}}
+	}
+
+	v := &validator{ctx: ctx, schema: s, doc: doc, op: op, visiting: make(map[string]bool), checked: make(map[string]size)}
+	n := v.selections(s.query, op.selections)
+	if err := ctx.Err(); err != nil {
+		return errorResponse(err.Error())
+	}
+	if n.fields > maxQueryFields {
+		v.fail(op.loc, "The query selects more than %d fields once its fragments are expanded.", maxQueryFields)
+	}
+	if n.depth > maxQueryDepth {
+		v.fail(op.loc, "The query nests fields more than %d deep.", maxQueryDepth)
+	}
+	if len(v.errors) > 0 {
+		return &Response{Errors: v.errors}
+	}
+	vars, errs := s.coerceVariables(op, req.Variables)
+	if len(errs) > 0 {
+		return &Response{Errors: errs}
+	}
+
+	e := &executor{schema: s, doc: doc, vars: vars}
+	data, _ := e.executeFields(ctx, s.query, nil, e.collect(s.query, op.selections, nil), nil)
+	return &Response{Data: data, Errors: e.errors}
+}
+
+func errorResponse(message string) *Response {
+	return &Response{Errors: []*Error{{Message: message}}}
+}
+
+// The most fields a query may select once its fragments are expanded, and
+// the deepest it may nest them. Fragments that spread each other twice over
+// would otherwise make a short query select billions of fields.
+const (
+	maxQueryFields = 5000
+	maxQueryDepth  = 20
+)
+
+// validator checks a query against the schema before it is run, so a
+// mistaken query returns errors and no data
+type validator struct {
+	ctx      context.Context
+	schema   *Schema
+	doc      *document
+	op       *operation
+	visiting map[string]bool // fragments being checked, to catch cycles
+	checked  map[string]size // fragments already checked, each only once
+	errors   []*Error
+}
+
+// size is how many fields a selection set selects once its fragments are
+// expanded, up to just over maxQueryFields, and how deeply it nests them
+type size struct {
+	fields, depth int
+}
+
+func (n *size) add(m size) {
+	n.fields = min(n.fields+m.fields, maxQueryFields+1)
+	n.depth = max(n.depth, m.depth)
+}
+
+func (v *validator) fail(loc Location, format string, args ...any) {
+	v.errors = append(v.errors, &Error{Message: fmt.Sprintf(format, args...), Locations: []Location{loc}})
+}
+
+// selections checks a selection set on parent and returns its size
+func (v *validator) selections(parent *Object, selections []selection) size {
+	var n size
+	for _, sel := range selections {
+		if v.ctx.Err() != nil {
+			return n
+		}
+		switch sel := sel.(type) {
+		case *field:
+			v.directives(sel.directives)
+			if sel.name == "__typename" {
+				if sel.selections != nil {
+					v.fail(sel.loc, "Field \"__typename\" must not have a selection since type \"String!\" has no subfields.")
+				}
+				n.add(size{1, 1})
+				continue
+			}
+			n.add(size{1, 1})
+			def := v.schema.field(parent, sel.name)
+			if def == nil {
+				v.fail(sel.loc, "Cannot query field %q on type %q.", sel.name, parent.Name)
+				continue
+			}
+			v.arguments(parent, def, sel)
+			if o, ok := named(def.Type).(*Object); ok {
+				if sel.selections == nil {
+					v.fail(sel.loc, "Field %q of type %q must have a selection of subfields.", sel.name, def.Type)
+				} else {
+					sub := v.selections(o, sel.selections)
+					n.add(size{sub.fields, sub.depth + 1})
+				}
+			} else if sel.selections != nil {
+				v.fail(sel.loc, "Field %q must not have a selection since type %q has no subfields.", sel.name, def.Type)
+			}
+		case *fragmentSpread:
+			v.directives(sel.directives)
+			f := v.doc.fragments[sel.name]
+			switch {
+			case f == nil:
+				v.fail(sel.loc, "Unknown fragment %q.", sel.name)
+			case f.on != parent.Name:
+				v.fail(sel.loc, "Fragment %q cannot be spread here as objects of type %q can never be of type %q.", sel.name, parent.Name, f.on)
+			case v.visiting[sel.name]:
+				v.fail(sel.loc, "Cannot spread fragment %q within itself.", sel.name)
+			default:
+				checked, ok := v.checked[sel.name]
+				if !ok {
+					v.visiting[sel.name] = true
+					v.directives(f.directives)
+					checked = v.selections(parent, f.selections)
+					delete(v.visiting, sel.name)
+					v.checked[sel.name] = checked
+				}
+				n.add(checked)
+			}
+		case *inlineFragment:
+			v.directives(sel.directives)
+			if sel.on != "" && sel.on != parent.Name {
+				v.fail(sel.loc, "Fragment cannot be spread here as objects of type %q can never be of type %q.", parent.Name, sel.on)
+				continue
+			}
+			n.add(v.selections(parent, sel.selections))
+		}
+	}
+	return n
+}
+
+func (v *validator) arguments(parent *Object, def *Field, sel *field) {
+	given := make(map[string]bool)
+	for _, a := range sel.arguments {
+		if !slices.ContainsFunc(def.Args, func(d *Argument) bool { return d.Name == a.name }) {
+			v.fail(a.loc, "Unknown argument %q on field \"%s.%s\".", a.name, parent.Name, def.Name)
+		}
+		if given[a.name] {
+			v.fail(a.loc, "There can be only one argument named %q.", a.name)
+		}
+		given[a.name] = true
+		v.variables(a.value)
+	}
+	for _, d := range def.Args {
+		if _, nonNull := d.Type.(*NonNull); nonNull && d.Default == nil && !given[d.Name] {
+			v.fail(sel.loc, "Field %q argument %q of type %q is required, but it was not provided.", def.Name, d.Name, d.Type)
+		}
+	}
+}
+
+func (v *validator) directives(directives []*directive) {
+	for _, d := range directives {
+		if d.name != "skip" && d.name != "include" {
+			v.fail(d.loc, "Unknown directive \"@%s\".", d.name)
+			continue
+		}
+		for _, a := range d.arguments {
+			v.variables(a.value)
+		}
+	}
+}
+
+// variables checks that the variables a value uses are defined
+func (v *validator) variables(val value) {
+	switch val.kind {
+	case variableValue:
+		if !slices.ContainsFunc(v.op.variables, func(d *variableDefinition) bool { return d.name == val.raw }) {
+			v.fail(val.loc, "Variable \"$%s\" is not defined.", val.raw)
+		}
+	case listValue:
+		for _, item := range val.list {
+			v.variables(item)
+		}
+	case objectValue:
+		for _, f := range val.fields {
+			v.variables(f.value)
+		}
+	}
+}
+
+// field returns the field of parent named name, counting the introspection
+// fields of the query type
+func (s *Schema) field(parent *Object, name string) *Field {
+	if parent == s.query {
+		switch name {
+		case "__schema":
+			return &Field{Name: name, Type: &NonNull{introspectionSchema}}
+		case "__type":
+			return &Field{Name: name, Type: introspectionType, Args: []*Argument{{Name: "name", Type: &NonNull{String}}}}
+		}
+	}
+	return parent.field(name)
+}
+
+// coerceVariables reads the request's variables as the types the operation
+// declares them
+func (s *Schema) coerceVariables(op *operation, given map[string]any) (map[string]any, []*Error) {
+	vars := make(map[string]any)
+	var errs []*Error
+	for _, d := range op.variables {
+		t, err := s.resolveType(d.typ)
+		if err != nil {
+			errs = append(errs, &Error{Message: err.Error(), Locations: []Location{d.loc}})
+			continue
+		}
+		raw, ok := given[d.name]
+		if !ok && d.fallback != nil {
+			raw, ok = literalValue(*d.fallback, nil), true
+		}
+		if !ok {
+			if _, nonNull := t.(*NonNull); nonNull {
+				errs = append(errs, &Error{Message: fmt.Sprintf("Variable \"$%s\" of required type %q was not provided.", d.name, t), Locations: []Location{d.loc}})
+			}
+			continue
+		}
+		val, err := coerceInput(t, raw)
+		if err != nil {
+			errs = append(errs, &Error{Message: fmt.Sprintf("Variable \"$%s\" got an invalid value %s: %s.", d.name, describe(raw), err), Locations: []Location{d.loc}})
+			continue
+		}
+		vars[d.name] = val
+	}
+	return vars, errs
+}
+
+// resolveType finds the type a variable is declared with
+func (s *Schema) resolveType(ref typeRef) (Type, error) {
+	var t Type
+	if ref.list != nil {
+		of, err := s.resolveType(*ref.list)
+		if err != nil {
+			return nil, err
+		}
+		t = &List{of}
+	} else {
+		t = s.types[ref.name]
+		switch t.(type) {
+		case nil:
+			return nil, fmt.Errorf("Unknown type %q.", ref.name)
+		case *Object:
+			return nil, fmt.Errorf("Variable type %q is an object, not an input type.", ref.name)
+		}
+	}
+	if ref.nonNull {
+		t = &NonNull{t}
+	}
+	return t, nil
+}
+
+// literalValue turns a value in a query into Go, with its variables
+func literalValue(val value, vars map[string]any) any {
+	switch val.kind {
+	case variableValue:
+		return vars[val.raw]
+	case intValue, floatValue:
+		return json.Number(val.raw)
+	case stringValue:
+		return val.raw
+	case booleanValue:
+		return val.raw == "true"
+	case enumValue:
+		return enumLiteral(val.raw)
+	case listValue:
+		items := make([]any, len(val.list))
+		for i, item := range val.list {
+			items[i] = literalValue(item, vars)
+		}
+		return items
+	case objectValue:
+		fields := make(map[string]any, len(val.fields))
+		for _, f := range val.fields {
+			fields[f.name] = literalValue(f.value, vars)
+		}
+		return fields
+	}
+	return nil
+}
+
+// coerceInput reads an argument or variable as type t
+func coerceInput(t Type, v any) (any, error) {
+	if nn, ok := t.(*NonNull); ok {
+		if v == nil {
+			return nil, fmt.Errorf("expected a value of type %s, found null", t)
+		}
+		return coerceInput(nn.Of, v)
+	}
+	if v == nil {
+		return nil, nil
+	}
+	switch t := t.(type) {
+	case *List:
+		items, ok := v.([]any)
+		if !ok {
+			items = []any{v}
+		}
+		out := make([]any, len(items))
+		for i, item := range items {
+			c, err := coerceInput(t.Of, item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = c
+		}
+		return out, nil
+	case *Scalar:
+		return t.parse(v)
+	case *Enum:
+		var name string
+		switch v := v.(type) {
+		case enumLiteral:
+			name = string(v)
+		case string:
+			name = v
+		}
+		if slices.Contains(t.Values, name) {
+			return name, nil
+		}
+		return nil, fmt.Errorf("%s is not a value of %s", describe(v), t.Name)
+	}
+	return nil, fmt.Errorf("%s is not an input type", t)
+}
+
+// executor runs a validated operation
+type executor struct {
+	schema  *Schema
+	doc     *document
+	vars    map[string]any
+	errors  []*Error
+	stopped bool // the context ended, which is reported once
+}
+
+// fieldGroup is the fields of a selection set returned under one key, whose
+// selection sets are merged
+type fieldGroup struct {
+	key    string
+	fields []*field
+}
+
+// collect gathers the fields of a selection set by response key, in order,
+// expanding fragments and leaving out those @skip or @include turn off
+func (e *executor) collect(parent *Object, selections []selection, groups []*fieldGroup) []*fieldGroup {
+	for _, sel := range selections {
+		switch sel := sel.(type) {
+		case *field:
+			if !e.included(sel.directives) {
+				continue
+			}
+			i := slices.IndexFunc(groups, func(g *fieldGroup) bool { return g.key == sel.responseKey() })
+			if i < 0 {
+				groups = append(groups, &fieldGroup{key: sel.responseKey()})
+				i = len(groups) - 1
+			}
+			groups[i].fields = append(groups[i].fields, sel)
+		case *fragmentSpread:
+			if f := e.doc.fragments[sel.name]; e.included(sel.directives) && e.included(f.directives) {
+				groups = e.collect(parent, f.selections, groups)
+			}
+		case *inlineFragment:
+			if e.included(sel.directives) {
+				groups = e.collect(parent, sel.selections, groups)
+			}
+		}
+	}
+	return groups
+}
+
+func (e *executor) included(directives []*directive) bool {
+	for _, d := range directives {
+		on := false
+		for _, a := range d.arguments {
+			if a.name == "if" {
+				on, _ = literalValue(a.value, e.vars).(bool)
+			}
+		}
+		if d.name == "skip" && on || d.name == "include" && !on {
+			return false
+		}
+	}
+	return true
+}
+
+// executeFields resolves the fields of an object. It returns false when a
+// non-null field is null, making the whole object null.
+func (e *executor) executeFields(ctx context.Context, obj *Object, source any, groups []*fieldGroup, path []any) (*orderedMap, bool) {
+	result := &orderedMap{}
+	for _, g := range groups {
+		if err := ctx.Err(); err != nil {
+			if !e.stopped {
+				e.stopped = true
+				e.fail(err, g.fields[0], append(path, g.key))
+			}
+			return nil, false
+		}
+		v, ok := e.executeField(ctx, obj, source, g, append(path, g.key))
+		if !ok {
+			return nil, false
+		}
+		result.keys = append(result.keys, g.key)
+		result.values = append(result.values, v)
+	}
+	return result, true
+}
+
+func (e *executor) executeField(ctx context.Context, obj *Object, source any, g *fieldGroup, path []any) (any, bool) {
+	f := g.fields[0]
+	if f.name == "__typename" {
+		return obj.Name, true
+	}
+	def := e.schema.field(obj, f.name)
+	args, err := e.arguments(def, f)
+	var val any
+	if err == nil {
+		switch {
+		case obj == e.schema.query && f.name == "__schema":
+			val = e.schema
+		case obj == e.schema.query && f.name == "__type":
+			if t, ok := e.schema.types[args.String("name")]; ok {
+				val = t
+			}
+		default:
+			val, err = def.Resolve(ctx, source, args)
+		}
+	}
+	if err != nil {
+		e.fail(err, f, path)
+		_, nonNull := def.Type.(*NonNull)
+		return nil, !nonNull
+	}
+	return e.complete(ctx, def.Type, g, val, path)
+}
+
+// arguments coerces the arguments of a field to their types
+func (e *executor) arguments(def *Field, f *field) (Args, error) {
+	args := make(Args)
+	for _, d := range def.Args {
+		var raw any
+		given := false
+		for _, a := range f.arguments {
+			if a.name != d.Name {
+				continue
+			}
+			if a.value.kind == variableValue {
+				raw, given = e.vars[a.value.raw]
+			} else {
+				raw, given = literalValue(a.value, e.vars), true
+			}
+		}
+		if !given {
+			raw = d.Default
+		}
+		val, err := coerceInput(d.Type, raw)
+		if err != nil {
+			return nil, fmt.Errorf("Argument %q has an invalid value %s: %s.", d.Name, describe(raw), err)
+		}
+		if val != nil {
+			args[d.Name] = val
+		}
+	}
+	return args, nil
+}
+
+// complete turns a resolved value into the JSON of its type. It returns
+// false when the value is null, or has a non-null field that is, and type t
+// is non-null, so the null must reach up to the next field that may be null.
+func (e *executor) complete(ctx context.Context, t Type, g *fieldGroup, v any, path []any) (any, bool) {
+	if nn, ok := t.(*NonNull); ok {
+		out, ok := e.completeNullable(ctx, nn.Of, g, v, path)
+		if ok && out == nil {
+			e.fail(fmt.Errorf("Cannot return null for non-nullable field."), g.fields[0], path)
+		}
+		return out, ok && out != nil
+	}
+	out, ok := e.completeNullable(ctx, t, g, v, path)
+	if !ok {
+		return nil, true
+	}
+	return out, true
+}
+
+// completeNullable completes a value of a type that is not non-null. It
+// returns false when the value must be null because something it holds is.
+func (e *executor) completeNullable(ctx context.Context, t Type, g *fieldGroup, v any, path []any) (any, bool) {
+	if isNull(v) {
+		return nil, true
+	}
+	switch t := t.(type) {
+	case *Scalar:
+		out, err := t.serialize(v)
+		if err != nil {
+			e.fail(err, g.fields[0], path)
+			return nil, false
+		}
+		return out, true
+	case *Enum:
+		s, ok := v.(string)
+		if !ok || !slices.Contains(t.Values, s) {
+			e.fail(fmt.Errorf("%s cannot represent %v", t.Name, v), g.fields[0], path)
+			return nil, false
+		}
+		return s, true
+	case *List:
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Slice {
+			e.fail(fmt.Errorf("expected a list, found %T", v), g.fields[0], path)
+			return nil, false
+		}
+		items := make([]any, rv.Len())
+		for i := range items {
+			item, ok := e.complete(ctx, t.Of, g, rv.Index(i).Interface(), append(path, i))
+			if !ok {
+				return nil, false
+			}
+			items[i] = item
+		}
+		return items, true
+	case *Object:
+		var selections []selection
+		for _, f := range g.fields {
+			selections = append(selections, f.selections...)
+		}
+		return e.executeFields(ctx, t, v, e.collect(t, selections, nil), path)
+	}
+	return nil, false
+}
+
+// isNull reports whether a resolved value is null: nil, or a nil pointer or
+// map. A nil slice is an empty list.
+func isNull(v any) bool {
+	if v == nil {
+		return true
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
+func (e *executor) fail(err error, f *field, path []any) {
+	e.errors = append(e.errors, &Error{
+		Message:   err.Error(),
+		Locations: []Location{f.loc},
+		Path:      slices.Clone(path),
+	})
+}
+
+// orderedMap is an object in the response, its keys in the order queried
+type orderedMap struct {
+	keys   []string
+	values []any
+}
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, k := range m.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.Quote(k))
+		b.WriteByte(':')
+		v, err := json.Marshal(m.values[i])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(v)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
@@ -0,0 +1,248 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type testBook struct {
+	ID     int64
+	Title  string
+	Author *testAuthor
+}
+
+type testAuthor struct {
+	Name string
+}
+
+func testSchema(t *testing.T) *Schema {
+	author := &Object{Name: "Author", Fields: []*Field{
+		{Name: "name", Type: &NonNull{String}, Resolve: Value(func(a *testAuthor) any { return a.Name })},
+		{Name: "failing", Type: &NonNull{String}, Resolve: func(context.Context, any, Args) (any, error) {
+			return nil, errors.New("no such thing")
+		}},
+	}}
+	book := &Object{Name: "Book", Fields: []*Field{
+		{Name: "id", Type: &NonNull{ID}, Resolve: Value(func(b testBook) any { return b.ID })},
+		{Name: "title", Type: &NonNull{String}, Resolve: Value(func(b testBook) any { return b.Title })},
+		{Name: "author", Type: author, Resolve: Value(func(b testBook) any { return b.Author })},
+	}}
+	books := []testBook{
+		{ID: 1, Title: "Godan", Author: &testAuthor{Name: "Premchand"}},
+		{ID: 2, Title: "Anonymous"},
+	}
+	order := &Enum{Name: "Order", Values: []string{"ASC", "DESC"}}
+	query := &Object{Name: "Query", Fields: []*Field{
+		{
+			Name: "books",
+			Type: &NonNull{&List{&NonNull{book}}},
+			Args: []*Argument{
+				{Name: "limit", Type: Int, Default: 10},
+				{Name: "order", Type: order, Default: "ASC"},
+			},
+			Resolve: func(_ context.Context, _ any, args Args) (any, error) {
+				list := books
+				if args.String("order") == "DESC" {
+					list = []testBook{books[1], books[0]}
+				}
+				return list[:min(args.Int("limit"), len(list))], nil
+			},
+		},
+		{
+			Name: "book",
+			Type: book,
+			Args: []*Argument{{Name: "id", Type: &NonNull{ID}}},
+			Resolve: func(_ context.Context, _ any, args Args) (any, error) {
+				for _, b := range books {
+					if args.String("id") == strconv.FormatInt(b.ID, 10) {
+						return b, nil
+					}
+				}
+				return nil, nil
+			},
+		},
+	}}
+	s, err := NewSchema(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func run(t *testing.T, s *Schema, req Request) (string, []*Error) {
+	t.Helper()
+	resp := s.Execute(context.Background(), req)
+	if resp.Data == nil {
+		return "", resp.Errors
+	}
+	b, err := json.Marshal(resp.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b), resp.Errors
+}
+
+func TestExecute(t *testing.T) {
+	s := testSchema(t)
+	tests := []struct {
+		name string
+		req  Request
+		want string
+	}{
+		{
+			name: "nested fields in query order",
+			req:  Request{Query: `{ books { title id author { name } } }`},
+			want: `{"books":[{"title":"Godan","id":"1","author":{"name":"Premchand"}},{"title":"Anonymous","id":"2","author":null}]}`,
+		},
+		{
+			name: "aliases and arguments",
+			req:  Request{Query: `{ first: books(limit: 1) { title } last: books(order: DESC, limit: 1) { title } }`},
+			want: `{"first":[{"title":"Godan"}],"last":[{"title":"Anonymous"}]}`,
+		},
+		{
+			name: "fragments merge",
+			req: Request{Query: `
+				query { books(limit: 1) { ...Names id ... on Book { title } } }
+				fragment Names on Book { title author { name } }`},
+			want: `{"books":[{"title":"Godan","author":{"name":"Premchand"},"id":"1"}]}`,
+		},
+		{
+			name: "variables and directives",
+			req: Request{
+				Query:     `query Q($n: Int = 2, $full: Boolean!) { books(limit: $n) { title author @include(if: $full) { name } } }`,
+				Variables: map[string]any{"n": json.Number("1"), "full": false},
+			},
+			want: `{"books":[{"title":"Godan"}]}`,
+		},
+		{
+			name: "named operation",
+			req: Request{
+				Query:         `query A { books(limit: 1) { id } } query B { __typename }`,
+				OperationName: "B",
+			},
+			want: `{"__typename":"Query"}`,
+		},
+		{
+			name: "type introspection",
+			req:  Request{Query: `{ __type(name: "Book") { kind fields { name type { kind ofType { name } } } } }`},
+			want: `{"__type":{"kind":"OBJECT","fields":[{"name":"id","type":{"kind":"NON_NULL","ofType":{"name":"ID"}}},{"name":"title","type":{"kind":"NON_NULL","ofType":{"name":"String"}}},{"name":"author","type":{"kind":"OBJECT","ofType":null}}]}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, errs := run(t, s, tt.req)
+			if len(errs) > 0 {
+				t.Fatalf("errors: %v", errs)
+			}
+			if got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNullPropagation(t *testing.T) {
+	s := testSchema(t)
+	// author is nullable, so its failing non-null field nulls the author
+	// and not the book
+	got, errs := run(t, s, Request{Query: `{ books(limit: 1) { title author { failing } } }`})
+	if want := `{"books":[{"title":"Godan","author":null}]}`; got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+	if len(errs) != 1 || errs[0].Message != "no such thing" {
+		t.Fatalf("errors = %v", errs)
+	}
+	b, _ := json.Marshal(errs[0])
+	if want := `{"message":"no such thing","locations":[{"line":1,"column":36}],"path":["books",0,"author","failing"]}`; string(b) != want {
+		t.Errorf("error = %s, want %s", b, want)
+	}
+}
+
+func TestInvalidQueries(t *testing.T) {
+	s := testSchema(t)
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`{ books { title `, `Syntax error`},
+		{`{ books { price } }`, `Cannot query field "price" on type "Book".`},
+		{`{ books }`, `must have a selection of subfields`},
+		{`{ books { title { x } } }`, `must not have a selection`},
+		{`{ book { title } }`, `argument "id" of type "ID!" is required`},
+		{`{ books(page: 2) { title } }`, `Unknown argument "page"`},
+		{`{ books(limit: $n) { title } }`, `Variable "$n" is not defined.`},
+		{`{ books { ...F } } fragment F on Book { ...F }`, `Cannot spread fragment "F" within itself.`},
+		{`{ books { ...F } } fragment F on Author { name }`, `can never be of type "Author"`},
+		{`mutation { books { title } }`, `only queries are supported`},
+	}
+	for _, tt := range tests {
+		resp := s.Execute(context.Background(), Request{Query: tt.query})
+		if resp.Data != nil {
+			t.Errorf("%s: returned data for an invalid query", tt.query)
+		}
+		if len(resp.Errors) == 0 || !strings.Contains(resp.Errors[0].Message, tt.want) {
+			t.Errorf("%s: errors = %v, want %q", tt.query, resp.Errors, tt.want)
+		}
+	}
+
+	_, errs := run(t, s, Request{Query: `query($n: Int) { books(limit: $n) { id } }`, Variables: map[string]any{"n": "ten"}})
+	if len(errs) == 0 || !strings.Contains(errs[0].Message, `Variable "$n" got an invalid value "ten"`) {
+		t.Errorf("bad variable: errors = %v", errs)
+	}
+	_, errs = run(t, s, Request{Query: `{ books(order: UP) { id } }`})
+	if len(errs) == 0 || !strings.Contains(errs[0].Message, `"UP" is not a value of Order`) {
+		t.Errorf("unknown enum value: errors = %v", errs)
+	}
+	_, errs = run(t, s, Request{Query: `{ book(id: ONE) { id } }`})
+	if len(errs) == 0 || !strings.Contains(errs[0].Message, `ID cannot represent "ONE"`) {
+		t.Errorf("enum for ID: errors = %v", errs)
+	}
+}
+
+func TestQueryLimits(t *testing.T) {
+	s := testSchema(t)
+	// each fragment spreads the next twice, so expanded the query would
+	// select 2^40 titles
+	var q strings.Builder
+	q.WriteString(`{ books { ...F0 } }`)
+	for i := range 40 {
+		q.WriteString(" fragment F" + strconv.Itoa(i) + " on Book { ...F" + strconv.Itoa(i+1) + " ...F" + strconv.Itoa(i+1) + " }")
+	}
+	q.WriteString(" fragment F40 on Book { title }")
+	_, errs := run(t, s, Request{Query: q.String()})
+	if len(errs) != 1 || !strings.Contains(errs[0].Message, "more than 5000 fields") {
+		t.Errorf("fragment bomb: errors = %v", errs)
+	}
+
+	deep := `{ __type(name: "Book") { ` + strings.Repeat("ofType { ", 25) + "name" + strings.Repeat(" }", 25) + " } }"
+	_, errs = run(t, s, Request{Query: deep})
+	if len(errs) != 1 || !strings.Contains(errs[0].Message, "more than 20 deep") {
+		t.Errorf("deep query: errors = %v", errs)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	resp := s.Execute(ctx, Request{Query: `{ books { title } }`})
+	if resp.Data != nil || len(resp.Errors) != 1 || resp.Errors[0].Message != context.Canceled.Error() {
+		t.Errorf("canceled: data = %v, errors = %v", resp.Data, resp.Errors)
+	}
+}
+
+func TestSDL(t *testing.T) {
+	sdl := testSchema(t).SDL()
+	for _, want := range []string{
+		"type Query {\n  books(limit: Int = 10, order: Order = ASC): [Book!]!\n  book(id: ID!): Book\n}\n",
+		"enum Order {\n  ASC\n  DESC\n}\n",
+	} {
+		if !strings.Contains(sdl, want) {
+			t.Errorf("SDL() is missing\n%s\ngot\n%s", want, sdl)
+		}
+	}
+	if !strings.HasPrefix(sdl, "type Query {") {
+		t.Errorf("SDL() does not start with the query type:\n%s", sdl)
+	}
+}
@@ -0,0 +1,182 @@
+package graphql
+
+import "sort"
+
+// The types a schema describes itself with, for tools such as GraphiQL. See
+// https://spec.graphql.org/October2021/#sec-Schema-Introspection.
+var (
+	introspectionSchema     = &Object{Name: "__Schema"}
+	introspectionType       = &Object{Name: "__Type"}
+	introspectionField      = &Object{Name: "__Field"}
+	introspectionInputValue = &Object{Name: "__InputValue"}
+	introspectionEnumValue  = &Object{Name: "__EnumValue"}
+	introspectionDirective  = &Object{Name: "__Directive"}
+
+	typeKind = &Enum{
+		Name:   "__TypeKind",
+		Values: []string{"SCALAR", "OBJECT", "INTERFACE", "UNION", "ENUM", "INPUT_OBJECT", "LIST", "NON_NULL"},
+	}
+	directiveLocation = &Enum{
+		Name:   "__DirectiveLocation",
+		Values: []string{"QUERY", "MUTATION", "SUBSCRIPTION", "FIELD", "FRAGMENT_DEFINITION", "FRAGMENT_SPREAD", "INLINE_FRAGMENT", "VARIABLE_DEFINITION"},
+	}
+)
+
+// directiveDef is a directive the executor understands
+type directiveDef struct {
+	name        string
+	description string
+	locations   []string
+	args        []*Argument
+}
+
+// directives are @skip and @include, the only directives supported
+var directives = []*directiveDef{
+	{
+		name:        "skip",
+		description: "Leaves out this field or fragment when if is true.",
+		locations:   []string{"FIELD", "FRAGMENT_SPREAD", "INLINE_FRAGMENT"},
+		args:        []*Argument{{Name: "if", Type: &NonNull{Boolean}}},
+	},
+	{
+		name:        "include",
+		description: "Includes this field or fragment only when if is true.",
+		locations:   []string{"FIELD", "FRAGMENT_SPREAD", "INLINE_FRAGMENT"},
+		args:        []*Argument{{Name: "if", Type: &NonNull{Boolean}}},
+	},
+}
+
+// optional returns nil for an empty string, so it is null in the result
+func optional(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func init() {
+	nonNullString := &NonNull{String}
+	nonNullBoolean := &NonNull{Boolean}
+	types := &List{&NonNull{introspectionType}}
+	deprecated := []*Argument{{Name: "includeDeprecated", Type: Boolean, Default: false}}
+
+	introspectionSchema.Fields = []*Field{
+		{Name: "description", Type: String, Resolve: Value(func(*Schema) any { return nil })},
+		{Name: "types", Type: &NonNull{types}, Resolve: Value(func(s *Schema) any {
+			names := make([]string, 0, len(s.types))
+			for name := range s.types {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			list := make([]Type, len(names))
+			for i, name := range names {
+				list[i] = s.types[name]
+			}
+			return list
+		})},
+		{Name: "queryType", Type: &NonNull{introspectionType}, Resolve: Value(func(s *Schema) any { return Type(s.query) })},
+		{Name: "mutationType", Type: introspectionType, Resolve: Value(func(*Schema) any { return nil })},
+		{Name: "subscriptionType", Type: introspectionType, Resolve: Value(func(*Schema) any { return nil })},
+		{Name: "directives", Type: &NonNull{&List{&NonNull{introspectionDirective}}}, Resolve: Value(func(*Schema) any { return directives })},
+	}
+
+	introspectionType.Fields = []*Field{
+		{Name: "kind", Type: &NonNull{typeKind}, Resolve: Value(func(t Type) any {
+			switch t.(type) {
+			case *Scalar:
+				return "SCALAR"
+			case *Object:
+				return "OBJECT"
+			case *Enum:
+				return "ENUM"
+			case *List:
+				return "LIST"
+			}
+			return "NON_NULL"
+		})},
+		{Name: "name", Type: String, Resolve: Value(func(t Type) any {
+			switch t.(type) {
+			case *List, *NonNull:
+				return nil
+			}
+			return t.String()
+		})},
+		{Name: "description", Type: String, Resolve: Value(func(t Type) any {
+			switch t := t.(type) {
+			case *Scalar:
+				return optional(t.Description)
+			case *Object:
+				return optional(t.Description)
+			case *Enum:
+				return optional(t.Description)
+			}
+			return nil
+		})},
+		{Name: "specifiedByURL", Type: String, Resolve: Value(func(Type) any { return nil })},
+		{Name: "fields", Type: &List{&NonNull{introspectionField}}, Args: deprecated, Resolve: Value(func(t Type) any {
+			if o, ok := t.(*Object); ok {
+				return o.Fields
+			}
+			return nil
+		})},
+		{Name: "interfaces", Type: types, Resolve: Value(func(t Type) any {
+			if _, ok := t.(*Object); ok {
+				return []Type{}
+			}
+			return nil
+		})},
+		{Name: "possibleTypes", Type: types, Resolve: Value(func(Type) any { return nil })},
+		{Name: "enumValues", Type: &List{&NonNull{introspectionEnumValue}}, Args: deprecated, Resolve: Value(func(t Type) any {
+			if e, ok := t.(*Enum); ok {
+				return e.Values
+			}
+			return nil
+		})},
+		{Name: "inputFields", Type: &List{&NonNull{introspectionInputValue}}, Resolve: Value(func(Type) any { return nil })},
+		{Name: "ofType", Type: introspectionType, Resolve: Value(func(t Type) any {
+			switch t := t.(type) {
+			case *List:
+				return t.Of
+			case *NonNull:
+				return t.Of
+			}
+			return nil
+		})},
+	}
+
+	introspectionField.Fields = []*Field{
+		{Name: "name", Type: nonNullString, Resolve: Value(func(f *Field) any { return f.Name })},
+		{Name: "description", Type: String, Resolve: Value(func(f *Field) any { return optional(f.Description) })},
+		{Name: "args", Type: &NonNull{&List{&NonNull{introspectionInputValue}}}, Resolve: Value(func(f *Field) any { return f.Args })},
+		{Name: "type", Type: &NonNull{introspectionType}, Resolve: Value(func(f *Field) any { return f.Type })},
+		{Name: "isDeprecated", Type: nonNullBoolean, Resolve: Value(func(*Field) any { return false })},
+		{Name: "deprecationReason", Type: String, Resolve: Value(func(*Field) any { return nil })},
+	}
+
+	introspectionInputValue.Fields = []*Field{
+		{Name: "name", Type: nonNullString, Resolve: Value(func(a *Argument) any { return a.Name })},
+		{Name: "description", Type: String, Resolve: Value(func(a *Argument) any { return optional(a.Description) })},
+		{Name: "type", Type: &NonNull{introspectionType}, Resolve: Value(func(a *Argument) any { return a.Type })},
+		{Name: "defaultValue", Type: String, Resolve: Value(func(a *Argument) any {
+			if a.Default == nil {
+				return nil
+			}
+			return literal(a.Type, a.Default)
+		})},
+	}
+
+	introspectionEnumValue.Fields = []*Field{
+		{Name: "name", Type: nonNullString, Resolve: Value(func(v string) any { return v })},
+		{Name: "description", Type: String, Resolve: Value(func(string) any { return nil })},
+		{Name: "isDeprecated", Type: nonNullBoolean, Resolve: Value(func(string) any { return false })},
+		{Name: "deprecationReason", Type: String, Resolve: Value(func(string) any { return nil })},
+	}
+
+	introspectionDirective.Fields = []*Field{
+		{Name: "name", Type: nonNullString, Resolve: Value(func(d *directiveDef) any { return d.name })},
+		{Name: "description", Type: String, Resolve: Value(func(d *directiveDef) any { return optional(d.description) })},
+		{Name: "locations", Type: &NonNull{&List{&NonNull{directiveLocation}}}, Resolve: Value(func(d *directiveDef) any { return d.locations })},
+		{Name: "args", Type: &NonNull{&List{&NonNull{introspectionInputValue}}}, Resolve: Value(func(d *directiveDef) any { return d.args })},
+		{Name: "isRepeatable", Type: nonNullBoolean, Resolve: Value(func(*directiveDef) any { return false })},
+	}
+}
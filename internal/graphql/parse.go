@@ -0,0 +1,629 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// The executable part of the GraphQL language: operations and fragments.
+// See https://spec.graphql.org/October2021/#sec-Language.
+
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	kind       string // query, mutation or subscription
+	name       string
+	variables  []*variableDefinition
+	directives []*directive
+	selections []selection
+	loc        Location
+}
+
+type variableDefinition struct {
+	name     string
+	typ      typeRef
+	fallback *value // nil when there is no default
+	loc      Location
+}
+
+type fragment struct {
+	name       string
+	on         string
+	directives []*directive
+	selections []selection
+	loc        Location
+}
+
+// selection is a *field, *fragmentSpread or *inlineFragment
+type selection interface{ location() Location }
+
+type field struct {
+	alias      string
+	name       string
+	arguments  []*argument
+	directives []*directive
+	selections []selection
+	loc        Location
+}
+
+type fragmentSpread struct {
+	name       string
+	directives []*directive
+	loc        Location
+}
+
+type inlineFragment struct {
+	on         string // empty without a type condition
+	directives []*directive
+	selections []selection
+	loc        Location
+}
+
+func (f *field) location() Location          { return f.loc }
+func (f *fragmentSpread) location() Location { return f.loc }
+func (f *inlineFragment) location() Location { return f.loc }
+
+// responseKey is the name a field's value is returned under
+func (f *field) responseKey() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+type argument struct {
+	name  string
+	value value
+	loc   Location
+}
+
+type directive struct {
+	name      string
+	arguments []*argument
+	loc       Location
+}
+
+// typeRef is a type as written in a variable definition
+type typeRef struct {
+	name    string // the named type, when not a list
+	list    *typeRef
+	nonNull bool
+}
+
+func (t typeRef) String() string {
+	s := t.name
+	if t.list != nil {
+		s = "[" + t.list.String() + "]"
+	}
+	if t.nonNull {
+		s += "!"
+	}
+	return s
+}
+
+// value is a literal or a variable in a query
+type value struct {
+	kind   valueKind
+	raw    string // the name, number or string
+	list   []value
+	fields []objectField
+	loc    Location
+}
+
+type objectField struct {
+	name  string
+	value value
+}
+
+type valueKind int
+
+const (
+	variableValue valueKind = iota
+	intValue
+	floatValue
+	stringValue
+	booleanValue
+	nullValue
+	enumValue
+	listValue
+	objectValue
+)
+
+// Location is a line and column in a query, counting from 1
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// token kinds
+const (
+	tokEOF = iota
+	tokPunct
+	tokName
+	tokInt
+	tokFloat
+	tokString
+)
+
+type token struct {
+	kind  int
+	value string
+	loc   Location
+}
+
+type parser struct {
+	src  string
+	pos  int
+	line int
+	col  int // the column of pos
+	tok  token
+}
+
+// parse parses a query document
+func parse(src string) (doc *document, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(*Error)
+			if !ok {
+				panic(r)
+			}
+			doc, err = nil, e
+		}
+	}()
+	p := &parser{src: src, line: 1, col: 1}
+	p.next()
+	doc = &document{fragments: make(map[string]*fragment)}
+	if p.tok.kind == tokEOF {
+		p.fail(p.tok.loc, "the query is empty")
+	}
+	for p.tok.kind != tokEOF {
+		switch {
+		case p.peek("{"):
+			doc.operations = append(doc.operations, &operation{kind: "query", loc: p.tok.loc, selections: p.selectionSet()})
+		case p.tok.kind == tokName && (p.tok.value == "query" || p.tok.value == "mutation" || p.tok.value == "subscription"):
+			doc.operations = append(doc.operations, p.operation())
+		case p.tok.kind == tokName && p.tok.value == "fragment":
+			f := p.fragment()
+			if doc.fragments[f.name] != nil {
+				p.fail(f.loc, "there is more than one fragment named %q", f.name)
+			}
+			doc.fragments[f.name] = f
+		default:
+			p.unexpected()
+		}
+	}
+	return doc, nil
+}
+
+func (p *parser) operation() *operation {
+	op := &operation{kind: p.tok.value, loc: p.tok.loc}
+	p.next()
+	if p.tok.kind == tokName {
+		op.name = p.name()
+	}
+	if p.skip("(") {
+		for !p.skip(")") {
+			v := &variableDefinition{loc: p.tok.loc}
+			p.expect("$")
+			v.name = p.name()
+			p.expect(":")
+			v.typ = p.typeRef()
+			if p.skip("=") {
+				fallback := p.value(true)
+				v.fallback = &fallback
+			}
+			p.directives()
+			op.variables = append(op.variables, v)
+		}
+	}
+	op.directives = p.directives()
+	op.selections = p.selectionSet()
+	return op
+}
+
+func (p *parser) fragment() *fragment {
+	f := &fragment{loc: p.tok.loc}
+	p.next()
+	f.name = p.name()
+	if f.name == "on" {
+		p.fail(f.loc, "a fragment cannot be named \"on\"")
+	}
+	p.keyword("on")
+	f.on = p.name()
+	f.directives = p.directives()
+	f.selections = p.selectionSet()
+	return f
+}
+
+func (p *parser) selectionSet() []selection {
+	p.expect("{")
+	var selections []selection
+	for !p.skip("}") {
+		selections = append(selections, p.selection())
+	}
+	if len(selections) == 0 {
+		p.fail(p.tok.loc, "a selection set cannot be empty")
+	}
+	return selections
+}
+
+func (p *parser) selection() selection {
+	loc := p.tok.loc
+	if p.skip("...") {
+		if p.tok.kind == tokName && p.tok.value != "on" {
+			return &fragmentSpread{name: p.name(), directives: p.directives(), loc: loc}
+		}
+		f := &inlineFragment{loc: loc}
+		if p.tok.kind == tokName && p.tok.value == "on" {
+			p.next()
+			f.on = p.name()
+		}
+		f.directives = p.directives()
+		f.selections = p.selectionSet()
+		return f
+	}
+
+	f := &field{loc: loc, name: p.name()}
+	if p.skip(":") {
+		f.alias, f.name = f.name, p.name()
+	}
+	f.arguments = p.arguments(false)
+	f.directives = p.directives()
+	if p.peek("{") {
+		f.selections = p.selectionSet()
+	}
+	return f
+}
+
+func (p *parser) arguments(constant bool) []*argument {
+	if !p.skip("(") {
+		return nil
+	}
+	var args []*argument
+	for !p.skip(")") {
+		a := &argument{loc: p.tok.loc, name: p.name()}
+		p.expect(":")
+		a.value = p.value(constant)
+		args = append(args, a)
+	}
+	return args
+}
+
+func (p *parser) directives() []*directive {
+	var directives []*directive
+	for p.peek("@") {
+		d := &directive{loc: p.tok.loc}
+		p.next()
+		d.name = p.name()
+		d.arguments = p.arguments(false)
+		directives = append(directives, d)
+	}
+	return directives
+}
+
+func (p *parser) typeRef() typeRef {
+	var t typeRef
+	if p.skip("[") {
+		inner := p.typeRef()
+		t.list = &inner
+		p.expect("]")
+	} else {
+		t.name = p.name()
+	}
+	t.nonNull = p.skip("!")
+	return t
+}
+
+// value parses a value; constant ones, such as defaults, cannot hold
+// variables
+func (p *parser) value(constant bool) value {
+	tok := p.tok
+	v := value{loc: tok.loc, raw: tok.value}
+	switch {
+	case tok.kind == tokPunct && tok.value == "$" && !constant:
+		p.next()
+		v.kind, v.raw = variableValue, p.name()
+		return v
+	case tok.kind == tokInt:
+		v.kind = intValue
+	case tok.kind == tokFloat:
+		v.kind = floatValue
+	case tok.kind == tokString:
+		v.kind = stringValue
+	case tok.kind == tokName && (tok.value == "true" || tok.value == "false"):
+		v.kind = booleanValue
+	case tok.kind == tokName && tok.value == "null":
+		v.kind = nullValue
+	case tok.kind == tokName:
+		v.kind = enumValue
+	case tok.kind == tokPunct && tok.value == "[":
+		p.next()
+		v.kind = listValue
+		for !p.skip("]") {
+			v.list = append(v.list, p.value(constant))
+		}
+		return v
+	case tok.kind == tokPunct && tok.value == "{":
+		p.next()
+		v.kind = objectValue
+		for !p.skip("}") {
+			name := p.name()
+			p.expect(":")
+			v.fields = append(v.fields, objectField{name: name, value: p.value(constant)})
+		}
+		return v
+	default:
+		p.unexpected()
+	}
+	p.next()
+	return v
+}
+
+func (p *parser) name() string {
+	if p.tok.kind != tokName {
+		p.unexpected()
+	}
+	name := p.tok.value
+	p.next()
+	return name
+}
+
+func (p *parser) keyword(word string) {
+	if p.tok.kind != tokName || p.tok.value != word {
+		p.fail(p.tok.loc, "expected %q, found %s", word, p.describe())
+	}
+	p.next()
+}
+
+func (p *parser) peek(punct string) bool {
+	return p.tok.kind == tokPunct && p.tok.value == punct
+}
+
+func (p *parser) skip(punct string) bool {
+	if p.peek(punct) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(punct string) {
+	if !p.skip(punct) {
+		p.fail(p.tok.loc, "expected %q, found %s", punct, p.describe())
+	}
+}
+
+func (p *parser) unexpected() {
+	p.fail(p.tok.loc, "unexpected %s", p.describe())
+}
+
+func (p *parser) describe() string {
+	switch p.tok.kind {
+	case tokEOF:
+		return "end of query"
+	case tokString:
+		return "string"
+	}
+	return strconv.Quote(p.tok.value)
+}
+
+func (p *parser) fail(loc Location, format string, args ...any) {
+	panic(&Error{Message: "Syntax error: " + fmt.Sprintf(format, args...), Locations: []Location{loc}})
+}
+
+// next reads the next token, skipping whitespace, commas and comments
+func (p *parser) next() {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == '\n':
+			p.advance(1)
+			p.line, p.col = p.line+1, 1
+		case c == ' ' || c == '\t' || c == '\r' || c == ',':
+			p.advance(1)
+		case strings.HasPrefix(p.src[p.pos:], "\ufeff"):
+			// A byte order mark
+			p.advance(len("\ufeff"))
+		case c == '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.advance(1)
+			}
+		default:
+			p.lex()
+			return
+		}
+	}
+	p.tok = token{kind: tokEOF, loc: Location{p.line, p.col}}
+}
+
+func (p *parser) advance(n int) {
+	p.pos += n
+	p.col += n
+}
+
+func (p *parser) lex() {
+	loc := Location{p.line, p.col}
+	start := p.pos
+	c := p.src[p.pos]
+	switch {
+	case strings.HasPrefix(p.src[p.pos:], "..."):
+		p.advance(3)
+		p.tok = token{tokPunct, "...", loc}
+	case strings.IndexByte("!$&()=:@[]{}|", c) >= 0:
+		p.advance(1)
+		p.tok = token{tokPunct, string(c), loc}
+	case c == '_' || 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z':
+		for p.pos < len(p.src) && isNameChar(p.src[p.pos]) {
+			p.advance(1)
+		}
+		p.tok = token{tokName, p.src[start:p.pos], loc}
+	case c == '-' || '0' <= c && c <= '9':
+		p.number(loc)
+	case c == '"':
+		p.string(loc)
+	default:
+		r, _ := utf8.DecodeRuneInString(p.src[p.pos:])
+		p.fail(loc, "unexpected character %q", r)
+	}
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9'
+}
+
+func (p *parser) number(loc Location) {
+	start := p.pos
+	digits := func() int {
+		n := 0
+		for p.pos < len(p.src) && '0' <= p.src[p.pos] && p.src[p.pos] <= '9' {
+			p.advance(1)
+			n++
+		}
+		return n
+	}
+	if p.src[p.pos] == '-' {
+		p.advance(1)
+	}
+	if digits() == 0 {
+		p.fail(loc, "invalid number")
+	}
+	kind := tokInt
+	if p.pos < len(p.src) && p.src[p.pos] == '.' {
+		p.advance(1)
+		kind = tokFloat
+		if digits() == 0 {
+			p.fail(loc, "invalid number")
+		}
+	}
+	if p.pos < len(p.src) && (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') {
+		p.advance(1)
+		kind = tokFloat
+		if p.pos < len(p.src) && (p.src[p.pos] == '+' || p.src[p.pos] == '-') {
+			p.advance(1)
+		}
+		if digits() == 0 {
+			p.fail(loc, "invalid number")
+		}
+	}
+	if p.pos < len(p.src) && (isNameChar(p.src[p.pos]) || p.src[p.pos] == '.') {
+		p.fail(loc, "invalid number")
+	}
+	p.tok = token{kind, p.src[start:p.pos], loc}
+}
+
+func (p *parser) string(loc Location) {
+	if strings.HasPrefix(p.src[p.pos:], `"""`) {
+		p.blockString(loc)
+		return
+	}
+	p.advance(1)
+	var b strings.Builder
+	for {
+		if p.pos >= len(p.src) || p.src[p.pos] == '\n' {
+			p.fail(loc, "unterminated string")
+		}
+		c := p.src[p.pos]
+		if c == '"' {
+			p.advance(1)
+			break
+		}
+		if c != '\\' {
+			r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+			b.WriteRune(r)
+			p.advance(size)
+			continue
+		}
+		if p.pos+1 >= len(p.src) {
+			p.fail(loc, "unterminated string")
+		}
+		esc := p.src[p.pos+1]
+		p.advance(2)
+		switch esc {
+		case '"', '\\', '/':
+			b.WriteByte(esc)
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'u':
+			if p.pos+4 > len(p.src) {
+				p.fail(loc, "invalid unicode escape")
+			}
+			n, err := strconv.ParseUint(p.src[p.pos:p.pos+4], 16, 32)
+			if err != nil {
+				p.fail(loc, "invalid unicode escape")
+			}
+			b.WriteRune(rune(n))
+			p.advance(4)
+		default:
+			p.fail(loc, "invalid escape \\%c", esc)
+		}
+	}
+	p.tok = token{tokString, b.String(), loc}
+}
+
+// blockString reads a """triple-quoted""" string, removing the indentation
+// common to its lines and blank first and last lines
+func (p *parser) blockString(loc Location) {
+	p.advance(3)
+	start := p.pos
+	for {
+		if p.pos >= len(p.src) {
+			p.fail(loc, "unterminated string")
+		}
+		if strings.HasPrefix(p.src[p.pos:], `\"""`) {
+			p.advance(4)
+			continue
+		}
+		if strings.HasPrefix(p.src[p.pos:], `"""`) {
+			break
+		}
+		if p.src[p.pos] == '\n' {
+			p.pos++
+			p.line, p.col = p.line+1, 1
+			continue
+		}
+		p.advance(1)
+	}
+	raw := strings.ReplaceAll(p.src[start:p.pos], `\"""`, `"""`)
+	p.advance(3)
+
+	lines := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+	indent := -1
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			continue
+		}
+		if n := len(line) - len(trimmed); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	if indent > 0 {
+		for i := 1; i < len(lines); i++ {
+			if len(lines[i]) >= indent {
+				lines[i] = lines[i][indent:]
+			} else {
+				lines[i] = strings.TrimLeft(lines[i], " \t")
+			}
+		}
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	p.tok = token{tokString, strings.Join(lines, "\n"), loc}
+}
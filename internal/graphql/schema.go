@@ -0,0 +1,440 @@
+// Package graphql runs GraphQL queries against a schema of resolvers, enough
+// of the spec for reporting tools to fetch nested data in one request:
+// queries with variables, aliases, fragments, @skip and @include, and
+// introspection. There are no mutations, interfaces, unions or input
+// objects.
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Type is a GraphQL type: a *Scalar, *Enum or *Object, or one wrapped in a
+// *List or *NonNull
+type Type interface {
+	String() string
+}
+
+// Scalar is a leaf type. Its values are turned into JSON by serialize and
+// read from arguments by parse.
+type Scalar struct {
+	Name        string
+	Description string
+	serialize   func(any) (any, error)
+	parse       func(any) (any, error)
+}
+
+// Enum is a leaf type whose values are one of a set of names, returned by
+// resolvers as strings
+type Enum struct {
+	Name        string
+	Description string
+	Values      []string
+}
+
+// Object is a type with fields, each resolved from the object's value
+type Object struct {
+	Name        string
+	Description string
+	Fields      []*Field
+}
+
+// List is a list of another type
+type List struct{ Of Type }
+
+// NonNull is another type that is never null
+type NonNull struct{ Of Type }
+
+// Field is a field of an object
+type Field struct {
+	Name        string
+	Description string
+	Type        Type
+	Args        []*Argument
+	// Resolve returns the field's value from source, the value of the
+	// object the field is on (nil for the query type)
+	Resolve func(ctx context.Context, source any, args Args) (any, error)
+}
+
+// Argument is an argument of a field. Arguments may only be scalars, enums
+// and lists of them.
+type Argument struct {
+	Name        string
+	Description string
+	Type        Type
+	Default     any // given to Resolve when the argument is left out
+}
+
+func (s *Scalar) String() string  { return s.Name }
+func (e *Enum) String() string    { return e.Name }
+func (o *Object) String() string  { return o.Name }
+func (l *List) String() string    { return "[" + l.Of.String() + "]" }
+func (n *NonNull) String() string { return n.Of.String() + "!" }
+
+// field returns the object's field named name, or nil
+func (o *Object) field(name string) *Field {
+	for _, f := range o.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// Args holds a field's arguments, coerced to their types: an Int is an int,
+// a Float a float64, a String or ID a string and a Boolean a bool, and a
+// list is a []any. An argument left out without a default, or given as
+// null, is missing.
+type Args map[string]any
+
+// Int returns an Int argument, or 0
+func (a Args) Int(name string) int {
+	n, _ := a[name].(int)
+	return n
+}
+
+// Float returns a Float argument, or 0
+func (a Args) Float(name string) float64 {
+	f, _ := a[name].(float64)
+	return f
+}
+
+// String returns a String or ID argument, or ""
+func (a Args) String(name string) string {
+	s, _ := a[name].(string)
+	return s
+}
+
+// Bool returns a Boolean argument, or false
+func (a Args) Bool(name string) bool {
+	b, _ := a[name].(bool)
+	return b
+}
+
+// Has reports whether an argument was given or has a default
+func (a Args) Has(name string) bool {
+	_, ok := a[name]
+	return ok
+}
+
+// Value returns a resolver of a value derived from the source alone, which
+// must be an S
+func Value[S any](value func(S) any) func(context.Context, any, Args) (any, error) {
+	return func(_ context.Context, source any, _ Args) (any, error) {
+		return value(source.(S)), nil
+	}
+}
+
+// The built-in scalars
+var (
+	Int = &Scalar{
+		Name:        "Int",
+		Description: "A signed 32-bit integer.",
+		serialize: func(v any) (any, error) {
+			n, err := toInt(v)
+			if err != nil {
+				return nil, fmt.Errorf("Int cannot represent %v", v)
+			}
+			return n, nil
+		},
+		parse: func(v any) (any, error) {
+			n, err := toInt(v)
+			if err != nil {
+				return nil, fmt.Errorf("Int cannot represent %s", describe(v))
+			}
+			return n, nil
+		},
+	}
+	Float = &Scalar{
+		Name:        "Float",
+		Description: "A double-precision floating-point number.",
+		serialize: func(v any) (any, error) {
+			f, err := toFloat(v)
+			if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+				return nil, fmt.Errorf("Float cannot represent %v", v)
+			}
+			return f, nil
+		},
+		parse: func(v any) (any, error) {
+			f, err := toFloat(v)
+			if err != nil {
+				return nil, fmt.Errorf("Float cannot represent %s", describe(v))
+			}
+			return f, nil
+		},
+	}
+	String = &Scalar{
+		Name:        "String",
+		Description: "UTF-8 text.",
+		serialize: func(v any) (any, error) {
+			switch v := v.(type) {
+			case string:
+				return v, nil
+			case fmt.Stringer:
+				return v.String(), nil
+			}
+			return nil, fmt.Errorf("String cannot represent %v", v)
+		},
+		parse: func(v any) (any, error) {
+			if s, ok := v.(string); ok {
+				return s, nil
+			}
+			return nil, fmt.Errorf("String cannot represent %s", describe(v))
+		},
+	}
+	Boolean = &Scalar{
+		Name:        "Boolean",
+		Description: "true or false.",
+		serialize: func(v any) (any, error) {
+			if b, ok := v.(bool); ok {
+				return b, nil
+			}
+			return nil, fmt.Errorf("Boolean cannot represent %v", v)
+		},
+		parse: func(v any) (any, error) {
+			if b, ok := v.(bool); ok {
+				return b, nil
+			}
+			return nil, fmt.Errorf("Boolean cannot represent %s", describe(v))
+		},
+	}
+	ID = &Scalar{
+		Name:        "ID",
+		Description: "An identifier, serialized as a string.",
+		serialize: func(v any) (any, error) {
+			if s, ok := v.(string); ok {
+				return s, nil
+			}
+			if n, err := toInt64(v); err == nil {
+				return strconv.FormatInt(n, 10), nil
+			}
+			return nil, fmt.Errorf("ID cannot represent %v", v)
+		},
+		parse: func(v any) (any, error) {
+			if s, ok := v.(string); ok {
+				return s, nil
+			}
+			if n, err := toInt64(v); err == nil {
+				return strconv.FormatInt(n, 10), nil
+			}
+			return nil, fmt.Errorf("ID cannot represent %s", describe(v))
+		},
+	}
+)
+
+func toInt64(v any) (int64, error) {
+	switch v := v.(type) {
+	case int:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return int64(v), nil
+		}
+	case json.Number:
+		return v.Int64()
+	}
+	return 0, fmt.Errorf("not an integer")
+}
+
+func toInt(v any) (int, error) {
+	n, err := toInt64(v)
+	if err != nil || n < math.MinInt32 || n > math.MaxInt32 {
+		return 0, fmt.Errorf("not a 32-bit integer")
+	}
+	return int(n), nil
+}
+
+func toFloat(v any) (float64, error) {
+	switch v := v.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case json.Number:
+		return v.Float64()
+	}
+	return 0, fmt.Errorf("not a number")
+}
+
+// describe writes a value from a query or its variables for an error
+func describe(v any) string {
+	if v == nil {
+		return "null"
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// Schema is the types of an API, reached from its query type
+type Schema struct {
+	query *Object
+	types map[string]Type // the named types, introspection's included
+}
+
+// NewSchema returns the schema of the types reached from query
+func NewSchema(query *Object) (*Schema, error) {
+	s := &Schema{query: query, types: make(map[string]Type)}
+	schemaField := &Field{Name: "__schema", Type: &NonNull{introspectionSchema}}
+	typeField := &Field{Name: "__type", Type: introspectionType, Args: []*Argument{{Name: "name", Type: &NonNull{String}}}}
+	for _, t := range []Type{query, String, Boolean, schemaField.Type, typeField.Type} {
+		if err := s.add(t); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// add adds a type and the types it reaches, checking that a name is not
+// given to two types
+func (s *Schema) add(t Type) error {
+	t = named(t)
+	name := t.String()
+	if seen, ok := s.types[name]; ok {
+		if seen != t {
+			return fmt.Errorf("there is more than one type named %s", name)
+		}
+		return nil
+	}
+	s.types[name] = t
+	o, ok := t.(*Object)
+	if !ok {
+		return nil
+	}
+	for _, f := range o.Fields {
+		if f.Type == nil {
+			return fmt.Errorf("%s.%s has no type", o.Name, f.Name)
+		}
+		if f.Resolve == nil {
+			return fmt.Errorf("%s.%s has no resolver", o.Name, f.Name)
+		}
+		if err := s.add(f.Type); err != nil {
+			return err
+		}
+		for _, a := range f.Args {
+			if _, ok := named(a.Type).(*Object); ok {
+				return fmt.Errorf("argument %s of %s.%s is an object", a.Name, o.Name, f.Name)
+			}
+			if err := s.add(a.Type); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// named unwraps lists and non-nulls
+func named(t Type) Type {
+	for {
+		switch w := t.(type) {
+		case *List:
+			t = w.Of
+		case *NonNull:
+			t = w.Of
+		default:
+			return t
+		}
+	}
+}
+
+// SDL describes the schema in the GraphQL schema language
+func (s *Schema) SDL() string {
+	names := make([]string, 0, len(s.types))
+	for name := range s.types {
+		if !strings.HasPrefix(name, "__") && !builtIn[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		// The query type first, then the rest by name
+		if (names[i] == s.query.Name) != (names[j] == s.query.Name) {
+			return names[i] == s.query.Name
+		}
+		return names[i] < names[j]
+	})
+
+	var b strings.Builder
+	for i, name := range names {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		switch t := s.types[name].(type) {
+		case *Object:
+			writeDescription(&b, "", t.Description)
+			fmt.Fprintf(&b, "type %s {\n", t.Name)
+			for _, f := range t.Fields {
+				writeDescription(&b, "  ", f.Description)
+				fmt.Fprintf(&b, "  %s", f.Name)
+				if len(f.Args) > 0 {
+					var args []string
+					for _, a := range f.Args {
+						arg := a.Name + ": " + a.Type.String()
+						if a.Default != nil {
+							arg += " = " + literal(a.Type, a.Default)
+						}
+						args = append(args, arg)
+					}
+					fmt.Fprintf(&b, "(%s)", strings.Join(args, ", "))
+				}
+				fmt.Fprintf(&b, ": %s\n", f.Type)
+			}
+			b.WriteString("}\n")
+		case *Enum:
+			writeDescription(&b, "", t.Description)
+			fmt.Fprintf(&b, "enum %s {\n", t.Name)
+			for _, v := range t.Values {
+				fmt.Fprintf(&b, "  %s\n", v)
+			}
+			b.WriteString("}\n")
+		case *Scalar:
+			writeDescription(&b, "", t.Description)
+			fmt.Fprintf(&b, "scalar %s\n", t.Name)
+		}
+	}
+	return b.String()
+}
+
+// builtIn are the scalars every schema has
+var builtIn = map[string]bool{"Int": true, "Float": true, "String": true, "Boolean": true, "ID": true}
+
+func writeDescription(b *strings.Builder, indent, description string) {
+	if description != "" {
+		fmt.Fprintf(b, "%s%s\n", indent, strconv.Quote(description))
+	}
+}
+
+// literal writes an argument's default, of type t, in the query language
+func literal(t Type, v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		if _, ok := named(t).(*Enum); ok {
+			return v
+		}
+		return strconv.Quote(v)
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = literal(t, item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return fmt.Sprint(v)
+}
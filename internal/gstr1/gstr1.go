@@ -0,0 +1,274 @@
+// Package gstr1 reads the B2B invoices of a GSTR-1 return, as the GST portal
+// downloads them in JSON or the offline tool exports them in CSV, and checks
+// them against the sale bills in the books: bills filed but not in the books,
+// bills in the books but not filed, and bills filed with another value or
+// date.
+package gstr1
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Invoice is a B2B invoice as filed
+type Invoice struct {
+	Number        string
+	Date          time.Time
+	Value         float64 // the invoice value, tax included
+	ReceiverGSTIN string
+	ReceiverName  string // empty in JSON downloads, which carry only the GSTIN
+}
+
+// Return is the B2B part of a GSTR-1 return
+type Return struct {
+	GSTIN    string // the firm's; empty in CSV files
+	Period   string // the return period as 2006-01; empty in CSV files
+	Invoices []Invoice
+}
+
+// Parse reads a GSTR-1 return from the portal's JSON or the offline tool's
+// B2B CSV, telling them apart by their first character. An invoice with
+// several tax rates takes a CSV row per rate but is returned once.
+func Parse(r io.Reader) (Return, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Return{}, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		return parseJSON(trimmed)
+	}
+	return parseCSV(data)
+}
+
+type jsonReturn struct {
+	GSTIN string `json:"gstin"`
+	FP    string `json:"fp"` // MMYYYY
+	B2B   []struct {
+		CTIN      string `json:"ctin"`
+		TradeName string `json:"trdnm"`
+		Invoices  []struct {
+			Number string      `json:"inum"`
+			Date   string      `json:"idt"`
+			Value  json.Number `json:"val"`
+		} `json:"inv"`
+	} `json:"b2b"`
+}
+
+func parseJSON(data []byte) (Return, error) {
+	var j jsonReturn
+	if err := json.Unmarshal(data, &j); err != nil {
+		return Return{}, fmt.Errorf("reading GSTR-1 JSON: %w", err)
+	}
+	ret := Return{GSTIN: j.GSTIN}
+	if j.FP != "" {
+		fp, err := time.Parse("012006", j.FP)
+		if err != nil {
+			return Return{}, fmt.Errorf("return period %q is not MMYYYY", j.FP)
+		}
+		ret.Period = fp.Format("2006-01")
+	}
+	for _, b := range j.B2B {
+		for _, inv := range b.Invoices {
+			date, err := parseDate(inv.Date)
+			if err != nil {
+				return Return{}, fmt.Errorf("invoice %s: %w", inv.Number, err)
+			}
+			value, err := inv.Value.Float64()
+			if err != nil {
+				return Return{}, fmt.Errorf("invoice %s: value %q is not a number", inv.Number, inv.Value)
+			}
+			ret.Invoices = append(ret.Invoices, Invoice{
+				Number:        strings.TrimSpace(inv.Number),
+				Date:          date,
+				Value:         value,
+				ReceiverGSTIN: b.CTIN,
+				ReceiverName:  b.TradeName,
+			})
+		}
+	}
+	if ret.Invoices == nil {
+		return Return{}, fmt.Errorf("the file has no B2B invoices")
+	}
+	return ret, nil
+}
+
+// csvColumns are the B2B CSV columns read, by their header
+var csvColumns = map[string]string{
+	"gstin":  "gstin/uin of recipient",
+	"name":   "receiver name",
+	"number": "invoice number",
+	"date":   "invoice date",
+	"value":  "invoice value",
+}
+
+func parseCSV(data []byte) (Return, error) {
+	cr := csv.NewReader(bytes.NewReader(data))
+	cr.FieldsPerRecord = -1
+	records, err := cr.ReadAll()
+	if err != nil {
+		return Return{}, fmt.Errorf("reading GSTR-1 CSV: %w", err)
+	}
+
+	// The offline tool's export may start with summary rows before the
+	// header
+	header := -1
+	col := make(map[string]int)
+	for i, rec := range records {
+		for j, cell := range rec {
+			for key, name := range csvColumns {
+				if strings.EqualFold(strings.TrimSpace(cell), name) {
+					col[key] = j
+				}
+			}
+		}
+		if len(col) == len(csvColumns) {
+			header = i
+			break
+		}
+		clear(col)
+	}
+	if header < 0 {
+		return Return{}, fmt.Errorf("no B2B header row with Invoice Number, Invoice date and Invoice Value; is this the b2b sheet?")
+	}
+
+	var ret Return
+	seen := make(map[string]bool)
+	for i, rec := range records[header+1:] {
+		line := header + i + 2
+		if len(rec) <= max(col["gstin"], col["name"], col["number"], col["date"], col["value"]) {
+			continue
+		}
+		number := strings.TrimSpace(rec[col["number"]])
+		if number == "" || seen[number] {
+			continue
+		}
+		seen[number] = true
+		date, err := parseDate(rec[col["date"]])
+		if err != nil {
+			return Return{}, fmt.Errorf("line %d: %w", line, err)
+		}
+		value, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(rec[col["value"]]), ",", ""), 64)
+		if err != nil {
+			return Return{}, fmt.Errorf("line %d: invoice value %q is not a number", line, rec[col["value"]])
+		}
+		ret.Invoices = append(ret.Invoices, Invoice{
+			Number:        number,
+			Date:          date,
+			Value:         value,
+			ReceiverGSTIN: strings.TrimSpace(rec[col["gstin"]]),
+			ReceiverName:  strings.TrimSpace(rec[col["name"]]),
+		})
+	}
+	if ret.Invoices == nil {
+		return Return{}, fmt.Errorf("the file has no B2B invoices")
+	}
+	return ret, nil
+}
+
+// dateLayouts are the invoice date formats of the portal and offline tool
+var dateLayouts = []string{"02-01-2006", "02-Jan-2006", "02-Jan-06", "02/01/2006", "2006-01-02"}
+
+func parseDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invoice date %q is not a date like 05-09-2026 or 05-Sep-2026", s)
+}
+
+// Bill is a sale bill in the books
+type Bill struct {
+	ID     int64
+	Number string
+	Date   time.Time
+	Party  string
+	Amount float64
+	Credit bool // credit bills are expected to be filed; cash and card sales may be B2C
+}
+
+// ValueTolerance is how far a filed value may differ from the bill's before
+// it is flagged: invoice values are often rounded to the rupee
+const ValueTolerance = 1.0
+
+// Mismatch is a bill filed with another value or date than in the books
+type Mismatch struct {
+	Invoice Invoice
+	Bill    Bill
+}
+
+// Result is how the filed invoices and the books compare
+type Result struct {
+	Matched    int        // filed as in the books
+	Mismatched []Mismatch // filed with another value or date
+	NotInBooks []Invoice  // filed, but no bill has the number
+	NotFiled   []Bill     // credit bills not filed
+}
+
+// Reconcile matches filed invoices to bills by number, ignoring case and
+// spaces. Bills left over that are not credit bills are not reported, as a
+// cash or card sale to someone without a GSTIN is filed as B2C.
+func Reconcile(filed []Invoice, bills []Bill) Result {
+	byNumber := make(map[string][]int)
+	for i, b := range bills {
+		key := normalize(b.Number)
+		byNumber[key] = append(byNumber[key], i)
+	}
+	used := make([]bool, len(bills))
+
+	var res Result
+	for _, inv := range filed {
+		candidates := byNumber[normalize(inv.Number)]
+		if len(candidates) == 0 {
+			res.NotInBooks = append(res.NotInBooks, inv)
+			continue
+		}
+		// A number used twice, as by a new series each year, is matched to
+		// the unmatched bill nearest the invoice date
+		best := -1
+		for _, i := range candidates {
+			if !used[i] && (best < 0 || apart(bills[i].Date, inv.Date) < apart(bills[best].Date, inv.Date)) {
+				best = i
+			}
+		}
+		if best < 0 {
+			// The same invoice filed twice
+			best = candidates[0]
+		}
+		used[best] = true
+		bill := bills[best]
+		if math.Abs(bill.Amount-inv.Value) > ValueTolerance || !bill.Date.Equal(inv.Date) {
+			res.Mismatched = append(res.Mismatched, Mismatch{Invoice: inv, Bill: bill})
+		} else {
+			res.Matched++
+		}
+	}
+	for i, b := range bills {
+		if !used[i] && b.Credit {
+			res.NotFiled = append(res.NotFiled, b)
+		}
+	}
+	sort.SliceStable(res.NotFiled, func(i, j int) bool { return res.NotFiled[i].Date.Before(res.NotFiled[j].Date) })
+	return res
+}
+
+func normalize(number string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(number), ""))
+}
+
+func apart(a, b time.Time) time.Duration {
+	if a.Before(b) {
+		return b.Sub(a)
+	}
+	return a.Sub(b)
+}
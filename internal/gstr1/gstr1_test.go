@@ -0,0 +1,122 @@
+package gstr1
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParseJSON(t *testing.T) {
+	ret, err := Parse(strings.NewReader(`{
+		"gstin": "23AABFD1234K1ZV",
+		"fp": "092026",
+		"b2b": [
+			{"ctin": "23AAKPS5678L1Z2", "inv": [
+				{"inum": "A260900055", "idt": "05-09-2026", "val": 11538.74},
+				{"inum": " A260900122 ", "idt": "17-09-2026", "val": 4200}
+			]},
+			{"ctin": "23ABCDE9012F1Z3", "trdnm": "SHREE MEDICOS", "inv": [
+				{"inum": "A260900130", "idt": "18-09-2026", "val": 980.5}
+			]}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ret.GSTIN != "23AABFD1234K1ZV" || ret.Period != "2026-09" {
+		t.Errorf("GSTIN, Period = %q, %q", ret.GSTIN, ret.Period)
+	}
+	want := []Invoice{
+		{Number: "A260900055", Date: date(2026, 9, 5), Value: 11538.74, ReceiverGSTIN: "23AAKPS5678L1Z2"},
+		{Number: "A260900122", Date: date(2026, 9, 17), Value: 4200, ReceiverGSTIN: "23AAKPS5678L1Z2"},
+		{Number: "A260900130", Date: date(2026, 9, 18), Value: 980.5, ReceiverGSTIN: "23ABCDE9012F1Z3", ReceiverName: "SHREE MEDICOS"},
+	}
+	if len(ret.Invoices) != len(want) {
+		t.Fatalf("got %d invoices, want %d", len(ret.Invoices), len(want))
+	}
+	for i := range want {
+		if ret.Invoices[i] != want[i] {
+			t.Errorf("invoice %d = %+v, want %+v", i, ret.Invoices[i], want[i])
+		}
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	csv := "\ufeffSummary For B2B(4),,,,,,\n" +
+		"No. of Recipients,,No. of Invoices,,Total Invoice Value,,\n" +
+		"2,,2,,\"12,519.24\",,\n" +
+		"GSTIN/UIN of Recipient,Receiver Name,Invoice Number,Invoice date,Invoice Value,Rate,Taxable Value\n" +
+		"23AAKPS5678L1Z2,SIDDHI MEDICAL HALL,A260900055,05-Sep-2026,\"11,538.74\",12,9000.00\n" +
+		"23AAKPS5678L1Z2,SIDDHI MEDICAL HALL,A260900055,05-Sep-2026,\"11,538.74\",5,1400.00\n" +
+		"23ABCDE9012F1Z3,SHREE MEDICOS,A260900130,18/09/2026,980.50,5,933.81\n" +
+		",,,,,,\n"
+	ret, err := Parse(strings.NewReader(csv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Invoice{
+		{Number: "A260900055", Date: date(2026, 9, 5), Value: 11538.74, ReceiverGSTIN: "23AAKPS5678L1Z2", ReceiverName: "SIDDHI MEDICAL HALL"},
+		{Number: "A260900130", Date: date(2026, 9, 18), Value: 980.5, ReceiverGSTIN: "23ABCDE9012F1Z3", ReceiverName: "SHREE MEDICOS"},
+	}
+	if len(ret.Invoices) != len(want) {
+		t.Fatalf("got %d invoices, want %d", len(ret.Invoices), len(want))
+	}
+	for i := range want {
+		if ret.Invoices[i] != want[i] {
+			t.Errorf("invoice %d = %+v, want %+v", i, ret.Invoices[i], want[i])
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for name, input := range map[string]string{
+		"no header":    "Date,Narration,Amount\n05-09-2026,NEFT,100\n",
+		"bad date":     "GSTIN/UIN of Recipient,Receiver Name,Invoice Number,Invoice date,Invoice Value\nX,Y,A1,2026/09/05,100\n",
+		"bad value":    "GSTIN/UIN of Recipient,Receiver Name,Invoice Number,Invoice date,Invoice Value\nX,Y,A1,05-09-2026,abc\n",
+		"bad period":   `{"fp": "2026-09", "b2b": [{"ctin": "X", "inv": [{"inum": "A1", "idt": "05-09-2026", "val": 1}]}]}`,
+		"no invoices":  `{"fp": "092026", "b2b": []}`,
+		"invalid json": `{"fp": `,
+	} {
+		if _, err := Parse(strings.NewReader(input)); err == nil {
+			t.Errorf("%s: Parse() succeeded", name)
+		}
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	filed := []Invoice{
+		{Number: "A260900055", Date: date(2026, 9, 5), Value: 11539},    // rounded to the rupee
+		{Number: "a26 0900122", Date: date(2026, 9, 17), Value: 4200},   // another case and spacing
+		{Number: "A260900130", Date: date(2026, 9, 18), Value: 1980.50}, // another value
+		{Number: "A260900131", Date: date(2026, 9, 19), Value: 500},     // another date
+		{Number: "A260900199", Date: date(2026, 9, 30), Value: 750},     // not in the books
+		{Number: "7", Date: date(2026, 9, 2), Value: 100},               // a number used again in a new series
+	}
+	bills := []Bill{
+		{ID: 1, Number: "A260900055", Date: date(2026, 9, 5), Amount: 11538.74, Credit: true},
+		{ID: 2, Number: "A260900122", Date: date(2026, 9, 17), Amount: 4200, Credit: true},
+		{ID: 3, Number: "A260900130", Date: date(2026, 9, 18), Amount: 980.50, Credit: true},
+		{ID: 4, Number: "A260900131", Date: date(2026, 9, 20), Amount: 500, Credit: true},
+		{ID: 5, Number: "A260900140", Date: date(2026, 9, 21), Amount: 300, Credit: true}, // not filed
+		{ID: 6, Number: "C260900012", Date: date(2026, 9, 21), Amount: 90},                // a cash sale
+		{ID: 7, Number: "7", Date: date(2025, 9, 2), Amount: 100, Credit: true},           // last year's, not filed
+		{ID: 8, Number: "7", Date: date(2026, 9, 2), Amount: 100, Credit: true},
+	}
+	res := Reconcile(filed, bills)
+	if res.Matched != 3 {
+		t.Errorf("Matched = %d, want 3", res.Matched)
+	}
+	if len(res.Mismatched) != 2 || res.Mismatched[0].Bill.ID != 3 || res.Mismatched[1].Bill.ID != 4 {
+		t.Errorf("Mismatched = %+v, want bills 3 and 4", res.Mismatched)
+	}
+	if len(res.NotInBooks) != 1 || res.NotInBooks[0].Number != "A260900199" {
+		t.Errorf("NotInBooks = %+v, want A260900199", res.NotInBooks)
+	}
+	if len(res.NotFiled) != 2 || res.NotFiled[0].ID != 7 || res.NotFiled[1].ID != 5 {
+		t.Errorf("NotFiled = %+v, want bills 7 and 5", res.NotFiled)
+	}
+}
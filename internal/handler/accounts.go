@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/parser"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// accountStaleDays is how old the latest imported entry of an account may be
+// before its balance is flagged as stale
+const accountStaleDays = 3
+
+// accountID returns the shop bank account an entry was credited to, creating
+// the account the first time it is seen
+func (h *Handler) accountID(ctx context.Context, tx parser.Transaction) (sql.NullInt64, error) {
+	if tx.AccountNumber == "" {
+		return sql.NullInt64{}, nil
+	}
+	account, err := h.queries.UpsertAccount(ctx, sqlc.UpsertAccountParams{
+		Bank:          tx.AccountBank,
+		AccountNumber: tx.AccountNumber,
+		FirmID:        firmID(ctx),
+	})
+	if err != nil {
+		return sql.NullInt64{}, fmt.Errorf("creating account: %w", err)
+	}
+	return sql.NullInt64{Int64: account.ID, Valid: true}, nil
+}
+
+// accountFilter reads the bank account filter of a search or report, along
+// with the current firm's accounts to offer in its selector. An empty or
+// unknown account combines all accounts and is returned as 0.
+func (h *Handler) accountFilter(r *http.Request) (int64, []pages.AccountOption, error) {
+	accounts, err := h.queries.ListAccounts(r.Context(), firmID(r.Context()))
+	if err != nil {
+		return 0, nil, err
+	}
+	requested, _ := strconv.ParseInt(r.FormValue("account"), 10, 64)
+	var selected int64
+	options := make([]pages.AccountOption, len(accounts))
+	for i, a := range accounts {
+		options[i] = pages.AccountOption{ID: a.ID, Label: a.Bank + " " + a.AccountNumber}
+		if a.ID == requested {
+			selected = a.ID
+		}
+	}
+	return selected, options, nil
+}
+
+// accountBalances computes the running balance of each account: the balance
+// of the last statement checked plus the entries credited after it
+func (h *Handler) accountBalances(ctx context.Context) ([]pages.AccountBalance, error) {
+	accounts, err := h.queries.ListAccounts(ctx, firmID(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	today := time.Now()
+	balances := make([]pages.AccountBalance, len(accounts))
+	for i, a := range accounts {
+		id := sql.NullInt64{Int64: a.ID, Valid: true}
+		var since time.Time
+		if a.StatementDate.Valid {
+			since = a.StatementDate.Time
+		}
+
+		credits, err := h.queries.SumAccountCreditsAfter(ctx, sqlc.SumAccountCreditsAfterParams{
+			AccountID:       id,
+			TransactionDate: since,
+		})
+		if err != nil {
+			return nil, err
+		}
+		posCredits, err := h.queries.SumAccountPOSCreditsAfter(ctx, sqlc.SumAccountPOSCreditsAfterParams{
+			AccountID:  id,
+			CreditDate: since,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		balance := pages.AccountBalance{
+			ID:               a.ID,
+			Bank:             a.Bank,
+			AccountNumber:    a.AccountNumber,
+			StatementBalance: a.StatementBalance,
+			StatementDate:    formatNullDate(a.StatementDate),
+			Credits:          credits + posCredits,
+			Balance:          a.StatementBalance + credits + posCredits,
+			Stale:            true,
+		}
+		latest, err := h.queries.GetLatestAccountTransaction(ctx, id)
+		if err == nil {
+			balance.LastEntryDate = latest.TransactionDate.Format("02 Jan 2006")
+			balance.DaysSinceEntry = int(today.Sub(latest.TransactionDate).Hours() / 24)
+			balance.Stale = balance.DaysSinceEntry > accountStaleDays
+		}
+		balances[i] = balance
+	}
+	return balances, nil
+}
+
+// Accounts lists the shop's bank accounts with their running balances
+func (h *Handler) Accounts(w http.ResponseWriter, r *http.Request) {
+	balances, err := h.accountBalances(r.Context())
+	if err != nil {
+		http.Error(w, "Error loading accounts", http.StatusInternalServerError)
+		return
+	}
+	_, options, err := h.accountFilter(r)
+	if err != nil {
+		http.Error(w, "Error loading accounts", http.StatusInternalServerError)
+		return
+	}
+	pages.Accounts(balances, options, time.Now().AddDate(0, 0, -30).Format("2006-01-02"), time.Now().Format("2006-01-02")).Render(r.Context(), w)
+}
+
+// UpdateAccountStatement records the balance of an account as per a bank
+// statement, which the running balance is computed from
+func (h *Handler) UpdateAccountStatement(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid account ID", http.StatusBadRequest)
+		return
+	}
+	balance, err := strconv.ParseFloat(strings.TrimSpace(r.FormValue("balance")), 64)
+	if err != nil {
+		http.Error(w, "Invalid balance", http.StatusBadRequest)
+		return
+	}
+	date, err := time.Parse("2006-01-02", r.FormValue("date"))
+	if err != nil {
+		http.Error(w, "Invalid statement date", http.StatusBadRequest)
+		return
+	}
+
+	err = h.queries.UpdateAccountStatement(r.Context(), sqlc.UpdateAccountStatementParams{
+		StatementBalance: balance,
+		StatementDate:    sql.NullTime{Time: date, Valid: true},
+		ID:               id,
+	})
+	if err != nil {
+		http.Error(w, "Error updating account", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/accounts", http.StatusSeeOther)
+}
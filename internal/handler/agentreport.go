@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// agentCollections totals the receipts each agent collected between two dates
+// by payment mode, with the commission due on them, along with the payment
+// modes collected in and the totals of all agents. Agents who collected
+// nothing are listed too, so the report doubles as the payroll list.
+func (h *Handler) agentCollections(ctx context.Context, fromDate, tillDate time.Time) ([]pages.AgentCollection, []string, pages.AgentCollection, error) {
+	total := pages.AgentCollection{Name: "Total", ByMode: make(map[string]float64)}
+	agents, err := h.queries.ListAgents(ctx, firmID(ctx))
+	if err != nil {
+		return nil, nil, total, err
+	}
+	rows, err := h.queries.ListAgentCollections(ctx, sqlc.ListAgentCollectionsParams{
+		TransactionDate:   fromDate,
+		TransactionDate_2: tillDate,
+		FirmID:            firmID(ctx),
+	})
+	if err != nil {
+		return nil, nil, total, err
+	}
+
+	collections := make([]pages.AgentCollection, len(agents))
+	index := make(map[int64]int, len(agents))
+	for i, a := range agents {
+		collections[i] = pages.AgentCollection{
+			ID:                a.ID,
+			Name:              a.Name,
+			Code:              a.Code,
+			CommissionPercent: a.CommissionPercent,
+			ByMode:            make(map[string]float64),
+		}
+		index[a.ID] = i
+	}
+	seen := make(map[string]bool)
+	var modes []string
+	for _, row := range rows {
+		i, ok := index[row.AgentID]
+		if !ok {
+			continue
+		}
+		c := &collections[i]
+		c.Receipts += row.ReceiptCount
+		c.Amount += row.Total
+		c.ByMode[row.PaymentMode] += row.Total
+		if !seen[row.PaymentMode] {
+			seen[row.PaymentMode] = true
+			modes = append(modes, row.PaymentMode)
+		}
+	}
+	sort.Strings(modes)
+
+	for i := range collections {
+		c := &collections[i]
+		// Commission is due on whole paise of what was collected
+		c.Commission = math.Round(c.Amount*c.CommissionPercent) / 100
+		total.Receipts += c.Receipts
+		total.Amount += c.Amount
+		total.Commission += c.Commission
+		for mode, amount := range c.ByMode {
+			total.ByMode[mode] += amount
+		}
+	}
+	return collections, modes, total, nil
+}
+
+// AgentReport reports the receipts each agent collected in a period, split
+// by payment mode, and the commission due on them
+func (h *Handler) AgentReport(w http.ResponseWriter, r *http.Request) {
+	fromDate, tillDate, year := reportPeriod(r, time.Now().AddDate(0, 0, -30))
+	collections, modes, total, err := h.agentCollections(r.Context(), fromDate, tillDate)
+	if err != nil {
+		http.Error(w, "Error loading collections", http.StatusInternalServerError)
+		return
+	}
+	pages.AgentReport(pages.AgentReportView{
+		FromDate:    fromDate.Format("2006-01-02"),
+		TillDate:    tillDate.Format("2006-01-02"),
+		Year:        year,
+		Modes:       modes,
+		Collections: collections,
+		Total:       total,
+	}).Render(r.Context(), w)
+}
+
+// ExportAgentCommissions downloads the agent collection and commission report
+// of a period as CSV, for payroll
+func (h *Handler) ExportAgentCommissions(w http.ResponseWriter, r *http.Request) {
+	fromDate, tillDate, _ := reportPeriod(r, time.Now().AddDate(0, 0, -30))
+	collections, modes, total, err := h.agentCollections(r.Context(), fromDate, tillDate)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Loading collections: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	filename := fmt.Sprintf("agent-commissions-%s-%s.csv", fromDate.Format("2006-01-02"), tillDate.Format("2006-01-02"))
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	cw := csv.NewWriter(w)
+	header := []string{"Agent", "Code", "Receipts", "Collected"}
+	for _, mode := range modes {
+		header = append(header, pages.ModeLabel(mode))
+	}
+	cw.Write(append(header, "Commission %", "Commission"))
+	for _, c := range append(collections, total) {
+		record := []string{c.Name, c.Code, fmt.Sprintf("%d", c.Receipts), fmt.Sprintf("%.2f", c.Amount)}
+		for _, mode := range modes {
+			record = append(record, fmt.Sprintf("%.2f", c.ByMode[mode]))
+		}
+		percent := ""
+		if c.ID != 0 {
+			percent = fmt.Sprintf("%g", c.CommissionPercent)
+		}
+		cw.Write(append(record, percent, fmt.Sprintf("%.2f", c.Commission)))
+	}
+	cw.Flush()
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExportAgentCommissions(t *testing.T) {
+	h, db := newTestHandler(t)
+	exec(t, db, `INSERT INTO agents (id, name, code, commission_percent, firm_id) VALUES
+		(1, 'RAMESH', 'DDG002035', 2.5, 1), (2, 'SURESH', '', 1, 1), (3, 'MOHAN', '', 0, 1), (4, 'VERMA', '', 5, 2)`)
+	exec(t, db, `INSERT INTO parties (id, name, firm_id) VALUES (1, 'SHARMA MEDICAL', 1), (2, 'VERMA AGENCIES', 2)`)
+	exec(t, db, `INSERT INTO transactions (id, party_id, amount, transaction_date, payment_mode, narration, firm_id) VALUES
+		(1, 1, 1000, '2025-04-02 00:00:00 +0000 UTC', 'CASH', 'CASH/1', 1),
+		(2, 1, 333.33, '2025-04-03 00:00:00 +0000 UTC', 'CHEQUE', 'CHQ/2', 1),
+		(3, 1, 500, '2025-04-04 00:00:00 +0000 UTC', 'CASH', 'CASH/3', 1),
+		(4, 1, 9000, '2025-05-04 00:00:00 +0000 UTC', 'CASH', 'CASH/OUT OF PERIOD', 1),
+		(5, 2, 7000, '2025-04-04 00:00:00 +0000 UTC', 'CASH', 'CASH/OTHER FIRM', 2)`)
+	exec(t, db, `INSERT INTO transaction_agents (transaction_id, agent_id, source) VALUES
+		(1, 1, 'code'), (2, 1, 'manual'), (3, 2, 'location'), (4, 1, 'code'), (5, 4, 'manual')`)
+	target := "/export/agent-commissions.csv?from_date=2025-04-01&till_date=2025-04-30"
+
+	w := serve(h, http.HandlerFunc(h.ExportAgentCommissions), httptest.NewRequest(http.MethodGet, target, nil))
+	if header, _, _ := strings.Cut(w.Body.String(), "\n"); header != "Agent,Code,Receipts,Collected,CASH,CHEQUE,Commission %,Commission" {
+		t.Errorf("header = %q", header)
+	}
+	// Every agent of the firm is listed, with the commission on whole paise
+	want := [][]string{
+		{"MOHAN", "", "0", "0.00", "0.00", "0.00", "0", "0.00"},
+		{"RAMESH", "DDG002035", "2", "1333.33", "1000.00", "333.33", "2.5", "33.33"},
+		{"SURESH", "", "1", "500.00", "500.00", "0.00", "1", "5.00"},
+		{"Total", "", "3", "1833.33", "1500.00", "333.33", "", "38.33"},
+	}
+	if got := exportCSV(t, h, h.ExportAgentCommissions, target); !reflect.DeepEqual(got, want) {
+		t.Errorf("agent commissions:\n%q\nwant\n%q", got, want)
+	}
+}
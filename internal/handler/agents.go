@@ -0,0 +1,350 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/extractor"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// How a receipt was assigned to the agent who collected it on import; one
+// assigned by hand is of the source "manual"
+const (
+	agentSourceCode     = "code"
+	agentSourceLocation = "location"
+)
+
+// agentFor finds the agent who collected a receipt: the one whose code its
+// narration carries or the receipt book gave it, else the one who deposits at
+// its cash deposit location. It returns 0 when no agent matches.
+func agentFor(agents []sqlc.Agent, narration, code, location string) (int64, string) {
+	codes := extractor.ExtractByType(narration, extractor.TypeCashAgentCode)
+	if code != "" {
+		codes = append(codes, code)
+	}
+	for _, a := range agents {
+		for _, code := range codes {
+			if a.Code != "" && strings.EqualFold(a.Code, code) {
+				return a.ID, agentSourceCode
+			}
+		}
+	}
+	location = strings.TrimSpace(location)
+	for _, a := range agents {
+		if a.Location != "" && strings.EqualFold(a.Location, location) {
+			return a.ID, agentSourceLocation
+		}
+	}
+	return 0, ""
+}
+
+// assignAgent assigns a receipt to the agent who collected it, unless it is
+// already assigned, and reports whether an agent was found
+func (h *Handler) assignAgent(ctx context.Context, q *sqlc.Queries, agents []sqlc.Agent, id int64, narration, code, location string) (bool, error) {
+	agentID, source := agentFor(agents, narration, code, location)
+	if agentID == 0 {
+		return false, nil
+	}
+	err := q.AssignTransactionAgent(ctx, sqlc.AssignTransactionAgentParams{
+		TransactionID: id,
+		AgentID:       agentID,
+		Source:        source,
+	})
+	if err != nil {
+		return false, fmt.Errorf("assigning transaction %d to agent: %w", id, err)
+	}
+	return true, nil
+}
+
+// renderAgents renders the agents page with the given form: each agent's
+// receipts in the period, and the receipts of the selected agent
+func (h *Handler) renderAgents(w http.ResponseWriter, r *http.Request, form pages.AgentForm, formError string) {
+	ctx := r.Context()
+	fromDate, tillDate, year := reportPeriod(r, time.Now().AddDate(0, 0, -30))
+
+	view := pages.AgentsView{
+		FromDate: fromDate.Format("2006-01-02"),
+		TillDate: tillDate.Format("2006-01-02"),
+		Year:     year,
+		Form:     form,
+		Error:    formError,
+		Assigned: r.URL.Query().Get("assigned"),
+	}
+	var err error
+	view.Agents, err = h.queries.ListAgentSummaries(ctx, sqlc.ListAgentSummariesParams{
+		TransactionDate:   fromDate,
+		TransactionDate_2: tillDate,
+		FirmID:            firmID(ctx),
+	})
+	if err != nil {
+		http.Error(w, "Error loading agents", http.StatusInternalServerError)
+		return
+	}
+
+	selected, _ := strconv.ParseInt(r.FormValue("agent"), 10, 64)
+	for _, a := range view.Agents {
+		if a.ID != selected {
+			continue
+		}
+		view.Selected = a
+		view.Receipts, err = h.queries.ListAgentReceipts(ctx, sqlc.ListAgentReceiptsParams{
+			AgentID:           a.ID,
+			TransactionDate:   fromDate,
+			TransactionDate_2: tillDate,
+		})
+		if err != nil {
+			http.Error(w, "Error loading receipts", http.StatusInternalServerError)
+			return
+		}
+	}
+	pages.Agents(view).Render(ctx, w)
+}
+
+// Agents lists the field agents with the receipts each collected in a
+// period, with a form to add or edit one
+func (h *Handler) Agents(w http.ResponseWriter, r *http.Request) {
+	var form pages.AgentForm
+	if editStr := r.URL.Query().Get("edit"); editStr != "" {
+		id, err := strconv.ParseInt(editStr, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid agent ID", http.StatusBadRequest)
+			return
+		}
+		agents, err := h.queries.ListAgents(r.Context(), firmID(r.Context()))
+		if err != nil {
+			http.Error(w, "Error loading agents", http.StatusInternalServerError)
+			return
+		}
+		for _, a := range agents {
+			if a.ID == id {
+				form = pages.AgentForm{
+					ID:         a.ID,
+					Name:       a.Name,
+					Code:       a.Code,
+					Location:   a.Location,
+					Commission: strconv.FormatFloat(a.CommissionPercent, 'f', -1, 64),
+				}
+			}
+		}
+		if form.ID == 0 {
+			http.NotFound(w, r)
+			return
+		}
+	}
+	h.renderAgents(w, r, form, "")
+}
+
+// SaveAgent creates or updates an agent. Receipts already imported are
+// assigned by the agent's code and location only when asked to.
+func (h *Handler) SaveAgent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	form := pages.AgentForm{
+		Name:       strings.TrimSpace(r.FormValue("name")),
+		Code:       strings.ToUpper(strings.TrimSpace(r.FormValue("code"))),
+		Location:   strings.ToUpper(strings.TrimSpace(r.FormValue("location"))),
+		Commission: strings.TrimSpace(r.FormValue("commission")),
+	}
+	if id, err := strconv.ParseInt(r.FormValue("id"), 10, 64); err == nil {
+		form.ID = id
+	}
+	if form.Name == "" {
+		h.renderAgents(w, r, form, "Agent name is required.")
+		return
+	}
+	var commission float64
+	if form.Commission != "" {
+		var err error
+		commission, err = strconv.ParseFloat(form.Commission, 64)
+		if err != nil || commission < 0 || commission > 100 {
+			h.renderAgents(w, r, form, "Commission must be a percentage from 0 to 100.")
+			return
+		}
+	}
+
+	var err error
+	if form.ID > 0 {
+		err = h.queries.UpdateAgent(ctx, sqlc.UpdateAgentParams{
+			Name:              form.Name,
+			Code:              form.Code,
+			Location:          form.Location,
+			CommissionPercent: commission,
+			ID:                form.ID,
+			FirmID:            firmID(ctx),
+		})
+	} else {
+		_, err = h.queries.CreateAgent(ctx, sqlc.CreateAgentParams{
+			FirmID:            firmID(ctx),
+			Name:              form.Name,
+			Code:              form.Code,
+			Location:          form.Location,
+			CommissionPercent: commission,
+		})
+	}
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			h.renderAgents(w, r, form, "An agent named "+form.Name+" already exists.")
+			return
+		}
+		h.renderAgents(w, r, form, "Error saving agent: "+err.Error())
+		return
+	}
+	http.Redirect(w, r, "/agents", http.StatusSeeOther)
+}
+
+// DeleteAgent removes an agent, leaving the receipts they collected
+// unassigned
+func (h *Handler) DeleteAgent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid agent ID", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		http.Error(w, "Error deleting agent", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	q := h.queries.WithTx(tx)
+	if err := q.DeleteAgentTransactions(ctx, sqlc.DeleteAgentTransactionsParams{ID: id, FirmID: firmID(ctx)}); err != nil {
+		http.Error(w, "Error deleting agent", http.StatusInternalServerError)
+		return
+	}
+	if err := q.DeleteAgent(ctx, sqlc.DeleteAgentParams{ID: id, FirmID: firmID(ctx)}); err != nil {
+		http.Error(w, "Error deleting agent", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error deleting agent", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/agents", http.StatusSeeOther)
+}
+
+// AssignAgents assigns the receipts imported before their agent was set up,
+// or before the agent's code or location was, by agent code and deposit
+// location. Receipts already assigned are left as they are.
+func (h *Handler) AssignAgents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	agents, err := h.queries.ListAgents(ctx, firmID(ctx))
+	if err != nil {
+		http.Error(w, "Error loading agents", http.StatusInternalServerError)
+		return
+	}
+	receipts, err := h.queries.ListUnassignedAgentReceipts(ctx, firmID(ctx))
+	if err != nil {
+		http.Error(w, "Error loading receipts", http.StatusInternalServerError)
+		return
+	}
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		http.Error(w, "Error assigning receipts", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	q := h.queries.WithTx(tx)
+	assigned := 0
+	for _, rc := range receipts {
+		ok, err := h.assignAgent(ctx, q, agents, rc.ID, rc.Narration.String, "", rc.CashBankLocation.String)
+		if err != nil {
+			http.Error(w, "Error assigning receipts", http.StatusInternalServerError)
+			return
+		}
+		if ok {
+			assigned++
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error assigning receipts", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/agents?assigned=%d", assigned), http.StatusSeeOther)
+}
+
+// UpdateTransactionAgent assigns a receipt to an agent by hand from the party
+// page, or clears its agent when none is chosen
+func (h *Handler) UpdateTransactionAgent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	txn, err := h.firmTransaction(r)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.FormValue("agent") == "" {
+		err = h.queries.ClearTransactionAgent(ctx, txn.ID)
+	} else {
+		agentID, perr := strconv.ParseInt(r.FormValue("agent"), 10, 64)
+		if perr != nil || !h.firmAgent(ctx, agentID) {
+			http.Error(w, "Invalid agent", http.StatusBadRequest)
+			return
+		}
+		err = h.queries.SetTransactionAgent(ctx, sqlc.SetTransactionAgentParams{TransactionID: txn.ID, AgentID: agentID})
+	}
+	if err != nil {
+		http.Error(w, "Error saving agent", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/party/%d", txn.PartyID), http.StatusSeeOther)
+}
+
+// firmAgent reports whether id is an agent of the current firm
+func (h *Handler) firmAgent(ctx context.Context, id int64) bool {
+	agents, err := h.queries.ListAgents(ctx, firmID(ctx))
+	if err != nil {
+		return false
+	}
+	for _, a := range agents {
+		if a.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// copyTransactionAgent assigns a share of a split receipt to the agent the
+// receipt was assigned to, if any
+func copyTransactionAgent(ctx context.Context, q *sqlc.Queries, fromID, toID int64) error {
+	ta, err := q.GetTransactionAgent(ctx, fromID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return q.AssignTransactionAgent(ctx, sqlc.AssignTransactionAgentParams{
+		TransactionID: toID,
+		AgentID:       ta.AgentID,
+		Source:        ta.Source,
+	})
+}
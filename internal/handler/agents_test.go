@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"suspense.durgadawaghar.com/internal/views"
+)
+
+func TestAgents(t *testing.T) {
+	h, db := newTestHandler(t)
+	ctx := views.WithFirm(context.Background(), views.Firm{ID: 1, Name: "Durga Dawa Ghar"}, nil)
+	post := func(handler http.HandlerFunc, target string, form url.Values) string {
+		t.Helper()
+		w := serve(h, handler, postForm(target, form))
+		if w.Code != http.StatusSeeOther {
+			t.Fatalf("%s: status %d: %s", target, w.Code, w.Body)
+		}
+		return w.Header().Get("Location")
+	}
+	// agentOf returns the agent of the receipt whose narration ends with end
+	agentOf := func(end string) float64 {
+		return count(t, db, "SELECT a.agent_id FROM transaction_agents a JOIN transactions t ON t.id = a.transaction_id WHERE t.narration LIKE ?", "%"+end)
+	}
+
+	for name, tt := range map[string]struct {
+		form    url.Values
+		problem string
+	}{
+		"no name":               {url.Values{"name": {" "}}, "name is required"},
+		"too much commission":   {url.Values{"name": {"RAMESH"}, "commission": {"120"}}, "from 0 to 100"},
+		"a negative commission": {url.Values{"name": {"RAMESH"}, "commission": {"-1"}}, "from 0 to 100"},
+	} {
+		if body := serve(h, http.HandlerFunc(h.SaveAgent), postForm("/agents/save", tt.form)).Body.String(); !strings.Contains(body, tt.problem) {
+			t.Errorf("saving an agent with %s: %s", name, body)
+		}
+	}
+
+	// Receipts imported after an agent is set up are assigned by the code
+	// the receipt book gives them
+	post(h.SaveAgent, "/agents/save", url.Values{"name": {"RAMESH"}, "code": {" ddg002035 "}, "commission": {"2.5"}})
+	ramesh := count(t, db, "SELECT id FROM agents WHERE name = 'RAMESH' AND code = 'DDG002035' AND commission_percent = 2.5 AND firm_id = 1")
+	if ramesh == 0 {
+		t.Fatal("agent not saved")
+	}
+	book := "Dec 20 SANDHYA MEDICAL STORE TIRWA 1200.00\nICICI 000105001234 1200.00\nBY CASH -733300 TIRWA Ag. DDG002035\n\n" +
+		"Dec 21 GUPTA STORES UNNAO 800.00\nICICI 000105001234 800.00\nBY CASH -733301 UNNAO Ag. DDG009999"
+	if _, err := h.importReceiptBook(ctx, book, 2025); err != nil {
+		t.Fatal(err)
+	}
+	if got := agentOf("733300 TIRWA"); got != ramesh {
+		t.Errorf("receipt with the agent's code assigned to agent %v, want %v", got, ramesh)
+	}
+	if n := count(t, db, "SELECT COUNT(*) FROM transaction_agents WHERE source = 'code'"); n != 1 {
+		t.Errorf("%v receipts assigned by code, want 1", n)
+	}
+
+	// Receipts imported before their agent was set up are assigned by
+	// deposit location when asked to
+	exec(t, db, `INSERT INTO transactions (party_id, amount, transaction_date, payment_mode, narration, cash_bank_location, firm_id)
+		SELECT party_id, 300, transaction_date, 'CASH', 'BY CASH -733301 UNNAO (UP)', 'UNNAO (UP)', 1 FROM transactions WHERE narration LIKE '%733301%'`)
+	post(h.SaveAgent, "/agents/save", url.Values{"name": {"SURESH"}, "location": {"unnao (up)"}})
+	suresh := count(t, db, "SELECT id FROM agents WHERE name = 'SURESH'")
+	if loc := post(h.AssignAgents, "/agents/assign", nil); loc != "/agents?assigned=1" {
+		t.Errorf("assigning earlier receipts redirects to %s", loc)
+	}
+	if got := agentOf("UNNAO (UP)"); got != suresh {
+		t.Errorf("earlier receipt assigned to agent %v, want %v", got, suresh)
+	}
+
+	// By hand a receipt goes to any agent of the firm
+	exec(t, db, `INSERT INTO agents (id, name, firm_id) VALUES (99, 'OTHER FIRM AGENT', 2)`)
+	gupta := count(t, db, "SELECT id FROM transactions WHERE narration LIKE '%733301 UNNAO'")
+	id := strconv.FormatFloat(gupta, 'f', -1, 64)
+	if w := serve(h, http.HandlerFunc(h.UpdateTransactionAgent), postForm("/transactions/agent", url.Values{"id": {id}, "agent": {"99"}})); w.Code != http.StatusBadRequest {
+		t.Errorf("assigning to another firm's agent: status %d", w.Code)
+	}
+	post(h.UpdateTransactionAgent, "/transactions/agent", url.Values{"id": {id}, "agent": {strconv.FormatFloat(ramesh, 'f', -1, 64)}})
+	if got := agentOf("733301 UNNAO"); got != ramesh {
+		t.Errorf("receipt assigned by hand to agent %v, want %v", got, ramesh)
+	}
+	if n := count(t, db, "SELECT COUNT(*) FROM transaction_agents WHERE source = 'manual'"); n != 1 {
+		t.Errorf("assignment by hand not marked manual")
+	}
+
+	// Deleting an agent leaves their receipts unassigned
+	post(h.DeleteAgent, "/agents/delete", url.Values{"id": {strconv.FormatFloat(ramesh, 'f', -1, 64)}})
+	if n := count(t, db, "SELECT COUNT(*) FROM transaction_agents WHERE agent_id = ?", ramesh); n != 0 {
+		t.Errorf("%v receipts still assigned after their agent was deleted", n)
+	}
+}
@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// allocationEditor lists what of a party's credit bills and receipts is left
+// to allocate by hand, oldest first, and the allocations already made by hand.
+// Allocations by hand of bills or receipts no longer the party's, or of
+// bounced cheques, are left out as allocateReceipts leaves them out.
+func allocationEditor(bills []sqlc.SaleBill, receipts []sqlc.Transaction, manual []sqlc.BillAllocation) pages.AllocationEditor {
+	var editor pages.AllocationEditor
+	billOpen := make(map[int64]float64, len(bills))
+	billNumber := make(map[int64]string, len(bills))
+	for _, b := range bills {
+		billOpen[b.ID] = b.Amount
+		billNumber[b.ID] = b.BillNumber
+	}
+	receiptOpen := make(map[int64]float64, len(receipts))
+	receiptLabel := make(map[int64]string, len(receipts))
+	for _, t := range receipts {
+		receiptOpen[t.ID] = t.Amount
+		receiptLabel[t.ID] = fmt.Sprintf("%s %s ₹%.2f", t.TransactionDate.Format("02 Jan 2006"), t.PaymentMode.String, t.Amount)
+	}
+
+	for _, m := range manual {
+		bill, okBill := billNumber[m.SaleBillID]
+		receipt, okReceipt := receiptLabel[m.TransactionID]
+		if !okBill || !okReceipt {
+			continue
+		}
+		billOpen[m.SaleBillID] -= m.Amount
+		receiptOpen[m.TransactionID] -= m.Amount
+		editor.ByHand = append(editor.ByHand, pages.ManualAllocation{
+			ID:      m.ID,
+			Bill:    bill,
+			Receipt: receipt,
+			Amount:  m.Amount,
+		})
+	}
+
+	for _, b := range bills {
+		if open := billOpen[b.ID]; open > billSettledTolerance {
+			editor.Bills = append(editor.Bills, pages.AllocationItem{
+				ID:     b.ID,
+				Label:  b.BillNumber,
+				Date:   b.BillDate.Format("02 Jan 2006"),
+				Amount: b.Amount,
+				Open:   open,
+			})
+		}
+	}
+	for _, t := range receipts {
+		if open := receiptOpen[t.ID]; open > billSettledTolerance {
+			editor.Receipts = append(editor.Receipts, pages.AllocationItem{
+				ID:     t.ID,
+				Label:  t.PaymentMode.String,
+				Date:   t.TransactionDate.Format("02 Jan 2006"),
+				Amount: t.Amount,
+				Open:   open,
+			})
+		}
+	}
+	return editor
+}
+
+// openAllocation finds the bill or receipt of id among items, with what is
+// left of it to allocate
+func openAllocation(items []pages.AllocationItem, id int64) (pages.AllocationItem, bool) {
+	for _, item := range items {
+		if item.ID == id {
+			return item, true
+		}
+	}
+	return pages.AllocationItem{}, false
+}
+
+// AllocateBill applies part of a party's receipt to one of its credit bills
+// by hand, no more than is left to allocate of either
+func (h *Handler) AllocateBill(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	partyID, err := strconv.ParseInt(r.FormValue("party_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid party ID", http.StatusBadRequest)
+		return
+	}
+	party, err := h.queries.GetPartyByID(ctx, partyID)
+	if err != nil || party.FirmID != firmID(ctx) {
+		http.NotFound(w, r)
+		return
+	}
+	billID, _ := strconv.ParseInt(r.FormValue("bill_id"), 10, 64)
+	receiptID, _ := strconv.ParseInt(r.FormValue("transaction_id"), 10, 64)
+	amount, err := strconv.ParseFloat(r.FormValue("amount"), 64)
+	if err != nil || amount <= 0 {
+		http.Error(w, "Amount must be a positive amount", http.StatusBadRequest)
+		return
+	}
+	amount = math.Round(amount*100) / 100
+
+	bills, receipts, manual, err := h.partyAllocations(ctx, partyID)
+	if err != nil {
+		http.Error(w, "Error loading allocations", http.StatusInternalServerError)
+		return
+	}
+	editor := allocationEditor(bills, receipts, manual)
+	bill, ok := openAllocation(editor.Bills, billID)
+	if !ok {
+		http.Error(w, "Choose a bill of the party that is not fully allocated", http.StatusBadRequest)
+		return
+	}
+	receipt, ok := openAllocation(editor.Receipts, receiptID)
+	if !ok {
+		http.Error(w, "Choose a receipt of the party that is not fully allocated", http.StatusBadRequest)
+		return
+	}
+	if amount > bill.Open+billSettledTolerance {
+		http.Error(w, fmt.Sprintf("Bill %s has only ₹%.2f left to allocate", bill.Label, bill.Open), http.StatusBadRequest)
+		return
+	}
+	if amount > receipt.Open+billSettledTolerance {
+		http.Error(w, fmt.Sprintf("The receipt of %s has only ₹%.2f left to allocate", receipt.Date, receipt.Open), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.queries.AllocateBillReceipt(ctx, sqlc.AllocateBillReceiptParams{
+		SaleBillID:    bill.ID,
+		TransactionID: receipt.ID,
+		Amount:        min(amount, bill.Open, receipt.Open),
+	}); err != nil {
+		http.Error(w, "Error saving allocation", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/party/%d?tab=%s", partyID, pages.PartyTabAllocations), http.StatusSeeOther)
+}
+
+// RemoveBillAllocation undoes an allocation made by hand, leaving the receipt
+// to be applied first in, first out again
+func (h *Handler) RemoveBillAllocation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid allocation ID", http.StatusBadRequest)
+		return
+	}
+	partyID, _ := strconv.ParseInt(r.FormValue("party_id"), 10, 64)
+	if err := h.queries.DeleteBillAllocation(ctx, sqlc.DeleteBillAllocationParams{ID: id, FirmID: firmID(ctx)}); err != nil {
+		http.Error(w, "Error removing allocation", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/party/%d?tab=%s", partyID, pages.PartyTabAllocations), http.StatusSeeOther)
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestAllocateBill(t *testing.T) {
+	h, db := newTestHandler(t)
+	exec(t, db, `INSERT INTO parties (id, name, firm_id) VALUES (1, 'SHARMA MEDICAL', 1), (2, 'GUPTA STORES', 1), (3, 'VERMA AGENCIES', 2)`)
+	exec(t, db, `INSERT INTO sale_bills (id, bill_number, bill_date, party_name, amount, is_cash_sale, party_id, firm_id) VALUES
+		(1, 'A-1', '2025-04-01 00:00:00 +0000 UTC', 'SHARMA MEDICAL', 1000, FALSE, 1, 1),
+		(2, 'A-2', '2025-04-02 00:00:00 +0000 UTC', 'SHARMA MEDICAL', 500, FALSE, 1, 1),
+		(3, 'A-3', '2025-04-03 00:00:00 +0000 UTC', 'GUPTA STORES', 800, FALSE, 2, 1),
+		(4, 'A-4', '2025-04-04 00:00:00 +0000 UTC', 'SHARMA MEDICAL', 2000, FALSE, 1, 1),
+		(5, 'B-1', '2025-04-04 00:00:00 +0000 UTC', 'VERMA AGENCIES', 900, FALSE, 3, 2)`)
+	exec(t, db, `INSERT INTO transactions (id, party_id, amount, transaction_date, payment_mode, narration, firm_id) VALUES
+		(1, 1, 1500, '2025-04-10 00:00:00 +0000 UTC', 'NEFT', 'NEFT/1', 1),
+		(2, 1, 500, '2025-04-11 00:00:00 +0000 UTC', 'UPI', 'UPI/2', 1),
+		(3, 2, 800, '2025-04-12 00:00:00 +0000 UTC', 'UPI', 'UPI/3', 1),
+		(4, 3, 900, '2025-04-12 00:00:00 +0000 UTC', 'UPI', 'UPI/4', 2)`)
+	// A-2 is paid in full by the receipt of 11 Apr
+	exec(t, db, `INSERT INTO bill_allocations (sale_bill_id, transaction_id, amount) VALUES (2, 2, 500)`)
+	allocate := func(party, bill, receipt, amount string) (int, string) {
+		w := serve(h, http.HandlerFunc(h.AllocateBill), postForm("/party/allocations/save",
+			url.Values{"party_id": {party}, "bill_id": {bill}, "transaction_id": {receipt}, "amount": {amount}}))
+		return w.Code, w.Body.String()
+	}
+
+	if status, body := allocate("1", "1", "1", "400"); status != http.StatusSeeOther {
+		t.Fatalf("allocation refused: %d\n%s", status, body)
+	}
+	if n := count(t, db, "SELECT amount FROM bill_allocations WHERE sale_bill_id = 1 AND transaction_id = 1"); n != 400 {
+		t.Fatalf("allocated %v, want 400", n)
+	}
+
+	for _, tt := range []struct {
+		name                         string
+		party, bill, receipt, amount string
+		status                       int
+		problem                      string
+	}{
+		{"more than the bill has open", "1", "1", "1", "600.02", http.StatusBadRequest, "Bill A-1 has only ₹600.00 left"},
+		{"more than the receipt has open", "1", "4", "1", "1100.02", http.StatusBadRequest, "has only ₹1100.00 left"},
+		{"a bill paid in full", "1", "2", "1", "1", http.StatusBadRequest, "Choose a bill"},
+		{"a receipt allocated in full", "1", "4", "2", "1", http.StatusBadRequest, "Choose a receipt"},
+		{"another party's bill", "1", "3", "1", "1", http.StatusBadRequest, "Choose a bill"},
+		{"another party's receipt", "1", "4", "3", "1", http.StatusBadRequest, "Choose a receipt"},
+		{"a party of another firm", "3", "5", "4", "1", http.StatusNotFound, "not found"},
+		{"no amount", "1", "1", "1", "0", http.StatusBadRequest, "positive amount"},
+	} {
+		status, body := allocate(tt.party, tt.bill, tt.receipt, tt.amount)
+		if status != tt.status || !strings.Contains(body, tt.problem) {
+			t.Errorf("allocating %s: status %d, want %d with %q:\n%s", tt.name, status, tt.status, tt.problem, body)
+		}
+	}
+	if n := count(t, db, "SELECT COUNT(*) FROM bill_allocations"); n != 2 {
+		t.Errorf("refused allocations left %v allocations, want 2", n)
+	}
+
+	// A paisa over what is open is taken as rounding and allocates what is open
+	if status, body := allocate("1", "1", "1", "600.01"); status != http.StatusSeeOther {
+		t.Fatalf("allocating the rest refused: %d\n%s", status, body)
+	}
+	if n := count(t, db, "SELECT amount FROM bill_allocations WHERE sale_bill_id = 1 AND transaction_id = 1"); n != 1000 {
+		t.Errorf("allocated %v of bill A-1, want 1000", n)
+	}
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"suspense.durgadawaghar.com/internal/extractor"
+	"suspense.durgadawaghar.com/internal/matcher"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// assignOptions lists the firm's parties for assigning a narration to, the
+// matched parties first in match order
+func (h *Handler) assignOptions(ctx context.Context, results []matcher.MatchResult) ([]pages.AssignPartyOption, error) {
+	parties, err := h.queries.ListParties(ctx, firmID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	// A match may combine several parties of the same name
+	var matched []int64
+	for _, res := range results {
+		matched = append(matched, res.PartyIDs...)
+	}
+	rank := func(o pages.AssignPartyOption) int {
+		if i := slices.Index(matched, o.ID); i >= 0 {
+			return i
+		}
+		return len(matched)
+	}
+	options := make([]pages.AssignPartyOption, 0, len(parties))
+	for _, p := range parties {
+		options = append(options, pages.AssignPartyOption{
+			PartyOption: pages.PartyOption{ID: p.ID, Name: p.Name, Location: p.Location.String},
+			Matched:     slices.Contains(matched, p.ID),
+		})
+	}
+	slices.SortStableFunc(options, func(a, b pages.AssignPartyOption) int { return rank(a) - rank(b) })
+	return options, nil
+}
+
+// AssignNarration assigns a searched narration to a party by hand, attaching
+// the identifiers ticked from those extracted from it. Identifiers already
+// another party's stay with it like on import, the unique ones recorded as
+// conflicts to review.
+func (h *Handler) AssignNarration(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+
+	narration := r.FormValue("narration")
+	id, err := strconv.ParseInt(r.FormValue("party_id"), 10, 64)
+	if err != nil {
+		w.Write([]byte(`<div class="error">Choose the party to assign the narration to.</div>`))
+		return
+	}
+	id, _ = h.survivingParty(ctx, id)
+	party, err := h.queries.GetPartyByID(ctx, id)
+	if err != nil || party.FirmID != firmID(ctx) {
+		w.Write([]byte(`<div class="error">Party not found.</div>`))
+		return
+	}
+
+	// Only identifiers of the narration itself can be ticked
+	ticked := r.Form["identifier"]
+	var assigned []pages.AssignedIdentifier
+	for _, identifier := range extractor.Extract(narration) {
+		if !slices.Contains(ticked, string(identifier.Type)+":"+identifier.Value) {
+			continue
+		}
+		linked, ok, err := h.linkIdentifier(ctx, party.ID, identifier, identifierCauseAssign, narration)
+		if err != nil {
+			w.Write([]byte(fmt.Sprintf(`<div class="error">Error attaching %s %s: %s</div>`,
+				html.EscapeString(string(identifier.Type)), html.EscapeString(identifier.Value), html.EscapeString(err.Error()))))
+			return
+		}
+		a := pages.AssignedIdentifier{Type: string(identifier.Type), Value: identifier.Value, Attached: ok}
+		if !ok {
+			a.Conflict = identifier.Type.Unique()
+			a.OtherParty = fmt.Sprintf("party %d", linked.PartyID)
+			if other, err := h.queries.GetPartyByID(ctx, linked.PartyID); err == nil {
+				a.OtherParty = other.Name
+			}
+		}
+		assigned = append(assigned, a)
+	}
+	pages.AssignNarrationResult(party.ID, party.Name, assigned).Render(ctx, w)
+}
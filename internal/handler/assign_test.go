@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestAssignNarration(t *testing.T) {
+	h, db := newTestHandler(t)
+	exec(t, db, `INSERT INTO parties (id, name, location, firm_id) VALUES (1, 'ANURAG MEDICAL', 'KANPUR', 1), (2, 'YADAV PHARMA', 'UNNAO', 1), (3, 'VERMA AGENCIES', '', 2)`)
+	exec(t, db, `INSERT INTO identifiers (party_id, type, value, firm_id) VALUES (2, 'phone', '9450852076', 1)`)
+	assign := func(form url.Values) string {
+		t.Helper()
+		w := serve(h, http.HandlerFunc(h.AssignNarration), postForm("/search/assign", form))
+		if w.Code != http.StatusOK {
+			t.Fatalf("assign: status = %d: %s", w.Code, w.Body)
+		}
+		return w.Body.String()
+	}
+
+	// The search offers the firm's parties, the matched first, with the
+	// strong identifiers ticked
+	imps := "MMT/IMPS/529816026379/OK/ANURAGYADA/STATE BANK"
+	body := serve(h, http.HandlerFunc(h.Search), postForm("/search", url.Values{"narration": {imps}})).Body.String()
+	if !strings.Contains(body, "Assign to a Party") || !strings.Contains(body, `value="imps_name:ANURAGYADA"`) ||
+		strings.Contains(body, `value="imps_name:ANURAGYADA" checked`) || strings.Contains(body, "VERMA AGENCIES") {
+		t.Errorf("search lacks the assign form or offers the wrong parties:\n%s", body)
+	}
+	body = serve(h, http.HandlerFunc(h.Search), postForm("/search", url.Values{"narration": {"UPI/9450852076@YBL/PAYMENT"}})).Body.String()
+	if !strings.Contains(body, `value="upi_vpa:9450852076@YBL" checked`) || strings.Index(body, "YADAV PHARMA") > strings.Index(body, "ANURAG MEDICAL") {
+		t.Errorf("strong identifier not ticked or matched party not first:\n%s", body)
+	}
+
+	if body := assign(url.Values{"narration": {imps}, "party_id": {"3"}}); !strings.Contains(body, "Party not found") {
+		t.Errorf("assigning to another firm's party: %s", body)
+	}
+
+	// Only the ticked identifiers of the narration itself are attached
+	body = assign(url.Values{"narration": {imps}, "party_id": {"1"}, "identifier": {"imps_name:ANURAGYADA", "upi_vpa:ELSE@YBL"}})
+	if !strings.Contains(body, "ANURAG MEDICAL") || !strings.Contains(body, "attached") {
+		t.Errorf("assign result: %s", body)
+	}
+	for query, want := range map[string]float64{
+		"SELECT COUNT(*) FROM identifiers WHERE party_id = 1 AND type = 'imps_name' AND value = 'ANURAGYADA'":                                  1,
+		"SELECT COUNT(*) FROM identifiers WHERE value IN ('ELSE@YBL', 'STATE BANK OF INDIA')":                                                  0,
+		"SELECT COUNT(*) FROM identifier_history WHERE party_id = 1 AND value = 'ANURAGYADA' AND cause = 'assign' AND detail = '" + imps + "'": 1,
+	} {
+		if n := count(t, db, query); n != want {
+			t.Errorf("%s = %v, want %v", query, n, want)
+		}
+	}
+
+	// Another party's unique identifier stays with it as a conflict to review
+	body = assign(url.Values{"narration": {"UPI/9450852076@YBL/PAYMENT"}, "party_id": {"1"}, "identifier": {"phone:9450852076"}})
+	if !strings.Contains(body, "stays with YADAV PHARMA") {
+		t.Errorf("assigning another party's phone: %s", body)
+	}
+	if n := count(t, db, "SELECT COUNT(*) FROM identifier_conflicts WHERE value = '9450852076' AND claimed_party_id = 1"); n != 1 {
+		t.Errorf("conflict not recorded")
+	}
+	if n := count(t, db, "SELECT party_id FROM identifiers WHERE value = '9450852076'"); n != 2 {
+		t.Errorf("phone moved to party %v", n)
+	}
+}
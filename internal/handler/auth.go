@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+)
+
+// publicHandler serves a route readable without signing in, such as a shared
+// statement link
+type publicHandler struct {
+	http.HandlerFunc
+}
+
+// Public marks the handler of a route anyone may read without signing in,
+// such as a statement link shared with a party or the stylesheets its page
+// needs. Wrap the route where it is registered.
+func Public(h http.HandlerFunc) http.Handler {
+	return publicHandler{h}
+}
+
+// Auth asks for the user and password over HTTP basic auth before serving
+// any route of mux but those registered with Public, for a server reachable
+// from the internet
+func Auth(next http.Handler, mux *http.ServeMux, user, password string) http.Handler {
+	want := credentialsHash(user, password)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if route, _ := mux.Handler(r); isPublic(route) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		u, p, ok := r.BasicAuth()
+		if got := credentialsHash(u, p); !ok || subtle.ConstantTimeCompare(got[:], want[:]) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="Suspense", charset="UTF-8"`)
+			http.Error(w, "Sign in to use this server", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// credentialsHash hashes a user and password together, so comparing them
+// takes as long whatever their lengths
+func credentialsHash(user, password string) [sha256.Size]byte {
+	return sha256.Sum256([]byte(user + "\x00" + password))
+}
+
+// isPublic reports whether a route was registered with Public
+func isPublic(route http.Handler) bool {
+	_, ok := route.(publicHandler)
+	return ok
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuth(t *testing.T) {
+	mux := http.NewServeMux()
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	mux.HandleFunc("/backup", ok)
+	mux.HandleFunc("/graphql", ok)
+	mux.Handle("/s/", Public(ok))
+	mux.Handle("/static/", Public(ok))
+	app := Auth(mux, mux, "accounts", "s3cret:pass")
+
+	tests := []struct {
+		path, user, password string
+		want                 int
+	}{
+		{"/backup", "", "", http.StatusUnauthorized},
+		{"/graphql", "accounts", "wrong", http.StatusUnauthorized},
+		{"/graphql", "sales", "s3cret:pass", http.StatusUnauthorized},
+		{"/backup", "accounts", "s3cret", http.StatusUnauthorized},
+		{"/backup", "accounts", "s3cret:pass", http.StatusOK},
+		{"/s/abc123", "", "", http.StatusOK},
+		{"/static/style.css", "", "", http.StatusOK},
+		{"/nowhere", "", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.user != "" {
+			r.SetBasicAuth(tt.user, tt.password)
+		}
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, r)
+		if w.Code != tt.want {
+			t.Errorf("%s as %q = %d, want %d", tt.path, tt.user, w.Code, tt.want)
+		}
+		if w.Code == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%s as %q asks for no sign-in", tt.path, tt.user)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+)
+
+// DownloadBackup takes a consistent snapshot of the whole database, every
+// firm included, and streams it as a download. The snapshot is recorded so
+// the dashboard can show when the last one was taken.
+func (h *Handler) DownloadBackup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	dir, err := os.MkdirTemp("", "suspense-backup-")
+	if err != nil {
+		http.Error(w, "Error creating backup", http.StatusInternalServerError)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	filename := "suspense-" + time.Now().Format("2006-01-02-1504") + ".db"
+	path := filepath.Join(dir, filename)
+	size, err := h.backupTo(ctx, path)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error creating backup: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		http.Error(w, "Error reading backup", http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", "application/vnd.sqlite3")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", size))
+	io.Copy(w, file)
+}
+
+// backupTo writes a snapshot of the whole database to path and records it
+func (h *Handler) backupTo(ctx context.Context, path string) (int64, error) {
+	// VACUUM INTO writes a compacted copy from a single read transaction, so
+	// imports running meanwhile are either wholly in it or not at all. sqlc
+	// cannot parse it, so it runs on the database directly.
+	if _, err := h.db.ExecContext(ctx, "VACUUM INTO ?", path); err != nil {
+		return 0, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := h.queries.RecordBackup(ctx, sqlc.RecordBackupParams{Filename: filepath.Base(path), SizeBytes: info.Size()}); err != nil {
+		return 0, fmt.Errorf("recording backup: %w", err)
+	}
+	return info.Size(), nil
+}
+
+// databaseFile returns the path of the main database file
+func (h *Handler) databaseFile(ctx context.Context) (string, error) {
+	var file string
+	if err := h.db.QueryRowContext(ctx, "SELECT file FROM pragma_database_list WHERE name = 'main'").Scan(&file); err != nil {
+		return "", fmt.Errorf("finding database file: %w", err)
+	}
+	if file == "" {
+		return "", fmt.Errorf("the database is not a file")
+	}
+	return file, nil
+}
+
+// BackUpLocally takes a snapshot of the whole database into dir, or the
+// backups directory beside the database when dir is empty, for the scheduled
+// backup job. The oldest scheduled snapshots there beyond keep are deleted;
+// those taken before imports are left alone. It returns the snapshot's path.
+func (h *Handler) BackUpLocally(ctx context.Context, dir string, keep int) (string, error) {
+	if dir == "" {
+		mainFile, err := h.databaseFile(ctx)
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(filepath.Dir(mainFile), "backups")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "suspense-scheduled-"+time.Now().Format("2006-01-02-150405")+".db")
+	if _, err := h.backupTo(ctx, path); err != nil {
+		return "", err
+	}
+
+	// Snapshot names sort by when they were taken
+	old, err := filepath.Glob(filepath.Join(dir, "suspense-scheduled-*.db"))
+	if err != nil {
+		return path, err
+	}
+	sort.Strings(old)
+	for keep > 0 && len(old) > keep {
+		if err := os.Remove(old[0]); err != nil {
+			return path, fmt.Errorf("deleting old backup: %w", err)
+		}
+		old = old[1:]
+	}
+	return path, nil
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDownloadBackup(t *testing.T) {
+	h, db := newTestHandler(t)
+	exec(t, db, `INSERT INTO parties (id, name, firm_id) VALUES (1, 'SHARMA MEDICAL', 1), (2, 'VERMA AGENCIES', 2)`)
+	dashboard := func() string {
+		return serve(h, http.HandlerFunc(h.Dashboard), httptest.NewRequest(http.MethodGet, "/dashboard", nil)).Body.String()
+	}
+
+	if body := dashboard(); !strings.Contains(body, "No backup has been taken yet") {
+		t.Errorf("dashboard before any backup:\n%s", body)
+	}
+
+	w := serve(h, http.HandlerFunc(h.DownloadBackup), httptest.NewRequest(http.MethodPost, "/backup", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	if d := w.Header().Get("Content-Disposition"); !strings.HasPrefix(d, `attachment; filename="suspense-`) {
+		t.Errorf("Content-Disposition = %q", d)
+	}
+
+	// The download is a database holding every firm's parties
+	file := filepath.Join(t.TempDir(), "download.db")
+	if err := os.WriteFile(file, w.Body.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	backup, err := sql.Open("sqlite", file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer backup.Close()
+	if n := count(t, backup, "SELECT COUNT(*) FROM parties"); n != 2 {
+		t.Errorf("backup holds %v parties, want 2", n)
+	}
+
+	if n := count(t, db, "SELECT COUNT(*) FROM backups WHERE size_bytes = ?", w.Body.Len()); n != 1 {
+		t.Errorf("backup not recorded with its size")
+	}
+	if body := dashboard(); !strings.Contains(body, "Last backup") || strings.Contains(body, "No backup has been taken yet") {
+		t.Errorf("dashboard does not show the backup:\n%s", body)
+	}
+}
@@ -0,0 +1,268 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"suspense.durgadawaghar.com/internal/category"
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/matcher"
+	"suspense.durgadawaghar.com/internal/parser"
+	"suspense.durgadawaghar.com/internal/rules"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// bookedSlack is how many days either side of its bank date a credit is
+// looked for in the books, for entries posted a few days late
+const bookedSlack = 3
+
+// statementCredit is a credit read from a bank statement, with whether the
+// books already hold it and, if not, the party it would be imported under
+type statementCredit struct {
+	tx     parser.Transaction
+	res    rules.Result
+	booked bool
+	match  *matcher.MatchResult // nil unless matched by the matcher
+}
+
+// importable reports whether a credit missing from the books has a party to
+// be imported under, or is a card settlement
+func (c statementCredit) importable() bool {
+	return !c.booked && (c.tx.PaymentMode == "POS" || c.res.PartyName != "" || c.match != nil)
+}
+
+// BankStatement renders the bank statement import page
+func (h *Handler) BankStatement(w http.ResponseWriter, r *http.Request) {
+	pages.BankStatement().Render(r.Context(), w)
+}
+
+// BankStatementPreview reads an uploaded ICICI statement CSV and lists its
+// credits, marking those already in the books and matching a party to the
+// rest
+func (h *Handler) BankStatementPreview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxSaleBillFileSize)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		w.Write([]byte(`<div class="error">Choose the statement's CSV file, up to 10 MB.</div>`))
+		return
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		w.Write([]byte(`<div class="error">Error reading the file.</div>`))
+		return
+	}
+	data := string(content)
+	if err := parser.CheckText(data); err != nil {
+		w.Write([]byte(fmt.Sprintf(`<div class="error">Cannot read %s: %s. Download the statement as CSV.</div>`, html.EscapeString(header.Filename), html.EscapeString(err.Error()))))
+		return
+	}
+
+	credits, err := h.readBankStatement(r.Context(), data)
+	if err != nil {
+		w.Write([]byte(fmt.Sprintf(`<div class="error">Cannot read %s: %s</div>`, html.EscapeString(header.Filename), html.EscapeString(err.Error()))))
+		return
+	}
+
+	preview := make([]pages.StatementCredit, len(credits))
+	for i, c := range credits {
+		p := pages.StatementCredit{
+			Index:       i,
+			Date:        c.tx.Date.Format("02 Jan 2006"),
+			Amount:      fmt.Sprintf("%.2f", c.tx.Amount),
+			PaymentMode: c.tx.PaymentMode,
+			Narration:   c.tx.Narration,
+			Booked:      c.booked,
+			Importable:  c.importable(),
+			Category:    c.res.Category,
+			Internal:    c.res.Internal,
+			Rules:       c.res.Matched,
+			PartyName:   c.res.PartyName,
+		}
+		if c.match != nil {
+			p.PartyID = c.match.Party.ID
+			p.PartyName = c.match.Party.Name
+			p.Location = c.match.Party.Location.String
+			p.Confidence = c.match.Confidence
+			// Matches found by narration alone are left for a look first
+			p.Selected = len(c.match.MatchedOn) > 0
+		} else {
+			p.Selected = p.Importable
+		}
+		preview[i] = p
+	}
+	pages.BankStatementPreview(header.Filename, preview, data).Render(r.Context(), w)
+}
+
+// BankStatementConfirm imports the credits chosen on the preview
+func (h *Handler) BankStatementConfirm(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := readImportForm(w, r); err != nil {
+		w.Write([]byte(fmt.Sprintf(`<div class="error">%s</div>`, html.EscapeString(err.Error()))))
+		return
+	}
+	ctx := r.Context()
+
+	credits, err := h.readBankStatement(ctx, r.FormValue("data"))
+	if err != nil {
+		w.Write([]byte(fmt.Sprintf(`<div class="error">Import error: %s</div>`, html.EscapeString(err.Error()))))
+		return
+	}
+
+	var summary importSummary
+	for _, v := range r.Form["credit"] {
+		i, err := strconv.Atoi(v)
+		if err != nil || i < 0 || i >= len(credits) {
+			continue
+		}
+		c := credits[i]
+		if c.booked {
+			// Imported since the preview
+			summary.Duplicates++
+			continue
+		}
+		if !c.importable() {
+			continue
+		}
+		if err := h.importStatementCredit(ctx, c); err != nil {
+			if errors.Is(err, errDuplicate) {
+				summary.Duplicates++
+			} else {
+				summary.Errors = append(summary.Errors, fmt.Sprintf("%s %.2f: %s", c.tx.Date.Format("02 Jan"), c.tx.Amount, err.Error()))
+			}
+			continue
+		}
+		switch {
+		case c.tx.PaymentMode == "POS":
+			summary.POSSettlements++
+		case c.res.Category != category.Receipt:
+			summary.NonReceipts++
+		default:
+			summary.Imported++
+		}
+	}
+	pages.BankStatementResult(summary.Imported, summary.NonReceipts, summary.POSSettlements, summary.Duplicates, summary.Errors).Render(ctx, w)
+}
+
+// importStatementCredit stores a credit missing from the books: a card
+// settlement as a POS collection, and a receipt under the party a rule
+// assigns it to or the matcher found
+func (h *Handler) importStatementCredit(ctx context.Context, c statementCredit) error {
+	if c.tx.PaymentMode == "POS" {
+		return h.importPOSSettlement(ctx, c.tx)
+	}
+	var partyID int64
+	if c.match != nil && c.res.PartyName == "" {
+		partyID = c.match.Party.ID
+		c.tx.PartyName, c.tx.Location = c.match.Party.Name, c.match.Party.Location.String
+	}
+	_, err := h.importTransaction(ctx, c.tx, c.res, partyID)
+	return err
+}
+
+// readBankStatement reads the credits of an ICICI statement CSV, finding
+// which are already in the books: an entry or card settlement within a few
+// days whose narration holds the credit's. Rules are applied to the rest, and
+// those left with a customer's receipt are matched to a party.
+func (h *Handler) readBankStatement(ctx context.Context, data string) ([]statementCredit, error) {
+	p, err := h.loadParser(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("loading parser vocabulary: %w", err)
+	}
+	transactions, err := p.ParseICICIStatement(strings.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(transactions) == 0 {
+		return nil, nil
+	}
+
+	engine, err := h.loadRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("loading rules: %w", err)
+	}
+
+	from, till := transactions[0].Date, transactions[0].Date
+	for _, tx := range transactions {
+		if tx.Date.Before(from) {
+			from = tx.Date
+		}
+		if tx.Date.After(till) {
+			till = tx.Date
+		}
+	}
+	from, till = from.AddDate(0, 0, -bookedSlack), till.AddDate(0, 0, bookedSlack)
+	booked, err := h.queries.ListBookedNarrations(ctx, sqlc.ListBookedNarrationsParams{
+		FirmID:            firmID(ctx),
+		TransactionDate:   from,
+		TransactionDate_2: till,
+		FirmID_2:          firmID(ctx),
+		CreditDate:        from,
+		CreditDate_2:      till,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("loading the entries already in the books: %w", err)
+	}
+	for i := range booked {
+		booked[i].Narration.String = squashNarration(booked[i].Narration.String)
+	}
+
+	credits := make([]statementCredit, len(transactions))
+	for i, tx := range transactions {
+		c := statementCredit{tx: tx}
+		narration := squashNarration(tx.Narration)
+		for _, b := range booked {
+			if narration != "" && withinDays(b.CreditDate, tx.Date, bookedSlack) && strings.Contains(b.Narration.String, narration) {
+				c.booked = true
+				break
+			}
+		}
+		if !c.booked && tx.PaymentMode != "POS" {
+			c.res = applyRules(engine, tx)
+			if c.res.PartyName == "" && !c.res.Internal && c.res.Category == category.Receipt {
+				c.match, err = h.matcher.MatchSingle(ctx, firmID(ctx), tx.Narration, tx.Amount)
+				if err != nil {
+					return nil, fmt.Errorf("matching %s: %w", tx.Narration, err)
+				}
+			}
+		}
+		credits[i] = c
+	}
+	return credits, nil
+}
+
+// squashNarration drops the spaces and commas of a narration, which the
+// receipt book wraps and groups differently from the bank
+func squashNarration(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || r == ',' {
+			return -1
+		}
+		return unicode.ToUpper(r)
+	}, s)
+}
+
+// withinDays reports whether two dates are at most days apart
+func withinDays(a, b time.Time, days int) bool {
+	d := a.Sub(b)
+	if d < 0 {
+		d = -d
+	}
+	return d <= time.Duration(days)*24*time.Hour
+}
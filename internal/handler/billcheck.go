@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"suspense.durgadawaghar.com/internal/billcheck"
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/fy"
+	"suspense.durgadawaghar.com/internal/parser"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// SaleBillAnomalies reports the sale bills of a period that look like data
+// entry errors on the billing side: bills to a party for the same amount on
+// the same or the next day under different numbers, bills of zero or a
+// negative amount, and numbers missing from a bill series or dated out of
+// order. The period defaults to the last three months.
+func (h *Handler) SaleBillAnomalies(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	from, till, year := reportPeriod(r, time.Now().AddDate(0, -3, 0))
+	bills, err := h.billsToCheck(ctx, from, till)
+	if err != nil {
+		http.Error(w, "Error loading sale bills", http.StatusInternalServerError)
+		return
+	}
+	pages.SaleBillAnomalies(from.Format("2006-01-02"), till.Format("2006-01-02"), year, len(bills), billcheck.Check(bills)).Render(ctx, w)
+}
+
+// checkImportedSeries checks the bill number series of imported bills for
+// gaps and bills out of date order, over the financial years the bills fall
+// in, as a bill may fill a gap an earlier import left
+func (h *Handler) checkImportedSeries(ctx context.Context, imported []parser.SaleBill) (pages.SeriesCheck, error) {
+	if len(imported) == 0 {
+		return pages.SeriesCheck{}, nil
+	}
+	from, till := fy.Of(imported[0].Date).Start(), fy.Of(imported[0].Date).End()
+	touched := make(map[string]bool)
+	for _, b := range imported {
+		if y := fy.Of(b.Date); y.Start().Before(from) {
+			from = y.Start()
+		} else if y.End().After(till) {
+			till = y.End()
+		}
+		if series, _, _, ok := billcheck.Split(b.BillNumber); ok {
+			touched[series] = true
+		}
+	}
+	bills, err := h.billsToCheck(ctx, from, till)
+	if err != nil {
+		return pages.SeriesCheck{}, err
+	}
+
+	report := billcheck.Check(bills)
+	check := pages.SeriesCheck{From: from.Format("2006-01-02"), Till: till.Format("2006-01-02")}
+	for _, g := range report.Gaps {
+		if touched[g.Series] {
+			check.Gaps++
+			check.Missing += g.Missing
+		}
+	}
+	for _, o := range report.OutOfOrder {
+		if series, _, _, _ := billcheck.Split(o.Bill.Number); touched[series] {
+			check.OutOfOrder++
+		}
+	}
+	return check, nil
+}
+
+// billsToCheck loads the current firm's sale bills dated between from and
+// till for billcheck
+func (h *Handler) billsToCheck(ctx context.Context, from, till time.Time) ([]billcheck.Bill, error) {
+	rows, err := h.queries.ListSaleBillsBetween(ctx, sqlc.ListSaleBillsBetweenParams{
+		FirmID:     firmID(ctx),
+		BillDate:   from,
+		BillDate_2: till,
+	})
+	if err != nil {
+		return nil, err
+	}
+	bills := make([]billcheck.Bill, len(rows))
+	for i, b := range rows {
+		bills[i] = billcheck.Bill{
+			ID:      b.ID,
+			Number:  b.BillNumber,
+			Date:    b.BillDate,
+			PartyID: b.PartyID.Int64,
+			Party:   b.PartyName,
+			Amount:  b.Amount,
+		}
+	}
+	return bills, nil
+}
@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"suspense.durgadawaghar.com/internal/cashchain"
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/extractor"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// CashReconciliation compares counter cash sales with cash deposited in the
+// bank, day by day. Deposits are the internal cash entries from the receipt
+// book, so they usually lag sales by a day; the running undeposited balance is
+// what should still be at the counter.
+func (h *Handler) CashReconciliation(w http.ResponseWriter, r *http.Request) {
+	// Default to the last 30 days
+	fromDate, tillDate, year := reportPeriod(r, time.Now().AddDate(0, 0, -30))
+
+	ctx := r.Context()
+
+	accountID, accounts, err := h.accountFilter(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Loading accounts: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	sales, err := h.queries.GetDailyCashSales(ctx, sqlc.GetDailyCashSalesParams{
+		BillDate:   fromDate,
+		BillDate_2: tillDate,
+		FirmID:     firmID(ctx),
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Loading cash sales: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	deposits, err := h.cashDeposits(ctx, fromDate, tillDate, accountID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Loading cash deposits: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	byDate := make(map[string]*pages.CashDay)
+	day := func(d time.Time) *pages.CashDay {
+		key := d.Format("2006-01-02")
+		if _, ok := byDate[key]; !ok {
+			byDate[key] = &pages.CashDay{Date: d.Format("02 Jan 2006")}
+		}
+		return byDate[key]
+	}
+	for _, s := range sales {
+		d := day(s.BillDate)
+		d.Bills = s.BillCount
+		d.Sales = s.TotalAmount.Float64
+	}
+	for _, dep := range deposits {
+		d := day(dep.TransactionDate)
+		d.Deposits = dep.DepositCount
+		d.Deposited = dep.TotalAmount.Float64
+	}
+
+	chain, err := h.cashChain(ctx, fromDate, tillDate, accountID, sales)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Tracing cash deposits: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+	for _, dep := range chain {
+		for _, link := range dep.Banked {
+			d := byDate[link.Key]
+			d.BankedBy = append(d.BankedBy, pages.CashLink{Date: dep.Date, Amount: link.Amount})
+			d.Traced += link.Amount
+		}
+	}
+
+	keys := make([]string, 0, len(byDate))
+	for k := range byDate {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var totalSales, totalDeposited float64
+	days := make([]pages.CashDay, len(keys))
+	for i, k := range keys {
+		d := byDate[k]
+		totalSales += d.Sales
+		totalDeposited += d.Deposited
+		d.Difference = d.Deposited - d.Sales
+		d.Undeposited = totalSales - totalDeposited
+		days[i] = *d
+	}
+
+	pages.CashReconciliation(fromDate.Format("2006-01-02"), tillDate.Format("2006-01-02"), year, accounts, accountID, days, chain, totalSales, totalDeposited).Render(ctx, w)
+}
+
+// cashDeposits totals cash deposited each day, into accountID only when it is
+// set
+func (h *Handler) cashDeposits(ctx context.Context, fromDate, tillDate time.Time, accountID int64) ([]sqlc.GetDailyCashDepositsRow, error) {
+	if accountID == 0 {
+		return h.queries.GetDailyCashDeposits(ctx, sqlc.GetDailyCashDepositsParams{
+			TransactionDate:   fromDate,
+			TransactionDate_2: tillDate,
+			FirmID:            firmID(ctx),
+		})
+	}
+	rows, err := h.queries.GetDailyCashDepositsByAccount(ctx, sqlc.GetDailyCashDepositsByAccountParams{
+		TransactionDate:   fromDate,
+		TransactionDate_2: tillDate,
+		FirmID:            firmID(ctx),
+		AccountID:         sql.NullInt64{Int64: accountID, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	deposits := make([]sqlc.GetDailyCashDepositsRow, len(rows))
+	for i, row := range rows {
+		deposits[i] = sqlc.GetDailyCashDepositsRow(row)
+	}
+	return deposits, nil
+}
+
+// depositPoint is where a cash deposit was made from: the depositing agent's
+// code, or else the bank branch's location
+func depositPoint(dep sqlc.ListCashDepositsRow) string {
+	if agents := extractor.ExtractByType(dep.Narration.String, extractor.TypeCashAgentCode); len(agents) > 0 {
+		return agents[0]
+	}
+	return dep.CashBankLocation.String
+}
+
+// cashChain ties the period's counter cash deposits, into accountID only when
+// it is set, to the cash sale days they banked. Counter deposits are the
+// internal cash entries, and other cash deposits made from an agent or branch
+// the counter deposits from, which rules may have missed; customers' own cash
+// deposits come from elsewhere and are left out.
+func (h *Handler) cashChain(ctx context.Context, fromDate, tillDate time.Time, accountID int64, sales []sqlc.GetDailyCashSalesRow) ([]pages.CashDeposit, error) {
+	rows, err := h.queries.ListCashDeposits(ctx, sqlc.ListCashDepositsParams{
+		TransactionDate:   fromDate,
+		TransactionDate_2: tillDate,
+		FirmID:            firmID(ctx),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var internal, others []sqlc.ListCashDepositsRow
+	for _, row := range rows {
+		if accountID != 0 && row.AccountID.Int64 != accountID {
+			continue
+		}
+		if row.IsInternal {
+			internal = append(internal, row)
+		} else {
+			others = append(others, row)
+		}
+	}
+
+	var counter []cashchain.Deposit
+	for _, row := range internal {
+		counter = append(counter, cashchain.Deposit{ID: row.ID, Date: row.TransactionDate, Amount: row.Amount, Point: depositPoint(row)})
+	}
+	points := cashchain.CounterPoints(counter)
+	deposits := make(map[int64]sqlc.ListCashDepositsRow, len(rows))
+	for _, row := range internal {
+		deposits[row.ID] = row
+	}
+	for _, row := range others {
+		if point := depositPoint(row); points[point] {
+			counter = append(counter, cashchain.Deposit{ID: row.ID, Date: row.TransactionDate, Amount: row.Amount, Point: point})
+			deposits[row.ID] = row
+		}
+	}
+
+	days := make([]cashchain.Day, len(sales))
+	for i, s := range sales {
+		days[i] = cashchain.Day{Date: s.BillDate, Sales: s.TotalAmount.Float64}
+	}
+	result := cashchain.Match(days, counter)
+
+	banked := make(map[int64][]pages.CashLink)
+	for _, link := range result.Links {
+		banked[link.DepositID] = append(banked[link.DepositID], pages.CashLink{
+			Key:    cashchain.DateKey(link.SaleDate),
+			Date:   link.SaleDate.Format("02 Jan 2006"),
+			Amount: link.Amount,
+		})
+	}
+	chain := make([]pages.CashDeposit, 0, len(counter))
+	for _, dep := range counter {
+		row := deposits[dep.ID]
+		chain = append(chain, pages.CashDeposit{
+			ID:        dep.ID,
+			Date:      dep.Date.Format("02 Jan 2006"),
+			Amount:    dep.Amount,
+			Point:     dep.Point,
+			Narration: row.Narration.String,
+			Internal:  row.IsInternal,
+			Banked:    banked[dep.ID],
+			Unmatched: result.Unmatched[dep.ID],
+		})
+	}
+	sort.SliceStable(chain, func(i, j int) bool {
+		return deposits[chain[i].ID].TransactionDate.Before(deposits[chain[j].ID].TransactionDate)
+	})
+	return chain, nil
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCashReconciliation(t *testing.T) {
+	h, db := newTestHandler(t)
+	exec(t, db, `INSERT INTO sale_bills (bill_number, bill_date, party_name, amount, is_cash_sale, firm_id) VALUES
+		('C-1', '2025-04-01 00:00:00 +0000 UTC', 'CASH', 1000, TRUE, 1),
+		('C-2', '2025-04-01 00:00:00 +0000 UTC', 'CASH', 500, TRUE, 1),
+		('C-3', '2025-04-02 00:00:00 +0000 UTC', 'CASH', 800, TRUE, 1),
+		('A-1', '2025-04-02 00:00:00 +0000 UTC', 'SHARMA MEDICAL', 9000, FALSE, 1),
+		('D-1', '2025-04-01 00:00:00 +0000 UTC', 'CASH', 7000, TRUE, 2)`)
+	exec(t, db, `INSERT INTO parties (id, name, firm_id) VALUES (1, 'CASH', 1), (2, 'CASH', 2)`)
+	// The counter banks the day's cash the next morning, ₹200 short on the 3rd
+	exec(t, db, `INSERT INTO transactions (party_id, amount, transaction_date, payment_mode, narration, is_internal, firm_id) VALUES
+		(1, 1500, '2025-04-02 00:00:00 +0000 UTC', 'CASH', 'CASH DEP KANPUR', TRUE, 1),
+		(1, 600, '2025-04-03 00:00:00 +0000 UTC', 'CASH', 'CASH DEP KANPUR', TRUE, 1),
+		(2, 7000, '2025-04-02 00:00:00 +0000 UTC', 'CASH', 'CASH DEP UNNAO', TRUE, 2)`)
+
+	w := serve(h, http.HandlerFunc(h.CashReconciliation), httptest.NewRequest(http.MethodGet, "/cash?from_date=2025-04-01&till_date=2025-04-05", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		"01 Apr 2025", "02 Apr 2025", "03 Apr 2025",
+		// sales, deposits and the shortfall of the period, of this firm only
+		"₹2300.00", "₹2100.00", "₹-200.00",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("reconciliation lacks %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "7000.00") || strings.Contains(body, "9000.00") {
+		t.Errorf("reconciliation counts another firm's cash or a credit bill:\n%s", body)
+	}
+}
@@ -0,0 +1,307 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// Cheque statuses, in lifecycle order
+const (
+	chequeReceived  = "received"
+	chequeDeposited = "deposited"
+	chequeCleared   = "cleared"
+	chequeBounced   = "bounced"
+)
+
+// chequeViews maps the cheques page filter to the statuses it shows
+var chequeViews = map[string][]string{
+	"pending": {chequeReceived, chequeDeposited},
+	"cleared": {chequeCleared},
+	"bounced": {chequeBounced},
+	"all":     {chequeReceived, chequeDeposited, chequeCleared, chequeBounced},
+}
+
+// nextChequeStatus returns the status a cheque moves to when action is taken,
+// or an error if the action isn't allowed from the current status
+func nextChequeStatus(current, action string) (string, error) {
+	switch action {
+	case "deposit":
+		// Bounced cheques may be presented again
+		if current == chequeReceived || current == chequeBounced {
+			return chequeDeposited, nil
+		}
+	case "clear":
+		if current == chequeReceived || current == chequeDeposited {
+			return chequeCleared, nil
+		}
+	case "bounce":
+		if current == chequeReceived || current == chequeDeposited {
+			return chequeBounced, nil
+		}
+	default:
+		return "", fmt.Errorf("unknown action %q", action)
+	}
+	return "", fmt.Errorf("cannot %s a %s cheque", action, current)
+}
+
+// listCheques loads the current firm's cheques with the given statuses,
+// only those received into accountID when it is set
+func (h *Handler) listCheques(ctx context.Context, statuses []string, accountID int64) ([]sqlc.ListChequesByStatusRow, error) {
+	if accountID == 0 {
+		return h.queries.ListChequesByStatus(ctx, sqlc.ListChequesByStatusParams{
+			FirmID:   firmID(ctx),
+			Statuses: statuses,
+		})
+	}
+	rows, err := h.queries.ListChequesByStatusAndAccount(ctx, sqlc.ListChequesByStatusAndAccountParams{
+		FirmID:    firmID(ctx),
+		AccountID: sql.NullInt64{Int64: accountID, Valid: true},
+		Statuses:  statuses,
+	})
+	if err != nil {
+		return nil, err
+	}
+	cheques := make([]sqlc.ListChequesByStatusRow, len(rows))
+	for i, row := range rows {
+		cheques[i] = sqlc.ListChequesByStatusRow(row)
+	}
+	return cheques, nil
+}
+
+// Cheques lists cheques by lifecycle status, pending ones by default
+func (h *Handler) Cheques(w http.ResponseWriter, r *http.Request) {
+	view := r.URL.Query().Get("status")
+	statuses, ok := chequeViews[view]
+	if !ok {
+		view = "pending"
+		statuses = chequeViews[view]
+	}
+
+	accountID, accounts, err := h.accountFilter(r)
+	if err != nil {
+		http.Error(w, "Error loading accounts", http.StatusInternalServerError)
+		return
+	}
+
+	rows, err := h.listCheques(r.Context(), statuses, accountID)
+	if err != nil {
+		http.Error(w, "Error loading cheques", http.StatusInternalServerError)
+		return
+	}
+
+	today := time.Now()
+	total := 0.0
+	cheques := make([]pages.ChequeRow, len(rows))
+	for i, c := range rows {
+		total += c.Amount
+		cheques[i] = pages.ChequeRow{
+			ID:            c.ID,
+			PartyID:       c.PartyID,
+			PartyName:     c.PartyName,
+			Location:      c.PartyLocation.String,
+			Number:        c.ChequeNumber.String,
+			ChequeDate:    formatNullDate(c.ChequeDate),
+			Amount:        fmt.Sprintf("%.2f", c.Amount),
+			Status:        c.Status,
+			ReceivedDate:  c.ReceivedDate.Format("02 Jan 2006"),
+			DepositedDate: formatNullDate(c.DepositedDate),
+			ClearedDate:   formatNullDate(c.ClearedDate),
+			BouncedDate:   formatNullDate(c.BouncedDate),
+			Notes:         c.Notes.String,
+			DaysPending:   int(today.Sub(c.ReceivedDate).Hours() / 24),
+		}
+	}
+
+	pages.Cheques(view, accounts, accountID, cheques, fmt.Sprintf("%.2f", total), today.Format("2006-01-02"), r.URL.Query().Get("error")).Render(r.Context(), w)
+}
+
+// UpdateCheque moves a cheque to its next lifecycle stage
+func (h *Handler) UpdateCheque(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	view := r.FormValue("view")
+	if _, ok := chequeViews[view]; !ok {
+		view = "pending"
+	}
+	redirect := func(errMsg string) {
+		target := "/cheques?status=" + view
+		if account, err := strconv.ParseInt(r.FormValue("account"), 10, 64); err == nil && account > 0 {
+			target += fmt.Sprintf("&account=%d", account)
+		}
+		if errMsg != "" {
+			target += "&error=" + url.QueryEscape(errMsg)
+		}
+		http.Redirect(w, r, target, http.StatusSeeOther)
+	}
+
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid cheque ID", http.StatusBadRequest)
+		return
+	}
+
+	date := time.Now()
+	if dateStr := r.FormValue("date"); dateStr != "" {
+		parsed, err := time.Parse("2006-01-02", dateStr)
+		if err != nil {
+			redirect("Invalid date.")
+			return
+		}
+		date = parsed
+	}
+
+	ctx := r.Context()
+	cheque, err := h.queries.GetCheque(ctx, id)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	status, err := nextChequeStatus(cheque.Status, r.FormValue("action"))
+	if err != nil {
+		redirect(err.Error())
+		return
+	}
+
+	params := sqlc.UpdateChequeStatusParams{
+		Status:        status,
+		DepositedDate: cheque.DepositedDate,
+		ClearedDate:   cheque.ClearedDate,
+		BouncedDate:   cheque.BouncedDate,
+		Notes:         cheque.Notes,
+		ID:            cheque.ID,
+	}
+	stageDate := sql.NullTime{Time: date, Valid: true}
+	switch status {
+	case chequeDeposited:
+		params.DepositedDate = stageDate
+	case chequeCleared:
+		params.ClearedDate = stageDate
+	case chequeBounced:
+		params.BouncedDate = stageDate
+	}
+	if notes := strings.TrimSpace(r.FormValue("notes")); notes != "" {
+		params.Notes = sql.NullString{String: notes, Valid: true}
+	}
+
+	if err := h.queries.UpdateChequeStatus(ctx, params); err != nil {
+		redirect(fmt.Sprintf("Error updating cheque: %s", err.Error()))
+		return
+	}
+	redirect("")
+}
+
+func formatNullDate(t sql.NullTime) string {
+	if !t.Valid {
+		return ""
+	}
+	return t.Time.Format("02 Jan 2006")
+}
+
+// chequeNumberQuery turns a cheque number as quoted in a bank's return memo
+// into a LIKE pattern, matching with or without leading zeros; ok is false for
+// fewer than three digits
+func chequeNumberQuery(number string) (pattern string, ok bool) {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, number)
+	digits = strings.TrimLeft(digits, "0")
+	if len(digits) < 3 {
+		return "", false
+	}
+	return "%" + digits + "%", true
+}
+
+// SearchCheques finds the receipts of a cheque number, as bank return memos
+// quote the cheque rather than the narration, with the party and the bills
+// the receipt was allocated to
+func (h *Handler) SearchCheques(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	number := strings.TrimSpace(r.URL.Query().Get("number"))
+	if number == "" {
+		pages.ChequeSearch("", nil, "").Render(ctx, w)
+		return
+	}
+	pattern, ok := chequeNumberQuery(number)
+	if !ok {
+		pages.ChequeSearch(number, nil, "Enter at least three digits of the cheque number.").Render(ctx, w)
+		return
+	}
+
+	rows, err := h.queries.SearchChequesByNumber(ctx, sqlc.SearchChequesByNumberParams{
+		FirmID:       firmID(ctx),
+		ChequeNumber: sql.NullString{String: pattern, Valid: true},
+	})
+	if err != nil {
+		http.Error(w, "Error searching cheques", http.StatusInternalServerError)
+		return
+	}
+
+	// Each party's receipts are allocated once, however many of its cheques
+	// match
+	allocations := make(map[int64]map[int64][]pages.BillAllocation)
+	billsOf := make(map[int64][]sqlc.SaleBill)
+	matches := make([]pages.ChequeMatch, len(rows))
+	for i, c := range rows {
+		if _, ok := allocations[c.PartyID]; !ok {
+			bills, receipts, manual, err := h.partyAllocations(ctx, c.PartyID)
+			if err != nil {
+				http.Error(w, "Error loading allocations", http.StatusInternalServerError)
+				return
+			}
+			allocations[c.PartyID] = allocateReceipts(bills, receipts, manual, roundingTolerance(ctx))
+			billsOf[c.PartyID] = bills
+		}
+
+		match := pages.ChequeMatch{
+			Cheque: pages.ChequeRow{
+				ID:            c.ID,
+				PartyID:       c.PartyID,
+				PartyName:     c.PartyName,
+				Location:      c.PartyLocation.String,
+				Number:        c.ChequeNumber.String,
+				ChequeDate:    formatNullDate(c.ChequeDate),
+				Amount:        fmt.Sprintf("%.2f", c.Amount),
+				Status:        c.Status,
+				ReceivedDate:  c.ReceivedDate.Format("02 Jan 2006"),
+				DepositedDate: formatNullDate(c.DepositedDate),
+				ClearedDate:   formatNullDate(c.ClearedDate),
+				BouncedDate:   formatNullDate(c.BouncedDate),
+				Notes:         c.Notes.String,
+			},
+			TransactionID: c.TransactionID,
+			ReceiptDate:   c.TransactionDate.Format("02 Jan 2006"),
+			PaymentMode:   c.PaymentMode.String,
+		}
+		for _, b := range billsOf[c.PartyID] {
+			for _, a := range allocations[c.PartyID][b.ID] {
+				if a.TransactionID == c.TransactionID {
+					match.Bills = append(match.Bills, pages.ChequeBill{
+						ID:      b.ID,
+						Number:  b.BillNumber,
+						Date:    b.BillDate.Format("02 Jan 2006"),
+						Applied: a.Amount,
+					})
+				}
+			}
+		}
+		matches[i] = match
+	}
+
+	pages.ChequeSearch(number, matches, "").Render(ctx, w)
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSearchCheques(t *testing.T) {
+	h, db := newTestHandler(t)
+	exec(t, db, `INSERT INTO parties (id, name, location, firm_id) VALUES
+		(1, 'SHARMA MEDICAL', 'TIRWA', 1), (2, 'VERMA AGENCIES', '', 2)`)
+	exec(t, db, `INSERT INTO sale_bills (id, bill_number, bill_date, party_name, amount, is_cash_sale, party_id, firm_id)
+		VALUES (1, 'A-1', '2025-04-01 00:00:00 +0000 UTC', 'SHARMA MEDICAL', 700, FALSE, 1, 1)`)
+	exec(t, db, `INSERT INTO transactions (id, party_id, amount, transaction_date, payment_mode, narration, firm_id) VALUES
+		(1, 1, 1000, '2025-04-05 00:00:00 +0000 UTC', 'CHEQUE', 'CLG/088027', 1),
+		(2, 2, 500, '2025-04-05 00:00:00 +0000 UTC', 'CHEQUE', 'CLG/088027', 2)`)
+	exec(t, db, `INSERT INTO cheques (transaction_id, cheque_number, status, received_date) VALUES
+		(1, '000088027', 'cleared', '2025-04-05 00:00:00 +0000 UTC'),
+		(2, '088027', 'received', '2025-04-05 00:00:00 +0000 UTC')`)
+	search := func(number string) string {
+		r := httptest.NewRequest(http.MethodGet, "/cheques/search?number="+url.QueryEscape(number), nil)
+		return serve(h, http.HandlerFunc(h.SearchCheques), r).Body.String()
+	}
+
+	// The number is found however the memo quotes it, with the party and the
+	// bill the receipt paid, and only in the selected firm
+	for _, number := range []string{"88027", "088027", "0880-27"} {
+		body := search(number)
+		if !strings.Contains(body, "Cheque 000088027") || !strings.Contains(body, "SHARMA MEDICAL") || strings.Contains(body, "VERMA AGENCIES") {
+			t.Errorf("%q found the wrong cheques:\n%s", number, body)
+		}
+		if !strings.Contains(body, "A-1") || !strings.Contains(body, "₹700.00") {
+			t.Errorf("%q does not show the bill paid:\n%s", number, body)
+		}
+	}
+	if body := search("12"); !strings.Contains(body, "at least three digits") {
+		t.Errorf("two digits searched:\n%s", body)
+	}
+	if body := search("555"); !strings.Contains(body, "No cheque numbered 555") {
+		t.Errorf("unknown number:\n%s", body)
+	}
+
+	graphql := func(query string) string {
+		r := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query":`+strconv.Quote(query)+`}`))
+		return serve(h, http.HandlerFunc(h.GraphQL), r).Body.String()
+	}
+	body := graphql(`{ cheques(number: "88027") { number status transaction { amount party { name } } } }`)
+	if want := `{"data":{"cheques":[{"number":"000088027","status":"cleared","transaction":{"amount":1000,"party":{"name":"SHARMA MEDICAL"}}}]}}`; strings.TrimSpace(body) != want {
+		t.Errorf("GraphQL cheques = %s, want %s", body, want)
+	}
+	if body := graphql(`{ cheques(number: "12") { number } }`); !strings.Contains(body, "at least three digits") {
+		t.Errorf("GraphQL searched two digits: %s", body)
+	}
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/extractor"
+)
+
+// contactIdentifierDetail is the history detail of identifiers linked from a
+// party's contact details
+const contactIdentifierDetail = "Party contact details"
+
+// UpdatePartyContact saves a party's phone, address, GSTIN and drug license.
+// The phone and GSTIN are linked to the party as identifiers, so receipts
+// quoting them match it.
+func (h *Handler) UpdatePartyContact(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid party ID", http.StatusBadRequest)
+		return
+	}
+	party, err := h.queries.GetPartyByID(ctx, id)
+	if err != nil || party.FirmID != firmID(ctx) {
+		http.NotFound(w, r)
+		return
+	}
+
+	phone := strings.TrimSpace(r.FormValue("phone"))
+	if phone != "" {
+		if phone, err = extractor.Normalize(extractor.TypePhone, phone); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	gstin := strings.TrimSpace(r.FormValue("gstin"))
+	if gstin != "" {
+		if gstin, err = extractor.Normalize(extractor.TypeGSTIN, gstin); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	address := strings.TrimSpace(r.FormValue("address"))
+	drugLicense := strings.ToUpper(strings.TrimSpace(r.FormValue("drug_license")))
+
+	if err := h.queries.UpdatePartyContact(ctx, sqlc.UpdatePartyContactParams{
+		Phone:       phone,
+		Address:     address,
+		Gstin:       gstin,
+		DrugLicense: drugLicense,
+		ID:          party.ID,
+		FirmID:      party.FirmID,
+	}); err != nil {
+		http.Error(w, "Error saving contact details", http.StatusInternalServerError)
+		return
+	}
+	if err := h.linkContactIdentifiers(ctx, party.ID, phone, gstin); err != nil {
+		http.Error(w, "Error linking contact identifiers", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/party/%d", party.ID), http.StatusSeeOther)
+}
+
+// linkContactIdentifiers links a party's phone and GSTIN to it as identifiers.
+// One already linked to another party is left there, and recorded as a
+// conflict to resolve.
+func (h *Handler) linkContactIdentifiers(ctx context.Context, partyID int64, phone, gstin string) error {
+	for _, id := range []extractor.Identifier{
+		{Type: extractor.TypePhone, Value: phone},
+		{Type: extractor.TypeGSTIN, Value: gstin},
+	} {
+		if id.Value == "" {
+			continue
+		}
+		if _, _, err := h.linkIdentifier(ctx, partyID, id, identifierCauseAssign, contactIdentifierDetail); err != nil {
+			return fmt.Errorf("linking %s %s: %w", id.Type, id.Value, err)
+		}
+	}
+	return nil
+}
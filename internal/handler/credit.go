@@ -0,0 +1,294 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/mailer"
+	"suspense.durgadawaghar.com/internal/views"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// partyBalance converts a party balance row for display. Outstanding is credit
+// sale bills less receipts; a credit limit of 0 means no limit is set.
+func partyBalance(id int64, name, location string, creditLimit, billed, received float64, receipts int64) pages.PartyBalance {
+	outstanding := billed - received
+	return pages.PartyBalance{
+		ID:          id,
+		Name:        name,
+		Location:    location,
+		Receipts:    receipts,
+		Billed:      billed,
+		Received:    received,
+		Outstanding: outstanding,
+		CreditLimit: creditLimit,
+		Breached:    creditLimit > 0 && outstanding > creditLimit,
+	}
+}
+
+// creditBreaches lists parties whose outstanding exceeds their credit limit,
+// largest excess first
+func (h *Handler) creditBreaches(ctx context.Context) ([]pages.PartyBalance, error) {
+	rows, err := h.queries.ListCreditLimitBreaches(ctx, firmID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	breaches := make([]pages.PartyBalance, len(rows))
+	for i, p := range rows {
+		breaches[i] = partyBalance(p.ID, p.Name, p.Location.String, p.CreditLimit, p.Billed, p.Received, p.ReceiptCount)
+	}
+	return breaches, nil
+}
+
+// dashboardSummary reads what the firm's parties owe and the receipts of the
+// last 12 months by payment mode from the summary tables, which imports keep
+// up to date, rather than adding up every receipt and bill
+func (h *Handler) dashboardSummary(ctx context.Context) (pages.DashboardSummary, error) {
+	var summary pages.DashboardSummary
+	outstanding, err := h.queries.GetOutstandingSummary(ctx, firmID(ctx))
+	if err != nil {
+		return summary, err
+	}
+	summary.Owing = outstanding.Owing
+	summary.Outstanding = outstanding.Outstanding
+	summary.Advances = outstanding.Advances
+
+	now := time.Now()
+	since := time.Date(now.Year(), now.Month()-11, 1, 0, 0, 0, 0, time.UTC)
+	rows, err := h.queries.ListReceiptMonthTotals(ctx, sqlc.ListReceiptMonthTotalsParams{
+		FirmID: firmID(ctx),
+		Month:  since.Format("2006-01"),
+	})
+	if err != nil {
+		return summary, err
+	}
+	seen := make(map[string]bool)
+	for _, row := range rows {
+		if n := len(summary.Months); n == 0 || summary.Months[n-1].Label != row.Month {
+			summary.Months = append(summary.Months, pages.MonthReceipts{Label: row.Month, ByMode: make(map[string]float64)})
+		}
+		m := &summary.Months[len(summary.Months)-1]
+		m.Receipts += row.ReceiptCount
+		m.Amount += row.Amount
+		m.ByMode[row.PaymentMode] += row.Amount
+		if !seen[row.PaymentMode] {
+			seen[row.PaymentMode] = true
+			summary.Modes = append(summary.Modes, row.PaymentMode)
+		}
+	}
+	sort.Strings(summary.Modes)
+	for i := range summary.Months {
+		if t, err := time.Parse("2006-01", summary.Months[i].Label); err == nil {
+			summary.Months[i].Label = t.Format("Jan 2006")
+		}
+	}
+	return summary, nil
+}
+
+// Parties renders the party directory with outstanding balances, flagging
+// parties over their credit limit
+func (h *Handler) Parties(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.queries.ListPartyBalances(r.Context(), firmID(r.Context()))
+	if err != nil {
+		http.Error(w, "Error loading parties", http.StatusInternalServerError)
+		return
+	}
+
+	onlyBreached := r.URL.Query().Get("filter") == "breached"
+	breached := 0
+	parties := make([]pages.PartyBalance, 0, len(rows))
+	for _, p := range rows {
+		balance := partyBalance(p.ID, p.Name, p.Location.String, p.CreditLimit, p.Billed, p.Received, p.ReceiptCount)
+		if balance.Breached {
+			breached++
+		} else if onlyBreached {
+			continue
+		}
+		parties = append(parties, balance)
+	}
+
+	pages.Parties(parties, breached, onlyBreached).Render(r.Context(), w)
+}
+
+// UpdateCreditLimit sets a party's credit limit; an empty or zero limit
+// removes it
+func (h *Handler) UpdateCreditLimit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid party ID", http.StatusBadRequest)
+		return
+	}
+
+	limit := 0.0
+	if s := strings.TrimSpace(r.FormValue("credit_limit")); s != "" {
+		limit, err = strconv.ParseFloat(s, 64)
+		if err != nil || limit < 0 {
+			http.Error(w, "Credit limit must be a non-negative amount", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := h.queries.UpdatePartyCreditLimit(r.Context(), sqlc.UpdatePartyCreditLimitParams{
+		CreditLimit: limit,
+		ID:          id,
+	}); err != nil {
+		http.Error(w, "Error updating credit limit", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/party/%d", id), http.StatusSeeOther)
+}
+
+// Dashboard shows what needs attention today: parties over their credit limit,
+// what parties owe, receipts by month, cheques not yet cleared and bank
+// account balances. The cheques and balances
+// can be narrowed to one bank account.
+func (h *Handler) Dashboard(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	breaches, err := h.creditBreaches(ctx)
+	if err != nil {
+		http.Error(w, "Error loading credit limit breaches", http.StatusInternalServerError)
+		return
+	}
+
+	summary, err := h.dashboardSummary(ctx)
+	if err != nil {
+		http.Error(w, "Error loading receipt totals", http.StatusInternalServerError)
+		return
+	}
+
+	accountID, options, err := h.accountFilter(r)
+	if err != nil {
+		http.Error(w, "Error loading accounts", http.StatusInternalServerError)
+		return
+	}
+
+	pending, err := h.listCheques(ctx, chequeViews["pending"], accountID)
+	if err != nil {
+		http.Error(w, "Error loading cheques", http.StatusInternalServerError)
+		return
+	}
+	pendingTotal := 0.0
+	for _, c := range pending {
+		pendingTotal += c.Amount
+	}
+
+	balances, err := h.accountBalances(ctx)
+	if err != nil {
+		http.Error(w, "Error loading accounts", http.StatusInternalServerError)
+		return
+	}
+	accounts := balances[:0]
+	for _, b := range balances {
+		if accountID == 0 || b.ID == accountID {
+			accounts = append(accounts, b)
+		}
+	}
+
+	var lastBackup *sqlc.Backup
+	if b, err := h.queries.GetLatestBackup(ctx); err == nil {
+		lastBackup = &b
+	} else if err != sql.ErrNoRows {
+		http.Error(w, "Error loading backups", http.StatusInternalServerError)
+		return
+	}
+
+	pages.Dashboard(breaches, summary, options, accountID, len(pending), pendingTotal, accounts, lastBackup).Render(ctx, w)
+}
+
+// Digest returns a plain-text notification digest suitable for sending by
+// mail or messaging from a scheduled job, with a section for each firm
+func (h *Handler) Digest(w http.ResponseWriter, r *http.Request) {
+	digest, err := h.digest(r.Context())
+	if err != nil {
+		http.Error(w, "Error loading credit limit breaches", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(digest))
+}
+
+// digest writes the notification digest of every firm
+func (h *Handler) digest(ctx context.Context) (string, error) {
+	// Scheduled jobs run outside a request, with no firms on the context
+	firms := views.Firms(ctx)
+	if len(firms) == 0 {
+		rows, err := h.queries.ListFirms(ctx)
+		if err != nil {
+			return "", err
+		}
+		for _, f := range rows {
+			firms = append(firms, firmView(f))
+		}
+	}
+	if len(firms) == 0 {
+		firms = []views.Firm{views.CurrentFirm(ctx)}
+	}
+
+	var b strings.Builder
+	for i, firm := range firms {
+		breaches, err := h.creditBreaches(views.WithFirm(ctx, firm, firms))
+		if err != nil {
+			return "", err
+		}
+
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%s digest for %s\n\n", firm.Name, time.Now().Format("02 Jan 2006"))
+		if len(breaches) == 0 {
+			b.WriteString("Credit limits: no party is over its limit.\n")
+		} else {
+			fmt.Fprintf(&b, "Credit limits: %d parties over their limit\n", len(breaches))
+			for _, p := range breaches {
+				name := p.Name
+				if p.Location != "" {
+					name += " (" + p.Location + ")"
+				}
+				fmt.Fprintf(&b, "- %s: outstanding %.2f, limit %.2f, over by %.2f\n",
+					name, p.Outstanding, p.CreditLimit, p.Outstanding-p.CreditLimit)
+			}
+		}
+	}
+	return b.String(), nil
+}
+
+// EmailDigest emails the notification digest to each of the comma-separated
+// addresses in to, for the scheduled digest job
+func (h *Handler) EmailDigest(ctx context.Context, to string) (string, error) {
+	if h.mail == nil {
+		return "", fmt.Errorf("email is not set up")
+	}
+	digest, err := h.digest(ctx)
+	if err != nil {
+		return "", err
+	}
+	sent := 0
+	for _, addr := range strings.Split(to, ",") {
+		if addr = strings.TrimSpace(addr); addr == "" {
+			continue
+		}
+		if err := h.mail.Send(mailer.Message{
+			To:      addr,
+			Subject: "Digest for " + time.Now().Format("02 Jan 2006"),
+			Body:    digest,
+		}); err != nil {
+			return "", fmt.Errorf("emailing %s: %w", addr, err)
+		}
+		sent++
+	}
+	return fmt.Sprintf("emailed to %d addresses", sent), nil
+}
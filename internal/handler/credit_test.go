@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestCreditLimitBreaches(t *testing.T) {
+	h, db := newTestHandler(t)
+	exec(t, db, `INSERT INTO parties (id, name, location, credit_limit, firm_id) VALUES
+		(1, 'SHARMA MEDICAL', 'KANPUR', 1000, 1), (2, 'GUPTA STORES', '', 5000, 1), (3, 'VERMA AGENCIES', '', 100, 2)`)
+	exec(t, db, `INSERT INTO sale_bills (bill_number, bill_date, party_name, amount, is_cash_sale, party_id, firm_id) VALUES
+		('A-1', '2025-04-01 00:00:00 +0000 UTC', 'SHARMA MEDICAL', 1500, FALSE, 1, 1),
+		('A-2', '2025-04-01 00:00:00 +0000 UTC', 'GUPTA STORES', 1500, FALSE, 2, 1),
+		('B-1', '2025-04-01 00:00:00 +0000 UTC', 'VERMA AGENCIES', 900, FALSE, 3, 2)`)
+	exec(t, db, `INSERT INTO transactions (party_id, amount, transaction_date, payment_mode, narration, firm_id)
+		VALUES (1, 200, '2025-04-05 00:00:00 +0000 UTC', 'UPI', 'UPI/1', 1)`)
+
+	w := serve(h, http.HandlerFunc(h.Parties), httptest.NewRequest(http.MethodGet, "/parties?filter=breached", nil))
+	if body := w.Body.String(); !strings.Contains(body, "SHARMA MEDICAL") || strings.Contains(body, "GUPTA STORES") || strings.Contains(body, "VERMA AGENCIES") {
+		t.Errorf("parties over their limit are not SHARMA MEDICAL alone:\n%s", body)
+	}
+
+	digest, err := h.digest(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Durga Dawa Ghar digest", "Credit limits: 1 parties over their limit",
+		"- SHARMA MEDICAL (KANPUR): outstanding 1300.00, limit 1000.00, over by 300.00",
+		"Durga Pharma digest", "- VERMA AGENCIES: outstanding 900.00, limit 100.00, over by 800.00",
+	} {
+		if !strings.Contains(digest, want) {
+			t.Errorf("digest lacks %q:\n%s", want, digest)
+		}
+	}
+	if strings.Contains(digest, "GUPTA STORES") {
+		t.Errorf("digest lists a party within its limit:\n%s", digest)
+	}
+
+	setLimit := func(limit string) int {
+		return serve(h, http.HandlerFunc(h.UpdateCreditLimit), postForm("/party/credit-limit", url.Values{"id": {"1"}, "credit_limit": {limit}})).Code
+	}
+	if status := setLimit("-1"); status != http.StatusBadRequest {
+		t.Errorf("negative limit: status = %d", status)
+	}
+	// An empty limit removes it
+	if status := setLimit(""); status != http.StatusSeeOther {
+		t.Fatalf("removing the limit: status = %d", status)
+	}
+	if digest, _ := h.digest(context.Background()); strings.Contains(digest, "SHARMA MEDICAL") {
+		t.Errorf("a party without a limit is still over it:\n%s", digest)
+	}
+}
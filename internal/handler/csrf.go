@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"suspense.durgadawaghar.com/internal/views"
+)
+
+// csrfCookie holds the browser's token, which every form and htmx request
+// sends back as the csrf_token field or the X-CSRF-Token header
+const csrfCookie = "csrf_token"
+
+// csrfTokenBytes is the length of a token before hex encoding
+const csrfTokenBytes = 32
+
+// csrfExempt are the POST routes other programs call, which carry no token.
+// They only read, and without CORS headers another site can't read what
+// they return.
+var csrfExempt = map[string]bool{
+	"/graphql": true,
+}
+
+// CSRF turns away POSTs and other changing requests that don't carry the
+// browser's token, so another site can't submit forms to this server in the
+// name of someone who has it open. The token is a cookie other sites can't
+// read; pages put it in their forms and htmx headers.
+func CSRF(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := ""
+		if c, err := r.Cookie(csrfCookie); err == nil && len(c.Value) == 2*csrfTokenBytes {
+			token = c.Value
+		} else {
+			token = newCSRFToken()
+			http.SetCookie(w, &http.Cookie{
+				Name:     csrfCookie,
+				Value:    token,
+				Path:     "/",
+				MaxAge:   365 * 24 * 60 * 60,
+				HttpOnly: true,
+				Secure:   r.TLS != nil,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			if csrfExempt[r.URL.Path] {
+				break
+			}
+			sent, err := sentCSRFToken(w, r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+				return
+			}
+			if subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+				http.Error(w, "This form has expired or came from another site; reload the page and try again", http.StatusForbidden)
+				return
+			}
+		}
+		next.ServeHTTP(w, r.WithContext(views.WithCSRFToken(r.Context(), token)))
+	})
+}
+
+// sentCSRFToken returns the token a request carries. Only url-encoded forms
+// are read for it here, up to the import size limit, which is the largest
+// form any handler takes; a larger one is an error rather than a form without
+// a token. Uploads come from htmx, which sends the header, so their bodies are
+// left to the handlers' size limits.
+func sentCSRFToken(w http.ResponseWriter, r *http.Request) (string, error) {
+	if token := r.Header.Get("X-CSRF-Token"); token != "" {
+		return token, nil
+	}
+	if contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); contentType != "application/x-www-form-urlencoded" {
+		return "", nil
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportTextSize)
+	if err := r.ParseForm(); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return "", errFormTooLarge
+		}
+		return "", nil
+	}
+	return r.PostForm.Get(csrfCookie), nil
+}
+
+// errFormTooLarge turns away a form over maxImportTextSize before its token
+// is read
+var errFormTooLarge = fmt.Errorf("Form too large: a form may be up to %d MB", maxImportTextSize>>20)
+
+// newCSRFToken returns a random token
+func newCSRFToken() string {
+	b := make([]byte, csrfTokenBytes)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
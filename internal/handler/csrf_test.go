@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"suspense.durgadawaghar.com/internal/views"
+)
+
+func TestCSRF(t *testing.T) {
+	token := strings.Repeat("ab", csrfTokenBytes)
+	var got string // the data field the handler read
+	app := CSRF(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.FormValue("data")
+		w.Write([]byte(views.CSRFToken(r.Context())))
+	}))
+	form := func(v url.Values) *strings.Reader { return strings.NewReader(v.Encode()) }
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   *strings.Reader
+		cookie bool
+		header string
+		want   int
+	}{
+		{"GET passes without a cookie", http.MethodGet, "/parties", nil, false, "", http.StatusOK},
+		{"no cookie", http.MethodPost, "/import/preview", form(url.Values{"csrf_token": {token}, "data": {"x"}}), false, "", http.StatusForbidden},
+		{"no token", http.MethodPost, "/import/preview", form(url.Values{"data": {"x"}}), true, "", http.StatusForbidden},
+		{"token mismatch", http.MethodPost, "/import/preview", form(url.Values{"csrf_token": {strings.Repeat("cd", csrfTokenBytes)}, "data": {"x"}}), true, "", http.StatusForbidden},
+		{"header token mismatch", http.MethodPost, "/import/preview", form(url.Values{"csrf_token": {token}, "data": {"x"}}), true, "bad", http.StatusForbidden},
+		{"valid form token", http.MethodPost, "/import/preview", form(url.Values{"csrf_token": {token}, "data": {"x"}}), true, "", http.StatusOK},
+		{"valid header token", http.MethodPost, "/import/preview", form(url.Values{"data": {"x"}}), true, token, http.StatusOK},
+		{"GraphQL is exempt", http.MethodPost, "/graphql", strings.NewReader(`{"query":"{ __typename }"}`), false, "", http.StatusOK},
+		{"DELETE needs a token", http.MethodDelete, "/saved-searches/1", strings.NewReader(""), true, "", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = ""
+			var r *http.Request
+			if tt.body == nil {
+				r = httptest.NewRequest(tt.method, tt.path, nil)
+			} else {
+				r = httptest.NewRequest(tt.method, tt.path, tt.body)
+				r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+			if tt.cookie {
+				r.AddCookie(&http.Cookie{Name: csrfCookie, Value: token})
+			}
+			if tt.header != "" {
+				r.Header.Set("X-CSRF-Token", tt.header)
+			}
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			setsCookie := strings.Contains(w.Header().Get("Set-Cookie"), csrfCookie+"=")
+			if setsCookie == tt.cookie {
+				t.Errorf("Set-Cookie = %q with a cookie sent: %v", w.Header().Get("Set-Cookie"), tt.cookie)
+			}
+			if w.Code == http.StatusOK && tt.cookie {
+				if w.Body.String() != token {
+					t.Errorf("token on the context = %q", w.Body)
+				}
+				if got != "x" {
+					t.Errorf("handler read data = %q, want the form's", got)
+				}
+			}
+		})
+	}
+}
+
+func TestCSRFFormTooLarge(t *testing.T) {
+	token := strings.Repeat("ab", csrfTokenBytes)
+	app := CSRF(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("an oversized form reached the handler")
+	}))
+	body := "csrf_token=" + token + "&data=" + strings.Repeat("a", maxImportTextSize)
+	r := httptest.NewRequest(http.MethodPost, "/parties/notes", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.AddCookie(&http.Cookie{Name: csrfCookie, Value: token})
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+	if w.Code != http.StatusRequestEntityTooLarge || !strings.HasPrefix(w.Body.String(), "Form too large") {
+		t.Errorf("status = %d: %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"suspense.durgadawaghar.com/internal/dump"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// maxDumpFileSize limits uploaded JSON dumps
+const maxDumpFileSize = 512 << 20
+
+// ImportDump shows the form for loading a JSON dump of another instance
+func (h *Handler) ImportDump(w http.ResponseWriter, r *http.Request) {
+	pages.ImportDump().Render(r.Context(), w)
+}
+
+// ImportDumpFile loads an uploaded JSON dump into the database, every firm
+// included, so the data of two instances, such as the laptop and the office,
+// can be consolidated. The database is backed up first. Rows already here are
+// not added twice; parties that turn out to be the same customer under two
+// names are left to the duplicate scan, which is run afterwards.
+func (h *Handler) ImportDumpFile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxDumpFileSize)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		w.Write([]byte(`<div class="error">Choose a JSON dump up to 512 MB.</div>`))
+		return
+	}
+	defer file.Close()
+
+	mainFile, err := h.databaseFile(ctx)
+	if err != nil {
+		w.Write([]byte(fmt.Sprintf(`<div class="error">Error backing up: %s</div>`, html.EscapeString(err.Error()))))
+		return
+	}
+	dir := filepath.Join(filepath.Dir(mainFile), "backups")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		w.Write([]byte(fmt.Sprintf(`<div class="error">Error creating backup directory: %s</div>`, html.EscapeString(err.Error()))))
+		return
+	}
+	backupPath := filepath.Join(dir, "suspense-before-dump-import-"+time.Now().Format("2006-01-02-150405")+".db")
+	if _, err := h.backupTo(ctx, backupPath); err != nil {
+		w.Write([]byte(fmt.Sprintf(`<div class="error">Error backing up: %s</div>`, html.EscapeString(err.Error()))))
+		return
+	}
+
+	summary, err := dump.Load(ctx, h.db, file)
+	if err != nil {
+		w.Write([]byte(fmt.Sprintf(`<div class="error">Error importing dump, nothing was added: %s</div>`, html.EscapeString(err.Error()))))
+		return
+	}
+	// The import stands even if the scan fails; the nightly scan catches up
+	suggestions, _ := h.ScanDuplicates(ctx)
+	pages.ImportDumpResult(summary, backupPath, suggestions).Render(ctx, w)
+}
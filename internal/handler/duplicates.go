@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/dupes"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// ScanDuplicates queues the likely duplicate parties of every firm for
+// review, and reports how many pairs were found. It is run nightly, so
+// duplicates an import created are caught the next morning.
+func (h *Handler) ScanDuplicates(ctx context.Context) (int, error) {
+	firms, err := h.queries.ListFirms(ctx)
+	if err != nil {
+		return 0, err
+	}
+	found := 0
+	for _, firm := range firms {
+		n, err := h.scanFirmDuplicates(ctx, firm.ID)
+		if err != nil {
+			return found, fmt.Errorf("scanning %s: %w", firm.Name, err)
+		}
+		found += n
+	}
+	return found, nil
+}
+
+// scanFirmDuplicates queues the likely duplicate parties of a firm. Pairs
+// already queued are updated, and dismissed ones are left dismissed.
+func (h *Handler) scanFirmDuplicates(ctx context.Context, firmID int64) (int, error) {
+	rows, err := h.queries.ListParties(ctx, firmID)
+	if err != nil {
+		return 0, err
+	}
+	parties := make([]dupes.Party, len(rows))
+	for i, p := range rows {
+		parties[i] = dupes.Party{ID: p.ID, Name: p.Name, Location: p.Location.String}
+	}
+	linkRows, err := h.queries.ListIdentifierLinks(ctx, firmID)
+	if err != nil {
+		return 0, err
+	}
+	links := make([]dupes.Link, len(linkRows))
+	for i, l := range linkRows {
+		links[i] = dupes.Link{PartyID: l.PartyID, OtherPartyID: l.ClaimedPartyID, Identifier: l.Type + " " + l.Value}
+	}
+
+	suggestions := dupes.Find(parties, links)
+	for _, s := range suggestions {
+		if err := h.queries.UpsertMergeSuggestion(ctx, sqlc.UpsertMergeSuggestionParams{
+			FirmID:       firmID,
+			PartyID:      s.PartyID,
+			OtherPartyID: s.OtherPartyID,
+			Score:        s.Score,
+			Reasons:      strings.Join(s.Reasons, "; "),
+		}); err != nil {
+			return 0, err
+		}
+	}
+	return len(suggestions), nil
+}
+
+// DuplicateParties lists the suggested merges waiting for review
+func (h *Handler) DuplicateParties(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	suggestions, err := h.queries.ListMergeSuggestions(ctx, firmID(ctx))
+	if err != nil {
+		http.Error(w, "Error loading suggested merges", http.StatusInternalServerError)
+		return
+	}
+	pages.DuplicateParties(suggestions).Render(ctx, w)
+}
+
+// ScanDuplicatesNow runs the duplicate scan for the current firm without
+// waiting for the night
+func (h *Handler) ScanDuplicatesNow(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+	if _, err := h.scanFirmDuplicates(ctx, firmID(ctx)); err != nil {
+		http.Error(w, "Error scanning for duplicates", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/parties/duplicates", http.StatusSeeOther)
+}
+
+// DismissDuplicate marks a suggested merge as not duplicates, so later scans
+// don't suggest it again
+func (h *Handler) DismissDuplicate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid suggestion ID", http.StatusBadRequest)
+		return
+	}
+	if err := h.queries.DismissMergeSuggestion(ctx, sqlc.DismissMergeSuggestionParams{ID: id, FirmID: firmID(ctx)}); err != nil {
+		http.Error(w, "Error dismissing suggestion", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/parties/duplicates", http.StatusSeeOther)
+}
@@ -0,0 +1,250 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/dump"
+	"suspense.durgadawaghar.com/internal/tally"
+)
+
+// ExportReceipts downloads the current firm's receipt book entries between two
+// dates as CSV, from one bank account or all of them combined
+func (h *Handler) ExportReceipts(w http.ResponseWriter, r *http.Request) {
+	// Default to the last 30 days
+	fromDate, tillDate, _ := reportPeriod(r, time.Now().AddDate(0, 0, -30))
+
+	ctx := r.Context()
+
+	accountID, accounts, err := h.accountFilter(r)
+	if err != nil {
+		http.Error(w, "Error loading accounts", http.StatusInternalServerError)
+		return
+	}
+	labels := make(map[int64]string, len(accounts))
+	for _, a := range accounts {
+		labels[a.ID] = a.Label
+	}
+
+	var rows []sqlc.ListReceiptsForExportRow
+	if accountID == 0 {
+		rows, err = h.queries.ListReceiptsForExport(ctx, sqlc.ListReceiptsForExportParams{
+			FirmID:            firmID(ctx),
+			TransactionDate:   fromDate,
+			TransactionDate_2: tillDate,
+		})
+	} else {
+		var accountRows []sqlc.ListReceiptsForExportByAccountRow
+		accountRows, err = h.queries.ListReceiptsForExportByAccount(ctx, sqlc.ListReceiptsForExportByAccountParams{
+			FirmID:            firmID(ctx),
+			TransactionDate:   fromDate,
+			TransactionDate_2: tillDate,
+			AccountID:         sql.NullInt64{Int64: accountID, Valid: true},
+		})
+		for _, row := range accountRows {
+			rows = append(rows, sqlc.ListReceiptsForExportRow(row))
+		}
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Loading receipts: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	filename := fmt.Sprintf("receipts-%s-%s.csv", fromDate.Format("2006-01-02"), tillDate.Format("2006-01-02"))
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"Date", "Party", "Location", "Amount", "Mode", "Category", "Bank Account", "Narration"})
+	for _, row := range rows {
+		cw.Write([]string{
+			row.TransactionDate.Format("2006-01-02"),
+			row.PartyName,
+			row.PartyLocation.String,
+			fmt.Sprintf("%.2f", row.Amount),
+			row.PaymentMode.String,
+			row.Category,
+			labels[row.AccountID.Int64],
+			row.Narration.String,
+		})
+	}
+	cw.Flush()
+}
+
+// ExportTally downloads the current firm's receipts between two dates as
+// Tally receipt vouchers, each against the bills it pays as allocated here,
+// from one bank account or all of them. The bank ledger is the account's
+// label, or the ledger parameter for every receipt when Tally's ledger is
+// named otherwise; receipts in no account go to the ledger named Bank.
+// Bounced cheques are left out.
+func (h *Handler) ExportTally(w http.ResponseWriter, r *http.Request) {
+	fromDate, tillDate, _ := reportPeriod(r, time.Now().AddDate(0, 0, -30))
+	ctx := r.Context()
+
+	accountID, accounts, err := h.accountFilter(r)
+	if err != nil {
+		http.Error(w, "Error loading accounts", http.StatusInternalServerError)
+		return
+	}
+	labels := make(map[int64]string, len(accounts))
+	for _, a := range accounts {
+		labels[a.ID] = a.Label
+	}
+
+	all, err := h.queries.ListReceiptsForTally(ctx, sqlc.ListReceiptsForTallyParams{
+		FirmID:            firmID(ctx),
+		TransactionDate:   fromDate,
+		TransactionDate_2: tillDate,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Loading receipts: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+	bankLedger := strings.TrimSpace(r.FormValue("ledger"))
+	var rows []sqlc.ListReceiptsForTallyRow
+	for _, row := range all {
+		if accountID == 0 || row.AccountID.Int64 == accountID {
+			rows = append(rows, row)
+		}
+	}
+
+	// The bills each receipt pays, from its party's allocations, which
+	// depend on the party's receipts outside the period too
+	paid := make(map[int64][]tally.Bill)
+	loaded := make(map[int64]bool)
+	for _, row := range rows {
+		if loaded[row.PartyID] {
+			continue
+		}
+		loaded[row.PartyID] = true
+		bills, receipts, manual, err := h.partyAllocations(ctx, row.PartyID)
+		if err != nil {
+			http.Error(w, "Error loading allocations", http.StatusInternalServerError)
+			return
+		}
+		allocations := allocateReceipts(bills, receipts, manual, roundingTolerance(ctx))
+		for _, b := range bills {
+			for _, a := range allocations[b.ID] {
+				paid[a.TransactionID] = append(paid[a.TransactionID], tally.Bill{Number: b.BillNumber, Amount: a.Amount})
+			}
+		}
+	}
+
+	var vouchers []tally.Receipt
+	for _, row := range rows {
+		ledger := bankLedger
+		if ledger == "" {
+			ledger = labels[row.AccountID.Int64]
+		}
+		if ledger == "" {
+			ledger = "Bank"
+		}
+		vouchers = append(vouchers, tally.Receipt{
+			ID:        fmt.Sprintf("suspense-%d-%d", firmID(ctx), row.ID),
+			Date:      row.TransactionDate,
+			Party:     row.PartyName,
+			Ledger:    ledger,
+			Amount:    row.Amount,
+			Narration: row.Narration.String,
+			Bills:     paid[row.ID],
+		})
+	}
+
+	filename := fmt.Sprintf("tally-receipts-%s-%s.xml", fromDate.Format("2006-01-02"), tillDate.Format("2006-01-02"))
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	tally.WriteReceipts(w, vouchers)
+}
+
+// exportedIdentifier is an identifier-to-party mapping in the JSON export
+type exportedIdentifier struct {
+	Type      string `json:"type"`
+	Value     string `json:"value"`
+	PartyID   int64  `json:"party_id"`
+	PartyName string `json:"party_name"`
+	Location  string `json:"location,omitempty"`
+	FirstSeen string `json:"first_seen,omitempty"`
+	LastSeen  string `json:"last_seen,omitempty"`
+	HitCount  int64  `json:"hit_count"`
+}
+
+// ExportIdentifiers downloads the current firm's identifier knowledge base,
+// each identifier with the party it points to, when it was first and last seen
+// in imported entries and how many entries it appeared in. The format follows
+// the path: /export/identifiers.csv or /export/identifiers.json.
+func (h *Handler) ExportIdentifiers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rows, err := h.queries.ListIdentifiersForExport(ctx, firmID(ctx))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Loading identifiers: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	identifiers := make([]exportedIdentifier, len(rows))
+	for i, row := range rows {
+		identifiers[i] = exportedIdentifier{
+			Type:      row.Type,
+			Value:     row.Value,
+			PartyID:   row.PartyID,
+			PartyName: row.PartyName,
+			Location:  row.PartyLocation.String,
+			HitCount:  row.HitCount,
+		}
+		if row.FirstSeen.Valid {
+			identifiers[i].FirstSeen = row.FirstSeen.Time.Format("2006-01-02")
+		}
+		if row.LastSeen.Valid {
+			identifiers[i].LastSeen = row.LastSeen.Time.Format("2006-01-02")
+		}
+	}
+
+	filename := "identifiers-" + time.Now().Format("2006-01-02")
+	if strings.HasSuffix(r.URL.Path, ".json") {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, filename))
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(identifiers)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, filename))
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"Type", "Value", "Party ID", "Party", "Location", "First Seen", "Last Seen", "Hit Count"})
+	for _, id := range identifiers {
+		cw.Write([]string{
+			id.Type,
+			id.Value,
+			strconv.FormatInt(id.PartyID, 10),
+			id.PartyName,
+			id.Location,
+			id.FirstSeen,
+			id.LastSeen,
+			strconv.FormatInt(id.HitCount, 10),
+		})
+	}
+	cw.Flush()
+}
+
+// ExportDump downloads every table of the database, every firm included, as
+// one JSON document with the schema version, for offsite archival or loading
+// into analytical tools
+func (h *Handler) ExportDump(w http.ResponseWriter, r *http.Request) {
+	filename := "suspense-" + time.Now().Format("2006-01-02-1504") + ".json"
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	// The dump streams, so an error part way leaves it cut short rather than
+	// turning into an error page; a cut short dump does not parse
+	if err := dump.Write(r.Context(), h.reads, w); err != nil {
+		log.Printf("Exporting the database dump %s: %v", filename, err)
+	}
+}
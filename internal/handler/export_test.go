@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"suspense.durgadawaghar.com/internal/views"
+)
+
+// exportCSV runs an export and returns the records of the CSV it downloads,
+// without the header
+func exportCSV(t *testing.T, h *Handler, handler http.HandlerFunc, target string) [][]string {
+	t.Helper()
+	w := serve(h, handler, httptest.NewRequest(http.MethodGet, target, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("%s: status = %d: %s", target, w.Code, w.Body)
+	}
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil || len(records) == 0 {
+		t.Fatalf("%s: %v", target, err)
+	}
+	return records[1:]
+}
+
+func TestExportReceiptsByAccount(t *testing.T) {
+	h, db := newTestHandler(t)
+	exec(t, db, `INSERT INTO accounts (id, bank, account_number, firm_id) VALUES (1, 'ICICI', '192105002017', 1), (2, 'PNB', '0451000100', 1), (3, 'ICICI', '192105009999', 2)`)
+	exec(t, db, `INSERT INTO parties (id, name, location, firm_id) VALUES (1, 'SHARMA MEDICAL', 'KANPUR', 1), (2, 'VERMA AGENCIES', '', 2)`)
+	exec(t, db, `INSERT INTO transactions (party_id, amount, transaction_date, payment_mode, narration, account_id, firm_id) VALUES
+		(1, 500, '2025-04-01 00:00:00 +0000 UTC', 'NEFT', 'NEFT/ICICI', 1, 1),
+		(1, 700, '2025-04-02 00:00:00 +0000 UTC', 'UPI', 'UPI/PNB', 2, 1),
+		(2, 900, '2025-04-02 00:00:00 +0000 UTC', 'UPI', 'UPI/OTHER FIRM', 3, 2)`)
+	narrations := func(query string) []string {
+		var got []string
+		for _, record := range exportCSV(t, h, h.ExportReceipts, "/export/receipts.csv?from_date=2025-04-01&till_date=2025-04-30"+query) {
+			got = append(got, record[6]+" "+record[7])
+		}
+		return got
+	}
+
+	for query, want := range map[string][]string{
+		"":           {"ICICI 192105002017 NEFT/ICICI", "PNB 0451000100 UPI/PNB"},
+		"&account=1": {"ICICI 192105002017 NEFT/ICICI"},
+		"&account=2": {"PNB 0451000100 UPI/PNB"},
+		// another firm's account is no filter
+		"&account=3": {"ICICI 192105002017 NEFT/ICICI", "PNB 0451000100 UPI/PNB"},
+	} {
+		if got := narrations(query); !reflect.DeepEqual(got, want) {
+			t.Errorf("receipts exported with %q = %q, want %q", query, got, want)
+		}
+	}
+}
+
+func TestExportIdentifiers(t *testing.T) {
+	h, db := newTestHandler(t)
+	ctx := views.WithFirm(context.Background(), views.Firm{ID: 1, Name: "Durga Dawa Ghar"}, nil)
+	// Two payments from one VPA, the later one listed first; importing the
+	// book again adds no sightings
+	book := "Dec 26 SANDHYA MEDICAL STORE LUCKNOW 5000.00\nUPI/SANDHYA@YBL 5000.00\n\n" +
+		"Dec 20 SANDHYA MEDICAL STORE LUCKNOW 1200.00\nUPI/SANDHYA@YBL 1200.00"
+	for range 2 {
+		if _, err := h.importReceiptBook(ctx, book, 2025); err != nil {
+			t.Fatal(err)
+		}
+	}
+	exec(t, db, `INSERT INTO parties (id, name, firm_id) VALUES (99, 'VERMA AGENCIES', 2)`)
+	exec(t, db, `INSERT INTO identifiers (party_id, type, value, firm_id) VALUES (99, 'upi_vpa', 'verma@ybl', 2)`)
+
+	var vpas [][]string
+	for _, record := range exportCSV(t, h, h.ExportIdentifiers, "/export/identifiers.csv") {
+		if record[0] == "upi_vpa" {
+			vpas = append(vpas, append(record[1:2], record[3:]...))
+		}
+	}
+	want := [][]string{{"SANDHYA@YBL", "SANDHYA MEDICAL STORE", "LUCKNOW", "2025-12-20", "2025-12-26", "2"}}
+	if !reflect.DeepEqual(vpas, want) {
+		t.Errorf("exported VPAs %q, want %q", vpas, want)
+	}
+
+	w := serve(h, http.HandlerFunc(h.ExportIdentifiers), httptest.NewRequest(http.MethodGet, "/export/identifiers.json", nil))
+	var identifiers []exportedIdentifier
+	if err := json.NewDecoder(w.Body).Decode(&identifiers); err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, id := range identifiers {
+		if id.Type == "upi_vpa" {
+			found = id.Value == "SANDHYA@YBL" && id.FirstSeen == "2025-12-20" && id.LastSeen == "2025-12-26" && id.HitCount == 2
+		}
+	}
+	if !found {
+		t.Errorf("JSON export lacks the VPA's sightings: %+v", identifiers)
+	}
+}
@@ -0,0 +1,678 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"suspense.durgadawaghar.com/internal/billcheck"
+	"suspense.durgadawaghar.com/internal/category"
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/fy"
+	"suspense.durgadawaghar.com/internal/tracing"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// errClosedYear is returned for entries dated in a closed financial year
+var errClosedYear = errors.New("dated in closed financial year")
+
+// openingMode marks the receipts and openingBill the sale bills that carry an
+// archived year's closing balances into the next year
+const (
+	openingMode = "OPENING"
+	openingBill = "OPENING "
+)
+
+// closableYears is how many past financial years the close form offers
+const closableYears = 6
+
+// checkOpenYear returns errClosedYear, naming the year, when date falls in a
+// closed financial year of the current firm
+func (h *Handler) checkOpenYear(ctx context.Context, date time.Time) error {
+	year, err := h.queries.GetFinancialYearForDate(ctx, sqlc.GetFinancialYearForDateParams{
+		FirmID:    firmID(ctx),
+		StartDate: date,
+		EndDate:   date,
+	})
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%w %s", errClosedYear, year.Label)
+}
+
+// FinancialYears lists the firm's closed financial years, with forms to close
+// another and to search archived years
+func (h *Handler) FinancialYears(w http.ResponseWriter, r *http.Request) {
+	h.renderFinancialYears(w, r, "")
+}
+
+func (h *Handler) renderFinancialYears(w http.ResponseWriter, r *http.Request, formError string) {
+	ctx := r.Context()
+	years, err := h.queries.ListFinancialYears(ctx, firmID(ctx))
+	if err != nil {
+		http.Error(w, "Error loading financial years", http.StatusInternalServerError)
+		return
+	}
+
+	closed := make(map[string]bool, len(years))
+	for _, y := range years {
+		closed[y.Label] = true
+	}
+	var closable []string
+	y := fy.Of(time.Now()).Prev()
+	for i := 0; i < closableYears; i, y = i+1, y.Prev() {
+		if !closed[y.Label()] {
+			closable = append(closable, y.Label())
+		}
+	}
+	pages.FinancialYears(years, closable, formError).Render(ctx, w)
+}
+
+// YearEnd guides closing a financial year that has ended: what to settle
+// before closing it, each party's balances over it as they stand, and closing
+// it, rolling the balances over into the next year if asked, to its closing
+// report
+func (h *Handler) YearEnd(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	years, err := h.queries.ListFinancialYears(ctx, firmID(ctx))
+	if err != nil {
+		http.Error(w, "Error loading financial years", http.StatusInternalServerError)
+		return
+	}
+	closed := make(map[string]bool, len(years))
+	for _, y := range years {
+		closed[y.Label] = true
+	}
+	// The oldest year not closed comes first, as years are closed in turn
+	var closable []string
+	y := fy.Of(time.Now()).Prev()
+	for i := 0; i < closableYears; i, y = i+1, y.Prev() {
+		if !closed[y.Label()] {
+			closable = append([]string{y.Label()}, closable...)
+		}
+	}
+	if len(closable) == 0 {
+		http.Redirect(w, r, "/financial-years", http.StatusSeeOther)
+		return
+	}
+	year, err := fy.Parse(r.URL.Query().Get("year"))
+	if err != nil || closed[year.Label()] || !year.End().Before(time.Now()) {
+		year, _ = fy.Parse(closable[0])
+	}
+
+	checks, err := h.yearEndChecks(ctx, year)
+	if err != nil {
+		http.Error(w, "Error checking the year", http.StatusInternalServerError)
+		return
+	}
+	rows, err := yearEndBalances(ctx, h.queries, firmID(ctx), year)
+	if err != nil {
+		http.Error(w, "Error computing balances", http.StatusInternalServerError)
+		return
+	}
+	balances := make([]pages.ClosingBalance, 0, len(rows))
+	for _, b := range rows {
+		balances = append(balances, pages.ClosingBalance{
+			ID:           b.ID,
+			Name:         b.Name,
+			Location:     b.Location,
+			Opening:      b.Opening,
+			Billed:       b.YearBilled,
+			Received:     b.YearReceived,
+			Closing:      b.Billed - b.Received,
+			HasMovements: true,
+		})
+	}
+	pages.YearEnd(year.Label(), closable, checks, balances).Render(ctx, w)
+}
+
+// yearEndChecks counts what is better settled before year is closed, as its
+// entries cannot be changed after: credit bills not linked to a party,
+// cheques of the year neither cleared nor bounced, and sale bills that look
+// like data entry errors
+func (h *Handler) yearEndChecks(ctx context.Context, year fy.Year) (pages.YearEndChecks, error) {
+	var checks pages.YearEndChecks
+	unlinked, err := h.queries.ListUnlinkedSaleBills(ctx, firmID(ctx))
+	if err != nil {
+		return checks, err
+	}
+	for _, b := range unlinked {
+		if !b.BillDate.Before(year.Start()) && !b.BillDate.After(year.End()) {
+			checks.UnlinkedBills++
+		}
+	}
+
+	cheques, err := h.queries.ListChequesByStatus(ctx, sqlc.ListChequesByStatusParams{
+		FirmID:   firmID(ctx),
+		Statuses: []string{"received", "deposited"},
+	})
+	if err != nil {
+		return checks, err
+	}
+	for _, c := range cheques {
+		if !c.ReceivedDate.After(year.End()) {
+			checks.PendingCheques++
+		}
+	}
+
+	bills, err := h.billsToCheck(ctx, year.Start(), year.End())
+	if err != nil {
+		return checks, err
+	}
+	report := billcheck.Check(bills)
+	checks.BillAnomalies = len(report.Duplicates) + len(report.NonPositive) + len(report.Gaps) + len(report.OutOfOrder)
+	return checks, nil
+}
+
+// CloseFinancialYear closes a financial year that has ended: its receipts and
+// sale bills become read-only, and each party's balance at its end is kept
+func (h *Handler) CloseFinancialYear(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	year, err := fy.Parse(strings.TrimSpace(r.FormValue("year")))
+	if err != nil {
+		h.renderFinancialYears(w, r, err.Error())
+		return
+	}
+	if !year.End().Before(time.Now()) {
+		h.renderFinancialYears(w, r, fmt.Sprintf("%s has not ended yet", year.Label()))
+		return
+	}
+
+	closed, err := h.closeFinancialYear(ctx, year)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			err = fmt.Errorf("%s is already closed", year.Label())
+		}
+		h.renderFinancialYears(w, r, err.Error())
+		return
+	}
+
+	// The year-end page closes and rolls the balances over in one step
+	if r.FormValue("rollover") != "" {
+		if err := h.archiveFinancialYear(ctx, closed); err != nil {
+			h.renderFinancialYears(w, r, fmt.Sprintf("%s was closed, but archiving it failed: %s", year.Label(), err.Error()))
+			return
+		}
+	}
+	if r.FormValue("report") != "" {
+		http.Redirect(w, r, fmt.Sprintf("/financial-years/balances?id=%d", closed.ID), http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, "/financial-years", http.StatusSeeOther)
+}
+
+// closeFinancialYear records year as closed with every party's credit sales
+// and receipts up to its end, and its opening balance and movements in the
+// year for the closing report
+func (h *Handler) closeFinancialYear(ctx context.Context, year fy.Year) (sqlc.FinancialYear, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return sqlc.FinancialYear{}, err
+	}
+	defer tx.Rollback()
+	q := h.queries.WithTx(tx)
+
+	closed, err := q.CloseFinancialYear(ctx, sqlc.CloseFinancialYearParams{
+		FirmID:    firmID(ctx),
+		Label:     year.Label(),
+		StartDate: year.Start(),
+		EndDate:   year.End(),
+	})
+	if err != nil {
+		return closed, err
+	}
+	balances, err := yearEndBalances(ctx, q, firmID(ctx), year)
+	if err != nil {
+		return closed, fmt.Errorf("computing closing balances: %w", err)
+	}
+	for _, b := range balances {
+		if err := q.AddFinancialYearBalance(ctx, sqlc.AddFinancialYearBalanceParams{
+			FinancialYearID: closed.ID,
+			PartyID:         b.ID,
+			Billed:          b.Billed,
+			Received:        b.Received,
+			Opening:         sql.NullFloat64{Float64: b.Opening, Valid: true},
+			YearBilled:      sql.NullFloat64{Float64: b.YearBilled, Valid: true},
+			YearReceived:    sql.NullFloat64{Float64: b.YearReceived, Valid: true},
+		}); err != nil {
+			return closed, fmt.Errorf("saving closing balance of %s: %w", b.Name, err)
+		}
+	}
+	return closed, tx.Commit()
+}
+
+// yearEndBalance is a party's credit sales and receipts up to a financial
+// year's end, and of them its balance brought into the year and its credit
+// sales and receipts in the year
+type yearEndBalance struct {
+	ID           int64
+	Name         string
+	Location     string
+	Billed       float64
+	Received     float64
+	Opening      float64
+	YearBilled   float64
+	YearReceived float64
+}
+
+// yearEndBalances computes each party's balances over year. The opening
+// balance entries that archiving or purging an earlier year dated on the
+// year's first day count towards the opening balance rather than the year's
+// sales and receipts.
+func yearEndBalances(ctx context.Context, q *sqlc.Queries, firm int64, year fy.Year) ([]yearEndBalance, error) {
+	total, err := q.ListPartyMovements(ctx, sqlc.ListPartyMovementsParams{
+		BillDate:          time.Time{},
+		BillDate_2:        year.End(),
+		TransactionDate:   time.Time{},
+		TransactionDate_2: year.End(),
+		FirmID:            firm,
+	})
+	if err != nil {
+		return nil, err
+	}
+	inYear, err := q.ListPartyMovements(ctx, sqlc.ListPartyMovementsParams{
+		BillDate:          year.Start(),
+		BillDate_2:        year.End(),
+		TransactionDate:   year.Start(),
+		TransactionDate_2: year.End(),
+		FirmID:            firm,
+	})
+	if err != nil {
+		return nil, err
+	}
+	entries, err := q.ListOpeningEntries(ctx, sqlc.ListOpeningEntriesParams{
+		FirmID:          firm,
+		BillNumber:      openingBill + year.Label(),
+		BillDate:        year.Start(),
+		FirmID_2:        firm,
+		TransactionDate: year.Start(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	type movement struct{ billed, received float64 }
+	moved := make(map[int64]movement, len(inYear))
+	for _, m := range inYear {
+		moved[m.ID] = movement{m.Billed, m.Received}
+	}
+	for _, e := range entries {
+		m := moved[e.PartyID.Int64]
+		if e.Amount > 0 {
+			m.billed -= e.Amount
+		} else {
+			m.received += e.Amount
+		}
+		moved[e.PartyID.Int64] = m
+	}
+
+	balances := make([]yearEndBalance, len(total))
+	for i, t := range total {
+		m := moved[t.ID]
+		balances[i] = yearEndBalance{
+			ID:           t.ID,
+			Name:         t.Name,
+			Location:     t.Location.String,
+			Billed:       t.Billed,
+			Received:     t.Received,
+			Opening:      math.Round(((t.Billed-t.Received)-(m.billed-m.received))*100) / 100,
+			YearBilled:   math.Round(m.billed*100) / 100,
+			YearReceived: math.Round(m.received*100) / 100,
+		}
+	}
+	return balances, nil
+}
+
+// ReopenFinancialYear makes a closed year editable again, unless it has been
+// archived
+func (h *Handler) ReopenFinancialYear(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+	id, _ := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err := h.queries.ReopenFinancialYear(ctx, sqlc.ReopenFinancialYearParams{ID: id, FirmID: firmID(ctx)}); err != nil {
+		http.Error(w, "Error reopening financial year", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/financial-years", http.StatusSeeOther)
+}
+
+// FinancialYearBalances shows the closing report of a closed year: each
+// party's balance brought into the year, its credit sales and receipts in the
+// year and its closing balance, as they stood when the year was closed
+func (h *Handler) FinancialYearBalances(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	year, balances, err := h.closingReport(r)
+	if err == sql.ErrNoRows {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error loading closing balances", http.StatusInternalServerError)
+		return
+	}
+	pages.FinancialYearBalances(year, balances).Render(ctx, w)
+}
+
+// ExportClosingReport downloads a closed year's closing report as CSV. Years
+// closed before the report was kept have only their closing balances.
+func (h *Handler) ExportClosingReport(w http.ResponseWriter, r *http.Request) {
+	year, balances, err := h.closingReport(r)
+	if err == sql.ErrNoRows {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Loading closing balances: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="closing-balances-%s.csv"`, year.Label))
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"Party", "Location", "Opening Balance", "Billed in Year", "Received in Year", "Closing Balance"})
+	for _, b := range balances {
+		opening, billed, received := "", "", ""
+		if b.HasMovements {
+			opening = fmt.Sprintf("%.2f", b.Opening)
+			billed = fmt.Sprintf("%.2f", b.Billed)
+			received = fmt.Sprintf("%.2f", b.Received)
+		}
+		cw.Write([]string{b.Name, b.Location, opening, billed, received, fmt.Sprintf("%.2f", b.Closing)})
+	}
+	cw.Flush()
+}
+
+// closingReport loads the closed year of the id parameter and its closing
+// balances
+func (h *Handler) closingReport(r *http.Request) (sqlc.FinancialYear, []pages.ClosingBalance, error) {
+	ctx := r.Context()
+	id, _ := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	year, err := h.queries.GetFinancialYear(ctx, sqlc.GetFinancialYearParams{ID: id, FirmID: firmID(ctx)})
+	if err != nil {
+		return year, nil, err
+	}
+	rows, err := h.queries.ListFinancialYearBalances(ctx, year.ID)
+	if err != nil {
+		return year, nil, err
+	}
+	balances := make([]pages.ClosingBalance, len(rows))
+	for i, b := range rows {
+		balances[i] = pages.ClosingBalance{
+			ID:           b.ID,
+			Name:         b.Name,
+			Location:     b.Location.String,
+			Opening:      b.Opening.Float64,
+			Billed:       b.YearBilled.Float64,
+			Received:     b.YearReceived.Float64,
+			Closing:      b.Billed - b.Received,
+			HasMovements: b.Opening.Valid,
+		}
+	}
+	return year, balances, nil
+}
+
+// ArchiveFinancialYear moves a closed year's receipts and sale bills to a
+// database file of their own beside the main one, and carries each party's
+// balance over those entries into the next year as an opening balance: a
+// sale bill for an amount owed, a receipt for an advance. Party balances are
+// unchanged, and the archive stays searchable.
+func (h *Handler) ArchiveFinancialYear(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+	id, _ := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	year, err := h.queries.GetFinancialYear(ctx, sqlc.GetFinancialYearParams{ID: id, FirmID: firmID(ctx)})
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if year.ArchivedAt.Valid {
+		h.renderFinancialYears(w, r, fmt.Sprintf("%s is already archived", year.Label))
+		return
+	}
+
+	if err := h.archiveFinancialYear(ctx, year); err != nil {
+		h.renderFinancialYears(w, r, fmt.Sprintf("Error archiving %s: %s", year.Label, err.Error()))
+		return
+	}
+	http.Redirect(w, r, "/financial-years", http.StatusSeeOther)
+}
+
+// archiveCopy selects the rows of a table copied to a year's archive
+type archiveCopy struct {
+	table string
+	where string
+	args  []any
+}
+
+// archiveCopies lists what goes into a year's archive: its receipts, their
+// cheques, tags, splits, merges and agents, its sale bills, and allocations
+// by hand of either, with the firms, accounts, parties and agents they refer
+// to so names and accounts still show
+func archiveCopies(year sqlc.FinancialYear) []archiveCopy {
+	return []archiveCopy{
+		{"firms", "", nil},
+		{"accounts", "", nil},
+		{"agents", "WHERE firm_id = ?", []any{year.FirmID}},
+		{"parties", "WHERE firm_id = ?", []any{year.FirmID}},
+		{"transactions", "WHERE firm_id = ? AND transaction_date BETWEEN ? AND ?", []any{year.FirmID, year.StartDate, year.EndDate}},
+		{"cheques", "WHERE transaction_id IN (SELECT id FROM archive.transactions)", nil},
+		{"transaction_tags", "WHERE transaction_id IN (SELECT id FROM archive.transactions)", nil},
+		{"transaction_splits", "WHERE transaction_id IN (SELECT id FROM archive.transactions)", nil},
+		{"transaction_merges", "WHERE transaction_id IN (SELECT id FROM archive.transactions)", nil},
+		{"transaction_agents", "WHERE transaction_id IN (SELECT id FROM archive.transactions)", nil},
+		{"sale_bills", "WHERE firm_id = ? AND bill_date BETWEEN ? AND ?", []any{year.FirmID, year.StartDate, year.EndDate}},
+		{"bill_allocations", "WHERE transaction_id IN (SELECT id FROM archive.transactions) OR sale_bill_id IN (SELECT id FROM archive.sale_bills)", nil},
+	}
+}
+
+// createTable matches the start of a table's schema
+var createTable = regexp.MustCompile(`^CREATE TABLE (IF NOT EXISTS )?`)
+
+// copyToArchive creates table in the attached archive database with the main
+// table's schema, so dates read back as dates, and copies the rows where
+// selects
+func copyToArchive(ctx context.Context, tx *sql.Tx, table, where string, args ...any) error {
+	var schema string
+	if err := tx.QueryRowContext(ctx, "SELECT sql FROM main.sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&schema); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, createTable.ReplaceAllString(schema, "CREATE TABLE archive.")); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, "INSERT INTO archive."+table+" SELECT * FROM main."+table+" "+where, args...)
+	return err
+}
+
+// archiveDeletes remove the copied entries from the main database
+var archiveDeletes = []string{
+	"DELETE FROM main.transaction_tags WHERE transaction_id IN (SELECT id FROM archive.transactions)",
+	"DELETE FROM main.transaction_splits WHERE transaction_id IN (SELECT id FROM archive.transactions)",
+	"DELETE FROM main.transaction_merges WHERE transaction_id IN (SELECT id FROM archive.transactions)",
+	"DELETE FROM main.transaction_agents WHERE transaction_id IN (SELECT id FROM archive.transactions)",
+	"DELETE FROM main.bill_allocations WHERE transaction_id IN (SELECT id FROM archive.transactions) OR sale_bill_id IN (SELECT id FROM archive.sale_bills)",
+	"DELETE FROM main.cheques WHERE transaction_id IN (SELECT id FROM archive.transactions)",
+	"DELETE FROM main.transactions WHERE id IN (SELECT id FROM archive.transactions)",
+	"DELETE FROM main.sale_bills WHERE id IN (SELECT id FROM archive.sale_bills)",
+}
+
+func (h *Handler) archiveFinancialYear(ctx context.Context, year sqlc.FinancialYear) error {
+	mainFile, err := h.databaseFile(ctx)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(filepath.Dir(mainFile), fmt.Sprintf("archive-%d-%s.db", year.FirmID, year.Label))
+	// Left over from an attempt that failed
+	os.Remove(path)
+
+	// ATTACH applies to one connection, and cannot run in a transaction
+	conn, err := h.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "ATTACH DATABASE ? AS archive", path); err != nil {
+		return fmt.Errorf("creating %s: %w", path, err)
+	}
+	defer conn.ExecContext(context.Background(), "DETACH DATABASE archive")
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	q := h.queries.WithTx(tx)
+
+	movements, err := q.ListPartyMovements(ctx, sqlc.ListPartyMovementsParams{
+		BillDate:          year.StartDate,
+		BillDate_2:        year.EndDate,
+		TransactionDate:   year.StartDate,
+		TransactionDate_2: year.EndDate,
+		FirmID:            year.FirmID,
+	})
+	if err != nil {
+		return fmt.Errorf("computing balances: %w", err)
+	}
+	for _, c := range archiveCopies(year) {
+		if err := copyToArchive(ctx, tx, c.table, c.where, c.args...); err != nil {
+			return fmt.Errorf("copying %s: %w", c.table, err)
+		}
+	}
+	// Marked archived first, since entries of closed years cannot be deleted
+	if err := q.SetFinancialYearArchived(ctx, sqlc.SetFinancialYearArchivedParams{ArchivePath: path, ID: year.ID}); err != nil {
+		return err
+	}
+	for _, stmt := range archiveDeletes {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("removing archived entries: %w", err)
+		}
+	}
+
+	next := fy.Of(year.EndDate).Next()
+	for _, m := range movements {
+		balance := math.Round((m.Billed-m.Received)*100) / 100
+		if balance == 0 {
+			continue
+		}
+		if err := addOpeningBalance(ctx, q, year.FirmID, m.ID, m.Name, next, balance); err != nil {
+			return fmt.Errorf("carrying over balance of %s: %w", m.Name, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		os.Remove(path)
+		return err
+	}
+	return nil
+}
+
+// addOpeningBalance records a party's balance brought forward into year: a
+// credit sale bill when it owes, a receipt when it paid in advance
+func addOpeningBalance(ctx context.Context, q *sqlc.Queries, firm, partyID int64, name string, year fy.Year, balance float64) error {
+	if balance > 0 {
+		_, err := q.CreateSaleBill(ctx, sqlc.CreateSaleBillParams{
+			BillNumber: openingBill + year.Label(),
+			BillDate:   year.Start(),
+			PartyName:  name,
+			Amount:     balance,
+			IsCashSale: sql.NullBool{Bool: false, Valid: true},
+			PartyID:    sql.NullInt64{Int64: partyID, Valid: true},
+			FirmID:     firm,
+		})
+		return err
+	}
+	_, err := q.CreateTransaction(ctx, sqlc.CreateTransactionParams{
+		PartyID:         partyID,
+		Amount:          -balance,
+		TransactionDate: year.Start(),
+		PaymentMode:     sql.NullString{String: openingMode, Valid: true},
+		Narration:       sql.NullString{String: "OPENING BALANCE " + year.Label(), Valid: true},
+		Category:        string(category.Receipt),
+		FirmID:          firm,
+	})
+	return err
+}
+
+// SearchArchives searches the receipts and sale bills of archived years by
+// party name, narration or bill number
+func (h *Handler) SearchArchives(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	years, err := h.queries.ListFinancialYears(ctx, firmID(ctx))
+	if err != nil {
+		http.Error(w, "Error loading financial years", http.StatusInternalServerError)
+		return
+	}
+
+	var results []pages.ArchiveResult
+	var errs []string
+	if query != "" {
+		for _, year := range years {
+			if !year.ArchivedAt.Valid {
+				continue
+			}
+			res, err := searchArchive(ctx, year, query)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %s", year.Label, err.Error()))
+				continue
+			}
+			if len(res.Receipts) > 0 || len(res.Bills) > 0 {
+				results = append(results, res)
+			}
+		}
+	}
+	pages.ArchiveSearch(query, results, errs).Render(ctx, w)
+}
+
+// searchArchive searches one archived year's database
+func searchArchive(ctx context.Context, year sqlc.FinancialYear, query string) (pages.ArchiveResult, error) {
+	res := pages.ArchiveResult{Year: year.Label}
+	if _, err := os.Stat(year.ArchivePath); err != nil {
+		return res, fmt.Errorf("archive file missing: %s", year.ArchivePath)
+	}
+	db, err := sql.Open("sqlite", "file:"+year.ArchivePath+"?mode=ro")
+	if err != nil {
+		return res, err
+	}
+	defer db.Close()
+	q := sqlc.New(tracing.WrapDB(db))
+
+	pattern := "%" + query + "%"
+	res.Receipts, err = q.SearchReceipts(ctx, sqlc.SearchReceiptsParams{
+		FirmID:    year.FirmID,
+		Narration: sql.NullString{String: pattern, Valid: true},
+		Name:      pattern,
+	})
+	if err != nil {
+		return res, err
+	}
+	res.Bills, err = q.SearchSaleBills(ctx, sqlc.SearchSaleBillsParams{
+		FirmID:     year.FirmID,
+		PartyName:  pattern,
+		BillNumber: pattern,
+	})
+	return res, err
+}
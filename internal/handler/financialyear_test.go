@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"suspense.durgadawaghar.com/internal/fy"
+)
+
+func TestCloseFinancialYear(t *testing.T) {
+	h, db := newTestHandler(t)
+	exec(t, db, `INSERT INTO parties (id, name, location, firm_id) VALUES (1, 'SHARMA MEDICAL', 'KANPUR', 1), (2, 'GUPTA STORES', 'UNNAO', 1), (3, 'VERMA AGENCIES', '', 2)`)
+	exec(t, db, `INSERT INTO sale_bills (bill_number, bill_date, party_name, amount, party_id, firm_id) VALUES
+		('A-1', '2023-05-01 00:00:00 +0000 UTC', 'SHARMA MEDICAL', 1000, 1, 1),
+		('A-2', '2024-05-01 00:00:00 +0000 UTC', 'SHARMA MEDICAL', 200, 1, 1),
+		('B-1', '2023-05-01 00:00:00 +0000 UTC', 'VERMA AGENCIES', 700, 3, 2)`)
+	exec(t, db, `INSERT INTO transactions (party_id, amount, transaction_date, payment_mode, narration, firm_id) VALUES
+		(1, 600, '2023-06-01 00:00:00 +0000 UTC', 'UPI', 'UPI/SHARMA', 1),
+		(2, 300, '2023-07-01 00:00:00 +0000 UTC', 'UPI', 'UPI/GUPTA ADVANCE', 1)`)
+	closeYear := func(form url.Values) *httptest.ResponseRecorder {
+		return serve(h, http.HandlerFunc(h.CloseFinancialYear), postForm("/financial-years/close", form))
+	}
+
+	if w := closeYear(url.Values{"year": {fy.Of(time.Now()).Label()}}); !strings.Contains(w.Body.String(), "has not ended yet") {
+		t.Errorf("closing the current year: %s", w.Body)
+	}
+
+	// Closing with the rollover locks the year, archives its entries and
+	// carries each balance into the next year
+	w := closeYear(url.Values{"year": {"2023-24"}, "rollover": {"1"}, "report": {"1"}})
+	report, found := strings.CutPrefix(w.Header().Get("Location"), "/financial-years/balances?")
+	if w.Code != http.StatusSeeOther || !found {
+		t.Fatalf("closing 2023-24: status %d: %s", w.Code, w.Body)
+	}
+	if w := closeYear(url.Values{"year": {"2023-24"}}); !strings.Contains(w.Body.String(), "already closed") {
+		t.Errorf("closing 2023-24 again: %s", w.Body)
+	}
+
+	want := [][]string{
+		{"GUPTA STORES", "UNNAO", "0.00", "0.00", "300.00", "-300.00"},
+		{"SHARMA MEDICAL", "KANPUR", "0.00", "1000.00", "600.00", "400.00"},
+	}
+	if got := exportCSV(t, h, h.ExportClosingReport, "/export/closing-balances.csv?"+report); !reflect.DeepEqual(got, want) {
+		t.Errorf("closing report:\n%q\nwant\n%q", got, want)
+	}
+
+	for query, want := range map[string]float64{
+		"SELECT COUNT(*) FROM transactions WHERE narration LIKE 'UPI/%'":                                          0,
+		"SELECT COUNT(*) FROM sale_bills WHERE bill_number = 'A-1'":                                               0,
+		"SELECT COUNT(*) FROM sale_bills WHERE bill_number = 'OPENING 2024-25' AND party_id = 1 AND amount = 400": 1,
+		"SELECT COUNT(*) FROM transactions WHERE payment_mode = 'OPENING' AND party_id = 2 AND amount = 300":      1,
+		"SELECT COUNT(*) FROM sale_bills WHERE bill_number = 'B-1'":                                               1,
+		"SELECT billed - received FROM party_balances WHERE party_id = 1":                                         600,
+		"SELECT billed - received FROM party_balances WHERE party_id = 2":                                         -300,
+	} {
+		if n := count(t, db, query); n != want {
+			t.Errorf("%s = %v, want %v", query, n, want)
+		}
+	}
+
+	var file string
+	if err := db.QueryRow("SELECT archive_path FROM financial_years WHERE label = '2023-24'").Scan(&file); err != nil || file == "" {
+		t.Fatalf("year not marked archived: %v", err)
+	}
+	archive, err := sql.Open("sqlite", file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+	if n := count(t, archive, "SELECT COUNT(*) FROM transactions"); n != 2 {
+		t.Errorf("archive holds %v receipts, want 2", n)
+	}
+
+	// Entries of the closed year can no longer be added
+	if _, err := db.Exec(`INSERT INTO transactions (party_id, amount, transaction_date, payment_mode, narration, firm_id)
+		VALUES (1, 50, '2023-08-01 00:00:00 +0000 UTC', 'UPI', 'UPI/LATE', 1)`); err == nil || !strings.Contains(err.Error(), "closed financial year") {
+		t.Errorf("receipt dated in the closed year: %v", err)
+	}
+}
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/views"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// firmCookie remembers the firm chosen with the switcher
+const firmCookie = "firm"
+
+func firmView(f sqlc.Firm) views.Firm {
+	return views.Firm{ID: f.ID, Name: f.Name, GSTIN: f.Gstin, RoundingTolerance: f.RoundingTolerance}
+}
+
+// firmID returns the ID of the firm a request works in
+func firmID(ctx context.Context) int64 {
+	return views.CurrentFirm(ctx).ID
+}
+
+// roundingTolerance returns how far amounts may differ in the firm a request
+// works in and still match
+func roundingTolerance(ctx context.Context) float64 {
+	return views.CurrentFirm(ctx).RoundingTolerance
+}
+
+// WithFirm puts the firm chosen with the switcher on each request's context,
+// defaulting to the first firm
+func (h *Handler) WithFirm(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		firms, err := h.queries.ListFirms(r.Context())
+		if err != nil || len(firms) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		list := make([]views.Firm, len(firms))
+		for i, f := range firms {
+			list[i] = firmView(f)
+		}
+		current := list[0]
+		if c, err := r.Cookie(firmCookie); err == nil {
+			if id, err := strconv.ParseInt(c.Value, 10, 64); err == nil {
+				for _, f := range list {
+					if f.ID == id {
+						current = f
+					}
+				}
+			}
+		}
+		next.ServeHTTP(w, r.WithContext(views.WithFirm(r.Context(), current, list)))
+	})
+}
+
+// withPartyFirm returns ctx working in the party's firm, for pages about one
+// party that are shown whichever firm is selected, or to someone outside
+func (h *Handler) withPartyFirm(ctx context.Context, party sqlc.Party) context.Context {
+	firm, err := h.queries.GetFirm(ctx, party.FirmID)
+	if err != nil {
+		return ctx
+	}
+	return views.WithFirm(ctx, firmView(firm), views.Firms(ctx))
+}
+
+// SwitchFirm changes the firm being worked in and returns to the page the
+// switcher was used on
+func (h *Handler) SwitchFirm(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.FormValue("firm_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid firm ID", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.queries.GetFirm(r.Context(), id); err != nil {
+		http.Error(w, "Firm not found", http.StatusBadRequest)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     firmCookie,
+		Value:    strconv.FormatInt(id, 10),
+		Path:     "/",
+		Expires:  time.Now().AddDate(1, 0, 0),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	// Pages of a single party or bill belong to the other firm
+	back := "/"
+	if ref, err := url.Parse(r.Referer()); err == nil && ref.Path != "" {
+		back = ref.Path
+		if strings.HasPrefix(back, "/party/") || strings.HasPrefix(back, "/sale-bill/") {
+			back = "/parties"
+		} else if ref.RawQuery != "" {
+			back += "?" + ref.RawQuery
+		}
+	}
+	http.Redirect(w, r, back, http.StatusSeeOther)
+}
+
+// Firms lists the firms for naming them and adding another
+func (h *Handler) Firms(w http.ResponseWriter, r *http.Request) {
+	firms, err := h.queries.ListFirms(r.Context())
+	if err != nil {
+		http.Error(w, "Error loading firms", http.StatusInternalServerError)
+		return
+	}
+	list := make([]views.Firm, len(firms))
+	for i, f := range firms {
+		list[i] = firmView(f)
+	}
+	pages.Firms(list).Render(r.Context(), w)
+}
+
+// SaveFirm renames a firm or sets its GSTIN and rounding tolerance, or adds a
+// new firm when no ID is given
+func (h *Handler) SaveFirm(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	name := strings.TrimSpace(r.FormValue("name"))
+	gstin := strings.ToUpper(strings.TrimSpace(r.FormValue("gstin")))
+	if name == "" {
+		http.Error(w, "Firm name is required", http.StatusBadRequest)
+		return
+	}
+	tolerance := 0.0
+	if s := strings.TrimSpace(r.FormValue("rounding_tolerance")); s != "" {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil || v < 0 {
+			http.Error(w, "Rounding tolerance must be an amount of zero or more", http.StatusBadRequest)
+			return
+		}
+		tolerance = math.Round(v*100) / 100
+	}
+
+	var err error
+	if idStr := r.FormValue("id"); idStr != "" {
+		id, perr := strconv.ParseInt(idStr, 10, 64)
+		if perr != nil {
+			http.Error(w, "Invalid firm ID", http.StatusBadRequest)
+			return
+		}
+		err = h.queries.UpdateFirm(ctx, sqlc.UpdateFirmParams{Name: name, Gstin: gstin, RoundingTolerance: tolerance, ID: id})
+	} else {
+		_, err = h.queries.CreateFirm(ctx, sqlc.CreateFirmParams{Name: name, Gstin: gstin, RoundingTolerance: tolerance})
+	}
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			http.Error(w, fmt.Sprintf("A firm named %s already exists", name), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Error saving firm", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/firms", http.StatusSeeOther)
+}
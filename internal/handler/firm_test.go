@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestSwitchFirm(t *testing.T) {
+	h, db := newTestHandler(t)
+	exec(t, db, `INSERT INTO parties (id, name, firm_id) VALUES (1, 'SHARMA MEDICAL', 1), (2, 'VERMA AGENCIES', 2)`)
+	switchTo := func(firm, referer string) *httptest.ResponseRecorder {
+		r := postForm("/firms/switch", url.Values{"firm_id": {firm}})
+		r.Header.Set("Referer", referer)
+		return serve(h, http.HandlerFunc(h.SwitchFirm), r)
+	}
+	parties := func(cookie string) string {
+		r := httptest.NewRequest(http.MethodGet, "/parties", nil)
+		if cookie != "" {
+			r.AddCookie(&http.Cookie{Name: firmCookie, Value: cookie})
+		}
+		return serve(h, http.HandlerFunc(h.Parties), r).Body.String()
+	}
+
+	if w := switchTo("3", "/parties"); w.Code != http.StatusBadRequest {
+		t.Errorf("switching to no firm: status = %d", w.Code)
+	}
+	for referer, back := range map[string]string{
+		"http://shop.local/cheques?view=pending": "/cheques?view=pending",
+		// a party or bill of the first firm is not one of the second
+		"http://shop.local/party/1":     "/parties",
+		"http://shop.local/sale-bill/4": "/parties",
+		"":                              "/",
+	} {
+		w := switchTo("2", referer)
+		if w.Code != http.StatusSeeOther || w.Header().Get("Location") != back {
+			t.Errorf("switching from %q: status %d to %q, want %q", referer, w.Code, w.Header().Get("Location"), back)
+		}
+		if c := w.Result().Cookies(); len(c) != 1 || c[0].Name != firmCookie || c[0].Value != "2" || !c[0].HttpOnly {
+			t.Errorf("switching sets cookies %v", c)
+		}
+	}
+
+	// Each firm sees its own parties; a cookie of no firm gets the first
+	for cookie, want := range map[string]string{"": "SHARMA MEDICAL", "1": "SHARMA MEDICAL", "2": "VERMA AGENCIES", "9": "SHARMA MEDICAL"} {
+		body := parties(cookie)
+		other := "VERMA AGENCIES"
+		if want == other {
+			other = "SHARMA MEDICAL"
+		}
+		if !strings.Contains(body, want) || strings.Contains(body, other) {
+			t.Errorf("with firm cookie %q the directory does not list %s alone", cookie, want)
+		}
+	}
+}
+
+func TestSaveFirm(t *testing.T) {
+	h, db := newTestHandler(t)
+	save := func(form url.Values) *httptest.ResponseRecorder {
+		return serve(h, http.HandlerFunc(h.SaveFirm), postForm("/firms/save", form))
+	}
+
+	for name, tt := range map[string]struct {
+		form    url.Values
+		problem string
+	}{
+		"no name":              {url.Values{"name": {" "}}, "name is required"},
+		"a name in use":        {url.Values{"name": {"Durga Pharma"}}, "already exists"},
+		"a negative tolerance": {url.Values{"name": {"Durga Surgicals"}, "rounding_tolerance": {"-1"}}, "zero or more"},
+	} {
+		if w := save(tt.form); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), tt.problem) {
+			t.Errorf("saving a firm with %s: status %d: %s", name, w.Code, w.Body)
+		}
+	}
+
+	if w := save(url.Values{"name": {" Durga Surgicals "}, "gstin": {"09bbbbb1111b1z5"}, "rounding_tolerance": {"0.999"}}); w.Code != http.StatusSeeOther {
+		t.Fatalf("adding a firm: status %d: %s", w.Code, w.Body)
+	}
+	if n := count(t, db, "SELECT rounding_tolerance FROM firms WHERE name = 'Durga Surgicals' AND gstin = '09BBBBB1111B1Z5'"); n != 1 {
+		t.Errorf("new firm's tolerance = %v, want 1", n)
+	}
+	if w := save(url.Values{"id": {"2"}, "name": {"Durga Pharma Distributors"}}); w.Code != http.StatusSeeOther {
+		t.Fatalf("renaming a firm: status %d: %s", w.Code, w.Body)
+	}
+	if n := count(t, db, "SELECT COUNT(*) FROM firms WHERE id = 2 AND name = 'Durga Pharma Distributors'"); n != 1 {
+		t.Errorf("firm not renamed")
+	}
+}
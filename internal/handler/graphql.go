@@ -0,0 +1,719 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"suspense.durgadawaghar.com/internal/category"
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/graphql"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// maxGraphQLPage is the most parties, transactions or bills one list field
+// returns; longer lists are fetched a page at a time with offset
+const maxGraphQLPage = 1000
+
+// maxGraphQLRequestSize limits a posted query and its variables
+const maxGraphQLRequestSize = 1 << 20
+
+// GraphQL answers GraphQL queries over parties, receipts, sale bills and the
+// allocations between them, for reporting tools that want nested data in one
+// request. Queries are posted as JSON or given in the query string; there are
+// no mutations.
+func (h *Handler) GraphQL(w http.ResponseWriter, r *http.Request) {
+	var req graphql.Request
+	switch r.Method {
+	case http.MethodGet:
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if vars := r.URL.Query().Get("variables"); vars != "" {
+			if err := decodeJSON(strings.NewReader(vars), &req.Variables); err != nil {
+				writeGraphQL(w, http.StatusBadRequest, &graphql.Response{Errors: []*graphql.Error{{Message: "variables are not valid JSON: " + err.Error()}}})
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := decodeJSON(http.MaxBytesReader(w, r.Body, maxGraphQLRequestSize), &req); err != nil {
+			writeGraphQL(w, http.StatusBadRequest, &graphql.Response{Errors: []*graphql.Error{{Message: "the request is not valid JSON: " + err.Error()}}})
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), graphqlCacheKey{}, &graphqlCache{
+		ledgers:  make(map[int64]*partyLedger),
+		balances: make(map[int64]sqlc.GetPartyBalanceRow),
+	})
+	resp := h.schema.Execute(ctx, req)
+	status := http.StatusOK
+	if resp.Data == nil {
+		status = http.StatusBadRequest
+	}
+	writeGraphQL(w, status, resp)
+}
+
+// GraphQLSchema describes the GraphQL API in the schema language
+func (h *Handler) GraphQLSchema(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	io.WriteString(w, h.schema.SDL())
+}
+
+// decodeJSON reads JSON keeping numbers as json.Number, so large IDs in
+// variables survive
+func decodeJSON(r io.Reader, v any) error {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+func writeGraphQL(w http.ResponseWriter, status int, resp *graphql.Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// graphqlCache holds what a query loads per party, so a party's ledger and
+// balance are loaded once however many fields need them. Fields are resolved
+// one at a time, so it needs no lock.
+type graphqlCache struct {
+	ledgers  map[int64]*partyLedger
+	balances map[int64]sqlc.GetPartyBalanceRow
+}
+
+type graphqlCacheKey struct{}
+
+// partyLedger is a party's credit bills, oldest first, and receipts, with how
+// the receipts are allocated to the bills
+type partyLedger struct {
+	bills       []sqlc.SaleBill
+	receipts    map[int64]sqlc.Transaction
+	allocations map[int64][]pages.BillAllocation // by bill ID
+}
+
+// billAllocation is part of a receipt allocated to a bill
+type billAllocation struct {
+	bill     sqlc.SaleBill
+	receipt  sqlc.Transaction
+	amount   float64
+	byHand   bool
+	rounding float64 // the shortfall written off as rounding
+}
+
+// ledger loads a party's ledger, once per query
+func (h *Handler) ledger(ctx context.Context, partyID int64) (*partyLedger, error) {
+	cache, _ := ctx.Value(graphqlCacheKey{}).(*graphqlCache)
+	if cache != nil {
+		if l, ok := cache.ledgers[partyID]; ok {
+			return l, nil
+		}
+	}
+	bills, receipts, manual, err := h.partyAllocations(ctx, partyID)
+	if err != nil {
+		return nil, fmt.Errorf("loading allocations: %w", err)
+	}
+	l := &partyLedger{
+		bills:       bills,
+		receipts:    make(map[int64]sqlc.Transaction, len(receipts)),
+		allocations: allocateReceipts(bills, receipts, manual, roundingTolerance(ctx)),
+	}
+	for _, t := range receipts {
+		l.receipts[t.ID] = t
+	}
+	if cache != nil {
+		cache.ledgers[partyID] = l
+	}
+	return l, nil
+}
+
+// billAllocations lists the allocations to a bill
+func (l *partyLedger) billAllocations(bill sqlc.SaleBill) []billAllocation {
+	var list []billAllocation
+	for _, a := range l.allocations[bill.ID] {
+		list = append(list, billAllocation{
+			bill:     bill,
+			receipt:  l.receipts[a.TransactionID],
+			amount:   math.Round(a.Amount*100) / 100,
+			byHand:   a.ByHand,
+			rounding: math.Round(a.RoundingDiff*100) / 100,
+		})
+	}
+	return list
+}
+
+// partyBalance loads a party's totals, once per query
+func (h *Handler) partyBalance(ctx context.Context, partyID int64) (sqlc.GetPartyBalanceRow, error) {
+	cache, _ := ctx.Value(graphqlCacheKey{}).(*graphqlCache)
+	if cache != nil {
+		if b, ok := cache.balances[partyID]; ok {
+			return b, nil
+		}
+	}
+	b, err := h.queries.GetPartyBalance(ctx, partyID)
+	if err != nil {
+		return b, fmt.Errorf("loading balance: %w", err)
+	}
+	if cache != nil {
+		cache.balances[partyID] = b
+	}
+	return b, nil
+}
+
+// graphqlID reads an ID argument, which for every type here is a number
+func graphqlID(args graphql.Args) (int64, error) {
+	id, err := strconv.ParseInt(args.String("id"), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not an ID", args.String("id"))
+	}
+	return id, nil
+}
+
+// graphqlPeriod reads the from and till arguments, leaving the period open
+// at an end not given
+func graphqlPeriod(args graphql.Args) (from, till time.Time, err error) {
+	from, till = time.Time{}, time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+	if args.Has("from") {
+		if from, err = time.Parse("2006-01-02", args.String("from")); err != nil {
+			return from, till, fmt.Errorf("from must be a date as YYYY-MM-DD")
+		}
+	}
+	if args.Has("till") {
+		if till, err = time.Parse("2006-01-02", args.String("till")); err != nil {
+			return from, till, fmt.Errorf("till must be a date as YYYY-MM-DD")
+		}
+	}
+	return from, till, nil
+}
+
+// graphqlPage reads the limit and offset arguments
+func graphqlPage(args graphql.Args) (limit, offset int, err error) {
+	limit, offset = args.Int("limit"), args.Int("offset")
+	if limit < 0 || limit > maxGraphQLPage {
+		return 0, 0, fmt.Errorf("limit must be from 0 to %d", maxGraphQLPage)
+	}
+	if offset < 0 {
+		return 0, 0, fmt.Errorf("offset must not be negative")
+	}
+	return limit, offset, nil
+}
+
+// inPeriod keeps the items of list dated from till, then a page of them
+func inPeriod[T any](list []T, date func(T) time.Time, args graphql.Args) ([]T, error) {
+	from, till, err := graphqlPeriod(args)
+	if err != nil {
+		return nil, err
+	}
+	limit, offset, err := graphqlPage(args)
+	if err != nil {
+		return nil, err
+	}
+	var kept []T
+	for _, item := range list {
+		if d := date(item); !d.Before(from) && !d.After(till) {
+			kept = append(kept, item)
+		}
+	}
+	kept = kept[min(offset, len(kept)):]
+	return kept[:min(limit, len(kept))], nil
+}
+
+func nullString(s sql.NullString) any {
+	if !s.Valid {
+		return nil
+	}
+	return s.String
+}
+
+// nonEmpty is null for an empty string, for columns that are blank rather
+// than NULL when unset
+func nonEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func graphqlDate(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+func nullDate(t sql.NullTime) any {
+	if !t.Valid {
+		return nil
+	}
+	return graphqlDate(t.Time)
+}
+
+// graphqlSchema builds the schema of the GraphQL API
+func (h *Handler) graphqlSchema() *graphql.Schema {
+	firm := &graphql.Object{Name: "Firm", Description: "A firm whose accounts are kept here."}
+	party := &graphql.Object{Name: "Party", Description: "A customer."}
+	transaction := &graphql.Object{Name: "Transaction", Description: "An entry from the receipt book: a receipt, or a bank charge, interest or transfer."}
+	bill := &graphql.Object{Name: "Bill", Description: "A sale bill."}
+	allocation := &graphql.Object{Name: "Allocation", Description: "Part of a receipt paying a credit bill, allocated by hand or first in, first out."}
+	identifier := &graphql.Object{Name: "Identifier", Description: "A UPI ID, account number, phone number or the like seen in a party's narrations."}
+	cheque := &graphql.Object{Name: "Cheque", Description: "A cheque received, and how far it has got to clearing."}
+	billKind := &graphql.Enum{Name: "BillKind", Values: []string{"CREDIT", "CASH", "CARD"}}
+
+	nonNull := func(t graphql.Type) graphql.Type { return &graphql.NonNull{Of: t} }
+	listOf := func(t graphql.Type) graphql.Type { return nonNull(&graphql.List{Of: nonNull(t)}) }
+	period := []*graphql.Argument{
+		{Name: "from", Type: graphql.String, Description: "The first date, as YYYY-MM-DD."},
+		{Name: "till", Type: graphql.String, Description: "The last date, as YYYY-MM-DD."},
+		{Name: "limit", Type: graphql.Int, Default: 100, Description: fmt.Sprintf("At most %d.", maxGraphQLPage)},
+		{Name: "offset", Type: graphql.Int, Default: 0},
+	}
+	byID := []*graphql.Argument{{Name: "id", Type: nonNull(graphql.ID)}}
+
+	firm.Fields = []*graphql.Field{
+		{Name: "id", Type: nonNull(graphql.ID), Resolve: graphql.Value(func(f sqlc.Firm) any { return f.ID })},
+		{Name: "name", Type: nonNull(graphql.String), Resolve: graphql.Value(func(f sqlc.Firm) any { return f.Name })},
+		{Name: "gstin", Type: nonNull(graphql.String), Resolve: graphql.Value(func(f sqlc.Firm) any { return f.Gstin })},
+		{Name: "roundingTolerance", Type: nonNull(graphql.Float), Description: "How far a payment may differ from a bill and still be taken for it.", Resolve: graphql.Value(func(f sqlc.Firm) any { return f.RoundingTolerance })},
+		{
+			Name:        "parties",
+			Description: "The firm's parties by name, those whose name contains search if given.",
+			Type:        listOf(party),
+			Args: []*graphql.Argument{
+				{Name: "search", Type: graphql.String},
+				{Name: "limit", Type: graphql.Int, Default: 100, Description: fmt.Sprintf("At most %d.", maxGraphQLPage)},
+				{Name: "offset", Type: graphql.Int, Default: 0},
+			},
+			Resolve: func(ctx context.Context, source any, args graphql.Args) (any, error) {
+				limit, offset, err := graphqlPage(args)
+				if err != nil {
+					return nil, err
+				}
+				parties, err := h.queries.ListParties(ctx, source.(sqlc.Firm).ID)
+				if err != nil {
+					return nil, fmt.Errorf("loading parties: %w", err)
+				}
+				search := strings.ToLower(args.String("search"))
+				var matched []sqlc.Party
+				for _, p := range parties {
+					if strings.Contains(strings.ToLower(p.Name), search) {
+						matched = append(matched, p)
+					}
+				}
+				matched = matched[min(offset, len(matched)):]
+				return matched[:min(limit, len(matched))], nil
+			},
+		},
+		{
+			Name:        "transactions",
+			Description: "The firm's receipt book entries in a period, newest first.",
+			Type:        listOf(transaction),
+			Args:        period,
+			Resolve: func(ctx context.Context, source any, args graphql.Args) (any, error) {
+				from, till, err := graphqlPeriod(args)
+				if err != nil {
+					return nil, err
+				}
+				limit, offset, err := graphqlPage(args)
+				if err != nil {
+					return nil, err
+				}
+				return h.queries.ListTransactionsInPeriod(ctx, sqlc.ListTransactionsInPeriodParams{
+					FirmID:            source.(sqlc.Firm).ID,
+					TransactionDate:   from,
+					TransactionDate_2: till,
+					Limit:             int64(limit),
+					Offset:            int64(offset),
+				})
+			},
+		},
+		{
+			Name:        "bills",
+			Description: "The firm's sale bills in a period, newest first.",
+			Type:        listOf(bill),
+			Args:        period,
+			Resolve: func(ctx context.Context, source any, args graphql.Args) (any, error) {
+				from, till, err := graphqlPeriod(args)
+				if err != nil {
+					return nil, err
+				}
+				limit, offset, err := graphqlPage(args)
+				if err != nil {
+					return nil, err
+				}
+				return h.queries.ListSaleBillsInPeriod(ctx, sqlc.ListSaleBillsInPeriodParams{
+					FirmID:     source.(sqlc.Firm).ID,
+					BillDate:   from,
+					BillDate_2: till,
+					Limit:      int64(limit),
+					Offset:     int64(offset),
+				})
+			},
+		},
+	}
+
+	party.Fields = []*graphql.Field{
+		{Name: "id", Type: nonNull(graphql.ID), Resolve: graphql.Value(func(p sqlc.Party) any { return p.ID })},
+		{Name: "name", Type: nonNull(graphql.String), Resolve: graphql.Value(func(p sqlc.Party) any { return p.Name })},
+		{Name: "location", Type: graphql.String, Resolve: graphql.Value(func(p sqlc.Party) any { return nullString(p.Location) })},
+		{Name: "email", Type: nonNull(graphql.String), Resolve: graphql.Value(func(p sqlc.Party) any { return p.Email })},
+		{Name: "phone", Type: nonNull(graphql.String), Resolve: graphql.Value(func(p sqlc.Party) any { return p.Phone })},
+		{Name: "creditLimit", Type: nonNull(graphql.Float), Description: "0 when the party has no limit.", Resolve: graphql.Value(func(p sqlc.Party) any { return p.CreditLimit })},
+		{
+			Name:        "billed",
+			Description: "The total of the party's credit bills.",
+			Type:        nonNull(graphql.Float),
+			Resolve: func(ctx context.Context, source any, _ graphql.Args) (any, error) {
+				b, err := h.partyBalance(ctx, source.(sqlc.Party).ID)
+				return b.Billed, err
+			},
+		},
+		{
+			Name:        "received",
+			Description: "The total of the party's receipts, bounced cheques left out.",
+			Type:        nonNull(graphql.Float),
+			Resolve: func(ctx context.Context, source any, _ graphql.Args) (any, error) {
+				b, err := h.partyBalance(ctx, source.(sqlc.Party).ID)
+				return b.Received, err
+			},
+		},
+		{
+			Name:        "outstanding",
+			Description: "What the party owes: billed less received.",
+			Type:        nonNull(graphql.Float),
+			Resolve: func(ctx context.Context, source any, _ graphql.Args) (any, error) {
+				b, err := h.partyBalance(ctx, source.(sqlc.Party).ID)
+				return math.Round((b.Billed-b.Received)*100) / 100, err
+			},
+		},
+		{
+			Name:        "transactions",
+			Description: "The party's receipt book entries, newest first.",
+			Type:        listOf(transaction),
+			Args:        period,
+			Resolve: func(ctx context.Context, source any, args graphql.Args) (any, error) {
+				transactions, err := h.queries.GetTransactionsByPartyID(ctx, source.(sqlc.Party).ID)
+				if err != nil {
+					return nil, fmt.Errorf("loading transactions: %w", err)
+				}
+				return inPeriod(transactions, func(t sqlc.Transaction) time.Time { return t.TransactionDate }, args)
+			},
+		},
+		{
+			Name:        "bills",
+			Description: "The party's sale bills, newest first.",
+			Type:        listOf(bill),
+			Args:        period,
+			Resolve: func(ctx context.Context, source any, args graphql.Args) (any, error) {
+				id := source.(sqlc.Party).ID
+				bills, err := h.queries.GetSaleBillsByPartyID(ctx, sql.NullInt64{Int64: id, Valid: true})
+				if err != nil {
+					return nil, fmt.Errorf("loading bills: %w", err)
+				}
+				return inPeriod(bills, func(b sqlc.SaleBill) time.Time { return b.BillDate }, args)
+			},
+		},
+		{
+			Name:        "allocations",
+			Description: "How the party's receipts pay its credit bills, oldest bill first.",
+			Type:        listOf(allocation),
+			Resolve: func(ctx context.Context, source any, _ graphql.Args) (any, error) {
+				l, err := h.ledger(ctx, source.(sqlc.Party).ID)
+				if err != nil {
+					return nil, err
+				}
+				var list []billAllocation
+				for _, b := range l.bills {
+					list = append(list, l.billAllocations(b)...)
+				}
+				return list, nil
+			},
+		},
+		{
+			Name: "identifiers",
+			Type: listOf(identifier),
+			Resolve: func(ctx context.Context, source any, _ graphql.Args) (any, error) {
+				return h.queries.GetIdentifiersByPartyID(ctx, source.(sqlc.Party).ID)
+			},
+		},
+	}
+
+	transaction.Fields = []*graphql.Field{
+		{Name: "id", Type: nonNull(graphql.ID), Resolve: graphql.Value(func(t sqlc.Transaction) any { return t.ID })},
+		{Name: "date", Type: nonNull(graphql.String), Resolve: graphql.Value(func(t sqlc.Transaction) any { return graphqlDate(t.TransactionDate) })},
+		{Name: "amount", Type: nonNull(graphql.Float), Resolve: graphql.Value(func(t sqlc.Transaction) any { return t.Amount })},
+		{Name: "paymentMode", Type: graphql.String, Resolve: graphql.Value(func(t sqlc.Transaction) any { return nullString(t.PaymentMode) })},
+		{Name: "narration", Type: graphql.String, Resolve: graphql.Value(func(t sqlc.Transaction) any { return nullString(t.Narration) })},
+		{Name: "category", Type: nonNull(graphql.String), Description: "receipt, bank_charge, interest, internal_transfer or other.", Resolve: graphql.Value(func(t sqlc.Transaction) any { return t.Category })},
+		{Name: "internal", Type: nonNull(graphql.Boolean), Description: "Whether the entry is a transfer between the firm's own accounts.", Resolve: graphql.Value(func(t sqlc.Transaction) any { return t.IsInternal })},
+		{Name: "note", Type: nonNull(graphql.String), Resolve: graphql.Value(func(t sqlc.Transaction) any { return t.Note })},
+		{
+			Name: "party",
+			Type: party,
+			Resolve: func(ctx context.Context, source any, _ graphql.Args) (any, error) {
+				p, err := h.queries.GetPartyByID(ctx, source.(sqlc.Transaction).PartyID)
+				if errors.Is(err, sql.ErrNoRows) {
+					return nil, nil
+				}
+				return p, err
+			},
+		},
+		{
+			Name:        "allocations",
+			Description: "The credit bills this receipt pays, oldest first; none for other entries and bounced cheques.",
+			Type:        listOf(allocation),
+			Resolve: func(ctx context.Context, source any, _ graphql.Args) (any, error) {
+				t := source.(sqlc.Transaction)
+				if t.Category != string(category.Receipt) {
+					return []billAllocation{}, nil
+				}
+				l, err := h.ledger(ctx, t.PartyID)
+				if err != nil {
+					return nil, err
+				}
+				var list []billAllocation
+				for _, b := range l.bills {
+					for _, a := range l.billAllocations(b) {
+						if a.receipt.ID == t.ID {
+							list = append(list, a)
+						}
+					}
+				}
+				return list, nil
+			},
+		},
+	}
+
+	bill.Fields = []*graphql.Field{
+		{Name: "id", Type: nonNull(graphql.ID), Resolve: graphql.Value(func(b sqlc.SaleBill) any { return b.ID })},
+		{Name: "number", Type: nonNull(graphql.String), Resolve: graphql.Value(func(b sqlc.SaleBill) any { return b.BillNumber })},
+		{Name: "date", Type: nonNull(graphql.String), Resolve: graphql.Value(func(b sqlc.SaleBill) any { return graphqlDate(b.BillDate) })},
+		{Name: "amount", Type: nonNull(graphql.Float), Resolve: graphql.Value(func(b sqlc.SaleBill) any { return b.Amount })},
+		{Name: "partyName", Type: nonNull(graphql.String), Description: "The name on the bill, which may differ from its party's.", Resolve: graphql.Value(func(b sqlc.SaleBill) any { return b.PartyName })},
+		{Name: "kind", Type: nonNull(billKind), Resolve: graphql.Value(func(b sqlc.SaleBill) any { return saleBillKind(b) })},
+		{Name: "taxableValue", Type: nonNull(graphql.Float), Description: "The amount before tax; 0 when the bill's tax breakup was not imported.", Resolve: graphql.Value(func(b sqlc.SaleBill) any { return b.TaxableValue })},
+		{Name: "cgst", Type: nonNull(graphql.Float), Resolve: graphql.Value(func(b sqlc.SaleBill) any { return b.Cgst })},
+		{Name: "sgst", Type: nonNull(graphql.Float), Resolve: graphql.Value(func(b sqlc.SaleBill) any { return b.Sgst })},
+		{Name: "igst", Type: nonNull(graphql.Float), Resolve: graphql.Value(func(b sqlc.SaleBill) any { return b.Igst })},
+		{Name: "irn", Type: graphql.String, Description: "The e-invoice's Invoice Reference Number.", Resolve: graphql.Value(func(b sqlc.SaleBill) any { return nonEmpty(b.Irn) })},
+		{Name: "ackNumber", Type: graphql.String, Resolve: graphql.Value(func(b sqlc.SaleBill) any { return nonEmpty(b.AckNumber) })},
+		{
+			Name: "ackDate",
+			Type: graphql.String,
+			Resolve: graphql.Value(func(b sqlc.SaleBill) any {
+				if !b.AckDate.Valid {
+					return nil
+				}
+				return graphqlDate(b.AckDate.Time)
+			}),
+		},
+		{
+			Name: "party",
+			Type: party,
+			Resolve: func(ctx context.Context, source any, _ graphql.Args) (any, error) {
+				b := source.(sqlc.SaleBill)
+				if !b.PartyID.Valid {
+					return nil, nil
+				}
+				p, err := h.queries.GetPartyByID(ctx, b.PartyID.Int64)
+				if errors.Is(err, sql.ErrNoRows) {
+					return nil, nil
+				}
+				return p, err
+			},
+		},
+		{
+			Name:        "allocations",
+			Description: "The receipts paying this bill, if it is a credit bill.",
+			Type:        listOf(allocation),
+			Resolve: func(ctx context.Context, source any, _ graphql.Args) (any, error) {
+				b := source.(sqlc.SaleBill)
+				if saleBillKind(b) != "CREDIT" || !b.PartyID.Valid {
+					return []billAllocation{}, nil
+				}
+				l, err := h.ledger(ctx, b.PartyID.Int64)
+				if err != nil {
+					return nil, err
+				}
+				return l.billAllocations(b), nil
+			},
+		},
+		{
+			Name:        "due",
+			Description: "What is left to pay: nothing for cash and card sales.",
+			Type:        nonNull(graphql.Float),
+			Resolve: func(ctx context.Context, source any, _ graphql.Args) (any, error) {
+				b := source.(sqlc.SaleBill)
+				if saleBillKind(b) != "CREDIT" {
+					return 0.0, nil
+				}
+				due := b.Amount
+				if b.PartyID.Valid {
+					l, err := h.ledger(ctx, b.PartyID.Int64)
+					if err != nil {
+						return nil, err
+					}
+					for _, a := range l.allocations[b.ID] {
+						due -= a.Amount + a.RoundingDiff
+					}
+				}
+				if due < billSettledTolerance {
+					due = 0
+				}
+				return math.Round(due*100) / 100, nil
+			},
+		},
+	}
+
+	allocation.Fields = []*graphql.Field{
+		{Name: "bill", Type: nonNull(bill), Resolve: graphql.Value(func(a billAllocation) any { return a.bill })},
+		{Name: "transaction", Type: nonNull(transaction), Resolve: graphql.Value(func(a billAllocation) any { return a.receipt })},
+		{Name: "amount", Type: nonNull(graphql.Float), Resolve: graphql.Value(func(a billAllocation) any { return a.amount })},
+		{Name: "byHand", Type: nonNull(graphql.Boolean), Description: "Whether the allocation was made by hand rather than first in, first out.", Resolve: graphql.Value(func(a billAllocation) any { return a.byHand })},
+		{Name: "roundingDiff", Type: nonNull(graphql.Float), Description: "What the bill was left short by when this allocation settled it within the firm's rounding tolerance.", Resolve: graphql.Value(func(a billAllocation) any { return a.rounding })},
+	}
+
+	identifier.Fields = []*graphql.Field{
+		{Name: "type", Type: nonNull(graphql.String), Resolve: graphql.Value(func(i sqlc.Identifier) any { return i.Type })},
+		{Name: "value", Type: nonNull(graphql.String), Resolve: graphql.Value(func(i sqlc.Identifier) any { return i.Value })},
+		{Name: "hits", Type: nonNull(graphql.Int), Description: "How many receipts it was seen in.", Resolve: graphql.Value(func(i sqlc.Identifier) any { return i.HitCount })},
+	}
+
+	cheque.Fields = []*graphql.Field{
+		{Name: "id", Type: nonNull(graphql.ID), Resolve: graphql.Value(func(c sqlc.SearchChequesByNumberRow) any { return c.ID })},
+		{Name: "number", Type: graphql.String, Resolve: graphql.Value(func(c sqlc.SearchChequesByNumberRow) any { return nullString(c.ChequeNumber) })},
+		{Name: "date", Type: graphql.String, Description: "The date written on the cheque.", Resolve: graphql.Value(func(c sqlc.SearchChequesByNumberRow) any { return nullDate(c.ChequeDate) })},
+		{Name: "status", Type: nonNull(graphql.String), Description: "received, deposited, cleared or bounced.", Resolve: graphql.Value(func(c sqlc.SearchChequesByNumberRow) any { return c.Status })},
+		{Name: "receivedDate", Type: nonNull(graphql.String), Resolve: graphql.Value(func(c sqlc.SearchChequesByNumberRow) any { return graphqlDate(c.ReceivedDate) })},
+		{Name: "depositedDate", Type: graphql.String, Resolve: graphql.Value(func(c sqlc.SearchChequesByNumberRow) any { return nullDate(c.DepositedDate) })},
+		{Name: "clearedDate", Type: graphql.String, Resolve: graphql.Value(func(c sqlc.SearchChequesByNumberRow) any { return nullDate(c.ClearedDate) })},
+		{Name: "bouncedDate", Type: graphql.String, Resolve: graphql.Value(func(c sqlc.SearchChequesByNumberRow) any { return nullDate(c.BouncedDate) })},
+		{Name: "notes", Type: graphql.String, Resolve: graphql.Value(func(c sqlc.SearchChequesByNumberRow) any { return nullString(c.Notes) })},
+		{
+			Name:        "transaction",
+			Description: "The receipt the cheque was entered as, with its party and the bills it pays.",
+			Type:        nonNull(transaction),
+			Resolve: func(ctx context.Context, source any, _ graphql.Args) (any, error) {
+				return h.queries.GetTransactionByID(ctx, source.(sqlc.SearchChequesByNumberRow).TransactionID)
+			},
+		},
+	}
+
+	query := &graphql.Object{Name: "Query", Fields: []*graphql.Field{
+		{
+			Name: "firms",
+			Type: listOf(firm),
+			Resolve: func(ctx context.Context, _ any, _ graphql.Args) (any, error) {
+				return h.queries.ListFirms(ctx)
+			},
+		},
+		{
+			Name:        "firm",
+			Description: "The firm of id, or else the one selected in the browser, the first without a selection.",
+			Type:        firm,
+			Args:        []*graphql.Argument{{Name: "id", Type: graphql.ID}},
+			Resolve: func(ctx context.Context, _ any, args graphql.Args) (any, error) {
+				id := firmID(ctx)
+				if args.Has("id") {
+					var err error
+					if id, err = graphqlID(args); err != nil {
+						return nil, err
+					}
+				}
+				f, err := h.queries.GetFirm(ctx, id)
+				if errors.Is(err, sql.ErrNoRows) {
+					return nil, nil
+				}
+				return f, err
+			},
+		},
+		{
+			Name: "party",
+			Type: party,
+			Args: byID,
+			Resolve: func(ctx context.Context, _ any, args graphql.Args) (any, error) {
+				id, err := graphqlID(args)
+				if err != nil {
+					return nil, err
+				}
+				p, err := h.queries.GetPartyByID(ctx, id)
+				if errors.Is(err, sql.ErrNoRows) {
+					return nil, nil
+				}
+				return p, err
+			},
+		},
+		{
+			Name: "transaction",
+			Type: transaction,
+			Args: byID,
+			Resolve: func(ctx context.Context, _ any, args graphql.Args) (any, error) {
+				id, err := graphqlID(args)
+				if err != nil {
+					return nil, err
+				}
+				t, err := h.queries.GetTransactionByID(ctx, id)
+				if errors.Is(err, sql.ErrNoRows) {
+					return nil, nil
+				}
+				return t, err
+			},
+		},
+		{
+			Name:        "cheques",
+			Description: "The selected firm's cheques whose number contains number, leading zeros ignored, latest received first.",
+			Type:        listOf(cheque),
+			Args:        []*graphql.Argument{{Name: "number", Type: nonNull(graphql.String)}},
+			Resolve: func(ctx context.Context, _ any, args graphql.Args) (any, error) {
+				pattern, ok := chequeNumberQuery(args.String("number"))
+				if !ok {
+					return nil, errors.New("number must have at least three digits")
+				}
+				return h.queries.SearchChequesByNumber(ctx, sqlc.SearchChequesByNumberParams{
+					FirmID:       firmID(ctx),
+					ChequeNumber: sql.NullString{String: pattern, Valid: true},
+				})
+			},
+		},
+		{
+			Name: "bill",
+			Type: bill,
+			Args: byID,
+			Resolve: func(ctx context.Context, _ any, args graphql.Args) (any, error) {
+				id, err := graphqlID(args)
+				if err != nil {
+					return nil, err
+				}
+				b, err := h.queries.GetSaleBillByID(ctx, id)
+				if errors.Is(err, sql.ErrNoRows) {
+					return nil, nil
+				}
+				return b, err
+			},
+		},
+	}}
+
+	schema, err := graphql.NewSchema(query)
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}
+
+// saleBillKind says how a bill was paid for: CASH or CARD at the counter, or
+// CREDIT by the party's receipts
+func saleBillKind(b sqlc.SaleBill) string {
+	switch {
+	case b.IsCashSale.Valid && b.IsCashSale.Bool:
+		return "CASH"
+	case b.IsCardSale:
+		return "CARD"
+	}
+	return "CREDIT"
+}
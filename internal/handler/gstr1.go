@@ -0,0 +1,196 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+	"time"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/gstr1"
+	"suspense.durgadawaghar.com/internal/views"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// maxGSTR1FileSize limits GSTR-1 uploads; a month's B2B invoices of a busy
+// firm come to a few hundred kilobytes
+const maxGSTR1FileSize = 10 << 20
+
+// GSTR1 shows the GSTR-1 returns imported for the current firm, and checks the
+// invoices filed in a period against the sale bills: a month from the period
+// parameter, a financial year or a date range, or else the latest return's month
+func (h *Handler) GSTR1(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	returns, err := h.queries.ListGSTR1Returns(ctx, firmID(ctx))
+	if err != nil {
+		http.Error(w, "Error loading GSTR-1 returns", http.StatusInternalServerError)
+		return
+	}
+	list := make([]pages.GSTR1Return, len(returns))
+	for i, ret := range returns {
+		list[i] = pages.GSTR1Return{Period: ret.ReturnPeriod, Invoices: int(ret.Invoices), Total: ret.Total}
+	}
+
+	var from, till time.Time
+	var year string
+	if month, err := time.Parse("2006-01", r.FormValue("period")); err == nil {
+		from, till = month, month.AddDate(0, 1, -1)
+	} else if r.FormValue("fy") != "" || r.FormValue("from_date") != "" || len(returns) == 0 {
+		from, till, year = reportPeriod(r, time.Now().AddDate(0, -1, 0))
+	} else {
+		month, _ := time.Parse("2006-01", returns[0].ReturnPeriod)
+		from, till = month, month.AddDate(0, 1, -1)
+	}
+
+	var result *gstr1.Result
+	if len(returns) > 0 {
+		res, err := h.reconcileGSTR1(ctx, from, till)
+		if err != nil {
+			http.Error(w, "Error checking GSTR-1 against sale bills", http.StatusInternalServerError)
+			return
+		}
+		result = &res
+	}
+	pages.GSTR1(list, from.Format("2006-01-02"), till.Format("2006-01-02"), year, result).Render(ctx, w)
+}
+
+// ImportGSTR1 reads a GSTR-1 return's B2B invoices from the portal's JSON or
+// the offline tool's CSV, replacing those imported before for the same return
+// period, and shows how they compare with the sale bills of the period
+func (h *Handler) ImportGSTR1(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxGSTR1FileSize)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		w.Write([]byte(`<div class="error">Choose a GSTR-1 JSON or B2B CSV file up to 10 MB.</div>`))
+		return
+	}
+	defer file.Close()
+	ret, err := gstr1.Parse(file)
+	if err != nil {
+		w.Write([]byte(fmt.Sprintf(`<div class="error">Error reading file: %s</div>`, html.EscapeString(err.Error()))))
+		return
+	}
+
+	// The JSON names its return period and GSTIN; a CSV takes the period
+	// chosen on the form
+	period := strings.TrimSpace(r.FormValue("period"))
+	if ret.Period != "" {
+		if period != "" && period != ret.Period {
+			w.Write([]byte(fmt.Sprintf(`<div class="error">The file is the return for %s, not %s.</div>`, ret.Period, html.EscapeString(period))))
+			return
+		}
+		period = ret.Period
+	}
+	month, err := time.Parse("2006-01", period)
+	if err != nil {
+		w.Write([]byte(`<div class="error">Choose the month of the return.</div>`))
+		return
+	}
+	if gstin := views.CurrentFirm(ctx).GSTIN; ret.GSTIN != "" && gstin != "" && !strings.EqualFold(ret.GSTIN, gstin) {
+		w.Write([]byte(fmt.Sprintf(`<div class="error">The return was filed by GSTIN %s, but this firm's GSTIN is %s.</div>`, html.EscapeString(ret.GSTIN), html.EscapeString(gstin))))
+		return
+	}
+
+	if err := h.saveGSTR1Return(ctx, period, ret.Invoices); err != nil {
+		w.Write([]byte(fmt.Sprintf(`<div class="error">Error saving the return: %s</div>`, html.EscapeString(err.Error()))))
+		return
+	}
+
+	// Check the whole span of the invoices, which an amended or late invoice
+	// may take outside the return's month
+	from, till := month, month.AddDate(0, 1, -1)
+	for _, inv := range ret.Invoices {
+		if inv.Date.Before(from) {
+			from = inv.Date
+		}
+		if inv.Date.After(till) {
+			till = inv.Date
+		}
+	}
+	result, err := h.reconcileGSTR1(ctx, from, till)
+	if err != nil {
+		w.Write([]byte(`<div class="error">Error checking the return against sale bills.</div>`))
+		return
+	}
+	pages.GSTR1ImportResult(period, len(ret.Invoices), from.Format("2006-01-02"), till.Format("2006-01-02"), result).Render(ctx, w)
+}
+
+// saveGSTR1Return replaces the invoices of a return period with invoices
+func (h *Handler) saveGSTR1Return(ctx context.Context, period string, invoices []gstr1.Invoice) error {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	q := h.queries.WithTx(tx)
+
+	if err := q.DeleteGSTR1Return(ctx, sqlc.DeleteGSTR1ReturnParams{FirmID: firmID(ctx), ReturnPeriod: period}); err != nil {
+		return err
+	}
+	for _, inv := range invoices {
+		if err := q.CreateGSTR1Invoice(ctx, sqlc.CreateGSTR1InvoiceParams{
+			FirmID:        firmID(ctx),
+			ReturnPeriod:  period,
+			InvoiceNumber: inv.Number,
+			InvoiceDate:   inv.Date,
+			Value:         inv.Value,
+			ReceiverGstin: inv.ReceiverGSTIN,
+			ReceiverName:  inv.ReceiverName,
+		}); err != nil {
+			return fmt.Errorf("invoice %s: %w", inv.Number, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// reconcileGSTR1 checks the invoices filed with dates between from and till
+// against the sale bills of those dates
+func (h *Handler) reconcileGSTR1(ctx context.Context, from, till time.Time) (gstr1.Result, error) {
+	invoices, err := h.queries.ListGSTR1Invoices(ctx, sqlc.ListGSTR1InvoicesParams{
+		FirmID:        firmID(ctx),
+		InvoiceDate:   from,
+		InvoiceDate_2: till,
+	})
+	if err != nil {
+		return gstr1.Result{}, err
+	}
+	bills, err := h.queries.ListSaleBillsBetween(ctx, sqlc.ListSaleBillsBetweenParams{
+		FirmID:     firmID(ctx),
+		BillDate:   from,
+		BillDate_2: till,
+	})
+	if err != nil {
+		return gstr1.Result{}, err
+	}
+
+	filed := make([]gstr1.Invoice, len(invoices))
+	for i, inv := range invoices {
+		filed[i] = gstr1.Invoice{
+			Number:        inv.InvoiceNumber,
+			Date:          inv.InvoiceDate,
+			Value:         inv.Value,
+			ReceiverGSTIN: inv.ReceiverGstin,
+			ReceiverName:  inv.ReceiverName,
+		}
+	}
+	books := make([]gstr1.Bill, len(bills))
+	for i, b := range bills {
+		books[i] = gstr1.Bill{
+			ID:     b.ID,
+			Number: b.BillNumber,
+			Date:   b.BillDate,
+			Party:  b.PartyName,
+			Amount: b.Amount,
+			Credit: !b.IsCashSale.Bool && !b.IsCardSale,
+		}
+	}
+	return gstr1.Reconcile(filed, books), nil
+}
@@ -5,15 +5,31 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"html"
+	"io"
+	"math"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	"suspense.durgadawaghar.com/internal/category"
 	"suspense.durgadawaghar.com/internal/db/sqlc"
 	"suspense.durgadawaghar.com/internal/extractor"
+	"suspense.durgadawaghar.com/internal/fy"
+	"suspense.durgadawaghar.com/internal/graphql"
+	"suspense.durgadawaghar.com/internal/jobs"
+	"suspense.durgadawaghar.com/internal/mailer"
 	"suspense.durgadawaghar.com/internal/matcher"
+	"suspense.durgadawaghar.com/internal/offsite"
 	"suspense.durgadawaghar.com/internal/parser"
+	"suspense.durgadawaghar.com/internal/pdf"
+	"suspense.durgadawaghar.com/internal/replica"
+	"suspense.durgadawaghar.com/internal/rules"
+	"suspense.durgadawaghar.com/internal/slowlog"
+	"suspense.durgadawaghar.com/internal/sms"
+	"suspense.durgadawaghar.com/internal/tracing"
 	"suspense.durgadawaghar.com/internal/views/pages"
 )
 
@@ -23,18 +39,43 @@ var errDuplicate = errors.New("duplicate transaction")
 // Handler holds dependencies for HTTP handlers
 type Handler struct {
 	queries *sqlc.Queries
-	db      *sql.DB
+	db      *sql.DB // the writer, for transactions and statements that write
+	reads   *sql.DB // the read pool
 	matcher *matcher.Matcher
+	slow    *slowlog.Recorder
+	mail    *mailer.Mailer      // nil when email is not set up
+	sms     sms.Sender          // nil when SMS is not set up
+	offsite *offsite.Store      // nil when off-site backup is not set up
+	replica *replica.Replicator // nil when replication is not set up
+	jobs    *jobs.Scheduler     // the scheduled jobs
+	schema  *graphql.Schema     // the GraphQL API
 }
 
-// NewHandler creates a new Handler instance
-func NewHandler(db *sql.DB) *Handler {
-	queries := sqlc.New(db)
-	return &Handler{
+// NewHandler creates a new Handler instance. Writes go through db, which
+// should hold a single connection so they queue rather than fail with
+// SQLITE_BUSY, and queries that only read through reads. Queries outside
+// transactions are traced, and those taking slowQuery or longer are logged
+// (none when it is zero). Statements are emailed through mail, and receipts
+// acknowledged by text message through texts, backups uploaded to store, and
+// the replication of replicas reported; any may be nil to turn it off. The
+// jobs of scheduler are listed and run from the jobs page.
+func NewHandler(db, reads *sql.DB, slowQuery time.Duration, mail *mailer.Mailer, texts sms.Sender, store *offsite.Store, replicas *replica.Replicator, scheduler *jobs.Scheduler) *Handler {
+	slow := slowlog.NewRecorder(slowQuery)
+	queries := sqlc.New(tracing.WrapDB(slowlog.Wrap(pools{writer: db, reader: reads}, slow)))
+	h := &Handler{
 		queries: queries,
 		db:      db,
+		reads:   reads,
 		matcher: matcher.NewMatcher(queries),
+		slow:    slow,
+		mail:    mail,
+		sms:     texts,
+		offsite: store,
+		replica: replicas,
+		jobs:    scheduler,
 	}
+	h.schema = h.graphqlSchema()
+	return h
 }
 
 // Home renders the search page
@@ -43,10 +84,18 @@ func (h *Handler) Home(w http.ResponseWriter, r *http.Request) {
 		http.NotFound(w, r)
 		return
 	}
-	pages.Home().Render(r.Context(), w)
+	accountID, accounts, err := h.accountFilter(r)
+	if err != nil {
+		http.Error(w, "Error loading accounts", http.StatusInternalServerError)
+		return
+	}
+	pages.Home(h.recentSearches(r.Context()), h.savedSearches(r.Context()), accounts, accountID).Render(r.Context(), w)
 }
 
-// Search handles narration search requests
+// Search handles narration search requests. Live searches, sent while the
+// narration is typed or pasted, get a short list of matches without recent
+// transactions and are not kept in the history; submitting the form gets the
+// full results.
 func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -54,13 +103,30 @@ func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
 	}
 
 	narration := r.FormValue("narration")
+	live := r.FormValue("live") != ""
 
 	if narration == "" {
+		if live {
+			return
+		}
 		w.Write([]byte(`<div class="error">Please enter a narration to search.</div>`))
 		return
 	}
 
-	results, err := h.matcher.Match(r.Context(), narration)
+	match := h.matcher.Match
+	if live {
+		match = h.matcher.Preview
+	}
+	results, err := match(r.Context(), firmID(r.Context()), narration, searchAmount(r))
+	if err != nil {
+		w.Write([]byte(fmt.Sprintf(`<div class="error">Search error: %s</div>`, err.Error())))
+		return
+	}
+
+	accountID, _, err := h.accountFilter(r)
+	if err == nil && accountID != 0 {
+		results, err = h.resultsForAccount(r.Context(), results, accountID)
+	}
 	if err != nil {
 		w.Write([]byte(fmt.Sprintf(`<div class="error">Search error: %s</div>`, err.Error())))
 		return
@@ -70,16 +136,108 @@ func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
 	ids := extractor.Extract(narration)
 	extractedIDs := make([]pages.ExtractedID, len(ids))
 	for i, id := range ids {
-		extractedIDs[i] = pages.ExtractedID{Type: string(id.Type), Value: id.Value}
+		extractedIDs[i] = pages.ExtractedID{Type: string(id.Type), Value: id.Value, Strong: id.Type.Unique()}
 	}
-
 	pages.ExtractedIdentifiers(extractedIDs).Render(r.Context(), w)
+
+	if live {
+		pages.LiveResults(results).Render(r.Context(), w)
+		return
+	}
+
+	// History is best-effort and never fails the search
+	_ = h.recordSearch(r.Context(), narration, results)
 	pages.SearchResults(results, narration).Render(r.Context(), w)
+	if len(extractedIDs) > 0 {
+		parties, err := h.assignOptions(r.Context(), results)
+		if err == nil {
+			pages.AssignNarration(narration, extractedIDs, parties).Render(r.Context(), w)
+		}
+	}
+}
+
+// searchAmount returns the receipt amount entered with a narration search,
+// such as "1,84,000" or "₹1500", or 0 when none was
+func searchAmount(r *http.Request) float64 {
+	s := strings.NewReplacer(",", "", "₹", "", " ", "").Replace(r.FormValue("amount"))
+	amount, err := strconv.ParseFloat(s, 64)
+	if err != nil || amount < 0 {
+		return 0
+	}
+	return amount
+}
+
+// resultsForAccount narrows match results to parties that have paid into a
+// bank account, with their history counted in that account only
+func (h *Handler) resultsForAccount(ctx context.Context, results []matcher.MatchResult, accountID int64) ([]matcher.MatchResult, error) {
+	account := sql.NullInt64{Int64: accountID, Valid: true}
+	var filtered []matcher.MatchResult
+	for _, result := range results {
+		var count int64
+		var total float64
+		for _, partyID := range result.PartyIDs {
+			activity, err := h.queries.GetPartyAccountActivity(ctx, sqlc.GetPartyAccountActivityParams{
+				PartyID:   partyID,
+				AccountID: account,
+			})
+			if err != nil {
+				return nil, err
+			}
+			count += activity.TransactionCount
+			total += activity.TotalAmount
+		}
+		if count == 0 {
+			continue
+		}
+
+		var recent []sqlc.Transaction
+		for _, txn := range result.RecentTxns {
+			if txn.AccountID == account {
+				recent = append(recent, txn)
+			}
+		}
+		result.TransactionCount = count
+		result.TotalAmount = total
+		result.RecentTxns = recent
+		filtered = append(filtered, result)
+	}
+	return filtered, nil
 }
 
 // Import renders the import page
 func (h *Handler) Import(w http.ResponseWriter, r *http.Request) {
-	pages.Import().Render(r.Context(), w)
+	pending, _ := h.queries.CountPendingImports(r.Context())
+	pages.Import(pending).Render(r.Context(), w)
+}
+
+// maxImportTextSize limits the pasted text of a receipt book or sale bill
+// import, which carries it again to confirm; a year of entries is well under
+const maxImportTextSize = 10 << 20
+
+// readImportForm parses the form of a pasted import, refusing one over
+// maxImportTextSize rather than reading it all
+func readImportForm(w http.ResponseWriter, r *http.Request) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportTextSize)
+	if err := r.ParseForm(); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return errImportTooLarge
+		}
+		return fmt.Errorf("reading the form: %w", err)
+	}
+	return nil
+}
+
+// errImportTooLarge turns away a form over maxImportTextSize
+var errImportTooLarge = fmt.Errorf("the pasted text is over %d MB; import a month or a quarter at a time", maxImportTextSize>>20)
+
+// checkImportText reports why pasted import text or its year cannot be
+// imported
+func checkImportText(data string, year int) error {
+	if latest := time.Now().Year() + 1; year < 2000 || year > latest {
+		return fmt.Errorf("the year must be between 2000 and %d, not %d", latest, year)
+	}
+	return parser.CheckText(data)
 }
 
 // ImportPreview parses and previews import data
@@ -89,9 +247,55 @@ func (h *Handler) ImportPreview(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	data := r.FormValue("data")
-	yearStr := r.FormValue("year")
+	if err := readImportForm(w, r); err != nil {
+		w.Write([]byte(fmt.Sprintf(`<div class="error">%s</div>`, html.EscapeString(err.Error()))))
+		return
+	}
+	h.renderImportPreview(w, r, r.FormValue("data"), pendingImportID(r))
+}
+
+// maxReceiptBookPDFSize limits uploaded receipt book PDFs
+const maxReceiptBookPDFSize = 20 << 20
 
+// ImportPDF previews an uploaded receipt book PDF as its pasted text would
+// be. The text is extracted a page at a time with each line rebuilt from where
+// its pieces sit on the page, so an entry's date, party and amount come out on
+// one line, as copying out of a PDF viewer does not always keep them.
+func (h *Handler) ImportPDF(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxReceiptBookPDFSize)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		w.Write([]byte(`<div class="error">Choose the receipt book PDF, up to 20 MB.</div>`))
+		return
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		w.Write([]byte(`<div class="error">Error reading the file.</div>`))
+		return
+	}
+
+	texts, err := pdf.ExtractText(content)
+	if err != nil {
+		w.Write([]byte(fmt.Sprintf(`<div class="error">Cannot read %s: %s</div>`, html.EscapeString(header.Filename), html.EscapeString(err.Error()))))
+		return
+	}
+	data := strings.Join(texts, "\n")
+	if strings.TrimSpace(data) == "" {
+		w.Write([]byte(fmt.Sprintf(`<div class="error">%s has no text to read; a scanned receipt book is only pictures of its pages. Save the PDF from the accounting software instead.</div>`, html.EscapeString(header.Filename))))
+		return
+	}
+	h.renderImportPreview(w, r, data, 0)
+}
+
+// renderImportPreview previews receipt book text, in the year of its header
+// unless the form gives another
+func (h *Handler) renderImportPreview(w http.ResponseWriter, r *http.Request, data string, pendingID int64) {
 	// Try to extract year from header first
 	extractedYear := parser.ExtractYearFromHeader(data)
 
@@ -101,12 +305,37 @@ func (h *Handler) ImportPreview(w http.ResponseWriter, r *http.Request) {
 		year = extractedYear
 	}
 	// User-provided year overrides extraction (if different from default)
-	if y, err := strconv.Atoi(yearStr); err == nil && y != time.Now().Year() {
+	if y, err := strconv.Atoi(r.FormValue("year")); err == nil && y != time.Now().Year() {
 		year = y
 		extractedYear = 0 // Don't show "auto-detected" if user overrode it
 	}
+	if err := checkImportText(data, year); err != nil {
+		w.Write([]byte(fmt.Sprintf(`<div class="error">Cannot import: %s</div>`, html.EscapeString(err.Error()))))
+		return
+	}
 
-	transactions := parser.Parse(data, year)
+	previewTxns, err := h.previewReceiptBook(r.Context(), data, year)
+	if err != nil {
+		w.Write([]byte(fmt.Sprintf(`<div class="error">Error %s</div>`, html.EscapeString(err.Error()))))
+		return
+	}
+
+	pages.ImportPreview(previewTxns, data, year, extractedYear, pendingID).Render(r.Context(), w)
+}
+
+// previewReceiptBook parses receipt book text for the preview, with what the
+// rules make of each entry
+func (h *Handler) previewReceiptBook(ctx context.Context, data string, year int) ([]pages.PreviewTransaction, error) {
+	p, err := h.loadParser(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("loading parser vocabulary: %w", err)
+	}
+	transactions := p.Parse(data, year)
+
+	engine, err := h.loadRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("loading rules: %w", err)
+	}
 
 	previewTxns := make([]pages.PreviewTransaction, len(transactions))
 	for i, tx := range transactions {
@@ -116,17 +345,26 @@ func (h *Handler) ImportPreview(w http.ResponseWriter, r *http.Request) {
 			previewIDs[j] = pages.PreviewIdentifier{Type: string(id.Type), Value: id.Value}
 		}
 
+		res := applyRules(engine, tx)
+		partyName, location := tx.PartyName, tx.Location
+		if res.PartyName != "" {
+			partyName, location = res.PartyName, ""
+		}
+
 		previewTxns[i] = pages.PreviewTransaction{
 			Date:        tx.Date.Format("02 Jan 2006"),
-			PartyName:   tx.PartyName,
-			Location:    tx.Location,
+			PartyName:   partyName,
+			Location:    location,
 			Amount:      fmt.Sprintf("%.2f", tx.Amount),
 			PaymentMode: tx.PaymentMode,
+			Category:    res.Category,
+			Internal:    res.Internal,
+			Rules:       res.Matched,
 			Identifiers: previewIDs,
 		}
 	}
 
-	pages.ImportPreview(previewTxns, data, year, extractedYear).Render(r.Context(), w)
+	return previewTxns, nil
 }
 
 // ImportConfirm executes the import
@@ -136,6 +374,10 @@ func (h *Handler) ImportConfirm(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := readImportForm(w, r); err != nil {
+		w.Write([]byte(fmt.Sprintf(`<div class="error">%s</div>`, html.EscapeString(err.Error()))))
+		return
+	}
 	data := r.FormValue("data")
 	yearStr := r.FormValue("year")
 
@@ -144,85 +386,259 @@ func (h *Handler) ImportConfirm(w http.ResponseWriter, r *http.Request) {
 	if y, err := strconv.Atoi(yearStr); err == nil {
 		year = y
 	}
+	if err := checkImportText(data, year); err != nil {
+		w.Write([]byte(fmt.Sprintf(`<div class="error">Cannot import: %s</div>`, html.EscapeString(err.Error()))))
+		return
+	}
 
-	transactions := parser.Parse(data, year)
+	summary, err := h.importReceiptBook(r.Context(), data, year)
+	if err != nil {
+		w.Write([]byte(fmt.Sprintf(`<div class="error">Import error: %s</div>`, html.EscapeString(err.Error()))))
+		return
+	}
+	if err := h.resolvePendingImport(r.Context(), pendingImportID(r), pendingImported); err != nil {
+		summary.Errors = append(summary.Errors, fmt.Sprintf("marking the emailed import done: %s", err.Error()))
+	}
+	pages.ImportResult(summary.Imported, summary.NonReceipts, summary.POSSettlements, summary.Duplicates, summary.Errors, summary.Stats, summary.Identifiers, summary.Conflicts).Render(r.Context(), w)
+}
 
-	ctx := r.Context()
-	imported := 0
-	duplicates := 0
-	var importErrors []string
+// importSummary counts what a receipt book import did
+type importSummary struct {
+	Imported       int
+	NonReceipts    int
+	POSSettlements int
+	Duplicates     int
+	Errors         []string
+	Stats          parser.Stats
+	Identifiers    int // identifiers extracted from the parsed entries
+	Conflicts      int // identifiers claimed by more than one party, unresolved
+}
 
+// importReceiptBook imports the transactions of pasted receipt book text,
+// skipping ones already imported. Entries that fail are reported in the
+// summary; the error is for failing to start the import, or for the request
+// being abandoned or timing out part way, which keeps the entries before.
+func (h *Handler) importReceiptBook(ctx context.Context, data string, year int) (importSummary, error) {
+	var summary importSummary
+	p, err := h.loadParser(ctx)
+	if err != nil {
+		return summary, err
+	}
+	transactions, stats := p.ParseWithStats(data, year)
+	summary.Stats = stats
 	for _, tx := range transactions {
-		err := h.importTransaction(ctx, tx)
+		summary.Identifiers += len(extractor.Extract(tx.Narration))
+	}
+
+	engine, err := h.loadRules(ctx)
+	if err != nil {
+		return summary, err
+	}
+
+	// The transactions stored are tied to the import batch recorded after
+	var stored []int64
+	for i, tx := range transactions {
+		if err := ctx.Err(); err != nil {
+			return summary, fmt.Errorf("stopped after %d of %d entries: %w", i, len(transactions), err)
+		}
+
+		// Card machine settlements are not party receipts
+		if tx.PaymentMode == "POS" {
+			err := h.importPOSSettlement(ctx, tx)
+			if err != nil {
+				if errors.Is(err, errDuplicate) {
+					summary.Duplicates++
+				} else {
+					summary.Errors = append(summary.Errors, fmt.Sprintf("%s: %s", tx.PartyName, err.Error()))
+				}
+			} else {
+				summary.POSSettlements++
+			}
+			continue
+		}
+
+		res := applyRules(engine, tx)
+		id, err := h.importTransaction(ctx, tx, res, 0)
+		if id != 0 {
+			stored = append(stored, id)
+		}
 		if err != nil {
 			if errors.Is(err, errDuplicate) {
-				duplicates++
+				summary.Duplicates++
 			} else {
-				importErrors = append(importErrors, fmt.Sprintf("%s: %s", tx.PartyName, err.Error()))
+				summary.Errors = append(summary.Errors, fmt.Sprintf("%s: %s", tx.PartyName, err.Error()))
 			}
+		} else if res.Category != category.Receipt {
+			summary.NonReceipts++
 		} else {
-			imported++
+			summary.Imported++
+		}
+	}
+
+	// Keep the book's closing total to check the imported entries against
+	if bt, ok := parser.ExtractBookTotal(data); ok {
+		err := h.queries.UpsertReceiptBookTotal(ctx, sqlc.UpsertReceiptBookTotalParams{
+			FirmID:    firmID(ctx),
+			StartDate: bt.From,
+			EndDate:   bt.To,
+			Total:     bt.Total,
+			Suspense:  bt.Suspense,
+		})
+		if err != nil {
+			summary.Errors = append(summary.Errors, fmt.Sprintf("recording book total: %s", err.Error()))
+		}
+	}
+
+	// New parties may match sale bills imported before them
+	if _, err := h.linkUnlinkedSaleBills(ctx); err != nil {
+		summary.Errors = append(summary.Errors, fmt.Sprintf("linking sale bills: %s", err.Error()))
+	}
+
+	batchID, err := h.queries.CreateImportBatch(ctx, sqlc.CreateImportBatchParams{
+		FirmID:       firmID(ctx),
+		Lines:        int64(stats.Lines),
+		Transactions: int64(stats.Transactions),
+		PageHeaders:  int64(stats.PageHeaders),
+		SkipPatterns: int64(stats.SkipPatterns),
+		Suspense:     int64(stats.Suspense),
+		Unattached:   int64(stats.Unattached),
+		Identifiers:  int64(summary.Identifiers),
+		Imported:     int64(summary.Imported + summary.NonReceipts + summary.POSSettlements),
+		Duplicates:   int64(summary.Duplicates),
+		Errors:       int64(len(summary.Errors)),
+	})
+	if err != nil {
+		summary.Errors = append(summary.Errors, fmt.Sprintf("recording import metrics: %s", err.Error()))
+	} else {
+		for _, id := range stored {
+			err := h.queries.SetTransactionImportBatch(ctx, sqlc.SetTransactionImportBatchParams{
+				ImportBatchID: sql.NullInt64{Int64: batchID, Valid: true},
+				ID:            id,
+			})
+			if err != nil {
+				summary.Errors = append(summary.Errors, fmt.Sprintf("recording the import of transaction %d: %s", id, err.Error()))
+				break
+			}
 		}
 	}
 
-	pages.ImportResult(imported, duplicates, importErrors).Render(r.Context(), w)
+	conflicts, err := h.queries.ListIdentifierConflicts(ctx, firmID(ctx))
+	if err != nil {
+		summary.Errors = append(summary.Errors, fmt.Sprintf("listing identifier conflicts: %s", err.Error()))
+	}
+	summary.Conflicts = len(conflicts)
+	return summary, nil
 }
 
-func (h *Handler) importTransaction(ctx context.Context, tx parser.Transaction) error {
+// importTransaction stores a parsed entry under its party, returning the ID of
+// the transaction stored, also when a step after storing it fails. partyID,
+// when set, is the party already chosen for the entry; otherwise the party is
+// found by the entry's identifiers, or created.
+func (h *Handler) importTransaction(ctx context.Context, tx parser.Transaction, res rules.Result, partyID int64) (int64, error) {
 	// Check for duplicate by amount, date, and narration (regardless of party_id)
 	_, err := h.queries.GetTransactionByDetails(ctx, sqlc.GetTransactionByDetailsParams{
 		Amount:          tx.Amount,
 		TransactionDate: tx.Date,
 		Narration:       sql.NullString{String: tx.Narration, Valid: tx.Narration != ""},
+		FirmID:          firmID(ctx),
 	})
 	if err == nil {
 		// Found existing transaction with same details
-		return errDuplicate
+		return 0, errDuplicate
+	}
+	// A receipt split across parties since has none of its shares' amounts
+	_, err = h.queries.GetSplitTransactionByDetails(ctx, sqlc.GetSplitTransactionByDetailsParams{
+		OriginalAmount:  tx.Amount,
+		TransactionDate: tx.Date,
+		Narration:       sql.NullString{String: tx.Narration, Valid: tx.Narration != ""},
+		FirmID:          firmID(ctx),
+	})
+	if err == nil {
+		return 0, errDuplicate
+	}
+	// nor does a duplicate merged into another transaction since
+	_, err = h.queries.GetMergedTransactionByDetails(ctx, sqlc.GetMergedTransactionByDetailsParams{
+		SupersededAmount:    tx.Amount,
+		SupersededDate:      tx.Date,
+		SupersededNarration: sql.NullString{String: tx.Narration, Valid: tx.Narration != ""},
+		FirmID:              firmID(ctx),
+	})
+	if err == nil {
+		return 0, errDuplicate
+	}
+	if err := h.checkOpenYear(ctx, tx.Date); err != nil {
+		return 0, err
 	}
 
 	// Extract identifiers from narration
 	ids := extractor.Extract(tx.Narration)
 
-	// Try to find existing party by identifier
-	var partyID int64
-	for _, id := range ids {
-		existing, err := h.queries.GetIdentifierByTypeValue(ctx, sqlc.GetIdentifierByTypeValueParams{
-			Type:  string(id.Type),
-			Value: id.Value,
-		})
-		if err == nil {
-			partyID = existing.PartyID
-			break
+	partyName, location := tx.PartyName, tx.Location
+	if res.PartyName != "" {
+		partyName, location = res.PartyName, ""
+	}
+
+	switch {
+	case partyID != 0:
+		// The party chosen for the entry is kept
+	case res.PartyName != "" || res.Internal:
+		// Rule-assigned and internal entries are grouped by party name, since
+		// their identifiers don't identify a customer
+		if id, err := h.partyByName(ctx, partyName); err == nil {
+			partyID = id
+		}
+	default:
+		// Try to find existing party by identifier
+		for _, id := range ids {
+			existing, err := h.queries.GetIdentifierByTypeValue(ctx, sqlc.GetIdentifierByTypeValueParams{
+				Type:   string(id.Type),
+				Value:  id.Value,
+				FirmID: firmID(ctx),
+			})
+			if err == nil {
+				partyID = existing.PartyID
+				break
+			}
 		}
 	}
 
 	// If no existing party found, create new one
 	if partyID == 0 {
 		party, err := h.queries.CreateParty(ctx, sqlc.CreatePartyParams{
-			Name:     tx.PartyName,
-			Location: sql.NullString{String: tx.Location, Valid: tx.Location != ""},
+			Name:     partyName,
+			Location: sql.NullString{String: location, Valid: location != ""},
+			FirmID:   firmID(ctx),
 		})
 		if err != nil {
-			return fmt.Errorf("creating party: %w", err)
+			return 0, fmt.Errorf("creating party: %w", err)
 		}
 		partyID = party.ID
 	}
 
-	// Insert identifiers (upsert - will update party_id if exists)
+	// Internal entries don't link identifiers, so they never drive matching
+	if res.Internal {
+		ids = nil
+	}
+
+	// Link new identifiers to the party; ones already linked to another party
+	// stay with it, and the unique ones are reported as conflicts
+	var linked []sqlc.Identifier
 	for _, id := range ids {
-		_, err := h.queries.CreateIdentifier(ctx, sqlc.CreateIdentifierParams{
-			PartyID: partyID,
-			Type:    string(id.Type),
-			Value:   id.Value,
-		})
-		if err != nil {
+		identifier, ok, err := h.linkIdentifier(ctx, partyID, id, identifierCauseImport, tx.Narration)
+		if err != nil || !ok {
 			// Log but don't fail on identifier insert errors
 			continue
 		}
+		linked = append(linked, identifier)
+	}
+
+	accountID, err := h.accountID(ctx, tx)
+	if err != nil {
+		return 0, err
 	}
 
 	// Insert transaction
-	_, err = h.queries.CreateTransaction(ctx, sqlc.CreateTransactionParams{
+	created, err := h.queries.CreateTransaction(ctx, sqlc.CreateTransactionParams{
 		PartyID:          partyID,
 		Amount:           tx.Amount,
 		TransactionDate:  tx.Date,
@@ -230,16 +646,126 @@ func (h *Handler) importTransaction(ctx context.Context, tx parser.Transaction)
 		Narration:        sql.NullString{String: tx.Narration, Valid: tx.Narration != ""},
 		CashBankCode:     sql.NullString{String: tx.CashBankCode, Valid: tx.CashBankCode != ""},
 		CashBankLocation: sql.NullString{String: tx.CashBankLocation, Valid: tx.CashBankLocation != ""},
+		Category:         string(res.Category),
+		IsInternal:       res.Internal,
+		AccountID:        accountID,
+		FirmID:           firmID(ctx),
 	})
 	if err != nil {
 		// Check for UNIQUE constraint violation (SQLite error)
 		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
-			return errDuplicate
+			return 0, errDuplicate
 		}
-		return fmt.Errorf("creating transaction: %w", err)
+		return 0, fmt.Errorf("creating transaction: %w", err)
 	}
 
-	return nil
+	// Count the entry as a sighting of its identifiers only once it is stored,
+	// so re-imported duplicates don't inflate the counts
+	for _, identifier := range linked {
+		if err := h.queries.UpdateIdentifierSighting(ctx, identifierSighting(identifier, tx.Date)); err != nil {
+			return created.ID, fmt.Errorf("recording identifier sighting: %w", err)
+		}
+	}
+
+	// Receipts are credited to the agent who collected them, by their agent
+	// code or the deposit location of their narration
+	if !res.Internal {
+		agents, err := h.queries.ListAgents(ctx, firmID(ctx))
+		if err != nil {
+			return created.ID, fmt.Errorf("loading agents: %w", err)
+		}
+		if _, err := h.assignAgent(ctx, h.queries, agents, created.ID, tx.Narration, tx.CashAgentCode, tx.CashBankLocation); err != nil {
+			return created.ID, err
+		}
+	}
+
+	// Parties with a phone number are told their payment has arrived
+	if res.Category == category.Receipt && !res.Internal {
+		if err := h.queueAcknowledgement(ctx, partyID, created); err != nil {
+			return created.ID, err
+		}
+	}
+
+	// Cheques are tracked until they clear
+	if tx.PaymentMode == "CHEQUE" {
+		_, err = h.queries.CreateCheque(ctx, sqlc.CreateChequeParams{
+			TransactionID: created.ID,
+			ChequeNumber:  sql.NullString{String: tx.ChequeNumber, Valid: tx.ChequeNumber != ""},
+			ChequeDate:    sql.NullTime{Time: tx.ChequeDate, Valid: !tx.ChequeDate.IsZero()},
+			ReceivedDate:  tx.Date,
+		})
+		if err != nil {
+			return created.ID, fmt.Errorf("creating cheque: %w", err)
+		}
+	}
+
+	return created.ID, nil
+}
+
+// linkIdentifier links id to partyID unless it is already linked to another
+// party, and reports whether the identifier is now the party's. A unique
+// identifier of another party found in narration is recorded as a conflict
+// for someone to resolve, rather than moved to the latest party it appears in.
+// A new link is recorded in the identifier's history with cause.
+func (h *Handler) linkIdentifier(ctx context.Context, partyID int64, id extractor.Identifier, cause, narration string) (sqlc.Identifier, bool, error) {
+	existing, err := h.queries.GetIdentifierByTypeValue(ctx, sqlc.GetIdentifierByTypeValueParams{
+		Type:   string(id.Type),
+		Value:  id.Value,
+		FirmID: firmID(ctx),
+	})
+	switch {
+	case err == nil && existing.PartyID == partyID:
+		return existing, true, nil
+	case err == nil:
+		if !id.Type.Unique() {
+			return existing, false, nil
+		}
+		err := h.queries.AddIdentifierConflict(ctx, sqlc.AddIdentifierConflictParams{
+			FirmID:         firmID(ctx),
+			Type:           string(id.Type),
+			Value:          id.Value,
+			ClaimedPartyID: partyID,
+			Narration:      narration,
+		})
+		if err != nil {
+			return existing, false, fmt.Errorf("recording conflict on %s %s: %w", id.Type, id.Value, err)
+		}
+		return existing, false, nil
+	case !errors.Is(err, sql.ErrNoRows):
+		return existing, false, err
+	}
+
+	identifier, err := h.queries.CreateIdentifier(ctx, sqlc.CreateIdentifierParams{
+		PartyID: partyID,
+		Type:    string(id.Type),
+		Value:   id.Value,
+		FirmID:  firmID(ctx),
+	})
+	if err != nil {
+		return identifier, false, err
+	}
+	if err := addIdentifierHistory(ctx, h.queries, identifier, 0, cause, narration); err != nil {
+		return identifier, false, err
+	}
+	return identifier, true, nil
+}
+
+// identifierSighting widens an identifier's first and last seen dates to take
+// in an entry dated date, and counts the hit
+func identifierSighting(identifier sqlc.Identifier, date time.Time) sqlc.UpdateIdentifierSightingParams {
+	params := sqlc.UpdateIdentifierSightingParams{
+		FirstSeen: identifier.FirstSeen,
+		LastSeen:  identifier.LastSeen,
+		HitCount:  identifier.HitCount + 1,
+		ID:        identifier.ID,
+	}
+	if !params.FirstSeen.Valid || date.Before(params.FirstSeen.Time) {
+		params.FirstSeen = sql.NullTime{Time: date, Valid: true}
+	}
+	if !params.LastSeen.Valid || date.After(params.LastSeen.Time) {
+		params.LastSeen = sql.NullTime{Time: date, Valid: true}
+	}
+	return params
 }
 
 // PartyDetail shows a single party's details
@@ -254,21 +780,129 @@ func (h *Handler) PartyDetail(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
 
-	party, err := h.queries.GetPartyWithTransactionCount(ctx, id)
+	party, err := h.queries.GetPartyBalance(ctx, id)
 	if err != nil {
+		if survivor, ok := h.survivingParty(ctx, id); ok {
+			target := fmt.Sprintf("/party/%d", survivor)
+			if r.URL.RawQuery != "" {
+				target += "?" + r.URL.RawQuery
+			}
+			http.Redirect(w, r, target, http.StatusMovedPermanently)
+			return
+		}
 		http.NotFound(w, r)
 		return
 	}
 
+	q := r.URL.Query()
+	view := pages.PartyView{
+		Tab:       partyTab(q.Get("tab")),
+		Links:     h.statementLinks(r, id, party.Phone),
+		EmailOn:   h.mail != nil,
+		TagFilter: normalizeTag(q.Get("tag")),
+		Counts:    make(map[string]int),
+	}
+	statementFrom, statementTill := previousMonth(time.Now())
+	view.StatementFrom = statementFrom.Format("2006-01-02")
+	view.StatementTill = statementTill.Format("2006-01-02")
+	view.StatementEmails, _ = h.queries.ListStatementEmailsByParty(ctx, id)
+	view.SMSOn = h.sms != nil
+	acks, _ := h.queries.ListSMSAcknowledgementsByParty(ctx, id)
+	view.SMSLog = smsAcknowledgementViews(acks)
+	page, _ := strconv.Atoi(q.Get("page"))
+
 	identifiers, _ := h.queries.GetIdentifiersByPartyID(ctx, id)
+	history, _ := h.queries.ListIdentifierHistoryByPartyID(ctx, sqlc.ListIdentifierHistoryByPartyIDParams{
+		FirmID:  firmID(ctx),
+		PartyID: id,
+	})
 	transactions, _ := h.queries.GetTransactionsByPartyID(ctx, id)
+	bills, _ := h.queries.GetSaleBillsByPartyID(ctx, sql.NullInt64{Int64: id, Valid: true})
+	notes, _ := h.queries.GetNotesByPartyID(ctx, id)
+
+	view.Cheques = make(map[int64]sqlc.Cheque)
+	partyCheques, _ := h.queries.GetChequesByPartyID(ctx, id)
+	for _, c := range partyCheques {
+		view.Cheques[c.TransactionID] = c
+	}
+	view.Splits = make(map[int64]bool)
+	splitIDs, _ := h.queries.ListSplitTransactionIDsByParty(ctx, id)
+	for _, txnID := range splitIDs {
+		view.Splits[txnID] = true
+	}
+	view.MergedTxns = make(map[int64]bool)
+	mergedIDs, _ := h.queries.ListMergedTransactionIDsByParty(ctx, id)
+	for _, txnID := range mergedIDs {
+		view.MergedTxns[txnID] = true
+	}
+
+	view.Agents = make(map[int64]sqlc.ListTransactionAgentsByPartyRow)
+	agentRows, _ := h.queries.ListTransactionAgentsByParty(ctx, id)
+	for _, a := range agentRows {
+		view.Agents[a.TransactionID] = a
+	}
+	view.AgentOptions, _ = h.queries.ListAgents(ctx, firmID(ctx))
+
+	tagRows, _ := h.queries.GetTransactionTagsByPartyID(ctx, id)
+	view.Tags = groupTags(tagRows)
+	if view.TagFilter != "" {
+		filtered := transactions[:0]
+		for _, txn := range transactions {
+			if slices.Contains(view.Tags[txn.ID], view.TagFilter) {
+				filtered = append(filtered, txn)
+			}
+		}
+		transactions = filtered
+	}
+
+	creditBills, receipts, manual, err := h.partyAllocations(ctx, id)
+	if err != nil {
+		http.Error(w, "Error loading allocations", http.StatusInternalServerError)
+		return
+	}
+	allocations := billStatuses(creditBills, receipts, manual, roundingTolerance(ctx))
+
+	view.Merges, _ = h.queries.ListPartyMergesBySurvivor(ctx, id)
+	parties, _ := h.queries.ListParties(ctx, party.FirmID)
+	for _, p := range parties {
+		if p.ID != id {
+			view.MergeOptions = append(view.MergeOptions, pages.PartyOption{ID: p.ID, Name: p.Name, Location: p.Location.String})
+		}
+	}
+
+	view.Counts[pages.PartyTabReceipts] = len(transactions)
+	view.Counts[pages.PartyTabBills] = len(bills)
+	view.Counts[pages.PartyTabAllocations] = len(allocations)
+	view.Counts[pages.PartyTabIdentifiers] = len(identifiers)
+	view.Counts[pages.PartyTabHistory] = len(history)
+	view.Counts[pages.PartyTabNotes] = len(notes)
 
-	pages.PartyDetail(party, identifiers, transactions).Render(ctx, w)
+	// Only the open tab is paginated and rendered
+	var start, end int
+	start, end, view.Page, view.Pages = paginate(view.Counts[view.Tab], page)
+	switch view.Tab {
+	case pages.PartyTabReceipts:
+		view.Transactions = transactions[start:end]
+	case pages.PartyTabBills:
+		view.SaleBills = bills[start:end]
+	case pages.PartyTabAllocations:
+		view.Allocations = allocations[start:end]
+		view.AllocationEditor = allocationEditor(creditBills, receipts, manual)
+	case pages.PartyTabIdentifiers:
+		view.Identifiers = identifiers[start:end]
+	case pages.PartyTabHistory:
+		view.History = history[start:end]
+	case pages.PartyTabNotes:
+		view.Notes = notes[start:end]
+	}
+
+	pages.PartyDetail(party, view).Render(ctx, w)
 }
 
 // ImportSaleBills renders the sale bill import form
 func (h *Handler) ImportSaleBills(w http.ResponseWriter, r *http.Request) {
-	pages.ImportSaleBills().Render(r.Context(), w)
+	pending, _ := h.queries.CountPendingImports(r.Context())
+	pages.ImportSaleBills(pending).Render(r.Context(), w)
 }
 
 // ImportSaleBillsPreview parses and previews sale bill import data
@@ -277,16 +911,23 @@ func (h *Handler) ImportSaleBillsPreview(w http.ResponseWriter, r *http.Request)
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	if err := readImportForm(w, r); err != nil {
+		w.Write([]byte(fmt.Sprintf(`<div class="error">%s</div>`, html.EscapeString(err.Error()))))
+		return
+	}
 
-	data := r.FormValue("data")
-	yearStr := r.FormValue("year")
-	year := 2025
-	if y, err := strconv.Atoi(yearStr); err == nil {
-		year = y
+	src := saleBillSource(r)
+	bills, unread, err := parseSaleBillSource(src)
+	if err != nil {
+		w.Write([]byte(fmt.Sprintf(`<div class="error">Import error: %s</div>`, html.EscapeString(err.Error()))))
+		return
 	}
 
-	bills := parser.ParseSaleBills(data, year)
+	pages.ImportSaleBillsPreview(previewSaleBills(bills), unread, src).Render(r.Context(), w)
+}
 
+// previewSaleBills shows parsed sale bills for the preview
+func previewSaleBills(bills []parser.SaleBill) []pages.PreviewSaleBill {
 	previewBills := make([]pages.PreviewSaleBill, len(bills))
 	for i, bill := range bills {
 		previewBills[i] = pages.PreviewSaleBill{
@@ -295,10 +936,12 @@ func (h *Handler) ImportSaleBillsPreview(w http.ResponseWriter, r *http.Request)
 			PartyName:  bill.PartyName,
 			Amount:     fmt.Sprintf("%.2f", bill.Amount),
 			IsCashSale: bill.IsCashSale,
+			IsCardSale: bill.IsCardSale,
+			IRN:        bill.IRN,
+			Tax:        bill.CGST + bill.SGST + bill.IGST,
 		}
 	}
-
-	pages.ImportSaleBillsPreview(previewBills, data, year).Render(r.Context(), w)
+	return previewBills
 }
 
 // ImportSaleBillsConfirm executes the sale bill import
@@ -307,41 +950,155 @@ func (h *Handler) ImportSaleBillsConfirm(w http.ResponseWriter, r *http.Request)
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	if err := readImportForm(w, r); err != nil {
+		w.Write([]byte(fmt.Sprintf(`<div class="error">%s</div>`, html.EscapeString(err.Error()))))
+		return
+	}
 
-	data := r.FormValue("data")
-	yearStr := r.FormValue("year")
-	year := 2025
-	if y, err := strconv.Atoi(yearStr); err == nil {
-		year = y
+	src := saleBillSource(r)
+	bills, _, err := parseSaleBillSource(src)
+	if err != nil {
+		w.Write([]byte(fmt.Sprintf(`<div class="error">Import error: %s</div>`, html.EscapeString(err.Error()))))
+		return
 	}
 
-	bills := parser.ParseSaleBills(data, year)
+	summary, err := h.importSaleBills(r.Context(), bills)
+	if err != nil {
+		w.Write([]byte(fmt.Sprintf(`<div class="error">Import error: %s</div>`, html.EscapeString(err.Error()))))
+		return
+	}
+	if err := h.resolvePendingImport(r.Context(), src.PendingID, pendingImported); err != nil {
+		summary.Errors = append(summary.Errors, fmt.Sprintf("marking the emailed import done: %s", err.Error()))
+	}
+	pages.ImportSaleBillsResult(summary.Imported, summary.Duplicates, summary.Unlinked, summary.Completed, summary.Series, summary.Errors).Render(r.Context(), w)
+}
 
-	ctx := r.Context()
-	imported := 0
-	duplicates := 0
-	var importErrors []string
+// saleImportSummary counts what a sale bill import did
+type saleImportSummary struct {
+	Imported   int
+	Duplicates int
+	Unlinked   int
+	Completed  int // duplicates given the IRN or tax breakup they were imported without
+	Errors     []string
+	Series     pages.SeriesCheck // the bill number series imported into, checked afterwards
+}
+
+// importSaleBills saves sale bills, linking credit bills to the party their
+// name matches and skipping bill numbers already imported. A bill imported
+// before without an IRN or tax breakup takes those of the same bill in a
+// fuller register. The bill number series imported into are then checked for
+// gaps and bills out of date order.
+func (h *Handler) importSaleBills(ctx context.Context, bills []parser.SaleBill) (saleImportSummary, error) {
+	var summary saleImportSummary
+	parties, err := h.salePartyIndex(ctx)
+	if err != nil {
+		return summary, err
+	}
+
+	for i, bill := range bills {
+		if err := ctx.Err(); err != nil {
+			return summary, fmt.Errorf("stopped after %d of %d bills: %w", i, len(bills), err)
+		}
+
+		// Credit bills are linked to the party their name matches
+		var partyID sql.NullInt64
+		if !bill.IsCashSale && !bill.IsCardSale {
+			if id, ok := parties[partyNameKey(bill.PartyName)]; ok {
+				partyID = sql.NullInt64{Int64: id, Valid: true}
+			}
+		}
+
+		if err := h.checkOpenYear(ctx, bill.Date); err != nil {
+			summary.Errors = append(summary.Errors, fmt.Sprintf("%s: %s", bill.BillNumber, err.Error()))
+			continue
+		}
 
-	for _, bill := range bills {
 		_, err := h.queries.CreateSaleBill(ctx, sqlc.CreateSaleBillParams{
-			BillNumber: bill.BillNumber,
-			BillDate:   bill.Date,
-			PartyName:  bill.PartyName,
-			Amount:     bill.Amount,
-			IsCashSale: sql.NullBool{Bool: bill.IsCashSale, Valid: true},
+			BillNumber:   bill.BillNumber,
+			BillDate:     bill.Date,
+			PartyName:    bill.PartyName,
+			Amount:       bill.Amount,
+			IsCashSale:   sql.NullBool{Bool: bill.IsCashSale, Valid: true},
+			IsCardSale:   bill.IsCardSale,
+			PartyID:      partyID,
+			FirmID:       firmID(ctx),
+			Irn:          bill.IRN,
+			AckNumber:    bill.AckNumber,
+			AckDate:      sql.NullTime{Time: bill.AckDate, Valid: !bill.AckDate.IsZero()},
+			TaxableValue: bill.TaxableValue,
+			Cgst:         bill.CGST,
+			Sgst:         bill.SGST,
+			Igst:         bill.IGST,
 		})
 		if err != nil {
 			if strings.Contains(err.Error(), "UNIQUE constraint failed") {
-				duplicates++
+				summary.Duplicates++
+				completed, err := h.completeSaleBill(ctx, bill)
+				if err != nil {
+					summary.Errors = append(summary.Errors, fmt.Sprintf("%s: %s", bill.BillNumber, err.Error()))
+				} else if completed {
+					summary.Completed++
+				}
 			} else {
-				importErrors = append(importErrors, fmt.Sprintf("%s: %s", bill.BillNumber, err.Error()))
+				summary.Errors = append(summary.Errors, fmt.Sprintf("%s: %s", bill.BillNumber, err.Error()))
 			}
 		} else {
-			imported++
+			summary.Imported++
+			if !bill.IsCashSale && !bill.IsCardSale && !partyID.Valid {
+				summary.Unlinked++
+			}
 		}
 	}
 
-	pages.ImportSaleBillsResult(imported, duplicates, importErrors).Render(r.Context(), w)
+	// The bills are in, so a failed check is reported rather than failing
+	// the import
+	series, err := h.checkImportedSeries(ctx, bills)
+	if err != nil {
+		summary.Errors = append(summary.Errors, fmt.Sprintf("checking bill number series: %s", err.Error()))
+	}
+	summary.Series = series
+	return summary, nil
+}
+
+// completeSaleBill gives a bill imported before the IRN and tax breakup it
+// was imported without, from the same bill imported again, and reports
+// whether it took either. What a bill already has is kept.
+func (h *Handler) completeSaleBill(ctx context.Context, bill parser.SaleBill) (bool, error) {
+	var completed int64
+	if bill.IRN != "" {
+		n, err := h.queries.SetSaleBillEInvoice(ctx, sqlc.SetSaleBillEInvoiceParams{
+			Irn:        bill.IRN,
+			AckNumber:  bill.AckNumber,
+			AckDate:    sql.NullTime{Time: bill.AckDate, Valid: !bill.AckDate.IsZero()},
+			FirmID:     firmID(ctx),
+			BillNumber: bill.BillNumber,
+			BillDate:   bill.Date,
+			PartyName:  bill.PartyName,
+			Amount:     bill.Amount,
+		})
+		if err != nil {
+			return false, err
+		}
+		completed += n
+	}
+	if bill.TaxableValue != 0 || bill.CGST != 0 || bill.SGST != 0 || bill.IGST != 0 {
+		n, err := h.queries.SetSaleBillTaxes(ctx, sqlc.SetSaleBillTaxesParams{
+			TaxableValue: bill.TaxableValue,
+			Cgst:         bill.CGST,
+			Sgst:         bill.SGST,
+			Igst:         bill.IGST,
+			FirmID:       firmID(ctx),
+			BillNumber:   bill.BillNumber,
+			BillDate:     bill.Date,
+			PartyName:    bill.PartyName,
+			Amount:       bill.Amount,
+		})
+		if err != nil {
+			return false, err
+		}
+		completed += n
+	}
+	return completed > 0, nil
 }
 
 // SearchSaleBills renders the sale bill search form
@@ -349,7 +1106,38 @@ func (h *Handler) SearchSaleBills(w http.ResponseWriter, r *http.Request) {
 	// Default from date is 1 year ago, till date is today
 	defaultFromDate := time.Now().AddDate(-1, 0, 0).Format("2006-01-02")
 	defaultTillDate := time.Now().Format("2006-01-02")
-	pages.SearchSaleBills(defaultFromDate, defaultTillDate).Render(r.Context(), w)
+
+	// A saved search opens with its parameters filled in and runs at once
+	q := r.URL.Query()
+	if _, err := time.Parse("2006-01-02", q.Get("from_date")); err == nil {
+		defaultFromDate = q.Get("from_date")
+	}
+	if _, err := time.Parse("2006-01-02", q.Get("till_date")); err == nil {
+		defaultTillDate = q.Get("till_date")
+	}
+	year := ""
+	if y, err := fy.Parse(q.Get("fy")); err == nil {
+		defaultFromDate, defaultTillDate = y.Start().Format("2006-01-02"), y.End().Format("2006-01-02")
+		year = y.Label()
+	}
+	amount := ""
+	if _, err := strconv.ParseFloat(q.Get("amount"), 64); err == nil {
+		amount = q.Get("amount")
+	}
+	// Without a variation of its own the search allows the firm's rounding
+	// tolerance
+	variation := strconv.FormatFloat(roundingTolerance(r.Context()), 'f', -1, 64)
+	if _, err := strconv.ParseFloat(q.Get("variation"), 64); err == nil {
+		variation = q.Get("variation")
+	}
+
+	accountID, accounts, err := h.accountFilter(r)
+	if err != nil {
+		http.Error(w, "Error loading accounts", http.StatusInternalServerError)
+		return
+	}
+
+	pages.SearchSaleBills(defaultFromDate, defaultTillDate, year, amount, variation, accounts, accountID).Render(r.Context(), w)
 }
 
 // SearchSaleBillsResults executes the sale bill search
@@ -361,8 +1149,6 @@ func (h *Handler) SearchSaleBillsResults(w http.ResponseWriter, r *http.Request)
 
 	amountStr := r.FormValue("amount")
 	variationStr := r.FormValue("variation")
-	fromDateStr := r.FormValue("from_date")
-	tillDateStr := r.FormValue("till_date")
 
 	amount, err := strconv.ParseFloat(amountStr, 64)
 	if err != nil {
@@ -370,39 +1156,77 @@ func (h *Handler) SearchSaleBillsResults(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	variation := 0.0
+	variation := roundingTolerance(r.Context())
 	if v, err := strconv.ParseFloat(variationStr, 64); err == nil {
 		variation = v
+	} else {
+		variationStr = strconv.FormatFloat(variation, 'f', -1, 64)
 	}
 
-	fromDate := time.Now().AddDate(-1, 0, 0)
-	if fromDateStr != "" {
-		if parsed, err := time.Parse("2006-01-02", fromDateStr); err == nil {
-			fromDate = parsed
-		}
+	// Default to the last year
+	fromDate, tillDate, _ := reportPeriod(r, time.Now().AddDate(-1, 0, 0))
+
+	minAmount := amount - variation
+	maxAmount := amount + variation
+
+	// With an account selected, only bills of parties who pay into it are shown
+	accountID, _, err := h.accountFilter(r)
+	var bills []sqlc.SaleBill
+	if err == nil && accountID != 0 {
+		bills, err = h.queries.SearchSaleBillsByAmountRangeForAccount(r.Context(), sqlc.SearchSaleBillsByAmountRangeForAccountParams{
+			Amount:     minAmount,
+			Amount_2:   maxAmount,
+			BillDate:   fromDate,
+			BillDate_2: tillDate,
+			FirmID:     firmID(r.Context()),
+			AccountID:  sql.NullInt64{Int64: accountID, Valid: true},
+		})
+	} else if err == nil {
+		bills, err = h.queries.SearchSaleBillsByAmountRange(r.Context(), sqlc.SearchSaleBillsByAmountRangeParams{
+			Amount:     minAmount,
+			Amount_2:   maxAmount,
+			BillDate:   fromDate,
+			BillDate_2: tillDate,
+			FirmID:     firmID(r.Context()),
+		})
+	}
+	if err != nil {
+		w.Write([]byte(fmt.Sprintf(`<div class="error">Search error: %s</div>`, err.Error())))
+		return
 	}
 
-	tillDate := time.Now()
-	if tillDateStr != "" {
-		if parsed, err := time.Parse("2006-01-02", tillDateStr); err == nil {
-			tillDate = parsed
+	// Near misses are matches, noted as a rounding difference
+	results := saleBillSearchResults(bills)
+	for i, bill := range bills {
+		if diff := math.Round((bill.Amount-amount)*100) / 100; diff != 0 {
+			results[i].RoundingDiff = diff
 		}
 	}
+	pages.SaleBillSearchResults(results, fmt.Sprintf("amount %s +/- %s", amountStr, variationStr)).Render(r.Context(), w)
+}
 
-	minAmount := amount - variation
-	maxAmount := amount + variation
-
-	bills, err := h.queries.SearchSaleBillsByAmountRange(r.Context(), sqlc.SearchSaleBillsByAmountRangeParams{
-		Amount:     minAmount,
-		Amount_2:   maxAmount,
-		BillDate:   fromDate,
-		BillDate_2: tillDate,
+// SearchSaleBillsByIRN finds the sale bills whose e-invoice IRN contains the
+// text given, as quoted in a payment advice, or whose acknowledgement number
+// is the text. Spaces and line breaks picked up when copying are ignored.
+func (h *Handler) SearchSaleBillsByIRN(w http.ResponseWriter, r *http.Request) {
+	irn := strings.ToLower(strings.Join(strings.Fields(r.URL.Query().Get("irn")), ""))
+	if len(irn) < 6 {
+		w.Write([]byte(`<div class="error">Enter at least 6 characters of the IRN or Ack No.</div>`))
+		return
+	}
+	bills, err := h.queries.SearchSaleBillsByIRN(r.Context(), sqlc.SearchSaleBillsByIRNParams{
+		FirmID:    firmID(r.Context()),
+		Irn:       "%" + irn + "%",
+		AckNumber: irn,
 	})
 	if err != nil {
-		w.Write([]byte(fmt.Sprintf(`<div class="error">Search error: %s</div>`, err.Error())))
+		w.Write([]byte(fmt.Sprintf(`<div class="error">Search error: %s</div>`, html.EscapeString(err.Error()))))
 		return
 	}
+	pages.SaleBillSearchResults(saleBillSearchResults(bills), "IRN or Ack No. "+irn).Render(r.Context(), w)
+}
 
+func saleBillSearchResults(bills []sqlc.SaleBill) []pages.SaleBillSearchResult {
 	results := make([]pages.SaleBillSearchResult, len(bills))
 	for i, bill := range bills {
 		isCash := false
@@ -416,8 +1240,9 @@ func (h *Handler) SearchSaleBillsResults(w http.ResponseWriter, r *http.Request)
 			PartyName:  bill.PartyName,
 			Amount:     fmt.Sprintf("%.2f", bill.Amount),
 			IsCashSale: isCash,
+			IsCardSale: bill.IsCardSale,
+			IRN:        bill.Irn,
 		}
 	}
-
-	pages.SaleBillSearchResults(results, amountStr, variationStr).Render(r.Context(), w)
+	return results
 }
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+// newTestHandler returns a Handler on a new database with the schema of
+// internal/db/schema.sql, holding two firms and nothing else
+func newTestHandler(t *testing.T) (*Handler, *sql.DB) {
+	t.Helper()
+	schema, err := os.ReadFile("../db/schema.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "suspense.db")+"?_pragma=busy_timeout(10000)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(string(schema)); err != nil {
+		t.Fatal(err)
+	}
+	exec(t, db, "INSERT INTO firms (id, name) VALUES (1, 'Durga Dawa Ghar'), (2, 'Durga Pharma')")
+	return NewHandler(db, db, 0, nil, nil, nil, nil, nil), db
+}
+
+// exec runs a statement setting up or changing a test's data
+func exec(t *testing.T, db *sql.DB, query string, args ...any) sql.Result {
+	t.Helper()
+	res, err := db.Exec(query, args...)
+	if err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	return res
+}
+
+// count returns the single number a query selects
+func count(t *testing.T, db *sql.DB, query string, args ...any) float64 {
+	t.Helper()
+	var n sql.NullFloat64
+	if err := db.QueryRow(query, args...).Scan(&n); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	return n.Float64
+}
+
+// serve runs a request through next in the firm of the firm cookie, or the
+// first firm, and returns the response
+func serve(h *Handler, next http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.WithFirm(next).ServeHTTP(w, r)
+	return w
+}
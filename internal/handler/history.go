@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/matcher"
+	"suspense.durgadawaghar.com/internal/views"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+const (
+	// recentSearchLimit is how many searches are shown on the home page
+	recentSearchLimit = 15
+
+	// searchEditWindow is how soon after a search a longer or shorter version
+	// of the same narration is treated as the same search still being typed
+	searchEditWindow = 2 * time.Minute
+)
+
+// recordSearch saves a narration search and its top result in the search
+// history. The search box searches as you type, so a narration that extends
+// or trims the previous search replaces it instead of adding another entry.
+func (h *Handler) recordSearch(ctx context.Context, narration string, results []matcher.MatchResult) error {
+	if views.ReadOnly(ctx) {
+		return nil
+	}
+	var topPartyID sql.NullInt64
+	var topPartyName sql.NullString
+	var topConfidence float64
+	if len(results) > 0 {
+		top := results[0]
+		topPartyID = sql.NullInt64{Int64: top.Party.ID, Valid: true}
+		topPartyName = sql.NullString{String: top.Party.Name, Valid: true}
+		topConfidence = top.Confidence
+	}
+
+	latest, err := h.queries.GetLatestSearch(ctx, firmID(ctx))
+	if err == nil && latest.Narration != narration && time.Since(latest.SearchedAt) < searchEditWindow &&
+		(strings.HasPrefix(narration, latest.Narration) || strings.HasPrefix(latest.Narration, narration)) {
+		err = h.queries.UpdateSearch(ctx, sqlc.UpdateSearchParams{
+			Narration:     narration,
+			TopPartyID:    topPartyID,
+			TopPartyName:  topPartyName,
+			TopConfidence: topConfidence,
+			ResultCount:   int64(len(results)),
+			ID:            latest.ID,
+			FirmID:        firmID(ctx),
+		})
+		if err == nil {
+			return nil
+		}
+		// The narration was searched before; fall through to refresh that entry
+	}
+
+	return h.queries.RecordSearch(ctx, sqlc.RecordSearchParams{
+		Narration:     narration,
+		TopPartyID:    topPartyID,
+		TopPartyName:  topPartyName,
+		TopConfidence: topConfidence,
+		ResultCount:   int64(len(results)),
+		FirmID:        firmID(ctx),
+	})
+}
+
+// recentSearches returns the latest searches for the home page
+func (h *Handler) recentSearches(ctx context.Context) []pages.RecentSearch {
+	rows, _ := h.queries.ListRecentSearches(ctx, sqlc.ListRecentSearchesParams{FirmID: firmID(ctx), Limit: recentSearchLimit})
+	searches := make([]pages.RecentSearch, len(rows))
+	for i, s := range rows {
+		searches[i] = pages.RecentSearch{
+			Narration:     s.Narration,
+			TopPartyID:    s.TopPartyID.Int64,
+			TopPartyName:  s.TopPartyName.String,
+			TopConfidence: s.TopConfidence,
+			ResultCount:   s.ResultCount,
+			SearchedAt:    s.SearchedAt.Local().Format("02 Jan 15:04"),
+		}
+	}
+	return searches
+}
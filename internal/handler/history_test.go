@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestSearchHistory(t *testing.T) {
+	h, db := newTestHandler(t)
+	exec(t, db, `INSERT INTO parties (id, name, firm_id) VALUES (1, 'SHARMA MEDICAL', 1)`)
+	exec(t, db, `INSERT INTO identifiers (party_id, type, value, firm_id) VALUES (1, 'phone', '9876543210', 1)`)
+	exec(t, db, `INSERT INTO search_history (narration, result_count, firm_id) VALUES ('NEFT/DURGA PHARMA', 0, 2)`)
+	search := func(form url.Values) {
+		t.Helper()
+		if w := serve(h, http.HandlerFunc(h.Search), postForm("/search", form)); w.Code != http.StatusOK {
+			t.Fatalf("search: status = %d: %s", w.Code, w.Body)
+		}
+	}
+	searches := func() float64 {
+		return count(t, db, "SELECT COUNT(*) FROM search_history WHERE firm_id = 1")
+	}
+
+	// Searches as the narration is typed are not kept
+	search(url.Values{"narration": {"UPI/9876543210"}, "live": {"1"}})
+	if n := searches(); n != 0 {
+		t.Fatalf("a live search was kept: %v searches", n)
+	}
+
+	search(url.Values{"narration": {"UPI/9876543210"}})
+	// the same narration finished typing replaces it
+	search(url.Values{"narration": {"UPI/9876543210/PAYMENT"}})
+	if n := count(t, db, "SELECT COUNT(*) FROM search_history WHERE firm_id = 1 AND narration = 'UPI/9876543210/PAYMENT' AND top_party_id = 1 AND result_count = 1"); n != 1 || searches() != 1 {
+		t.Errorf("history of a search and its extension: %v searches", searches())
+	}
+	search(url.Values{"narration": {"CASH DEP KANPUR"}})
+	search(url.Values{"narration": {"UPI/9876543210/PAYMENT"}})
+	if n := searches(); n != 2 {
+		t.Errorf("two narrations, one searched twice, kept %v searches", n)
+	}
+
+	w := serve(h, http.HandlerFunc(h.Home), httptest.NewRequest(http.MethodGet, "/", nil))
+	body := w.Body.String()
+	for _, want := range []string{"UPI/9876543210/PAYMENT", "SHARMA MEDICAL", "CASH DEP KANPUR"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("home page lacks %q in the search history:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "DURGA PHARMA") {
+		t.Errorf("home page shows another firm's search:\n%s", body)
+	}
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"suspense.durgadawaghar.com/internal/views"
+)
+
+func TestIdentifierHistory(t *testing.T) {
+	h, db := newTestHandler(t)
+	ctx := views.WithFirm(context.Background(), views.Firm{ID: 1, Name: "Durga Dawa Ghar"}, nil)
+	book := "Dec 20 SANDHYA MEDICAL STORE LUCKNOW 1200.00\nUPI/SANDHYA@YBL 1200.00"
+	if _, err := h.importReceiptBook(ctx, book, 2025); err != nil {
+		t.Fatal(err)
+	}
+	sandhya := count(t, db, "SELECT party_id FROM identifiers WHERE value = 'SANDHYA@YBL'")
+	if n := count(t, db, "SELECT COUNT(*) FROM identifier_history WHERE value = 'SANDHYA@YBL' AND party_id = ? AND previous_party_id IS NULL AND cause = 'import' AND detail LIKE '%SANDHYA@YBL%'", sandhya); n != 1 {
+		t.Fatalf("import not recorded in the identifier's history")
+	}
+
+	// Moving the identifier to the party claiming it records where it came from
+	exec(t, db, `INSERT INTO parties (id, name, firm_id) VALUES (50, 'SANDHYA PHARMA', 1)`)
+	exec(t, db, `INSERT INTO identifier_conflicts (id, firm_id, type, value, claimed_party_id, narration) VALUES (1, 1, 'upi_vpa', 'SANDHYA@YBL', 50, 'UPI/SANDHYA@YBL/NEW SHOP')`)
+	w := serve(h, http.HandlerFunc(h.ResolveIdentifierConflict), postForm("/identifiers/conflicts/resolve", url.Values{"id": {"1"}, "resolution": {"moved"}}))
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("resolving the conflict: status %d: %s", w.Code, w.Body)
+	}
+	if n := count(t, db, "SELECT COUNT(*) FROM identifier_history WHERE value = 'SANDHYA@YBL' AND party_id = 50 AND previous_party_id = ? AND cause = 'manual' AND detail = 'UPI/SANDHYA@YBL/NEW SHOP'", sandhya); n != 1 {
+		t.Errorf("move not recorded in the identifier's history")
+	}
+	// keeping it where it is records nothing
+	exec(t, db, `INSERT INTO identifier_conflicts (id, firm_id, type, value, claimed_party_id) VALUES (2, 1, 'upi_vpa', 'SANDHYA@YBL', ?)`, sandhya)
+	serve(h, http.HandlerFunc(h.ResolveIdentifierConflict), postForm("/identifiers/conflicts/resolve", url.Values{"id": {"2"}, "resolution": {"kept"}}))
+	if n := count(t, db, "SELECT COUNT(*) FROM identifier_history"); n != 2 {
+		t.Errorf("history holds %v assignments, want 2", n)
+	}
+
+	// Both parties show the whole history of the identifier they have held
+	for _, party := range []string{"50", strconv.FormatFloat(sandhya, 'f', -1, 64)} {
+		body := serve(h, http.HandlerFunc(h.PartyDetail), httptest.NewRequest(http.MethodGet, "/party/"+party+"?tab=history", nil)).Body.String()
+		for _, want := range []string{"Imported entry", "Conflict resolved", "UPI/SANDHYA@YBL/NEW SHOP", "SANDHYA PHARMA"} {
+			if !strings.Contains(body, want) {
+				t.Errorf("history of party %s lacks %q:\n%s", party, want, body)
+			}
+		}
+	}
+}
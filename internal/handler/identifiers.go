@@ -0,0 +1,293 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/extractor"
+	"suspense.durgadawaghar.com/internal/parser"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// maxIdentifierFileSize limits uploaded identifier seed files
+const maxIdentifierFileSize = 5 << 20
+
+// Causes of an identifier being linked to a party, kept in its history
+const (
+	identifierCauseImport = "import" // found in an imported entry
+	identifierCauseSeed   = "seed"   // listed in an identifier seed file
+	identifierCauseManual = "manual" // moved by resolving a conflict
+	identifierCauseMerge  = "merge"  // moved with a party merged into another
+	identifierCauseAssign = "assign" // attached by hand, from an assigned narration or the unattached review
+)
+
+// ImportIdentifiers shows the form for seeding identifiers from a CSV file
+func (h *Handler) ImportIdentifiers(w http.ResponseWriter, r *http.Request) {
+	pages.ImportIdentifiers().Render(r.Context(), w)
+}
+
+// ImportIdentifiersFile links the identifiers in an uploaded CSV file of
+// party, type and value rows to their parties, so known mappings match
+// entries before they first appear in a narration. The party is a party ID or
+// name; parties not found by name are created.
+func (h *Handler) ImportIdentifiersFile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxIdentifierFileSize)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		w.Write([]byte(`<div class="error">Choose a CSV file up to 5 MB.</div>`))
+		return
+	}
+	defer file.Close()
+	rows, err := parser.ReadCSVRows(file)
+	if err != nil {
+		w.Write([]byte(fmt.Sprintf(`<div class="error">Error reading file: %s</div>`, html.EscapeString(err.Error()))))
+		return
+	}
+
+	ctx := r.Context()
+	parties, err := h.salePartyIndex(ctx)
+	if err != nil {
+		http.Error(w, "Error loading parties", http.StatusInternalServerError)
+		return
+	}
+	var summary pages.IdentifierSeedSummary
+	for i, row := range rows {
+		if err := ctx.Err(); err != nil {
+			summary.Errors = append(summary.Errors, fmt.Sprintf("stopped at line %d: %s", i+1, err.Error()))
+			break
+		}
+		if strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue
+		}
+		// A header row names the columns rather than an identifier type
+		if i == 0 && len(row) >= 2 {
+			if _, ok := extractor.ParseType(row[1]); !ok {
+				continue
+			}
+		}
+		if err := h.seedIdentifier(ctx, parties, row, &summary); err != nil {
+			summary.Errors = append(summary.Errors, fmt.Sprintf("line %d: %s", i+1, err.Error()))
+		}
+	}
+
+	// New parties may match sale bills imported before them
+	if summary.PartiesCreated > 0 {
+		if _, err := h.linkUnlinkedSaleBills(ctx); err != nil {
+			summary.Errors = append(summary.Errors, fmt.Sprintf("linking sale bills: %s", err.Error()))
+		}
+	}
+
+	pages.ImportIdentifiersResult(summary).Render(ctx, w)
+}
+
+// seedIdentifier links one party, type, value row of a seed file and counts
+// the outcome in summary. parties maps party names to IDs as sale bills are
+// matched, and gains the parties created.
+func (h *Handler) seedIdentifier(ctx context.Context, parties map[string]int64, row []string, summary *pages.IdentifierSeedSummary) error {
+	if len(row) < 3 {
+		return fmt.Errorf("expected party, type and value columns")
+	}
+	idType, ok := extractor.ParseType(row[1])
+	if !ok {
+		return fmt.Errorf("unknown identifier type %q", strings.TrimSpace(row[1]))
+	}
+	value, err := extractor.Normalize(idType, row[2])
+	if err != nil {
+		return err
+	}
+	partyID, created, err := h.seedParty(ctx, parties, row[0])
+	if err != nil {
+		return err
+	}
+	if created {
+		summary.PartiesCreated++
+	}
+
+	existing, err := h.queries.GetIdentifierByTypeValue(ctx, sqlc.GetIdentifierByTypeValueParams{
+		Type:   string(idType),
+		Value:  value,
+		FirmID: firmID(ctx),
+	})
+	switch {
+	case err == nil && existing.PartyID == partyID:
+		summary.Known++
+		return nil
+	case err == nil:
+		// The file may be stale, so someone decides which party keeps it
+		summary.Conflicts++
+		err := h.queries.AddIdentifierConflict(ctx, sqlc.AddIdentifierConflictParams{
+			FirmID:         firmID(ctx),
+			Type:           string(idType),
+			Value:          value,
+			ClaimedPartyID: partyID,
+			Narration:      "Identifier seed file",
+		})
+		if err != nil {
+			return fmt.Errorf("recording conflict on %s %s: %w", idType, value, err)
+		}
+		return nil
+	case !errors.Is(err, sql.ErrNoRows):
+		return fmt.Errorf("looking up %s %s: %w", idType, value, err)
+	}
+
+	summary.Added++
+	identifier, err := h.queries.CreateIdentifier(ctx, sqlc.CreateIdentifierParams{
+		PartyID: partyID,
+		Type:    string(idType),
+		Value:   value,
+		FirmID:  firmID(ctx),
+	})
+	if err != nil {
+		return fmt.Errorf("linking %s %s: %w", idType, value, err)
+	}
+	return addIdentifierHistory(ctx, h.queries, identifier, 0, identifierCauseSeed, "Identifier seed file")
+}
+
+// addIdentifierHistory records that identifier was linked to its party, from
+// previousPartyID unless it is 0, and why
+func addIdentifierHistory(ctx context.Context, q *sqlc.Queries, identifier sqlc.Identifier, previousPartyID int64, cause, detail string) error {
+	err := q.AddIdentifierHistory(ctx, sqlc.AddIdentifierHistoryParams{
+		FirmID:          identifier.FirmID,
+		Type:            identifier.Type,
+		Value:           identifier.Value,
+		PartyID:         identifier.PartyID,
+		PreviousPartyID: sql.NullInt64{Int64: previousPartyID, Valid: previousPartyID != 0},
+		Cause:           cause,
+		Detail:          detail,
+	})
+	if err != nil {
+		return fmt.Errorf("recording history of %s %s: %w", identifier.Type, identifier.Value, err)
+	}
+	return nil
+}
+
+// seedParty finds the party a seed row names by ID or name, creating it when
+// no party has the name, and reports whether it was created
+func (h *Handler) seedParty(ctx context.Context, parties map[string]int64, party string) (int64, bool, error) {
+	name := partyNameKey(party)
+	if name == "" {
+		return 0, false, fmt.Errorf("missing party")
+	}
+	if id, err := strconv.ParseInt(name, 10, 64); err == nil {
+		// A file exported before a merge names the merged party
+		id, _ = h.survivingParty(ctx, id)
+		p, err := h.queries.GetPartyByID(ctx, id)
+		if err != nil || p.FirmID != firmID(ctx) {
+			return 0, false, fmt.Errorf("no party with ID %d", id)
+		}
+		return p.ID, false, nil
+	}
+
+	if id, ok := parties[name]; ok {
+		return id, false, nil
+	}
+	// Names shared by several parties are left out of the index; take the
+	// first rather than adding another
+	if p, err := h.queries.GetPartyByName(ctx, sqlc.GetPartyByNameParams{Name: name, FirmID: firmID(ctx)}); err == nil {
+		return p.ID, false, nil
+	} else if err != sql.ErrNoRows {
+		return 0, false, fmt.Errorf("finding party %s: %w", name, err)
+	}
+	p, err := h.queries.CreateParty(ctx, sqlc.CreatePartyParams{Name: name, FirmID: firmID(ctx)})
+	if err != nil {
+		return 0, false, fmt.Errorf("creating party %s: %w", name, err)
+	}
+	parties[name] = p.ID
+	return p.ID, true, nil
+}
+
+// IdentifierConflicts lists identifiers found in entries of a party other than
+// the one they are linked to, for someone to decide which party they belong to
+func (h *Handler) IdentifierConflicts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	conflicts, err := h.queries.ListIdentifierConflicts(ctx, firmID(ctx))
+	if err != nil {
+		http.Error(w, "Error loading identifier conflicts", http.StatusInternalServerError)
+		return
+	}
+	pages.IdentifierConflicts(conflicts).Render(ctx, w)
+}
+
+// ResolveIdentifierConflict keeps a conflicting identifier with its party, or
+// moves it to the party that claimed it
+func (h *Handler) ResolveIdentifierConflict(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid conflict ID", http.StatusBadRequest)
+		return
+	}
+	resolution := r.FormValue("resolution")
+	if resolution != "kept" && resolution != "moved" {
+		http.Error(w, "Resolution must be kept or moved", http.StatusBadRequest)
+		return
+	}
+	conflict, err := h.queries.GetIdentifierConflict(ctx, sqlc.GetIdentifierConflictParams{ID: id, FirmID: firmID(ctx)})
+	if err != nil {
+		http.Error(w, "Conflict not found", http.StatusNotFound)
+		return
+	}
+	if err := h.resolveIdentifierConflict(ctx, conflict, resolution); err != nil {
+		http.Error(w, "Error resolving conflict", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/identifiers/conflicts", http.StatusSeeOther)
+}
+
+// resolveIdentifierConflict records the resolution of conflict, moving the
+// identifier to the claiming party when it was moved
+func (h *Handler) resolveIdentifierConflict(ctx context.Context, conflict sqlc.IdentifierConflict, resolution string) error {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	q := h.queries.WithTx(tx)
+
+	if resolution == "moved" {
+		identifier, err := q.GetIdentifierByTypeValue(ctx, sqlc.GetIdentifierByTypeValueParams{
+			Type:   conflict.Type,
+			Value:  conflict.Value,
+			FirmID: conflict.FirmID,
+		})
+		if err != nil {
+			return err
+		}
+		if err := q.MoveIdentifier(ctx, sqlc.MoveIdentifierParams{
+			PartyID: conflict.ClaimedPartyID,
+			FirmID:  conflict.FirmID,
+			Type:    conflict.Type,
+			Value:   conflict.Value,
+		}); err != nil {
+			return err
+		}
+		previousPartyID := identifier.PartyID
+		identifier.PartyID = conflict.ClaimedPartyID
+		if err := addIdentifierHistory(ctx, q, identifier, previousPartyID, identifierCauseManual, conflict.Narration); err != nil {
+			return err
+		}
+	}
+	if err := q.ResolveIdentifierConflict(ctx, sqlc.ResolveIdentifierConflictParams{
+		Resolution: sql.NullString{String: resolution, Valid: true},
+		ID:         conflict.ID,
+	}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
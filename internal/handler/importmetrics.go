@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"net/http"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+const (
+	// importBatchLimit is how many recent imports the metrics page lists
+	importBatchLimit = 50
+	// densityBaseline is how many earlier imports an import's identifiers
+	// per entry are compared with
+	densityBaseline = 10
+	// densityDrop flags an import whose identifiers per entry fall below
+	// this share of the earlier imports' average
+	densityDrop = 0.7
+)
+
+// ImportMetrics lists the parse metrics of recent receipt book imports,
+// flagging imports whose entries gave far fewer identifiers than before
+func (h *Handler) ImportMetrics(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	batches, err := h.queries.ListImportBatches(ctx, sqlc.ListImportBatchesParams{
+		FirmID: firmID(ctx),
+		Limit:  importBatchLimit + densityBaseline,
+	})
+	if err != nil {
+		http.Error(w, "Error loading import metrics: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	rows := importBatchRows(batches)
+	if len(rows) > importBatchLimit {
+		rows = rows[:importBatchLimit]
+	}
+	pages.ImportMetrics(rows).Render(ctx, w)
+}
+
+// importBatchRows compares each import, newest first, with the imports
+// before it
+func importBatchRows(batches []sqlc.ImportBatch) []pages.ImportBatchRow {
+	density := func(b sqlc.ImportBatch) float64 {
+		return float64(b.Identifiers) / float64(b.Transactions)
+	}
+	rows := make([]pages.ImportBatchRow, len(batches))
+	for i, b := range batches {
+		rows[i].Batch = b
+		if b.Transactions == 0 {
+			continue
+		}
+		rows[i].Density = density(b)
+
+		var sum float64
+		n := 0
+		for _, earlier := range batches[i+1:] {
+			if earlier.Transactions == 0 {
+				continue
+			}
+			sum += density(earlier)
+			if n++; n == densityBaseline {
+				break
+			}
+		}
+		if n > 0 {
+			rows[i].Baseline = sum / float64(n)
+			rows[i].Dropped = rows[i].Density < densityDrop*rows[i].Baseline
+		}
+	}
+	return rows
+}
@@ -0,0 +1,263 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/inbox"
+	"suspense.durgadawaghar.com/internal/jobs"
+	"suspense.durgadawaghar.com/internal/parser"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// The imports an emailed file is queued for, by the page it is confirmed on
+const (
+	pendingReceipts  = "receipts"
+	pendingSaleBills = "sale-bills"
+)
+
+// What became of an emailed file once dealt with
+const (
+	pendingImported  = "imported"
+	pendingDismissed = "dismissed"
+)
+
+// inboxJob is the scheduled job reading the inbox
+const inboxJob = "inbox"
+
+// CheckInbox queues the receipt book statements and sale bill exports
+// attached to unread email in box as pending imports, to be reviewed and
+// confirmed on the inbox page. Attachments neither parser reads anything from
+// are left out. Nothing is returned when there was no new email.
+func (h *Handler) CheckInbox(ctx context.Context, box *inbox.Mailbox) (string, error) {
+	p, err := h.loadParser(ctx)
+	if err != nil {
+		return "", err
+	}
+	queued, ignored := 0, 0
+	read, err := box.Fetch(ctx, func(msg inbox.Message) error {
+		received := msg.Date
+		if received.IsZero() {
+			received = time.Now()
+		}
+		for _, a := range msg.Attachments {
+			kind, entries := classifyAttachment(p, a, received.Year())
+			if kind == "" {
+				ignored++
+				continue
+			}
+			// Email read again, after failing to mark it read, is not queued twice
+			n, err := h.queries.CreatePendingImport(ctx, sqlc.CreatePendingImportParams{
+				Kind:       kind,
+				Filename:   a.Filename,
+				Content:    a.Data,
+				Sender:     msg.From,
+				Subject:    msg.Subject,
+				MessageID:  msg.ID,
+				ReceivedAt: received.UTC(),
+				Entries:    int64(entries),
+			})
+			if err != nil {
+				return fmt.Errorf("queueing %s: %w", a.Filename, err)
+			}
+			queued += int(n)
+		}
+		return nil
+	})
+	if read == 0 && err == nil {
+		return "", nil
+	}
+	result := fmt.Sprintf("%d emails read, %d files queued", read, queued)
+	if ignored > 0 {
+		result += fmt.Sprintf(", %d other attachments left out", ignored)
+	}
+	return result, err
+}
+
+// classifyAttachment says which import an emailed file is for, and how many
+// entries are read from it: a CSV or .xlsx file is a sale bill export whose
+// columns are recognised, and text is a receipt book or a sale bill register,
+// whichever more entries are read as. The kind is empty for any other file.
+func classifyAttachment(p *parser.Parser, a inbox.Attachment, year int) (string, int) {
+	if isSpreadsheet(a.Filename) {
+		src, _, _, err := readSaleBillFile(a.Filename, a.Data)
+		if err != nil {
+			return "", 0
+		}
+		src.Year = year
+		bills, _, err := parseSaleBillSource(src)
+		if err != nil || len(bills) == 0 {
+			return "", 0
+		}
+		return pendingSaleBills, len(bills)
+	}
+
+	text := string(a.Data)
+	if parser.CheckText(text) != nil {
+		return "", 0
+	}
+	if y := parser.ExtractYearFromHeader(text); y > 0 {
+		year = y
+	}
+	receipts, bills := len(p.Parse(text, year)), len(parser.ParseSaleBills(text, year))
+	switch {
+	case receipts == 0 && bills == 0:
+		return "", 0
+	case bills > receipts:
+		return pendingSaleBills, bills
+	default:
+		return pendingReceipts, receipts
+	}
+}
+
+// isSpreadsheet reports whether a file is read as spreadsheet rows rather
+// than text
+func isSpreadsheet(filename string) bool {
+	ext := strings.ToLower(filepath.Ext(filename))
+	return ext == ".csv" || ext == ".xlsx"
+}
+
+// Inbox lists the emailed files waiting to be imported, with how the last
+// check of the mailbox went
+func (h *Handler) Inbox(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.queries.ListPendingImports(r.Context())
+	if err != nil {
+		http.Error(w, "Error listing emailed imports", http.StatusInternalServerError)
+		return
+	}
+	imports := make([]pages.PendingImport, len(rows))
+	for i, row := range rows {
+		imports[i] = pages.PendingImport{
+			ID:       row.ID,
+			Kind:     row.Kind,
+			Filename: row.Filename,
+			Sender:   row.Sender,
+			Subject:  row.Subject,
+			Received: row.ReceivedAt.Local().Format("02 Jan 2006 15:04"),
+			Entries:  row.Entries,
+		}
+	}
+
+	var check *jobs.Status
+	for _, s := range h.jobs.Status() {
+		if s.Name == inboxJob {
+			check = &s
+		}
+	}
+	pages.Inbox(imports, check).Render(r.Context(), w)
+}
+
+// ReviewPendingImport previews an emailed file the way its import page
+// would, confirming the import marking it imported. A spreadsheet first has
+// its columns mapped.
+func (h *Handler) ReviewPendingImport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid import ID", http.StatusBadRequest)
+		return
+	}
+	p, err := h.queries.GetPendingImport(ctx, id)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if p.Status != "pending" {
+		w.Write([]byte(fmt.Sprintf(`<div class="error">%s was already %s.</div>`, html.EscapeString(p.Filename), html.EscapeString(p.Status))))
+		return
+	}
+
+	year := p.ReceivedAt.Year()
+	switch {
+	case p.Kind == pendingReceipts:
+		data := string(p.Content)
+		extractedYear := parser.ExtractYearFromHeader(data)
+		if extractedYear > 0 {
+			year = extractedYear
+		}
+		if err := checkImportText(data, year); err != nil {
+			w.Write([]byte(fmt.Sprintf(`<div class="error">Cannot import: %s</div>`, html.EscapeString(err.Error()))))
+			return
+		}
+		transactions, err := h.previewReceiptBook(ctx, data, year)
+		if err != nil {
+			w.Write([]byte(fmt.Sprintf(`<div class="error">Error %s</div>`, html.EscapeString(err.Error()))))
+			return
+		}
+		pages.ImportPreview(transactions, data, year, extractedYear, p.ID).Render(ctx, w)
+
+	case isSpreadsheet(p.Filename):
+		src, columns, sample, err := readSaleBillFile(p.Filename, p.Content)
+		if err != nil {
+			w.Write([]byte(fmt.Sprintf(`<div class="error">Cannot read %s: %s</div>`, html.EscapeString(p.Filename), html.EscapeString(err.Error()))))
+			return
+		}
+		src.Year = year
+		src.PendingID = p.ID
+		pages.ImportSaleBillsMapping(p.Filename, src, columns, sample).Render(ctx, w)
+
+	default:
+		src := pages.SaleBillSource{Data: string(p.Content), Year: year, PendingID: p.ID}
+		bills, unread, err := parseSaleBillSource(src)
+		if err != nil {
+			w.Write([]byte(fmt.Sprintf(`<div class="error">Import error: %s</div>`, html.EscapeString(err.Error()))))
+			return
+		}
+		pages.ImportSaleBillsPreview(previewSaleBills(bills), unread, src).Render(ctx, w)
+	}
+}
+
+// DismissPendingImport drops an emailed file from the inbox without
+// importing it
+func (h *Handler) DismissPendingImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid import ID", http.StatusBadRequest)
+		return
+	}
+	if err := h.resolvePendingImport(r.Context(), id, pendingDismissed); err != nil {
+		http.Error(w, "Error dismissing the import", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/import/inbox", http.StatusSeeOther)
+}
+
+// CheckInboxNow reads the mailbox without waiting for the inbox job's
+// schedule
+func (h *Handler) CheckInboxNow(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := h.jobs.RunNow(inboxJob); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	http.Redirect(w, r, "/import/inbox", http.StatusSeeOther)
+}
+
+// pendingImportID returns the emailed import an import form came from, or 0
+func pendingImportID(r *http.Request) int64 {
+	id, _ := strconv.ParseInt(r.FormValue("pending_id"), 10, 64)
+	return id
+}
+
+// resolvePendingImport records what became of an emailed import; id 0 is an
+// import that was not emailed
+func (h *Handler) resolvePendingImport(ctx context.Context, id int64, status string) error {
+	if id == 0 {
+		return nil
+	}
+	return h.queries.ResolvePendingImport(ctx, sqlc.ResolvePendingImportParams{Status: status, ID: id})
+}
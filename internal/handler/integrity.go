@@ -0,0 +1,20 @@
+package handler
+
+import (
+	"net/http"
+
+	"suspense.durgadawaghar.com/internal/integrity"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// IntegrityCheck runs the database consistency checks over every firm and
+// shows what they found with how to fix it
+func (h *Handler) IntegrityCheck(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	results, err := integrity.Run(ctx, h.reads)
+	if err != nil {
+		http.Error(w, "Error checking database: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	pages.IntegrityCheck(results).Render(ctx, w)
+}
@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"net/http"
+
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// Jobs lists the scheduled jobs with when they next run and how their last
+// run went
+func (h *Handler) Jobs(w http.ResponseWriter, r *http.Request) {
+	pages.Jobs(h.jobs.Status()).Render(r.Context(), w)
+}
+
+// RunJob starts a scheduled job without waiting for its schedule
+func (h *Handler) RunJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := h.jobs.RunNow(r.FormValue("name")); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	http.Redirect(w, r, "/settings/jobs", http.StatusSeeOther)
+}
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+)
+
+// partyMergeMoves hand everything of the merged party (?2) to the survivor
+// (?1). Allocations follow, since they are worked out from the party's bills
+// and receipts. Closing balances of the same year add up. Suggestions naming
+// the merged party go, the next duplicate scan finds the survivor's again.
+var partyMergeMoves = []string{
+	"UPDATE transactions SET party_id = ?1 WHERE party_id = ?2",
+	"UPDATE sale_bills SET party_id = ?1 WHERE party_id = ?2",
+	"UPDATE identifiers SET party_id = ?1 WHERE party_id = ?2",
+	"UPDATE party_aliases SET party_id = ?1 WHERE party_id = ?2",
+	"UPDATE party_notes SET party_id = ?1 WHERE party_id = ?2",
+	"UPDATE statement_links SET party_id = ?1 WHERE party_id = ?2",
+	"UPDATE statement_emails SET party_id = ?1 WHERE party_id = ?2",
+	"UPDATE parties SET email = (SELECT email FROM parties WHERE id = ?2) WHERE id = ?1 AND email = ''",
+	"UPDATE sms_acknowledgements SET party_id = ?1 WHERE party_id = ?2",
+	"UPDATE parties SET phone = (SELECT phone FROM parties WHERE id = ?2) WHERE id = ?1 AND phone = ''",
+	"UPDATE transaction_splits SET original_party_id = ?1 WHERE original_party_id = ?2",
+	"UPDATE search_history SET top_party_id = ?1 WHERE top_party_id = ?2",
+	"UPDATE OR IGNORE identifier_conflicts SET claimed_party_id = ?1 WHERE claimed_party_id = ?2",
+	"DELETE FROM identifier_conflicts WHERE claimed_party_id = ?2",
+	`INSERT INTO financial_year_balances (financial_year_id, party_id, billed, received)
+		SELECT financial_year_id, ?1, billed, received FROM financial_year_balances WHERE party_id = ?2
+		ON CONFLICT (financial_year_id, party_id) DO UPDATE SET
+			billed = billed + excluded.billed, received = received + excluded.received`,
+	"DELETE FROM financial_year_balances WHERE party_id = ?2",
+	"UPDATE party_merges SET survivor_party_id = ?1 WHERE survivor_party_id = ?2",
+	"DELETE FROM merge_suggestions WHERE party_id = ?2 OR other_party_id = ?2",
+	"DELETE FROM parties WHERE id = ?2",
+}
+
+// MergeParty merges a duplicate party into another: its receipts, sale bills,
+// identifiers, aliases, notes and statement links move to the survivor, and
+// the merged party's ID and name lead to the survivor from then on
+func (h *Handler) MergeParty(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid party ID", http.StatusBadRequest)
+		return
+	}
+	into, err := strconv.ParseInt(r.FormValue("into"), 10, 64)
+	if err != nil || into == id {
+		http.Error(w, "Choose another party to merge into", http.StatusBadRequest)
+		return
+	}
+	merged, err := h.queries.GetPartyByID(ctx, id)
+	if err != nil || merged.FirmID != firmID(ctx) {
+		http.NotFound(w, r)
+		return
+	}
+	survivor, err := h.queries.GetPartyByID(ctx, into)
+	if err != nil || survivor.FirmID != firmID(ctx) {
+		http.Error(w, "Party to merge into not found", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.mergeParties(ctx, merged, survivor); err != nil {
+		if strings.Contains(err.Error(), "closed financial year") {
+			http.Error(w, fmt.Sprintf("%s has entries in a closed financial year; reopen it to merge", merged.Name), http.StatusConflict)
+			return
+		}
+		http.Error(w, "Error merging parties", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/party/%d", survivor.ID), http.StatusSeeOther)
+}
+
+// mergeParties moves everything of merged to survivor in one transaction,
+// records the identifiers that moved in their history, keeps merged's name
+// as an alias of survivor so sale bills under it still link, and records the
+// merge
+func (h *Handler) mergeParties(ctx context.Context, merged, survivor sqlc.Party) error {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	q := h.queries.WithTx(tx)
+
+	identifiers, err := q.GetIdentifiersByPartyID(ctx, merged.ID)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range partyMergeMoves {
+		if _, err := tx.ExecContext(ctx, stmt, survivor.ID, merged.ID); err != nil {
+			return fmt.Errorf("merging party %d into %d: %w", merged.ID, survivor.ID, err)
+		}
+	}
+
+	detail := fmt.Sprintf("Merged %s (party %d)", merged.Name, merged.ID)
+	for _, identifier := range identifiers {
+		identifier.PartyID = survivor.ID
+		if err := addIdentifierHistory(ctx, q, identifier, merged.ID, identifierCauseMerge, detail); err != nil {
+			return err
+		}
+	}
+	if err := q.UpsertPartyAlias(ctx, sqlc.UpsertPartyAliasParams{
+		PartyID: survivor.ID,
+		Alias:   partyNameKey(merged.Name),
+		FirmID:  survivor.FirmID,
+	}); err != nil {
+		return fmt.Errorf("keeping %s as an alias: %w", merged.Name, err)
+	}
+	if err := q.CreatePartyMerge(ctx, sqlc.CreatePartyMergeParams{
+		FirmID:          survivor.FirmID,
+		MergedPartyID:   merged.ID,
+		MergedName:      merged.Name,
+		SurvivorPartyID: survivor.ID,
+	}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// survivingParty returns the ID of the party that id was merged into, and
+// whether it was merged
+func (h *Handler) survivingParty(ctx context.Context, id int64) (int64, bool) {
+	merge, err := h.queries.GetPartyMerge(ctx, id)
+	if err != nil {
+		return id, false
+	}
+	return merge.SurvivorPartyID, true
+}
+
+// partyByName finds the firm's party named name, or the party it was merged
+// into
+func (h *Handler) partyByName(ctx context.Context, name string) (int64, error) {
+	party, err := h.queries.GetPartyByName(ctx, sqlc.GetPartyByNameParams{Name: name, FirmID: firmID(ctx)})
+	if err == nil {
+		return party.ID, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+	merge, err := h.queries.GetPartyMergeByName(ctx, sqlc.GetPartyMergeByNameParams{MergedName: name, FirmID: firmID(ctx)})
+	if err != nil {
+		return 0, err
+	}
+	return merge.SurvivorPartyID, nil
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// postForm returns a POST of the url-encoded form values
+func postForm(target string, values url.Values) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(values.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r
+}
+
+// partyReferences lists the columns holding a party's ID, as table and column
+func partyReferences(t *testing.T, db *sql.DB) [][2]string {
+	t.Helper()
+	rows, err := db.Query(`SELECT m.name, f."from" FROM sqlite_master m, pragma_foreign_key_list(m.name) f
+		WHERE m.type = 'table' AND f."table" = 'parties'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	columns := [][2]string{{"transaction_splits", "original_party_id"}, {"party_merges", "survivor_party_id"}}
+	for rows.Next() {
+		var c [2]string
+		if err := rows.Scan(&c[0], &c[1]); err != nil {
+			t.Fatal(err)
+		}
+		columns = append(columns, c)
+	}
+	return columns
+}
+
+func TestMergeParty(t *testing.T) {
+	h, db := newTestHandler(t)
+	exec(t, db, `INSERT INTO parties (id, name, location, firm_id, phone) VALUES
+		(1, 'SHARMA MEDICAL', 'KANPUR', 1, ''), (2, 'SHARMA MEDICAL STORE', 'KANPUR', 1, '9450852076'),
+		(3, 'GUPTA STORES', 'UNNAO', 1, ''), (4, 'SHARMA MEDICAL', 'KANPUR', 2, '')`)
+	exec(t, db, `INSERT INTO transactions (id, party_id, amount, transaction_date, payment_mode, narration, firm_id) VALUES
+		(1, 1, 1000, '2025-04-02 00:00:00 +0000 UTC', 'UPI', 'UPI/1', 1),
+		(2, 2, 500, '2025-04-03 00:00:00 +0000 UTC', 'UPI', 'UPI/2', 1),
+		(3, 2, 250, '2025-04-04 00:00:00 +0000 UTC', 'NEFT', 'NEFT/3', 1),
+		(4, 3, 800, '2025-04-04 00:00:00 +0000 UTC', 'NEFT', 'NEFT/4', 1)`)
+	exec(t, db, `INSERT INTO sale_bills (id, bill_number, bill_date, party_name, amount, is_cash_sale, party_id, firm_id) VALUES
+		(1, 'A-1', '2025-04-01 00:00:00 +0000 UTC', 'SHARMA MEDICAL', 1500, FALSE, 1, 1),
+		(2, 'A-2', '2025-04-01 00:00:00 +0000 UTC', 'SHARMA MEDICAL STORE', 900, FALSE, 2, 1)`)
+	exec(t, db, `INSERT INTO bill_allocations (sale_bill_id, transaction_id, amount) VALUES (2, 2, 500)`)
+	exec(t, db, `INSERT INTO identifiers (party_id, type, value, firm_id) VALUES
+		(1, 'upi_vpa', 'sharma@okicici', 1), (2, 'phone', '9450852076', 1)`)
+	exec(t, db, `INSERT INTO party_aliases (party_id, alias, firm_id) VALUES (2, 'SHARMA MED', 1)`)
+	exec(t, db, `INSERT INTO party_notes (party_id, note) VALUES (2, 'Pays on the 5th')`)
+	exec(t, db, `INSERT INTO statement_links (party_id, token, expires_at) VALUES (2, 'abc', '2030-01-01 00:00:00 +0000 UTC')`)
+	exec(t, db, `INSERT INTO search_history (narration, top_party_id, firm_id) VALUES ('UPI/2', 2, 1)`)
+	exec(t, db, `INSERT INTO identifier_conflicts (firm_id, type, value, claimed_party_id) VALUES (1, 'upi_vpa', 'sharma@okicici', 2)`)
+	exec(t, db, `INSERT INTO merge_suggestions (firm_id, party_id, other_party_id, score, reasons) VALUES (1, 1, 2, 0.9, 'same place'), (1, 2, 3, 0.5, 'same UPI')`)
+
+	// a party of another firm cannot be merged into
+	w := serve(h, http.HandlerFunc(h.MergeParty), postForm("/party/merge", url.Values{"id": {"2"}, "into": {"4"}}))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("merging across firms: status = %d", w.Code)
+	}
+
+	w = serve(h, http.HandlerFunc(h.MergeParty), postForm("/party/merge", url.Values{"id": {"2"}, "into": {"1"}}))
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/party/1" {
+		t.Fatalf("status = %d, location %q: %s", w.Code, w.Header().Get("Location"), w.Body)
+	}
+
+	if n := count(t, db, "SELECT COUNT(*) FROM parties WHERE id = 2"); n != 0 {
+		t.Error("the merged party is still there")
+	}
+	for _, c := range partyReferences(t, db) {
+		query := `SELECT COUNT(*) FROM "` + c[0] + `" WHERE "` + c[1] + `" IS NOT NULL AND "` + c[1] + `" NOT IN (SELECT id FROM parties)`
+		if n := count(t, db, query); n != 0 {
+			t.Errorf("%s.%s: %v rows lead to a party that is gone", c[0], c[1], n)
+		}
+	}
+	for query, want := range map[string]float64{
+		"SELECT COUNT(*) FROM transactions WHERE party_id = 1":                             3,
+		"SELECT COUNT(*) FROM sale_bills WHERE party_id = 1":                               2,
+		"SELECT COUNT(*) FROM identifiers WHERE party_id = 1":                              2,
+		"SELECT COUNT(*) FROM party_notes WHERE party_id = 1":                              1,
+		"SELECT COUNT(*) FROM party_aliases WHERE party_id = 1":                            2,
+		"SELECT COUNT(*) FROM party_merges WHERE merged_party_id = 2":                      1,
+		"SELECT COUNT(*) FROM identifier_history WHERE party_id = 1":                       1,
+		"SELECT COUNT(*) FROM bill_allocations":                                            1,
+		"SELECT COUNT(*) FROM parties WHERE id = 1 AND phone = '9450852076'":               1,
+		"SELECT COUNT(*) FROM merge_suggestions WHERE party_id = 1 AND other_party_id = 1": 0,
+	} {
+		if n := count(t, db, query); n != want {
+			t.Errorf("%s = %v, want %v", query, n, want)
+		}
+	}
+
+	// the trigger-kept balance is the survivor's bills and receipts together
+	var receipts, received, billed float64
+	if err := db.QueryRow("SELECT receipt_count, received, billed FROM party_balances WHERE party_id = 1").Scan(&receipts, &received, &billed); err != nil {
+		t.Fatal(err)
+	}
+	if receipts != 3 || received != 1750 || billed != 2400 {
+		t.Errorf("party balance = %v receipts of %v, billed %v; want 3 of 1750, billed 2400", receipts, received, billed)
+	}
+	if n := count(t, db, "SELECT COUNT(*) FROM party_balances WHERE party_id = 2"); n != 0 {
+		t.Error("the merged party kept a balance")
+	}
+}
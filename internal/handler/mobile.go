@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"net/http"
+
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// MobileSearch renders the phone quick-search page: paste a narration, see the
+// matching party and what they owe
+func (h *Handler) MobileSearch(w http.ResponseWriter, r *http.Request) {
+	pages.MobileSearch().Render(r.Context(), w)
+}
+
+// MobileSearchResults matches a narration and shows each matched party with
+// its outstanding balance
+func (h *Handler) MobileSearchResults(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	narration := r.FormValue("narration")
+	if narration == "" {
+		w.Write([]byte(`<div class="error">Paste a narration to match.</div>`))
+		return
+	}
+
+	results, err := h.matcher.Match(ctx, firmID(ctx), narration, searchAmount(r))
+	if err != nil {
+		w.Write([]byte(`<div class="error">Error matching the narration.</div>`))
+		return
+	}
+	_ = h.recordSearch(ctx, narration, results)
+
+	matches := make([]pages.MobileMatch, 0, len(results))
+	for _, res := range results {
+		m := pages.MobileMatch{
+			PartyID:    res.Party.ID,
+			Name:       res.Party.Name,
+			Location:   res.Party.Location.String,
+			Confidence: res.Confidence,
+		}
+		if len(res.RecentTxns) > 0 {
+			m.LastPayment = res.RecentTxns[0].TransactionDate.Format("02 Jan 2006")
+		}
+		// A match may combine several parties of the same name; they owe
+		// together
+		for _, id := range res.PartyIDs {
+			balance, err := h.queries.GetPartyBalance(ctx, id)
+			if err != nil {
+				continue
+			}
+			m.Outstanding += balance.Billed - balance.Received
+			m.CreditLimit += balance.CreditLimit
+		}
+		matches = append(matches, m)
+	}
+
+	pages.MobileResults(matches).Render(ctx, w)
+}
+
+// webManifest makes the quick-search page installable on a phone home screen
+const webManifest = `{
+  "name": "Durga Dawa Ghar Suspense",
+  "short_name": "Suspense",
+  "start_url": "/m",
+  "scope": "/",
+  "display": "standalone",
+  "background_color": "#ffffff",
+  "theme_color": "#1e88e5",
+  "icons": [
+    {"src": "/icon.svg", "sizes": "any", "type": "image/svg+xml", "purpose": "any maskable"}
+  ]
+}
+`
+
+// serviceWorker keeps the pages last seen, and the stylesheet and scripts
+// they use, so the app opens without a connection and forms can be queued
+// offline. Pages are always fetched fresh when online; searches and other
+// posts always go to the server.
+const serviceWorker = `const CACHE = 'suspense-v2';
+const SHELL = ['/m', '/import', '/sale-bills/import', '/offline.js', '/manifest.webmanifest', '/icon.svg'];
+const ASSETS = [
+  'https://cdn.jsdelivr.net/npm/@picocss/pico@2/css/pico.min.css',
+  'https://unpkg.com/htmx.org@1.9.10'
+];
+
+self.addEventListener('install', function(e) {
+  e.waitUntil(caches.open(CACHE).then(function(c) { return c.addAll(SHELL); }));
+  self.skipWaiting();
+});
+
+self.addEventListener('activate', function(e) {
+  e.waitUntil(caches.keys().then(function(keys) {
+    return Promise.all(keys.filter(function(k) { return k !== CACHE; }).map(function(k) { return caches.delete(k); }));
+  }));
+  self.clients.claim();
+});
+
+self.addEventListener('fetch', function(e) {
+  if (e.request.method !== 'GET') return;
+  const url = new URL(e.request.url);
+  if (ASSETS.includes(e.request.url)) {
+    e.respondWith(caches.match(e.request).then(function(hit) {
+      return hit || fetch(e.request).then(function(res) {
+        const copy = res.clone();
+        caches.open(CACHE).then(function(c) { c.put(e.request, copy); });
+        return res;
+      });
+    }));
+    return;
+  }
+  if (url.origin !== location.origin || url.pathname === '/sw.js') return;
+  e.respondWith(fetch(e.request).then(function(res) {
+    if (res.ok) {
+      const copy = res.clone();
+      caches.open(CACHE).then(function(c) { c.put(e.request, copy); });
+    }
+    return res;
+  }).catch(function() { return caches.match(e.request); }));
+});
+`
+
+const appIcon = `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 512 512">
+<rect width="512" height="512" rx="96" fill="#1e88e5"/>
+<text x="256" y="340" font-family="sans-serif" font-size="260" font-weight="bold" text-anchor="middle" fill="#ffffff">₹</text>
+</svg>
+`
+
+// Manifest serves the web app manifest
+func (h *Handler) Manifest(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/manifest+json")
+	w.Write([]byte(webManifest))
+}
+
+// ServiceWorker serves the service worker from the site root so that it
+// controls the quick-search page
+func (h *Handler) ServiceWorker(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/javascript")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write([]byte(serviceWorker))
+}
+
+// AppIcon serves the home screen icon
+func (h *Handler) AppIcon(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Write([]byte(appIcon))
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestMobileSearchResults(t *testing.T) {
+	h, db := newTestHandler(t)
+	exec(t, db, `INSERT INTO parties (id, name, location, credit_limit, firm_id) VALUES
+		(1, 'SHARMA MEDICAL', 'KANPUR', 500, 1), (2, 'GUPTA STORES', '', 0, 1)`)
+	exec(t, db, `INSERT INTO identifiers (party_id, type, value, firm_id) VALUES (1, 'phone', '9876543210', 1)`)
+	exec(t, db, `INSERT INTO sale_bills (bill_number, bill_date, party_name, amount, is_cash_sale, party_id, firm_id) VALUES
+		('A-1', '2025-04-01 00:00:00 +0000 UTC', 'SHARMA MEDICAL', 1000, FALSE, 1, 1),
+		('A-2', '2025-04-02 00:00:00 +0000 UTC', 'GUPTA STORES', 300, FALSE, 2, 1)`)
+	exec(t, db, `INSERT INTO transactions (party_id, amount, transaction_date, payment_mode, narration, firm_id)
+		VALUES (1, 400, '2025-04-05 00:00:00 +0000 UTC', 'UPI', 'UPI/9876543210@ybl', 1)`)
+	search := func(narration string) string {
+		return serve(h, http.HandlerFunc(h.MobileSearchResults), postForm("/m/search", url.Values{"narration": {narration}})).Body.String()
+	}
+
+	body := search("UPI/9876543210@YBL/PAYMENT FROM PHONEPE")
+	for _, want := range []string{"SHARMA MEDICAL", "(KANPUR)", "last paid 05 Apr 2025", "₹600.00 Dr", "Limit ₹500.00", `href="/party/`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("quick search result lacks %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "GUPTA STORES") {
+		t.Errorf("quick search shows a party not matched:\n%s", body)
+	}
+	if n := count(t, db, "SELECT COUNT(*) FROM search_history WHERE top_party_name = 'SHARMA MEDICAL'"); n != 1 {
+		t.Errorf("quick search not kept in the history")
+	}
+
+	if body := search(""); !strings.Contains(body, "Paste a narration") {
+		t.Errorf("empty narration: %s", body)
+	}
+	if body := search("NEFT/UNKNOWN TRADERS"); !strings.Contains(body, "No party matches") {
+		t.Errorf("narration of no party: %s", body)
+	}
+
+	w := httptest.NewRecorder()
+	h.Manifest(w, httptest.NewRequest(http.MethodGet, "/manifest.webmanifest", nil))
+	if !strings.Contains(w.Body.String(), `"start_url": "/m"`) || w.Header().Get("Content-Type") != "application/manifest+json" {
+		t.Errorf("manifest does not install the quick-search page: %s", w.Body)
+	}
+}
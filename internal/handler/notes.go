@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+)
+
+// AddPartyNote adds a timestamped note to a party, such as how they usually
+// pay or which bill they dispute
+func (h *Handler) AddPartyNote(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	partyID, err := strconv.ParseInt(r.FormValue("party_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid party ID", http.StatusBadRequest)
+		return
+	}
+	note := strings.TrimSpace(r.FormValue("note"))
+	if note == "" {
+		http.Error(w, "Note is empty", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.queries.CreatePartyNote(r.Context(), sqlc.CreatePartyNoteParams{
+		PartyID: partyID,
+		Note:    note,
+	}); err != nil {
+		http.Error(w, "Error saving note", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/party/%d?tab=notes", partyID), http.StatusSeeOther)
+}
+
+// DeletePartyNote removes a note from a party
+func (h *Handler) DeletePartyNote(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid note ID", http.StatusBadRequest)
+		return
+	}
+	partyID, err := strconv.ParseInt(r.FormValue("party_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid party ID", http.StatusBadRequest)
+		return
+	}
+	if err := h.queries.DeletePartyNote(r.Context(), id); err != nil {
+		http.Error(w, "Error deleting note", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/party/%d?tab=notes", partyID), http.StatusSeeOther)
+}
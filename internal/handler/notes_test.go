@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestPartyNotes(t *testing.T) {
+	h, db := newTestHandler(t)
+	exec(t, db, `INSERT INTO parties (id, name, firm_id) VALUES (1, 'SHARMA MEDICAL', 1), (2, 'GUPTA STORES', 1)`)
+	add := func(party, note string) *httptest.ResponseRecorder {
+		return serve(h, http.HandlerFunc(h.AddPartyNote), postForm("/party/notes", url.Values{"party_id": {party}, "note": {note}}))
+	}
+	notes := func(party string) string {
+		return serve(h, http.HandlerFunc(h.PartyDetail), httptest.NewRequest(http.MethodGet, "/party/"+party+"?tab=notes", nil)).Body.String()
+	}
+
+	if w := add("1", "  "); w.Code != http.StatusBadRequest {
+		t.Errorf("empty note: status = %d", w.Code)
+	}
+	w := add("1", "  Pays on the 5th by his son's UPI ")
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/party/1?tab=notes" {
+		t.Fatalf("adding a note: status = %d, location %q", w.Code, w.Header().Get("Location"))
+	}
+	add("1", "Disputes bill A-1742")
+
+	if body := notes("1"); !strings.Contains(body, "Pays on the 5th by his son&#39;s UPI") || !strings.Contains(body, "Disputes bill A-1742") {
+		t.Errorf("party page lacks its notes:\n%s", body)
+	}
+	if body := notes("2"); strings.Contains(body, "A-1742") {
+		t.Errorf("another party's page shows the notes:\n%s", body)
+	}
+
+	id := count(t, db, "SELECT id FROM party_notes WHERE note = 'Disputes bill A-1742'")
+	w = serve(h, http.HandlerFunc(h.DeletePartyNote), postForm("/party/notes/delete", url.Values{"id": {strconv.FormatFloat(id, 'f', -1, 64)}, "party_id": {"1"}}))
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("deleting a note: status = %d: %s", w.Code, w.Body)
+	}
+	if body := notes("1"); strings.Contains(body, "A-1742") || !strings.Contains(body, "on the 5th") {
+		t.Errorf("deleting one note left the wrong notes:\n%s", body)
+	}
+}
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// OffsiteBackup takes a snapshot of the whole database and uploads it,
+// encrypted, to the off-site bucket, pruning the oldest beyond the number
+// kept. The upload, or why it failed, is recorded for the settings page. It
+// returns the key the snapshot was stored under.
+func (h *Handler) OffsiteBackup(ctx context.Context) (string, error) {
+	if h.offsite == nil {
+		return "", fmt.Errorf("off-site backup is not set up")
+	}
+	key, size, err := h.uploadSnapshot(ctx)
+	record := sqlc.RecordOffsiteBackupParams{ObjectKey: key, SizeBytes: size}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	if recErr := h.queries.RecordOffsiteBackup(ctx, record); recErr != nil && err == nil {
+		err = fmt.Errorf("recording off-site backup: %w", recErr)
+	}
+	return key, err
+}
+
+// uploadSnapshot takes a snapshot into a temporary directory and uploads it,
+// returning its key and its size before encryption
+func (h *Handler) uploadSnapshot(ctx context.Context) (string, int64, error) {
+	dir, err := os.MkdirTemp("", "suspense-offsite-")
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.RemoveAll(dir)
+
+	name := snapshotName(time.Now())
+	file := filepath.Join(dir, name+".db")
+	size, err := h.backupTo(ctx, file)
+	if err != nil {
+		return "", 0, fmt.Errorf("taking snapshot: %w", err)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", 0, err
+	}
+	key, err := h.offsite.Upload(ctx, name, data)
+	return key, size, err
+}
+
+// snapshotName names a snapshot taken at t by the second it was taken, so
+// names sort in the order taken, with a random ending so two taken in the
+// same second do not overwrite each other in the bucket
+func snapshotName(t time.Time) string {
+	b := make([]byte, 3)
+	rand.Read(b)
+	return "suspense-" + t.Format("2006-01-02-150405") + "-" + hex.EncodeToString(b)
+}
+
+// OffsiteBackups shows where off-site backups go, the recent uploads with
+// any failures, and the snapshots in the bucket
+func (h *Handler) OffsiteBackups(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	view := pages.OffsiteView{Uploaded: r.URL.Query().Get("uploaded")}
+	if h.offsite == nil {
+		pages.OffsiteBackups(view).Render(ctx, w)
+		return
+	}
+	view.Location = h.offsite.Location()
+
+	uploads, err := h.queries.ListOffsiteBackups(ctx)
+	if err != nil {
+		http.Error(w, "Error loading off-site backups", http.StatusInternalServerError)
+		return
+	}
+	for _, u := range uploads {
+		view.Uploads = append(view.Uploads, pages.OffsiteUploadView{
+			Key:       u.ObjectKey,
+			Size:      u.SizeBytes,
+			Error:     u.Error,
+			CreatedAt: u.CreatedAt.Time.Local().Format("02 Jan 2006 15:04"),
+		})
+	}
+
+	snapshots, err := h.offsite.List(ctx)
+	if err != nil {
+		view.ListError = err.Error()
+	}
+	for _, s := range snapshots {
+		view.Snapshots = append(view.Snapshots, pages.OffsiteSnapshotView{
+			Key:     s.Key,
+			Size:    s.Size,
+			Created: s.Created.Local().Format("02 Jan 2006 15:04"),
+		})
+	}
+	pages.OffsiteBackups(view).Render(ctx, w)
+}
+
+// BackUpOffsite uploads an off-site backup now rather than waiting for the
+// nightly one
+func (h *Handler) BackUpOffsite(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.offsite == nil {
+		http.Error(w, "Off-site backup is not set up", http.StatusServiceUnavailable)
+		return
+	}
+	key, err := h.OffsiteBackup(r.Context())
+	if err != nil {
+		// The failure is in the upload log
+		http.Redirect(w, r, "/settings/offsite", http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, "/settings/offsite?uploaded="+path.Base(key), http.StatusSeeOther)
+}
+
+// DownloadOffsite fetches a snapshot from the bucket and streams it
+// decrypted, ready to open with -db, to check a backup restores or to
+// restore one on another machine
+func (h *Handler) DownloadOffsite(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.offsite == nil {
+		http.Error(w, "Off-site backup is not set up", http.StatusServiceUnavailable)
+		return
+	}
+	key := r.FormValue("key")
+	data, err := h.offsite.Download(r.Context(), key)
+	if err != nil {
+		http.Error(w, "Error downloading backup: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	filename := strings.TrimSuffix(path.Base(key), ".enc")
+	if key == "latest" {
+		filename = "suspense-latest.db"
+	}
+	w.Header().Set("Content-Type", "application/vnd.sqlite3")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(data)))
+	w.Write(data)
+}
@@ -0,0 +1,17 @@
+package handler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSnapshotName(t *testing.T) {
+	at := time.Date(2026, time.October, 17, 3, 0, 0, 0, time.UTC)
+	first, second := snapshotName(at), snapshotName(at)
+	if first == second {
+		t.Errorf("two snapshots taken at once are both named %s", first)
+	}
+	if later := snapshotName(at.Add(time.Second)); later <= first || later <= second {
+		t.Errorf("snapshot taken a second later, %s, sorts before %s or %s", later, first, second)
+	}
+}
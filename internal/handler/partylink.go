@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// partyNameKey is the form sale bill party names are matched in, ignoring
+// case and surrounding spaces
+func partyNameKey(name string) string {
+	return strings.ToUpper(strings.TrimSpace(name))
+}
+
+// salePartyIndex maps sale bill party names to party IDs. A name matches the
+// party of that name unless several parties share it; aliases recorded while
+// reviewing unlinked bills take precedence.
+func (h *Handler) salePartyIndex(ctx context.Context) (map[string]int64, error) {
+	parties, err := h.queries.ListParties(ctx, firmID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	aliases, err := h.queries.ListPartyAliases(ctx, firmID(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int64)
+	ambiguous := make(map[string]bool)
+	for _, p := range parties {
+		key := partyNameKey(p.Name)
+		if _, ok := index[key]; ok {
+			ambiguous[key] = true
+		}
+		index[key] = p.ID
+	}
+	for key := range ambiguous {
+		delete(index, key)
+	}
+	for _, a := range aliases {
+		index[a.Alias] = a.PartyID
+	}
+	return index, nil
+}
+
+// linkUnlinkedSaleBills links credit sale bills without a party to the party
+// their name now matches, and reports how many were linked
+func (h *Handler) linkUnlinkedSaleBills(ctx context.Context) (int, error) {
+	index, err := h.salePartyIndex(ctx)
+	if err != nil {
+		return 0, err
+	}
+	bills, err := h.queries.ListUnlinkedSaleBills(ctx, firmID(ctx))
+	if err != nil {
+		return 0, err
+	}
+
+	linked := 0
+	for _, b := range bills {
+		partyID, ok := index[partyNameKey(b.PartyName)]
+		if !ok {
+			continue
+		}
+		if err := h.queries.LinkSaleBill(ctx, sqlc.LinkSaleBillParams{
+			PartyID: sql.NullInt64{Int64: partyID, Valid: true},
+			ID:      b.ID,
+		}); err != nil {
+			return linked, fmt.Errorf("linking bill %s: %w", b.BillNumber, err)
+		}
+		linked++
+	}
+	return linked, nil
+}
+
+// UnlinkedSaleBills lists credit sale bills not linked to a party, grouped by
+// the party name on the bill, for linking to an existing or new party
+func (h *Handler) UnlinkedSaleBills(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	bills, err := h.queries.ListUnlinkedSaleBills(ctx, firmID(ctx))
+	if err != nil {
+		http.Error(w, "Error loading sale bills", http.StatusInternalServerError)
+		return
+	}
+	parties, err := h.queries.ListParties(ctx, firmID(ctx))
+	if err != nil {
+		http.Error(w, "Error loading parties", http.StatusInternalServerError)
+		return
+	}
+
+	// Bills are ordered by party name, so each name's bills are together
+	var names []pages.UnlinkedSaleParty
+	for _, b := range bills {
+		key := partyNameKey(b.PartyName)
+		if len(names) == 0 || partyNameKey(names[len(names)-1].Name) != key {
+			names = append(names, pages.UnlinkedSaleParty{
+				Name:      strings.TrimSpace(b.PartyName),
+				FirstDate: b.BillDate.Format("02 Jan 2006"),
+			})
+		}
+		n := &names[len(names)-1]
+		n.Bills++
+		n.Total += b.Amount
+		n.LastDate = b.BillDate.Format("02 Jan 2006")
+	}
+
+	options := make([]pages.PartyOption, len(parties))
+	for i, p := range parties {
+		options[i] = pages.PartyOption{ID: p.ID, Name: p.Name, Location: p.Location.String}
+	}
+
+	pages.UnlinkedSaleBills(names, options).Render(ctx, w)
+}
+
+// LinkSaleBills links all unlinked bills of a party name to the chosen party,
+// or to a new party of that name, and remembers the name as the party's alias
+// for future imports
+func (h *Handler) LinkSaleBills(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	name := strings.TrimSpace(r.FormValue("party_name"))
+	if name == "" {
+		http.Error(w, "Party name is required", http.StatusBadRequest)
+		return
+	}
+
+	var partyID int64
+	if r.FormValue("create") != "" {
+		party, err := h.queries.CreateParty(ctx, sqlc.CreatePartyParams{Name: name, FirmID: firmID(ctx)})
+		if err != nil {
+			http.Error(w, "Error creating party", http.StatusInternalServerError)
+			return
+		}
+		partyID = party.ID
+	} else {
+		id, err := strconv.ParseInt(r.FormValue("party_id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Choose a party to link", http.StatusBadRequest)
+			return
+		}
+		partyID = id
+	}
+
+	if err := h.queries.UpsertPartyAlias(ctx, sqlc.UpsertPartyAliasParams{
+		PartyID: partyID,
+		Alias:   partyNameKey(name),
+		FirmID:  firmID(ctx),
+	}); err != nil {
+		http.Error(w, "Error saving alias", http.StatusInternalServerError)
+		return
+	}
+	if _, err := h.linkUnlinkedSaleBills(ctx); err != nil {
+		http.Error(w, "Error linking sale bills", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/sale-bills/unlinked", http.StatusSeeOther)
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestLinkSaleBills(t *testing.T) {
+	h, db := newTestHandler(t)
+	// Two parties are called GUPTA STORES, so the name alone links neither
+	exec(t, db, `INSERT INTO parties (id, name, firm_id) VALUES (1, 'SHARMA MEDICAL', 1), (2, 'GUPTA STORES', 1), (3, 'GUPTA STORES', 1)`)
+	exec(t, db, `INSERT INTO sale_bills (id, bill_number, bill_date, party_name, amount, is_cash_sale, firm_id) VALUES
+		(1, 'A-1', '2025-04-01 00:00:00 +0000 UTC', ' sharma medical', 1000, FALSE, 1),
+		(2, 'A-2', '2025-04-02 00:00:00 +0000 UTC', 'SHARMA MED.', 500, FALSE, 1),
+		(3, 'A-3', '2025-04-03 00:00:00 +0000 UTC', 'SHARMA MED.', 250, FALSE, 1),
+		(4, 'A-4', '2025-04-03 00:00:00 +0000 UTC', 'GUPTA STORES', 800, FALSE, 1),
+		(5, 'A-5', '2025-04-04 00:00:00 +0000 UTC', 'VERMA AGENCIES', 300, FALSE, 1),
+		(6, 'B-1', '2025-04-04 00:00:00 +0000 UTC', 'SHARMA MED.', 900, FALSE, 2)`)
+	link := func(form url.Values) {
+		t.Helper()
+		if w := serve(h, http.HandlerFunc(h.LinkSaleBills), postForm("/sale-bills/link", form)); w.Code != http.StatusSeeOther {
+			t.Fatalf("linking %v: status = %d: %s", form, w.Code, w.Body)
+		}
+	}
+	partyOf := func(bill int) float64 {
+		return count(t, db, "SELECT party_id FROM sale_bills WHERE id = ?", bill)
+	}
+
+	w := serve(h, http.HandlerFunc(h.UnlinkedSaleBills), httptest.NewRequest(http.MethodGet, "/sale-bills/unlinked", nil))
+	if body := w.Body.String(); !strings.Contains(body, "SHARMA MED.") || !strings.Contains(body, "₹750.00") {
+		t.Errorf("review screen lacks the two SHARMA MED. bills:\n%s", body)
+	}
+
+	// Linking a name links the bills the names now match, and the name is
+	// kept as an alias for later imports
+	link(url.Values{"party_name": {"SHARMA MED."}, "party_id": {"1"}})
+	link(url.Values{"party_name": {"GUPTA STORES"}, "party_id": {"3"}})
+	for bill, want := range map[int]float64{1: 1, 2: 1, 3: 1, 4: 3, 5: 0, 6: 0} {
+		if got := partyOf(bill); got != want {
+			t.Errorf("bill %d linked to party %v, want %v", bill, got, want)
+		}
+	}
+	if n := count(t, db, "SELECT COUNT(*) FROM party_aliases WHERE alias = 'SHARMA MED.' AND party_id = 1 AND firm_id = 1"); n != 1 {
+		t.Errorf("alias not kept")
+	}
+
+	// A bill imported later under the alias is linked when bills are next
+	// linked, here with a new party for another name
+	exec(t, db, `INSERT INTO sale_bills (id, bill_number, bill_date, party_name, amount, is_cash_sale, firm_id)
+		VALUES (7, 'A-7', '2025-04-05 00:00:00 +0000 UTC', 'Sharma Med.', 100, FALSE, 1)`)
+	link(url.Values{"party_name": {"VERMA AGENCIES"}, "create": {"1"}})
+	if got := partyOf(7); got != 1 {
+		t.Errorf("bill under the alias linked to party %v, want 1", got)
+	}
+	if n := count(t, db, "SELECT COUNT(*) FROM parties p JOIN sale_bills b ON b.party_id = p.id WHERE b.id = 5 AND p.name = 'VERMA AGENCIES' AND p.firm_id = 1"); n != 1 {
+		t.Errorf("bill of a new party not linked to it")
+	}
+}
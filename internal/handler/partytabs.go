@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// partyPageSize is how many rows a party page tab shows at a time
+const partyPageSize = 25
+
+// partyTab returns the party page tab named in a request, defaulting to
+// receipts
+func partyTab(tab string) string {
+	switch tab {
+	case pages.PartyTabBills, pages.PartyTabAllocations, pages.PartyTabIdentifiers, pages.PartyTabHistory, pages.PartyTabNotes:
+		return tab
+	}
+	return pages.PartyTabReceipts
+}
+
+// paginate returns the bounds of a page of n rows, with the page clamped to
+// the pages available. Pages are numbered from 1.
+func paginate(n, page int) (start, end, current, pages int) {
+	pages = max(1, (n+partyPageSize-1)/partyPageSize)
+	current = min(max(page, 1), pages)
+	start = (current - 1) * partyPageSize
+	end = min(start+partyPageSize, n)
+	return start, end, current, pages
+}
+
+// partyAllocations loads what a party's receipts are allocated from: its
+// credit bills and receipts, oldest first, and the allocations made by hand
+func (h *Handler) partyAllocations(ctx context.Context, partyID int64) ([]sqlc.SaleBill, []sqlc.Transaction, []sqlc.BillAllocation, error) {
+	bills, err := h.queries.GetCreditSaleBillsByPartyID(ctx, sql.NullInt64{Int64: partyID, Valid: true})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	receipts, err := h.partyReceipts(ctx, partyID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	manual, err := h.queries.ListBillAllocationsByParty(ctx, partyID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return bills, receipts, manual, nil
+}
+
+// billStatuses lists a party's credit bills, newest first, with the receipts
+// allocated to each and what remains due, a shortfall within tolerance being
+// written off as rounding
+func billStatuses(bills []sqlc.SaleBill, receipts []sqlc.Transaction, manual []sqlc.BillAllocation, tolerance float64) []pages.BillStatus {
+	allocations := allocateReceipts(bills, receipts, manual, tolerance)
+
+	statuses := make([]pages.BillStatus, len(bills))
+	for i, b := range bills {
+		status := pages.BillStatus{
+			ID:          b.ID,
+			BillNumber:  b.BillNumber,
+			Date:        b.BillDate.Format("02 Jan 2006"),
+			Amount:      b.Amount,
+			Allocations: allocations[b.ID],
+		}
+		status.Due = b.Amount
+		for _, a := range status.Allocations {
+			status.Paid += a.Amount
+			status.Due -= a.Amount + a.RoundingDiff
+		}
+		if status.Due < billSettledTolerance {
+			status.Due = 0
+		}
+		statuses[len(bills)-1-i] = status
+	}
+	return statuses
+}
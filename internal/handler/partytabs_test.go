@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPaginate(t *testing.T) {
+	for _, tt := range []struct {
+		n, page                   int
+		start, end, current, last int
+	}{
+		{0, 1, 0, 0, 1, 1},
+		{partyPageSize, 2, 0, partyPageSize, 1, 1},
+		{partyPageSize + 5, 2, partyPageSize, partyPageSize + 5, 2, 2},
+		{partyPageSize + 5, 9, partyPageSize, partyPageSize + 5, 2, 2},
+		{partyPageSize + 5, 0, 0, partyPageSize, 1, 2},
+	} {
+		start, end, current, last := paginate(tt.n, tt.page)
+		if start != tt.start || end != tt.end || current != tt.current || last != tt.last {
+			t.Errorf("paginate(%d, %d) = %d, %d, %d, %d, want %d, %d, %d, %d",
+				tt.n, tt.page, start, end, current, last, tt.start, tt.end, tt.current, tt.last)
+		}
+	}
+}
+
+func TestPartyTabs(t *testing.T) {
+	h, db := newTestHandler(t)
+	exec(t, db, `INSERT INTO parties (id, name, firm_id) VALUES (1, 'SHARMA MEDICAL', 1)`)
+	// Bills B-001 to B-030, a day apart, newest first on the page
+	for i := 1; i <= 30; i++ {
+		exec(t, db, `INSERT INTO sale_bills (bill_number, bill_date, party_name, amount, is_cash_sale, party_id, firm_id)
+			VALUES (?, ?, 'SHARMA MEDICAL', 100, FALSE, 1, 1)`, fmt.Sprintf("B-%03d", i), fmt.Sprintf("2025-04-%02d 00:00:00 +0000 UTC", i))
+	}
+	exec(t, db, `INSERT INTO transactions (party_id, amount, transaction_date, payment_mode, narration, firm_id)
+		VALUES (1, 450, '2025-05-01 00:00:00 +0000 UTC', 'UPI', 'UPI/SHARMA', 1)`)
+	party := func(query string) string {
+		return serve(h, http.HandlerFunc(h.PartyDetail), httptest.NewRequest(http.MethodGet, "/party/1"+query, nil)).Body.String()
+	}
+
+	for _, tt := range []struct {
+		query      string
+		shown, not []string
+	}{
+		{"", []string{"UPI/SHARMA"}, []string{"B-030"}},
+		{"?tab=unknown", []string{"UPI/SHARMA"}, []string{"B-030"}},
+		{"?tab=bills", []string{"B-030", "B-006"}, []string{"B-005", "UPI/SHARMA"}},
+		{"?tab=bills&page=2", []string{"B-005", "B-001"}, []string{"B-006"}},
+		{"?tab=bills&page=9", []string{"B-005", "B-001"}, []string{"B-006"}},
+	} {
+		body := party(tt.query)
+		for _, s := range tt.shown {
+			if !strings.Contains(body, s) {
+				t.Errorf("/party/1%s lacks %s", tt.query, s)
+			}
+		}
+		for _, s := range tt.not {
+			if strings.Contains(body, s) {
+				t.Errorf("/party/1%s shows %s", tt.query, s)
+			}
+		}
+	}
+}
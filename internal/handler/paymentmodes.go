@@ -0,0 +1,261 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/parser"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// renderPaymentModes renders the payment mode rules page with the given form
+func (h *Handler) renderPaymentModes(w http.ResponseWriter, r *http.Request, form pages.PaymentModeForm, formError string) {
+	list, err := h.queries.ListPaymentModeRules(r.Context())
+	if err != nil {
+		http.Error(w, "Error loading payment mode rules", http.StatusInternalServerError)
+		return
+	}
+	pages.PaymentModes(list, form, formError).Render(r.Context(), w)
+}
+
+// PaymentModes lists the payment mode detection rules, with a form to add or
+// edit one
+func (h *Handler) PaymentModes(w http.ResponseWriter, r *http.Request) {
+	form := pages.PaymentModeForm{Priority: "100"}
+	if editStr := r.URL.Query().Get("edit"); editStr != "" {
+		id, err := strconv.ParseInt(editStr, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid rule ID", http.StatusBadRequest)
+			return
+		}
+		row, err := h.queries.GetPaymentModeRule(r.Context(), id)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		form = pages.PaymentModeForm{
+			ID:       row.ID,
+			Pattern:  row.Pattern,
+			Mode:     row.Mode,
+			Priority: strconv.FormatInt(row.Priority, 10),
+		}
+	}
+	h.renderPaymentModes(w, r, form, "")
+}
+
+// SavePaymentMode creates or updates a payment mode rule
+func (h *Handler) SavePaymentMode(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	form := pages.PaymentModeForm{
+		Pattern:  strings.TrimSpace(r.FormValue("pattern")),
+		Mode:     strings.ToUpper(strings.TrimSpace(r.FormValue("mode"))),
+		Priority: strings.TrimSpace(r.FormValue("priority")),
+	}
+	if id, err := strconv.ParseInt(r.FormValue("id"), 10, 64); err == nil {
+		form.ID = id
+	}
+
+	priority := int64(100)
+	if form.Priority != "" {
+		var err error
+		if priority, err = strconv.ParseInt(form.Priority, 10, 64); err != nil {
+			h.renderPaymentModes(w, r, form, "Invalid priority.")
+			return
+		}
+	}
+	if err := parser.ValidatePaymentModeRule(parser.PaymentModeRule{Pattern: form.Pattern, Mode: form.Mode}); err != nil {
+		h.renderPaymentModes(w, r, form, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+	var err error
+	if form.ID > 0 {
+		err = h.queries.UpdatePaymentModeRule(ctx, sqlc.UpdatePaymentModeRuleParams{
+			Pattern:  form.Pattern,
+			Mode:     form.Mode,
+			Priority: priority,
+			ID:       form.ID,
+		})
+	} else {
+		_, err = h.queries.CreatePaymentModeRule(ctx, sqlc.CreatePaymentModeRuleParams{
+			Pattern:  form.Pattern,
+			Mode:     form.Mode,
+			Priority: priority,
+		})
+	}
+	if err != nil {
+		h.renderPaymentModes(w, r, form, fmt.Sprintf("Error saving rule: %s", err.Error()))
+		return
+	}
+
+	http.Redirect(w, r, "/settings/payment-modes", http.StatusSeeOther)
+}
+
+// DeletePaymentMode removes a payment mode rule
+func (h *Handler) DeletePaymentMode(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid rule ID", http.StatusBadRequest)
+		return
+	}
+	if err := h.queries.DeletePaymentModeRule(r.Context(), id); err != nil {
+		http.Error(w, "Error deleting rule", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/settings/payment-modes", http.StatusSeeOther)
+}
+
+// TestPaymentMode shows which payment mode rule a sample narration matches
+func (h *Handler) TestPaymentMode(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	narration := strings.TrimSpace(r.FormValue("narration"))
+	if narration == "" {
+		w.Write([]byte(`<div class="error">Please enter a narration to test.</div>`))
+		return
+	}
+
+	p, err := h.loadParser(r.Context())
+	if err != nil {
+		w.Write([]byte(fmt.Sprintf(`<div class="error">Error loading payment mode rules: %s</div>`, err.Error())))
+		return
+	}
+	rule, ok := p.MatchPaymentMode(narration)
+	pages.PaymentModeTestResult(rule, ok).Render(r.Context(), w)
+}
+
+// redetectPreviewRows is how many of the entries whose mode would change the
+// redetect page lists
+const redetectPreviewRows = 200
+
+// paymentModeChanges runs the current payment mode rules over the firm's
+// stored narrations and returns the entries whose mode would change, leaving
+// out opening balances
+func (h *Handler) paymentModeChanges(ctx context.Context) ([]pages.PaymentModeChange, error) {
+	p, err := h.loadParser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := h.queries.ListTransactionPaymentModes(ctx, firmID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	var changes []pages.PaymentModeChange
+	for _, row := range rows {
+		if row.PaymentMode.String == openingMode {
+			continue
+		}
+		mode := p.DetectPaymentMode(row.Narration.String)
+		if mode == row.PaymentMode.String {
+			continue
+		}
+		changes = append(changes, pages.PaymentModeChange{
+			TransactionID: row.ID,
+			PartyID:       row.PartyID,
+			PartyName:     row.PartyName,
+			Date:          row.TransactionDate,
+			Narration:     row.Narration.String,
+			From:          row.PaymentMode.String,
+			To:            mode,
+		})
+	}
+	return changes, nil
+}
+
+// RedetectPaymentModes shows the entries whose payment mode the current
+// rules would change, counted by old and new mode, with a button to update
+// them. Entries imported under older rules are otherwise left as they were.
+func (h *Handler) RedetectPaymentModes(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	changes, err := h.paymentModeChanges(ctx)
+	if err != nil {
+		http.Error(w, "Error checking payment modes", http.StatusInternalServerError)
+		return
+	}
+
+	view := pages.RedetectView{Total: len(changes), Updated: r.URL.Query().Get("updated"), Skipped: r.URL.Query().Get("skipped")}
+	counts := make(map[[2]string]int)
+	for _, c := range changes {
+		counts[[2]string{c.From, c.To}]++
+	}
+	for k, n := range counts {
+		view.Summary = append(view.Summary, pages.PaymentModeChangeCount{From: k[0], To: k[1], Entries: n})
+	}
+	sort.Slice(view.Summary, func(i, j int) bool {
+		if view.Summary[i].Entries != view.Summary[j].Entries {
+			return view.Summary[i].Entries > view.Summary[j].Entries
+		}
+		if view.Summary[i].From != view.Summary[j].From {
+			return view.Summary[i].From < view.Summary[j].From
+		}
+		return view.Summary[i].To < view.Summary[j].To
+	})
+	view.Changes = changes[:min(len(changes), redetectPreviewRows)]
+	pages.RedetectPaymentModes(view).Render(ctx, w)
+}
+
+// ApplyPaymentModes updates the entries whose payment mode the current rules
+// would change, in one transaction. Entries of closed financial years, and
+// entries that would become a copy of another, are skipped and counted.
+func (h *Handler) ApplyPaymentModes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+	changes, err := h.paymentModeChanges(ctx)
+	if err != nil {
+		http.Error(w, "Error checking payment modes", http.StatusInternalServerError)
+		return
+	}
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		http.Error(w, "Error updating payment modes", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	q := h.queries.WithTx(tx)
+
+	updated, skipped := 0, 0
+	for _, c := range changes {
+		n, err := q.UpdateTransactionPaymentMode(ctx, sqlc.UpdateTransactionPaymentModeParams{
+			PaymentMode: sql.NullString{String: c.To, Valid: true},
+			ID:          c.TransactionID,
+		})
+		if err != nil && !strings.Contains(err.Error(), "closed financial year") {
+			http.Error(w, "Error updating payment modes", http.StatusInternalServerError)
+			return
+		}
+		if err != nil || n == 0 {
+			skipped++
+			continue
+		}
+		updated++
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error updating payment modes", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/settings/payment-modes/redetect?updated=%d&skipped=%d", updated, skipped), http.StatusSeeOther)
+}
@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"suspense.durgadawaghar.com/internal/fy"
+)
+
+// reportPeriod reads the dates a report covers: the financial year chosen in
+// the fy parameter, else the from_date and till_date parameters, each
+// defaulting to defaultFrom and today. year is the chosen financial year, if
+// one was.
+func reportPeriod(r *http.Request, defaultFrom time.Time) (from, till time.Time, year string) {
+	if y, err := fy.Parse(r.FormValue("fy")); err == nil {
+		return y.Start(), y.End(), y.Label()
+	}
+	from, till = defaultFrom, time.Now()
+	if parsed, err := time.Parse("2006-01-02", r.FormValue("from_date")); err == nil {
+		from = parsed
+	}
+	if parsed, err := time.Parse("2006-01-02", r.FormValue("till_date")); err == nil {
+		till = parsed
+	}
+	return from, till, ""
+}
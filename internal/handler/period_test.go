@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReportPeriod(t *testing.T) {
+	defaultFrom := time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC)
+	date := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+	for _, tt := range []struct {
+		query      string
+		from, till time.Time
+		year       string
+	}{
+		{"?fy=2025-26", date("2025-04-01"), date("2026-03-31"), "2025-26"},
+		// a financial year overrides the dates
+		{"?fy=2024-2025&from_date=2025-01-01", date("2024-04-01"), date("2025-03-31"), "2024-25"},
+		{"?fy=FY25&from_date=2025-01-01&till_date=2025-01-31", date("2025-01-01"), date("2025-01-31"), ""},
+		{"?till_date=2025-07-31", defaultFrom, date("2025-07-31"), ""},
+	} {
+		from, till, year := reportPeriod(httptest.NewRequest(http.MethodGet, "/report"+tt.query, nil), defaultFrom)
+		if !from.Equal(tt.from) || !till.Equal(tt.till) || year != tt.year {
+			t.Errorf("%s: period %s to %s (%q), want %s to %s (%q)", tt.query,
+				from.Format("2006-01-02"), till.Format("2006-01-02"), year, tt.from.Format("2006-01-02"), tt.till.Format("2006-01-02"), tt.year)
+		}
+	}
+
+	if from, till, _ := reportPeriod(httptest.NewRequest(http.MethodGet, "/report", nil), defaultFrom); !from.Equal(defaultFrom) || time.Since(till) > time.Minute {
+		t.Errorf("no period chosen: %s to %s, want %s to today", from, till, defaultFrom)
+	}
+}
+
+func TestExportFinancialYear(t *testing.T) {
+	h, db := newTestHandler(t)
+	exec(t, db, `INSERT INTO parties (id, name, firm_id) VALUES (1, 'SHARMA MEDICAL', 1)`)
+	exec(t, db, `INSERT INTO transactions (party_id, amount, transaction_date, payment_mode, narration, firm_id) VALUES
+		(1, 100, '2025-03-31 00:00:00 +0000 UTC', 'UPI', 'UPI/LAST YEAR', 1),
+		(1, 200, '2025-04-01 00:00:00 +0000 UTC', 'UPI', 'UPI/FIRST DAY', 1),
+		(1, 300, '2026-03-31 00:00:00 +0000 UTC', 'UPI', 'UPI/LAST DAY', 1),
+		(1, 400, '2026-04-01 00:00:00 +0000 UTC', 'UPI', 'UPI/NEXT YEAR', 1)`)
+
+	var got []string
+	for _, record := range exportCSV(t, h, h.ExportReceipts, "/export/receipts.csv?fy=2025-26") {
+		got = append(got, record[7])
+	}
+	if want := []string{"UPI/FIRST DAY", "UPI/LAST DAY"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("receipts of 2025-26 = %q, want %q", got, want)
+	}
+}
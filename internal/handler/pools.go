@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"unicode"
+)
+
+// pools sends statements that only read to the read pool, and every other
+// statement, INSERT ... RETURNING included, to the writer pool of a single
+// connection. Writes then wait their turn in the pool instead of failing with
+// SQLITE_BUSY, while searches and reports run alongside them.
+type pools struct {
+	writer *sql.DB
+	reader *sql.DB
+}
+
+func (p pools) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return p.writer.ExecContext(ctx, query, args...)
+}
+
+func (p pools) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return p.pool(query).PrepareContext(ctx, query)
+}
+
+func (p pools) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return p.pool(query).QueryContext(ctx, query, args...)
+}
+
+func (p pools) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return p.pool(query).QueryRowContext(ctx, query, args...)
+}
+
+// pool returns the pool to run query on
+func (p pools) pool(query string) *sql.DB {
+	if readsOnly(query) {
+		return p.reader
+	}
+	return p.writer
+}
+
+// readsOnly reports whether query is a SELECT, after the "-- name:" comment
+// sqlc puts first
+func readsOnly(query string) bool {
+	for {
+		query = strings.TrimSpace(query)
+		if !strings.HasPrefix(query, "--") {
+			break
+		}
+		end := strings.IndexByte(query, '\n')
+		if end < 0 {
+			return false
+		}
+		query = query[end+1:]
+	}
+	keyword := query
+	if end := strings.IndexFunc(query, func(r rune) bool { return !unicode.IsLetter(r) }); end >= 0 {
+		keyword = query[:end]
+	}
+	keyword = strings.ToUpper(keyword)
+	return keyword == "SELECT" || keyword == "WITH" && !strings.Contains(strings.ToUpper(query), "RETURNING")
+}
@@ -0,0 +1,35 @@
+package handler
+
+import "testing"
+
+func TestReadsOnly(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"SELECT * FROM parties", true},
+		{"select id from parties", true},
+		{"-- name: GetParty :one\nSELECT * FROM parties WHERE id = ?", true},
+		{"  \n\t-- name: ListParties :many\n-- the firm's parties\n  SELECT id FROM parties", true},
+		{"SELECT\n    id, name\nFROM parties", true},
+		{"SELECT\tid FROM parties", true},
+		{"WITH totals AS (SELECT party_id, SUM(amount) AS s FROM transactions GROUP BY 1) SELECT * FROM totals", true},
+		{"with recent as (select 1) select * from recent", true},
+		{"WITH moved AS (SELECT id FROM parties) INSERT INTO party_merges (party_id) SELECT id FROM moved RETURNING id", false},
+		{"INSERT INTO parties (name) VALUES (?) RETURNING id", false},
+		{"-- name: CreateParty :one\nINSERT INTO parties (name) VALUES (?) RETURNING *", false},
+		{"UPDATE parties SET name = ? WHERE id = ? RETURNING *", false},
+		{"update parties set name = ? returning id", false},
+		{"DELETE FROM parties WHERE id = ?", false},
+		{"PRAGMA wal_checkpoint(TRUNCATE)", false},
+		{"pragma table_info(parties)", false},
+		{"-- only a comment", false},
+		{"", false},
+		{"SELECTED", false},
+	}
+	for _, tt := range tests {
+		if got := readsOnly(tt.query); got != tt.want {
+			t.Errorf("readsOnly(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
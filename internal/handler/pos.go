@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/parser"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// importPOSSettlement stores a card machine settlement in pos_settlements
+// instead of creating a party and transaction for it
+func (h *Handler) importPOSSettlement(ctx context.Context, tx parser.Transaction) error {
+	settlementDate := tx.POSSettlementDate
+	if settlementDate.IsZero() {
+		settlementDate = tx.Date
+	}
+
+	accountID, err := h.accountID(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	_, err = h.queries.CreatePOSSettlement(ctx, sqlc.CreatePOSSettlementParams{
+		CreditDate:     tx.Date,
+		SettlementDate: settlementDate,
+		TerminalID:     sql.NullString{String: tx.POSTerminalID, Valid: tx.POSTerminalID != ""},
+		BatchNumber:    sql.NullString{String: tx.POSBatchNumber, Valid: tx.POSBatchNumber != ""},
+		Amount:         tx.Amount,
+		Narration:      sql.NullString{String: tx.Narration, Valid: tx.Narration != ""},
+		AccountID:      accountID,
+		FirmID:         firmID(ctx),
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return errDuplicate
+		}
+		return fmt.Errorf("creating POS settlement: %w", err)
+	}
+	return nil
+}
+
+// dailyPOSCollections totals POS settlements by settlement date, only those
+// credited to accountID when it is set
+func (h *Handler) dailyPOSCollections(ctx context.Context, fromDate, tillDate time.Time, accountID int64) ([]sqlc.GetDailyPOSCollectionsRow, error) {
+	if accountID == 0 {
+		return h.queries.GetDailyPOSCollections(ctx, sqlc.GetDailyPOSCollectionsParams{
+			SettlementDate:   fromDate,
+			SettlementDate_2: tillDate,
+			FirmID:           firmID(ctx),
+		})
+	}
+	rows, err := h.queries.GetDailyPOSCollectionsByAccount(ctx, sqlc.GetDailyPOSCollectionsByAccountParams{
+		SettlementDate:   fromDate,
+		SettlementDate_2: tillDate,
+		FirmID:           firmID(ctx),
+		AccountID:        sql.NullInt64{Int64: accountID, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	daily := make([]sqlc.GetDailyPOSCollectionsRow, len(rows))
+	for i, row := range rows {
+		daily[i] = sqlc.GetDailyPOSCollectionsRow(row)
+	}
+	return daily, nil
+}
+
+// posSettlements lists POS settlements, only those credited to accountID when
+// it is set
+func (h *Handler) posSettlements(ctx context.Context, fromDate, tillDate time.Time, accountID int64) ([]sqlc.PosSettlement, error) {
+	if accountID == 0 {
+		return h.queries.ListPOSSettlements(ctx, sqlc.ListPOSSettlementsParams{
+			SettlementDate:   fromDate,
+			SettlementDate_2: tillDate,
+			FirmID:           firmID(ctx),
+		})
+	}
+	return h.queries.ListPOSSettlementsByAccount(ctx, sqlc.ListPOSSettlementsByAccountParams{
+		SettlementDate:   fromDate,
+		SettlementDate_2: tillDate,
+		FirmID:           firmID(ctx),
+		AccountID:        sql.NullInt64{Int64: accountID, Valid: true},
+	})
+}
+
+// posMismatchTolerance is how far a settlement may differ from the expected
+// net card sales before it is flagged, to allow for rounding of the MDR
+const posMismatchTolerance = 1.0
+
+// POSSettlements renders daily card collections from POS settlements and
+// reconciles them against card sale bills of the same sale date, net of the
+// merchant discount rate (MDR)
+func (h *Handler) POSSettlements(w http.ResponseWriter, r *http.Request) {
+	// Default to the last 30 days
+	fromDate, tillDate, year := reportPeriod(r, time.Now().AddDate(0, 0, -30))
+	mdr := 0.0
+	if parsed, err := strconv.ParseFloat(r.FormValue("mdr"), 64); err == nil && parsed >= 0 && parsed < 100 {
+		mdr = parsed
+	}
+
+	ctx := r.Context()
+
+	accountID, accounts, err := h.accountFilter(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Loading accounts: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	daily, err := h.dailyPOSCollections(ctx, fromDate, tillDate, accountID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Loading POS collections: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	settlements, err := h.posSettlements(ctx, fromDate, tillDate, accountID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Loading POS settlements: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	cardSales, err := h.queries.GetDailyCardSales(ctx, sqlc.GetDailyCardSalesParams{
+		BillDate:   fromDate,
+		BillDate_2: tillDate,
+		FirmID:     firmID(ctx),
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Loading card sales: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	type posDay struct {
+		date        time.Time
+		settlements int64
+		settled     float64
+		bills       int64
+		sales       float64
+	}
+	byDate := make(map[string]*posDay)
+	day := func(d time.Time) *posDay {
+		key := d.Format("2006-01-02")
+		if _, ok := byDate[key]; !ok {
+			byDate[key] = &posDay{date: d}
+		}
+		return byDate[key]
+	}
+	for _, d := range daily {
+		pd := day(d.SettlementDate)
+		pd.settlements = d.SettlementCount
+		pd.settled = d.TotalAmount.Float64
+	}
+	for _, s := range cardSales {
+		pd := day(s.BillDate)
+		pd.bills = s.BillCount
+		pd.sales = s.TotalAmount.Float64
+	}
+
+	keys := make([]string, 0, len(byDate))
+	for k := range byDate {
+		keys = append(keys, k)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+
+	var total float64
+	days := make([]pages.POSDailyCollection, len(keys))
+	for i, k := range keys {
+		d := byDate[k]
+		expected := d.sales * (1 - mdr/100)
+		difference := d.settled - expected
+		days[i] = pages.POSDailyCollection{
+			Date:        d.date.Format("02 Jan 2006"),
+			Settlements: d.settlements,
+			Amount:      fmt.Sprintf("%.2f", d.settled),
+			CardBills:   d.bills,
+			CardSales:   fmt.Sprintf("%.2f", d.sales),
+			Expected:    fmt.Sprintf("%.2f", expected),
+			Difference:  fmt.Sprintf("%.2f", difference),
+			Mismatch:    math.Abs(difference) > posMismatchTolerance,
+		}
+		total += d.settled
+	}
+
+	rows := make([]pages.POSSettlementRow, len(settlements))
+	for i, s := range settlements {
+		rows[i] = pages.POSSettlementRow{
+			SettlementDate: s.SettlementDate.Format("02 Jan 2006"),
+			CreditDate:     s.CreditDate.Format("02 Jan 2006"),
+			TerminalID:     s.TerminalID.String,
+			BatchNumber:    s.BatchNumber.String,
+			Amount:         fmt.Sprintf("%.2f", s.Amount),
+		}
+	}
+
+	pages.POSSettlements(fromDate.Format("2006-01-02"), tillDate.Format("2006-01-02"), year, accounts, accountID, strconv.FormatFloat(mdr, 'f', -1, 64), days, rows, fmt.Sprintf("%.2f", total)).Render(ctx, w)
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"suspense.durgadawaghar.com/internal/quality"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// qualityMonths is how many months of coverage the data quality page shows
+const qualityMonths = 12
+
+// qualityPartyLimit is how many parties without identifiers are listed, most
+// receipts first
+const qualityPartyLimit = 25
+
+// suspenseAges are the ages, in days since a receipt book period ended, its
+// SUSPENSE A/C entries are grouped by
+var suspenseAges = []struct {
+	label string
+	days  int
+}{
+	{"Up to 30 days", 30},
+	{"31–90 days", 90},
+	{"91–180 days", 180},
+	{"Over 180 days", -1},
+}
+
+// DataQuality shows whether the system is learning: how many receipts give
+// a strong identifier and how many were matched by what earlier receipts
+// taught, month by month, with the backlog left to people: SUSPENSE A/C
+// entries by age, sale bills linked to no party and parties no identifier
+// matches
+func (h *Handler) DataQuality(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	view, err := h.dataQuality(ctx)
+	if err != nil {
+		http.Error(w, "Error measuring data quality: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	pages.DataQuality(view).Render(ctx, w)
+}
+
+// dataQuality measures the current firm's data
+func (h *Handler) dataQuality(ctx context.Context) (pages.DataQualityView, error) {
+	var view pages.DataQualityView
+
+	linked, err := h.queries.ListIdentifiersForExport(ctx, firmID(ctx))
+	if err != nil {
+		return view, err
+	}
+	links := make(map[quality.Key]quality.Link, len(linked))
+	for _, l := range linked {
+		links[quality.Key{Type: l.Type, Value: l.Value}] = quality.Link{PartyID: l.PartyID, FirstSeen: l.FirstSeen.Time}
+	}
+	narrations, err := h.queries.ListReceiptNarrations(ctx, firmID(ctx))
+	if err != nil {
+		return view, err
+	}
+	receipts := make([]quality.Receipt, len(narrations))
+	for i, n := range narrations {
+		receipts[i] = quality.Receipt{PartyID: n.PartyID, Date: n.TransactionDate, Narration: n.Narration.String}
+	}
+	view.Total, view.Months = quality.Measure(receipts, links)
+	if len(view.Months) > qualityMonths {
+		view.Months = view.Months[:qualityMonths]
+	}
+
+	books, err := h.queries.ListReceiptBookChecks(ctx, firmID(ctx))
+	if err != nil {
+		return view, err
+	}
+	for _, a := range suspenseAges {
+		view.SuspenseAges = append(view.SuspenseAges, pages.SuspenseAge{Label: a.label})
+	}
+	today := time.Now()
+	for _, b := range books {
+		if b.Suspense == 0 {
+			continue
+		}
+		view.Suspense += b.Suspense
+		view.SuspenseBooks++
+		if view.SuspenseSince.IsZero() || b.StartDate.Before(view.SuspenseSince) {
+			view.SuspenseSince = b.StartDate
+		}
+		days := int(today.Sub(b.EndDate).Hours() / 24)
+		for i, a := range suspenseAges {
+			if a.days < 0 || days <= a.days {
+				view.SuspenseAges[i].Books++
+				view.SuspenseAges[i].Amount += b.Suspense
+				break
+			}
+		}
+	}
+
+	bills, err := h.queries.ListUnlinkedSaleBills(ctx, firmID(ctx))
+	if err != nil {
+		return view, err
+	}
+	for _, b := range bills {
+		view.UnlinkedBills++
+		view.UnlinkedAmount += b.Amount
+		if view.UnlinkedSince.IsZero() || b.BillDate.Before(view.UnlinkedSince) {
+			view.UnlinkedSince = b.BillDate
+		}
+	}
+
+	if view.Parties, err = h.queries.CountParties(ctx, firmID(ctx)); err != nil {
+		return view, err
+	}
+	parties, err := h.queries.ListPartiesWithoutIdentifiers(ctx, firmID(ctx))
+	if err != nil {
+		return view, err
+	}
+	view.WithoutIdentifiers = len(parties)
+	for _, p := range parties {
+		if p.ReceiptCount > 0 {
+			view.PayingWithoutIdentifiers++
+		}
+	}
+	if len(parties) > qualityPartyLimit {
+		parties = parties[:qualityPartyLimit]
+	}
+	view.PartiesWithoutIdentifiers = parties
+	return view, nil
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"suspense.durgadawaghar.com/internal/views"
+)
+
+// quickMatch is the best party for a narration as helper scripts read it
+type quickMatch struct {
+	PartyID     int64   `json:"party_id"`
+	Party       string  `json:"party"`
+	Location    string  `json:"location"`
+	Confidence  float64 `json:"confidence"`
+	Outstanding float64 `json:"outstanding"` // negative when the party has paid in advance
+}
+
+// QuickMatch answers GET /match?narration= with just the best matching party,
+// the confidence and what the party owes, for scripts at the data-entry desk
+// to read without scraping a page. It is plain text, one tab-separated line,
+// unless format=json or the request accepts JSON. amount ranks parties as the
+// search page does, and firm_id matches in another firm than the first.
+func (h *Handler) QuickMatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+	asJSON := r.FormValue("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json")
+
+	if id, err := strconv.ParseInt(r.FormValue("firm_id"), 10, 64); err == nil {
+		for _, f := range views.Firms(ctx) {
+			if f.ID == id {
+				ctx = views.WithFirm(ctx, f, views.Firms(ctx))
+			}
+		}
+	}
+
+	narration := strings.TrimSpace(r.FormValue("narration"))
+	if narration == "" {
+		writeQuickMatchError(w, asJSON, http.StatusBadRequest, "missing narration")
+		return
+	}
+	result, err := h.matcher.MatchSingle(ctx, firmID(ctx), narration, searchAmount(r))
+	if err != nil {
+		writeQuickMatchError(w, asJSON, http.StatusInternalServerError, "match error: "+err.Error())
+		return
+	}
+	if result == nil {
+		writeQuickMatchError(w, asJSON, http.StatusNotFound, "no party matches the narration")
+		return
+	}
+
+	// Parties sharing the name are one match, owing together
+	outstanding := 0.0
+	for _, id := range result.PartyIDs {
+		balance, err := h.queries.GetPartyBalance(ctx, id)
+		if err != nil {
+			writeQuickMatchError(w, asJSON, http.StatusInternalServerError, "error loading outstanding")
+			return
+		}
+		outstanding += balance.Billed - balance.Received
+	}
+	writeQuickMatch(w, asJSON, quickMatch{
+		PartyID:     result.Party.ID,
+		Party:       result.Party.Name,
+		Location:    result.Party.Location.String,
+		Confidence:  math.Round(result.Confidence*10) / 10,
+		Outstanding: math.Round(outstanding*100) / 100,
+	})
+}
+
+// writeQuickMatch writes a quick match as JSON, or as a line of party name,
+// confidence and outstanding separated by tabs
+func writeQuickMatch(w http.ResponseWriter, asJSON bool, m quickMatch) {
+	if asJSON {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(m)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	name := m.Party
+	if m.Location != "" {
+		name += " (" + m.Location + ")"
+	}
+	fmt.Fprintf(w, "%s\t%.1f\t%.2f\n", name, m.Confidence, m.Outstanding)
+}
+
+// writeQuickMatchError writes why there is no quick match, as {"error": ...}
+// or a line of text
+func writeQuickMatchError(w http.ResponseWriter, asJSON bool, status int, message string) {
+	if asJSON {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": message})
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(status)
+	fmt.Fprintln(w, message)
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestQuickMatch(t *testing.T) {
+	h, db := newTestHandler(t)
+	exec(t, db, `INSERT INTO parties (id, name, location, firm_id) VALUES
+		(1, 'SANDHYA MEDICAL STORE', 'TIRWA', 1), (2, 'VERMA AGENCIES', '', 2)`)
+	exec(t, db, `INSERT INTO identifiers (party_id, type, value, firm_id) VALUES
+		(1, 'upi_vpa', 'SANDHYA@YBL', 1), (2, 'upi_vpa', 'VERMA@YBL', 2)`)
+	exec(t, db, `INSERT INTO sale_bills (bill_number, bill_date, party_name, amount, is_cash_sale, party_id, firm_id)
+		VALUES ('A-1', '2025-04-01 00:00:00 +0000 UTC', 'SANDHYA MEDICAL STORE', 1200, FALSE, 1, 1)`)
+	exec(t, db, `INSERT INTO transactions (party_id, amount, transaction_date, payment_mode, narration, firm_id)
+		VALUES (1, 450.50, '2025-04-05 00:00:00 +0000 UTC', 'UPI', 'UPI/SANDHYA@YBL/PAYMENT', 1)`)
+	match := func(query url.Values, accept string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/match?"+query.Encode(), nil)
+		if accept != "" {
+			r.Header.Set("Accept", accept)
+		}
+		return serve(h, http.HandlerFunc(h.QuickMatch), r)
+	}
+
+	// Plain text is one line of party, confidence and outstanding
+	w := match(url.Values{"narration": {"UPI/SANDHYA@YBL/PAYMENT"}}, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	fields := strings.Split(strings.TrimSuffix(w.Body.String(), "\n"), "\t")
+	if len(fields) != 3 || fields[0] != "SANDHYA MEDICAL STORE (TIRWA)" || fields[2] != "749.50" {
+		t.Errorf("plain text match = %q", w.Body)
+	}
+
+	// JSON when asked for, by parameter or header
+	for _, w := range []*httptest.ResponseRecorder{
+		match(url.Values{"narration": {"UPI/SANDHYA@YBL/PAYMENT"}, "format": {"json"}}, ""),
+		match(url.Values{"narration": {"UPI/SANDHYA@YBL/PAYMENT"}}, "application/json"),
+	} {
+		var m quickMatch
+		if err := json.Unmarshal(w.Body.Bytes(), &m); err != nil {
+			t.Fatalf("%v: %s", err, w.Body)
+		}
+		if m.PartyID != 1 || m.Party != "SANDHYA MEDICAL STORE" || m.Location != "TIRWA" || m.Confidence <= 0 || m.Outstanding != 749.50 {
+			t.Errorf("JSON match = %+v", m)
+		}
+	}
+
+	// Another firm's party matches only in that firm
+	if w := match(url.Values{"narration": {"UPI/VERMA@YBL/PAYMENT"}}, ""); w.Code != http.StatusNotFound {
+		t.Errorf("another firm's party: status = %d: %s", w.Code, w.Body)
+	}
+	if w := match(url.Values{"narration": {"UPI/VERMA@YBL/PAYMENT"}, "firm_id": {"2"}}, ""); !strings.HasPrefix(w.Body.String(), "VERMA AGENCIES\t") {
+		t.Errorf("match in firm 2 = %q", w.Body)
+	}
+
+	w = match(url.Values{"narration": {" "}, "format": {"json"}}, "")
+	if w.Code != http.StatusBadRequest || strings.TrimSpace(w.Body.String()) != `{"error":"missing narration"}` {
+		t.Errorf("missing narration: status = %d: %s", w.Code, w.Body)
+	}
+	w = match(url.Values{"narration": {"UPI/NOBODY@YBL/PAYMENT"}}, "")
+	if w.Code != http.StatusNotFound || w.Body.String() != "no party matches the narration\n" {
+		t.Errorf("no match: status = %d: %s", w.Code, w.Body)
+	}
+}
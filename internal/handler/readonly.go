@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"net/http"
+
+	"suspense.durgadawaghar.com/internal/views"
+)
+
+// readSafeHandler serves a POST route that only reads, such as a search,
+// which a read-only server keeps
+type readSafeHandler struct {
+	http.HandlerFunc
+}
+
+// ReadSafe marks the handler of a POST route that changes no data, such as a
+// search, a pattern test, a GraphQL query or the firm switcher, which only
+// sets a cookie, so a read-only server keeps it. Wrap the route where it is
+// registered.
+func ReadSafe(h http.HandlerFunc) http.Handler {
+	return readSafeHandler{h}
+}
+
+// ReadOnly turns off every route that changes data, such as imports, edits
+// and assignments, keeping searches and reports, for a server opened to the
+// sales team or run against a backup copy. Of the routes of mux, GETs and
+// the POSTs registered with ReadSafe are kept.
+func ReadOnly(next http.Handler, mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead && !(r.Method == http.MethodPost && isReadSafe(mux, r)) {
+			http.Error(w, "This server is read-only: imports and edits are turned off", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(views.WithReadOnly(r.Context())))
+	})
+}
+
+// isReadSafe reports whether the route of mux serving r was registered with
+// ReadSafe
+func isReadSafe(mux *http.ServeMux, r *http.Request) bool {
+	route, _ := mux.Handler(r)
+	_, ok := route.(readSafeHandler)
+	return ok
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReadOnly(t *testing.T) {
+	mux := http.NewServeMux()
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	mux.HandleFunc("/parties", ok)
+	mux.Handle("/search", ReadSafe(ok))
+	mux.HandleFunc("/import/confirm", ok)
+	mux.Handle("/party/merge", Long(ok))
+	app := ReadOnly(mux, mux)
+
+	tests := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/parties", http.StatusOK},
+		{http.MethodHead, "/parties", http.StatusOK},
+		{http.MethodPost, "/search", http.StatusOK},
+		{http.MethodGet, "/search", http.StatusOK},
+		{http.MethodPost, "/import/confirm", http.StatusForbidden},
+		{http.MethodPost, "/party/merge", http.StatusForbidden},
+		{http.MethodPost, "/parties", http.StatusForbidden},
+		{http.MethodDelete, "/search", http.StatusForbidden},
+		{http.MethodPut, "/search", http.StatusForbidden},
+		{http.MethodPost, "/search/", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.want {
+			t.Errorf("%s %s = %d, want %d", tt.method, tt.path, w.Code, tt.want)
+		}
+	}
+}
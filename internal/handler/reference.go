@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/extractor"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// minReferenceLength is the fewest characters of a UTR or RRN searched for;
+// shorter ones match too many narrations to tell a payment
+const minReferenceLength = 6
+
+// SearchReference finds the transactions whose narration carries a UTR, IMPS
+// or UPI reference, or part of one, as a customer disputing a payment quotes
+// it, with their party and the receipt book import that brought them in.
+// Transactions whose narration gives the reference as its bank reference come
+// first; parties with an identifier of the same value are listed after.
+func (h *Handler) SearchReference(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	ref := extractor.NormalizeReference(r.URL.Query().Get("ref"))
+	if ref == "" {
+		pages.ReferenceSearch("", nil, nil, "").Render(ctx, w)
+		return
+	}
+	if len(ref) < minReferenceLength {
+		pages.ReferenceSearch(ref, nil, nil, fmt.Sprintf("Enter at least %d characters of the reference.", minReferenceLength)).Render(ctx, w)
+		return
+	}
+
+	rows, err := h.queries.SearchTransactionsByReference(ctx, sqlc.SearchTransactionsByReferenceParams{
+		FirmID:    firmID(ctx),
+		Narration: sql.NullString{String: "%" + ref + "%", Valid: true},
+	})
+	if err != nil {
+		http.Error(w, "Error searching transactions", http.StatusInternalServerError)
+		return
+	}
+	matches := make([]pages.ReferenceMatch, len(rows))
+	for i, t := range rows {
+		matches[i] = pages.ReferenceMatch{
+			TransactionID: t.ID,
+			Date:          t.TransactionDate.Format("02 Jan 2006"),
+			Amount:        t.Amount,
+			PaymentMode:   t.PaymentMode.String,
+			Narration:     t.Narration.String,
+			PartyID:       t.PartyID,
+			PartyName:     t.PartyName,
+			Location:      t.PartyLocation.String,
+			Exact:         slices.Contains(extractor.References(t.Narration.String), ref),
+			BatchID:       t.ImportBatchID.Int64,
+		}
+		if t.BatchImportedAt.Valid {
+			matches[i].BatchImportedAt = t.BatchImportedAt.Time.Format("02 Jan 2006 15:04")
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b pages.ReferenceMatch) int {
+		switch {
+		case a.Exact && !b.Exact:
+			return -1
+		case b.Exact && !a.Exact:
+			return 1
+		}
+		return 0
+	})
+
+	found, err := h.queries.FindPartiesByIdentifierValue(ctx, sqlc.FindPartiesByIdentifierValueParams{
+		Value:  ref,
+		FirmID: firmID(ctx),
+	})
+	if err != nil {
+		http.Error(w, "Error searching identifiers", http.StatusInternalServerError)
+		return
+	}
+	parties := make([]pages.ReferenceParty, len(found))
+	for i, p := range found {
+		parties[i] = pages.ReferenceParty{
+			ID:       p.ID,
+			Name:     p.Name,
+			Location: p.Location.String,
+			Type:     p.MatchType,
+		}
+	}
+
+	pages.ReferenceSearch(ref, matches, parties, "").Render(ctx, w)
+}
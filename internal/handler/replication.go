@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// Replication shows where the database is replicated to and how far behind
+// the replica is
+func (h *Handler) Replication(w http.ResponseWriter, r *http.Request) {
+	view := pages.ReplicationView{}
+	if h.replica != nil {
+		s := h.replica.Status()
+		view.On = true
+		view.Dir = s.Dir
+		view.Generation = s.Generation
+		view.Segments = s.Segments
+		view.Error = s.LastError
+		if !s.LastSync.IsZero() {
+			view.LastSync = s.LastSync.Format("02 Jan 2006 15:04:05")
+			view.Behind = time.Since(s.LastSync).Round(time.Second).String()
+		}
+	}
+	pages.Replication(view).Render(r.Context(), w)
+}
@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/fy"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// retentionYears is the default number of financial years kept, the eight
+// years books of account must be preserved for
+const retentionYears = 8
+
+// purgeConfirm must be typed to run a purge
+const purgeConfirm = "PURGE"
+
+// errDryRun rolls back a purge run only to report what it would remove
+var errDryRun = errors.New("dry run")
+
+// Retention shows the purge form and, for a number of years to keep, a dry
+// run of what purging would remove
+func (h *Handler) Retention(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	form := pages.RetentionForm{Years: strconv.Itoa(retentionYears)}
+	if r.URL.Query().Get("years") == "" {
+		pages.Retention(form, nil, "").Render(ctx, w)
+		return
+	}
+
+	form.Years = r.URL.Query().Get("years")
+	keep, err := retentionCutoff(form.Years)
+	if err != nil {
+		pages.Retention(form, nil, err.Error()).Render(ctx, w)
+		return
+	}
+	report, err := h.purge(ctx, keep, true)
+	if err != nil {
+		pages.Retention(form, nil, err.Error()).Render(ctx, w)
+		return
+	}
+	pages.Retention(form, &report, "").Render(ctx, w)
+}
+
+// PurgeData removes the firm's receipts, sale bills and card settlements
+// dated before the financial years kept, once a backup of the whole
+// database has been written beside it. Each party's balance over the removed
+// entries is brought forward as an opening balance, so outstanding amounts
+// do not change.
+func (h *Handler) PurgeData(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+	form := pages.RetentionForm{Years: r.FormValue("years")}
+
+	keep, err := retentionCutoff(form.Years)
+	if err != nil {
+		pages.Retention(form, nil, err.Error()).Render(ctx, w)
+		return
+	}
+	if r.FormValue("confirm") != purgeConfirm {
+		pages.Retention(form, nil, fmt.Sprintf("Type %s to confirm the purge", purgeConfirm)).Render(ctx, w)
+		return
+	}
+
+	report, err := h.purge(ctx, keep, false)
+	if err != nil {
+		pages.Retention(form, nil, fmt.Sprintf("Error purging: %s", err.Error())).Render(ctx, w)
+		return
+	}
+	pages.Retention(form, &report, "").Render(ctx, w)
+}
+
+// retentionCutoff returns the first financial year kept when the current one
+// and the years before it, years in all, are kept
+func retentionCutoff(years string) (fy.Year, error) {
+	n, err := strconv.Atoi(years)
+	if err != nil || n < 1 {
+		return fy.Year{}, fmt.Errorf("years to keep must be a whole number of at least 1")
+	}
+	return fy.Year{StartYear: fy.Of(time.Now()).StartYear - n + 1}, nil
+}
+
+// purge removes the firm's entries dated before keep and brings each party's
+// balance over them forward. A dry run does the same in a transaction it
+// rolls back, so its report is exactly what a purge would remove; otherwise
+// the database is backed up first and compacted after.
+func (h *Handler) purge(ctx context.Context, keep fy.Year, dryRun bool) (pages.PurgeReport, error) {
+	firm := firmID(ctx)
+	report := pages.PurgeReport{Before: keep.Start(), DryRun: dryRun}
+
+	// Entries of closed years cannot be deleted until they are archived
+	years, err := h.queries.ListFinancialYears(ctx, firm)
+	if err != nil {
+		return report, err
+	}
+	for _, y := range years {
+		if y.StartDate.Before(keep.Start()) && !y.ArchivedAt.Valid {
+			return report, fmt.Errorf("%s is closed: archive or reopen it before purging", y.Label)
+		}
+	}
+
+	if !dryRun {
+		mainFile, err := h.databaseFile(ctx)
+		if err != nil {
+			return report, err
+		}
+		dir := filepath.Join(filepath.Dir(mainFile), "backups")
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return report, fmt.Errorf("creating backup directory: %w", err)
+		}
+		report.BackupPath = filepath.Join(dir, "suspense-before-purge-"+time.Now().Format("2006-01-02-1504")+".db")
+		if _, err := h.backupTo(ctx, report.BackupPath); err != nil {
+			return report, fmt.Errorf("backing up: %w", err)
+		}
+	}
+
+	err = h.purgeEntries(ctx, firm, keep, dryRun, &report)
+	if errors.Is(err, errDryRun) {
+		return report, nil
+	}
+	if err != nil {
+		return report, err
+	}
+
+	// Deleted rows only leave free pages behind until the file is rebuilt
+	if _, err := h.db.ExecContext(ctx, "VACUUM"); err != nil {
+		return report, fmt.Errorf("compacting database: %w", err)
+	}
+	return report, nil
+}
+
+// purgeEntries deletes the entries in one transaction, counting them into
+// report, and returns errDryRun instead of committing a dry run
+func (h *Handler) purgeEntries(ctx context.Context, firm int64, keep fy.Year, dryRun bool, report *pages.PurgeReport) error {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	q := h.queries.WithTx(tx)
+
+	movements, err := q.ListPartyMovements(ctx, sqlc.ListPartyMovementsParams{
+		BillDate:          time.Time{},
+		BillDate_2:        keep.Start().AddDate(0, 0, -1),
+		TransactionDate:   time.Time{},
+		TransactionDate_2: keep.Start().AddDate(0, 0, -1),
+		FirmID:            firm,
+	})
+	if err != nil {
+		return fmt.Errorf("computing balances: %w", err)
+	}
+
+	if report.Tags, err = q.PurgeTransactionTags(ctx, sqlc.PurgeTransactionTagsParams{FirmID: firm, TransactionDate: keep.Start()}); err != nil {
+		return fmt.Errorf("removing tags: %w", err)
+	}
+	if err := q.PurgeTransactionSplits(ctx, sqlc.PurgeTransactionSplitsParams{
+		FirmID:            firm,
+		TransactionDate:   keep.Start(),
+		FirmID_2:          firm,
+		TransactionDate_2: keep.Start(),
+	}); err != nil {
+		return fmt.Errorf("removing splits: %w", err)
+	}
+	if err := q.PurgeTransactionMerges(ctx, sqlc.PurgeTransactionMergesParams{FirmID: firm, TransactionDate: keep.Start()}); err != nil {
+		return fmt.Errorf("removing merges: %w", err)
+	}
+	if err := q.PurgeTransactionAgents(ctx, sqlc.PurgeTransactionAgentsParams{FirmID: firm, TransactionDate: keep.Start()}); err != nil {
+		return fmt.Errorf("removing agent assignments: %w", err)
+	}
+	if err := q.PurgeBillAllocations(ctx, sqlc.PurgeBillAllocationsParams{
+		FirmID:          firm,
+		TransactionDate: keep.Start(),
+		FirmID_2:        firm,
+		BillDate:        keep.Start(),
+	}); err != nil {
+		return fmt.Errorf("removing bill allocations: %w", err)
+	}
+	if report.Cheques, err = q.PurgeCheques(ctx, sqlc.PurgeChequesParams{FirmID: firm, TransactionDate: keep.Start()}); err != nil {
+		return fmt.Errorf("removing cheques: %w", err)
+	}
+	if report.Transactions, err = q.PurgeTransactions(ctx, sqlc.PurgeTransactionsParams{FirmID: firm, TransactionDate: keep.Start()}); err != nil {
+		return fmt.Errorf("removing transactions: %w", err)
+	}
+	if report.SaleBills, err = q.PurgeSaleBills(ctx, sqlc.PurgeSaleBillsParams{FirmID: firm, BillDate: keep.Start()}); err != nil {
+		return fmt.Errorf("removing sale bills: %w", err)
+	}
+	if report.POSSettlements, err = q.PurgePOSSettlements(ctx, sqlc.PurgePOSSettlementsParams{FirmID: firm, CreditDate: keep.Start()}); err != nil {
+		return fmt.Errorf("removing card settlements: %w", err)
+	}
+	if err := q.PurgeReceiptBookTotals(ctx, sqlc.PurgeReceiptBookTotalsParams{FirmID: firm, EndDate: keep.Start()}); err != nil {
+		return fmt.Errorf("removing receipt book totals: %w", err)
+	}
+
+	for _, m := range movements {
+		balance := math.Round((m.Billed-m.Received)*100) / 100
+		if balance == 0 {
+			continue
+		}
+		if err := addOpeningBalance(ctx, q, firm, m.ID, m.Name, keep, balance); err != nil {
+			return fmt.Errorf("carrying over balance of %s: %w", m.Name, err)
+		}
+		report.OpeningBalances++
+	}
+
+	if dryRun {
+		return errDryRun
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"suspense.durgadawaghar.com/internal/fy"
+)
+
+func TestPurgeData(t *testing.T) {
+	h, db := newTestHandler(t)
+	// Keeping two years keeps last year and this one
+	current := fy.Of(time.Now())
+	old := fy.Year{StartYear: current.StartYear - 3}
+	kept := fy.Year{StartYear: current.StartYear - 1}
+	oldDate, keptDate := old.Start().AddDate(0, 1, 0), kept.Start().AddDate(0, 1, 0)
+
+	exec(t, db, `INSERT INTO parties (id, name, firm_id) VALUES (1, 'SHARMA MEDICAL', 1), (2, 'VERMA AGENCIES', 2)`)
+	exec(t, db, `INSERT INTO transactions (id, party_id, amount, transaction_date, payment_mode, narration, firm_id) VALUES
+		(1, 1, 600, ?1, 'CHEQUE', 'CHQ 1', 1), (2, 1, 200, ?2, 'UPI', 'UPI/2', 1), (3, 2, 300, ?1, 'UPI', 'UPI/3', 2)`, oldDate, keptDate)
+	exec(t, db, `INSERT INTO cheques (transaction_id, cheque_number, received_date) VALUES (1, '000123', ?)`, oldDate)
+	exec(t, db, `INSERT INTO transaction_tags (transaction_id, tag) VALUES (1, 'advance'), (2, 'advance')`)
+	exec(t, db, `INSERT INTO sale_bills (id, bill_number, bill_date, party_name, amount, is_cash_sale, party_id, firm_id) VALUES
+		(1, 'A-1', ?1, 'SHARMA MEDICAL', 1000, FALSE, 1, 1), (2, 'A-2', ?2, 'SHARMA MEDICAL', 500, FALSE, 1, 1),
+		(3, 'B-1', ?1, 'VERMA AGENCIES', 700, FALSE, 2, 2)`, oldDate, keptDate)
+	exec(t, db, `INSERT INTO pos_settlements (credit_date, settlement_date, amount, firm_id) VALUES (?1, ?1, 100, 1), (?1, ?1, 100, 2)`, oldDate)
+	exec(t, db, `INSERT INTO financial_years (id, firm_id, label, start_date, end_date) VALUES (1, 1, ?, ?, ?)`, old.Label(), old.Start(), old.End())
+	purge := func() string {
+		w := serve(h, http.HandlerFunc(h.PurgeData), postForm("/settings/retention/purge", url.Values{"years": {"2"}, "confirm": {purgeConfirm}}))
+		return w.Body.String()
+	}
+	rows := func() float64 {
+		return count(t, db, "SELECT (SELECT COUNT(*) FROM transactions) + (SELECT COUNT(*) FROM sale_bills) + (SELECT COUNT(*) FROM pos_settlements)")
+	}
+
+	// A closed year must be archived before its entries go
+	if body := purge(); !strings.Contains(body, old.Label()+" is closed") {
+		t.Errorf("purge of a closed year not refused:\n%s", body)
+	}
+	if n := rows(); n != 8 {
+		t.Fatalf("refused purge left %v entries, want 8", n)
+	}
+
+	exec(t, db, "UPDATE financial_years SET archived_at = CURRENT_TIMESTAMP WHERE id = 1")
+	if body := purge(); !strings.Contains(body, "Purged") {
+		t.Fatalf("purge failed:\n%s", body)
+	}
+	for query, want := range map[string]float64{
+		// the firm's old entries go; its kept entries and the other firm's stay
+		"SELECT COUNT(*) FROM transactions WHERE id = 1":         0,
+		"SELECT COUNT(*) FROM transactions WHERE id IN (2, 3)":   2,
+		"SELECT COUNT(*) FROM sale_bills WHERE id = 1":           0,
+		"SELECT COUNT(*) FROM sale_bills WHERE id IN (2, 3)":     2,
+		"SELECT COUNT(*) FROM pos_settlements WHERE firm_id = 1": 0,
+		"SELECT COUNT(*) FROM pos_settlements WHERE firm_id = 2": 1,
+		"SELECT COUNT(*) FROM cheques":                           0,
+		"SELECT COUNT(*) FROM transaction_tags":                  1,
+		// what was owed on the removed entries is brought forward
+		"SELECT amount FROM sale_bills WHERE bill_number LIKE 'OPENING %' AND party_id = 1": 400,
+		"SELECT billed - received FROM party_balances WHERE party_id = 1":                   700,
+	} {
+		if n := count(t, db, query); n != want {
+			t.Errorf("%s = %v, want %v", query, n, want)
+		}
+	}
+
+	file, err := h.databaseFile(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	backups, err := os.ReadDir(filepath.Join(filepath.Dir(file), "backups"))
+	if err != nil || len(backups) != 1 {
+		t.Errorf("want one backup before the purge, got %v: %v", len(backups), err)
+	}
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// routeDues groups the current firm's parties that owe money by the route of
+// their location, parties on no route under route 0, each route's parties in
+// location and name order as they are visited. Each party's latest receipt
+// date is filled in.
+func (h *Handler) routeDues(ctx context.Context) ([]pages.RouteOutstanding, map[int64][]pages.RouteDue, error) {
+	routes, err := h.queries.ListRoutes(ctx, firmID(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
+	locations, err := h.queries.ListRouteLocations(ctx, firmID(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
+	balances, err := h.queries.ListPartyBalances(ctx, firmID(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
+	receipts, err := h.queries.ListReceiptsInPeriod(ctx, sqlc.ListReceiptsInPeriodParams{
+		FirmID:            firmID(ctx),
+		TransactionDate_2: time.Now(),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	lastReceipt := make(map[int64]time.Time)
+	for _, rc := range receipts {
+		lastReceipt[rc.PartyID] = rc.TransactionDate // receipts come in date order
+	}
+
+	summaries := make([]pages.RouteOutstanding, 0, len(routes)+1)
+	index := make(map[int64]int, len(routes)+1)
+	for _, rt := range routes {
+		index[rt.ID] = len(summaries)
+		summaries = append(summaries, pages.RouteOutstanding{ID: rt.ID, Name: rt.Name})
+	}
+	index[0] = len(summaries)
+	summaries = append(summaries, pages.RouteOutstanding{Name: "No route"})
+	onRoute := make(map[string]int64, len(locations))
+	for _, l := range locations {
+		onRoute[l.Location] = l.RouteID
+	}
+
+	dues := make(map[int64][]pages.RouteDue)
+	for _, p := range balances {
+		balance := partyBalance(p.ID, p.Name, p.Location.String, p.CreditLimit, p.Billed, p.Received, p.ReceiptCount)
+		if balance.Outstanding < 0.005 {
+			continue
+		}
+		routeID := onRoute[routeLocation(p.Location.String)]
+		if _, ok := index[routeID]; !ok {
+			routeID = 0
+		}
+		due := pages.RouteDue{PartyBalance: balance}
+		if t, ok := lastReceipt[p.ID]; ok {
+			due.LastReceipt = t.Format("02 Jan 2006")
+		}
+		dues[routeID] = append(dues[routeID], due)
+
+		s := &summaries[index[routeID]]
+		s.Parties++
+		s.Outstanding += balance.Outstanding
+		if balance.Breached {
+			s.Breached++
+		}
+	}
+	for _, list := range dues {
+		sort.SliceStable(list, func(i, j int) bool {
+			if a, b := routeLocation(list[i].Location), routeLocation(list[j].Location); a != b {
+				return a < b
+			}
+			return list[i].Name < list[j].Name
+		})
+	}
+	return summaries, dues, nil
+}
+
+// selectedRoute finds the route of the route parameter among summaries; an
+// unknown or missing route is not found
+func selectedRoute(r *http.Request, summaries []pages.RouteOutstanding) (pages.RouteOutstanding, bool) {
+	id, err := strconv.ParseInt(r.FormValue("route"), 10, 64)
+	if err != nil {
+		return pages.RouteOutstanding{}, false
+	}
+	for _, s := range summaries {
+		if s.ID == id {
+			return s, true
+		}
+	}
+	return pages.RouteOutstanding{}, false
+}
+
+// RouteOutstanding reports outstanding balances by route, and the parties
+// owing on the selected route
+func (h *Handler) RouteOutstanding(w http.ResponseWriter, r *http.Request) {
+	summaries, dues, err := h.routeDues(r.Context())
+	if err != nil {
+		http.Error(w, "Error loading outstanding", http.StatusInternalServerError)
+		return
+	}
+	view := pages.RouteOutstandingView{Routes: summaries}
+	if selected, ok := selectedRoute(r, summaries); ok {
+		view.Selected = &selected
+		view.Parties = dues[selected.ID]
+	}
+	pages.RouteOutstandingReport(view).Render(r.Context(), w)
+}
+
+// PrintRouteSheet renders a route's outstanding parties as the sheet the
+// collection agent carries on the round, with space to note what each paid
+func (h *Handler) PrintRouteSheet(w http.ResponseWriter, r *http.Request) {
+	summaries, dues, err := h.routeDues(r.Context())
+	if err != nil {
+		http.Error(w, "Error loading outstanding", http.StatusInternalServerError)
+		return
+	}
+	selected, ok := selectedRoute(r, summaries)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	pages.RouteSheet(selected, dues[selected.ID], time.Now().Format("02 Jan 2006")).Render(r.Context(), w)
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"suspense.durgadawaghar.com/internal/views"
+)
+
+func TestRouteDues(t *testing.T) {
+	h, db := newTestHandler(t)
+	ctx := views.WithFirm(context.Background(), views.Firm{ID: 1, Name: "Durga Dawa Ghar"}, nil)
+	exec(t, db, `INSERT INTO routes (id, name, firm_id) VALUES (1, 'UNNAO ROUTE', 1)`)
+	exec(t, db, `INSERT INTO route_locations (route_id, location, firm_id) VALUES (1, 'UNNAO', 1), (1, 'ASOHA', 1)`)
+	exec(t, db, `INSERT INTO parties (id, name, location, credit_limit, firm_id) VALUES
+		(1, 'SHARMA MEDICAL', 'Unnao', 0, 1), (2, 'AGARWAL MEDICOS', 'UNNAO', 100, 1), (3, 'BAJPAI PHARMA', 'ASOHA', 0, 1),
+		(4, 'PAID UP STORES', 'UNNAO', 0, 1), (5, 'TIRWA MEDICOS', 'TIRWA', 0, 1)`)
+	exec(t, db, `INSERT INTO sale_bills (bill_number, bill_date, party_name, amount, party_id, firm_id) VALUES
+		('A-1', '2025-03-01 00:00:00 +0000 UTC', 'SHARMA MEDICAL', 500, 1, 1),
+		('A-2', '2025-03-01 00:00:00 +0000 UTC', 'AGARWAL MEDICOS', 200, 2, 1),
+		('A-3', '2025-03-01 00:00:00 +0000 UTC', 'BAJPAI PHARMA', 50, 3, 1),
+		('A-4', '2025-03-01 00:00:00 +0000 UTC', 'PAID UP STORES', 300, 4, 1),
+		('A-5', '2025-03-01 00:00:00 +0000 UTC', 'TIRWA MEDICOS', 900, 5, 1)`)
+	exec(t, db, `INSERT INTO transactions (party_id, amount, transaction_date, payment_mode, narration, firm_id) VALUES
+		(4, 300, '2025-04-02 00:00:00 +0000 UTC', 'CASH', 'CASH/1', 1),
+		(1, 100, '2025-04-03 00:00:00 +0000 UTC', 'CASH', 'CASH/2', 1),
+		(1, 100, '2025-04-09 00:00:00 +0000 UTC', 'CASH', 'CASH/3', 1)`)
+
+	summaries, dues, err := h.routeDues(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summaries) != 2 || summaries[0].Parties != 3 || summaries[0].Outstanding != 550 || summaries[0].Breached != 1 ||
+		summaries[1].Name != "No route" || summaries[1].Parties != 1 || summaries[1].Outstanding != 900 {
+		t.Errorf("route summaries = %+v", summaries)
+	}
+	// A route's parties come in location and name order, without those
+	// owing nothing
+	var names []string
+	for _, d := range dues[1] {
+		names = append(names, d.Name)
+	}
+	if want := []string{"BAJPAI PHARMA", "AGARWAL MEDICOS", "SHARMA MEDICAL"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("parties on the route = %q, want %q", names, want)
+	}
+	if last := dues[1][2].LastReceipt; last != "09 Apr 2025" {
+		t.Errorf("last receipt of SHARMA MEDICAL = %q", last)
+	}
+
+	sheet := func(query string) *httptest.ResponseRecorder {
+		return serve(h, http.HandlerFunc(h.PrintRouteSheet), httptest.NewRequest(http.MethodGet, "/routes/outstanding/print"+query, nil))
+	}
+	if w := sheet("?route=7"); w.Code != http.StatusNotFound {
+		t.Errorf("sheet of no route: status %d", w.Code)
+	}
+	body := sheet("?route=1").Body.String()
+	if !strings.Contains(body, "UNNAO ROUTE") || !strings.Contains(body, "AGARWAL MEDICOS") || strings.Contains(body, "PAID UP STORES") || strings.Contains(body, "TIRWA MEDICOS") {
+		t.Errorf("route sheet:\n%s", body)
+	}
+}
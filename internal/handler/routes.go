@@ -0,0 +1,266 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// routeLocation is how a party location is keyed on a route, so "Unnao " and
+// "UNNAO" are one place
+func routeLocation(location string) string {
+	return strings.ToUpper(strings.TrimSpace(location))
+}
+
+// renderRoutes renders the routes page: each route's parties, outstanding and
+// receipts in the period, the locations on no route yet, and the parties and
+// collection days of the selected route. A day receipts were collected on a
+// route counts as a visit.
+func (h *Handler) renderRoutes(w http.ResponseWriter, r *http.Request, formError string) {
+	ctx := r.Context()
+	fromDate, tillDate, year := reportPeriod(r, time.Now().AddDate(0, 0, -30))
+	view := pages.RoutesView{
+		FromDate: fromDate.Format("2006-01-02"),
+		TillDate: tillDate.Format("2006-01-02"),
+		Year:     year,
+		Error:    formError,
+	}
+
+	routes, err := h.queries.ListRoutes(ctx, firmID(ctx))
+	if err != nil {
+		http.Error(w, "Error loading routes", http.StatusInternalServerError)
+		return
+	}
+	locations, err := h.queries.ListRouteLocations(ctx, firmID(ctx))
+	if err != nil {
+		http.Error(w, "Error loading routes", http.StatusInternalServerError)
+		return
+	}
+	view.Unrouted, err = h.queries.ListUnroutedLocations(ctx, sqlc.ListUnroutedLocationsParams{FirmID: firmID(ctx), FirmID_2: firmID(ctx)})
+	if err != nil {
+		http.Error(w, "Error loading locations", http.StatusInternalServerError)
+		return
+	}
+	balances, err := h.queries.ListPartyBalances(ctx, firmID(ctx))
+	if err != nil {
+		http.Error(w, "Error loading parties", http.StatusInternalServerError)
+		return
+	}
+	receipts, err := h.queries.ListReceiptsInPeriod(ctx, sqlc.ListReceiptsInPeriodParams{
+		FirmID:            firmID(ctx),
+		TransactionDate:   fromDate,
+		TransactionDate_2: tillDate,
+	})
+	if err != nil {
+		http.Error(w, "Error loading receipts", http.StatusInternalServerError)
+		return
+	}
+
+	view.Routes = make([]pages.RouteSummary, len(routes))
+	index := make(map[int64]int, len(routes))
+	for i, rt := range routes {
+		view.Routes[i] = pages.RouteSummary{ID: rt.ID, Name: rt.Name}
+		index[rt.ID] = i
+	}
+	onRoute := make(map[string]int64, len(locations))
+	for _, l := range locations {
+		onRoute[l.Location] = l.RouteID
+		if i, ok := index[l.RouteID]; ok {
+			view.Routes[i].Locations = append(view.Routes[i].Locations, l)
+		}
+	}
+
+	selected, _ := strconv.ParseInt(r.FormValue("route"), 10, 64)
+	partyRoute := make(map[int64]int64)
+	parties := make(map[int64]int) // selected route's parties, by party ID
+	for _, p := range balances {
+		routeID, ok := onRoute[routeLocation(p.Location.String)]
+		i, known := index[routeID]
+		if !ok || !known {
+			continue
+		}
+		partyRoute[p.ID] = routeID
+		balance := partyBalance(p.ID, p.Name, p.Location.String, p.CreditLimit, p.Billed, p.Received, p.ReceiptCount)
+		view.Routes[i].Parties++
+		view.Routes[i].Outstanding += balance.Outstanding
+		if routeID == selected {
+			parties[p.ID] = len(view.Parties)
+			view.Parties = append(view.Parties, pages.RouteParty{PartyBalance: balance})
+		}
+	}
+
+	visits := make(map[int64]map[string]bool)
+	for _, rc := range receipts {
+		routeID, ok := partyRoute[rc.PartyID]
+		if !ok {
+			continue
+		}
+		rs := &view.Routes[index[routeID]]
+		rs.Receipts++
+		rs.Collected += rc.Amount
+		day := rc.TransactionDate.Format("2006-01-02")
+		if visits[routeID] == nil {
+			visits[routeID] = make(map[string]bool)
+		}
+		visits[routeID][day] = true
+		if i, ok := parties[rc.PartyID]; ok {
+			p := &view.Parties[i]
+			p.Collected += rc.Amount
+			// Receipts come in date order
+			p.LastReceipt = rc.TransactionDate.Format("02 Jan 2006")
+		}
+	}
+	for i := range view.Routes {
+		view.Routes[i].Visits = len(visits[view.Routes[i].ID])
+		if view.Routes[i].ID == selected {
+			view.Selected = view.Routes[i]
+		}
+	}
+	var days []string
+	for day := range visits[selected] {
+		days = append(days, day)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(days)))
+	for _, day := range days {
+		t, _ := time.Parse("2006-01-02", day)
+		view.VisitDays = append(view.VisitDays, t.Format("02 Jan 2006"))
+	}
+
+	pages.Routes(view).Render(ctx, w)
+}
+
+// Routes groups party locations into the routes parties are visited on, and
+// reports each route's receipts, outstanding and visits
+func (h *Handler) Routes(w http.ResponseWriter, r *http.Request) {
+	h.renderRoutes(w, r, "")
+}
+
+// SaveRoute adds a route
+func (h *Handler) SaveRoute(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	name := strings.TrimSpace(r.FormValue("name"))
+	if name == "" {
+		h.renderRoutes(w, r, "Route name is required.")
+		return
+	}
+	if err := h.queries.CreateRoute(ctx, sqlc.CreateRouteParams{FirmID: firmID(ctx), Name: name}); err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			h.renderRoutes(w, r, "A route named "+name+" already exists.")
+			return
+		}
+		h.renderRoutes(w, r, "Error saving route: "+err.Error())
+		return
+	}
+	http.Redirect(w, r, "/routes", http.StatusSeeOther)
+}
+
+// DeleteRoute removes a route, leaving its locations on no route
+func (h *Handler) DeleteRoute(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid route ID", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		http.Error(w, "Error deleting route", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	q := h.queries.WithTx(tx)
+	if err := q.DeleteRouteLocations(ctx, sqlc.DeleteRouteLocationsParams{RouteID: id, FirmID: firmID(ctx)}); err != nil {
+		http.Error(w, "Error deleting route", http.StatusInternalServerError)
+		return
+	}
+	if err := q.DeleteRoute(ctx, sqlc.DeleteRouteParams{ID: id, FirmID: firmID(ctx)}); err != nil {
+		http.Error(w, "Error deleting route", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error deleting route", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/routes", http.StatusSeeOther)
+}
+
+// AssignRouteLocation puts a party location on a route, moving it off the
+// route it was on
+func (h *Handler) AssignRouteLocation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	location := routeLocation(r.FormValue("location"))
+	if location == "" {
+		h.renderRoutes(w, r, "Choose a location to put on the route.")
+		return
+	}
+	routeID, err := strconv.ParseInt(r.FormValue("route_id"), 10, 64)
+	if err != nil {
+		h.renderRoutes(w, r, "Choose a route for "+location+".")
+		return
+	}
+	routes, err := h.queries.ListRoutes(ctx, firmID(ctx))
+	if err != nil {
+		http.Error(w, "Error loading routes", http.StatusInternalServerError)
+		return
+	}
+	found := false
+	for _, rt := range routes {
+		found = found || rt.ID == routeID
+	}
+	if !found {
+		http.Error(w, "Route not found", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.queries.SetRouteLocation(ctx, sqlc.SetRouteLocationParams{
+		FirmID:   firmID(ctx),
+		RouteID:  routeID,
+		Location: location,
+	}); err != nil {
+		http.Error(w, "Error assigning location", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/routes?route=%d", routeID), http.StatusSeeOther)
+}
+
+// RemoveRouteLocation takes a location off its route
+func (h *Handler) RemoveRouteLocation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid location ID", http.StatusBadRequest)
+		return
+	}
+	if err := h.queries.DeleteRouteLocation(ctx, sqlc.DeleteRouteLocationParams{ID: id, FirmID: firmID(ctx)}); err != nil {
+		http.Error(w, "Error removing location", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/routes", http.StatusSeeOther)
+}
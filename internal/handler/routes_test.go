@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRoutes(t *testing.T) {
+	h, db := newTestHandler(t)
+	exec(t, db, `INSERT INTO parties (id, name, location, firm_id) VALUES
+		(1, 'SHARMA MEDICAL', 'UNNAO ', 1), (2, 'GUPTA STORES', 'Unnao', 1), (3, 'TIRWA MEDICOS', 'TIRWA', 1), (4, 'VERMA AGENCIES', 'UNNAO', 2)`)
+	exec(t, db, `INSERT INTO sale_bills (bill_number, bill_date, party_name, amount, party_id, firm_id) VALUES
+		('A-1', '2025-03-01 00:00:00 +0000 UTC', 'SHARMA MEDICAL', 1000, 1, 1),
+		('A-2', '2025-03-01 00:00:00 +0000 UTC', 'GUPTA STORES', 400, 2, 1),
+		('A-3', '2025-03-01 00:00:00 +0000 UTC', 'TIRWA MEDICOS', 900, 3, 1)`)
+	exec(t, db, `INSERT INTO transactions (party_id, amount, transaction_date, payment_mode, narration, firm_id) VALUES
+		(1, 300, '2025-04-02 00:00:00 +0000 UTC', 'CASH', 'CASH/1', 1),
+		(1, 200, '2025-04-03 00:00:00 +0000 UTC', 'CASH', 'CASH/2', 1),
+		(2, 100, '2025-04-03 00:00:00 +0000 UTC', 'UPI', 'UPI/3', 1),
+		(3, 700, '2025-04-03 00:00:00 +0000 UTC', 'UPI', 'UPI/4', 1),
+		(2, 50, '2025-05-03 00:00:00 +0000 UTC', 'UPI', 'UPI/OUT OF PERIOD', 1)`)
+	post := func(handler http.HandlerFunc, target string, form url.Values) *httptest.ResponseRecorder {
+		return serve(h, handler, postForm(target, form))
+	}
+
+	if w := post(h.SaveRoute, "/routes/save", url.Values{"name": {" "}}); !strings.Contains(w.Body.String(), "Route name is required") {
+		t.Errorf("saving a route without a name: %s", w.Body)
+	}
+	for _, name := range []string{"UNNAO ROUTE", "KANPUR ROUTE"} {
+		if w := post(h.SaveRoute, "/routes/save", url.Values{"name": {name}}); w.Code != http.StatusSeeOther {
+			t.Fatalf("saving route %s: status %d: %s", name, w.Code, w.Body)
+		}
+	}
+	if w := post(h.SaveRoute, "/routes/save", url.Values{"name": {"UNNAO ROUTE"}}); !strings.Contains(w.Body.String(), "already exists") {
+		t.Errorf("saving a route twice: %s", w.Body)
+	}
+	unnao := strconv.FormatFloat(count(t, db, "SELECT id FROM routes WHERE name = 'UNNAO ROUTE'"), 'f', -1, 64)
+	kanpur := strconv.FormatFloat(count(t, db, "SELECT id FROM routes WHERE name = 'KANPUR ROUTE'"), 'f', -1, 64)
+	exec(t, db, `INSERT INTO routes (id, name, firm_id) VALUES (99, 'OTHER FIRM ROUTE', 2)`)
+
+	if w := post(h.AssignRouteLocation, "/routes/locations/assign", url.Values{"location": {"unnao"}, "route_id": {"99"}}); w.Code != http.StatusBadRequest {
+		t.Errorf("assigning to another firm's route: status %d", w.Code)
+	}
+	// A location moves off the route it was on
+	post(h.AssignRouteLocation, "/routes/locations/assign", url.Values{"location": {" unnao"}, "route_id": {kanpur}})
+	if w := post(h.AssignRouteLocation, "/routes/locations/assign", url.Values{"location": {"Unnao "}, "route_id": {unnao}}); w.Code != http.StatusSeeOther {
+		t.Fatalf("assigning a location: status %d: %s", w.Code, w.Body)
+	}
+	if n := count(t, db, "SELECT COUNT(*) FROM route_locations WHERE location = 'UNNAO' AND route_id = "+unnao); n != 1 || count(t, db, "SELECT COUNT(*) FROM route_locations") != 1 {
+		t.Errorf("location not moved to the route")
+	}
+
+	// Both spellings of the location are on the route: 750 outstanding to
+	// date, 600 collected in the period on two days, the other firm's party
+	// left out
+	body := serve(h, http.HandlerFunc(h.Routes), httptest.NewRequest(http.MethodGet, "/routes?route="+unnao+"&from_date=2025-04-01&till_date=2025-04-30", nil)).Body.String()
+	for _, want := range []string{"SHARMA MEDICAL", "GUPTA STORES", "₹750.00", "₹600.00", "02 Apr 2025", "03 Apr 2025", "TIRWA"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("routes page lacks %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "VERMA AGENCIES") || strings.Contains(body, "₹650.00") {
+		t.Errorf("routes page counts another firm's party or a receipt out of the period:\n%s", body)
+	}
+
+	// Deleting a route leaves its locations on no route
+	if w := post(h.DeleteRoute, "/routes/delete", url.Values{"id": {unnao}}); w.Code != http.StatusSeeOther {
+		t.Fatalf("deleting a route: status %d: %s", w.Code, w.Body)
+	}
+	if n := count(t, db, "SELECT COUNT(*) FROM route_locations"); n != 0 {
+		t.Errorf("deleted route kept %v locations", n)
+	}
+}
@@ -0,0 +1,252 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"suspense.durgadawaghar.com/internal/category"
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/parser"
+	"suspense.durgadawaghar.com/internal/rules"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// loadRules compiles the enabled classification rules
+func (h *Handler) loadRules(ctx context.Context) (*rules.Engine, error) {
+	rows, err := h.queries.ListEnabledRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing rules: %w", err)
+	}
+	list := make([]rules.Rule, len(rows))
+	for i, row := range rows {
+		list[i] = ruleFromRow(row)
+	}
+	return rules.NewEngine(list)
+}
+
+// applyRules classifies a parsed transaction
+func applyRules(engine *rules.Engine, tx parser.Transaction) rules.Result {
+	return engine.Apply(rules.Input{
+		PartyLine: strings.TrimSpace(tx.PartyName + " " + tx.Location),
+		Narration: tx.Narration,
+		Amount:    tx.Amount,
+	})
+}
+
+func ruleFromRow(row sqlc.Rule) rules.Rule {
+	return rules.Rule{
+		ID:               row.ID,
+		Name:             row.Name,
+		NarrationPattern: row.NarrationPattern,
+		PartyPattern:     row.PartyPattern,
+		MinAmount:        row.MinAmount,
+		MaxAmount:        row.MaxAmount,
+		Category:         category.Category(row.SetCategory),
+		Internal:         row.SetInternal,
+		PartyName:        row.SetPartyName,
+	}
+}
+
+func ruleFormFromRow(row sqlc.Rule) pages.RuleForm {
+	return pages.RuleForm{
+		ID:               row.ID,
+		Name:             row.Name,
+		NarrationPattern: row.NarrationPattern,
+		PartyPattern:     row.PartyPattern,
+		MinAmount:        formatOptionalAmount(row.MinAmount),
+		MaxAmount:        formatOptionalAmount(row.MaxAmount),
+		Category:         row.SetCategory,
+		Internal:         row.SetInternal,
+		PartyName:        row.SetPartyName,
+		Priority:         strconv.FormatInt(row.Priority, 10),
+		Enabled:          row.Enabled,
+	}
+}
+
+func formatOptionalAmount(amount float64) string {
+	if amount == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.2f", amount)
+}
+
+// renderRules renders the rules page with the given form
+func (h *Handler) renderRules(w http.ResponseWriter, r *http.Request, form pages.RuleForm, formError string) {
+	list, err := h.queries.ListRules(r.Context())
+	if err != nil {
+		http.Error(w, "Error loading rules", http.StatusInternalServerError)
+		return
+	}
+	pages.Rules(list, form, formError).Render(r.Context(), w)
+}
+
+// Rules lists classification rules, with a form to add or edit one
+func (h *Handler) Rules(w http.ResponseWriter, r *http.Request) {
+	form := pages.RuleForm{Priority: "100", Enabled: true}
+	if editStr := r.URL.Query().Get("edit"); editStr != "" {
+		id, err := strconv.ParseInt(editStr, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid rule ID", http.StatusBadRequest)
+			return
+		}
+		row, err := h.queries.GetRule(r.Context(), id)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		form = ruleFormFromRow(row)
+	}
+	h.renderRules(w, r, form, "")
+}
+
+// SaveRule creates or updates a rule
+func (h *Handler) SaveRule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	form := pages.RuleForm{
+		Name:             strings.TrimSpace(r.FormValue("name")),
+		NarrationPattern: strings.TrimSpace(r.FormValue("narration_pattern")),
+		PartyPattern:     strings.TrimSpace(r.FormValue("party_pattern")),
+		MinAmount:        strings.TrimSpace(r.FormValue("min_amount")),
+		MaxAmount:        strings.TrimSpace(r.FormValue("max_amount")),
+		Category:         r.FormValue("category"),
+		Internal:         r.FormValue("internal") != "",
+		PartyName:        strings.ToUpper(strings.TrimSpace(r.FormValue("party_name"))),
+		Priority:         strings.TrimSpace(r.FormValue("priority")),
+		Enabled:          r.FormValue("enabled") != "",
+	}
+	if id, err := strconv.ParseInt(r.FormValue("id"), 10, 64); err == nil {
+		form.ID = id
+	}
+
+	if form.Name == "" {
+		h.renderRules(w, r, form, "Rule name is required.")
+		return
+	}
+	var minAmount, maxAmount float64
+	var err error
+	if form.MinAmount != "" {
+		if minAmount, err = strconv.ParseFloat(form.MinAmount, 64); err != nil {
+			h.renderRules(w, r, form, "Invalid minimum amount.")
+			return
+		}
+	}
+	if form.MaxAmount != "" {
+		if maxAmount, err = strconv.ParseFloat(form.MaxAmount, 64); err != nil {
+			h.renderRules(w, r, form, "Invalid maximum amount.")
+			return
+		}
+	}
+	priority := int64(100)
+	if form.Priority != "" {
+		if priority, err = strconv.ParseInt(form.Priority, 10, 64); err != nil {
+			h.renderRules(w, r, form, "Invalid priority.")
+			return
+		}
+	}
+
+	rule := rules.Rule{
+		Name:             form.Name,
+		NarrationPattern: form.NarrationPattern,
+		PartyPattern:     form.PartyPattern,
+		MinAmount:        minAmount,
+		MaxAmount:        maxAmount,
+		Category:         category.Category(form.Category),
+		Internal:         form.Internal,
+		PartyName:        form.PartyName,
+	}
+	if err := rules.Validate(rule); err != nil {
+		h.renderRules(w, r, form, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+	if form.ID > 0 {
+		err = h.queries.UpdateRule(ctx, sqlc.UpdateRuleParams{
+			Name:             rule.Name,
+			NarrationPattern: rule.NarrationPattern,
+			PartyPattern:     rule.PartyPattern,
+			MinAmount:        rule.MinAmount,
+			MaxAmount:        rule.MaxAmount,
+			SetCategory:      string(rule.Category),
+			SetInternal:      rule.Internal,
+			SetPartyName:     rule.PartyName,
+			Priority:         priority,
+			Enabled:          form.Enabled,
+			ID:               form.ID,
+		})
+	} else {
+		_, err = h.queries.CreateRule(ctx, sqlc.CreateRuleParams{
+			Name:             rule.Name,
+			NarrationPattern: rule.NarrationPattern,
+			PartyPattern:     rule.PartyPattern,
+			MinAmount:        rule.MinAmount,
+			MaxAmount:        rule.MaxAmount,
+			SetCategory:      string(rule.Category),
+			SetInternal:      rule.Internal,
+			SetPartyName:     rule.PartyName,
+			Priority:         priority,
+			Enabled:          form.Enabled,
+		})
+	}
+	if err != nil {
+		h.renderRules(w, r, form, fmt.Sprintf("Error saving rule: %s", err.Error()))
+		return
+	}
+
+	http.Redirect(w, r, "/rules", http.StatusSeeOther)
+}
+
+// DeleteRule removes a rule
+func (h *Handler) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid rule ID", http.StatusBadRequest)
+		return
+	}
+	if err := h.queries.DeleteRule(r.Context(), id); err != nil {
+		http.Error(w, "Error deleting rule", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/rules", http.StatusSeeOther)
+}
+
+// TestRules shows how the enabled rules classify a sample entry
+func (h *Handler) TestRules(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	partyLine := strings.TrimSpace(r.FormValue("party_line"))
+	narration := strings.TrimSpace(r.FormValue("narration"))
+	if partyLine == "" && narration == "" {
+		w.Write([]byte(`<div class="error">Please enter a party line or narration to test.</div>`))
+		return
+	}
+	amount := 0.0
+	if a, err := strconv.ParseFloat(strings.ReplaceAll(r.FormValue("amount"), ",", ""), 64); err == nil {
+		amount = a
+	}
+
+	engine, err := h.loadRules(r.Context())
+	if err != nil {
+		w.Write([]byte(fmt.Sprintf(`<div class="error">Error loading rules: %s</div>`, err.Error())))
+		return
+	}
+
+	res := engine.Apply(rules.Input{PartyLine: partyLine, Narration: narration, Amount: amount})
+	pages.RuleTestResult(res).Render(r.Context(), w)
+}
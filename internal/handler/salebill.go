@@ -0,0 +1,355 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/parser"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// billSettledTolerance is the amount due below which a bill counts as paid,
+// absorbing paise lost to rounding
+const billSettledTolerance = 0.01
+
+// allocateReceipts applies receipts to credit bills: first the amounts
+// allocated by hand, each no more than is left of the bill and the receipt,
+// then what is left of each receipt first in, first out, settling the oldest
+// bill still due. Bills and receipts are expected oldest first; allocations
+// by hand of other bills or receipts are ignored. A bill paid short by no more
+// than tolerance counts as settled, the shortfall noted on its last allocation
+// as a rounding difference.
+func allocateReceipts(bills []sqlc.SaleBill, receipts []sqlc.Transaction, manual []sqlc.BillAllocation, tolerance float64) map[int64][]pages.BillAllocation {
+	allocations := make(map[int64][]pages.BillAllocation)
+	due := make([]float64, len(bills))
+	billIndex := make(map[int64]int, len(bills))
+	for i, b := range bills {
+		due[i] = b.Amount
+		billIndex[b.ID] = i
+	}
+	byHand := make([]bool, len(bills))
+	left := make([]float64, len(receipts))
+	receiptIndex := make(map[int64]int, len(receipts))
+	for j, t := range receipts {
+		left[j] = t.Amount
+		receiptIndex[t.ID] = j
+	}
+	apply := func(i, j int, amount float64, hand bool) {
+		t := receipts[j]
+		allocations[bills[i].ID] = append(allocations[bills[i].ID], pages.BillAllocation{
+			TransactionID: t.ID,
+			Date:          t.TransactionDate.Format("02 Jan 2006"),
+			PaymentMode:   t.PaymentMode.String,
+			Amount:        amount,
+			ByHand:        hand,
+		})
+		due[i] -= amount
+		left[j] -= amount
+		byHand[i] = byHand[i] || hand
+	}
+	// settle writes off what is left due of a paid bill within the tolerance
+	settle := func(i int) {
+		list := allocations[bills[i].ID]
+		if len(list) > 0 && due[i] >= billSettledTolerance && due[i] <= tolerance {
+			list[len(list)-1].RoundingDiff = due[i]
+			due[i] = 0
+		}
+	}
+
+	for _, m := range manual {
+		i, okBill := billIndex[m.SaleBillID]
+		j, okReceipt := receiptIndex[m.TransactionID]
+		if !okBill || !okReceipt {
+			continue
+		}
+		if amount := min(m.Amount, due[i], left[j]); amount > billSettledTolerance {
+			apply(i, j, amount, true)
+		}
+	}
+	for i := range bills {
+		if byHand[i] {
+			settle(i)
+		}
+	}
+
+	i := 0
+	for j := range receipts {
+		for left[j] > billSettledTolerance && i < len(bills) {
+			if byHand[i] && due[i] < billSettledTolerance {
+				i++
+				continue
+			}
+			apply(i, j, min(left[j], due[i]), false)
+			settle(i)
+			if due[i] < billSettledTolerance {
+				i++
+			}
+		}
+	}
+	return allocations
+}
+
+// SaleBillDetail shows a sale bill with its party and the receipts allocated
+// to it
+func (h *Handler) SaleBillDetail(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.URL.Path[len("/sale-bill/"):], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid sale bill ID", http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+
+	// A bill of another firm is not found, so the firm's rounding tolerance
+	// below is the bill's own
+	bill, err := h.queries.GetSaleBillByID(ctx, id)
+	if err != nil || bill.FirmID != firmID(ctx) {
+		http.NotFound(w, r)
+		return
+	}
+
+	view := pages.SaleBillView{
+		ID:         bill.ID,
+		BillNumber: bill.BillNumber,
+		Date:       bill.BillDate.Format("02 Jan 2006"),
+		PartyName:  bill.PartyName,
+		Amount:     bill.Amount,
+		IsCashSale: bill.IsCashSale.Valid && bill.IsCashSale.Bool,
+		IsCardSale: bill.IsCardSale,
+		IRN:        bill.Irn,
+		AckNumber:  bill.AckNumber,
+		Taxable:    bill.TaxableValue,
+		CGST:       bill.Cgst,
+		SGST:       bill.Sgst,
+		IGST:       bill.Igst,
+	}
+	if bill.AckDate.Valid {
+		view.AckDate = bill.AckDate.Time.Format("02 Jan 2006")
+	}
+
+	if party, err := h.queries.GetPartyBySaleBillID(ctx, id); err == nil {
+		view.PartyID = party.ID
+		view.Location = party.Location.String
+	}
+
+	// Cash and card sales are paid at the counter; credit bills are paid by
+	// the party's receipts
+	if view.IsCashSale || view.IsCardSale {
+		view.Paid = bill.Amount
+	} else if view.PartyID != 0 {
+		bills, receipts, manual, err := h.partyAllocations(ctx, view.PartyID)
+		if err != nil {
+			http.Error(w, "Error loading allocations", http.StatusInternalServerError)
+			return
+		}
+		view.Allocations = allocateReceipts(bills, receipts, manual, roundingTolerance(ctx))[bill.ID]
+		for _, a := range view.Allocations {
+			view.Paid += a.Amount
+			view.RoundingDiff += a.RoundingDiff
+		}
+	}
+	view.Due = bill.Amount - view.Paid - view.RoundingDiff
+	if view.Due < billSettledTolerance {
+		view.Due = 0
+	}
+
+	pages.SaleBillDetail(view).Render(ctx, w)
+}
+
+// maxSaleBillFileSize limits uploaded sale bill spreadsheets
+const maxSaleBillFileSize = 10 << 20
+
+// saleBillSource reads what to import from a sale bill import form: pasted
+// register text, or spreadsheet rows with their column mapping
+func saleBillSource(r *http.Request) pages.SaleBillSource {
+	src := pages.SaleBillSource{
+		Data:          r.FormValue("data"),
+		Year:          2025,
+		Rows:          r.FormValue("rows"),
+		Tolerant:      r.FormValue("tolerant") != "",
+		BillNumberCol: -1,
+		DateCol:       -1,
+		PartyNameCol:  -1,
+		AmountCol:     -1,
+		IRNCol:        -1,
+		AckNumberCol:  -1,
+		AckDateCol:    -1,
+		TaxableCol:    -1,
+		CGSTCol:       -1,
+		SGSTCol:       -1,
+		IGSTCol:       -1,
+	}
+	if y, err := strconv.Atoi(r.FormValue("year")); err == nil {
+		src.Year = y
+	}
+	src.PendingID = pendingImportID(r)
+	for field, col := range map[string]*int{
+		"col_bill_number": &src.BillNumberCol,
+		"col_date":        &src.DateCol,
+		"col_party_name":  &src.PartyNameCol,
+		"col_amount":      &src.AmountCol,
+		"col_irn":         &src.IRNCol,
+		"col_ack_number":  &src.AckNumberCol,
+		"col_ack_date":    &src.AckDateCol,
+		"col_taxable":     &src.TaxableCol,
+		"col_cgst":        &src.CGSTCol,
+		"col_sgst":        &src.SGSTCol,
+		"col_igst":        &src.IGSTCol,
+	} {
+		if c, err := strconv.Atoi(r.FormValue(field)); err == nil {
+			*col = c
+		}
+	}
+	return src
+}
+
+// parseSaleBillSource parses the sale bills of an import form, with the lines
+// of pasted text the tolerant parser could not read
+func parseSaleBillSource(src pages.SaleBillSource) ([]parser.SaleBill, []string, error) {
+	if err := checkImportText(src.Data, src.Year); err != nil {
+		return nil, nil, err
+	}
+	if src.Rows == "" && src.Tolerant {
+		bills, unread := parser.ParseSaleBillsTolerant(src.Data, src.Year)
+		return bills, unread, nil
+	}
+	if src.Rows == "" {
+		return parser.ParseSaleBills(src.Data, src.Year), nil, nil
+	}
+
+	cols := parser.SaleBillColumns{
+		BillNumber:   src.BillNumberCol,
+		Date:         src.DateCol,
+		PartyName:    src.PartyNameCol,
+		Amount:       src.AmountCol,
+		IRN:          src.IRNCol,
+		AckNumber:    src.AckNumberCol,
+		AckDate:      src.AckDateCol,
+		TaxableValue: src.TaxableCol,
+		CGST:         src.CGSTCol,
+		SGST:         src.SGSTCol,
+		IGST:         src.IGSTCol,
+	}
+	if cols.BillNumber < 0 || cols.Date < 0 || cols.PartyName < 0 || cols.Amount < 0 {
+		return nil, nil, errors.New("choose the column for each of bill number, date, party name and amount")
+	}
+	rows, err := parser.ReadCSVRows(strings.NewReader(src.Rows))
+	if err != nil {
+		return nil, nil, err
+	}
+	return parser.ParseSaleBillRows(rows, cols, src.Year), nil, nil
+}
+
+// ImportSaleBillsFile reads an uploaded CSV or .xlsx sale bill file and shows
+// its columns for mapping to sale bill fields before the preview
+func (h *Handler) ImportSaleBillsFile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxSaleBillFileSize)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		w.Write([]byte(`<div class="error">Choose a CSV or Excel (.xlsx) file up to 10 MB.</div>`))
+		return
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		w.Write([]byte(`<div class="error">Error reading the file.</div>`))
+		return
+	}
+
+	src, columns, sample, err := readSaleBillFile(header.Filename, content)
+	if err != nil {
+		w.Write([]byte(fmt.Sprintf(`<div class="error">Cannot read %s: %s</div>`, html.EscapeString(header.Filename), html.EscapeString(err.Error()))))
+		return
+	}
+	if y, err := strconv.Atoi(r.FormValue("year")); err == nil {
+		src.Year = y
+	}
+
+	pages.ImportSaleBillsMapping(header.Filename, src, columns, sample).Render(r.Context(), w)
+}
+
+// readSaleBillFile reads a CSV or .xlsx sale bill file into the rows to
+// import, with its columns labelled and mapped to the sale bill fields their
+// headers name, and its first rows as a sample to check the mapping against
+func readSaleBillFile(filename string, content []byte) (pages.SaleBillSource, []string, [][]string, error) {
+	var rows [][]string
+	var err error
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".xlsx":
+		rows, err = parser.ReadXLSXRows(content)
+	case ".xls":
+		err = fmt.Errorf("old .xls workbooks are not supported; save the file as .xlsx or CSV")
+	default:
+		rows, err = parser.ReadCSVRows(bytes.NewReader(content))
+	}
+	if err != nil {
+		return pages.SaleBillSource{}, nil, nil, err
+	}
+
+	// Drop blank rows, and carry the rest to the preview as CSV
+	var nonEmpty [][]string
+	for _, row := range rows {
+		if strings.TrimSpace(strings.Join(row, "")) != "" {
+			nonEmpty = append(nonEmpty, row)
+		}
+	}
+	if len(nonEmpty) == 0 {
+		return pages.SaleBillSource{}, nil, nil, errors.New("the file has no rows")
+	}
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+	cw.WriteAll(nonEmpty)
+
+	width := 0
+	for _, row := range nonEmpty {
+		width = max(width, len(row))
+	}
+	headerRow, cols := parser.FindSaleBillHeader(nonEmpty)
+	columns := make([]string, width)
+	for i := range columns {
+		columns[i] = columnLabel(i)
+		if headerRow >= 0 && i < len(nonEmpty[headerRow]) && strings.TrimSpace(nonEmpty[headerRow][i]) != "" {
+			columns[i] += ": " + strings.TrimSpace(nonEmpty[headerRow][i])
+		}
+	}
+
+	src := pages.SaleBillSource{
+		Year:          time.Now().Year(),
+		Rows:          buf.String(),
+		BillNumberCol: cols.BillNumber,
+		DateCol:       cols.Date,
+		PartyNameCol:  cols.PartyName,
+		AmountCol:     cols.Amount,
+		IRNCol:        cols.IRN,
+		AckNumberCol:  cols.AckNumber,
+		AckDateCol:    cols.AckDate,
+		TaxableCol:    cols.TaxableValue,
+		CGSTCol:       cols.CGST,
+		SGSTCol:       cols.SGST,
+		IGSTCol:       cols.IGST,
+	}
+	return src, columns, nonEmpty[:min(len(nonEmpty), headerRow+6)], nil
+}
+
+// columnLabel names a 0-based column the way spreadsheets do: A, B, ... AA
+func columnLabel(i int) string {
+	label := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		label = string(rune('A'+(i-1)%26)) + label
+	}
+	return label
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+)
+
+func TestSearchSaleBillsByIRNReadOnly(t *testing.T) {
+	h, db := newTestHandler(t)
+	exec(t, db, `INSERT INTO sale_bills (bill_number, bill_date, party_name, amount, irn, ack_number, firm_id)
+		VALUES ('A-101', '2025-04-02', 'SHARMA MEDICAL', 1180, 'a1b2c3d4e5f6', '112510012345', 1),
+		       ('B-7', '2025-04-02', 'SHARMA MEDICAL', 500, 'a1b2c3d4e5ff', '', 2)`)
+
+	// the search only reads, so a read-only server answers it
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sale-bills/search/irn", h.SearchSaleBillsByIRN)
+	w := serve(h, ReadOnly(mux, mux), httptest.NewRequest(http.MethodGet, "/sale-bills/search/irn?irn=A1B2+C3D4", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	if body := w.Body.String(); !strings.Contains(body, "A-101") || strings.Contains(body, "B-7") {
+		t.Errorf("IRN search found the wrong bills:\n%s", body)
+	}
+
+	w = serve(h, http.HandlerFunc(h.SearchSaleBillsByIRN), httptest.NewRequest(http.MethodGet, "/sale-bills/search/irn?irn=112510012345", nil))
+	if !strings.Contains(w.Body.String(), "A-101") {
+		t.Errorf("Ack No. search did not find the bill:\n%s", w.Body)
+	}
+}
+
+func TestSaleBillDetail(t *testing.T) {
+	h, db := newTestHandler(t)
+	// Durga Pharma writes off up to a rupee short as rounding
+	exec(t, db, "UPDATE firms SET rounding_tolerance = 1 WHERE id = 2")
+	exec(t, db, `INSERT INTO parties (id, name, firm_id) VALUES (1, 'VERMA AGENCIES', 2)`)
+	exec(t, db, `INSERT INTO sale_bills (id, bill_number, bill_date, party_name, amount, is_cash_sale, party_id, firm_id)
+		VALUES (1, 'B-1', '2025-04-01 00:00:00 +0000 UTC', 'VERMA AGENCIES', 1000, FALSE, 1, 2)`)
+	exec(t, db, `INSERT INTO transactions (party_id, amount, transaction_date, payment_mode, narration, firm_id)
+		VALUES (1, 999.50, '2025-04-05 00:00:00 +0000 UTC', 'UPI', 'UPI/1', 2)`)
+	detail := func(firm string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/sale-bill/1", nil)
+		r.AddCookie(&http.Cookie{Name: firmCookie, Value: firm})
+		return serve(h, http.HandlerFunc(h.SaleBillDetail), r)
+	}
+
+	if w := detail("1"); w.Code != http.StatusNotFound {
+		t.Errorf("bill of another firm: status = %d", w.Code)
+	}
+	w := detail("2")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	if body := w.Body.String(); !strings.Contains(body, "paid</span>") || !strings.Contains(body, "rounding diff of ₹0.50") {
+		t.Errorf("bill short by 50 paise in a firm tolerating a rupee is not paid:\n%s", body)
+	}
+}
+
+func TestSearchSaleBillsWithinTolerance(t *testing.T) {
+	h, db := newTestHandler(t)
+	exec(t, db, "UPDATE firms SET rounding_tolerance = 1 WHERE id = 2")
+	exec(t, db, `INSERT INTO sale_bills (bill_number, bill_date, party_name, amount, firm_id) VALUES
+		('B-1', '2025-04-01 00:00:00 +0000 UTC', 'VERMA AGENCIES', 1000.40, 2),
+		('B-2', '2025-04-01 00:00:00 +0000 UTC', 'VERMA AGENCIES', 1000, 2),
+		('B-3', '2025-04-01 00:00:00 +0000 UTC', 'VERMA AGENCIES', 1001.50, 2)`)
+	search := func(form url.Values) string {
+		form.Set("from_date", "2025-04-01")
+		form.Set("till_date", "2025-04-30")
+		r := postForm("/sale-bills/search", form)
+		r.AddCookie(&http.Cookie{Name: firmCookie, Value: "2"})
+		return serve(h, http.HandlerFunc(h.SearchSaleBillsResults), r).Body.String()
+	}
+
+	// Without a variation the firm's tolerance applies, near misses noted
+	body := search(url.Values{"amount": {"1000"}})
+	if !strings.Contains(body, "B-1") || !strings.Contains(body, "B-2") || strings.Contains(body, "B-3") {
+		t.Errorf("search within a rupee found the wrong bills:\n%s", body)
+	}
+	if !strings.Contains(body, "rounding diff +0.40") || strings.Count(body, "rounding diff") != 1 {
+		t.Errorf("near miss is not noted as a rounding diff:\n%s", body)
+	}
+	if !strings.Contains(body, "amount 1000 +/- 1") {
+		t.Errorf("search does not say it allowed the tolerance:\n%s", body)
+	}
+
+	// A variation given overrides it
+	if body := search(url.Values{"amount": {"1000"}, "variation": {"0"}}); strings.Contains(body, "B-1") || !strings.Contains(body, "B-2") {
+		t.Errorf("exact search found the wrong bills:\n%s", body)
+	}
+}
+
+func TestAllocateReceiptsWithinTolerance(t *testing.T) {
+	bills := []sqlc.SaleBill{{ID: 1, Amount: 1000}, {ID: 2, Amount: 500}}
+	receipts := []sqlc.Transaction{{ID: 1, Amount: 999.50}, {ID: 2, Amount: 500}}
+
+	// A bill paid 50 paise short is settled within a rupee, leaving the next
+	// receipt for the next bill
+	got := allocateReceipts(bills, receipts, nil, 1)
+	if len(got[1]) != 1 || got[1][0].Amount != 999.50 || got[1][0].RoundingDiff != 0.50 {
+		t.Errorf("bill 1 allocations = %+v, want 999.50 with a 0.50 rounding diff", got[1])
+	}
+	if len(got[2]) != 1 || got[2][0].TransactionID != 2 || got[2][0].Amount != 500 {
+		t.Errorf("bill 2 allocations = %+v, want all of receipt 2", got[2])
+	}
+
+	// Without a tolerance the shortfall is taken from the next receipt
+	got = allocateReceipts(bills, receipts, nil, 0)
+	if len(got[1]) != 2 || got[1][1].Amount != 0.50 || got[1][0].RoundingDiff != 0 {
+		t.Errorf("bill 1 allocations = %+v, want 999.50 and 0.50", got[1])
+	}
+	if len(got[2]) != 1 || got[2][0].Amount != 499.50 {
+		t.Errorf("bill 2 allocations = %+v, want 499.50", got[2])
+	}
+
+	statuses := billStatuses(bills, receipts, nil, 1)
+	for _, s := range statuses {
+		if s.Due != 0 {
+			t.Errorf("bill %s: due %.2f, want settled", s.BillNumber, s.Due)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// Saved search kinds
+const (
+	savedSearchNarration = "narration"
+	savedSearchSaleBill  = "sale_bill"
+)
+
+// savedSearches lists saved searches for the home page with a description of
+// their parameters and how to run them
+func (h *Handler) savedSearches(ctx context.Context) []pages.SavedSearchView {
+	rows, _ := h.queries.ListSavedSearches(ctx, firmID(ctx))
+	searches := make([]pages.SavedSearchView, len(rows))
+	for i, s := range rows {
+		view := pages.SavedSearchView{ID: s.ID, Name: s.Name}
+		switch s.Kind {
+		case savedSearchNarration:
+			view.Description = "Narration: " + s.Narration
+			view.Narration = s.Narration
+		case savedSearchSaleBill:
+			view.Description = fmt.Sprintf("Sale bill ₹%.2f ± %.2f", s.Amount, s.Variation)
+			params := url.Values{}
+			params.Set("amount", strconv.FormatFloat(s.Amount, 'f', -1, 64))
+			params.Set("variation", strconv.FormatFloat(s.Variation, 'f', -1, 64))
+			if s.FromDate.Valid && s.TillDate.Valid {
+				view.Description += fmt.Sprintf(", %s to %s", s.FromDate.Time.Format("02 Jan 2006"), s.TillDate.Time.Format("02 Jan 2006"))
+				params.Set("from_date", s.FromDate.Time.Format("2006-01-02"))
+				params.Set("till_date", s.TillDate.Time.Format("2006-01-02"))
+			}
+			view.URL = "/sale-bills/search?" + params.Encode()
+		}
+		searches[i] = view
+	}
+	return searches
+}
+
+// SaveSearch saves the current narration or sale bill search under a name.
+// It is posted from the search forms and replies with a status message.
+func (h *Handler) SaveSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := strings.TrimSpace(r.FormValue("search_name"))
+	if name == "" {
+		w.Write([]byte(`<div class="error">Please enter a name for the search.</div>`))
+		return
+	}
+
+	params := sqlc.CreateSavedSearchParams{Name: name, FirmID: firmID(r.Context())}
+	if narration := strings.TrimSpace(r.FormValue("narration")); narration != "" {
+		params.Kind = savedSearchNarration
+		params.Narration = narration
+	} else {
+		amount, err := strconv.ParseFloat(r.FormValue("amount"), 64)
+		if err != nil {
+			w.Write([]byte(`<div class="error">Enter a narration or an amount to save.</div>`))
+			return
+		}
+		params.Kind = savedSearchSaleBill
+		params.Amount = amount
+		if v, err := strconv.ParseFloat(r.FormValue("variation"), 64); err == nil && v > 0 {
+			params.Variation = v
+		}
+		fromDate, fromErr := time.Parse("2006-01-02", r.FormValue("from_date"))
+		tillDate, tillErr := time.Parse("2006-01-02", r.FormValue("till_date"))
+		if fromErr == nil && tillErr == nil {
+			params.FromDate = sql.NullTime{Time: fromDate, Valid: true}
+			params.TillDate = sql.NullTime{Time: tillDate, Valid: true}
+		}
+	}
+
+	if _, err := h.queries.CreateSavedSearch(r.Context(), params); err != nil {
+		w.Write([]byte(fmt.Sprintf(`<div class="error">Error saving search: %s</div>`, html.EscapeString(err.Error()))))
+		return
+	}
+	w.Write([]byte(fmt.Sprintf(`<div class="success">Saved "%s". It is listed on the <a href="/">home page</a>.</div>`, html.EscapeString(name))))
+}
+
+// DeleteSavedSearch removes a saved search
+func (h *Handler) DeleteSavedSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid saved search ID", http.StatusBadRequest)
+		return
+	}
+	if err := h.queries.DeleteSavedSearch(r.Context(), sqlc.DeleteSavedSearchParams{ID: id, FirmID: firmID(r.Context())}); err != nil {
+		http.Error(w, "Error deleting saved search", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
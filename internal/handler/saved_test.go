@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSavedSearches(t *testing.T) {
+	h, db := newTestHandler(t)
+	save := func(form url.Values) string {
+		return serve(h, http.HandlerFunc(h.SaveSearch), postForm("/saved-searches/save", form)).Body.String()
+	}
+	home := func() string {
+		return serve(h, http.HandlerFunc(h.Home), httptest.NewRequest(http.MethodGet, "/", nil)).Body.String()
+	}
+
+	for name, form := range map[string]url.Values{
+		"no name":                     {"search_name": {" "}, "narration": {"NEFT/SHARMA"}},
+		"no narration or amount":      {"search_name": {"Sharma"}},
+		"an amount that is no number": {"search_name": {"Sharma"}, "amount": {"28,307"}},
+	} {
+		if body := save(form); !strings.Contains(body, `class="error"`) {
+			t.Errorf("saving with %s: %s", name, body)
+		}
+	}
+	if n := count(t, db, "SELECT COUNT(*) FROM saved_searches"); n != 0 {
+		t.Fatalf("refused searches saved %v searches", n)
+	}
+
+	if body := save(url.Values{"search_name": {"Sharma NEFT"}, "narration": {"NEFT/SHARMA"}}); !strings.Contains(body, `Saved "Sharma NEFT"`) {
+		t.Errorf("saving a narration search: %s", body)
+	}
+	if body := save(url.Values{"search_name": {"Disputed bill"}, "amount": {"28307"}, "variation": {"5"},
+		"from_date": {"2025-04-01"}, "till_date": {"2026-03-31"}}); !strings.Contains(body, `Saved "Disputed bill"`) {
+		t.Errorf("saving a sale bill search: %s", body)
+	}
+
+	body := home()
+	for _, want := range []string{
+		"Sharma NEFT", "Narration: NEFT/SHARMA",
+		"Disputed bill", "Sale bill ₹28307.00 ± 5.00, 01 Apr 2025 to 31 Mar 2026",
+		"/sale-bills/search?amount=28307&amp;from_date=2025-04-01&amp;till_date=2026-03-31&amp;variation=5",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("home page lacks %q:\n%s", want, body)
+		}
+	}
+
+	// The link opens the sale bill search with the saved amount filled in
+	w := serve(h, http.HandlerFunc(h.SearchSaleBills), httptest.NewRequest(http.MethodGet, "/sale-bills/search?amount=28307&variation=5&from_date=2025-04-01&till_date=2026-03-31", nil))
+	if body := w.Body.String(); !strings.Contains(body, `value="28307"`) || !strings.Contains(body, `value="2025-04-01"`) {
+		t.Errorf("saved sale bill search does not fill in its amount and dates:\n%s", body)
+	}
+
+	id := count(t, db, "SELECT id FROM saved_searches WHERE name = 'Sharma NEFT'")
+	remove := func(firm string) {
+		r := postForm("/saved-searches/delete", url.Values{"id": {strconv.FormatFloat(id, 'f', -1, 64)}})
+		r.AddCookie(&http.Cookie{Name: firmCookie, Value: firm})
+		if w := serve(h, http.HandlerFunc(h.DeleteSavedSearch), r); w.Code != http.StatusSeeOther {
+			t.Fatalf("deleting: status = %d: %s", w.Code, w.Body)
+		}
+	}
+	remove("2")
+	if n := count(t, db, "SELECT COUNT(*) FROM saved_searches"); n != 2 {
+		t.Errorf("another firm deleted a saved search")
+	}
+	remove("1")
+	if body := home(); strings.Contains(body, "Sharma NEFT") || !strings.Contains(body, "Disputed bill") {
+		t.Errorf("deleted search still listed:\n%s", body)
+	}
+}
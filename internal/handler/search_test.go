@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestLiveSearch(t *testing.T) {
+	h, db := newTestHandler(t)
+	for id := 1; id <= 7; id++ {
+		exec(t, db, `INSERT INTO parties (id, name, firm_id) VALUES (?, ?, 1)`, id, fmt.Sprintf("PARTY %d", id))
+		exec(t, db, `INSERT INTO identifiers (party_id, type, value, firm_id) VALUES (?, 'upi_vpa', ?, 1)`, id, fmt.Sprintf("SHOP%d@YBL", id))
+		exec(t, db, `INSERT INTO transactions (party_id, amount, transaction_date, payment_mode, narration, firm_id)
+			VALUES (?, 4321, '2025-04-01 00:00:00 +0000 UTC', 'UPI', 'UPI/OLD', 1)`, id)
+	}
+	search := func(form url.Values) string {
+		t.Helper()
+		w := serve(h, http.HandlerFunc(h.Search), postForm("/search", form))
+		if w.Code != http.StatusOK {
+			t.Fatalf("search: status = %d: %s", w.Code, w.Body)
+		}
+		return w.Body.String()
+	}
+
+	// Clearing the narration while typing clears the results
+	if body := search(url.Values{"narration": {""}, "live": {"1"}}); body != "" {
+		t.Errorf("live search of nothing: %s", body)
+	}
+	if body := search(url.Values{"narration": {""}}); !strings.Contains(body, "Please enter a narration") {
+		t.Errorf("search of nothing: %s", body)
+	}
+
+	// A live search lists the first five matches briefly
+	narration := "UPI/SHOP1@YBL/SHOP2@YBL/SHOP3@YBL/SHOP4@YBL/SHOP5@YBL/SHOP6@YBL/SHOP7@YBL"
+	body := search(url.Values{"narration": {narration}, "live": {"1"}})
+	if strings.Count(body, `href="/party/`) != 5 || !strings.Contains(body, "2 more.") || !strings.Contains(body, "Press Enter for full details") {
+		t.Errorf("live results do not list five of seven matches:\n%s", body)
+	}
+	if strings.Contains(body, "Recent Transactions") || strings.Contains(body, "4321.00") {
+		t.Errorf("live results show recent transactions:\n%s", body)
+	}
+	if body := search(url.Values{"narration": {"UPI/NOBODY@YBL/PAYMENT"}, "live": {"1"}}); !strings.Contains(body, "No matches yet.") {
+		t.Errorf("live search of no party:\n%s", body)
+	}
+
+	// Submitting shows every match with its recent transactions
+	body = search(url.Values{"narration": {narration}})
+	if !strings.Contains(body, "7 Matches Found") || strings.Count(body, "Recent Transactions") != 7 || !strings.Contains(body, "₹4321.00") {
+		t.Errorf("full results lack matches or their recent transactions:\n%s", body)
+	}
+}
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/parser"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// vocabularyLists maps each parser vocabulary kind stored in the database to
+// its list in a vocabulary
+func vocabularyLists(v *parser.Vocabulary) map[string]*[]string {
+	return map[string]*[]string{
+		pages.VocabularyLocations:         &v.Locations,
+		pages.VocabularyNonLocationWords:  &v.NonLocationWords,
+		pages.VocabularySkipPatterns:      &v.SkipPatterns,
+		pages.VocabularyNarrationPrefixes: &v.NarrationPrefixes,
+	}
+}
+
+// loadVocabulary reads the parser vocabulary and payment mode rules edited
+// on the settings pages
+func (h *Handler) loadVocabulary(ctx context.Context) (parser.Vocabulary, error) {
+	var v parser.Vocabulary
+	rows, err := h.queries.ListParserVocabulary(ctx)
+	if err != nil {
+		return v, fmt.Errorf("listing parser vocabulary: %w", err)
+	}
+	lists := vocabularyLists(&v)
+	for _, row := range rows {
+		if list, ok := lists[row.Kind]; ok {
+			*list = append(*list, row.Value)
+		}
+	}
+
+	modes, err := h.queries.ListPaymentModeRules(ctx)
+	if err != nil {
+		return v, fmt.Errorf("listing payment mode rules: %w", err)
+	}
+	for _, row := range modes {
+		v.PaymentModes = append(v.PaymentModes, parser.PaymentModeRule{Pattern: row.Pattern, Mode: row.Mode})
+	}
+	return v, nil
+}
+
+// loadParser creates a receipt book parser with the current vocabulary,
+// taking the firms' names as the start of a repeated page header
+func (h *Handler) loadParser(ctx context.Context) (*parser.Parser, error) {
+	v, err := h.loadVocabulary(ctx)
+	if err != nil {
+		return nil, err
+	}
+	firms, err := h.queries.ListFirms(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing firms: %w", err)
+	}
+	for _, f := range firms {
+		v.FirmNames = append(v.FirmNames, f.Name)
+	}
+	return parser.New(v)
+}
+
+// ParserSettings shows the parser vocabularies for editing, with a box to
+// test how receipt book text parses
+func (h *Handler) ParserSettings(w http.ResponseWriter, r *http.Request) {
+	v, err := h.loadVocabulary(r.Context())
+	if err != nil {
+		http.Error(w, "Error loading parser vocabulary", http.StatusInternalServerError)
+		return
+	}
+	pages.ParserSettings(v, r.URL.Query().Get("saved"), "").Render(r.Context(), w)
+}
+
+// SaveParserVocabulary replaces one of the parser's vocabulary lists with the
+// entries of a textarea, one per line
+func (h *Handler) SaveParserVocabulary(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	v, err := h.loadVocabulary(ctx)
+	if err != nil {
+		http.Error(w, "Error loading parser vocabulary", http.StatusInternalServerError)
+		return
+	}
+	kind := r.FormValue("kind")
+	list, ok := vocabularyLists(&v)[kind]
+	if !ok {
+		http.Error(w, "Unknown vocabulary", http.StatusBadRequest)
+		return
+	}
+
+	// Narration prefixes keep their spaces: "AG " must not match AGRA
+	var values []string
+	for _, line := range strings.Split(r.FormValue("values"), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if kind != pages.VocabularyNarrationPrefixes {
+			line = strings.TrimSpace(line)
+		}
+		if strings.TrimSpace(line) != "" {
+			values = append(values, line)
+		}
+	}
+	*list = values
+	if _, err := parser.New(v); err != nil {
+		pages.ParserSettings(v, "", err.Error()).Render(ctx, w)
+		return
+	}
+
+	if err := h.replaceVocabulary(ctx, kind, values); err != nil {
+		pages.ParserSettings(v, "", fmt.Sprintf("Error saving: %s", err.Error())).Render(ctx, w)
+		return
+	}
+	http.Redirect(w, r, "/settings/parser?saved="+kind, http.StatusSeeOther)
+}
+
+// replaceVocabulary replaces the entries of one vocabulary kind
+func (h *Handler) replaceVocabulary(ctx context.Context, kind string, values []string) error {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := h.queries.WithTx(tx)
+	if err := q.DeleteParserVocabularyKind(ctx, kind); err != nil {
+		return fmt.Errorf("clearing %s: %w", kind, err)
+	}
+	for _, value := range values {
+		if err := q.AddParserVocabulary(ctx, sqlc.AddParserVocabularyParams{Kind: kind, Value: value}); err != nil {
+			return fmt.Errorf("adding %q: %w", value, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// TestParse shows how receipt book text parses with the saved vocabulary
+func (h *Handler) TestParse(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	data := r.FormValue("data")
+	if strings.TrimSpace(data) == "" {
+		w.Write([]byte(`<div class="error">Please paste receipt book text to test.</div>`))
+		return
+	}
+	year := time.Now().Year()
+	if y := parser.ExtractYearFromHeader(data); y > 0 {
+		year = y
+	}
+	if y, err := strconv.Atoi(r.FormValue("year")); err == nil && y != time.Now().Year() {
+		year = y
+	}
+
+	p, err := h.loadParser(r.Context())
+	if err != nil {
+		w.Write([]byte(fmt.Sprintf(`<div class="error">Error loading parser vocabulary: %s</div>`, err.Error())))
+		return
+	}
+	transactions := p.Parse(data, year)
+	pages.ParseTestResult(transactions).Render(r.Context(), w)
+}
@@ -0,0 +1,16 @@
+package handler
+
+import (
+	"net/http"
+
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// slowQueryLimit is how many of the slowest queries the admin page lists
+const slowQueryLimit = 25
+
+// SlowQueries lists the queries that took longer than the slow query
+// threshold since the server started, the slowest first
+func (h *Handler) SlowQueries(w http.ResponseWriter, r *http.Request) {
+	pages.SlowQueries(h.slow.Threshold(), h.slow.Worst(slowQueryLimit)).Render(r.Context(), w)
+}
@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/sms"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// smsWindow is how recent a receipt must be to be acknowledged when it is
+// imported, so importing an old book doesn't text parties about payments made
+// long ago
+const smsWindow = 3 * 24 * time.Hour
+
+// smsAttempts is how many times an acknowledgement is tried before it is left
+// failed for someone to retry
+const smsAttempts = 3
+
+// queueAcknowledgement queues a text message acknowledging a receipt just
+// imported, when SMS is set up, the receipt is recent and the party has a
+// phone number
+func (h *Handler) queueAcknowledgement(ctx context.Context, partyID int64, receipt sqlc.Transaction) error {
+	if h.sms == nil || time.Since(receipt.TransactionDate) > smsWindow {
+		return nil
+	}
+	party, err := h.queries.GetPartyByID(ctx, partyID)
+	if err != nil || party.Phone == "" {
+		return err
+	}
+	if err := h.queries.QueueSMSAcknowledgement(ctx, sqlc.QueueSMSAcknowledgementParams{
+		FirmID:        party.FirmID,
+		PartyID:       party.ID,
+		TransactionID: receipt.ID,
+		Phone:         party.Phone,
+	}); err != nil {
+		return fmt.Errorf("queueing SMS acknowledgement: %w", err)
+	}
+	return nil
+}
+
+// SendAcknowledgements sends the queued text messages acknowledging
+// receipts, and reports how many went and how many failed. A failed message
+// is tried again next time, up to smsAttempts times.
+func (h *Handler) SendAcknowledgements(ctx context.Context) (sent, failed int, err error) {
+	if h.sms == nil {
+		return 0, 0, nil
+	}
+	pending, err := h.queries.ListPendingSMSAcknowledgements(ctx, smsAttempts)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, a := range pending {
+		message, err := h.acknowledgement(ctx, a)
+		if err != nil {
+			return sent, failed, err
+		}
+		sendCtx, cancel := context.WithTimeout(ctx, time.Minute)
+		sendErr := h.sms.Send(sendCtx, a.Phone, message)
+		cancel()
+		if sendErr != nil {
+			failed++
+			err = h.queries.MarkSMSAcknowledgementFailed(ctx, sqlc.MarkSMSAcknowledgementFailedParams{
+				Message: message,
+				Error:   sendErr.Error(),
+				ID:      a.ID,
+			})
+		} else {
+			sent++
+			err = h.queries.MarkSMSAcknowledgementSent(ctx, sqlc.MarkSMSAcknowledgementSentParams{Message: message, ID: a.ID})
+		}
+		if err != nil {
+			return sent, failed, err
+		}
+	}
+	return sent, failed, nil
+}
+
+// acknowledgement words the text message acknowledging a receipt, naming the
+// bills it was applied to, such as "Received ₹11,744 on 26-Dec against bill
+// DDG024782. Thank you, Durga Dawa Ghar"
+func (h *Handler) acknowledgement(ctx context.Context, a sqlc.ListPendingSMSAcknowledgementsRow) (string, error) {
+	bills, receipts, manual, err := h.partyAllocations(ctx, a.PartyID)
+	if err != nil {
+		return "", err
+	}
+	allocations := allocateReceipts(bills, receipts, manual, roundingTolerance(ctx))
+	var against []string
+	for _, b := range bills {
+		for _, alloc := range allocations[b.ID] {
+			if alloc.TransactionID == a.TransactionID {
+				against = append(against, b.BillNumber)
+				break
+			}
+		}
+	}
+
+	message := fmt.Sprintf("Received ₹%s on %s", formatIndianAmount(a.Amount), a.TransactionDate.Format("02-Jan"))
+	switch len(against) {
+	case 0:
+	case 1:
+		message += " against bill " + against[0]
+	default:
+		message += " against bills " + strings.Join(against, ", ")
+	}
+	message += ". Thank you"
+	if firm, err := h.queries.GetFirm(ctx, a.FirmID); err == nil {
+		message += ", " + firm.Name
+	}
+	return message, nil
+}
+
+// formatIndianAmount writes an amount with Indian digit grouping, such as
+// 1,23,456, leaving out the paise when there are none
+func formatIndianAmount(amount float64) string {
+	s := fmt.Sprintf("%.2f", amount)
+	whole, paise, _ := strings.Cut(s, ".")
+	sign := ""
+	if strings.HasPrefix(whole, "-") {
+		sign, whole = "-", whole[1:]
+	}
+	if len(whole) > 3 {
+		head, tail := whole[:len(whole)-3], whole[len(whole)-3:]
+		var groups []string
+		for len(head) > 2 {
+			groups = append([]string{head[len(head)-2:]}, groups...)
+			head = head[:len(head)-2]
+		}
+		whole = strings.Join(append(append([]string{head}, groups...), tail), ",")
+	}
+	if paise == "00" {
+		return sign + whole
+	}
+	return sign + whole + "." + paise
+}
+
+// smsAcknowledgementViews shows a party's acknowledgements with how each
+// stands
+func smsAcknowledgementViews(acks []sqlc.SmsAcknowledgement) []pages.SMSAcknowledgementView {
+	views := make([]pages.SMSAcknowledgementView, len(acks))
+	for i, a := range acks {
+		v := pages.SMSAcknowledgementView{
+			ID:        a.ID,
+			Phone:     a.Phone,
+			Message:   a.Message,
+			Error:     a.Error,
+			CreatedAt: a.CreatedAt.Time.Local().Format("02 Jan 2006 15:04"),
+		}
+		switch {
+		case a.SentAt.Valid:
+			v.Status = "Sent"
+		case a.Attempts >= smsAttempts:
+			v.Status = "Failed"
+			v.CanRetry = true
+		case a.Attempts > 0:
+			v.Status = "Retrying"
+		default:
+			v.Status = "Queued"
+		}
+		views[i] = v
+	}
+	return views
+}
+
+// UpdatePartyPhone saves the mobile number a party's receipts are
+// acknowledged on; an empty number stops them. The number is linked to the
+// party as an identifier.
+func (h *Handler) UpdatePartyPhone(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid party ID", http.StatusBadRequest)
+		return
+	}
+	phone := strings.TrimSpace(r.FormValue("phone"))
+	if phone != "" {
+		if phone, err = sms.Normalize(phone); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := h.queries.UpdatePartyPhone(ctx, sqlc.UpdatePartyPhoneParams{
+		Phone:  phone,
+		ID:     id,
+		FirmID: firmID(ctx),
+	}); err != nil {
+		http.Error(w, "Error saving phone", http.StatusInternalServerError)
+		return
+	}
+	if err := h.linkContactIdentifiers(ctx, id, phone, ""); err != nil {
+		http.Error(w, "Error linking phone identifier", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/party/%d", id), http.StatusSeeOther)
+}
+
+// RetrySMSAcknowledgement queues a failed acknowledgement to be tried again
+func (h *Handler) RetrySMSAcknowledgement(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid acknowledgement ID", http.StatusBadRequest)
+		return
+	}
+	partyID, _ := strconv.ParseInt(r.FormValue("party_id"), 10, 64)
+	if err := h.queries.RetrySMSAcknowledgement(ctx, sqlc.RetrySMSAcknowledgementParams{ID: id, FirmID: firmID(ctx)}); err != nil {
+		http.Error(w, "Error retrying acknowledgement", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/party/%d", partyID), http.StatusSeeOther)
+}
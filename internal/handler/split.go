@@ -0,0 +1,247 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// splitShareRows is how many shares the split form offers
+const splitShareRows = 4
+
+// firmTransaction returns the transaction of the current firm with the ID in
+// the id form value
+func (h *Handler) firmTransaction(r *http.Request) (sqlc.Transaction, error) {
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		return sqlc.Transaction{}, err
+	}
+	txn, err := h.queries.GetTransactionByID(r.Context(), id)
+	if err != nil {
+		return txn, err
+	}
+	if txn.FirmID != firmID(r.Context()) {
+		return txn, fmt.Errorf("transaction %d is of another firm", id)
+	}
+	return txn, nil
+}
+
+// renderSplit renders the split page of txn: its shares when it has been
+// split, or the form to split it, filled with shares
+func (h *Handler) renderSplit(w http.ResponseWriter, r *http.Request, txn sqlc.Transaction, shares []pages.SplitShare, formError string) {
+	ctx := r.Context()
+	view := pages.SplitView{Transaction: txn, Shares: shares, Error: formError}
+	if party, err := h.queries.GetPartyByID(ctx, txn.PartyID); err == nil {
+		view.PartyName = party.Name
+	}
+
+	if split, err := h.queries.GetTransactionSplit(ctx, txn.ID); err == nil {
+		source, err := h.queries.GetTransactionByID(ctx, split.SourceTransactionID)
+		if err != nil {
+			http.Error(w, "Error loading the split receipt", http.StatusInternalServerError)
+			return
+		}
+		view.Transaction = source
+		view.OriginalAmount = split.OriginalAmount
+		view.OriginalParty = fmt.Sprintf("party %d", split.OriginalPartyID)
+		if party, err := h.queries.GetPartyByID(ctx, split.OriginalPartyID); err == nil {
+			view.OriginalParty = party.Name
+		}
+		if view.Parts, err = h.queries.ListTransactionSplitParts(ctx, source.ID); err != nil {
+			http.Error(w, "Error loading the split receipt", http.StatusInternalServerError)
+			return
+		}
+	} else {
+		parties, err := h.queries.ListParties(ctx, firmID(ctx))
+		if err != nil {
+			http.Error(w, "Error loading parties", http.StatusInternalServerError)
+			return
+		}
+		for _, p := range parties {
+			view.Parties = append(view.Parties, pages.PartyOption{ID: p.ID, Name: p.Name, Location: p.Location.String})
+		}
+	}
+	pages.SplitTransaction(view).Render(ctx, w)
+}
+
+// TransactionSplit shows a receipt with a form to divide its amount across
+// parties, or the shares it was divided into
+func (h *Handler) TransactionSplit(w http.ResponseWriter, r *http.Request) {
+	txn, err := h.firmTransaction(r)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	// The receipt's party takes it all until the amounts are changed
+	shares := make([]pages.SplitShare, splitShareRows)
+	shares[0] = pages.SplitShare{PartyID: txn.PartyID, Amount: strconv.FormatFloat(txn.Amount, 'f', 2, 64)}
+	h.renderSplit(w, r, txn, shares, "")
+}
+
+// SplitTransaction divides a receipt lumped under one party, when the parser
+// missed a second party line, across the parties it was from. The receipt
+// keeps the first share and each other share becomes a receipt of its own
+// with the same date, mode and narration; the receipt's party and amount from
+// before are kept with the split.
+func (h *Handler) SplitTransaction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+	txn, err := h.firmTransaction(r)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if _, err := h.queries.GetTransactionSplit(ctx, txn.ID); err == nil {
+		http.Error(w, "This receipt has already been split", http.StatusConflict)
+		return
+	}
+
+	partyIDs, amounts := r.Form["party_id"], r.Form["amount"]
+	var shares []pages.SplitShare
+	for i := range partyIDs {
+		share := pages.SplitShare{Amount: strings.TrimSpace(strings.ReplaceAll(valueAt(amounts, i), ",", ""))}
+		share.PartyID, _ = strconv.ParseInt(partyIDs[i], 10, 64)
+		if share.PartyID == 0 && share.Amount == "" {
+			continue
+		}
+		shares = append(shares, share)
+	}
+	form := append(shares, make([]pages.SplitShare, max(0, splitShareRows-len(shares)))...)
+
+	parts, problem := h.splitShares(ctx, txn, shares)
+	if problem != "" {
+		h.renderSplit(w, r, txn, form, problem)
+		return
+	}
+	if err := h.splitTransaction(ctx, txn, parts); err != nil {
+		if errors.Is(err, errClosedYear) || strings.Contains(err.Error(), "closed financial year") {
+			h.renderSplit(w, r, txn, form, "The receipt is in a closed financial year; reopen it to split the receipt")
+			return
+		}
+		h.renderSplit(w, r, txn, form, "Error splitting the receipt: "+err.Error())
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/transactions/split?id=%d", txn.ID), http.StatusSeeOther)
+}
+
+// splitPart is a checked share of a split: a party of the firm and its amount
+type splitPart struct {
+	partyID int64
+	amount  float64
+}
+
+// splitShares checks the shares a receipt is split into: at least two, each
+// to a different party of the firm, each more than zero, adding up to the
+// receipt's amount. It returns the problem with them, if any.
+func (h *Handler) splitShares(ctx context.Context, txn sqlc.Transaction, shares []pages.SplitShare) ([]splitPart, string) {
+	if len(shares) < 2 {
+		return nil, "Give at least two shares to split the receipt into"
+	}
+	var parts []splitPart
+	var total float64
+	seen := make(map[int64]bool)
+	for _, s := range shares {
+		if s.PartyID == 0 {
+			return nil, "Choose a party for every share"
+		}
+		amount, err := strconv.ParseFloat(s.Amount, 64)
+		if err != nil || amount <= 0 {
+			return nil, fmt.Sprintf("Share amount %q is not a positive amount", s.Amount)
+		}
+		if seen[s.PartyID] {
+			return nil, "Give each party one share"
+		}
+		seen[s.PartyID] = true
+		party, err := h.queries.GetPartyByID(ctx, s.PartyID)
+		if err != nil || party.FirmID != txn.FirmID {
+			return nil, fmt.Sprintf("Party %d not found", s.PartyID)
+		}
+		parts = append(parts, splitPart{partyID: party.ID, amount: amount})
+		total += amount
+	}
+	if math.Round(total*100) != math.Round(txn.Amount*100) {
+		return nil, fmt.Sprintf("The shares add up to ₹%.2f, not the receipt's ₹%.2f", total, txn.Amount)
+	}
+	return parts, ""
+}
+
+// splitTransaction gives txn the first part and creates a receipt for each
+// other part, recording every part with txn's party and amount from before,
+// in one transaction. Its allocations to bills by hand are undone, since they
+// were of its party and amount.
+func (h *Handler) splitTransaction(ctx context.Context, txn sqlc.Transaction, parts []splitPart) error {
+	if err := h.checkOpenYear(ctx, txn.TransactionDate); err != nil {
+		return err
+	}
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	q := h.queries.WithTx(tx)
+
+	if err := q.ClearTransactionAllocations(ctx, txn.ID); err != nil {
+		return fmt.Errorf("undoing allocations: %w", err)
+	}
+	for i, part := range parts {
+		partID := txn.ID
+		if i == 0 {
+			err = q.SplitTransaction(ctx, sqlc.SplitTransactionParams{PartyID: part.partyID, Amount: part.amount, ID: txn.ID})
+		} else {
+			var created sqlc.Transaction
+			created, err = q.CreateTransaction(ctx, sqlc.CreateTransactionParams{
+				PartyID:          part.partyID,
+				Amount:           part.amount,
+				TransactionDate:  txn.TransactionDate,
+				PaymentMode:      txn.PaymentMode,
+				Narration:        txn.Narration,
+				CashBankCode:     txn.CashBankCode,
+				CashBankLocation: txn.CashBankLocation,
+				Category:         txn.Category,
+				IsInternal:       txn.IsInternal,
+				AccountID:        txn.AccountID,
+				FirmID:           txn.FirmID,
+			})
+			partID = created.ID
+			if err == nil {
+				err = copyTransactionAgent(ctx, q, txn.ID, partID)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("recording share %d: %w", i+1, err)
+		}
+		err = q.CreateTransactionSplit(ctx, sqlc.CreateTransactionSplitParams{
+			FirmID:              txn.FirmID,
+			SourceTransactionID: txn.ID,
+			TransactionID:       partID,
+			OriginalPartyID:     txn.PartyID,
+			OriginalAmount:      txn.Amount,
+		})
+		if err != nil {
+			return fmt.Errorf("recording share %d: %w", i+1, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// valueAt returns values[i], or "" past its end
+func valueAt(values []string, i int) string {
+	if i < len(values) {
+		return values[i]
+	}
+	return ""
+}
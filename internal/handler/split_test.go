@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestSplitTransaction(t *testing.T) {
+	h, db := newTestHandler(t)
+	exec(t, db, `INSERT INTO parties (id, name, firm_id) VALUES
+		(1, 'SHARMA MEDICAL', 1), (2, 'GUPTA STORES', 1), (3, 'VERMA AGENCIES', 1), (4, 'SHARMA MEDICAL', 2)`)
+	exec(t, db, `INSERT INTO transactions (id, party_id, amount, transaction_date, payment_mode, narration, firm_id) VALUES
+		(1, 1, 1000, '2025-04-02 00:00:00 +0000 UTC', 'NEFT', 'NEFT/SHARMA MED/GUPTA ST', 1)`)
+	split := func(parties, amounts []string) string {
+		w := serve(h, http.HandlerFunc(h.SplitTransaction), postForm("/transactions/split/save",
+			url.Values{"id": {"1"}, "party_id": parties, "amount": amounts}))
+		if w.Code == http.StatusSeeOther {
+			return ""
+		}
+		return w.Body.String()
+	}
+
+	for _, tt := range []struct {
+		name             string
+		parties, amounts []string
+		problem          string
+	}{
+		{"short of the receipt", []string{"1", "2"}, []string{"600", "399.99"}, "add up to ₹999.99"},
+		{"over the receipt", []string{"1", "2"}, []string{"600", "500"}, "add up to ₹1100.00"},
+		{"one share", []string{"1", ""}, []string{"1000", ""}, "at least two shares"},
+		{"party of another firm", []string{"1", "4"}, []string{"600", "400"}, "Party 4 not found"},
+		{"same party twice", []string{"1", "1"}, []string{"600", "400"}, "one share"},
+	} {
+		if body := split(tt.parties, tt.amounts); !strings.Contains(body, tt.problem) {
+			t.Errorf("%s: want %q, got\n%s", tt.name, tt.problem, body)
+		}
+	}
+	if n := count(t, db, "SELECT COUNT(*) FROM transactions"); n != 1 {
+		t.Fatalf("a refused split left %v receipts", n)
+	}
+
+	if body := split([]string{"1", "2", "3"}, []string{"500.50", "299.25", "200.25"}); body != "" {
+		t.Fatalf("split refused:\n%s", body)
+	}
+	if n := count(t, db, "SELECT ROUND(SUM(amount), 2) FROM transactions"); n != 1000 {
+		t.Errorf("the shares add up to %v, not the receipt's 1000", n)
+	}
+	for query, want := range map[string]float64{
+		"SELECT amount FROM transactions WHERE id = 1 AND party_id = 1":                                             500.50,
+		"SELECT amount FROM transactions WHERE party_id = 2 AND narration = 'NEFT/SHARMA MED/GUPTA ST'":             299.25,
+		"SELECT amount FROM transactions WHERE party_id = 3 AND transaction_date = '2025-04-02 00:00:00 +0000 UTC'": 200.25,
+		"SELECT COUNT(*) FROM transaction_splits WHERE source_transaction_id = 1 AND original_amount = 1000":        3,
+	} {
+		if n := count(t, db, query); n != want {
+			t.Errorf("%s = %v, want %v", query, n, want)
+		}
+	}
+
+	if w := serve(h, http.HandlerFunc(h.SplitTransaction), postForm("/transactions/split/save",
+		url.Values{"id": {"1"}, "party_id": {"1", "2"}, "amount": {"250.50", "250"}})); w.Code != http.StatusConflict {
+		t.Errorf("splitting a split receipt again: status = %d", w.Code)
+	}
+}
@@ -0,0 +1,344 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"suspense.durgadawaghar.com/internal/category"
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/views"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// Statement links are valid for defaultStatementLinkDays unless another
+// validity up to maxStatementLinkDays is chosen
+const (
+	defaultStatementLinkDays = 7
+	maxStatementLinkDays     = 90
+)
+
+// partyReceipts returns a party's receipts oldest first, leaving out bounced
+// cheques
+func (h *Handler) partyReceipts(ctx context.Context, partyID int64) ([]sqlc.Transaction, error) {
+	transactions, err := h.queries.GetTransactionsByPartyID(ctx, partyID)
+	if err != nil {
+		return nil, err
+	}
+	cheques, err := h.queries.GetChequesByPartyID(ctx, partyID)
+	if err != nil {
+		return nil, err
+	}
+	bounced := make(map[int64]bool)
+	for _, c := range cheques {
+		if c.Status == chequeBounced {
+			bounced[c.TransactionID] = true
+		}
+	}
+
+	var receipts []sqlc.Transaction
+	for _, t := range transactions {
+		if t.Category == string(category.Receipt) && !bounced[t.ID] {
+			receipts = append(receipts, t)
+		}
+	}
+	sort.SliceStable(receipts, func(i, j int) bool {
+		return receipts[i].TransactionDate.Before(receipts[j].TransactionDate)
+	})
+	return receipts, nil
+}
+
+// partyStatement builds a party's ledger: credit sale bills as debits and
+// receipts as credits, oldest first with a running balance. Entries before
+// from are brought forward as an opening balance, and those after till left
+// out; zero times leave the period open at that end.
+func (h *Handler) partyStatement(ctx context.Context, partyID int64, from, till time.Time) ([]pages.StatementEntry, error) {
+	bills, err := h.queries.GetCreditSaleBillsByPartyID(ctx, sql.NullInt64{Int64: partyID, Valid: true})
+	if err != nil {
+		return nil, err
+	}
+	receipts, err := h.partyReceipts(ctx, partyID)
+	if err != nil {
+		return nil, err
+	}
+
+	type ledgerEntry struct {
+		date   time.Time
+		entry  pages.StatementEntry
+		isBill bool
+	}
+	var entries []ledgerEntry
+	for _, b := range bills {
+		entries = append(entries, ledgerEntry{
+			date:   b.BillDate,
+			entry:  pages.StatementEntry{Particulars: "Bill " + b.BillNumber, Debit: b.Amount},
+			isBill: true,
+		})
+	}
+	for _, t := range receipts {
+		particulars := "Receipt"
+		if t.PaymentMode.Valid && t.PaymentMode.String != "" {
+			particulars += " (" + t.PaymentMode.String + ")"
+		}
+		entries = append(entries, ledgerEntry{
+			date:  t.TransactionDate,
+			entry: pages.StatementEntry{Particulars: particulars, Credit: t.Amount},
+		})
+	}
+
+	// Bills come before receipts of the same day
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].date.Equal(entries[j].date) {
+			return entries[i].date.Before(entries[j].date)
+		}
+		return entries[i].isBill && !entries[j].isBill
+	})
+
+	balance := 0.0
+	var statement []pages.StatementEntry
+	for _, e := range entries {
+		if !till.IsZero() && e.date.After(till) {
+			break
+		}
+		balance += e.entry.Debit - e.entry.Credit
+		if e.date.Before(from) {
+			continue
+		}
+		if len(statement) == 0 && !from.IsZero() {
+			statement = append(statement, openingEntry(from, balance-e.entry.Debit+e.entry.Credit))
+		}
+		e.entry.Date = e.date.Format("02 Jan 2006")
+		e.entry.Balance = balance
+		statement = append(statement, e.entry)
+	}
+	if len(statement) == 0 && !from.IsZero() {
+		statement = append(statement, openingEntry(from, balance))
+	}
+	return statement, nil
+}
+
+// openingEntry is the line a statement from a date opens with, carrying the
+// balance of the entries before it
+func openingEntry(from time.Time, balance float64) pages.StatementEntry {
+	entry := pages.StatementEntry{Date: from.Format("02 Jan 2006"), Particulars: "Opening balance", Balance: balance}
+	if balance > 0 {
+		entry.Debit = balance
+	} else {
+		entry.Credit = -balance
+	}
+	return entry
+}
+
+// statementLinkURL returns the absolute URL of a statement link as seen by the
+// client, so it can be pasted into a message
+func statementLinkURL(r *http.Request, token string) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s/s/%s", scheme, r.Host, token)
+}
+
+// statementMessage is the message a statement link is sent to a party in
+func statementMessage(firmName, linkURL string, expiresAt time.Time) string {
+	return fmt.Sprintf("Statement of account from %s: %s\nPDF: %s.pdf\nThe link works till %s.",
+		firmName, linkURL, linkURL, expiresAt.Format("02 Jan 2006"))
+}
+
+// whatsAppURL opens a WhatsApp chat with text typed in: to a party's mobile
+// when it is known, and otherwise to a contact chosen in WhatsApp
+func whatsAppURL(phone, text string) string {
+	if phone != "" {
+		return "https://wa.me/91" + phone + "?text=" + url.QueryEscape(text)
+	}
+	return "https://wa.me/?text=" + url.QueryEscape(text)
+}
+
+// statementLinks lists a party's unexpired statement links, each with a
+// WhatsApp message to the party's phone carrying it
+func (h *Handler) statementLinks(r *http.Request, partyID int64, phone string) []pages.StatementLinkView {
+	links, _ := h.queries.ListActiveStatementLinks(r.Context(), sqlc.ListActiveStatementLinksParams{
+		PartyID:   partyID,
+		ExpiresAt: time.Now(),
+	})
+	firm := views.CurrentFirm(r.Context())
+	linkViews := make([]pages.StatementLinkView, len(links))
+	for i, l := range links {
+		linkURL := statementLinkURL(r, l.Token)
+		linkViews[i] = pages.StatementLinkView{
+			ID:          l.ID,
+			URL:         linkURL,
+			ExpiresAt:   l.ExpiresAt.Format("02 Jan 2006 15:04"),
+			WhatsAppURL: whatsAppURL(phone, statementMessage(firm.Name, linkURL, l.ExpiresAt)),
+		}
+	}
+	return linkViews
+}
+
+// ShareStatement creates a time-limited public link to a party's statement
+func (h *Handler) ShareStatement(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	partyID, err := strconv.ParseInt(r.FormValue("party_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid party ID", http.StatusBadRequest)
+		return
+	}
+	days := defaultStatementLinkDays
+	if d, err := strconv.Atoi(r.FormValue("days")); err == nil && d > 0 && d <= maxStatementLinkDays {
+		days = d
+	}
+
+	if _, err := h.createStatementLink(r.Context(), partyID, days); err != nil {
+		http.Error(w, "Error creating link", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/party/%d", partyID), http.StatusSeeOther)
+}
+
+// createStatementLink creates a public link to a party's statement that
+// expires after days
+func (h *Handler) createStatementLink(ctx context.Context, partyID int64, days int) (sqlc.StatementLink, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return sqlc.StatementLink{}, err
+	}
+	return h.queries.CreateStatementLink(ctx, sqlc.CreateStatementLinkParams{
+		PartyID:   partyID,
+		Token:     hex.EncodeToString(b),
+		ExpiresAt: time.Now().AddDate(0, 0, days),
+	})
+}
+
+// WhatsAppStatement creates a statement link for a party and opens WhatsApp
+// with a message carrying it and its PDF, addressed to the party's mobile
+func (h *Handler) WhatsAppStatement(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	partyID, err := strconv.ParseInt(r.FormValue("party_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid party ID", http.StatusBadRequest)
+		return
+	}
+	party, err := h.queries.GetPartyByID(ctx, partyID)
+	if err != nil || party.FirmID != firmID(ctx) {
+		http.NotFound(w, r)
+		return
+	}
+
+	link, err := h.createStatementLink(ctx, party.ID, defaultStatementLinkDays)
+	if err != nil {
+		http.Error(w, "Error creating link", http.StatusInternalServerError)
+		return
+	}
+	message := statementMessage(views.CurrentFirm(ctx).Name, statementLinkURL(r, link.Token), link.ExpiresAt)
+	http.Redirect(w, r, whatsAppURL(party.Phone, message), http.StatusSeeOther)
+}
+
+// RevokeStatementLink deletes a statement link before it expires
+func (h *Handler) RevokeStatementLink(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid link ID", http.StatusBadRequest)
+		return
+	}
+	partyID, err := strconv.ParseInt(r.FormValue("party_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid party ID", http.StatusBadRequest)
+		return
+	}
+	if err := h.queries.DeleteStatementLink(r.Context(), id); err != nil {
+		http.Error(w, "Error revoking link", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/party/%d", partyID), http.StatusSeeOther)
+}
+
+// PublicStatement renders a party's statement for a shared link, or as a PDF
+// when the link ends in .pdf. It shows only that party's ledger and nothing
+// else of the app.
+func (h *Handler) PublicStatement(w http.ResponseWriter, r *http.Request) {
+	token, asPDF := strings.CutSuffix(r.URL.Path[len("/s/"):], ".pdf")
+	ctx := r.Context()
+
+	link, err := h.queries.GetStatementLinkByToken(ctx, token)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if time.Now().After(link.ExpiresAt) {
+		w.WriteHeader(http.StatusGone)
+		pages.StatementExpired().Render(ctx, w)
+		return
+	}
+
+	party, err := h.queries.GetPartyByID(ctx, link.PartyID)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	entries, err := h.partyStatement(ctx, party.ID, time.Time{}, time.Time{})
+	if err != nil {
+		http.Error(w, "Error loading statement", http.StatusInternalServerError)
+		return
+	}
+
+	ctx = h.withPartyFirm(ctx, party)
+	w.Header().Set("X-Robots-Tag", "noindex")
+	if asPDF {
+		now := time.Now()
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", statementFilename(party, time.Time{}, now)))
+		w.Write(statementPDF(views.CurrentFirm(ctx), party, time.Time{}, now, entries))
+		return
+	}
+	pages.PublicStatement(party, r.URL.Path+".pdf", entries, time.Now().Format("02 Jan 2006")).Render(ctx, w)
+}
+
+// PrintStatement renders a party's statement laid out for printing on A4
+func (h *Handler) PrintStatement(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.URL.Path[len("/print/statement/"):], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid party ID", http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+
+	party, err := h.queries.GetPartyByID(ctx, id)
+	if err != nil {
+		if survivor, ok := h.survivingParty(ctx, id); ok {
+			http.Redirect(w, r, fmt.Sprintf("/print/statement/%d", survivor), http.StatusMovedPermanently)
+			return
+		}
+		http.NotFound(w, r)
+		return
+	}
+	entries, err := h.partyStatement(ctx, party.ID, time.Time{}, time.Time{})
+	if err != nil {
+		http.Error(w, "Error loading statement", http.StatusInternalServerError)
+		return
+	}
+
+	ctx = h.withPartyFirm(ctx, party)
+	pages.PrintStatement(party, entries, time.Now().Format("02 Jan 2006")).Render(ctx, w)
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatementLink(t *testing.T) {
+	h, db := newTestHandler(t)
+	exec(t, db, `INSERT INTO parties (id, name, firm_id) VALUES (1, 'SHARMA MEDICAL', 1), (2, 'GUPTA STORES', 1)`)
+	exec(t, db, `INSERT INTO sale_bills (bill_number, bill_date, party_name, amount, is_cash_sale, party_id, firm_id) VALUES
+		('A-1', '2025-04-01 00:00:00 +0000 UTC', 'SHARMA MEDICAL', 1000, FALSE, 1, 1),
+		('A-2', '2025-04-01 00:00:00 +0000 UTC', 'GUPTA STORES', 800, FALSE, 2, 1)`)
+	exec(t, db, `INSERT INTO transactions (party_id, amount, transaction_date, payment_mode, narration, firm_id)
+		VALUES (1, 400, '2025-04-05 00:00:00 +0000 UTC', 'UPI', 'UPI/1', 1)`)
+	public := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.PublicStatement(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	w := serve(h, http.HandlerFunc(h.ShareStatement), postForm("/party/statement/share", url.Values{"party_id": {"1"}, "days": {"365"}}))
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("sharing: status = %d: %s", w.Code, w.Body)
+	}
+	var id int64
+	var token string
+	var expiresAt time.Time
+	if err := db.QueryRow("SELECT id, token, expires_at FROM statement_links WHERE party_id = 1").Scan(&id, &token, &expiresAt); err != nil {
+		t.Fatal(err)
+	}
+	// a validity beyond the longest allowed gets the default
+	if days := time.Until(expiresAt).Hours() / 24; days < defaultStatementLinkDays-1 || days > defaultStatementLinkDays {
+		t.Errorf("link valid for %.1f days, want %d", days, defaultStatementLinkDays)
+	}
+
+	w = public("/s/" + token)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	if body := w.Body.String(); !strings.Contains(body, "SHARMA MEDICAL") || !strings.Contains(body, "Bill A-1") || strings.Contains(body, "A-2") {
+		t.Errorf("link does not show the party's own statement:\n%s", body)
+	}
+	if w.Header().Get("X-Robots-Tag") != "noindex" {
+		t.Errorf("statement may be indexed")
+	}
+	if w := public("/s/" + token + ".pdf"); w.Code != http.StatusOK || !strings.HasPrefix(w.Body.String(), "%PDF") {
+		t.Errorf("PDF: status = %d, content type %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if w := public("/s/" + strings.Repeat("0", len(token))); w.Code != http.StatusNotFound {
+		t.Errorf("unknown token: status = %d", w.Code)
+	}
+
+	exec(t, db, "UPDATE statement_links SET expires_at = ? WHERE id = ?", time.Now().Add(-time.Minute), id)
+	if w := public("/s/" + token); w.Code != http.StatusGone || strings.Contains(w.Body.String(), "Bill A-1") {
+		t.Errorf("expired link: status = %d:\n%s", w.Code, w.Body)
+	}
+
+	exec(t, db, "UPDATE statement_links SET expires_at = ? WHERE id = ?", time.Now().Add(time.Hour), id)
+	w = serve(h, http.HandlerFunc(h.RevokeStatementLink), postForm("/party/statement/revoke", url.Values{"id": {strconv.FormatInt(id, 10)}, "party_id": {"1"}}))
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("revoking: status = %d: %s", w.Code, w.Body)
+	}
+	if w := public("/s/" + token); w.Code != http.StatusNotFound {
+		t.Errorf("revoked link: status = %d", w.Code)
+	}
+	if err := db.QueryRow("SELECT id FROM statement_links").Scan(&id); err != sql.ErrNoRows {
+		t.Errorf("revoked link left in the database: %v", err)
+	}
+}
+
+func TestPrintStatement(t *testing.T) {
+	h, db := newTestHandler(t)
+	exec(t, db, "UPDATE firms SET gstin = '09AAAAA0000A1Z5' WHERE id = 2")
+	exec(t, db, `INSERT INTO parties (id, name, location, firm_id) VALUES (1, 'VERMA AGENCIES', 'UNNAO', 2)`)
+	exec(t, db, `INSERT INTO sale_bills (bill_number, bill_date, party_name, amount, is_cash_sale, party_id, firm_id)
+		VALUES ('B-1', '2025-04-01 00:00:00 +0000 UTC', 'VERMA AGENCIES', 1000, FALSE, 1, 2)`)
+	exec(t, db, `INSERT INTO transactions (party_id, amount, transaction_date, payment_mode, narration, firm_id)
+		VALUES (1, 400, '2025-04-05 00:00:00 +0000 UTC', 'UPI', 'UPI/1', 2)`)
+
+	w := serve(h, http.HandlerFunc(h.PrintStatement), httptest.NewRequest(http.MethodGet, "/print/statement/1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		// headed by the party's firm, whichever firm is being worked in
+		"<h1>Durga Pharma</h1>", "GSTIN 09AAAAA0000A1Z5",
+		"Statement of Account: VERMA AGENCIES", "size: A4", "counter(page)",
+		"Bill B-1", "1000.00 Dr", "Receipt (UPI)", "600.00 Dr",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("printed statement lacks %q:\n%s", want, body)
+		}
+	}
+
+	if w := serve(h, http.HandlerFunc(h.PrintStatement), httptest.NewRequest(http.MethodGet, "/print/statement/2", nil)); w.Code != http.StatusNotFound {
+		t.Errorf("unknown party: status = %d", w.Code)
+	}
+}
+
+func TestWhatsAppStatement(t *testing.T) {
+	h, db := newTestHandler(t)
+	exec(t, db, `INSERT INTO parties (id, name, phone, firm_id) VALUES
+		(1, 'SHARMA MEDICAL', '9839012345', 1), (2, 'GUPTA STORES', '', 1), (3, 'VERMA AGENCIES', '9839054321', 2)`)
+	share := func(partyID string) *httptest.ResponseRecorder {
+		return serve(h, http.HandlerFunc(h.WhatsAppStatement), postForm("/party/statement/whatsapp", url.Values{"party_id": {partyID}}))
+	}
+
+	// The message goes to the party's mobile with the link and its PDF
+	w := share("1")
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	var token string
+	if err := db.QueryRow("SELECT token FROM statement_links WHERE party_id = 1").Scan(&token); err != nil {
+		t.Fatal(err)
+	}
+	to, text, _ := strings.Cut(w.Header().Get("Location"), "?text=")
+	message, err := url.QueryUnescape(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	link := "http://example.com/s/" + token
+	if to != "https://wa.me/919839012345" || !strings.HasPrefix(message, "Statement of account from Durga Dawa Ghar: "+link+"\nPDF: "+link+".pdf\n") {
+		t.Errorf("WhatsApp to %s:\n%s", to, message)
+	}
+
+	// The party page offers the same message for the link
+	w = serve(h, http.HandlerFunc(h.PartyDetail), httptest.NewRequest(http.MethodGet, "/party/1", nil))
+	if !strings.Contains(w.Body.String(), "https://wa.me/919839012345?text=") {
+		t.Errorf("party page has no WhatsApp message for its link:\n%s", w.Body)
+	}
+
+	// Without a mobile the contact is chosen in WhatsApp
+	if w := share("2"); !strings.HasPrefix(w.Header().Get("Location"), "https://wa.me/?text=") {
+		t.Errorf("party without a mobile: redirected to %s", w.Header().Get("Location"))
+	}
+	if w := share("3"); w.Code != http.StatusNotFound {
+		t.Errorf("party of another firm: status = %d", w.Code)
+	}
+	if n := count(t, db, "SELECT COUNT(*) FROM statement_links WHERE party_id = 3"); n != 0 {
+		t.Errorf("another firm's party got %v links", n)
+	}
+}
@@ -0,0 +1,278 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/mailer"
+	"suspense.durgadawaghar.com/internal/pdf"
+	"suspense.durgadawaghar.com/internal/views"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// statementRows is how many ledger lines fit on a page of a PDF statement
+const statementRows = 40
+
+// statementPeriod reads the period of a statement to send, the previous month
+// when none is given
+func statementPeriod(r *http.Request) (from, till time.Time) {
+	from, till = previousMonth(time.Now())
+	if parsed, err := time.Parse("2006-01-02", r.FormValue("from_date")); err == nil {
+		from = parsed
+	}
+	if parsed, err := time.Parse("2006-01-02", r.FormValue("till_date")); err == nil {
+		till = parsed
+	}
+	return from, till
+}
+
+// previousMonth returns the first and last days of the month before now's
+func previousMonth(now time.Time) (from, till time.Time) {
+	first := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return first.AddDate(0, -1, 0), first.AddDate(0, 0, -1)
+}
+
+// statementPDF lays out a party's statement for a period as an A4 PDF, the
+// firm and column headings repeated on each page. A zero from starts it at the
+// party's first entry.
+func statementPDF(firm views.Firm, party sqlc.Party, from, till time.Time, entries []pages.StatementEntry) []byte {
+	const (
+		left  = 40.0
+		right = pdf.PageWidth - 40
+		size  = 9.0
+	)
+	// Right edges of the amount columns
+	debit, credit, balance := right-170, right-85, right
+
+	pageCount := max(1, (len(entries)+statementRows-1)/statementRows)
+	name := party.Name
+	if party.Location.Valid && party.Location.String != "" {
+		name += " (" + party.Location.String + ")"
+	}
+	period := from.Format("02 Jan 2006") + " to " + till.Format("02 Jan 2006")
+	if from.IsZero() {
+		period = "Up to " + till.Format("02 Jan 2006")
+	}
+
+	d := pdf.New()
+	for page := 0; page < pageCount; page++ {
+		d.AddPage()
+		d.Text(left, 50, 14, pdf.Bold, firm.Name)
+		if firm.GSTIN != "" {
+			d.TextRight(right, 50, size, pdf.Regular, "GSTIN: "+firm.GSTIN)
+		}
+		d.Text(left, 72, 11, pdf.Bold, pdf.Fit("Statement of Account: "+name, right-left, 11, pdf.Bold))
+		y := 88.0
+		if party.Address != "" {
+			d.Text(left, y, size, pdf.Regular, pdf.Fit(party.Address, right-left, size, pdf.Regular))
+			y += 12
+		}
+		if registrations := pages.PartyRegistrations(party); len(registrations) > 0 {
+			d.Text(left, y, size, pdf.Regular, pdf.Fit(strings.Join(registrations, "   "), right-left, size, pdf.Regular))
+			y += 12
+		}
+		d.Text(left, y, size, pdf.Regular, "Period: "+period)
+
+		y += 24
+		d.Text(left, y, size, pdf.Bold, "Date")
+		d.Text(left+70, y, size, pdf.Bold, "Particulars")
+		d.TextRight(debit, y, size, pdf.Bold, "Debit")
+		d.TextRight(credit, y, size, pdf.Bold, "Credit")
+		d.TextRight(balance, y, size, pdf.Bold, "Balance")
+		d.Line(left, y+5, right, y+5)
+
+		end := min(len(entries), (page+1)*statementRows)
+		for _, e := range entries[page*statementRows : end] {
+			y += 16
+			d.Text(left, y, size, pdf.Regular, e.Date)
+			d.Text(left+70, y, size, pdf.Regular, pdf.Fit(e.Particulars, debit-left-140, size, pdf.Regular))
+			if e.Debit != 0 {
+				d.TextRight(debit, y, size, pdf.Regular, fmt.Sprintf("%.2f", e.Debit))
+			}
+			if e.Credit != 0 {
+				d.TextRight(credit, y, size, pdf.Regular, fmt.Sprintf("%.2f", e.Credit))
+			}
+			d.TextRight(balance, y, size, pdf.Regular, statementBalance(e.Balance))
+		}
+		if page == pageCount-1 {
+			closing := 0.0
+			if len(entries) > 0 {
+				closing = entries[len(entries)-1].Balance
+			}
+			d.Line(left, y+6, right, y+6)
+			d.Text(left+70, y+20, size, pdf.Bold, "Closing balance (Rs.)")
+			d.TextRight(balance, y+20, size, pdf.Bold, statementBalance(closing))
+		}
+		d.TextRight(right, pdf.PageHeight-30, 8, pdf.Regular, fmt.Sprintf("Page %d of %d", page+1, pageCount))
+	}
+
+	var buf bytes.Buffer
+	d.WriteTo(&buf)
+	return buf.Bytes()
+}
+
+// statementBalance shows a balance as due from the party (Dr) or in its
+// favour (Cr)
+func statementBalance(balance float64) string {
+	switch {
+	case balance > 0.005:
+		return fmt.Sprintf("%.2f Dr", balance)
+	case balance < -0.005:
+		return fmt.Sprintf("%.2f Cr", -balance)
+	}
+	return "0.00"
+}
+
+// statementFilename names a party's PDF statement for a period, or up to till
+// for one from the party's first entry
+func statementFilename(party sqlc.Party, from, till time.Time) string {
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '-'
+	}, party.Name)
+	if from.IsZero() {
+		return fmt.Sprintf("statement-%s-%s.pdf", name, till.Format("20060102"))
+	}
+	return fmt.Sprintf("statement-%s-%s-%s.pdf", name, from.Format("20060102"), till.Format("20060102"))
+}
+
+// loadStatementPDF loads a party of the current firm and its PDF statement for
+// the requested period
+func (h *Handler) loadStatementPDF(ctx context.Context, r *http.Request, partyID int64) (sqlc.Party, time.Time, time.Time, []byte, error) {
+	from, till := statementPeriod(r)
+	party, err := h.queries.GetPartyByID(ctx, partyID)
+	if err != nil {
+		return party, from, till, nil, err
+	}
+	entries, err := h.partyStatement(ctx, party.ID, from, till)
+	if err != nil {
+		return party, from, till, nil, err
+	}
+	firm := views.CurrentFirm(h.withPartyFirm(ctx, party))
+	return party, from, till, statementPDF(firm, party, from, till, entries), nil
+}
+
+// StatementPDF downloads a party's statement for a period as a PDF
+func (h *Handler) StatementPDF(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid party ID", http.StatusBadRequest)
+		return
+	}
+	party, from, till, data, err := h.loadStatementPDF(r.Context(), r, id)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", statementFilename(party, from, till)))
+	w.Write(data)
+}
+
+// UpdatePartyEmail saves the address a party's statements are emailed to;
+// an empty address stops them
+func (h *Handler) UpdatePartyEmail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid party ID", http.StatusBadRequest)
+		return
+	}
+	email := strings.TrimSpace(r.FormValue("email"))
+	if email != "" {
+		address, err := mail.ParseAddress(email)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("%q is not an email address", email), http.StatusBadRequest)
+			return
+		}
+		email = address.Address
+	}
+
+	if err := h.queries.UpdatePartyEmail(ctx, sqlc.UpdatePartyEmailParams{
+		Email:  email,
+		ID:     id,
+		FirmID: firmID(ctx),
+	}); err != nil {
+		http.Error(w, "Error saving email", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/party/%d", id), http.StatusSeeOther)
+}
+
+// EmailStatement emails a party its PDF statement for a period, and logs the
+// attempt whether or not it went through
+func (h *Handler) EmailStatement(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.mail == nil {
+		http.Error(w, "Email is not set up: start the server with -smtp-url", http.StatusServiceUnavailable)
+		return
+	}
+	ctx := r.Context()
+
+	id, err := strconv.ParseInt(r.FormValue("party_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid party ID", http.StatusBadRequest)
+		return
+	}
+	party, from, till, data, err := h.loadStatementPDF(ctx, r, id)
+	if err != nil || party.FirmID != firmID(ctx) {
+		http.NotFound(w, r)
+		return
+	}
+	if party.Email == "" {
+		http.Error(w, "Save the party's email address first", http.StatusBadRequest)
+		return
+	}
+	if till.Before(from) {
+		http.Error(w, "The period ends before it starts", http.StatusBadRequest)
+		return
+	}
+
+	firm := views.CurrentFirm(ctx)
+	period := from.Format("02 Jan 2006") + " to " + till.Format("02 Jan 2006")
+	sendErr := h.mail.Send(mailer.Message{
+		To:      party.Email,
+		Subject: fmt.Sprintf("Statement of account from %s, %s", firm.Name, period),
+		Body: fmt.Sprintf("Dear %s,\n\nPlease find attached your statement of account for %s.\n\nRegards,\n%s\n",
+			party.Name, period, firm.Name),
+		Attachments: []mailer.Attachment{{
+			Filename:    statementFilename(party, from, till),
+			ContentType: "application/pdf",
+			Data:        data,
+		}},
+	})
+	logged := sqlc.CreateStatementEmailParams{
+		FirmID:   party.FirmID,
+		PartyID:  party.ID,
+		Email:    party.Email,
+		FromDate: from,
+		TillDate: till,
+	}
+	if sendErr != nil {
+		log.Printf("Emailing statement to %s: %v", party.Email, sendErr)
+		logged.Error = sendErr.Error()
+	}
+	if err := h.queries.CreateStatementEmail(ctx, logged); err != nil {
+		http.Error(w, "Error logging email", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/party/%d", id), http.StatusSeeOther)
+}
@@ -0,0 +1,325 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/views"
+)
+
+// Kinds of work the browser queues while offline
+const (
+	syncKindImport    = "import"
+	syncKindSaleBills = "sale-bills"
+	syncKindTags      = "tags"
+)
+
+// syncResult tells the browser what became of a queued item. An item whose
+// client ID was already synced is reported as a duplicate and not applied
+// again.
+type syncResult struct {
+	Status  string `json:"status"` // synced, duplicate or error
+	Message string `json:"message"`
+}
+
+// Sync applies one item from the browser's offline queue: a receipt book or
+// sale bill import, or a transaction's tags and note. Items carry an ID made
+// by the browser so that one replayed after a lost response is skipped.
+func (h *Handler) Sync(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+	if err := readImportForm(w, r); err != nil {
+		writeSyncResult(w, http.StatusBadRequest, syncResult{Status: "error", Message: err.Error()})
+		return
+	}
+
+	clientID := strings.TrimSpace(r.FormValue("client_id"))
+	kind := r.FormValue("kind")
+	if clientID == "" {
+		writeSyncResult(w, http.StatusBadRequest, syncResult{Status: "error", Message: "missing client ID"})
+		return
+	}
+	// Items are applied in the firm they were queued in, whichever is
+	// selected now
+	if id, err := strconv.ParseInt(r.FormValue("firm_id"), 10, 64); err == nil {
+		for _, f := range views.Firms(ctx) {
+			if f.ID == id {
+				ctx = views.WithFirm(ctx, f, views.Firms(ctx))
+			}
+		}
+	}
+	if prev, err := h.queries.GetSyncLogByClientID(ctx, clientID); err == nil {
+		writeSyncResult(w, http.StatusOK, syncResult{Status: "duplicate", Message: "Already synced: " + prev.Summary})
+		return
+	}
+
+	var summary string
+	var err error
+	switch kind {
+	case syncKindImport:
+		summary, err = h.syncImport(ctx, r)
+	case syncKindSaleBills:
+		summary, err = h.syncSaleBills(ctx, r)
+	case syncKindTags:
+		summary, err = h.syncTags(ctx, r)
+	default:
+		err = fmt.Errorf("unknown kind %q", kind)
+	}
+	if err != nil {
+		writeSyncResult(w, http.StatusOK, syncResult{Status: "error", Message: err.Error()})
+		return
+	}
+
+	// The item is applied even if logging it fails; only a replay would
+	// notice
+	_ = h.queries.CreateSyncLog(ctx, sqlc.CreateSyncLogParams{
+		ClientID: clientID,
+		Kind:     kind,
+		Summary:  summary,
+	})
+	writeSyncResult(w, http.StatusOK, syncResult{Status: "synced", Message: summary})
+}
+
+func writeSyncResult(w http.ResponseWriter, status int, res syncResult) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(res)
+}
+
+// syncImport imports queued receipt book text. Entries imported meanwhile,
+// from another device or an earlier sync, are skipped as duplicates.
+func (h *Handler) syncImport(ctx context.Context, r *http.Request) (string, error) {
+	year := time.Now().Year()
+	if y, err := strconv.Atoi(r.FormValue("year")); err == nil {
+		year = y
+	}
+	if err := checkImportText(r.FormValue("data"), year); err != nil {
+		return "", err
+	}
+	s, err := h.importReceiptBook(ctx, r.FormValue("data"), year)
+	if err != nil {
+		return "", fmt.Errorf("importing: %w", err)
+	}
+
+	msg := fmt.Sprintf("Receipt book: %d imported", s.Imported)
+	if s.NonReceipts > 0 {
+		msg += fmt.Sprintf(", %d non-receipt entries", s.NonReceipts)
+	}
+	if s.POSSettlements > 0 {
+		msg += fmt.Sprintf(", %d card settlements", s.POSSettlements)
+	}
+	if s.Duplicates > 0 {
+		msg += fmt.Sprintf(", %d already imported (skipped)", s.Duplicates)
+	}
+	if s.Conflicts > 0 {
+		msg += fmt.Sprintf(", %d identifier conflicts to resolve", s.Conflicts)
+	}
+	if len(s.Errors) > 0 {
+		msg += fmt.Sprintf(", %d failed: %s", len(s.Errors), strings.Join(s.Errors, "; "))
+	}
+	return msg, nil
+}
+
+// syncSaleBills imports queued sale bills; bill numbers imported meanwhile
+// are skipped as duplicates
+func (h *Handler) syncSaleBills(ctx context.Context, r *http.Request) (string, error) {
+	bills, unread, err := parseSaleBillSource(saleBillSource(r))
+	if err != nil {
+		return "", err
+	}
+	s, err := h.importSaleBills(ctx, bills)
+	if err != nil {
+		return "", fmt.Errorf("importing: %w", err)
+	}
+
+	msg := fmt.Sprintf("Sale bills: %d imported", s.Imported)
+	if s.Duplicates > 0 {
+		msg += fmt.Sprintf(", %d already imported (skipped)", s.Duplicates)
+	}
+	if s.Unlinked > 0 {
+		msg += fmt.Sprintf(", %d not linked to a party", s.Unlinked)
+	}
+	if s.Completed > 0 {
+		msg += fmt.Sprintf(", %d given their IRN or tax breakup", s.Completed)
+	}
+	if s.Series.Gaps > 0 || s.Series.OutOfOrder > 0 {
+		msg += fmt.Sprintf(", %d bill numbers missing in the series and %d bills dated out of order", s.Series.Missing, s.Series.OutOfOrder)
+	}
+	if len(s.Errors) > 0 {
+		msg += fmt.Sprintf(", %d failed: %s", len(s.Errors), strings.Join(s.Errors, "; "))
+	}
+	// A queued import may never have been previewed, so the lines it could
+	// not read are named here
+	if len(unread) > 0 {
+		msg += fmt.Sprintf(", %d lines not read: %s", len(unread), strings.Join(unread, "; "))
+	}
+	return msg, nil
+}
+
+// syncTags sets a transaction's queued tags and note. The queued edit
+// replaces whatever the transaction has, as an edit made online would.
+func (h *Handler) syncTags(ctx context.Context, r *http.Request) (string, error) {
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid transaction ID")
+	}
+	txn, err := h.queries.GetTransactionByID(ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("transaction %d no longer exists", id)
+	}
+
+	tags := parseTags(r.FormValue("tags"))
+	if err := h.setTransactionTags(ctx, id, tags, strings.TrimSpace(r.FormValue("note"))); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Tags for ₹%.2f on %s: %s", txn.Amount, txn.TransactionDate.Format("02 Jan 2006"), strings.Join(tags, ", ")), nil
+}
+
+// offlineScript queues forms marked with data-offline-kind while the browser
+// is offline, in local storage, and sends them to /sync one by one when the
+// connection returns. The outcome of each is shown in the #offline-queue
+// banner.
+const offlineScript = `(function() {
+  const QUEUE = 'offlineQueue';
+  const RESULTS = 'offlineResults';
+
+  function load(key) { return JSON.parse(localStorage.getItem(key) || '[]'); }
+  function store(key, items) { localStorage.setItem(key, JSON.stringify(items)); render(); }
+
+  function newID() {
+    if (window.crypto && crypto.randomUUID) return crypto.randomUUID();
+    return Date.now().toString(36) + Math.random().toString(36).slice(2);
+  }
+
+  function enqueue(form) {
+    const params = new URLSearchParams(new FormData(form));
+    params.set('kind', form.dataset.offlineKind);
+    params.set('firm_id', document.body.dataset.firm || '');
+    const body = params.toString();
+    const queue = load(QUEUE);
+    // Pasting and then pressing the button queues the same form twice
+    if (queue.some(function(item) { return item.body === body; })) return;
+    params.set('client_id', newID());
+    queue.push({
+      label: form.dataset.offlineLabel || form.dataset.offlineKind,
+      body: params.toString(),
+      compare: body,
+      queuedAt: new Date().toLocaleString()
+    });
+    store(QUEUE, queue);
+  }
+
+  let syncing = false;
+  async function sync() {
+    if (syncing || !navigator.onLine) return;
+    syncing = true;
+    try {
+      let queue = load(QUEUE);
+      while (queue.length > 0) {
+        const item = queue[0];
+        let res;
+        try {
+          res = await fetch('/sync', {
+            method: 'POST',
+            headers: {'Content-Type': 'application/x-www-form-urlencoded', 'X-CSRF-Token': document.body.dataset.csrf || ''},
+            body: item.body
+          });
+        } catch (e) {
+          return; // still offline; try again later
+        }
+        if (res.status >= 500) return;
+        const result = await res.json();
+        const results = load(RESULTS);
+        results.unshift({label: item.label, queuedAt: item.queuedAt, status: result.status, message: result.message});
+        localStorage.setItem(RESULTS, JSON.stringify(results.slice(0, 20)));
+        queue = load(QUEUE).filter(function(q) { return q.body !== item.body; });
+        store(QUEUE, queue);
+      }
+    } finally {
+      syncing = false;
+      render();
+    }
+  }
+
+  function render() {
+    const el = document.getElementById('offline-queue');
+    if (!el) return;
+    const queue = load(QUEUE);
+    const results = load(RESULTS);
+    el.replaceChildren();
+    if (!navigator.onLine) {
+      const p = document.createElement('p');
+      p.className = 'error';
+      p.textContent = 'You are offline. Imports and tag changes will be queued and sent when the connection returns.';
+      el.append(p);
+    }
+    if (queue.length === 0 && results.length === 0) return;
+    const details = document.createElement('details');
+    details.open = queue.length > 0;
+    const summary = document.createElement('summary');
+    summary.textContent = queue.length + ' queued, ' + results.length + ' synced recently';
+    details.append(summary);
+    const list = document.createElement('ul');
+    queue.forEach(function(item) {
+      const li = document.createElement('li');
+      li.textContent = 'Waiting: ' + item.label + ' (queued ' + item.queuedAt + ')';
+      list.append(li);
+    });
+    results.forEach(function(r) {
+      const li = document.createElement('li');
+      li.className = 'sync-' + r.status;
+      li.textContent = r.label + ' (queued ' + r.queuedAt + '): ' + r.message;
+      list.append(li);
+    });
+    details.append(list);
+    if (results.length > 0) {
+      const clear = document.createElement('button');
+      clear.type = 'button';
+      clear.className = 'secondary outline';
+      clear.textContent = 'Clear synced';
+      clear.onclick = function() { store(RESULTS, []); };
+      details.append(clear);
+    }
+    el.append(details);
+  }
+
+  // Plain forms are caught on submit, before the browser navigates; htmx
+  // forms before htmx sends them, or when sending fails
+  document.addEventListener('submit', function(e) {
+    const form = e.target;
+    if (!form.dataset.offlineKind || form.hasAttribute('hx-post') || navigator.onLine) return;
+    e.preventDefault();
+    enqueue(form);
+  }, true);
+  document.addEventListener('htmx:beforeRequest', function(e) {
+    const form = e.detail.elt.closest('form');
+    if (!form || !form.dataset.offlineKind || navigator.onLine) return;
+    e.preventDefault();
+    enqueue(form);
+  });
+  document.addEventListener('htmx:sendError', function(e) {
+    const form = e.detail.elt.closest('form');
+    if (form && form.dataset.offlineKind) enqueue(form);
+  });
+
+  window.addEventListener('online', function() { render(); sync(); });
+  window.addEventListener('offline', render);
+  document.addEventListener('DOMContentLoaded', function() { render(); sync(); });
+})();
+`
+
+// OfflineScript serves the offline queue script
+func (h *Handler) OfflineScript(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/javascript")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write([]byte(offlineScript))
+}
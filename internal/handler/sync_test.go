@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+func TestSync(t *testing.T) {
+	h, db := newTestHandler(t)
+	book := "Dec 26 SANDHYA MEDICAL STORE LUCKNOW 5000.00\nUPI/9450852076@YBL 5000.00"
+	sync := func(form url.Values) (int, syncResult) {
+		t.Helper()
+		w := serve(h, http.HandlerFunc(h.Sync), postForm("/sync", form))
+		var res syncResult
+		if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+			t.Fatalf("status %d, reply not JSON: %v", w.Code, err)
+		}
+		return w.Code, res
+	}
+	receipts := func(firm int) float64 {
+		return count(t, db, "SELECT COUNT(*) FROM transactions WHERE firm_id = ?", firm)
+	}
+
+	if status, res := sync(url.Values{"kind": {syncKindImport}, "data": {book}}); status != http.StatusBadRequest || res.Status != "error" {
+		t.Errorf("item without a client ID: %d %+v", status, res)
+	}
+	if _, res := sync(url.Values{"client_id": {"c0"}, "kind": {"payments"}}); res.Status != "error" {
+		t.Errorf("item of an unknown kind: %+v", res)
+	}
+
+	// Queued while Durga Pharma was selected, synced with the first firm
+	// selected
+	item := url.Values{"client_id": {"c1"}, "kind": {syncKindImport}, "firm_id": {"2"}, "year": {"2025"}, "data": {book}}
+	if _, res := sync(item); res.Status != "synced" || res.Message != "Receipt book: 1 imported" {
+		t.Fatalf("import: %+v", res)
+	}
+	if receipts(1) != 0 || receipts(2) != 1 {
+		t.Errorf("import applied in the wrong firm: %v and %v receipts", receipts(1), receipts(2))
+	}
+
+	// A replay after a lost response is not applied again
+	if _, res := sync(item); res.Status != "duplicate" || res.Message != "Already synced: Receipt book: 1 imported" {
+		t.Errorf("replay: %+v", res)
+	}
+	// and the same book queued again on another device finds its entries
+	// imported
+	item.Set("client_id", "c2")
+	if _, res := sync(item); res.Status != "synced" || res.Message != "Receipt book: 0 imported, 1 already imported (skipped)" {
+		t.Errorf("import of entries already imported: %+v", res)
+	}
+	if n := receipts(2); n != 1 {
+		t.Errorf("%v receipts after syncing the book three times, want 1", n)
+	}
+
+	id := count(t, db, "SELECT id FROM transactions")
+	tags := url.Values{"client_id": {"c3"}, "kind": {syncKindTags}, "id": {strconv.FormatFloat(id, 'f', -1, 64)}, "tags": {"Agent Collected"}, "note": {"by Ramesh"}}
+	if _, res := sync(tags); res.Status != "synced" || res.Message != "Tags for ₹5000.00 on 26 Dec 2025: agent-collected" {
+		t.Errorf("tags: %+v", res)
+	}
+	if n := count(t, db, "SELECT COUNT(*) FROM transaction_tags t JOIN transactions x ON x.id = t.transaction_id WHERE t.tag = 'agent-collected' AND x.note = 'by Ramesh'"); n != 1 {
+		t.Errorf("tags not applied")
+	}
+	exec(t, db, "DELETE FROM transactions")
+	tags.Set("client_id", "c4")
+	if _, res := sync(tags); res.Status != "error" {
+		t.Errorf("tags of a deleted transaction: %+v", res)
+	}
+}
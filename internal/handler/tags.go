@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// normalizeTag lowercases a tag and joins its words with hyphens, so
+// "Agent Collected" and "agent-collected" are one tag
+func normalizeTag(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), "-")
+}
+
+// parseTags splits a comma-separated tag list into normalized tags without
+// duplicates
+func parseTags(s string) []string {
+	seen := make(map[string]bool)
+	var tags []string
+	for _, t := range strings.Split(s, ",") {
+		tag := normalizeTag(t)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// groupTags maps transaction IDs to their tags
+func groupTags(rows []sqlc.TransactionTag) map[int64][]string {
+	tags := make(map[int64][]string)
+	for _, t := range rows {
+		tags[t.TransactionID] = append(tags[t.TransactionID], t.Tag)
+	}
+	return tags
+}
+
+// setTransactionTags replaces the tags and note of a transaction
+func (h *Handler) setTransactionTags(ctx context.Context, id int64, tags []string, note string) error {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := h.queries.WithTx(tx)
+	if err := q.UpdateTransactionNote(ctx, sqlc.UpdateTransactionNoteParams{Note: note, ID: id}); err != nil {
+		return fmt.Errorf("updating note: %w", err)
+	}
+	if err := q.DeleteTransactionTags(ctx, id); err != nil {
+		return fmt.Errorf("clearing tags: %w", err)
+	}
+	for _, tag := range tags {
+		if err := q.AddTransactionTag(ctx, sqlc.AddTransactionTagParams{TransactionID: id, Tag: tag}); err != nil {
+			return fmt.Errorf("adding tag %s: %w", tag, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// UpdateTransactionTags sets the tags and note of a transaction from the
+// party page
+func (h *Handler) UpdateTransactionTags(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+	partyID, err := strconv.ParseInt(r.FormValue("party_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid party ID", http.StatusBadRequest)
+		return
+	}
+
+	tags := parseTags(r.FormValue("tags"))
+	note := strings.TrimSpace(r.FormValue("note"))
+	if err := h.setTransactionTags(r.Context(), id, tags, note); err != nil {
+		http.Error(w, "Error saving tags", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/party/%d", partyID), http.StatusSeeOther)
+}
+
+// Tags reports the tags in use with their transaction counts and totals, and
+// lists the transactions carrying the selected tag
+func (h *Handler) Tags(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	summaries, err := h.queries.ListTagSummaries(ctx, firmID(ctx))
+	if err != nil {
+		http.Error(w, "Error loading tags", http.StatusInternalServerError)
+		return
+	}
+
+	tag := normalizeTag(r.URL.Query().Get("tag"))
+	var transactions []sqlc.ListTransactionsByTagRow
+	var tags map[int64][]string
+	if tag != "" {
+		transactions, err = h.queries.ListTransactionsByTag(ctx, sqlc.ListTransactionsByTagParams{Tag: tag, FirmID: firmID(ctx)})
+		if err != nil {
+			http.Error(w, "Error loading transactions", http.StatusInternalServerError)
+			return
+		}
+		rows, _ := h.queries.GetTransactionTagsByTag(ctx, tag)
+		tags = groupTags(rows)
+	}
+
+	pages.Tags(summaries, tag, transactions, tags).Render(ctx, w)
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	for in, want := range map[string][]string{
+		"advance":                             {"advance"},
+		" Agent Collected , agent-collected,": {"agent-collected"},
+		"Disputed,advance, DISPUTED":          {"disputed", "advance"},
+		" , ":                                 nil,
+	} {
+		if got := parseTags(in); !reflect.DeepEqual(got, want) {
+			t.Errorf("parseTags(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestTransactionTags(t *testing.T) {
+	h, db := newTestHandler(t)
+	exec(t, db, `INSERT INTO parties (id, name, firm_id) VALUES (1, 'SHARMA MEDICAL', 1), (2, 'VERMA AGENCIES', 2)`)
+	exec(t, db, `INSERT INTO transactions (id, party_id, amount, transaction_date, payment_mode, narration, firm_id) VALUES
+		(1, 1, 500, '2025-04-01 00:00:00 +0000 UTC', 'NEFT', 'NEFT/1', 1),
+		(2, 1, 700, '2025-04-03 00:00:00 +0000 UTC', 'CASH', 'CASH/2', 1),
+		(3, 2, 900, '2025-04-02 00:00:00 +0000 UTC', 'UPI', 'UPI/3', 2)`)
+	exec(t, db, `INSERT INTO transaction_tags (transaction_id, tag) VALUES (3, 'advance')`)
+	tag := func(id, tags, note string) {
+		t.Helper()
+		w := serve(h, http.HandlerFunc(h.UpdateTransactionTags), postForm("/transactions/tags", url.Values{"id": {id}, "party_id": {"1"}, "tags": {tags}, "note": {note}}))
+		if w.Code != http.StatusSeeOther {
+			t.Fatalf("tagging: status = %d: %s", w.Code, w.Body)
+		}
+	}
+
+	tag("1", "Disputed, agent collected", "first note")
+	// tagging again replaces the tags and note
+	tag("1", "advance", " For the May order ")
+	tag("2", "Agent Collected", "")
+	for query, want := range map[string]float64{
+		"SELECT COUNT(*) FROM transaction_tags WHERE transaction_id = 1":                             1,
+		"SELECT COUNT(*) FROM transaction_tags WHERE transaction_id = 1 AND tag = 'advance'":         1,
+		"SELECT COUNT(*) FROM transactions WHERE id = 1 AND note = 'For the May order'":              1,
+		"SELECT COUNT(*) FROM transaction_tags WHERE transaction_id = 2 AND tag = 'agent-collected'": 1,
+	} {
+		if n := count(t, db, query); n != want {
+			t.Errorf("%s = %v, want %v", query, n, want)
+		}
+	}
+
+	// The tag report lists this firm's transactions of the tag only
+	w := serve(h, http.HandlerFunc(h.Tags), httptest.NewRequest(http.MethodGet, "/tags?tag=Advance", nil))
+	body := w.Body.String()
+	if !strings.Contains(body, "01 Apr 2025") || !strings.Contains(body, "For the May order") || strings.Contains(body, "03 Apr 2025") || strings.Contains(body, "900.00") {
+		t.Errorf("advance tag report lists the wrong transactions:\n%s", body)
+	}
+	if !strings.Contains(body, "agent-collected") {
+		t.Errorf("tag report lacks the tags in use:\n%s", body)
+	}
+}
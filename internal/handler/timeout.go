@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// longHandler serves a route of imports, exports or other bulk work, which
+// gets the long timeout
+type longHandler struct {
+	http.HandlerFunc
+}
+
+// Long marks the handler of a route doing bulk work, such as an import or
+// export, for the long timeout. Wrap the route where it is registered.
+func Long(h http.HandlerFunc) http.Handler {
+	return longHandler{h}
+}
+
+// Timeout cancels each request's context after timeout, or after long for the
+// routes of mux registered with Long, so a request the browser abandoned or
+// one running away stops querying the database. Its queries fail and long
+// loops stop at the next entry. A zero duration leaves those requests without
+// a deadline; a closed connection still cancels them.
+func Timeout(next http.Handler, mux *http.ServeMux, timeout, long time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d := timeout
+		if route, _ := mux.Handler(r); isLong(route) {
+			d = long
+		}
+		if d <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), d)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// isLong reports whether a route's handler was registered with Long
+func isLong(h http.Handler) bool {
+	_, ok := h.(longHandler)
+	return ok
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"suspense.durgadawaghar.com/internal/views"
+)
+
+func TestTimeout(t *testing.T) {
+	deadlines := make(map[string]time.Duration)
+	record := func(w http.ResponseWriter, r *http.Request) {
+		deadlines[r.URL.Path] = 0
+		if d, ok := r.Context().Deadline(); ok {
+			deadlines[r.URL.Path] = time.Until(d)
+		}
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/search", record)
+	mux.Handle("/import/confirm", Long(record))
+
+	for _, tt := range []struct {
+		timeout, long time.Duration
+		search, imp   time.Duration
+	}{
+		{time.Minute, time.Hour, time.Minute, time.Hour},
+		// a zero duration leaves its routes without a deadline
+		{0, time.Hour, 0, time.Hour},
+		{time.Minute, 0, time.Minute, 0},
+	} {
+		for _, path := range []string{"/search", "/import/confirm"} {
+			Timeout(mux, mux, tt.timeout, tt.long).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, path, nil))
+		}
+		for path, want := range map[string]time.Duration{"/search": tt.search, "/import/confirm": tt.imp} {
+			if got := deadlines[path]; got > want || got < want-time.Second {
+				t.Errorf("timeouts %v and %v: %s has %v left, want %v", tt.timeout, tt.long, path, got, want)
+			}
+		}
+	}
+}
+
+func TestImportCancelled(t *testing.T) {
+	h, db := newTestHandler(t)
+	ctx, cancel := context.WithCancel(views.WithFirm(context.Background(), views.Firm{ID: 1, Name: "Durga Dawa Ghar"}, nil))
+	cancel()
+	book := "Dec 20 SANDHYA MEDICAL STORE LUCKNOW 1200.00\nUPI/SANDHYA@YBL 1200.00"
+
+	if _, err := h.importReceiptBook(ctx, book, 2025); !errors.Is(err, context.Canceled) {
+		t.Errorf("import of an abandoned request: %v", err)
+	}
+	r := postForm("/import/confirm", url.Values{"data": {book}, "year": {"2025"}}).WithContext(ctx)
+	if body := serve(h, http.HandlerFunc(h.ImportConfirm), r).Body.String(); !strings.Contains(body, "Import error:") {
+		t.Errorf("abandoned import page: %s", body)
+	}
+	if n := count(t, db, "SELECT COUNT(*) FROM transactions"); n != 0 {
+		t.Errorf("abandoned import added %v transactions", n)
+	}
+}
@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// mergeWindowDays is how many days apart two entries of the same payment may
+// be dated, as a receipt book entry and its bank credit often are
+const mergeWindowDays = 7
+
+// transactionMergeMoves hand what the superseded transaction (?2) has to the
+// one it is merged into (?1): its tags, its cheque, note, account and agent
+// when the other has none, its allocations to bills, and earlier merges into
+// it; then remove it
+var transactionMergeMoves = []string{
+	"INSERT OR IGNORE INTO transaction_tags (transaction_id, tag) SELECT ?1, tag FROM transaction_tags WHERE transaction_id = ?2",
+	"DELETE FROM transaction_tags WHERE transaction_id = ?2",
+	"UPDATE OR IGNORE cheques SET transaction_id = ?1 WHERE transaction_id = ?2",
+	"DELETE FROM cheques WHERE transaction_id = ?2",
+	"UPDATE transactions SET note = (SELECT note FROM transactions WHERE id = ?2) WHERE id = ?1 AND note = ''",
+	"UPDATE transactions SET account_id = (SELECT account_id FROM transactions WHERE id = ?2) WHERE id = ?1 AND account_id IS NULL",
+	"INSERT OR IGNORE INTO transaction_agents (transaction_id, agent_id, source) SELECT ?1, agent_id, source FROM transaction_agents WHERE transaction_id = ?2",
+	"DELETE FROM transaction_agents WHERE transaction_id = ?2",
+	"UPDATE OR IGNORE bill_allocations SET transaction_id = ?1 WHERE transaction_id = ?2",
+	"DELETE FROM bill_allocations WHERE transaction_id = ?2",
+	"UPDATE transaction_merges SET transaction_id = ?1 WHERE transaction_id = ?2",
+	"DELETE FROM transactions WHERE id = ?2",
+}
+
+// renderTransactionMerge renders the merge page of txn: the duplicates merged
+// into it, and the transactions of its party and amount near its date that
+// may be the same payment
+func (h *Handler) renderTransactionMerge(w http.ResponseWriter, r *http.Request, txn sqlc.Transaction, formError string) {
+	ctx := r.Context()
+	view := pages.TransactionMergeView{Transaction: txn, Error: formError}
+	if party, err := h.queries.GetPartyByID(ctx, txn.PartyID); err == nil {
+		view.PartyName = party.Name
+	}
+
+	var err error
+	if view.Merges, err = h.queries.ListTransactionMerges(ctx, txn.ID); err != nil {
+		http.Error(w, "Error loading merged transactions", http.StatusInternalServerError)
+		return
+	}
+	view.PartyNames = make(map[int64]string)
+	for _, m := range view.Merges {
+		if party, err := h.queries.GetPartyByID(ctx, m.SupersededPartyID); err == nil {
+			view.PartyNames[party.ID] = party.Name
+		}
+	}
+	if _, err := h.queries.GetTransactionSplit(ctx, txn.ID); err == nil {
+		view.Split = true
+	} else {
+		view.Candidates, err = h.queries.ListTransactionMergeCandidates(ctx, sqlc.ListTransactionMergeCandidatesParams{
+			FirmID:            firmID(ctx),
+			PartyID:           txn.PartyID,
+			Amount:            txn.Amount,
+			TransactionDate:   txn.TransactionDate.AddDate(0, 0, -mergeWindowDays),
+			TransactionDate_2: txn.TransactionDate.AddDate(0, 0, mergeWindowDays),
+			ID:                txn.ID,
+		})
+		if err != nil {
+			http.Error(w, "Error loading transactions", http.StatusInternalServerError)
+			return
+		}
+	}
+	pages.TransactionMerge(view).Render(ctx, w)
+}
+
+// TransactionMerge shows a transaction with the duplicates merged into it,
+// and the transactions that may be the same payment to merge into it
+func (h *Handler) TransactionMerge(w http.ResponseWriter, r *http.Request) {
+	txn, err := h.firmTransaction(r)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	h.renderTransactionMerge(w, r, txn, "")
+}
+
+// MergeTransaction merges a duplicate transaction of the same payment, such
+// as a receipt entered from the receipt book and again from the bank
+// statement, into the one kept. The duplicate is removed, with its party,
+// date and narration kept in the merge record.
+func (h *Handler) MergeTransaction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	kept, err := h.firmTransaction(r)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	dupID, err := strconv.ParseInt(r.FormValue("duplicate"), 10, 64)
+	if err != nil || dupID == kept.ID {
+		http.Error(w, "Choose another transaction to merge", http.StatusBadRequest)
+		return
+	}
+	dup, err := h.queries.GetTransactionByID(ctx, dupID)
+	if err != nil || dup.FirmID != kept.FirmID {
+		http.Error(w, "Transaction to merge not found", http.StatusBadRequest)
+		return
+	}
+	if dup.PartyID != kept.PartyID {
+		h.renderTransactionMerge(w, r, kept, fmt.Sprintf("Transaction %d is of another party; move it to this receipt's party before merging", dup.ID))
+		return
+	}
+	if math.Round(dup.Amount*100) != math.Round(kept.Amount*100) {
+		h.renderTransactionMerge(w, r, kept, fmt.Sprintf("Transaction %d is of ₹%.2f, not ₹%.2f; only entries of the same payment can be merged", dup.ID, dup.Amount, kept.Amount))
+		return
+	}
+	for _, id := range []int64{kept.ID, dup.ID} {
+		if _, err := h.queries.GetTransactionSplit(ctx, id); err == nil {
+			h.renderTransactionMerge(w, r, kept, fmt.Sprintf("Transaction %d is part of a split receipt and cannot be merged", id))
+			return
+		}
+	}
+
+	if err := h.mergeTransactions(ctx, kept, dup); err != nil {
+		if errors.Is(err, errClosedYear) || strings.Contains(err.Error(), "closed financial year") {
+			h.renderTransactionMerge(w, r, kept, "An entry is in a closed financial year; reopen it to merge")
+			return
+		}
+		h.renderTransactionMerge(w, r, kept, "Error merging the transactions: "+err.Error())
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/transactions/merge?id=%d", kept.ID), http.StatusSeeOther)
+}
+
+// mergeTransactions records dup as merged into kept and moves what it has to
+// kept, in one transaction
+func (h *Handler) mergeTransactions(ctx context.Context, kept, dup sqlc.Transaction) error {
+	for _, txn := range []sqlc.Transaction{kept, dup} {
+		if err := h.checkOpenYear(ctx, txn.TransactionDate); err != nil {
+			return err
+		}
+	}
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	q := h.queries.WithTx(tx)
+
+	if err := q.CreateTransactionMerge(ctx, sqlc.CreateTransactionMergeParams{
+		FirmID:                  kept.FirmID,
+		TransactionID:           kept.ID,
+		SupersededTransactionID: dup.ID,
+		SupersededPartyID:       dup.PartyID,
+		SupersededAmount:        dup.Amount,
+		SupersededDate:          dup.TransactionDate,
+		SupersededPaymentMode:   dup.PaymentMode,
+		SupersededNarration:     dup.Narration,
+	}); err != nil {
+		return err
+	}
+	for _, stmt := range transactionMergeMoves {
+		if _, err := tx.ExecContext(ctx, stmt, kept.ID, dup.ID); err != nil {
+			return fmt.Errorf("merging transaction %d into %d: %w", dup.ID, kept.ID, err)
+		}
+	}
+	return tx.Commit()
+}
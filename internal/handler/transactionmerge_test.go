@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestMergeTransaction(t *testing.T) {
+	h, db := newTestHandler(t)
+	exec(t, db, `INSERT INTO parties (id, name, firm_id) VALUES (1, 'SHARMA MEDICAL', 1), (2, 'GUPTA STORES', 1), (3, 'SHARMA MEDICAL', 2)`)
+	// 1 is from the bank statement, 2 the same payment from the receipt book
+	exec(t, db, `INSERT INTO transactions (id, party_id, amount, transaction_date, payment_mode, narration, note, firm_id) VALUES
+		(1, 1, 500, '2025-04-03 00:00:00 +0000 UTC', 'NEFT', 'NEFT/SHARMA MEDICAL', '', 1),
+		(2, 1, 500, '2025-04-01 00:00:00 +0000 UTC', 'CASH', 'RECEIPT 41', 'Paid at the counter', 1),
+		(3, 2, 500, '2025-04-02 00:00:00 +0000 UTC', 'NEFT', 'NEFT/GUPTA STORES', '', 1),
+		(4, 3, 500, '2025-04-02 00:00:00 +0000 UTC', 'NEFT', 'NEFT/SHARMA MEDICAL', '', 2),
+		(5, 1, 450, '2025-04-02 00:00:00 +0000 UTC', 'NEFT', 'NEFT/SHARMA MEDICAL', '', 1)`)
+	exec(t, db, `INSERT INTO sale_bills (id, bill_number, bill_date, party_name, amount, is_cash_sale, party_id, firm_id) VALUES
+		(1, 'A-1', '2025-03-30 00:00:00 +0000 UTC', 'SHARMA MEDICAL', 800, FALSE, 1, 1)`)
+	exec(t, db, `INSERT INTO bill_allocations (sale_bill_id, transaction_id, amount) VALUES (1, 2, 500)`)
+	exec(t, db, `INSERT INTO transaction_tags (transaction_id, tag) VALUES (2, 'advance')`)
+	merge := func(duplicate string) (int, string) {
+		w := serve(h, http.HandlerFunc(h.MergeTransaction), postForm("/transactions/merge/save", url.Values{"id": {"1"}, "duplicate": {duplicate}}))
+		return w.Code, w.Body.String()
+	}
+
+	for _, tt := range []struct {
+		name, duplicate string
+		status          int
+		problem         string
+	}{
+		{"another firm", "4", http.StatusBadRequest, "not found"},
+		{"another party", "3", http.StatusOK, "another party"},
+		{"another amount", "5", http.StatusOK, "only entries of the same payment"},
+		{"itself", "1", http.StatusBadRequest, "another transaction"},
+	} {
+		if status, body := merge(tt.duplicate); status != tt.status || !strings.Contains(body, tt.problem) {
+			t.Errorf("merging %s: status %d, want %d with %q:\n%s", tt.name, status, tt.status, tt.problem, body)
+		}
+	}
+	if n := count(t, db, "SELECT COUNT(*) FROM transactions"); n != 5 {
+		t.Fatalf("a refused merge left %v transactions", n)
+	}
+
+	if status, body := merge("2"); status != http.StatusSeeOther {
+		t.Fatalf("merge refused: %d\n%s", status, body)
+	}
+	for query, want := range map[string]float64{
+		"SELECT COUNT(*) FROM transactions WHERE id = 2":                                                           0,
+		"SELECT amount FROM transactions WHERE id = 1 AND note = 'Paid at the counter'":                            500,
+		"SELECT amount FROM bill_allocations WHERE sale_bill_id = 1 AND transaction_id = 1":                        500,
+		"SELECT COUNT(*) FROM transaction_tags WHERE transaction_id = 1 AND tag = 'advance'":                       1,
+		"SELECT COUNT(*) FROM transaction_merges WHERE transaction_id = 1 AND superseded_narration = 'RECEIPT 41'": 1,
+		// one receipt of the payment is counted, not two
+		"SELECT receipt_count FROM party_balances WHERE party_id = 1": 2,
+		"SELECT received FROM party_balances WHERE party_id = 1":      950,
+		"SELECT billed FROM party_balances WHERE party_id = 1":        800,
+	} {
+		if n := count(t, db, query); n != want {
+			t.Errorf("%s = %v, want %v", query, n, want)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"suspense.durgadawaghar.com/internal/extractor"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// minUnattachedEntries is how many receipts an identifier must turn up in to
+// be listed as unattached; one sighting says little
+const minUnattachedEntries = 2
+
+// unattachedLimit is how many unattached identifiers are listed, most
+// entries first
+const unattachedLimit = 200
+
+// UnattachedIdentifiers lists UPI IDs, phones, account numbers and agent
+// codes that turn up in several receipts' narrations but are linked to no
+// party, with the parties of those receipts, to attach to the right one
+func (h *Handler) UnattachedIdentifiers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	identifiers, err := h.unattachedIdentifiers(ctx)
+	if err != nil {
+		http.Error(w, "Error finding unattached identifiers", http.StatusInternalServerError)
+		return
+	}
+	pages.UnattachedIdentifiers(identifiers, r.URL.Query().Get("attached")).Render(ctx, w)
+}
+
+// unattachedIdentifiers extracts the unique identifiers of the firm's receipt
+// narrations and counts, per party, the receipts of those linked to no party
+func (h *Handler) unattachedIdentifiers(ctx context.Context) ([]pages.UnattachedIdentifier, error) {
+	linked, err := h.queries.ListIdentifiersForExport(ctx, firmID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	type key struct{ kind, value string }
+	attached := make(map[key]bool, len(linked))
+	for _, l := range linked {
+		attached[key{l.Type, l.Value}] = true
+	}
+
+	narrations, err := h.queries.ListReceiptNarrations(ctx, firmID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[key]*pages.UnattachedIdentifier)
+	for i, n := range narrations {
+		// Extracting every narration takes a while on a big book
+		if i%1000 == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		seen := make(map[key]bool)
+		for _, id := range extractor.Extract(n.Narration.String) {
+			k := key{string(id.Type), id.Value}
+			if !id.Type.Unique() || attached[k] || seen[k] {
+				continue
+			}
+			seen[k] = true
+			u, ok := found[k]
+			if !ok {
+				u = &pages.UnattachedIdentifier{Type: k.kind, Value: k.value, FirstSeen: n.TransactionDate}
+				found[k] = u
+			}
+			u.Entries++
+			u.LastSeen = n.TransactionDate
+			j := 0
+			for j < len(u.Parties) && u.Parties[j].ID != n.PartyID {
+				j++
+			}
+			if j == len(u.Parties) {
+				u.Parties = append(u.Parties, pages.UnattachedParty{ID: n.PartyID, Name: n.PartyName})
+			}
+			u.Parties[j].Entries++
+		}
+	}
+
+	var identifiers []pages.UnattachedIdentifier
+	for _, u := range found {
+		if u.Entries < minUnattachedEntries {
+			continue
+		}
+		sort.SliceStable(u.Parties, func(i, j int) bool { return u.Parties[i].Entries > u.Parties[j].Entries })
+		identifiers = append(identifiers, *u)
+	}
+	sort.Slice(identifiers, func(i, j int) bool {
+		if identifiers[i].Entries != identifiers[j].Entries {
+			return identifiers[i].Entries > identifiers[j].Entries
+		}
+		if identifiers[i].Type != identifiers[j].Type {
+			return identifiers[i].Type < identifiers[j].Type
+		}
+		return identifiers[i].Value < identifiers[j].Value
+	})
+	if len(identifiers) > unattachedLimit {
+		identifiers = identifiers[:unattachedLimit]
+	}
+	return identifiers, nil
+}
+
+// AttachIdentifier links an unattached identifier to the party chosen for it
+func (h *Handler) AttachIdentifier(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	idType, ok := extractor.ParseType(r.FormValue("type"))
+	if !ok {
+		http.Error(w, "Unknown identifier type", http.StatusBadRequest)
+		return
+	}
+	value, err := extractor.Normalize(idType, r.FormValue("value"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	partyID, err := strconv.ParseInt(r.FormValue("party_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid party ID", http.StatusBadRequest)
+		return
+	}
+	party, err := h.queries.GetPartyByID(ctx, partyID)
+	if err != nil || party.FirmID != firmID(ctx) {
+		http.Error(w, "Party not found", http.StatusBadRequest)
+		return
+	}
+
+	// Linked to another party meanwhile, it stays there as a conflict
+	if _, _, err := h.linkIdentifier(ctx, party.ID, extractor.Identifier{Type: idType, Value: value}, identifierCauseAssign, "Unattached identifier review"); err != nil {
+		http.Error(w, "Error attaching identifier", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/identifiers/unattached?attached="+url.QueryEscape(value), http.StatusSeeOther)
+}
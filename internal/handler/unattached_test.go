@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"suspense.durgadawaghar.com/internal/views"
+)
+
+func TestUnattachedIdentifiers(t *testing.T) {
+	h, db := newTestHandler(t)
+	ctx := views.WithFirm(context.Background(), views.Firm{ID: 1, Name: "Durga Dawa Ghar"}, nil)
+	exec(t, db, `INSERT INTO parties (id, name, firm_id) VALUES (1, 'SANDHYA MEDICAL', 1), (2, 'SANDHYA MED STORE', 1), (3, 'GUPTA STORES', 1), (4, 'VERMA AGENCIES', 2)`)
+	exec(t, db, `INSERT INTO identifiers (party_id, type, value, firm_id) VALUES (3, 'upi_vpa', 'GUPTA@YBL', 1)`)
+	exec(t, db, `INSERT INTO transactions (party_id, amount, transaction_date, payment_mode, narration, firm_id) VALUES
+		(1, 100, '2025-04-01 00:00:00 +0000 UTC', 'UPI', 'UPI/SANDHYA@YBL/PAYMENT', 1),
+		(2, 200, '2025-04-05 00:00:00 +0000 UTC', 'UPI', 'UPI/SANDHYA@YBL/PAYMENT', 1),
+		(2, 300, '2025-04-09 00:00:00 +0000 UTC', 'UPI', 'UPI/SANDHYA@YBL/PAYMENT SANDHYA@YBL', 1),
+		(3, 400, '2025-04-02 00:00:00 +0000 UTC', 'UPI', 'UPI/GUPTA@YBL/PAYMENT', 1),
+		(3, 500, '2025-04-03 00:00:00 +0000 UTC', 'UPI', 'UPI/GUPTA@YBL/PAYMENT', 1),
+		(3, 600, '2025-04-04 00:00:00 +0000 UTC', 'UPI', 'UPI/ONCE@YBL/PAYMENT', 1),
+		(4, 700, '2025-04-04 00:00:00 +0000 UTC', 'UPI', 'UPI/VERMA@YBL/PAYMENT', 2),
+		(4, 800, '2025-04-05 00:00:00 +0000 UTC', 'UPI', 'UPI/VERMA@YBL/PAYMENT', 2)`)
+
+	// Only the identifier of several receipts linked to no party is listed,
+	// with the parties of its receipts, most receipts first
+	identifiers, err := h.unattachedIdentifiers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(identifiers) != 1 {
+		t.Fatalf("got %d unattached identifiers, want 1: %+v", len(identifiers), identifiers)
+	}
+	u := identifiers[0]
+	if u.Type != "upi_vpa" || u.Value != "SANDHYA@YBL" || u.Entries != 3 ||
+		u.FirstSeen.Format("2006-01-02") != "2025-04-01" || u.LastSeen.Format("2006-01-02") != "2025-04-09" {
+		t.Errorf("unattached identifier = %+v", u)
+	}
+	if len(u.Parties) != 2 || u.Parties[0].ID != 2 || u.Parties[0].Entries != 2 || u.Parties[1].ID != 1 || u.Parties[1].Entries != 1 {
+		t.Errorf("parties of the identifier = %+v", u.Parties)
+	}
+
+	attach := func(form url.Values) *httptest.ResponseRecorder {
+		return serve(h, http.HandlerFunc(h.AttachIdentifier), postForm("/identifiers/unattached/attach", form))
+	}
+	if w := attach(url.Values{"type": {"upi_vpa"}, "value": {"sandhya@ybl"}, "party_id": {"4"}}); w.Code != http.StatusBadRequest {
+		t.Errorf("attaching to another firm's party: status %d", w.Code)
+	}
+	if w := attach(url.Values{"type": {"upi_vpa"}, "value": {"sandhya@ybl"}, "party_id": {"2"}}); w.Code != http.StatusSeeOther {
+		t.Fatalf("attaching: status %d: %s", w.Code, w.Body)
+	}
+	if n := count(t, db, "SELECT COUNT(*) FROM identifiers WHERE party_id = 2 AND type = 'upi_vpa' AND value = 'SANDHYA@YBL' AND firm_id = 1"); n != 1 {
+		t.Errorf("identifier not attached")
+	}
+
+	body := serve(h, http.HandlerFunc(h.UnattachedIdentifiers), httptest.NewRequest(http.MethodGet, "/identifiers/unattached?attached=SANDHYA@YBL", nil)).Body.String()
+	if strings.Contains(body, "SANDHYA MEDICAL") || strings.Contains(body, "VERMA@YBL") {
+		t.Errorf("attached identifier or another firm's still listed:\n%s", body)
+	}
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"suspense.durgadawaghar.com/internal/integrity"
+	"suspense.durgadawaghar.com/internal/views/pages"
+)
+
+// VerifyTotals compares what was imported in each receipt book period with
+// the book's closing SUB TOTAL, to catch partly imported and doubly imported
+// books
+func (h *Handler) VerifyTotals(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	checks, err := h.queries.ListReceiptBookChecks(ctx, firmID(ctx))
+	if err != nil {
+		http.Error(w, "Error loading book totals: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	pages.VerifyTotals(checks).Render(ctx, w)
+}
+
+// Reconcile runs the database consistency checks and compares every firm's
+// receipt books with what was imported of them, for the scheduled
+// reconciliation job. What it finds fails the job, so the jobs page flags it.
+func (h *Handler) Reconcile(ctx context.Context) (string, error) {
+	results, err := integrity.Run(ctx, h.reads)
+	if err != nil {
+		return "", err
+	}
+	problems := integrity.Problems(results)
+
+	firms, err := h.queries.ListFirms(ctx)
+	if err != nil {
+		return "", err
+	}
+	books, mismatched := 0, 0
+	for _, f := range firms {
+		checks, err := h.queries.ListReceiptBookChecks(ctx, f.ID)
+		if err != nil {
+			return "", fmt.Errorf("checking %s book totals: %w", f.Name, err)
+		}
+		for _, c := range checks {
+			books++
+			if c.Difference != 0 {
+				mismatched++
+			}
+		}
+	}
+
+	result := fmt.Sprintf("%d integrity problems, %d of %d receipt books off their totals", problems, mismatched, books)
+	if problems > 0 || mismatched > 0 {
+		return result, fmt.Errorf("%s; see Integrity Check and Book Totals", result)
+	}
+	return result, nil
+}
@@ -0,0 +1,226 @@
+// Package integrity finds rows that the schema's constraints should have kept
+// out but that older migrations, and foreign keys SQLite does not enforce,
+// let in. Each check reports the rows it found and how to fix them.
+package integrity
+
+import (
+	"context"
+	"fmt"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+)
+
+// sampleRows is how many offending rows a result lists
+const sampleRows = 20
+
+// Check is one consistency rule. Query selects the offending rows as an id
+// and a description. Fix is SQL that repairs every row found, or empty when
+// the rows need a person to decide, in which case Hint says what to do.
+type Check struct {
+	Name  string
+	Query string
+	Fix   string
+	Hint  string
+}
+
+// Result is what a check found: the number of offending rows and the first
+// few of them
+type Result struct {
+	Check
+	Count int
+	Rows  []string
+}
+
+// Checks are run in this order
+var Checks = []Check{
+	{
+		Name: "Identifiers of missing parties",
+		Query: `SELECT i.id, printf('%s %s of party %d', i.type, i.value, i.party_id)
+			FROM identifiers i LEFT JOIN parties p ON p.id = i.party_id
+			WHERE p.id IS NULL`,
+		Fix: "DELETE FROM identifiers WHERE party_id NOT IN (SELECT id FROM parties);",
+	},
+	{
+		Name: "Transactions of missing parties",
+		Query: `SELECT t.id, printf('%s %.2f %s of party %d', substr(t.transaction_date, 1, 10), t.amount, COALESCE(t.narration, ''), t.party_id)
+			FROM transactions t LEFT JOIN parties p ON p.id = t.party_id
+			WHERE p.id IS NULL`,
+		Hint: "Move each to the party it belongs to with UPDATE transactions SET party_id = ? WHERE id = ?, or delete it and import the entry again.",
+	},
+	{
+		Name: "Sale bills linked to missing parties",
+		Query: `SELECT b.id, printf('%s %s %s %.2f linked to party %d', b.bill_number, substr(b.bill_date, 1, 10), b.party_name, b.amount, b.party_id)
+			FROM sale_bills b LEFT JOIN parties p ON p.id = b.party_id
+			WHERE b.party_id IS NOT NULL AND p.id IS NULL`,
+		Fix: "UPDATE sale_bills SET party_id = NULL WHERE party_id IS NOT NULL AND party_id NOT IN (SELECT id FROM parties);",
+	},
+	{
+		Name: "Cheques of missing transactions",
+		Query: `SELECT c.id, printf('cheque %s of transaction %d', COALESCE(c.cheque_number, ''), c.transaction_id)
+			FROM cheques c LEFT JOIN transactions t ON t.id = c.transaction_id
+			WHERE t.id IS NULL`,
+		Fix: "DELETE FROM cheques WHERE transaction_id NOT IN (SELECT id FROM transactions);",
+	},
+	{
+		Name: "Tags of missing transactions",
+		Query: `SELECT g.id, printf('%s on transaction %d', g.tag, g.transaction_id)
+			FROM transaction_tags g LEFT JOIN transactions t ON t.id = g.transaction_id
+			WHERE t.id IS NULL`,
+		Fix: "DELETE FROM transaction_tags WHERE transaction_id NOT IN (SELECT id FROM transactions);",
+	},
+	{
+		Name: "Splits of missing transactions",
+		Query: `SELECT s.id, printf('share transaction %d of source transaction %d', s.transaction_id, s.source_transaction_id)
+			FROM transaction_splits s
+			LEFT JOIN transactions t ON t.id = s.transaction_id
+			LEFT JOIN transactions src ON src.id = s.source_transaction_id
+			WHERE t.id IS NULL OR src.id IS NULL`,
+		Fix: "DELETE FROM transaction_splits WHERE transaction_id NOT IN (SELECT id FROM transactions) OR source_transaction_id NOT IN (SELECT id FROM transactions);",
+	},
+	{
+		Name: "Merges into missing transactions",
+		Query: `SELECT m.id, printf('transaction %d merged into transaction %d', m.superseded_transaction_id, m.transaction_id)
+			FROM transaction_merges m LEFT JOIN transactions t ON t.id = m.transaction_id
+			WHERE t.id IS NULL`,
+		Fix: "DELETE FROM transaction_merges WHERE transaction_id NOT IN (SELECT id FROM transactions);",
+	},
+	{
+		Name: "Agent assignments of missing transactions or agents",
+		Query: `SELECT ta.id, printf('transaction %d assigned to agent %d', ta.transaction_id, ta.agent_id)
+			FROM transaction_agents ta
+			LEFT JOIN transactions t ON t.id = ta.transaction_id
+			LEFT JOIN agents a ON a.id = ta.agent_id
+			WHERE t.id IS NULL OR a.id IS NULL`,
+		Fix: "DELETE FROM transaction_agents WHERE transaction_id NOT IN (SELECT id FROM transactions) OR agent_id NOT IN (SELECT id FROM agents);",
+	},
+	{
+		Name: "Bill allocations of missing bills or receipts",
+		Query: `SELECT ba.id, printf('%.2f of transaction %d allocated to bill %d', ba.amount, ba.transaction_id, ba.sale_bill_id)
+			FROM bill_allocations ba
+			LEFT JOIN transactions t ON t.id = ba.transaction_id
+			LEFT JOIN sale_bills b ON b.id = ba.sale_bill_id
+			WHERE t.id IS NULL OR b.id IS NULL`,
+		Fix: "DELETE FROM bill_allocations WHERE transaction_id NOT IN (SELECT id FROM transactions) OR sale_bill_id NOT IN (SELECT id FROM sale_bills);",
+	},
+	{
+		// The allocation editor keeps allocations within both sides, but a
+		// bill or receipt amount may be corrected after
+		Name: "Receipts allocated to bills beyond their amount",
+		Query: `SELECT t.id, printf('%s %.2f allocated %.2f by hand', substr(t.transaction_date, 1, 10), t.amount, SUM(ba.amount))
+			FROM bill_allocations ba JOIN transactions t ON t.id = ba.transaction_id
+			GROUP BY t.id
+			HAVING SUM(ba.amount) > t.amount + 0.01`,
+		Hint: "Remove allocations of the receipt on its party's Allocations tab until they add up to no more than the receipt.",
+	},
+	{
+		Name: "Locations on missing routes",
+		Query: `SELECT l.id, printf('%s on route %d', l.location, l.route_id)
+			FROM route_locations l LEFT JOIN routes r ON r.id = l.route_id
+			WHERE r.id IS NULL`,
+		Fix: "DELETE FROM route_locations WHERE route_id NOT IN (SELECT id FROM routes);",
+	},
+	{
+		Name: "Identifiers in another firm than their party",
+		Query: `SELECT i.id, printf('%s %s in firm %d, party %s in firm %d', i.type, i.value, i.firm_id, p.name, p.firm_id)
+			FROM identifiers i JOIN parties p ON p.id = i.party_id
+			WHERE i.firm_id != p.firm_id`,
+		Fix: "UPDATE identifiers SET firm_id = (SELECT firm_id FROM parties WHERE parties.id = identifiers.party_id) WHERE firm_id != (SELECT firm_id FROM parties WHERE parties.id = identifiers.party_id);",
+	},
+	{
+		Name: "Transactions in another firm than their party",
+		Query: `SELECT t.id, printf('%s %.2f in firm %d, party %s in firm %d', substr(t.transaction_date, 1, 10), t.amount, t.firm_id, p.name, p.firm_id)
+			FROM transactions t JOIN parties p ON p.id = t.party_id
+			WHERE t.firm_id != p.firm_id`,
+		Fix: "UPDATE transactions SET firm_id = (SELECT firm_id FROM parties WHERE parties.id = transactions.party_id) WHERE firm_id != (SELECT firm_id FROM parties WHERE parties.id = transactions.party_id);",
+	},
+	{
+		// Receipts are allocated to credit bills oldest first, and a bill
+		// that is not positive takes receipts back instead of settling
+		Name: "Credit bills that are not positive",
+		Query: `SELECT id, printf('%s %s %s %.2f', bill_number, substr(bill_date, 1, 10), party_name, amount)
+			FROM sale_bills
+			WHERE COALESCE(is_cash_sale, FALSE) = FALSE AND is_card_sale = FALSE AND amount <= 0`,
+		Hint: "Check each against the sale bill register: correct the amount, or delete a returned bill with DELETE FROM sale_bills WHERE id = ?.",
+	},
+	{
+		// The unique index treats every NULL payment mode as distinct
+		Name: "Duplicate transactions",
+		Query: `SELECT t.id, printf('%s %.2f %s, same as transaction %d', substr(t.transaction_date, 1, 10), t.amount, COALESCE(t.narration, ''), d.id)
+			FROM transactions t JOIN transactions d
+			ON d.id < t.id AND d.party_id = t.party_id AND d.amount = t.amount AND d.transaction_date = t.transaction_date
+				AND COALESCE(d.payment_mode, '') = COALESCE(t.payment_mode, '') AND COALESCE(d.narration, '') = COALESCE(t.narration, '')
+			GROUP BY t.id`,
+		Fix: `DELETE FROM transactions WHERE id NOT IN (SELECT MIN(id) FROM transactions GROUP BY party_id, amount, transaction_date, COALESCE(payment_mode, ''), COALESCE(narration, ''));`,
+	},
+	{
+		Name: "Parties whose names differ only in case or spacing",
+		Query: `SELECT p.id, printf('%s, like %s (party %d)', p.name, d.name, d.id)
+			FROM parties p JOIN parties d
+			ON d.id < p.id AND d.firm_id = p.firm_id AND UPPER(TRIM(d.name)) = UPPER(TRIM(p.name)) AND d.name != p.name
+			GROUP BY p.id`,
+		Hint: "If they are the same party, move the later party's transactions, identifiers and bills to the earlier one and delete it.",
+	},
+	{
+		Name: "Identifiers that differ only in case or spacing",
+		Query: `SELECT i.id, printf('%s %s of party %d, like %s of party %d', i.type, i.value, i.party_id, d.value, d.party_id)
+			FROM identifiers i JOIN identifiers d
+			ON d.id < i.id AND d.firm_id = i.firm_id AND d.type = i.type AND LOWER(TRIM(d.value)) = LOWER(TRIM(i.value)) AND d.value != i.value
+			GROUP BY i.id`,
+		Hint: "Delete the later identifier with DELETE FROM identifiers WHERE id = ? once its party is confirmed to match.",
+	},
+	{
+		Name: "Sale bills that differ only in case, spacing or paise",
+		Query: `SELECT b.id, printf('%s %s %s %.2f, like bill %d', b.bill_number, substr(b.bill_date, 1, 10), b.party_name, b.amount, d.id)
+			FROM sale_bills b JOIN sale_bills d
+			ON d.id < b.id AND d.firm_id = b.firm_id AND d.bill_date = b.bill_date
+				AND UPPER(TRIM(d.bill_number)) = UPPER(TRIM(b.bill_number)) AND UPPER(TRIM(d.party_name)) = UPPER(TRIM(b.party_name))
+				AND ABS(d.amount - b.amount) < 1
+			GROUP BY b.id`,
+		Hint: "Delete the wrongly imported copy with DELETE FROM sale_bills WHERE id = ?.",
+	},
+}
+
+// Run runs every check and returns their results, including those that
+// found nothing
+func Run(ctx context.Context, db sqlc.DBTX) ([]Result, error) {
+	results := make([]Result, 0, len(Checks))
+	for _, c := range Checks {
+		res, err := run(ctx, db, c)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", c.Name, err)
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// run runs one check
+func run(ctx context.Context, db sqlc.DBTX, c Check) (Result, error) {
+	res := Result{Check: c}
+	rows, err := db.QueryContext(ctx, c.Query)
+	if err != nil {
+		return res, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var detail string
+		if err := rows.Scan(&id, &detail); err != nil {
+			return res, err
+		}
+		res.Count++
+		if len(res.Rows) < sampleRows {
+			res.Rows = append(res.Rows, fmt.Sprintf("#%d %s", id, detail))
+		}
+	}
+	return res, rows.Err()
+}
+
+// Problems counts the offending rows across results
+func Problems(results []Result) int {
+	n := 0
+	for _, r := range results {
+		n += r.Count
+	}
+	return n
+}
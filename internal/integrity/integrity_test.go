@@ -0,0 +1,95 @@
+package integrity
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestRun(t *testing.T) {
+	schema, err := os.ReadFile("../db/schema.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "suspense.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	ctx := context.Background()
+	for _, query := range []string{
+		string(schema),
+		`INSERT INTO firms (id, name) VALUES (1, 'Durga Dawa Ghar'), (2, 'Durga Pharma')`,
+		`INSERT INTO parties (id, name, firm_id) VALUES (1, 'SHARMA MEDICAL', 1), (2, 'Sharma Medical ', 1), (3, 'VERMA AGENCIES', 2)`,
+		`INSERT INTO identifiers (party_id, type, value, firm_id) VALUES
+			(1, 'upi_vpa', 'sharma@ybl', 1), (9, 'upi_vpa', 'gone@ybl', 1), (1, 'upi_vpa', 'SHARMA@YBL', 1), (3, 'phone', '9839012345', 1)`,
+		// a receipt of a missing party, a duplicate without a payment mode,
+		// and a receipt allocated beyond its amount
+		`INSERT INTO transactions (id, party_id, amount, transaction_date, narration, firm_id) VALUES
+			(1, 9, 500, '2025-04-01 00:00:00 +0000 UTC', 'CASH/GONE', 1),
+			(2, 1, 700, '2025-04-02 00:00:00 +0000 UTC', 'CASH/TWICE', 1),
+			(3, 1, 700, '2025-04-02 00:00:00 +0000 UTC', 'CASH/TWICE', 1),
+			(4, 1, 300, '2025-04-03 00:00:00 +0000 UTC', 'CASH/ALLOCATED', 1)`,
+		`INSERT INTO sale_bills (id, bill_number, bill_date, party_name, amount, party_id, firm_id) VALUES
+			(1, 'A-1', '2025-04-01 00:00:00 +0000 UTC', 'SHARMA MEDICAL', 250, 1, 1),
+			(2, 'A-2', '2025-04-01 00:00:00 +0000 UTC', 'SHARMA MEDICAL', 250, 1, 1),
+			(3, 'A-3', '2025-04-01 00:00:00 +0000 UTC', 'SHARMA MEDICAL', 400, 9, 1)`,
+		`INSERT INTO bill_allocations (sale_bill_id, transaction_id, amount) VALUES (1, 4, 250), (2, 4, 250), (8, 2, 100)`,
+	} {
+		if _, err := db.ExecContext(ctx, query); err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+	}
+
+	results, err := Run(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != len(Checks) {
+		t.Fatalf("got %d results of %d checks", len(results), len(Checks))
+	}
+	want := map[string]int{
+		"Identifiers of missing parties":                     1,
+		"Transactions of missing parties":                    1,
+		"Sale bills linked to missing parties":               1,
+		"Bill allocations of missing bills or receipts":      1,
+		"Receipts allocated to bills beyond their amount":    1,
+		"Identifiers in another firm than their party":       1,
+		"Duplicate transactions":                             1,
+		"Parties whose names differ only in case or spacing": 1,
+		"Identifiers that differ only in case or spacing":    1,
+	}
+	for _, r := range results {
+		if r.Count != want[r.Name] || len(r.Rows) != r.Count {
+			t.Errorf("%s: found %d rows %q, want %d", r.Name, r.Count, r.Rows, want[r.Name])
+		}
+		if r.Count > 0 && r.Fix == "" && r.Hint == "" {
+			t.Errorf("%s: says neither how to fix it nor what to do", r.Name)
+		}
+	}
+	if n := Problems(results); n != 9 {
+		t.Errorf("Problems = %d, want 9", n)
+	}
+
+	// Every fix repairs the rows its check found
+	for _, c := range Checks {
+		if c.Fix == "" {
+			continue
+		}
+		if _, err := db.ExecContext(ctx, c.Fix); err != nil {
+			t.Fatalf("%s: %v", c.Name, err)
+		}
+		res, err := run(ctx, db, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Count != 0 {
+			t.Errorf("%s: %q left after its fix", c.Name, res.Rows)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+// Package jobs runs the server's background work, such as backups and scans,
+// on schedules, and keeps how each job last went to show on an admin page
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Func does a job's work, returning a line on what it did, or nothing when
+// there was nothing to do, which is not logged
+type Func func(ctx context.Context) (string, error)
+
+// Status is how a job has gone since the server started
+type Status struct {
+	Name        string
+	Description string
+	Schedule    string
+	Next        time.Time // zero when the job is not scheduled again
+	Running     bool
+	Runs        int
+	Failures    int
+	LastStart   time.Time
+	LastTook    time.Duration
+	LastResult  string
+	LastError   string // of the last run, empty when it succeeded
+	LastFailure time.Time
+}
+
+// job is a scheduled job with how it has gone
+type job struct {
+	schedule Schedule
+	run      Func
+	status   Status
+}
+
+// Scheduler runs jobs on their schedules, one run of a job at a time
+type Scheduler struct {
+	mu   sync.Mutex
+	jobs []*job
+	ctx  context.Context // the context runs are given, once started
+}
+
+// New returns a scheduler with no jobs
+func New() *Scheduler {
+	return &Scheduler{ctx: context.Background()}
+}
+
+// Add schedules a job; see ParseSchedule for the forms spec takes
+func (s *Scheduler) Add(name, description, spec string, run Func) error {
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.find(name) != nil {
+		return fmt.Errorf("%s: already added", name)
+	}
+	s.jobs = append(s.jobs, &job{
+		schedule: schedule,
+		run:      run,
+		status:   Status{Name: name, Description: description, Schedule: spec},
+	})
+	return nil
+}
+
+// Start runs each job on its schedule until ctx is done. A run due while
+// the last is still going is skipped.
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	s.ctx = ctx
+	jobs := append([]*job(nil), s.jobs...)
+	s.mu.Unlock()
+	for _, j := range jobs {
+		go s.loop(ctx, j)
+	}
+}
+
+// loop waits for each run of a job in turn
+func (s *Scheduler) loop(ctx context.Context, j *job) {
+	for {
+		next := j.schedule.Next(time.Now())
+		s.mu.Lock()
+		j.status.Next = next
+		s.mu.Unlock()
+		if next.IsZero() {
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if !s.begin(j) {
+			log.Printf("Job %s: skipped, the last run has not finished", j.status.Name)
+			continue
+		}
+		s.execute(ctx, j)
+	}
+}
+
+// RunNow starts a run of the named job without waiting for its schedule
+func (s *Scheduler) RunNow(name string) error {
+	s.mu.Lock()
+	j, ctx := s.find(name), s.ctx
+	s.mu.Unlock()
+	if j == nil {
+		return fmt.Errorf("no job named %q", name)
+	}
+	if !s.begin(j) {
+		return fmt.Errorf("%s is already running", name)
+	}
+	go s.execute(ctx, j)
+	return nil
+}
+
+// Status returns how every job has gone, in the order they were added
+func (s *Scheduler) Status() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	statuses := make([]Status, len(s.jobs))
+	for i, j := range s.jobs {
+		statuses[i] = j.status
+	}
+	return statuses
+}
+
+// find returns the named job, or nil; s.mu must be held
+func (s *Scheduler) find(name string) *job {
+	for _, j := range s.jobs {
+		if j.status.Name == name {
+			return j
+		}
+	}
+	return nil
+}
+
+// begin marks a job running, unless it already is
+func (s *Scheduler) begin(j *job) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if j.status.Running {
+		return false
+	}
+	j.status.Running = true
+	j.status.LastStart = time.Now()
+	return true
+}
+
+// execute does a run of a job begun with begin, recording and logging how it
+// went. A panic fails the run rather than the server.
+func (s *Scheduler) execute(ctx context.Context, j *job) {
+	s.mu.Lock()
+	start := j.status.LastStart
+	s.mu.Unlock()
+
+	result, err := func() (result string, err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = fmt.Errorf("panic: %v", p)
+			}
+		}()
+		return j.run(ctx)
+	}()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := &j.status
+	st.Running = false
+	st.Runs++
+	st.LastTook = time.Since(start)
+	st.LastResult = result
+	st.LastError = ""
+	if err != nil {
+		st.Failures++
+		st.LastError = err.Error()
+		st.LastFailure = time.Now()
+		log.Printf("Job %s failed: %v", st.Name, err)
+		return
+	}
+	if result != "" {
+		log.Printf("Job %s: %s (took %s)", st.Name, result, st.LastTook.Round(time.Millisecond))
+	}
+}
@@ -0,0 +1,135 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	ist := time.FixedZone("IST", 5*3600+1800)
+	// A Friday
+	after := time.Date(2026, time.October, 16, 10, 30, 15, 0, ist)
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"02:00", time.Date(2026, time.October, 17, 2, 0, 0, 0, ist)},
+		{"22:15", time.Date(2026, time.October, 16, 22, 15, 0, 0, ist)},
+		{"10:30", time.Date(2026, time.October, 17, 10, 30, 0, 0, ist)},
+		{"every 15m", after.Add(15 * time.Minute)},
+		{"* * * * *", time.Date(2026, time.October, 16, 10, 31, 0, 0, ist)},
+		{"*/20 * * * *", time.Date(2026, time.October, 16, 10, 40, 0, 0, ist)},
+		{"0 9-17/4 * * *", time.Date(2026, time.October, 16, 13, 0, 0, 0, ist)},
+		{"30 8 * * 1-5", time.Date(2026, time.October, 19, 8, 30, 0, 0, ist)},
+		{"0 0 * * 0", time.Date(2026, time.October, 18, 0, 0, 0, 0, ist)},
+		{"0 0 * * 7", time.Date(2026, time.October, 18, 0, 0, 0, 0, ist)},
+		{"0 6 1,15 * *", time.Date(2026, time.November, 1, 6, 0, 0, 0, ist)},
+		{"0 6 1 4 *", time.Date(2027, time.April, 1, 6, 0, 0, 0, ist)},
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, ist)},
+		// Day of month or day of week when both are given: the 20th or a Saturday
+		{"0 0 20 * 6", time.Date(2026, time.October, 17, 0, 0, 0, 0, ist)},
+	}
+	for _, tt := range tests {
+		s, err := ParseSchedule(tt.spec)
+		if err != nil {
+			t.Errorf("ParseSchedule(%q) error: %v", tt.spec, err)
+			continue
+		}
+		if got := s.Next(after); !got.Equal(tt.want) {
+			t.Errorf("ParseSchedule(%q).Next() = %s, want %s", tt.spec, got, tt.want)
+		}
+	}
+
+	never, err := ParseSchedule("0 0 30 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := never.Next(after); !got.IsZero() {
+		t.Errorf("30 February schedule runs at %s", got)
+	}
+
+	for _, spec := range []string{"", "25:00", "every", "every 10ms", "every soon", "* * * *", "60 * * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "a * * * *"} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("ParseSchedule(%q) accepted an invalid schedule", spec)
+		}
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	s := New()
+	release := make(chan struct{})
+	done := make(chan struct{}, 3)
+	calls := 0
+	err := s.Add("scan", "Scans", "02:00", func(ctx context.Context) (string, error) {
+		calls++
+		defer func() { done <- struct{}{} }()
+		switch calls {
+		case 1:
+			<-release
+			return "3 found", nil
+		case 2:
+			return "", errors.New("database is locked")
+		default:
+			panic("boom")
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add("scan", "Again", "03:00", nil); err == nil {
+		t.Error("Add() accepted a second job of the same name")
+	}
+	if err := s.Add("backup", "Backs up", "soon", nil); err == nil {
+		t.Error("Add() accepted an invalid schedule")
+	}
+	if err := s.RunNow("missing"); err == nil {
+		t.Error("RunNow() ran a job that does not exist")
+	}
+
+	if err := s.RunNow("scan"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RunNow("scan"); err == nil {
+		t.Error("RunNow() started a job already running")
+	}
+	if st := s.Status()[0]; !st.Running {
+		t.Errorf("status while running = %+v", st)
+	}
+	close(release)
+	<-done
+	waitIdle(t, s)
+	st := s.Status()[0]
+	if st.Runs != 1 || st.Failures != 0 || st.LastResult != "3 found" || st.LastError != "" || st.Schedule != "02:00" {
+		t.Errorf("status after a run = %+v", st)
+	}
+
+	for _, want := range []string{"database is locked", "panic: boom"} {
+		if err := s.RunNow("scan"); err != nil {
+			t.Fatal(err)
+		}
+		<-done
+		waitIdle(t, s)
+		st = s.Status()[0]
+		if st.LastError != want || st.LastFailure.IsZero() {
+			t.Errorf("status after a failed run = %+v, want error %q", st, want)
+		}
+	}
+	if st.Runs != 3 || st.Failures != 2 {
+		t.Errorf("runs = %d, failures = %d, want 3 and 2", st.Runs, st.Failures)
+	}
+}
+
+// waitIdle waits for the runs started to be recorded
+func waitIdle(t *testing.T, s *Scheduler) {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		if !s.Status()[0].Running {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("job still running")
+}
@@ -0,0 +1,163 @@
+package jobs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule says when a job next runs
+type Schedule interface {
+	// Next is the first time after after that the job runs, or the zero
+	// time when it never runs again
+	Next(after time.Time) time.Time
+}
+
+// ParseSchedule reads a schedule in one of three forms: a local time of day
+// as HH:MM for once a day, "every" and a duration such as "every 15m", or a
+// five-field cron expression of minute, hour, day of month, month and day of
+// week, each *, a number, a range a-b, a list of those, or any of them with a
+// /step (day of week 0 or 7 is Sunday)
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if rest, ok := strings.CutPrefix(spec, "every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+		}
+		if d < time.Second {
+			return nil, fmt.Errorf("invalid schedule %q: the interval must be at least a second", spec)
+		}
+		return interval(d), nil
+	}
+	if at, err := time.Parse("15:04", spec); err == nil {
+		return daily{hour: at.Hour(), minute: at.Minute()}, nil
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid schedule %q: want HH:MM, \"every\" and a duration, or a five-field cron expression", spec)
+	}
+	var c cron
+	var err error
+	for i, f := range []struct {
+		set      *[]bool
+		min, max int
+	}{
+		{&c.minute, 0, 59},
+		{&c.hour, 0, 23},
+		{&c.day, 1, 31},
+		{&c.month, 1, 12},
+		{&c.weekday, 0, 7},
+	} {
+		if *f.set, err = parseField(fields[i], f.min, f.max); err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+		}
+	}
+	c.weekday[0] = c.weekday[0] || c.weekday[7]
+	c.anyDay = fields[2] == "*"
+	c.anyWeekday = fields[4] == "*"
+	return c, nil
+}
+
+// interval runs a job every so often
+type interval time.Duration
+
+func (d interval) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(d))
+}
+
+// daily runs a job once a day at a local time of day
+type daily struct {
+	hour, minute int
+}
+
+func (d daily) Next(after time.Time) time.Time {
+	next := time.Date(after.Year(), after.Month(), after.Day(), d.hour, d.minute, 0, 0, after.Location())
+	if !next.After(after) {
+		next = time.Date(after.Year(), after.Month(), after.Day()+1, d.hour, d.minute, 0, 0, after.Location())
+	}
+	return next
+}
+
+// cron runs a job at the minutes matching every field of a cron expression
+type cron struct {
+	minute, hour, day, month, weekday []bool
+	anyDay, anyWeekday                bool
+}
+
+func (c cron) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	// Five years is enough to find any date that exists, such as 29 February
+	for limit := t.AddDate(5, 0, 0); t.Before(limit); {
+		y, m, d := t.Date()
+		switch {
+		case !c.month[m]:
+			t = time.Date(y, m+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.dayMatches(t):
+			t = time.Date(y, m, d+1, 0, 0, 0, 0, t.Location())
+		case !c.hour[t.Hour()]:
+			t = time.Date(y, m, d, t.Hour()+1, 0, 0, 0, t.Location())
+		case !c.minute[t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches follows cron in running on either the day of month or the day
+// of week when both are given
+func (c cron) dayMatches(t time.Time) bool {
+	day, weekday := c.day[t.Day()], c.weekday[t.Weekday()]
+	switch {
+	case c.anyDay && c.anyWeekday:
+		return true
+	case c.anyDay:
+		return weekday
+	case c.anyWeekday:
+		return day
+	default:
+		return day || weekday
+	}
+}
+
+// parseField reads one field of a cron expression into the values it
+// matches, indexed from 0 up to max
+func parseField(field string, min, max int) ([]bool, error) {
+	set := make([]bool, max+1)
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if r, s, ok := strings.Cut(part, "/"); ok {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+			part, step = r, n
+		}
+		lo, hi := min, max
+		if part != "*" {
+			a, b, isRange := strings.Cut(part, "-")
+			var err error
+			if lo, err = strconv.Atoi(a); err != nil {
+				return nil, fmt.Errorf("invalid value %q", part)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(b); err != nil {
+					return nil, fmt.Errorf("invalid range %q", part)
+				}
+			} else if step > 1 {
+				hi = max
+			}
+			if lo < min || hi > max || lo > hi {
+				return nil, fmt.Errorf("%q is outside %d-%d", part, min, max)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+	return set, nil
+}
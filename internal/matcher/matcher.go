@@ -7,8 +7,12 @@ import (
 	"sort"
 	"strings"
 
+	"go.opentelemetry.io/otel/attribute"
+
 	"suspense.durgadawaghar.com/internal/db/sqlc"
 	"suspense.durgadawaghar.com/internal/extractor"
+	"suspense.durgadawaghar.com/internal/parser"
+	"suspense.durgadawaghar.com/internal/tracing"
 )
 
 // MatchResult represents a party match with confidence score
@@ -31,6 +35,8 @@ type MatchedIdentifier struct {
 // Confidence weights for different identifier types
 const (
 	UPIVPAWeight        = 0.95
+	NACHMandateWeight   = 0.90 // High - a mandate is registered to one payer's account
+	GSTINWeight         = 0.90 // High - a GST registration belongs to one business
 	PhoneWeight         = 0.85
 	AccountNumberWeight = 0.80
 	CashAgentCodeWeight = 0.75 // High - agent codes are unique to depositing agencies
@@ -44,6 +50,25 @@ const (
 	ActcdepWeight       = 0.20 // Low - many parties share ACTCDEP
 )
 
+// A party's payment mode profile moves its confidence by up to
+// PaymentModeBoost either way: up when most of its receipts came in the mode
+// detected in the narration, down when few did. Parties with fewer than
+// minModeProfile receipts have no profile.
+const (
+	PaymentModeBoost = 0.15
+	minModeProfile   = 5
+)
+
+// A party's typical amount moves its confidence by up to AmountBoost either
+// way: up for a receipt within the usual spread of its last amountProfileSize
+// receipts, down for one an order of magnitude or more beyond it. Parties with
+// fewer than minAmountProfile receipts have no profile.
+const (
+	AmountBoost       = 0.10
+	minAmountProfile  = 5
+	amountProfileSize = 200
+)
+
 // Matcher handles party matching logic
 type Matcher struct {
 	queries *sqlc.Queries
@@ -54,10 +79,33 @@ func NewMatcher(q *sqlc.Queries) *Matcher {
 	return &Matcher{queries: q}
 }
 
-// Match finds parties matching the given narration and returns scored results
-func (m *Matcher) Match(ctx context.Context, narration string) ([]MatchResult, error) {
+// Match finds parties of a firm matching the given narration and returns
+// scored results. amount is the receipt's amount, or 0 when not known.
+func (m *Matcher) Match(ctx context.Context, firmID int64, narration string, amount float64) ([]MatchResult, error) {
+	return m.match(ctx, firmID, narration, amount, true)
+}
+
+// Preview finds matches like Match without each party's recent transactions,
+// for results shown while a narration is typed
+func (m *Matcher) Preview(ctx context.Context, firmID int64, narration string, amount float64) ([]MatchResult, error) {
+	return m.match(ctx, firmID, narration, amount, false)
+}
+
+// match finds and scores matching parties, with their recent transactions
+// when recent is set
+func (m *Matcher) match(ctx context.Context, firmID int64, narration string, amount float64, recent bool) (_ []MatchResult, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "matcher.Match")
+	defer func() { tracing.End(span, err) }()
+	span.SetAttributes(attribute.Bool("recent_transactions", recent), attribute.Float64("amount", amount))
+
 	// Extract identifiers from the narration
+	_, extractSpan := tracing.Tracer.Start(ctx, "extractor.Extract")
 	identifiers := extractor.Extract(narration)
+	extractSpan.SetAttributes(attribute.Int("identifiers", len(identifiers)))
+	extractSpan.End()
+
+	mode := m.narrationMode(ctx, narration)
+	span.SetAttributes(attribute.String("payment_mode", mode))
 
 	var matches []sqlc.FindPartiesByIdentifierValuesRow
 
@@ -70,16 +118,21 @@ func (m *Matcher) Match(ctx context.Context, narration string) ([]MatchResult, e
 		}
 
 		// Query database for matching parties
-		var err error
-		matches, err = m.queries.FindPartiesByIdentifierValues(ctx, values)
+		matches, err = m.queries.FindPartiesByIdentifierValues(ctx, sqlc.FindPartiesByIdentifierValuesParams{
+			FirmID: firmID,
+			Values: values,
+		})
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	span.SetAttributes(attribute.Int("identifier_matches", len(matches)))
+
 	// If no identifier matches found, try fallback narration search
 	if len(matches) == 0 {
-		return m.matchByNarration(ctx, narration, identifiers)
+		span.SetAttributes(attribute.Bool("narration_fallback", true))
+		return m.matchByNarration(ctx, firmID, narration, identifiers, mode, amount, recent)
 	}
 
 	// Group matches by party name (not ID) and calculate scores
@@ -119,8 +172,16 @@ func (m *Matcher) Match(ctx context.Context, narration string) ([]MatchResult, e
 
 	// Calculate confidence scores and fetch transaction stats
 	results := make([]MatchResult, 0, len(partyMatches))
+	statsCtx, statsSpan := tracing.Tracer.Start(ctx, "matcher.partyStats")
+	statsSpan.SetAttributes(attribute.Int("parties", len(partyMatches)))
 
 	for _, result := range partyMatches {
+		// An abandoned search stops rather than querying every party
+		if err := ctx.Err(); err != nil {
+			statsSpan.End()
+			return nil, err
+		}
+
 		// Calculate base confidence from identifier matches
 		result.Confidence = calculateConfidence(result.MatchedOn)
 
@@ -130,7 +191,7 @@ func (m *Matcher) Match(ctx context.Context, narration string) ([]MatchResult, e
 		var allRecentTxns []sqlc.Transaction
 
 		for _, partyID := range result.PartyIDs {
-			stats, err := m.queries.GetPartyWithTransactionCount(ctx, partyID)
+			stats, err := m.queries.GetPartyWithTransactionCount(statsCtx, partyID)
 			if err == nil {
 				totalTxCount += stats.TransactionCount
 				if stats.TotalAmount.Valid {
@@ -138,8 +199,11 @@ func (m *Matcher) Match(ctx context.Context, narration string) ([]MatchResult, e
 				}
 			}
 
+			if !recent {
+				continue
+			}
 			// Get recent transactions for this party ID
-			recentTxns, err := m.queries.GetRecentTransactionsByPartyID(ctx, sqlc.GetRecentTransactionsByPartyIDParams{
+			recentTxns, err := m.queries.GetRecentTransactionsByPartyID(statsCtx, sqlc.GetRecentTransactionsByPartyIDParams{
 				PartyID: partyID,
 				Limit:   5,
 			})
@@ -166,8 +230,17 @@ func (m *Matcher) Match(ctx context.Context, narration string) ([]MatchResult, e
 			result.Confidence = math.Min(result.Confidence*historyBoost, 100.0)
 		}
 
+		// Apply payment mode boost: a party who always pays by cheque is
+		// less likely for a UPI narration
+		result.Confidence = math.Min(result.Confidence*m.modeBoost(statsCtx, result.PartyIDs, mode), 100.0)
+
+		// Apply amount boost: a receipt of lakhs is likelier from a party
+		// who pays lakhs than from one who pays hundreds
+		result.Confidence = math.Min(result.Confidence*m.typicalAmountBoost(statsCtx, result.PartyIDs, amount), 100.0)
+
 		results = append(results, *result)
 	}
+	statsSpan.End()
 
 	// Sort by confidence (descending)
 	sort.Slice(results, func(i, j int) bool {
@@ -219,6 +292,10 @@ func calculateConfidence(matches []MatchedIdentifier) float64 {
 			weight = BankNameWeight * 100
 		case string(extractor.TypeActcdep):
 			weight = ActcdepWeight * 100
+		case string(extractor.TypeNACHMandate):
+			weight = NACHMandateWeight * 100
+		case string(extractor.TypeGSTIN):
+			weight = GSTINWeight * 100
 		default:
 			weight = 50 // Unknown type, moderate confidence
 		}
@@ -236,9 +313,9 @@ func calculateConfidence(matches []MatchedIdentifier) float64 {
 	return math.Min(confidence, 100.0)
 }
 
-// MatchSingle finds the best matching party for a narration
-func (m *Matcher) MatchSingle(ctx context.Context, narration string) (*MatchResult, error) {
-	results, err := m.Match(ctx, narration)
+// MatchSingle finds the firm's best matching party for a narration
+func (m *Matcher) MatchSingle(ctx context.Context, firmID int64, narration string, amount float64) (*MatchResult, error) {
+	results, err := m.Match(ctx, firmID, narration, amount)
 	if err != nil {
 		return nil, err
 	}
@@ -250,7 +327,7 @@ func (m *Matcher) MatchSingle(ctx context.Context, narration string) (*MatchResu
 
 // matchByNarration searches for parties by matching narration patterns in transactions
 // This is a fallback when no identifier matches are found
-func (m *Matcher) matchByNarration(ctx context.Context, narration string, identifiers []extractor.Identifier) ([]MatchResult, error) {
+func (m *Matcher) matchByNarration(ctx context.Context, firmID int64, narration string, identifiers []extractor.Identifier, mode string, amount float64, recent bool) ([]MatchResult, error) {
 	// Build search patterns from extracted identifiers (e.g., IMPS names, NEFT names)
 	var patterns []string
 	for _, id := range identifiers {
@@ -295,7 +372,13 @@ func (m *Matcher) matchByNarration(ctx context.Context, narration string, identi
 	partyMatches := make(map[string]*MatchResult)
 
 	for _, pattern := range patterns {
-		matches, err := m.queries.FindPartiesByNarrationPattern(ctx, sql.NullString{String: pattern, Valid: true})
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		matches, err := m.queries.FindPartiesByNarrationPattern(ctx, sqlc.FindPartiesByNarrationPatternParams{
+			Narration: sql.NullString{String: pattern, Valid: true},
+			FirmID:    firmID,
+		})
 		if err != nil {
 			continue
 		}
@@ -328,15 +411,22 @@ func (m *Matcher) matchByNarration(ctx context.Context, narration string, identi
 
 	// Calculate final scores and fetch stats
 	results := make([]MatchResult, 0, len(partyMatches))
+	statsCtx, statsSpan := tracing.Tracer.Start(ctx, "matcher.partyStats")
+	statsSpan.SetAttributes(attribute.Int("parties", len(partyMatches)))
 
 	for _, result := range partyMatches {
+		if err := ctx.Err(); err != nil {
+			statsSpan.End()
+			return nil, err
+		}
+
 		// Aggregate stats from all party IDs
 		var totalTxCount int64
 		var totalAmount float64
 		var allRecentTxns []sqlc.Transaction
 
 		for _, partyID := range result.PartyIDs {
-			stats, err := m.queries.GetPartyWithTransactionCount(ctx, partyID)
+			stats, err := m.queries.GetPartyWithTransactionCount(statsCtx, partyID)
 			if err == nil {
 				totalTxCount += stats.TransactionCount
 				if stats.TotalAmount.Valid {
@@ -344,8 +434,11 @@ func (m *Matcher) matchByNarration(ctx context.Context, narration string, identi
 				}
 			}
 
+			if !recent {
+				continue
+			}
 			// Get recent transactions for this party ID
-			recentTxns, err := m.queries.GetRecentTransactionsByPartyID(ctx, sqlc.GetRecentTransactionsByPartyIDParams{
+			recentTxns, err := m.queries.GetRecentTransactionsByPartyID(statsCtx, sqlc.GetRecentTransactionsByPartyIDParams{
 				PartyID: partyID,
 				Limit:   5,
 			})
@@ -372,8 +465,17 @@ func (m *Matcher) matchByNarration(ctx context.Context, narration string, identi
 			result.Confidence = math.Min(result.Confidence*historyBoost, 100.0)
 		}
 
+		// Apply payment mode boost: a party who always pays by cheque is
+		// less likely for a UPI narration
+		result.Confidence = math.Min(result.Confidence*m.modeBoost(statsCtx, result.PartyIDs, mode), 100.0)
+
+		// Apply amount boost: a receipt of lakhs is likelier from a party
+		// who pays lakhs than from one who pays hundreds
+		result.Confidence = math.Min(result.Confidence*m.typicalAmountBoost(statsCtx, result.PartyIDs, amount), 100.0)
+
 		results = append(results, *result)
 	}
+	statsSpan.End()
 
 	// Sort by confidence (descending)
 	sort.Slice(results, func(i, j int) bool {
@@ -383,6 +485,121 @@ func (m *Matcher) matchByNarration(ctx context.Context, narration string, identi
 	return results, nil
 }
 
+// narrationMode returns the payment mode the payment mode rules detect in a
+// narration, or "" when no rule matches
+func (m *Matcher) narrationMode(ctx context.Context, narration string) string {
+	rules, err := m.queries.ListPaymentModeRules(ctx)
+	if err != nil {
+		return ""
+	}
+	var v parser.Vocabulary
+	for _, rule := range rules {
+		v.PaymentModes = append(v.PaymentModes, parser.PaymentModeRule{Pattern: rule.Pattern, Mode: rule.Mode})
+	}
+	p, err := parser.New(v)
+	if err != nil {
+		return ""
+	}
+	rule, ok := p.MatchPaymentMode(narration)
+	if !ok {
+		return ""
+	}
+	return rule.Mode
+}
+
+// modeBoost returns the payment mode boost of the party with partyIDs, from
+// the modes its receipts came in, for a narration in mode
+func (m *Matcher) modeBoost(ctx context.Context, partyIDs []int64, mode string) float64 {
+	if mode == "" {
+		return 1
+	}
+	receipts := make(map[string]int64)
+	for _, partyID := range partyIDs {
+		modes, err := m.queries.GetPartyPaymentModes(ctx, partyID)
+		if err != nil {
+			continue
+		}
+		for _, row := range modes {
+			receipts[row.PaymentMode] += row.Receipts
+		}
+	}
+	return paymentModeBoost(receipts, mode)
+}
+
+// paymentModeBoost returns the confidence multiplier for a party whose
+// receipts came in the given modes, for a narration in mode: from
+// 1-PaymentModeBoost when none came in it to 1+PaymentModeBoost when all did,
+// and 1 when the mode or the party's profile is unknown
+func paymentModeBoost(receipts map[string]int64, mode string) float64 {
+	if mode == "" {
+		return 1
+	}
+	var total int64
+	for _, n := range receipts {
+		total += n
+	}
+	if total < minModeProfile {
+		return 1
+	}
+	share := float64(receipts[mode]) / float64(total)
+	return 1 + PaymentModeBoost*(2*share-1)
+}
+
+// typicalAmountBoost returns the amount boost of the party with partyIDs,
+// from the amounts of its latest receipts, for a receipt of amount
+func (m *Matcher) typicalAmountBoost(ctx context.Context, partyIDs []int64, amount float64) float64 {
+	if amount <= 0 {
+		return 1
+	}
+	var amounts []float64
+	for _, partyID := range partyIDs {
+		partyAmounts, err := m.queries.GetPartyReceiptAmounts(ctx, sqlc.GetPartyReceiptAmountsParams{
+			PartyID: partyID,
+			Limit:   amountProfileSize,
+		})
+		if err != nil {
+			continue
+		}
+		amounts = append(amounts, partyAmounts...)
+	}
+	return amountBoost(amounts, amount)
+}
+
+// amountBoost returns the confidence multiplier for a party with the given
+// past receipt amounts, for a receipt of amount. Amounts are compared by
+// order of magnitude: the party's typical amount is the median of their
+// logarithms and its spread their median distance from it. The multiplier is
+// 1+AmountBoost within the spread, falling to 1-AmountBoost an order of
+// magnitude beyond it, and 1 when the amount or the profile is unknown.
+func amountBoost(amounts []float64, amount float64) float64 {
+	if amount <= 0 || len(amounts) < minAmountProfile {
+		return 1
+	}
+	logs := make([]float64, len(amounts))
+	for i, a := range amounts {
+		logs[i] = math.Log10(a)
+	}
+	typical := median(logs)
+	deviations := make([]float64, len(logs))
+	for i, l := range logs {
+		deviations[i] = math.Abs(l - typical)
+	}
+	spread := median(deviations)
+
+	beyond := math.Max(0, math.Abs(math.Log10(amount)-typical)-spread)
+	return 1 + AmountBoost*(1-2*math.Min(beyond, 1))
+}
+
+// median returns the middle of values, which it sorts
+func median(values []float64) float64 {
+	sort.Float64s(values)
+	n := len(values)
+	if n%2 == 1 {
+		return values[n/2]
+	}
+	return (values[n/2-1] + values[n/2]) / 2
+}
+
 // containsInt64 checks if a slice contains a value
 func containsInt64(slice []int64, val int64) bool {
 	for _, v := range slice {
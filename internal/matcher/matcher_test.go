@@ -0,0 +1,189 @@
+package matcher
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "modernc.org/sqlite"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+)
+
+// newTestMatcher returns a Matcher on a new database with the schema of
+// internal/db/schema.sql, holding one firm, parties SHARMA MEDICAL (1), whose
+// account number is 9876543210, and GUPTA STORES (2), whose phone is, and a
+// payment mode rule taking CHQ narrations as cheques
+func newTestMatcher(t *testing.T) (*Matcher, *sql.DB) {
+	t.Helper()
+	schema, err := os.ReadFile("../db/schema.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "suspense.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.SetMaxOpenConns(1)
+	for _, stmt := range []string{
+		string(schema),
+		"INSERT INTO firms (id, name) VALUES (1, 'Durga Dawa Ghar')",
+		"INSERT INTO parties (id, name, firm_id) VALUES (1, 'SHARMA MEDICAL', 1), (2, 'GUPTA STORES', 1)",
+		"INSERT INTO identifiers (party_id, type, value, firm_id) VALUES (1, 'account_number', '9876543210', 1), (2, 'phone', '9876543210', 1)",
+		"INSERT INTO payment_mode_rules (pattern, mode) VALUES ('CHQ', 'CHEQUE')",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewMatcher(sqlc.New(db)), db
+}
+
+// addReceipts gives a party receipts of the amounts in mode
+func addReceipts(t *testing.T, db *sql.DB, partyID int64, mode string, amounts ...float64) {
+	t.Helper()
+	for i, amount := range amounts {
+		_, err := db.Exec(`INSERT INTO transactions (party_id, amount, transaction_date, payment_mode, narration, firm_id)
+			VALUES (?, ?, '2025-04-01 00:00:00 +0000 UTC', ?, ?, 1)`, partyID, amount, mode, fmt.Sprintf("%s %d/%d", mode, partyID, i))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// ranking returns the names of the parties matched, best first
+func ranking(t *testing.T, m *Matcher, narration string, amount float64) []string {
+	t.Helper()
+	results, err := m.Match(t.Context(), 1, narration, amount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, r := range results {
+		names = append(names, r.Party.Name)
+	}
+	return names
+}
+
+func TestPaymentModeBoost(t *testing.T) {
+	m, db := newTestMatcher(t)
+	// The same history but for the mode: SHARMA MEDICAL always pays by
+	// cheque, GUPTA STORES by UPI
+	addReceipts(t, db, 1, "CHEQUE", 1000, 1000, 1000, 1000, 1000, 1000)
+	addReceipts(t, db, 2, "UPI", 1000, 1000, 1000, 1000, 1000, 1000)
+
+	// The phone outweighs the account number until the mode is known
+	if got := ranking(t, m, "DEP 9876543210", 0); len(got) != 2 || got[0] != "GUPTA STORES" {
+		t.Errorf("receipt of no mode ranks %v, want GUPTA STORES first", got)
+	}
+	if got := ranking(t, m, "CHQ DEP 9876543210", 0); len(got) != 2 || got[0] != "SHARMA MEDICAL" {
+		t.Errorf("cheque receipt ranks %v, want SHARMA MEDICAL first", got)
+	}
+}
+
+func TestPaymentModeBoostMultiplier(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		receipts map[string]int64
+		mode     string
+		want     float64
+	}{
+		{"all in the mode", map[string]int64{"CHEQUE": 6}, "CHEQUE", 1 + PaymentModeBoost},
+		{"none in the mode", map[string]int64{"UPI": 6}, "CHEQUE", 1 - PaymentModeBoost},
+		{"half in the mode", map[string]int64{"CHEQUE": 3, "UPI": 3}, "CHEQUE", 1},
+		{"too few receipts", map[string]int64{"UPI": minModeProfile - 1}, "CHEQUE", 1},
+		{"no mode", map[string]int64{"UPI": 6}, "", 1},
+	} {
+		if got := paymentModeBoost(tt.receipts, tt.mode); got != tt.want {
+			t.Errorf("%s: boost = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAmountBoost(t *testing.T) {
+	m, db := newTestMatcher(t)
+	// SHARMA MEDICAL pays in tens of thousands, GUPTA STORES in hundreds
+	addReceipts(t, db, 1, "NEFT", 42000, 55000, 38000, 61000, 47500, 50000)
+	addReceipts(t, db, 2, "NEFT", 450, 520, 610, 380, 500, 475)
+
+	// The phone outweighs the account number until the amount is known
+	if got := ranking(t, m, "DEP 9876543210", 0); len(got) != 2 || got[0] != "GUPTA STORES" {
+		t.Errorf("receipt of no amount ranks %v, want GUPTA STORES first", got)
+	}
+	if got := ranking(t, m, "DEP 9876543210", 48000); len(got) != 2 || got[0] != "SHARMA MEDICAL" {
+		t.Errorf("receipt of ₹48,000 ranks %v, want SHARMA MEDICAL first", got)
+	}
+	if got := ranking(t, m, "DEP 9876543210", 500); len(got) != 2 || got[0] != "GUPTA STORES" {
+		t.Errorf("receipt of ₹500 ranks %v, want GUPTA STORES first", got)
+	}
+}
+
+func TestAmountBoostMultiplier(t *testing.T) {
+	typical := []float64{900, 1000, 1000, 1100, 1200}
+	for _, tt := range []struct {
+		name    string
+		amounts []float64
+		amount  float64
+		want    float64
+	}{
+		{"the typical amount", typical, 1000, 1 + AmountBoost},
+		{"an order of magnitude beyond", typical, 120000, 1 - AmountBoost},
+		{"an order of magnitude below", typical, 9, 1 - AmountBoost},
+		{"too few receipts", typical[:minAmountProfile-1], 120000, 1},
+		{"no amount", typical, 0, 1},
+	} {
+		if got := amountBoost(tt.amounts, tt.amount); got != tt.want {
+			t.Errorf("%s: boost = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPreview(t *testing.T) {
+	m, db := newTestMatcher(t)
+	addReceipts(t, db, 1, "NEFT", 1000, 2000)
+	addReceipts(t, db, 2, "UPI", 500)
+	if _, err := db.Exec(`INSERT INTO transactions (party_id, amount, transaction_date, payment_mode, narration, firm_id)
+		VALUES (1, 700, '2025-04-02 00:00:00 +0000 UTC', 'IMPS', 'MMT/IMPS/529816026379/OK', 1)`); err != nil {
+		t.Fatal(err)
+	}
+
+	// By identifier and, with none known, by narration
+	for _, narration := range []string{"DEP 9876543210", "MMT/IMPS/529816026379/OK"} {
+		full, err := m.Match(t.Context(), 1, narration, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		brief, err := m.Preview(t.Context(), 1, narration, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(full) == 0 || len(brief) != len(full) {
+			t.Fatalf("%s: previewed %d matches of %d", narration, len(brief), len(full))
+		}
+		for i := range full {
+			if brief[i].Party.ID != full[i].Party.ID || brief[i].Confidence != full[i].Confidence || brief[i].TransactionCount != full[i].TransactionCount {
+				t.Errorf("%s: preview %d is %s at %.1f%%, match is %s at %.1f%%", narration, i,
+					brief[i].Party.Name, brief[i].Confidence, full[i].Party.Name, full[i].Confidence)
+			}
+			if len(full[i].RecentTxns) == 0 || len(brief[i].RecentTxns) != 0 {
+				t.Errorf("%s: %s has %d recent transactions in the match and %d in the preview", narration,
+					full[i].Party.Name, len(full[i].RecentTxns), len(brief[i].RecentTxns))
+			}
+		}
+	}
+}
+
+func TestMatchCancelled(t *testing.T) {
+	m, db := newTestMatcher(t)
+	addReceipts(t, db, 1, "NEFT", 1000)
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	if results, err := m.Match(ctx, 1, "DEP 9876543210", 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("abandoned search got %d results and error %v", len(results), err)
+	}
+}
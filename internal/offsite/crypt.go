@@ -0,0 +1,81 @@
+package offsite
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// magic starts every encrypted snapshot, naming the format
+const magic = "SUSPENSE-BACKUP-1\n"
+
+// saltSize is the length of the random salt the key is derived with
+const saltSize = 16
+
+// errWrongKey is returned when a snapshot does not decrypt with the key given
+var errWrongKey = errors.New("the snapshot does not decrypt with this key, or it is damaged")
+
+// encrypt seals data with AES-256-GCM under a key derived from passphrase
+// with scrypt and a random salt: the magic, the salt and the nonce, then the
+// ciphertext
+func encrypt(data []byte, passphrase string) ([]byte, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(magic)+len(salt)+len(nonce)+len(data)+aead.Overhead())
+	out = append(out, magic...)
+	out = append(out, salt...)
+	out = append(out, nonce...)
+	// The header is authenticated with the data, so it cannot be swapped
+	return aead.Seal(out, nonce, data, out), nil
+}
+
+// decrypt opens what encrypt sealed
+func decrypt(sealed []byte, passphrase string) ([]byte, error) {
+	if !bytes.HasPrefix(sealed, []byte(magic)) {
+		return nil, fmt.Errorf("not an encrypted snapshot")
+	}
+	header := len(magic) + saltSize
+	if len(sealed) < header {
+		return nil, errWrongKey
+	}
+	aead, err := newAEAD(passphrase, sealed[len(magic):header])
+	if err != nil {
+		return nil, err
+	}
+	header += aead.NonceSize()
+	if len(sealed) < header {
+		return nil, errWrongKey
+	}
+	data, err := aead.Open(nil, sealed[header-aead.NonceSize():header], sealed[header:], sealed[:header])
+	if err != nil {
+		return nil, errWrongKey
+	}
+	return data, nil
+}
+
+func newAEAD(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
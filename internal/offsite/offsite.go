@@ -0,0 +1,163 @@
+// Package offsite keeps encrypted database snapshots in an S3-compatible
+// bucket, given as a URL: s3://ACCESS_KEY:SECRET_KEY@host/bucket/prefix over
+// HTTPS, or s3+http:// for a local MinIO, with ?region= where the service
+// needs one (us-east-1 by default, auto for Cloudflare R2). Snapshots are
+// encrypted before they leave the machine, and only the newest are kept.
+package offsite
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// suffix ends the key of every snapshot, so other objects under the prefix
+// are neither listed nor pruned
+const suffix = ".db.enc"
+
+// Store uploads and fetches snapshots
+type Store struct {
+	bucket     *bucket
+	prefix     string // ends in a slash unless empty
+	passphrase string
+	keep       int
+}
+
+// Snapshot is an encrypted snapshot in the bucket
+type Snapshot struct {
+	Key     string
+	Size    int64
+	Created time.Time
+}
+
+// New returns a store for a bucket URL, encrypting with passphrase and
+// keeping the newest keep snapshots
+func New(bucketURL, passphrase string, keep int) (*Store, error) {
+	u, err := url.Parse(bucketURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing bucket URL: %w", err)
+	}
+	scheme := "https"
+	switch u.Scheme {
+	case "s3":
+	case "s3+http":
+		scheme = "http"
+	default:
+		return nil, fmt.Errorf("bucket URL %q is not s3:// or s3+http://", u.Redacted())
+	}
+	secret, _ := u.User.Password()
+	if u.User.Username() == "" || secret == "" {
+		return nil, fmt.Errorf("bucket URL %q has no ACCESS_KEY:SECRET_KEY", u.Redacted())
+	}
+	name, prefix, _ := strings.Cut(strings.Trim(u.Path, "/"), "/")
+	if u.Host == "" || name == "" {
+		return nil, fmt.Errorf("bucket URL %q is not s3://ACCESS_KEY:SECRET_KEY@host/bucket", u.Redacted())
+	}
+	if prefix != "" {
+		prefix += "/"
+	}
+	if passphrase == "" {
+		return nil, fmt.Errorf("no key to encrypt snapshots with")
+	}
+	if keep < 1 {
+		return nil, fmt.Errorf("keep at least one snapshot, not %d", keep)
+	}
+	region := u.Query().Get("region")
+	if region == "" {
+		region = "us-east-1"
+	}
+	return &Store{
+		bucket: &bucket{
+			endpoint:  scheme + "://" + u.Host,
+			name:      name,
+			region:    region,
+			accessKey: u.User.Username(),
+			secretKey: secret,
+			client:    &http.Client{Timeout: 10 * time.Minute},
+		},
+		prefix:     prefix,
+		passphrase: passphrase,
+		keep:       keep,
+	}, nil
+}
+
+// Location describes where snapshots go, without the credentials
+func (s *Store) Location() string {
+	return s.bucket.endpoint + "/" + s.bucket.name + "/" + s.prefix
+}
+
+// Upload encrypts a snapshot of the database and stores it as name, then
+// deletes all but the newest snapshots. It returns the snapshot's key.
+func (s *Store) Upload(ctx context.Context, name string, data []byte) (string, error) {
+	sealed, err := encrypt(data, s.passphrase)
+	if err != nil {
+		return "", fmt.Errorf("encrypting snapshot: %w", err)
+	}
+	key := s.prefix + name + suffix
+	if err := s.bucket.put(ctx, key, sealed); err != nil {
+		return "", fmt.Errorf("uploading snapshot: %w", err)
+	}
+
+	snapshots, err := s.List(ctx)
+	if err != nil {
+		return key, fmt.Errorf("listing snapshots to prune: %w", err)
+	}
+	for _, old := range prune(snapshots, s.keep) {
+		if err := s.bucket.delete(ctx, old.Key); err != nil {
+			return key, fmt.Errorf("pruning snapshot %s: %w", old.Key, err)
+		}
+	}
+	return key, nil
+}
+
+// List lists the snapshots in the bucket, newest first
+func (s *Store) List(ctx context.Context) ([]Snapshot, error) {
+	objects, err := s.bucket.list(ctx, s.prefix)
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []Snapshot
+	for _, o := range objects {
+		if strings.HasSuffix(o.Key, suffix) {
+			snapshots = append(snapshots, Snapshot{Key: o.Key, Size: o.Size, Created: o.LastModified})
+		}
+	}
+	// Keys name the time they were taken, so they sort in the order taken
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Key > snapshots[j].Key })
+	return snapshots, nil
+}
+
+// Download fetches and decrypts the snapshot under key, or the newest when
+// key is "latest"
+func (s *Store) Download(ctx context.Context, key string) ([]byte, error) {
+	if key == "latest" {
+		snapshots, err := s.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(snapshots) == 0 {
+			return nil, fmt.Errorf("there are no snapshots in %s", s.Location())
+		}
+		key = snapshots[0].Key
+	}
+	if !strings.HasPrefix(key, s.prefix) || !strings.HasSuffix(key, suffix) {
+		return nil, fmt.Errorf("%q is not a snapshot", key)
+	}
+	sealed, err := s.bucket.get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("downloading snapshot: %w", err)
+	}
+	return decrypt(sealed, s.passphrase)
+}
+
+// prune returns the snapshots beyond the newest keep, given newest first
+func prune(snapshots []Snapshot, keep int) []Snapshot {
+	if len(snapshots) <= keep {
+		return nil
+	}
+	return snapshots[keep:]
+}
@@ -0,0 +1,181 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// statementColumns maps ICICI statement fields to CSV columns (0-based); -1
+// means the statement has no such column. A statement either has separate
+// withdrawal and deposit columns, or one amount column with a Cr/Dr column
+// saying which it is.
+type statementColumns struct {
+	Date    int
+	Remarks int
+	Deposit int
+	Amount  int
+	CrDr    int
+}
+
+// statementHeaderSearchRows is how many leading rows are searched for the
+// column header, allowing for the account details above it
+const statementHeaderSearchRows = 30
+
+// iciciAccountPattern finds the account number among the account details
+// above the header; ICICI account numbers are 12 digits
+var iciciAccountPattern = regexp.MustCompile(`(?:^|\D)(\d{12})(?:\D|$)`)
+
+// ErrNoStatementHeader is returned for a CSV file without the columns of an
+// ICICI statement
+var ErrNoStatementHeader = errors.New("no ICICI statement columns found: expected a date, transaction remarks and deposit amount")
+
+// ParseICICIStatement reads the credits of an ICICI Bank statement CSV, as
+// downloaded from internet banking: either the detailed statement, with its
+// "Transaction Remarks" and "Deposit Amount (INR )" columns, or the corporate
+// banking export, with "Description", "Cr/Dr" and "Transaction Amount(INR)".
+// Debits, totals and legend rows are skipped.
+//
+// Each credit's narration reads like the bank account line of a receipt book
+// entry, "ICICI 192105002017 11145.00 UPI/...", so that it is matched,
+// categorized and checked for duplicates the same way. Party names are left
+// for the caller to fill in.
+func (p *Parser) ParseICICIStatement(r io.Reader) ([]Transaction, error) {
+	rows, err := ReadCSVRows(r)
+	if err != nil {
+		return nil, err
+	}
+	header, cols := findStatementHeader(rows)
+	if header < 0 {
+		return nil, ErrNoStatementHeader
+	}
+	account := ""
+	for _, row := range rows[:header] {
+		if m := iciciAccountPattern.FindStringSubmatch(strings.Join(row, " ")); m != nil {
+			account = m[1]
+			break
+		}
+	}
+
+	var transactions []Transaction
+	for _, row := range rows[header+1:] {
+		cell := func(i int) string {
+			if i < 0 || i >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[i])
+		}
+		date, ok := parseStatementDate(cell(cols.Date))
+		if !ok {
+			continue
+		}
+		var amount float64
+		if cols.Deposit >= 0 {
+			amount, ok = parseSaleBillAmount(cell(cols.Deposit))
+		} else if strings.HasPrefix(strings.ToUpper(cell(cols.CrDr)), "C") {
+			amount, ok = parseSaleBillAmount(cell(cols.Amount))
+		} else {
+			ok = false
+		}
+		if !ok {
+			continue
+		}
+
+		remarks := strings.Join(strings.Fields(cell(cols.Remarks)), " ")
+		narration := remarks
+		if account != "" {
+			narration = strings.TrimSpace(fmt.Sprintf("ICICI %s %.2f %s", account, amount, remarks))
+		}
+		tx := Transaction{Date: date, Amount: amount, Narration: narration}
+		p.deriveFromNarration(&tx)
+		if tx.AccountBank == "" {
+			tx.AccountBank = "ICICI"
+		}
+		transactions = append(transactions, tx)
+	}
+	return transactions, nil
+}
+
+// findStatementHeader finds the column header among the first rows of a
+// statement and returns its index and column mapping, or -1 if no row names a
+// date, the remarks and the credited amount
+func findStatementHeader(rows [][]string) (int, statementColumns) {
+	for i := 0; i < len(rows) && i < statementHeaderSearchRows; i++ {
+		cols := guessStatementColumns(rows[i])
+		if cols.Date < 0 || cols.Remarks < 0 {
+			continue
+		}
+		if cols.Deposit >= 0 || (cols.Amount >= 0 && cols.CrDr >= 0) {
+			return i, cols
+		}
+	}
+	return -1, statementColumns{Date: -1, Remarks: -1, Deposit: -1, Amount: -1, CrDr: -1}
+}
+
+// guessStatementColumns maps statement fields to columns by their header
+// names. The transaction date is preferred to the value date, and either to
+// the date a transaction was posted.
+func guessStatementColumns(header []string) statementColumns {
+	cols := statementColumns{Date: -1, Remarks: -1, Deposit: -1, Amount: -1, CrDr: -1}
+	valueDate, anyDate := -1, -1
+	for i, name := range header {
+		switch {
+		case headerHasWord(name, []string{"DATE", "DT"}):
+			switch {
+			case headerHasWord(name, []string{"POSTED"}):
+				if anyDate < 0 {
+					anyDate = i
+				}
+			case headerHasWord(name, []string{"TRANSACTION", "TXN", "TRAN"}):
+				if cols.Date < 0 {
+					cols.Date = i
+				}
+			case headerHasWord(name, []string{"VALUE"}):
+				valueDate = i
+			default:
+				if anyDate < 0 {
+					anyDate = i
+				}
+			}
+		case headerHasWord(name, []string{"REMARKS", "DESCRIPTION", "PARTICULARS", "NARRATION"}):
+			cols.Remarks = i
+		case headerHasWord(name, []string{"DEPOSIT", "DEPOSITS", "CREDIT"}):
+			cols.Deposit = i
+		case headerHasWord(name, []string{"CR"}) && headerHasWord(name, []string{"DR"}):
+			cols.CrDr = i
+		case headerHasWord(name, []string{"AMOUNT", "AMT"}) && !headerHasWord(name, []string{"WITHDRAWAL", "WITHDRAWALS", "DEBIT", "BALANCE"}):
+			cols.Amount = i
+		}
+	}
+	if cols.Date < 0 {
+		cols.Date = valueDate
+	}
+	if cols.Date < 0 {
+		cols.Date = anyDate
+	}
+	return cols
+}
+
+// parseStatementDate parses a statement date, dropping the time some exports
+// give with it, as in 01/05/2025 10:42:17 AM
+func parseStatementDate(s string) (time.Time, bool) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return time.Time{}, false
+	}
+	for _, layout := range saleDateLayouts {
+		if t, err := time.Parse(layout, fields[0]); err == nil {
+			return t, true
+		}
+	}
+	// "01 May 2025" has its date split across fields
+	if len(fields) >= 3 {
+		if t, err := time.Parse("02 Jan 2006", strings.Join(fields[:3], " ")); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
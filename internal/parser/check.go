@@ -0,0 +1,49 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxLineLength is the longest line of pasted text accepted for import; the
+// longest narration of a receipt book or line of a sale bill register is a
+// few hundred characters
+const MaxLineLength = 2000
+
+// maxBinaryFraction is the share of control and replacement characters above
+// which text is taken for binary data
+const maxBinaryFraction = 0.01
+
+// CheckText reports why pasted receipt book or sale bill text cannot be
+// imported: the raw bytes of a PDF, spreadsheet or other file pasted by
+// mistake, or lines far longer than any report has. Such text would only keep
+// the parser busy and import nothing.
+func CheckText(text string) error {
+	if strings.HasPrefix(strings.TrimSpace(text), "%PDF") {
+		return errors.New("this is the raw content of a PDF file; open the PDF and copy its text, or export the report as text")
+	}
+	if !utf8.ValidString(text) || strings.ContainsRune(text, 0) {
+		return errors.New("this is not text but the content of a file; paste the report's text instead")
+	}
+
+	var runes, binary int
+	for _, r := range text {
+		runes++
+		if r == utf8.RuneError || unicode.IsControl(r) && r != '\n' && r != '\r' && r != '\t' && r != '\f' {
+			binary++
+		}
+	}
+	if runes > 0 && float64(binary)/float64(runes) > maxBinaryFraction {
+		return errors.New("this is not text but the content of a file; paste the report's text instead")
+	}
+
+	for i, line := range strings.Split(text, "\n") {
+		if len(line) > MaxLineLength {
+			return fmt.Errorf("line %d is %d characters long, longer than any line of a report; check what was pasted", i+1, len(line))
+		}
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -18,58 +19,66 @@ type Transaction struct {
 	CashBankCode     string // Bank code from cash deposits (e.g., "733300")
 	CashBankLocation string // Bank location from cash deposits (e.g., "TIRWA (UP)")
 	CashAgentCode    string // Agent code from deposits (e.g., "DDG002035")
+	Page             int    // Receipt book page the party line appeared on
+	AccountBank      string // Bank of the shop account credited (e.g., "ICICI")
+	AccountNumber    string // Shop account credited, from the bank account line (e.g., "192105002017")
+
+	// POS settlement details, populated when PaymentMode is "POS"
+	POSTerminalID     string    // Terminal/merchant ID (e.g., "10XX174556")
+	POSBatchNumber    string    // Settlement batch number, if present
+	POSSettlementDate time.Time // Card sale date being settled (e.g., "010525" -> 01 May 2025)
+
+	// Cheque details, populated when PaymentMode is "CHEQUE"
+	ChequeNumber string    // Cheque number (e.g., "704339")
+	ChequeDate   time.Time // Date written on the cheque, if present
 }
 
 var (
-	// Date pattern: "Dec 26", "Jan 1", etc.
-	datePattern = regexp.MustCompile(`^(Jan|Feb|Mar|Apr|May|Jun|Jul|Aug|Sep|Oct|Nov|Dec)\s+(\d{1,2})\s+`)
+	// Date pattern: "Dec 26", "Jan 1", "December 26", "dec 26", "26 Dec", "26 December", etc.
+	// Groups 1-2 capture month-first dates, groups 3-4 capture day-first dates
+	datePattern = regexp.MustCompile(`(?i)^(?:(` + monthToken + `)\.?\s+(\d{1,2})|(\d{1,2})\s+(` + monthToken + `)\.?)\s+`)
 
 	// Receipt book header date range pattern: "01-08-2024 - 31-08-2024"
 	// Captures the year from both dates (we use the second/TO date)
 	receiptBookHeaderPattern = regexp.MustCompile(`^\d{2}-\d{2}-(\d{4})\s+-\s+\d{2}-\d{2}-(\d{4})`)
 
-	// Amount pattern: number with optional decimal at end of line
-	amountPattern = regexp.MustCompile(`(\d+(?:\.\d{2})?)\s*$`)
+	// Receipt book header date range with both dates captured whole
+	receiptBookPeriodPattern = regexp.MustCompile(`^(\d{2}-\d{2}-\d{4})\s+-\s+(\d{2}-\d{2}-\d{4})`)
+
+	// Running total at the foot of a receipt book page, or the closing total:
+	// "SUB TOTAL 2,55,213.50 2,55,213.50" (debit and credit) or "SUB TOTAL 5000.00"
+	bookTotalPattern = regexp.MustCompile(`(?i)^(?:SUB\s+)?TOTAL\s+([\d,]+(?:\.\d{1,2})?)(?:\s+([\d,]+(?:\.\d{1,2})?))?\s*$`)
+
+	// Amount pattern: number at end of line, preceded by whitespace
+	// Handles Indian comma grouping ("1,25,213.00"), western grouping ("125,213.00")
+	// and amounts without paise ("5000")
+	amountPattern = regexp.MustCompile(`(?:^|\s)(\d{1,3}(?:,\d{2})*,\d{3}(?:\.\d{1,2})?|\d{1,3}(?:,\d{3})+(?:\.\d{1,2})?|\d+(?:\.\d{1,2})?)\s*$`)
 
 	// Bank account line pattern: Bank name followed by account number and amount
 	// e.g., "ICICI 192105002017 11145.00"
-	bankAccountPattern = regexp.MustCompile(`^(?i)(ICICI|HDFC|SBI|PNB|AXIS|KOTAK|YES|IDBI|CANARA|BOI|BOB|IDFC|UNION|INDIAN|UCO|CENTRAL|PUNJAB|BARODA|ALLAHABAD|ANDHRA|BANK|STATE)\s+\d+\s+[\d,.]+`)
-
-	// Lines to skip
-	skipPatterns = []*regexp.Regexp{
-		regexp.MustCompile(`(?i)^SUB\s+TOTAL`),
-		regexp.MustCompile(`(?i)Continued\.\.`), // Continued..2, Continued..3, etc.
-		regexp.MustCompile(`(?i)^SUSPENSE\s+A/C`),
-		regexp.MustCompile(`(?i)^\s*$`),
-		regexp.MustCompile(`^-+$`),                                           // Separator lines (-----)
-		regexp.MustCompile(`^=+$`),                                           // Separator lines (=====)
-		regexp.MustCompile(`(?i)^TOTAL\s+[\d,.]+\s+[\d,.]+$`),                // Total line
-		regexp.MustCompile(`(?i)^\*\*\*.*\*\*\*$`),                           // *** End of Report ***
-		regexp.MustCompile(`(?i)^DATE\s+PARTICULARS\s+DEBIT\s+CREDIT`),       // Header line
-		regexp.MustCompile(`(?i)^RECEIPT\s+BOOK`),                            // Receipt book header
-		regexp.MustCompile(`(?i)^DURGA\s+DAWA\s+GHAR`),                       // Company name header
-		regexp.MustCompile(`(?i)^\d{2}-\d{2}-\d{4}\s+-\s+\d{2}-\d{2}-\d{4}`), // Date range header (with optional page number)
-		regexp.MustCompile(`(?i)^E-Mail\s*:`),                                // Email line
-		regexp.MustCompile(`(?i)^D\.?L\.?\s*No\.?\s*:`),                      // DL number line
-		regexp.MustCompile(`(?i)^GSTIN\s*:`),                                 // GSTIN line
-		regexp.MustCompile(`(?i)^\d+/\d+,`),                                  // Address line (60/33,...)
-		regexp.MustCompile(`(?i)^Page\s+No\.`),                               // Page number line
-		regexp.MustCompile(`^\d+(\.\d{2})?\s+\d+(\.\d{2})?$`),                // Balance lines (75901.00 75901.00)
-		regexp.MustCompile(`^,`),                                             // Invoice ref continuation (,DDG)
-	}
-
-	// Payment mode detection patterns
-	// Note: These patterns match anywhere in the narration since bank account info often comes first
-	upiModePattern  = regexp.MustCompile(`(?i)^UPI/|/UPI/|/UPI$|\sUPI/`)
-	impsModePattern = regexp.MustCompile(`(?i)IMPS/|/IMPS/|MMT/IMPS|\sIMPS-IN/|^IMPS-IN/`)
-	neftModePattern = regexp.MustCompile(`(?i)\sNEFT-|^NEFT-|\sNEFT_IN:|^NEFT_IN:`)
-	rtgsModePattern = regexp.MustCompile(`(?i)\sRTGS-|^RTGS-`)
-	clgModePattern  = regexp.MustCompile(`(?i)\sCLG/|^CLG/`)
-	infModePattern  = regexp.MustCompile(`(?i)\sINF/|^INF/|^INFT/|/INFT/|\sINFT/`)
-	trfModePattern  = regexp.MustCompile(`(?i)\sTRF/|^TRF/|\sTRTR/|^TRTR/`)
-	chqModePattern  = regexp.MustCompile(`(?i)Chq\.|Cheque|CHQ`)
-	posModePattern  = regexp.MustCompile(`(?i)FT-MESPOS|MESPOS\s+SET|POS\s+MACHINE`)
-	cashModePattern = regexp.MustCompile(`(?i)^BY\s+CASH|\sBY\s+CASH|CASH\s+DEP|CAM/|\sBY\s+[A-Z].+\s-\d{3,8}\s|^BY\s+[A-Z].+\s-\d{3,8}\s`)
+	bankAccountPattern = regexp.MustCompile(`^(?i)(` + bankToken + `)\s+\d+\s+[\d,.]+`)
+
+	// Bank account within a narration, capturing the bank and account number
+	// e.g., "ICICI 192105002017 11145.00 UPI/..." -> "ICICI", "192105002017"
+	accountPattern = regexp.MustCompile(`(?i)(?:^|\s)(` + bankToken + `)\s+(\d{6,18})\s+[\d,]+(?:\.\d{1,2})?(?:\s|$)`)
+
+	// Page boundary markers: page footer ("Continued..2"), page number ("Page No..2")
+	// and the title line of a repeated page header. A header starting with the
+	// firm's name is found by the firm names of the vocabulary.
+	pageBreakPattern = regexp.MustCompile(`(?i)Continued\.\.|Page\s+No\.|^RECEIPT\s+BOOK`)
+
+	// Page number from "Continued..2" (next page) or "Page No..2" (current page)
+	pageNumberPattern = regexp.MustCompile(`(?i)(?:Continued|Page\s+No)\.+\s*(\d+)`)
+
+	// Column header line that closes a page header block
+	columnHeaderPattern = regexp.MustCompile(`(?i)^DATE\s+PARTICULARS\s+DEBIT\s+CREDIT`)
+
+	// Separator lines around the column header
+	separatorPattern = regexp.MustCompile(`^(?:-+|=+)$`)
+
+	// Cheque pattern: captures cheque number and optional cheque date
+	// Example: "Chq.704339 Dt. 26-12-2025" -> number="704339", date="26-12-2025"
+	chequePattern = regexp.MustCompile(`(?i)\b(?:Chq|Cheque)\.?\s*(?:No\.?\s*)?(\d{4,10})(?:\s+Dt\.?\s*(\d{2}-\d{2}-\d{4}))?`)
 
 	// Cash deposit pattern: captures bank code and location with optional state/district
 	// Example: "BY CASH -733300 TIRWA (UP)" -> code="733300", location="TIRWA (UP)"
@@ -80,6 +89,11 @@ var (
 	// Example: "BY VETERINARY HOUSE -010010 LUCKNOW-AMINABAD" -> code="010010", location="LUCKNOW-AMINABAD"
 	cashDepositNamedPattern = regexp.MustCompile(`BY\s+[A-Z].+\s-(\d{3,8})\s+([A-Z][A-Za-z-]*(?:\s+\([^)]+\))?)`)
 
+	// POS settlement pattern: MESPOS SET <terminal> <DDMMYY> [<batch>]
+	// Example: "FT-MESPOS SET 10XX174556 010525" -> terminal="10XX174556", date="010525"
+	// Example: "FT-MESPOS SET 10XX174556 010525 000123" -> batch="000123"
+	posSettlementPattern = regexp.MustCompile(`(?i)MESPOS\s+SET\s+([A-Z0-9]+)(?:\s+(\d{6}))?(?:\s+(\d+))?`)
+
 	// Agent code pattern: extracts DDG/DDGT-style codes from narration
 	// Must be applied BEFORE invoiceRefPattern strips the Ag. portion
 	agentCodePattern = regexp.MustCompile(`(?i)AG\.?\s*\*?([A-Z]{2,4}\d{6,10})`)
@@ -88,36 +102,98 @@ var (
 	// Matches everything after "Ag." since it's all invoice reference data
 	invoiceRefPattern = regexp.MustCompile(`\s*Ag\.\s*.*$`)
 
-	// Month name to number mapping
+	// Month name to number mapping, keyed by the lowercase three-letter prefix
 	monthMap = map[string]time.Month{
-		"Jan": time.January,
-		"Feb": time.February,
-		"Mar": time.March,
-		"Apr": time.April,
-		"May": time.May,
-		"Jun": time.June,
-		"Jul": time.July,
-		"Aug": time.August,
-		"Sep": time.September,
-		"Oct": time.October,
-		"Nov": time.November,
-		"Dec": time.December,
+		"jan": time.January,
+		"feb": time.February,
+		"mar": time.March,
+		"apr": time.April,
+		"may": time.May,
+		"jun": time.June,
+		"jul": time.July,
+		"aug": time.August,
+		"sep": time.September,
+		"oct": time.October,
+		"nov": time.November,
+		"dec": time.December,
 	}
 )
 
-// Parse parses receipt book text and returns a slice of transactions
+// monthToken matches abbreviated and full month names ("Sep", "Sept", "September")
+const monthToken = `jan(?:uary)?|feb(?:ruary)?|mar(?:ch)?|apr(?:il)?|may|june?|july?|aug(?:ust)?|sep(?:t(?:ember)?)?|oct(?:ober)?|nov(?:ember)?|dec(?:ember)?`
+
+// bankToken matches the bank names that start a bank account line
+const bankToken = `ICICI|HDFC|SBI|PNB|AXIS|KOTAK|YES|IDBI|CANARA|BOI|BOB|IDFC|UNION|INDIAN|UCO|CENTRAL|PUNJAB|BARODA|ALLAHABAD|ANDHRA|BANK|STATE`
+
+// Parse parses receipt book text with the default vocabulary and returns a
+// slice of transactions
 func Parse(text string, year int) []Transaction {
+	return defaultParser.Parse(text, year)
+}
+
+// Stats counts what became of the lines of parsed receipt book text. Lines
+// going unparsed or fewer identifiers per entry is the first sign of a
+// changed narration format.
+type Stats struct {
+	Lines        int // non-blank lines seen
+	Transactions int // transactions produced
+	PageHeaders  int // lines of repeated page headers and footers
+	SkipPatterns int // lines matching a skip pattern, such as totals
+	Suspense     int // lines of SUSPENSE A/C entries, which are not imported
+	Unattached   int // lines before the first entry
+}
+
+// Parse parses receipt book text and returns a slice of transactions
+func (p *Parser) Parse(text string, year int) []Transaction {
+	transactions, _ := p.ParseWithStats(text, year)
+	return transactions
+}
+
+// ParseWithStats parses receipt book text like Parse, also counting what
+// became of its lines
+func (p *Parser) ParseWithStats(text string, year int) ([]Transaction, Stats) {
 	lines := strings.Split(text, "\n")
+	var stats Stats
 	var transactions []Transaction
 	var currentTx *Transaction
 	var narrationLines []string
 	var lastDate time.Time
+	inSuspense := false
+	page := 1
 
 	for i := 0; i < len(lines); i++ {
 		line := strings.TrimSpace(lines[i])
+		if line != "" {
+			stats.Lines++
+		}
+
+		// Page boundary: skip the repeated page header so a transaction split across
+		// pages keeps collecting its narration on the next page. A line starting
+		// with a firm's name only starts one when the column header follows, as
+		// a party may be named after the firm.
+		if pageBreakPattern.MatchString(line) || p.isFirmHeader(lines, i) {
+			end := pageHeaderEnd(lines, i)
+			stats.PageHeaders++
+			for j, l := range lines[i : end+1] {
+				if match := pageNumberPattern.FindStringSubmatch(l); match != nil {
+					if n, err := strconv.Atoi(match[1]); err == nil && n > 0 {
+						page = n
+					}
+				}
+				if j > 0 && strings.TrimSpace(l) != "" {
+					stats.Lines++
+					stats.PageHeaders++
+				}
+			}
+			i = end
+			continue
+		}
 
 		// Skip empty lines and known skip patterns
-		if shouldSkipLine(line) {
+		if p.shouldSkipLine(line) {
+			if line != "" {
+				stats.SkipPatterns++
+			}
 			continue
 		}
 
@@ -125,27 +201,29 @@ func Parse(text string, year int) []Transaction {
 		if match := datePattern.FindStringSubmatch(line); match != nil {
 			// Save previous transaction if exists
 			if currentTx != nil {
-				currentTx.Narration = buildNarration(narrationLines)
-				currentTx.PaymentMode = detectPaymentMode(currentTx.Narration)
-				if currentTx.PaymentMode == "CASH" {
-					currentTx.CashBankCode, currentTx.CashBankLocation = extractCashDepositInfo(currentTx.Narration)
-				}
+				p.finalizeTransaction(currentTx, narrationLines)
 				transactions = append(transactions, *currentTx)
 			}
 
 			// Parse new transaction
-			currentTx = parseFirstLine(line, match, year)
+			currentTx = p.parseFirstLine(line, match, year)
+			currentTx.Page = page
 			lastDate = currentTx.Date
 			narrationLines = nil
 
 			// Check if party name is SUSPENSE A/C
-			if strings.Contains(strings.ToUpper(currentTx.PartyName), "SUSPENSE A/C") {
+			inSuspense = strings.Contains(strings.ToUpper(currentTx.PartyName), "SUSPENSE A/C")
+			if inSuspense {
+				stats.Suspense++
 				currentTx = nil
 				continue
 			}
 		} else if currentTx != nil {
 			// Check if this is a bank account line (should be added to narration)
 			if bankAccountPattern.MatchString(line) {
+				if currentTx.CashAgentCode == "" {
+					currentTx.CashAgentCode = agentCode(line)
+				}
 				cleanLine := invoiceRefPattern.ReplaceAllString(line, "")
 				cleanLine = strings.TrimSpace(cleanLine)
 				if cleanLine != "" {
@@ -155,55 +233,123 @@ func Parse(text string, year int) []Transaction {
 			}
 
 			// Check if this looks like a party line (has amount at end, contains text)
-			if isPartyLine(line) {
+			if p.isPartyLine(line) {
 				// Save current transaction
-				currentTx.Narration = buildNarration(narrationLines)
-				currentTx.PaymentMode = detectPaymentMode(currentTx.Narration)
-				if currentTx.PaymentMode == "CASH" {
-					currentTx.CashBankCode, currentTx.CashBankLocation = extractCashDepositInfo(currentTx.Narration)
-				}
+				p.finalizeTransaction(currentTx, narrationLines)
 				transactions = append(transactions, *currentTx)
 
 				// Create new transaction with inherited date
-				currentTx = parsePartyLine(line, lastDate)
+				currentTx = p.parsePartyLine(line, lastDate)
+				currentTx.Page = page
 				narrationLines = nil
 
 				// Check if party name is SUSPENSE A/C
-				if strings.Contains(strings.ToUpper(currentTx.PartyName), "SUSPENSE A/C") {
+				inSuspense = strings.Contains(strings.ToUpper(currentTx.PartyName), "SUSPENSE A/C")
+				if inSuspense {
+					stats.Suspense++
 					currentTx = nil
-					continue
 				}
 				continue
 			}
 
 			// This is a continuation line (narration)
-			// Remove invoice references
+			// Remove invoice references, keeping the agent code
+			if currentTx.CashAgentCode == "" {
+				currentTx.CashAgentCode = agentCode(line)
+			}
 			cleanLine := invoiceRefPattern.ReplaceAllString(line, "")
 			cleanLine = strings.TrimSpace(cleanLine)
 			if cleanLine != "" {
 				narrationLines = append(narrationLines, cleanLine)
 			}
+		} else if inSuspense {
+			stats.Suspense++
+		} else {
+			stats.Unattached++
 		}
 	}
 
 	// Don't forget the last transaction
 	if currentTx != nil {
-		currentTx.Narration = buildNarration(narrationLines)
-		currentTx.PaymentMode = detectPaymentMode(currentTx.Narration)
-		if currentTx.PaymentMode == "CASH" {
-			currentTx.CashBankCode, currentTx.CashBankLocation = extractCashDepositInfo(currentTx.Narration)
-		}
+		p.finalizeTransaction(currentTx, narrationLines)
 		transactions = append(transactions, *currentTx)
 	}
 
-	return transactions
+	stats.Transactions = len(transactions)
+	return transactions, stats
+}
+
+// finalizeTransaction sets the narration and the fields derived from it once all
+// narration lines for a transaction have been collected
+func (p *Parser) finalizeTransaction(tx *Transaction, narrationLines []string) {
+	tx.Narration = buildNarration(narrationLines)
+	p.deriveFromNarration(tx)
 }
 
-func shouldSkipLine(line string) bool {
+// agentCode returns the first agent code of a line's "Ag." part, which is
+// stripped from the narration, or "" without one
+func agentCode(line string) string {
+	m := agentCodePattern.FindStringSubmatch(invoiceRefPattern.FindString(line))
+	if m == nil {
+		return ""
+	}
+	return strings.ToUpper(m[1])
+}
+
+// deriveFromNarration sets the payment mode, account credited and the cash,
+// POS and cheque details read from a transaction's narration
+func (p *Parser) deriveFromNarration(tx *Transaction) {
+	tx.PaymentMode = p.DetectPaymentMode(tx.Narration)
+	tx.AccountBank, tx.AccountNumber = ExtractBankAccount(tx.Narration)
+	switch tx.PaymentMode {
+	case "CASH":
+		tx.CashBankCode, tx.CashBankLocation = extractCashDepositInfo(tx.Narration)
+	case "POS":
+		tx.POSTerminalID, tx.POSBatchNumber, tx.POSSettlementDate = ExtractPOSSettlementInfo(tx.Narration)
+		if tx.POSSettlementDate.IsZero() {
+			tx.POSSettlementDate = tx.Date
+		}
+	case "CHEQUE":
+		tx.ChequeNumber, tx.ChequeDate = ExtractChequeInfo(tx.Narration)
+	}
+}
+
+// isFirmHeader reports whether lines[i] starts with a firm's name and begins a
+// page header block, ending in the column header
+func (p *Parser) isFirmHeader(lines []string, i int) bool {
+	return p.firmHeader != nil && p.firmHeader.MatchString(strings.TrimSpace(lines[i])) && pageHeaderEnd(lines, i) > i
+}
+
+// pageHeaderMaxLines limits how far ahead a page header block is searched for
+const pageHeaderMaxLines = 15
+
+// pageHeaderEnd returns the index of the last line of the page header block that
+// starts at lines[start]: everything up to the column header line and the
+// separators around it. Company name, address, phone and date range lines in
+// between are skipped even if they look like party lines. If no column header
+// follows, start is returned and only the marker line itself is skipped.
+func pageHeaderEnd(lines []string, start int) int {
+	for j := start + 1; j < len(lines) && j <= start+pageHeaderMaxLines; j++ {
+		line := strings.TrimSpace(lines[j])
+		if datePattern.MatchString(line) {
+			return start
+		}
+		if columnHeaderPattern.MatchString(line) {
+			end := j
+			for end+1 < len(lines) && separatorPattern.MatchString(strings.TrimSpace(lines[end+1])) {
+				end++
+			}
+			return end
+		}
+	}
+	return start
+}
+
+func (p *Parser) shouldSkipLine(line string) bool {
 	if line == "" {
 		return true
 	}
-	for _, pattern := range skipPatterns {
+	for _, pattern := range p.skipPatterns {
 		if pattern.MatchString(line) {
 			return true
 		}
@@ -211,14 +357,16 @@ func shouldSkipLine(line string) bool {
 	return false
 }
 
-func parseFirstLine(line string, dateMatch []string, year int) *Transaction {
+func (p *Parser) parseFirstLine(line string, dateMatch []string, year int) *Transaction {
 	tx := &Transaction{}
 
-	// Parse date
-	monthStr := dateMatch[1]
-	dayStr := dateMatch[2]
+	// Parse date (either "Dec 26" or "26 Dec" ordering)
+	monthStr, dayStr := dateMatch[1], dateMatch[2]
+	if monthStr == "" {
+		dayStr, monthStr = dateMatch[3], dateMatch[4]
+	}
 	day, _ := strconv.Atoi(dayStr)
-	month := monthMap[monthStr]
+	month := monthMap[strings.ToLower(monthStr[:3])]
 	tx.Date = time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
 
 	// Remove date from line
@@ -226,20 +374,20 @@ func parseFirstLine(line string, dateMatch []string, year int) *Transaction {
 
 	// Extract amount from end
 	if amountMatch := amountPattern.FindStringSubmatch(remaining); amountMatch != nil {
-		tx.Amount, _ = strconv.ParseFloat(amountMatch[1], 64)
+		tx.Amount = parseAmount(amountMatch[1])
 		remaining = amountPattern.ReplaceAllString(remaining, "")
 	}
 
 	// Remaining is party name + location
 	remaining = strings.TrimSpace(remaining)
-	tx.PartyName, tx.Location = parsePartyNameLocation(remaining)
+	tx.PartyName, tx.Location = p.parsePartyNameLocation(remaining)
 
 	return tx
 }
 
 // isPartyLine checks if a line looks like a party name with amount (but no date)
 // Used to detect additional parties in multi-party transactions
-func isPartyLine(line string) bool {
+func (p *Parser) isPartyLine(line string) bool {
 	// Must have an amount at the end
 	if !amountPattern.MatchString(line) {
 		return false
@@ -247,13 +395,7 @@ func isPartyLine(line string) bool {
 
 	// Should not start with known narration patterns
 	upperLine := strings.ToUpper(line)
-	narrationPrefixes := []string{
-		"UPI/", "NEFT-", "NEFT_", "RTGS-", "IMPS/", "IMPS-", "MMT/", "CLG/", "INF/", "INFT/", "TRF/", "TRTR/",
-		"CHQ.", "CHEQUE", "BY CASH", "FT-MESPOS", "BIL/",
-		"AG.", "AG ", // Invoice reference lines (Ag. DDG...) - should not be party lines
-		"FROM:",      // AEPS-style narration (From:XXXX8723:NAME)
-	}
-	for _, prefix := range narrationPrefixes {
+	for _, prefix := range p.narrationPrefixes {
 		if strings.HasPrefix(upperLine, prefix) {
 			return false
 		}
@@ -288,7 +430,7 @@ func isPartyLine(line string) bool {
 }
 
 // parsePartyLine parses a line that has party name and amount but no date
-func parsePartyLine(line string, inheritedDate time.Time) *Transaction {
+func (p *Parser) parsePartyLine(line string, inheritedDate time.Time) *Transaction {
 	tx := &Transaction{
 		Date: inheritedDate,
 	}
@@ -297,91 +439,49 @@ func parsePartyLine(line string, inheritedDate time.Time) *Transaction {
 
 	// Extract amount from end
 	if amountMatch := amountPattern.FindStringSubmatch(remaining); amountMatch != nil {
-		tx.Amount, _ = strconv.ParseFloat(amountMatch[1], 64)
+		tx.Amount = parseAmount(amountMatch[1])
 		remaining = amountPattern.ReplaceAllString(remaining, "")
 	}
 
 	// Remaining is party name + location
 	remaining = strings.TrimSpace(remaining)
-	tx.PartyName, tx.Location = parsePartyNameLocation(remaining)
+	tx.PartyName, tx.Location = p.parsePartyNameLocation(remaining)
 
 	return tx
 }
 
-func parsePartyNameLocation(text string) (name, location string) {
+// parseAmount parses an amount that may contain comma grouping (e.g., "1,25,213.00")
+func parseAmount(s string) float64 {
+	amount, _ := strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+	return amount
+}
+
+func (p *Parser) parsePartyNameLocation(text string) (name, location string) {
 	text = strings.TrimSpace(text)
 
-	// Words that should NOT be treated as locations even if they look like one
-	nonLocationWords := map[string]bool{
-		"BUSINESS": true,
-		"MACHINE":  true,
-		"STORE":    true,
-		"AGENCY":   true,
-		"TRADERS":  true,
-		"PHARMA":   true,
-		"CHEMIST":  true,
-		"MEDICOS":  true,
-		"MEDICAL":  true,
-		"DRUG":     true,
-		"HOUSE":    true,
-		"HALL":     true,
-		"CENTRE":   true,
-		"CENTER":   true,
-	}
-
-	// Common location indicators (uppercase versions)
-	// Includes major cities and locations from receipt book data
-	locationIndicators := []string{
-		// Major Indian cities
-		"DELHI", "MUMBAI", "KOLKATA", "CHENNAI", "BANGALORE", "HYDERABAD",
-		"AHMEDABAD", "PUNE", "SURAT", "JAIPUR", "LUCKNOW", "KANPUR",
-		"NAGPUR", "INDORE", "THANE", "BHOPAL", "PATNA", "VADODARA",
-		"GHAZIABAD", "LUDHIANA", "AGRA", "NASHIK", "FARIDABAD", "MEERUT",
-		"RAJKOT", "VARANASI", "SRINAGAR", "AURANGABAD", "DHANBAD", "AMRITSAR",
-		"JODHPUR", "RAIPUR", "RANCHI", "GWALIOR", "CHANDIGARH", "VIJAYAWADA",
-		"MADURAI", "COIMBATORE", "KOCHI", "GUWAHATI", "BHUBANESWAR", "DEHRADUN",
-		"NOIDA", "GURUGRAM", "GURGAON", "NCR", "GWALIOUR",
-		// UP towns and areas from receipt book
-		"SEKHREJ", "SHAMBHUA", "MUSKRA", "BILLHAUR", "RASULABAD", "MUNGISAPUR",
-		"JUNIHA", "MAHARAMAU", "AKBARPUR", "AKABARPUR", "CHIBRAMAU", "DHAURA",
-		"CHAMIYANI", "CHAUDAGRA", "BARAUR", "INDERGAR", "GHATAMPUR", "BITHOOR",
-		"BIGHAPUR", "BAIRAGIHAR", "SIKANDRA", "ACHALGANJ", "PUKHRAYA", "PUKHRAYAN",
-		"DIBIAPUR", "DIBIYAPUR", "MIYAGANJ", "AURAIYA", "LALITPUR", "MAKANPUR",
-		"RAATH", "KHAKHRERU", "SAHAYAL", "CHANI", "SAJETI", "BASIRAT", "JALLAUN",
-		"BANGARMAU", "ALIYAPUR", "TIRWA", "BAKEWAR", "BHAUTY", "KANNOUJ", "KONCH",
-		"NAWABGANJ", "FATEHPUR", "ORAI", "HARDOI", "UNNAO", "SITAPUR", "ETAWAH",
-		"BANDA", "JHANSI", "HAMEERPUR", "BHEWAN", "NABIPUR", "TISTI", "UMARDA",
-		"TALEGRAM", "KENJARI", "KENJARY", "JHIJHAK", "HASEERAN", "SHIVRAJPUR",
-		"BAHOSI", "KUDANY", "VISHDHAN", "KAKVAN", "MAUDAHA", "JAHANABAD",
-		"MURADIPUR", "PARSAULI", "AJGAIN", "RAMAIPUR", "DHANI", "BARUA", "SAHAR",
-		"KHAJUA", "BARUA", "FARRUKHABAD", "LAKHIMPUR", "GONDA", "SHIVLI",
-		"MANIMAU", "ROORA", "ROOMA", "RANIA", "NOONARI", "NARWAL", "TIKRA",
-		"BHARUA", "CHHIBRAMAU", "FAZALGANJ", "KALYANPUR", "KALYAN", "KAKADEV",
-		"BIRHANA", "MANISHA", "SUMER", "BEEGAHPUR", "HASWA", "SIRATHU",
-		"VIJAIPUR", "ATARDHANI", "MAURANIPUR", "SACHENDI", "BITHHOR", "BARAIGHAR",
-		"HAPUR", "GEHLO", "DEHAT",
-		// Additional locations from June 2025 receipt book
-		"NAUBASTA", "PANKI", "BHAGHPUR", "NARAMAU", "THATHIA", "REWARI",
-		"BAIRAMPUR", "GALUAPUR", "SAROSI", "AGAUS", "PATARA", "BANIPARA",
-		"MAQSUDABAD", "TIGAI", "HAIDRABAD", "KHEDA", "ALLIPUR", "ASHOTHAR",
-		"THARIYAOAN", "SIMRI", "CHAURA", "CHOWKI", "CHHILLA", "SAHLI",
-		"SAKURABAD", "SUMRAHA", "MURADAB", "GURSHAYAN",
-		"BARADEVI", "BARRA", "PATARSA", "KHAGA", "KORIYAN",
-		"BHOGNIPUR", "RAJPUR", "SAHJHANPUR",
-		// Additional locations from July 2025 receipt book
-		"CHITRAKOOT", "PRAYAGRAJ", "LALPUR", "BIHARIPURWA", "AHIRWA",
-		"MANAVATI", "JAFARGANJ", "KATHARA", "LALGANJ", "HUSAIN",
-		"DILEEP", "BAHUA", "KHAIR", "ROSHNMAU", "GAJNER", "KANCHAUSI",
-		"UGU", "JAMUKA", "FARIDPUR", "UMRI", "BADARKA", "ALIYAPUR",
-		// Additional locations from October 2025 receipt book
-		"ASHOTHAR", "PURAMEER", "BASREHAR", "AUSER", "GUJANI", "JALALABAD",
-		"SHAHNAGAR", "AMRAUDHA", "COLONELGANJ", "MAINPURI", "NADEMAU",
-		"AUNG", "GAYA", "SHIVALI", "BABARO", "BELA", "SINGHPUR", "AMAULI",
-		"RAWATPUR", "NAGAR", "KHANPUR", "KHAR", "RATH",
-		// Additional locations from October 2025 full data
-		"MUNSI", "GAO", "CHAURA", "SUMER", "KHERA",
-		// Additional locations from April 2025 PNB data
-		"LUDHIYANI", "INDERGARH",
+	// Words that complete a location when they follow a dictionary location
+	// (KANPUR DEHAT, BIRHANA ROAD, MUNSI GANJ)
+	locationSuffixWords := map[string]bool{
+		"DEHAT":    true,
+		"NAGAR":    true,
+		"ROAD":     true,
+		"GANJ":     true,
+		"BAZAR":    true,
+		"BAZAAR":   true,
+		"CHAURAHA": true,
+		"CHOWK":    true,
+		"MOD":      true,
+		"KHURD":    true,
+		"KALAN":    true,
+	}
+
+	isLocation := func(word string) bool {
+		for _, loc := range p.locations {
+			if word == loc || strings.HasPrefix(word, loc) {
+				return true
+			}
+		}
+		return false
 	}
 
 	words := strings.Fields(text)
@@ -393,18 +493,27 @@ func parsePartyNameLocation(text string) (name, location string) {
 	lastWord := strings.ToUpper(words[len(words)-1])
 
 	// Skip if it's a known non-location word
-	if nonLocationWords[lastWord] {
+	if p.nonLocationWords[lastWord] {
 		return text, ""
 	}
 
-	for _, loc := range locationIndicators {
-		if lastWord == loc || strings.HasPrefix(lastWord, loc) {
-			if len(words) > 1 {
-				return strings.Join(words[:len(words)-1], " "), words[len(words)-1]
-			}
+	// Multi-word locations need at least one word left for the party name
+	for _, phrase := range p.locationPhrases {
+		n := len(phrase)
+		if len(words) > n && slices.Equal(upperWords(words[len(words)-n:]), phrase) {
+			return strings.Join(words[:len(words)-n], " "), strings.Join(words[len(words)-n:], " ")
+		}
+	}
+	if len(words) > 2 {
+		if locationSuffixWords[lastWord] && isLocation(strings.ToUpper(words[len(words)-2])) {
+			return strings.Join(words[:len(words)-2], " "), strings.Join(words[len(words)-2:], " ")
 		}
 	}
 
+	if isLocation(lastWord) && len(words) > 1 {
+		return strings.Join(words[:len(words)-1], " "), words[len(words)-1]
+	}
+
 	// If last word is all caps and short (< 15 chars), might be location
 	if len(words) > 1 && len(lastWord) < 15 && lastWord == words[len(words)-1] {
 		// Check if it's alphabetic only (typical for place names)
@@ -423,10 +532,30 @@ func parsePartyNameLocation(text string) (name, location string) {
 	return text, ""
 }
 
+// upperWords returns words in uppercase
+func upperWords(words []string) []string {
+	upper := make([]string, len(words))
+	for i, w := range words {
+		upper[i] = strings.ToUpper(w)
+	}
+	return upper
+}
+
 func buildNarration(lines []string) string {
 	return strings.Join(lines, " ")
 }
 
+// ExtractBankAccount extracts the shop bank account credited from the bank
+// account line in a narration
+// Example: "ICICI 192105002017 11744.00 Chq.704339" -> "ICICI", "192105002017"
+func ExtractBankAccount(narration string) (bank string, number string) {
+	matches := accountPattern.FindStringSubmatch(narration)
+	if matches == nil {
+		return "", ""
+	}
+	return strings.ToUpper(matches[1]), matches[2]
+}
+
 // extractCashDepositInfo extracts bank code and location from cash deposit narrations
 // Example: "BY CASH -733300 TIRWA (UP)" -> "733300", "TIRWA (UP)"
 func extractCashDepositInfo(narration string) (bankCode string, bankLocation string) {
@@ -441,40 +570,59 @@ func extractCashDepositInfo(narration string) (bankCode string, bankLocation str
 	return "", ""
 }
 
-func detectPaymentMode(narration string) string {
-	if rtgsModePattern.MatchString(narration) {
-		return "RTGS"
-	}
-	if neftModePattern.MatchString(narration) {
-		return "NEFT"
-	}
-	if impsModePattern.MatchString(narration) {
-		return "IMPS"
-	}
-	if upiModePattern.MatchString(narration) {
-		return "UPI"
-	}
-	if clgModePattern.MatchString(narration) {
-		return "CLG"
-	}
-	if infModePattern.MatchString(narration) {
-		return "INF"
-	}
-	if trfModePattern.MatchString(narration) {
-		return "TRF"
+// ExtractPOSSettlementInfo extracts terminal ID, batch number and settlement date
+// from card machine settlement narrations
+// Example: "FT-MESPOS SET 10XX174556 010525" -> "10XX174556", "", 01 May 2025
+func ExtractPOSSettlementInfo(narration string) (terminalID string, batchNumber string, settlementDate time.Time) {
+	matches := posSettlementPattern.FindStringSubmatch(narration)
+	if matches == nil {
+		return "", "", time.Time{}
+	}
+	terminalID = strings.ToUpper(matches[1])
+	batchNumber = matches[3]
+	if matches[2] != "" {
+		if date, err := time.Parse("020106", matches[2]); err == nil {
+			settlementDate = date
+		}
 	}
-	if chqModePattern.MatchString(narration) {
-		return "CHEQUE"
+	return terminalID, batchNumber, settlementDate
+}
+
+// ExtractChequeInfo extracts the cheque number and cheque date from a narration
+// Example: "Chq.704339 Dt. 26-12-2025" -> "704339", 26 Dec 2025
+func ExtractChequeInfo(narration string) (number string, date time.Time) {
+	matches := chequePattern.FindStringSubmatch(narration)
+	if matches == nil {
+		return "", time.Time{}
 	}
-	if posModePattern.MatchString(narration) {
-		return "POS"
+	if matches[2] != "" {
+		if d, err := time.Parse("02-01-2006", matches[2]); err == nil {
+			date = d
+		}
 	}
-	if cashModePattern.MatchString(narration) {
-		return "CASH"
+	return matches[1], date
+}
+
+// DetectPaymentMode returns the mode of the first payment mode rule matching
+// the narration, or OTHER
+func (p *Parser) DetectPaymentMode(narration string) string {
+	if rule, ok := p.MatchPaymentMode(narration); ok {
+		return rule.Mode
 	}
 	return "OTHER"
 }
 
+// MatchPaymentMode returns the first payment mode rule whose pattern matches
+// the narration
+func (p *Parser) MatchPaymentMode(narration string) (PaymentModeRule, bool) {
+	for _, rule := range p.paymentModes {
+		if rule.pattern.MatchString(narration) {
+			return rule.PaymentModeRule, true
+		}
+	}
+	return PaymentModeRule{}, false
+}
+
 // ExtractYearFromHeader extracts the year from the receipt book header date range.
 // Header format: "01-08-2024 - 31-08-2024" (with optional page number suffix)
 // Returns the year from the "TO" date (second date), or 0 if not found.
@@ -493,6 +641,51 @@ func ExtractYearFromHeader(text string) int {
 	return 0
 }
 
+// BookTotal is the closing total of a receipt book, to check the entries
+// imported from it against
+type BookTotal struct {
+	From, To time.Time
+	// Total is the credit figure of the last SUB TOTAL or TOTAL line; sub
+	// totals run on from page to page, so the last one covers the whole book
+	Total float64
+	// Suspense is the sum of the SUSPENSE A/C entries, which are in the total
+	// but are not imported
+	Suspense float64
+}
+
+// ExtractBookTotal returns the period and closing total of receipt book text.
+// It reports false if the text has no date range header or no total line.
+func ExtractBookTotal(text string) (BookTotal, bool) {
+	var bt BookTotal
+	var haveTotal bool
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if match := receiptBookPeriodPattern.FindStringSubmatch(line); match != nil && bt.From.IsZero() {
+			from, err1 := time.Parse("02-01-2006", match[1])
+			to, err2 := time.Parse("02-01-2006", match[2])
+			if err1 == nil && err2 == nil {
+				bt.From, bt.To = from, to
+			}
+			continue
+		}
+		if match := bookTotalPattern.FindStringSubmatch(line); match != nil {
+			credit := match[1]
+			if match[2] != "" {
+				credit = match[2]
+			}
+			bt.Total = parseAmount(credit)
+			haveTotal = true
+			continue
+		}
+		if strings.Contains(strings.ToUpper(line), "SUSPENSE A/C") && !bankAccountPattern.MatchString(line) {
+			if match := amountPattern.FindStringSubmatch(line); match != nil {
+				bt.Suspense += parseAmount(match[1])
+			}
+		}
+	}
+	return bt, haveTotal && !bt.From.IsZero()
+}
+
 // ParseWithAutoYear parses receipt book text and auto-detects year from content
 // or uses the current year as default
 func ParseWithAutoYear(text string) []Transaction {
@@ -1,6 +1,9 @@
 package parser
 
 import (
+	"archive/zip"
+	"bytes"
+	"strings"
 	"testing"
 	"time"
 )
@@ -123,6 +126,28 @@ Chq.206132 Dt. 07-05-2025 Ag. *DDG028429,*DDG028437,*DDG028498,*DDG028723,*DDG02
 	}
 }
 
+func TestParseKeepsAgentCode(t *testing.T) {
+	input := `Dec 20 SANDHYA MEDICAL STORE TIRWA 1200.00
+ICICI 000105001234 1200.00
+BY CASH -733300 TIRWA Ag. *ddg002035,*DDG002036
+
+Dec 21 GUPTA STORES UNNAO 800.00
+ICICI 000105001234 800.00
+BY CASH -733301 UNNAO BAGS123456`
+
+	transactions := Parse(input, 2025)
+	if len(transactions) != 2 {
+		t.Fatalf("Expected 2 transactions, got %d", len(transactions))
+	}
+	// The first code of the stripped invoice reference is the agent's
+	if transactions[0].CashAgentCode != "DDG002035" {
+		t.Errorf("Expected agent code DDG002035, got %q", transactions[0].CashAgentCode)
+	}
+	if transactions[1].CashAgentCode != "" {
+		t.Errorf("Expected no agent code without an Ag. reference, got %q", transactions[1].CashAgentCode)
+	}
+}
+
 func TestParsePartyNameLocation(t *testing.T) {
 	tests := []struct {
 		input        string
@@ -135,11 +160,18 @@ func TestParsePartyNameLocation(t *testing.T) {
 		{"STORE MUMBAI", "STORE", "MUMBAI"},
 		{"PAYTM BUSINESS", "PAYTM BUSINESS", ""},       // BUSINESS is not a location
 		{"ICICI POS MACHINE", "ICICI POS MACHINE", ""}, // MACHINE is not a location
+		{"PANKAJ MEDICAL STOERE KANPUR DEHAT", "PANKAJ MEDICAL STOERE", "KANPUR DEHAT"},
+		{"UPMANYU TRADERS BIRHANA ROAD", "UPMANYU TRADERS", "BIRHANA ROAD"},
+		{"LAXMI MEDICAL STORE MUNSI GANJ", "LAXMI MEDICAL STORE", "MUNSI GANJ"},
+		{"GUPTA MEDICAL KIDWAI NAGAR", "GUPTA MEDICAL", "KIDWAI NAGAR"},
+		{"RAJ MEDICAL STORE NAGAR", "RAJ MEDICAL STORE", "NAGAR"}, // Suffix word alone
+		{"SHIV TRADERS ROAD", "SHIV TRADERS", "ROAD"},             // Suffix without dictionary location
+		{"KANPUR DEHAT", "KANPUR", "DEHAT"},                       // Party name must not be empty
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.input, func(t *testing.T) {
-			name, location := parsePartyNameLocation(tt.input)
+			name, location := defaultParser.parsePartyNameLocation(tt.input)
 			if name != tt.wantName {
 				t.Errorf("parsePartyNameLocation() name = %v, want %v", name, tt.wantName)
 			}
@@ -150,6 +182,30 @@ func TestParsePartyNameLocation(t *testing.T) {
 	}
 }
 
+func TestParsePartyNameLocationPhrases(t *testing.T) {
+	p, err := New(Vocabulary{Locations: []string{"LUCKNOW", "swaroop  nagar", "ARYA NAGAR CHAURAHA"}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	tests := []struct {
+		input        string
+		wantName     string
+		wantLocation string
+	}{
+		{"SHARMA MEDICAL SWAROOP NAGAR", "SHARMA MEDICAL", "SWAROOP NAGAR"},
+		{"Sharma Medical Swaroop Nagar", "Sharma Medical", "Swaroop Nagar"},
+		{"GUPTA AGENCY ARYA NAGAR CHAURAHA", "GUPTA AGENCY", "ARYA NAGAR CHAURAHA"},
+		{"SWAROOP NAGAR", "SWAROOP", "NAGAR"}, // Party name must not be empty
+		{"SANDHYA MEDICAL LUCKNOW", "SANDHYA MEDICAL", "LUCKNOW"},
+	}
+	for _, tt := range tests {
+		name, location := p.parsePartyNameLocation(tt.input)
+		if name != tt.wantName || location != tt.wantLocation {
+			t.Errorf("parsePartyNameLocation(%q) = %q, %q, want %q, %q", tt.input, name, location, tt.wantName, tt.wantLocation)
+		}
+	}
+}
+
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > 0 && containsHelper(s, substr))
 }
@@ -197,11 +253,11 @@ UPI/545843195657/UPI/ALOK7860855471@/PUNJAB NATIONAL/ICIB5D9264C992C4AFD848F`
 	// Check second transaction
 	if len(transactions) > 1 {
 		tx := transactions[1]
-		if tx.PartyName != "PANKAJ MEDICAL STOERE KANPUR" {
-			t.Errorf("Expected party name 'PANKAJ MEDICAL STOERE KANPUR', got '%s'", tx.PartyName)
+		if tx.PartyName != "PANKAJ MEDICAL STOERE" {
+			t.Errorf("Expected party name 'PANKAJ MEDICAL STOERE', got '%s'", tx.PartyName)
 		}
-		if tx.Location != "DEHAT" {
-			t.Errorf("Expected location 'DEHAT', got '%s'", tx.Location)
+		if tx.Location != "KANPUR DEHAT" {
+			t.Errorf("Expected location 'KANPUR DEHAT', got '%s'", tx.Location)
 		}
 		if tx.Amount != 3780.00 {
 			t.Errorf("Expected amount 3780.00, got %f", tx.Amount)
@@ -227,8 +283,8 @@ UPI/100270440630/FOR MEDICAL/8299120242@HDFC/HDFCBANK LTD/HDFD65E8311250F4F3`
 
 	if len(transactions) > 0 {
 		tx := transactions[0]
-		if tx.PartyName != "UPMANYU TRADERS BIRHANA" {
-			t.Errorf("Expected party name 'UPMANYU TRADERS BIRHANA', got '%s'", tx.PartyName)
+		if tx.PartyName != "UPMANYU TRADERS" {
+			t.Errorf("Expected party name 'UPMANYU TRADERS', got '%s'", tx.PartyName)
 		}
 		if tx.PaymentMode != "UPI" {
 			t.Errorf("Expected payment mode 'UPI', got '%s'", tx.PaymentMode)
@@ -292,11 +348,11 @@ SUB TOTAL 73494.00 73494.00
 		amount      float64
 		paymentMode string
 	}{
-		{"UPMANYU TRADERS BIRHANA", 11145.00, "UPI"},
+		{"UPMANYU TRADERS", 11145.00, "UPI"},
 		{"AMIT MED STORE", 1440.00, "UPI"},
 		{"CASH", 384000.00, "CASH"},
 		{"NIDHI MEDICAL STORE", 5361.00, "OTHER"}, // Empty narration (bank lines go to PANKAJ)
-		{"PANKAJ MEDICAL STOERE KANPUR", 3780.00, "UPI"},
+		{"PANKAJ MEDICAL STOERE", 3780.00, "UPI"},
 		{"SHRI RAM MEDICAL STORE", 17183.00, "CHEQUE"},
 	}
 
@@ -329,11 +385,14 @@ func TestIsPartyLine(t *testing.T) {
 		{"BY CASH -KANPUR - BIRHANA ROAD MANISHA", false},               // Narration
 		{"5361.00", false}, // Just amount
 		{"STORE", false},   // Single word
+		{"MAA DURGA MEDICAL STORE BILLHAUR 1,25,213.00", true}, // Indian comma grouping
+		{"MAA DURGA MEDICAL STORE BILLHAUR 5000", true},        // No paise
+		{"MESPOS SET 10XX174557", false},                       // Digits glued to text are not an amount
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.line, func(t *testing.T) {
-			got := isPartyLine(tt.line)
+			got := defaultParser.isPartyLine(tt.line)
 			if got != tt.expected {
 				t.Errorf("isPartyLine(%q) = %v, want %v", tt.line, got, tt.expected)
 			}
@@ -1056,11 +1115,11 @@ ICICI 192105002017 144.00
 
 	if len(transactions) > 0 {
 		tx := transactions[0]
-		if tx.PartyName != "LAXMI MEDICAL STORE MUNSI" {
-			t.Errorf("Expected party 'LAXMI MEDICAL STORE MUNSI', got '%s'", tx.PartyName)
+		if tx.PartyName != "LAXMI MEDICAL STORE" {
+			t.Errorf("Expected party 'LAXMI MEDICAL STORE', got '%s'", tx.PartyName)
 		}
-		if tx.Location != "GANJ" {
-			t.Errorf("Expected location 'GANJ', got '%s'", tx.Location)
+		if tx.Location != "MUNSI GANJ" {
+			t.Errorf("Expected location 'MUNSI GANJ', got '%s'", tx.Location)
 		}
 		if tx.Amount != 144.00 {
 			t.Errorf("Expected amount 144.00, got %.2f", tx.Amount)
@@ -1242,6 +1301,81 @@ Chq.704339 Dt. 26-12-2025`,
 	}
 }
 
+func TestParseWithStats(t *testing.T) {
+	input := `Opening note
+DURGA DAWA GHAR (PARTNER)
+01-10-2025 - 31-10-2025 Page No..1
+------------------------------------------------------------------------------
+DATE PARTICULARS DEBIT CREDIT
+------------------------------------------------------------------------------
+Oct 6 SUSPENSE A/C 427.00
+ICICI 192105002017 427.00
+UPI/528967984881/PAYMENT FROM PH/UMASHANKAR4444Y/INDIAN BANK/AXLF8B1D253C871
+
+Oct 18 LAXMI MEDICAL STORE KANPUR 144.00
+ICICI 192105002017 144.00
+UPI/100976122989/DURGA/7355103104@HDFC/HDFC BANK LTD/HDF08F768440A4B425BB125
+SUB TOTAL 571.00 571.00`
+
+	transactions, stats := defaultParser.ParseWithStats(input, 2025)
+	want := Stats{Lines: 13, Transactions: 1, PageHeaders: 5, SkipPatterns: 1, Suspense: 3, Unattached: 1}
+	if stats != want {
+		t.Errorf("ParseWithStats() stats = %+v, want %+v", stats, want)
+	}
+	if len(transactions) != stats.Transactions {
+		t.Errorf("got %d transactions, stats say %d", len(transactions), stats.Transactions)
+	}
+}
+
+func TestExtractBookTotal(t *testing.T) {
+	input := `DURGA DAWA GHAR (PARTNER)
+ICICI BANK
+01-10-2025 - 31-10-2025 Page No..1
+------------------------------------------------------------------------------
+DATE PARTICULARS DEBIT CREDIT
+------------------------------------------------------------------------------
+Oct 6 SUSPENSE A/C 427.00
+ICICI 192105002017 427.00
+Oct 18 LAXMI MEDICAL STORE KANPUR 1,25,000.00
+ICICI 192105002017 1,25,000.00
+SUB TOTAL 1,25,427.00 1,25,427.00
+Continued..2
+01-10-2025 - 31-10-2025 Page No..2
+B/F 1,25,427.00 1,25,427.00
+Oct 24 SUSPENSE A/C 7000.00
+Oct 24 SANDHYA MEDICAL STORE LUCKNOW 2000.50
+------------------------------------------------------------------------------
+SUB TOTAL 1,34,427.50 1,34,427.50
+------------------------------------------------------------------------------`
+
+	bt, ok := ExtractBookTotal(input)
+	if !ok {
+		t.Fatal("ExtractBookTotal() found no total")
+	}
+	if want := time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC); !bt.From.Equal(want) {
+		t.Errorf("From = %v, want %v", bt.From, want)
+	}
+	if want := time.Date(2025, time.October, 31, 0, 0, 0, 0, time.UTC); !bt.To.Equal(want) {
+		t.Errorf("To = %v, want %v", bt.To, want)
+	}
+	if bt.Total != 134427.50 {
+		t.Errorf("Total = %.2f, want 134427.50", bt.Total)
+	}
+	if bt.Suspense != 7427 {
+		t.Errorf("Suspense = %.2f, want 7427.00", bt.Suspense)
+	}
+
+	if bt, _ := ExtractBookTotal("01-07-2025 - 31-07-2025\nJul 1 RAM JI MEDICAL STORE KENJARI 500.00\nSUB TOTAL 500.00"); bt.Total != 500 {
+		t.Errorf("single figure total = %.2f, want 500.00", bt.Total)
+	}
+	if _, ok := ExtractBookTotal("Jul 1 RAM JI MEDICAL STORE KENJARI 500.00\nSUB TOTAL 500.00"); ok {
+		t.Error("ExtractBookTotal() without a header reported a total")
+	}
+	if _, ok := ExtractBookTotal("01-07-2025 - 31-07-2025\nJul 1 RAM JI MEDICAL STORE KENJARI 500.00"); ok {
+		t.Error("ExtractBookTotal() without a total line reported a total")
+	}
+}
+
 func TestExtractCashDepositInfo(t *testing.T) {
 	tests := []struct {
 		name             string
@@ -1492,6 +1626,27 @@ func TestDetectPaymentMode(t *testing.T) {
 			narration: "CAM/40791SRY/CASH DEP-OTHER/31-05-25/1582",
 			want:      "CASH",
 		},
+		// NACH patterns
+		{
+			name:      "ACH credit",
+			narration: "ACH/CR/HDFC7021807230034209/ABC PHARMA LTD",
+			want:      "NACH",
+		},
+		{
+			name:      "NACH with hyphens",
+			narration: "ICICI 192105002017 25000.00 NACH-CR-ICIC7021807230012345-SUN PHARMA",
+			want:      "NACH",
+		},
+		{
+			name:      "ECS credit",
+			narration: "ECS/MANKIND PHARMA/000000123456",
+			want:      "NACH",
+		},
+		{
+			name:      "ACH inside a word is not NACH",
+			narration: "BY CASH -KANPUR - NACHIKETA ACHARYA",
+			want:      "CASH",
+		},
 		// OTHER
 		{
 			name:      "Unknown pattern",
@@ -1507,9 +1662,9 @@ func TestDetectPaymentMode(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := detectPaymentMode(tt.narration)
+			got := defaultParser.DetectPaymentMode(tt.narration)
 			if got != tt.want {
-				t.Errorf("detectPaymentMode(%q) = %q, want %q", tt.narration, got, tt.want)
+				t.Errorf("DetectPaymentMode(%q) = %q, want %q", tt.narration, got, tt.want)
 			}
 		})
 	}
@@ -1726,3 +1881,731 @@ RTGS-PUNBR52025040810774253-DURGA DAWA GHAR-0257002100103683-PUNB0025700`
 		}
 	}
 }
+
+func TestExtractPOSSettlementInfo(t *testing.T) {
+	tests := []struct {
+		name          string
+		narration     string
+		expectedTID   string
+		expectedBatch string
+		expectedDate  time.Time
+	}{
+		{
+			name:         "FT-MESPOS with settlement date",
+			narration:    "ICICI 192105002017 80318.18 FT-MESPOS SET 10XX174556 010525",
+			expectedTID:  "10XX174556",
+			expectedDate: time.Date(2025, time.May, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:          "FT-MESPOS with batch number",
+			narration:     "FT-MESPOS SET 10XX174556 311225 000123",
+			expectedTID:   "10XX174556",
+			expectedBatch: "000123",
+			expectedDate:  time.Date(2025, time.December, 31, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "MESPOS without date",
+			narration:   "ICICI 192105002017 80318.18 MESPOS SET 10XX174556",
+			expectedTID: "10XX174556",
+		},
+		{
+			name:      "Not a POS narration",
+			narration: "UPI/514030181499/UPI/SURESHRATHORE19/CANARA BANK/ICIA72FE214318743F08A5267E9",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tid, batch, date := ExtractPOSSettlementInfo(tt.narration)
+			if tid != tt.expectedTID {
+				t.Errorf("Expected terminal '%s', got '%s'", tt.expectedTID, tid)
+			}
+			if batch != tt.expectedBatch {
+				t.Errorf("Expected batch '%s', got '%s'", tt.expectedBatch, batch)
+			}
+			if !date.Equal(tt.expectedDate) {
+				t.Errorf("Expected settlement date %v, got %v", tt.expectedDate, date)
+			}
+		})
+	}
+}
+
+func TestParsePOSSettlementTransaction(t *testing.T) {
+	input := `May 2 ICICI POS MACHINE 80318.18
+ICICI 192105002017 80318.18
+FT-MESPOS SET 10XX174556 010525
+May 2 HDFC POS MACHINE 1200.00
+HDFC 50200012345678 1200.00
+FT-MESPOS SET 10XX174557`
+
+	transactions := Parse(input, 2025)
+
+	if len(transactions) != 2 {
+		t.Fatalf("Expected 2 transactions, got %d", len(transactions))
+	}
+
+	tx := transactions[0]
+	if tx.PaymentMode != "POS" {
+		t.Errorf("Expected mode 'POS', got '%s'", tx.PaymentMode)
+	}
+	if tx.POSTerminalID != "10XX174556" {
+		t.Errorf("Expected terminal '10XX174556', got '%s'", tx.POSTerminalID)
+	}
+	if !tx.POSSettlementDate.Equal(time.Date(2025, time.May, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected settlement date May 1, got %v", tx.POSSettlementDate)
+	}
+
+	// Without a date in the narration, the settlement date falls back to the entry date
+	tx = transactions[1]
+	if !tx.POSSettlementDate.Equal(tx.Date) {
+		t.Errorf("Expected settlement date %v, got %v", tx.Date, tx.POSSettlementDate)
+	}
+}
+
+func TestParseCommaAndIntegerAmounts(t *testing.T) {
+	input := `Apr 3 MAA DURGA MEDICAL STORE BILLHAUR 1,25,213.00
+ICICI 192105002017 1,25,213.00
+NEFT-UCBAN52025040104667985-MAA DURGA MEDICAL-/FAST///
+SHIV MEDICAL AGENCY KANPUR 5000
+NEW GUPTA MEDICAL ORAI 125,000.50
+ICICI 192105002017 1,30,213.00
+UPI/564031341768/UPI/ANUJ19SENGARR-3/KOTAK MAHINDRA
+SUB TOTAL 2,55,213.50 2,55,213.50
+2,55,213.50 2,55,213.50`
+
+	transactions := Parse(input, 2025)
+
+	expected := []struct {
+		party    string
+		location string
+		amount   float64
+	}{
+		{"MAA DURGA MEDICAL STORE", "BILLHAUR", 125213.00},
+		{"SHIV MEDICAL AGENCY", "KANPUR", 5000.00},
+		{"NEW GUPTA MEDICAL", "ORAI", 125000.50},
+	}
+
+	if len(transactions) != len(expected) {
+		t.Fatalf("Expected %d transactions, got %d", len(expected), len(transactions))
+	}
+	for i, exp := range expected {
+		tx := transactions[i]
+		if tx.PartyName != exp.party {
+			t.Errorf("Transaction %d: Expected party '%s', got '%s'", i+1, exp.party, tx.PartyName)
+		}
+		if tx.Location != exp.location {
+			t.Errorf("Transaction %d: Expected location '%s', got '%s'", i+1, exp.location, tx.Location)
+		}
+		if tx.Amount != exp.amount {
+			t.Errorf("Transaction %d: Expected amount %.2f, got %.2f", i+1, exp.amount, tx.Amount)
+		}
+	}
+}
+
+func TestParseFlexibleMonthTokens(t *testing.T) {
+	tests := []struct {
+		name          string
+		line          string
+		expectedMonth time.Month
+		expectedDay   int
+	}{
+		{"Abbreviated month", "Dec 26 SANDHYA MEDICAL STORE LUCKNOW 5000.00", time.December, 26},
+		{"Full month name", "December 26 SANDHYA MEDICAL STORE LUCKNOW 5000.00", time.December, 26},
+		{"Lowercase month", "dec 26 SANDHYA MEDICAL STORE LUCKNOW 5000.00", time.December, 26},
+		{"Uppercase month", "SEPT 5 SANDHYA MEDICAL STORE LUCKNOW 5000.00", time.September, 5},
+		{"Day first", "26 Dec SANDHYA MEDICAL STORE LUCKNOW 5000.00", time.December, 26},
+		{"Day first full month", "3 january SANDHYA MEDICAL STORE LUCKNOW 5000.00", time.January, 3},
+		{"Month with period", "Aug. 14 SANDHYA MEDICAL STORE LUCKNOW 5000.00", time.August, 14},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transactions := Parse(tt.line+"\nUPI/9450852076@YBL 5000.00", 2025)
+			if len(transactions) != 1 {
+				t.Fatalf("Expected 1 transaction, got %d", len(transactions))
+			}
+			tx := transactions[0]
+			if tx.Date.Month() != tt.expectedMonth || tx.Date.Day() != tt.expectedDay {
+				t.Errorf("Expected %s %d, got %v", tt.expectedMonth, tt.expectedDay, tx.Date)
+			}
+			if tx.PartyName != "SANDHYA MEDICAL STORE" {
+				t.Errorf("Expected party 'SANDHYA MEDICAL STORE', got '%s'", tx.PartyName)
+			}
+			if tx.Location != "LUCKNOW" {
+				t.Errorf("Expected location 'LUCKNOW', got '%s'", tx.Location)
+			}
+		})
+	}
+}
+
+func TestParseTransactionSplitAcrossPages(t *testing.T) {
+	input := `DURGA DAWA GHAR (PARTNER)
+60/33,PURANI DAL MANDI KANPUR
+ICICI BANK
+01-10-2025 - 31-10-2025 Page No..1
+------------------------------------------------------------------------------------------------------------
+DATE PARTICULARS DEBIT CREDIT
+------------------------------------------------------------------------------------------------------------
+Oct 18 LAXMI MEDICAL STORE KANPUR 144.00
+ICICI BANK
+SUB TOTAL 144.00 144.00
+Continued..2
+DURGA DAWA GHAR (PARTNER)
+60/33,PURANI DAL MANDI KANPUR
+Ph. 0512-2352466 9415131234
+ICICI BANK
+01-10-2025 - 31-10-2025 Page No..2
+------------------------------------------------------------------------------------------------------------
+DATE PARTICULARS DEBIT CREDIT
+------------------------------------------------------------------------------------------------------------
+UPI/527818224843/PAYMENT FROM PH
+/9415123456@YBL
+B/F 144.00
+Oct 19 SHARMA PHARMA UNNAO 250.00
+ICICI BANK
+NEFT-SBIN0001234-SHARMA PHARMA
+`
+	transactions := Parse(input, 2025)
+	if len(transactions) != 2 {
+		t.Fatalf("Expected 2 transactions, got %d", len(transactions))
+	}
+
+	first := transactions[0]
+	if first.PartyName != "LAXMI MEDICAL STORE" {
+		t.Errorf("Expected party 'LAXMI MEDICAL STORE', got '%s'", first.PartyName)
+	}
+	if first.Amount != 144.00 {
+		t.Errorf("Expected amount 144.00, got %.2f", first.Amount)
+	}
+	if !strings.Contains(first.Narration, "9415123456@YBL") {
+		t.Errorf("Expected narration from next page, got '%s'", first.Narration)
+	}
+	if first.PaymentMode != "UPI" {
+		t.Errorf("Expected payment mode UPI, got '%s'", first.PaymentMode)
+	}
+	if first.Page != 1 {
+		t.Errorf("Expected page 1, got %d", first.Page)
+	}
+
+	second := transactions[1]
+	if second.PartyName != "SHARMA PHARMA" {
+		t.Errorf("Expected party 'SHARMA PHARMA', got '%s'", second.PartyName)
+	}
+	if second.Page != 2 {
+		t.Errorf("Expected page 2, got %d", second.Page)
+	}
+}
+
+func TestParseSecondFirmAcrossPages(t *testing.T) {
+	// The second page's header follows straight on, with no Continued line
+	input := `DURGA PHARMA
+14/2,BIRHANA ROAD KANPUR
+ICICI BANK
+01-10-2025 - 31-10-2025 Page No..1
+------------------------------------------------------------------------------------------------------------
+DATE PARTICULARS DEBIT CREDIT
+------------------------------------------------------------------------------------------------------------
+Oct 18 LAXMI MEDICAL STORE KANPUR 144.00
+ICICI BANK
+DURGA PHARMA
+14/2,BIRHANA ROAD KANPUR
+ICICI BANK
+01-10-2025 - 31-10-2025 Page No..2
+------------------------------------------------------------------------------------------------------------
+DATE PARTICULARS DEBIT CREDIT
+------------------------------------------------------------------------------------------------------------
+UPI/527818224843/PAYMENT FROM PH
+/9415123456@YBL
+DURGA PHARMA DISTRIBUTORS UNNAO 250.00
+ICICI BANK
+NEFT-SBIN0001234-DURGA PHARMA DIST
+`
+	v := DefaultVocabulary
+	v.FirmNames = []string{"Durga Dawa Ghar", "Durga Pharma"}
+	p, err := New(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transactions := p.Parse(input, 2025)
+	if len(transactions) != 2 {
+		t.Fatalf("Expected 2 transactions, got %d: %+v", len(transactions), transactions)
+	}
+
+	first := transactions[0]
+	if first.PartyName != "LAXMI MEDICAL STORE" || first.Page != 1 {
+		t.Errorf("Expected LAXMI MEDICAL STORE on page 1, got '%s' on page %d", first.PartyName, first.Page)
+	}
+	if !strings.Contains(first.Narration, "9415123456@YBL") || strings.Contains(first.Narration, "BIRHANA") {
+		t.Errorf("Expected the narration from the next page without its header, got '%s'", first.Narration)
+	}
+	if first.PaymentMode != "UPI" {
+		t.Errorf("Expected payment mode UPI, got '%s'", first.PaymentMode)
+	}
+
+	// A party named after the firm is not taken for a page header
+	second := transactions[1]
+	if second.PartyName != "DURGA PHARMA DISTRIBUTORS" || second.Page != 2 {
+		t.Errorf("Expected DURGA PHARMA DISTRIBUTORS on page 2, got '%s' on page %d", second.PartyName, second.Page)
+	}
+
+	// Without the firm's name, its header is read as narration
+	if got := Parse(input, 2025); len(got) > 0 && !strings.Contains(got[0].Narration, "DURGA PHARMA") {
+		t.Errorf("the default vocabulary knows the second firm: narration '%s'", got[0].Narration)
+	}
+}
+
+func TestExtractChequeInfo(t *testing.T) {
+	tests := []struct {
+		narration      string
+		expectedNumber string
+		expectedDate   time.Time
+	}{
+		{"ICICI 192105002017 11744.00 Chq.704339 Dt. 26-12-2025", "704339", time.Date(2025, 12, 26, 0, 0, 0, 0, time.UTC)},
+		{"Chq.567719 Dt. 01-05-2025", "567719", time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{"CHEQUE NO. 001234", "001234", time.Time{}},
+		{"UPI/9450852076@YBL", "", time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.narration, func(t *testing.T) {
+			number, date := ExtractChequeInfo(tt.narration)
+			if number != tt.expectedNumber {
+				t.Errorf("Expected number %q, got %q", tt.expectedNumber, number)
+			}
+			if !date.Equal(tt.expectedDate) {
+				t.Errorf("Expected date %v, got %v", tt.expectedDate, date)
+			}
+		})
+	}
+}
+
+func TestParseChequeTransaction(t *testing.T) {
+	input := `Dec 26 BABA MEDICAL AND GENERAL STOR SHAMBHUA 11744.00
+ICICI 192105002017 11744.00
+Chq.704339 Dt. 26-12-2025 Ag. DDG024782`
+
+	transactions := Parse(input, 2025)
+	if len(transactions) != 1 {
+		t.Fatalf("Expected 1 transaction, got %d", len(transactions))
+	}
+	tx := transactions[0]
+	if tx.PaymentMode != "CHEQUE" {
+		t.Errorf("Expected payment mode CHEQUE, got '%s'", tx.PaymentMode)
+	}
+	if tx.ChequeNumber != "704339" {
+		t.Errorf("Expected cheque number '704339', got '%s'", tx.ChequeNumber)
+	}
+	if tx.ChequeDate.Day() != 26 || tx.ChequeDate.Month() != time.December {
+		t.Errorf("Expected cheque date 26 Dec, got %v", tx.ChequeDate)
+	}
+}
+
+func TestParseSaleBillsPaymentType(t *testing.T) {
+	input := `SALE FROM 01-04-2025 TO 31-03-2026
+A250100001 01-04 CASH (RAMESH) 1,200.00
+A250100002 01-04 CARD (SURESH) 2,450.50
+A250100003 01-04 CARD 300.00
+A250100004 02-04 BABA MEDICAL STORE 10,000.00`
+
+	tests := []struct {
+		partyName string
+		isCash    bool
+		isCard    bool
+	}{
+		{"RAMESH", true, false},
+		{"SURESH", false, true},
+		{"CARD", false, true},
+		{"BABA MEDICAL STORE", false, false},
+	}
+
+	bills := ParseSaleBills(input, 2025)
+	if len(bills) != len(tests) {
+		t.Fatalf("Expected %d bills, got %d", len(tests), len(bills))
+	}
+	for i, tt := range tests {
+		bill := bills[i]
+		if bill.PartyName != tt.partyName {
+			t.Errorf("Bill %d: expected party %q, got %q", i, tt.partyName, bill.PartyName)
+		}
+		if bill.IsCashSale != tt.isCash || bill.IsCardSale != tt.isCard {
+			t.Errorf("Bill %d: expected cash=%v card=%v, got cash=%v card=%v", i, tt.isCash, tt.isCard, bill.IsCashSale, bill.IsCardSale)
+		}
+	}
+}
+
+func TestParseSaleBillsTolerant(t *testing.T) {
+	input := "SALE FROM 01-04-2025 TO 31-03-2026\n" +
+		"Bill No.  Date   Party Name              Amount\n" +
+		"A250100001 01/04 SHRI RAM MEDICAL AND SURGICAL\n" +
+		"           AGENCIES                      1,200.00\n" +
+		"A250100002 02.04.25 CASH (RAMESH KUMAR 450.00\n" +
+		"           SHARMA)\n" +
+		"A250100003 03-04 BABA MEDICAL STORE      10,000.00\n" +
+		"\n" +
+		"DURGA DAWA GHAR\n" +
+		"A250100004 31/02 BAD DATE MEDICOS        300.00\n" +
+		"A250100005 04/04 NO AMOUNT EVER\n" +
+		"A250100006 05/04/2026 GUPTA PHARMA       2,450.50\n" +
+		"A250100007 - 06/04 STRANGE SHAPE         99.00\n" +
+		"Page 2\n"
+
+	tests := []struct {
+		billNumber string
+		date       time.Time
+		partyName  string
+		amount     float64
+		isCash     bool
+	}{
+		{"A250100001", time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), "SHRI RAM MEDICAL AND SURGICAL AGENCIES", 1200, false},
+		{"A250100002", time.Date(2025, 4, 2, 0, 0, 0, 0, time.UTC), "RAMESH KUMAR SHARMA", 450, true},
+		{"A250100003", time.Date(2026, 4, 3, 0, 0, 0, 0, time.UTC), "BABA MEDICAL STORE", 10000, false},
+		{"A250100006", time.Date(2026, 4, 5, 0, 0, 0, 0, time.UTC), "GUPTA PHARMA", 2450.50, false},
+	}
+
+	bills, unread := ParseSaleBillsTolerant(input, 2025)
+	if len(bills) != len(tests) {
+		t.Fatalf("Expected %d bills, got %d: %+v", len(tests), len(bills), bills)
+	}
+	for i, tt := range tests {
+		bill := bills[i]
+		if bill.BillNumber != tt.billNumber || !bill.Date.Equal(tt.date) || bill.PartyName != tt.partyName || bill.Amount != tt.amount || bill.IsCashSale != tt.isCash {
+			t.Errorf("Bill %d: got %+v", i, bill)
+		}
+	}
+
+	wantUnread := []string{
+		"A250100004 31/02 BAD DATE MEDICOS        300.00",
+		"A250100005 04/04 NO AMOUNT EVER",
+		"A250100007 - 06/04 STRANGE SHAPE         99.00",
+	}
+	if len(unread) != len(wantUnread) {
+		t.Fatalf("Expected unread %q, got %q", wantUnread, unread)
+	}
+	for i := range wantUnread {
+		if unread[i] != wantUnread[i] {
+			t.Errorf("Unread %d: expected %q, got %q", i, wantUnread[i], unread[i])
+		}
+	}
+
+	// The strict parser reads the same bills from a well-formed register
+	strict := "A250100001 01-04 CASH (RAMESH) 1,200.00\nA250100002 01-04 BABA MEDICAL STORE 10,000.00"
+	bills, unread = ParseSaleBillsTolerant(strict, 2025)
+	if len(unread) != 0 || len(bills) != 2 || bills[0] != ParseSaleBills(strict, 2025)[0] || bills[1] != ParseSaleBills(strict, 2025)[1] {
+		t.Errorf("Expected the strict parser's bills, got %+v, unread %q", bills, unread)
+	}
+}
+
+func TestExtractBankAccount(t *testing.T) {
+	tests := []struct {
+		narration      string
+		expectedBank   string
+		expectedNumber string
+	}{
+		{"ICICI 192105002017 11744.00 Chq.704339 Dt. 26-12-2025", "ICICI", "192105002017"},
+		{"pnb 0123456789012 1,25,213.00 NEFT-HDFC0000001", "PNB", "0123456789012"},
+		{"UPI/SANDHYA ME/9450852076@YBL/PAYMENT FR/STATE BANK/450854353978", "", ""},
+		{"BY CASH -733300 TIRWA (UP)", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.narration, func(t *testing.T) {
+			bank, number := ExtractBankAccount(tt.narration)
+			if bank != tt.expectedBank || number != tt.expectedNumber {
+				t.Errorf("Expected %q %q, got %q %q", tt.expectedBank, tt.expectedNumber, bank, number)
+			}
+		})
+	}
+}
+
+func TestGuessSaleBillColumns(t *testing.T) {
+	header := []string{"Sr", "Bill No.", "Bill Date", "Party Name", "Net Amount"}
+	cols := GuessSaleBillColumns(header)
+	expected := unmappedSaleBillColumns
+	expected.BillNumber, expected.Date, expected.PartyName, expected.Amount = 1, 2, 3, 4
+	if cols != expected {
+		t.Errorf("Expected %+v, got %+v", expected, cols)
+	}
+
+	cols = GuessSaleBillColumns([]string{"A", "B"})
+	if cols.BillNumber != -1 || cols.Date != -1 || cols.PartyName != -1 || cols.Amount != -1 {
+		t.Errorf("Expected unmapped columns, got %+v", cols)
+	}
+
+	rows := [][]string{
+		{"Sale Register 01-04-2025 to 31-03-2026"},
+		{"Invoice", "Date", "Customer", "Amount"},
+		{"A250100001", "01-04-2025", "RAMESH", "1200"},
+	}
+	idx, cols := FindSaleBillHeader(rows)
+	expected = unmappedSaleBillColumns
+	expected.BillNumber, expected.Date, expected.PartyName, expected.Amount = 0, 1, 2, 3
+	if idx != 1 || cols != expected {
+		t.Errorf("Expected header at 1 with %+v, got %d with %+v", expected, idx, cols)
+	}
+
+	// An e-invoice export's acknowledgement columns are not taken for the
+	// bill date or number
+	header = []string{"Ack No", "Ack Date", "Bill No.", "Bill Date", "Party Name", "Amount", "IRN"}
+	cols = GuessSaleBillColumns(header)
+	expected = unmappedSaleBillColumns
+	expected.BillNumber, expected.Date, expected.PartyName, expected.Amount = 2, 3, 4, 5
+	expected.IRN, expected.AckNumber, expected.AckDate = 6, 0, 1
+	if cols != expected {
+		t.Errorf("Expected %+v, got %+v", expected, cols)
+	}
+
+	// Tax columns are not taken for the amount
+	header = []string{"Bill No", "Date", "Party", "Taxable Value", "CGST Amt", "SGST Amt", "IGST Amt", "Gross Amount"}
+	cols = GuessSaleBillColumns(header)
+	expected = unmappedSaleBillColumns
+	expected.BillNumber, expected.Date, expected.PartyName, expected.Amount = 0, 1, 2, 7
+	expected.TaxableValue, expected.CGST, expected.SGST, expected.IGST = 3, 4, 5, 6
+	if cols != expected {
+		t.Errorf("Expected %+v, got %+v", expected, cols)
+	}
+}
+
+func TestParseSaleBillRows(t *testing.T) {
+	rows := [][]string{
+		{"Bill No.", "Date", "Party Name", "Amount"},
+		{"A250100001", "01-04-2025", "CASH (RAMESH)", "1,200.00"},
+		{"A250100002", "45748", "BABA MEDICAL STORE", "₹10,000"},
+		{"A250100003", "02/04", "CARD", "300"},
+		{"", "", "TOTAL", "11,500.00"},
+	}
+	cols := unmappedSaleBillColumns
+	cols.BillNumber, cols.Date, cols.PartyName, cols.Amount = 0, 1, 2, 3
+
+	bills := ParseSaleBillRows(rows, cols, 2025)
+	if len(bills) != 3 {
+		t.Fatalf("Expected 3 bills, got %d", len(bills))
+	}
+
+	tests := []struct {
+		billNumber string
+		date       string
+		partyName  string
+		amount     float64
+		isCash     bool
+		isCard     bool
+	}{
+		{"A250100001", "2025-04-01", "RAMESH", 1200, true, false},
+		{"A250100002", "2025-04-01", "BABA MEDICAL STORE", 10000, false, false},
+		{"A250100003", "2025-04-02", "CARD", 300, false, true},
+	}
+	for i, tt := range tests {
+		bill := bills[i]
+		if bill.BillNumber != tt.billNumber || bill.PartyName != tt.partyName || bill.Amount != tt.amount {
+			t.Errorf("Bill %d: expected %s %q %.2f, got %s %q %.2f", i, tt.billNumber, tt.partyName, tt.amount, bill.BillNumber, bill.PartyName, bill.Amount)
+		}
+		if got := bill.Date.Format("2006-01-02"); got != tt.date {
+			t.Errorf("Bill %d: expected date %s, got %s", i, tt.date, got)
+		}
+		if bill.IsCashSale != tt.isCash || bill.IsCardSale != tt.isCard {
+			t.Errorf("Bill %d: expected cash=%v card=%v, got cash=%v card=%v", i, tt.isCash, tt.isCard, bill.IsCashSale, bill.IsCardSale)
+		}
+	}
+}
+
+func TestParseSaleBillRowsEInvoice(t *testing.T) {
+	rows := [][]string{
+		{"Bill No.", "Date", "Party Name", "Amount", "IRN", "Ack No", "Ack Date"},
+		{"A260900055", "05-09-2026", "SIDDHI MEDICAL HALL", "11538.74", "3F2A9C0B 7d41e5", "132619845513377", "05-09-2026 14:23:00"},
+		{"A260900056", "05-09-2026", "CASH (RAMESH)", "240", "", "", ""},
+	}
+	cols := unmappedSaleBillColumns
+	cols.BillNumber, cols.Date, cols.PartyName, cols.Amount = 0, 1, 2, 3
+	cols.IRN, cols.AckNumber, cols.AckDate = 4, 5, 6
+
+	bills := ParseSaleBillRows(rows, cols, 2026)
+	if len(bills) != 2 {
+		t.Fatalf("Expected 2 bills, got %d", len(bills))
+	}
+	if bills[0].IRN != "3f2a9c0b7d41e5" || bills[0].AckNumber != "132619845513377" || bills[0].AckDate.Format("2006-01-02") != "2026-09-05" {
+		t.Errorf("Expected the e-invoice fields, got IRN %q ack %q %v", bills[0].IRN, bills[0].AckNumber, bills[0].AckDate)
+	}
+	if bills[1].IRN != "" || bills[1].AckNumber != "" || !bills[1].AckDate.IsZero() {
+		t.Errorf("Expected no e-invoice fields, got IRN %q ack %q %v", bills[1].IRN, bills[1].AckNumber, bills[1].AckDate)
+	}
+}
+
+func TestParseSaleBillRowsTaxes(t *testing.T) {
+	rows := [][]string{
+		{"Bill No", "Date", "Party", "Taxable Value", "CGST", "SGST", "IGST", "Gross Amount"},
+		{"A260900055", "05-09-2026", "SIDDHI MEDICAL HALL", "10,302.45", "618.15", "618.15", "", "11,538.75"},
+		{"A260900056", "05-09-2026", "SHREE MEDICOS", "1000", "0", "0", "120", "1120"},
+	}
+	cols := unmappedSaleBillColumns
+	cols.BillNumber, cols.Date, cols.PartyName, cols.Amount = 0, 1, 2, 7
+	cols.TaxableValue, cols.CGST, cols.SGST, cols.IGST = 3, 4, 5, 6
+
+	bills := ParseSaleBillRows(rows, cols, 2026)
+	if len(bills) != 2 {
+		t.Fatalf("Expected 2 bills, got %d", len(bills))
+	}
+	if b := bills[0]; b.Amount != 11538.75 || b.TaxableValue != 10302.45 || b.CGST != 618.15 || b.SGST != 618.15 || b.IGST != 0 {
+		t.Errorf("Bill 0: got amount %.2f taxable %.2f CGST %.2f SGST %.2f IGST %.2f", b.Amount, b.TaxableValue, b.CGST, b.SGST, b.IGST)
+	}
+	if b := bills[1]; b.Amount != 1120 || b.TaxableValue != 1000 || b.CGST != 0 || b.SGST != 0 || b.IGST != 120 {
+		t.Errorf("Bill 1: got amount %.2f taxable %.2f CGST %.2f SGST %.2f IGST %.2f", b.Amount, b.TaxableValue, b.CGST, b.SGST, b.IGST)
+	}
+}
+
+func TestReadXLSXRows(t *testing.T) {
+	files := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Sales" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sales.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<si><t>Bill No.</t></si><si><r><t>BABA </t></r><r><t>MEDICAL</t></r></si></sst>`,
+		"xl/worksheets/sales.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c></row>
+<row r="2"><c r="A2" t="inlineStr"><is><t>A250100002</t></is></c><c r="C2" t="s"><v>1</v></c><c r="D2"><v>10000</v></c></row>
+</sheetData></worksheet>`,
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := ReadXLSXRows(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := [][]string{
+		{"Bill No."},
+		{"A250100002", "", "BABA MEDICAL", "10000"},
+	}
+	if len(rows) != len(expected) {
+		t.Fatalf("Expected %d rows, got %d", len(expected), len(rows))
+	}
+	for i := range expected {
+		if strings.Join(rows[i], "|") != strings.Join(expected[i], "|") {
+			t.Errorf("Row %d: expected %q, got %q", i, expected[i], rows[i])
+		}
+	}
+
+	if _, err := ReadXLSXRows([]byte("not a workbook")); err == nil {
+		t.Error("Expected an error for invalid data")
+	}
+}
+
+func TestCustomPaymentModeRules(t *testing.T) {
+	v := DefaultVocabulary
+	v.PaymentModes = append([]PaymentModeRule{{Pattern: `(?i)\bBHIM/`, Mode: "UPI"}}, v.PaymentModes...)
+	p, err := New(v)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	input := `Dec 26 SANDHYA MEDICAL STORE LUCKNOW 5000.00
+ICICI 192105002017 5000.00
+BHIM/9450852076/SANDHYA`
+
+	if got := Parse(input, 2025)[0].PaymentMode; got != "OTHER" {
+		t.Errorf("default PaymentMode = %q, want OTHER", got)
+	}
+	if got := p.Parse(input, 2025)[0].PaymentMode; got != "UPI" {
+		t.Errorf("custom PaymentMode = %q, want UPI", got)
+	}
+
+	if _, err := New(Vocabulary{PaymentModes: []PaymentModeRule{{Pattern: `(UPI`, Mode: "UPI"}}}); err == nil {
+		t.Error("New() with an invalid pattern succeeded, want error")
+	}
+	if err := ValidatePaymentModeRule(PaymentModeRule{Pattern: `UPI/`}); err == nil {
+		t.Error("ValidatePaymentModeRule() without a mode succeeded, want error")
+	}
+}
+
+func TestCheckText(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		wantErr bool
+	}{
+		{"receipt book", "Dec 26 SANDHYA MEDICAL STORE LUCKNOW 5000.00\r\nUPI/9450852076@YBL 5000.00\n\tSUB TOTAL\f", false},
+		{"empty", "", false},
+		{"pdf", "  %PDF-1.7\n%âãÏÓ\n1 0 obj", true},
+		{"invalid utf-8", "Dec 26 SANDHYA \xff\xfe 5000.00", true},
+		{"nul", "Dec 26 SANDHYA\x00 5000.00", true},
+		{"replacement characters", strings.Repeat("ab�", 100), true},
+		{"one stray control character", "Dec 26 SANDHYA\x1b MEDICAL STORE LUCKNOW 5000.00 UPI/9450852076@YBL 5000.00 " + strings.Repeat("x", 100), false},
+		{"long line", "Dec 26 X 10.00\n" + strings.Repeat("ICICI 1234 ", 300), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckText(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckText() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseICICIStatement(t *testing.T) {
+	detailed := `DETAILED STATEMENT
+Transactions List - DURGA DAWA GHAR (INR) - 192105002017
+
+S No.,Value Date,Transaction Date,Cheque Number,Transaction Remarks,Withdrawal Amount (INR ),Deposit Amount (INR ),Balance (INR )
+1,01/05/2025,01/05/2025,-,FT-MESPOS SET 10XX174556 010525,0.00,"80,318.18","1,20,318.18"
+2,01/05/2025,02/05/2025,-,NEFT-HDFCN52025050212345-RENT,"15,000.00",0.00,"1,05,318.18"
+3,20/05/2025,20/05/2025,-,"UPI/514030181499/UPI/SURESHRATHORE19/CANARA BANK/ICIA72FE214318743F08A5267E9",0.00,"8,495.00","1,13,813.18"
+,,,,Total,"15,000.00","88,813.18",
+Legends Used in Account Statement`
+
+	transactions, err := defaultParser.ParseICICIStatement(strings.NewReader(detailed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transactions) != 2 {
+		t.Fatalf("Expected 2 credits, got %d: %+v", len(transactions), transactions)
+	}
+	tx := transactions[0]
+	if tx.Amount != 80318.18 || tx.Date.Format("2006-01-02") != "2025-05-01" || tx.PaymentMode != "POS" {
+		t.Errorf("Expected a POS settlement of 80318.18 on 1 May, got %+v", tx)
+	}
+	if tx.Narration != "ICICI 192105002017 80318.18 FT-MESPOS SET 10XX174556 010525" {
+		t.Errorf("Unexpected narration %q", tx.Narration)
+	}
+	if tx.AccountBank != "ICICI" || tx.AccountNumber != "192105002017" || tx.POSTerminalID != "10XX174556" {
+		t.Errorf("Expected the account and terminal read from the narration, got %+v", tx)
+	}
+	if tx := transactions[1]; tx.Amount != 8495 || tx.PaymentMode != "UPI" || tx.PartyName != "" {
+		t.Errorf("Expected a UPI credit of 8495 with no party, got %+v", tx)
+	}
+
+	// The corporate banking export gives one amount column with Cr/Dr, and
+	// the time of each transaction
+	corporate := `Account Number,192105002017
+Account Name,DURGA DAWA GHAR
+No.,Transaction ID,Value Date,Txn Posted Date,ChequeNo.,Description,Cr/Dr,Transaction Amount(INR),Available Balance(INR)
+1,S12345,01-Jun-2025,01/06/2025 10:42:17 AM,,BY CASH -733300 TIRWA (UP),CR,"2,26,000.00","3,26,000.00"
+2,S12346,02-Jun-2025,02/06/2025 11:02:03 AM,,ACH/INDIAN CLEARING CORP,DR,500.00,"3,25,500.00"`
+	transactions, err = defaultParser.ParseICICIStatement(strings.NewReader(corporate))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transactions) != 1 {
+		t.Fatalf("Expected 1 credit, got %d: %+v", len(transactions), transactions)
+	}
+	tx = transactions[0]
+	if tx.Amount != 226000 || tx.Date.Format("2006-01-02") != "2025-06-01" || tx.PaymentMode != "CASH" || tx.CashBankCode != "733300" {
+		t.Errorf("Expected a cash deposit of 226000 on 1 Jun, got %+v", tx)
+	}
+	if tx.AccountNumber != "192105002017" {
+		t.Errorf("Expected the account from the account details, got %q", tx.AccountNumber)
+	}
+
+	if _, err := defaultParser.ParseICICIStatement(strings.NewReader("Bill No,Date,Party,Amount\n1,01-04-2025,RAMESH,100")); err != ErrNoStatementHeader {
+		t.Errorf("Expected ErrNoStatementHeader for a sale bill export, got %v", err)
+	}
+}
@@ -9,11 +9,19 @@ import (
 
 // SaleBill represents a parsed sale bill entry
 type SaleBill struct {
-	BillNumber string
-	Date       time.Time
-	PartyName  string
-	Amount     float64
-	IsCashSale bool
+	BillNumber   string
+	Date         time.Time
+	PartyName    string
+	Amount       float64 // gross, tax included
+	IsCashSale   bool
+	IsCardSale   bool
+	IRN          string    // the e-invoice's Invoice Reference Number, lowercase hex; empty without one
+	AckNumber    string    // the e-invoice acknowledgement number
+	AckDate      time.Time // zero without an acknowledgement
+	TaxableValue float64   // the tax breakup, zero when the register has none
+	CGST         float64
+	SGST         float64
+	IGST         float64
 }
 
 var (
@@ -24,26 +32,30 @@ var (
 	// e.g., A240100001 01-04 PARTY NAME HERE 1,234.56
 	billLinePattern = regexp.MustCompile(`^([A-Z0-9]+)\s+(\d{2}-\d{2})\s+(.+?)\s+([\d,]+\.\d{2})$`)
 
+	// Tolerant bill line pattern: BILLNUM DD-MM[-YY] PARTY NAME AMOUNT, the
+	// date separated by -, / or . and the year optional
+	// e.g., A/1234 01/04/24 PARTY NAME HERE 1,234.56
+	tolerantBillLinePattern = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9/-]*)\s+(\d{1,2})[-/.](\d{1,2})(?:[-/.](\d{4}|\d{2}))?\s+(.+?)\s+([\d,]+\.\d{2})$`)
+
+	// Tolerant bill start pattern: a bill line whose party name wraps, its
+	// amount on a following line
+	tolerantBillStartPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9/-]*\s+\d{1,2}[-/.]\d{1,2}(?:[-/.](?:\d{4}|\d{2}))?(?:\s|$)`)
+
+	// Amount at the end of a line
+	saleAmountPattern = regexp.MustCompile(`[\d,]+\.\d{2}$`)
+
 	// CASH party pattern: CASH (PARTY NAME)
 	cashPartyPattern = regexp.MustCompile(`(?i)^CASH\s*\(([^)]+)\)`)
+
+	// CARD party pattern: CARD (PARTY NAME), a counter sale paid on the card machine
+	cardPartyPattern = regexp.MustCompile(`(?i)^CARD\s*\(([^)]+)\)`)
 )
 
 // ParseSaleBills parses sale bill data and returns a slice of SaleBill
 func ParseSaleBills(data string, defaultYear int) []SaleBill {
 	lines := strings.Split(data, "\n")
 	var bills []SaleBill
-
-	// Try to extract year from header
-	year := defaultYear
-	for _, line := range lines {
-		if matches := saleHeaderPattern.FindStringSubmatch(line); matches != nil {
-			// Use the "TO" year (second year in range)
-			if y, err := strconv.Atoi(matches[2]); err == nil {
-				year = y
-			}
-			break
-		}
-	}
+	year := saleRegisterYear(lines, defaultYear)
 
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
@@ -66,6 +78,121 @@ func ParseSaleBills(data string, defaultYear int) []SaleBill {
 	return bills
 }
 
+// saleRegisterYear is the year of a register from its header, or defaultYear
+func saleRegisterYear(lines []string, defaultYear int) int {
+	for _, line := range lines {
+		if matches := saleHeaderPattern.FindStringSubmatch(line); matches != nil {
+			// Use the "TO" year (second year in range)
+			if y, err := strconv.Atoi(matches[2]); err == nil {
+				return y
+			}
+			break
+		}
+	}
+	return defaultYear
+}
+
+// ParseSaleBillsTolerant parses sale bill data from less structured exports:
+// dates as DD/MM or DD.MM as well as DD-MM, with or without a year, and long
+// party names wrapped onto the next line, before or after the amount (a line
+// after it indented, straight under the bill line and without figures). Lines
+// that look like a bill but cannot be read are returned as unread, rather than
+// dropped, for the user to check.
+func ParseSaleBillsTolerant(data string, defaultYear int) (bills []SaleBill, unread []string) {
+	lines := strings.Split(data, "\n")
+	year := saleRegisterYear(lines, defaultYear)
+
+	pending := ""    // a bill line waiting for its amount on the next line
+	wrapped := false // whether pending takes in more than one line
+	wraps := false   // whether the last bill's party name may go on to the next line
+	for _, raw := range lines {
+		line := strings.TrimSpace(raw)
+		if line == "" || shouldSkipSaleBillLine(line) {
+			wraps = false
+			continue
+		}
+		indented := strings.TrimLeft(raw, " \t") != raw
+
+		switch {
+		case tolerantBillStartPattern.MatchString(line):
+			if pending != "" {
+				unread = append(unread, pending)
+			}
+			pending, wrapped, wraps = line, false, false
+		case pending != "":
+			pending += " " + line
+			wrapped = true
+		case wraps && indented && !strings.ContainsAny(line, "0123456789"):
+			// The rest of a party name printed under the bill line, indented
+			// to its column
+			bills[len(bills)-1].PartyName += " " + line
+			continue
+		default:
+			// A line with an amount may be a bill in a shape not known here
+			if saleAmountPattern.MatchString(line) {
+				unread = append(unread, line)
+			}
+			wraps = false
+			continue
+		}
+
+		bill, ok := parseTolerantBillLine(pending, year)
+		if !ok {
+			if tolerantBillLinePattern.MatchString(pending) || wrapped && saleAmountPattern.MatchString(pending) {
+				// A bill with a date that does not exist, or one whose amount
+				// came but that still does not read as a bill
+				unread = append(unread, pending)
+				pending = ""
+			}
+			continue
+		}
+		bills = append(bills, bill)
+		pending, wraps = "", !wrapped
+	}
+	if pending != "" {
+		unread = append(unread, pending)
+	}
+
+	// Counter sales are told apart once the party names are whole, as the
+	// closing bracket of CASH (NAME) may be on the wrapped line
+	for i := range bills {
+		bills[i].PartyName, bills[i].IsCashSale, bills[i].IsCardSale = classifySaleParty(bills[i].PartyName)
+	}
+	return bills, unread
+}
+
+// parseTolerantBillLine parses a bill line in any of the shapes
+// ParseSaleBillsTolerant reads, its party name left unclassified. A date
+// that does not exist, such as 31/02, fails the line.
+func parseTolerantBillLine(line string, year int) (SaleBill, bool) {
+	matches := tolerantBillLinePattern.FindStringSubmatch(line)
+	if matches == nil {
+		return SaleBill{}, false
+	}
+	day, _ := strconv.Atoi(matches[2])
+	month, _ := strconv.Atoi(matches[3])
+	if matches[4] != "" {
+		year, _ = strconv.Atoi(matches[4])
+		if year < 100 {
+			year += 2000
+		}
+	}
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if date.Day() != day || int(date.Month()) != month {
+		return SaleBill{}, false
+	}
+	amount, err := strconv.ParseFloat(strings.ReplaceAll(matches[6], ",", ""), 64)
+	if err != nil {
+		return SaleBill{}, false
+	}
+	return SaleBill{
+		BillNumber: matches[1],
+		Date:       date,
+		PartyName:  strings.TrimSpace(matches[5]),
+		Amount:     amount,
+	}, true
+}
+
 // shouldSkipSaleBillLine returns true if the line should be skipped
 func shouldSkipSaleBillLine(line string) bool {
 	upperLine := strings.ToUpper(line)
@@ -147,20 +274,34 @@ func parseBillLine(line string, year int) *SaleBill {
 		return nil
 	}
 
+	bill := &SaleBill{
+		BillNumber: billNumber,
+		Date:       date,
+		Amount:     amount,
+	}
+	bill.PartyName, bill.IsCashSale, bill.IsCardSale = classifySaleParty(partyName)
+	return bill
+}
+
+// classifySaleParty detects counter sales from the party name: CASH (NAME)
+// and CARD (NAME) are cash and card sales to NAME
+func classifySaleParty(partyName string) (name string, isCashSale, isCardSale bool) {
+	name = partyName
+
 	// Check if it's a CASH sale and extract party name from parentheses
-	isCashSale := false
-	if cashMatches := cashPartyPattern.FindStringSubmatch(partyName); cashMatches != nil {
+	if cashMatches := cashPartyPattern.FindStringSubmatch(name); cashMatches != nil {
 		isCashSale = true
-		partyName = strings.TrimSpace(cashMatches[1])
-	} else if strings.ToUpper(partyName) == "CASH" {
+		name = strings.TrimSpace(cashMatches[1])
+	} else if strings.ToUpper(name) == "CASH" {
 		isCashSale = true
 	}
 
-	return &SaleBill{
-		BillNumber: billNumber,
-		Date:       date,
-		PartyName:  partyName,
-		Amount:     amount,
-		IsCashSale: isCashSale,
+	// Check if it was paid by card
+	if cardMatches := cardPartyPattern.FindStringSubmatch(name); cardMatches != nil {
+		isCardSale = true
+		name = strings.TrimSpace(cardMatches[1])
+	} else if strings.ToUpper(name) == "CARD" {
+		isCardSale = true
 	}
+	return name, isCashSale, isCardSale
 }
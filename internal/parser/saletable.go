@@ -0,0 +1,416 @@
+package parser
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SaleBillColumns maps sale bill fields to spreadsheet columns (0-based); -1
+// means the field is not mapped. Amount is the gross amount, tax included;
+// the e-invoice and tax fields are optional.
+type SaleBillColumns struct {
+	BillNumber   int
+	Date         int
+	PartyName    int
+	Amount       int
+	IRN          int
+	AckNumber    int
+	AckDate      int
+	TaxableValue int
+	CGST         int
+	SGST         int
+	IGST         int
+}
+
+// Header words that identify each sale bill column, checked in this order so
+// that "Bill Date" is taken as the date rather than the bill number, "Ack
+// Date" and "Ack No" as e-invoice fields rather than either, and "Taxable
+// Value" and "CGST Amount" as tax fields rather than the amount. A column
+// whose header has none of also, when given, is not taken.
+var saleColumnHeaders = []struct {
+	field func(*SaleBillColumns) *int
+	words []string
+	also  []string
+}{
+	{func(c *SaleBillColumns) *int { return &c.AckDate }, []string{"DATE", "DT"}, []string{"ACK", "ACKNOWLEDGEMENT"}},
+	{func(c *SaleBillColumns) *int { return &c.AckDate }, []string{"ACKDATE", "ACKDT"}, nil},
+	{func(c *SaleBillColumns) *int { return &c.AckNumber }, []string{"ACK", "ACKNO", "ACKNOWLEDGEMENT"}, nil},
+	{func(c *SaleBillColumns) *int { return &c.IRN }, []string{"IRN"}, nil},
+	{func(c *SaleBillColumns) *int { return &c.TaxableValue }, []string{"TAXABLE"}, nil},
+	{func(c *SaleBillColumns) *int { return &c.CGST }, []string{"CGST"}, nil},
+	{func(c *SaleBillColumns) *int { return &c.SGST }, []string{"SGST", "UTGST"}, nil},
+	{func(c *SaleBillColumns) *int { return &c.IGST }, []string{"IGST"}, nil},
+	{func(c *SaleBillColumns) *int { return &c.Date }, []string{"DATE", "DT"}, nil},
+	{func(c *SaleBillColumns) *int { return &c.Amount }, []string{"AMOUNT", "AMT", "TOTAL", "VALUE", "NET", "GROSS"}, nil},
+	{func(c *SaleBillColumns) *int { return &c.PartyName }, []string{"PARTY", "CUSTOMER", "NAME", "ACCOUNT"}, nil},
+	{func(c *SaleBillColumns) *int { return &c.BillNumber }, []string{"BILL", "INVOICE", "INV", "VOUCHER", "VCH", "NO"}, nil},
+}
+
+// unmappedSaleBillColumns maps no field
+var unmappedSaleBillColumns = SaleBillColumns{
+	BillNumber: -1, Date: -1, PartyName: -1, Amount: -1,
+	IRN: -1, AckNumber: -1, AckDate: -1,
+	TaxableValue: -1, CGST: -1, SGST: -1, IGST: -1,
+}
+
+// excelEpoch is day zero of spreadsheet date serial numbers
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// Date layouts accepted in sale bill spreadsheets, day first as in the
+// billing software
+var saleDateLayouts = []string{
+	"02-01-2006", "02/01/2006", "02.01.2006", "2006-01-02",
+	"02-01-06", "02/01/06", "02.01.06",
+	"02-Jan-2006", "02-Jan-06", "02 Jan 2006", "2-1-2006", "2/1/2006",
+}
+
+// GuessSaleBillColumns maps sale bill fields to columns by their header names.
+// Fields without a recognizable header are left unmapped.
+func GuessSaleBillColumns(header []string) SaleBillColumns {
+	cols := unmappedSaleBillColumns
+	taken := make(map[int]bool)
+	for _, h := range saleColumnHeaders {
+		field := h.field(&cols)
+		if *field >= 0 {
+			continue
+		}
+		for i, name := range header {
+			if taken[i] || !headerHasWord(name, h.words) || (h.also != nil && !headerHasWord(name, h.also)) {
+				continue
+			}
+			*field = i
+			taken[i] = true
+			break
+		}
+	}
+	return cols
+}
+
+// saleHeaderSearchRows is how many leading rows are searched for the header,
+// allowing for report titles above it
+const saleHeaderSearchRows = 10
+
+// FindSaleBillHeader finds the header row among the first rows of a sheet, the
+// one whose names map the most sale bill fields, and returns its index and
+// column mapping. The index is -1 if no row names any field.
+func FindSaleBillHeader(rows [][]string) (int, SaleBillColumns) {
+	best := -1
+	bestCols := unmappedSaleBillColumns
+	bestMapped := 0
+	for i := 0; i < len(rows) && i < saleHeaderSearchRows; i++ {
+		cols := GuessSaleBillColumns(rows[i])
+		mapped := 0
+		for _, c := range []int{cols.BillNumber, cols.Date, cols.PartyName, cols.Amount} {
+			if c >= 0 {
+				mapped++
+			}
+		}
+		if mapped > bestMapped {
+			best, bestCols, bestMapped = i, cols, mapped
+		}
+	}
+	return best, bestCols
+}
+
+// headerHasWord reports whether a header contains one of words as a word
+func headerHasWord(header string, words []string) bool {
+	fields := strings.FieldsFunc(strings.ToUpper(header), func(r rune) bool {
+		return (r < 'A' || r > 'Z') && (r < '0' || r > '9')
+	})
+	for _, f := range fields {
+		for _, w := range words {
+			if f == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ParseSaleBillRows converts spreadsheet rows to sale bills using the column
+// mapping. Rows that don't hold a bill, such as headers and totals, are
+// skipped. defaultYear completes dates given as day and month only. The
+// e-invoice and tax fields are read where mapped; bills without an IRN leave
+// its fields blank, and a blank tax is none.
+func ParseSaleBillRows(rows [][]string, cols SaleBillColumns, defaultYear int) []SaleBill {
+	if cols.BillNumber < 0 || cols.Date < 0 || cols.PartyName < 0 || cols.Amount < 0 {
+		return nil
+	}
+
+	var bills []SaleBill
+	for _, row := range rows {
+		cell := func(i int) string {
+			if i >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[i])
+		}
+
+		billNumber := cell(cols.BillNumber)
+		partyName := cell(cols.PartyName)
+		if billNumber == "" || partyName == "" {
+			continue
+		}
+		date, ok := parseSaleBillDate(cell(cols.Date), defaultYear)
+		if !ok {
+			continue
+		}
+		amount, ok := parseSaleBillAmount(cell(cols.Amount))
+		if !ok {
+			continue
+		}
+
+		bill := SaleBill{
+			BillNumber: billNumber,
+			Date:       date,
+			Amount:     amount,
+		}
+		bill.PartyName, bill.IsCashSale, bill.IsCardSale = classifySaleParty(partyName)
+		if cols.IRN >= 0 {
+			bill.IRN = strings.ToLower(strings.Join(strings.Fields(cell(cols.IRN)), ""))
+		}
+		if cols.AckNumber >= 0 {
+			bill.AckNumber = cell(cols.AckNumber)
+		}
+		if cols.AckDate >= 0 {
+			bill.AckDate = parseAckDate(cell(cols.AckDate), defaultYear)
+		}
+		for _, tax := range []struct {
+			col   int
+			field *float64
+		}{
+			{cols.TaxableValue, &bill.TaxableValue},
+			{cols.CGST, &bill.CGST},
+			{cols.SGST, &bill.SGST},
+			{cols.IGST, &bill.IGST},
+		} {
+			if tax.col >= 0 {
+				*tax.field, _ = parseSaleBillAmount(cell(tax.col))
+			}
+		}
+		bills = append(bills, bill)
+	}
+	return bills
+}
+
+// parseSaleBillDate parses a spreadsheet date: a date in one of the day-first
+// layouts, day and month only, or a spreadsheet serial number
+func parseSaleBillDate(s string, defaultYear int) (time.Time, bool) {
+	if s == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range saleDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	for _, layout := range []string{"02-01", "02/01"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return time.Date(defaultYear, t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), true
+		}
+	}
+	// Spreadsheets store dates as days since the epoch; accept 1954 to 2118
+	if serial, err := strconv.ParseFloat(s, 64); err == nil && serial >= 20000 && serial < 80000 {
+		return excelEpoch.AddDate(0, 0, int(serial)), true
+	}
+	return time.Time{}, false
+}
+
+// parseAckDate parses an e-invoice acknowledgement date, which the portal
+// gives with the time, as in 05-09-2026 14:23:00; the time is dropped. It is
+// the zero time when blank or not a date.
+func parseAckDate(s string, defaultYear int) time.Time {
+	if date, ok := parseSaleBillDate(s, defaultYear); ok {
+		return date
+	}
+	if fields := strings.Fields(s); len(fields) > 1 {
+		if date, ok := parseSaleBillDate(fields[0], defaultYear); ok {
+			return date
+		}
+	}
+	return time.Time{}
+}
+
+// parseSaleBillAmount parses an amount with optional rupee sign and commas
+func parseSaleBillAmount(s string) (float64, bool) {
+	s = strings.NewReplacer(",", "", "₹", "", " ", "").Replace(s)
+	s = strings.TrimPrefix(strings.ToUpper(s), "RS.")
+	amount, err := strconv.ParseFloat(s, 64)
+	if err != nil || amount <= 0 {
+		return 0, false
+	}
+	return amount, true
+}
+
+// ReadCSVRows reads the rows of a CSV file, allowing rows of varying length
+func ReadCSVRows(r io.Reader) ([][]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("reading CSV: %w", err)
+	}
+	return rows, nil
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		RelID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// xlsxText is rich or plain text in a shared string or inline string cell
+type xlsxText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var b strings.Builder
+	for _, r := range t.Runs {
+		b.WriteString(r.Text)
+	}
+	return b.String()
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// ReadXLSXRows reads the rows of the first worksheet of an .xlsx workbook as
+// text. Dates are returned as spreadsheet serial numbers.
+func ReadXLSXRows(data []byte) ([][]string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("opening workbook: %w", err)
+	}
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var shared xlsxSharedStrings
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodeZipXML(f, &shared); err != nil {
+			return nil, fmt.Errorf("reading shared strings: %w", err)
+		}
+	}
+
+	f, ok := files[firstSheetPath(files)]
+	if !ok {
+		return nil, fmt.Errorf("workbook has no worksheet")
+	}
+	var sheet xlsxSheet
+	if err := decodeZipXML(f, &sheet); err != nil {
+		return nil, fmt.Errorf("reading worksheet: %w", err)
+	}
+
+	rows := make([][]string, 0, len(sheet.Rows))
+	for _, r := range sheet.Rows {
+		var row []string
+		for i, c := range r.Cells {
+			col := i
+			if c.Ref != "" {
+				col = xlsxColumn(c.Ref)
+			}
+			var value string
+			switch c.Type {
+			case "s":
+				idx, err := strconv.Atoi(c.Value)
+				if err != nil || idx < 0 || idx >= len(shared.Items) {
+					return nil, fmt.Errorf("cell %s: invalid shared string %q", c.Ref, c.Value)
+				}
+				value = shared.Items[idx].String()
+			case "inlineStr":
+				value = c.Inline.String()
+			default:
+				value = c.Value
+			}
+			for len(row) <= col {
+				row = append(row, "")
+			}
+			row[col] = value
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// firstSheetPath finds the worksheet listed first in the workbook, falling
+// back to the conventional name of the first sheet
+func firstSheetPath(files map[string]*zip.File) string {
+	const fallback = "xl/worksheets/sheet1.xml"
+
+	var workbook xlsxWorkbook
+	var rels xlsxRelationships
+	wf, ok := files["xl/workbook.xml"]
+	rf, ok2 := files["xl/_rels/workbook.xml.rels"]
+	if !ok || !ok2 || decodeZipXML(wf, &workbook) != nil || decodeZipXML(rf, &rels) != nil || len(workbook.Sheets) == 0 {
+		return fallback
+	}
+	for _, rel := range rels.Relationships {
+		if rel.ID != workbook.Sheets[0].RelID {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/")
+		}
+		return path.Join("xl", rel.Target)
+	}
+	return fallback
+}
+
+// xlsxColumn converts the column letters of a cell reference such as "AB12"
+// to a 0-based column index
+func xlsxColumn(ref string) int {
+	col := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A') + 1
+	}
+	return col - 1
+}
+
+func decodeZipXML(f *zip.File, v any) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(rc).Decode(v)
+}
@@ -0,0 +1,257 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Vocabulary holds the word lists and patterns the receipt book parser
+// recognises, which change more often than the parsing code
+type Vocabulary struct {
+	// Locations are place names that end a party line; a last word equal to
+	// or starting with one is taken as the party's location. A place name of
+	// several words, such as KIDWAI NAGAR, is taken when the line ends with
+	// all of them.
+	Locations []string
+	// NonLocationWords are last words never taken as a location, such as
+	// STORE or MEDICAL
+	NonLocationWords []string
+	// SkipPatterns are regular expressions of lines that are not entries:
+	// headers, totals, carry-overs and separators
+	SkipPatterns []string
+	// NarrationPrefixes start narration lines, so a line starting with one
+	// is never taken as another party's line
+	NarrationPrefixes []string
+	// PaymentModes detect an entry's payment mode from its narration; the
+	// first rule that matches wins, and entries no rule matches are OTHER
+	PaymentModes []PaymentModeRule
+	// FirmNames are the names of the firms whose receipt books are parsed,
+	// which start the page header repeated on every page
+	FirmNames []string
+}
+
+// PaymentModeRule sets the payment mode of entries whose narration matches a
+// regular expression. Patterns match anywhere in the narration, since the
+// bank account line often comes first.
+type PaymentModeRule struct {
+	Pattern string
+	Mode    string
+}
+
+// DefaultVocabulary is the vocabulary built from the receipt books seen so
+// far. It seeds the vocabulary of a new database.
+var DefaultVocabulary = Vocabulary{
+	Locations: []string{
+		// Major Indian cities
+		"DELHI", "MUMBAI", "KOLKATA", "CHENNAI", "BANGALORE", "HYDERABAD",
+		"AHMEDABAD", "PUNE", "SURAT", "JAIPUR", "LUCKNOW", "KANPUR",
+		"NAGPUR", "INDORE", "THANE", "BHOPAL", "PATNA", "VADODARA",
+		"GHAZIABAD", "LUDHIANA", "AGRA", "NASHIK", "FARIDABAD", "MEERUT",
+		"RAJKOT", "VARANASI", "SRINAGAR", "AURANGABAD", "DHANBAD", "AMRITSAR",
+		"JODHPUR", "RAIPUR", "RANCHI", "GWALIOR", "CHANDIGARH", "VIJAYAWADA",
+		"MADURAI", "COIMBATORE", "KOCHI", "GUWAHATI", "BHUBANESWAR", "DEHRADUN",
+		"NOIDA", "GURUGRAM", "GURGAON", "NCR", "GWALIOUR",
+		// UP towns and areas from receipt book
+		"SEKHREJ", "SHAMBHUA", "MUSKRA", "BILLHAUR", "RASULABAD", "MUNGISAPUR",
+		"JUNIHA", "MAHARAMAU", "AKBARPUR", "AKABARPUR", "CHIBRAMAU", "DHAURA",
+		"CHAMIYANI", "CHAUDAGRA", "BARAUR", "INDERGAR", "GHATAMPUR", "BITHOOR",
+		"BIGHAPUR", "BAIRAGIHAR", "SIKANDRA", "ACHALGANJ", "PUKHRAYA", "PUKHRAYAN",
+		"DIBIAPUR", "DIBIYAPUR", "MIYAGANJ", "AURAIYA", "LALITPUR", "MAKANPUR",
+		"RAATH", "KHAKHRERU", "SAHAYAL", "CHANI", "SAJETI", "BASIRAT", "JALLAUN",
+		"BANGARMAU", "ALIYAPUR", "TIRWA", "BAKEWAR", "BHAUTY", "KANNOUJ", "KONCH",
+		"NAWABGANJ", "FATEHPUR", "ORAI", "HARDOI", "UNNAO", "SITAPUR", "ETAWAH",
+		"BANDA", "JHANSI", "HAMEERPUR", "BHEWAN", "NABIPUR", "TISTI", "UMARDA",
+		"TALEGRAM", "KENJARI", "KENJARY", "JHIJHAK", "HASEERAN", "SHIVRAJPUR",
+		"BAHOSI", "KUDANY", "VISHDHAN", "KAKVAN", "MAUDAHA", "JAHANABAD",
+		"MURADIPUR", "PARSAULI", "AJGAIN", "RAMAIPUR", "DHANI", "BARUA", "SAHAR",
+		"KHAJUA", "BARUA", "FARRUKHABAD", "LAKHIMPUR", "GONDA", "SHIVLI",
+		"MANIMAU", "ROORA", "ROOMA", "RANIA", "NOONARI", "NARWAL", "TIKRA",
+		"BHARUA", "CHHIBRAMAU", "FAZALGANJ", "KALYANPUR", "KALYAN", "KAKADEV",
+		"BIRHANA", "MANISHA", "SUMER", "BEEGAHPUR", "HASWA", "SIRATHU",
+		"VIJAIPUR", "ATARDHANI", "MAURANIPUR", "SACHENDI", "BITHHOR", "BARAIGHAR",
+		"HAPUR", "GEHLO", "DEHAT",
+		// Additional locations from June 2025 receipt book
+		"NAUBASTA", "PANKI", "BHAGHPUR", "NARAMAU", "THATHIA", "REWARI",
+		"BAIRAMPUR", "GALUAPUR", "SAROSI", "AGAUS", "PATARA", "BANIPARA",
+		"MAQSUDABAD", "TIGAI", "HAIDRABAD", "KHEDA", "ALLIPUR", "ASHOTHAR",
+		"THARIYAOAN", "SIMRI", "CHAURA", "CHOWKI", "CHHILLA", "SAHLI",
+		"SAKURABAD", "SUMRAHA", "MURADAB", "GURSHAYAN",
+		"BARADEVI", "BARRA", "PATARSA", "KHAGA", "KORIYAN",
+		"BHOGNIPUR", "RAJPUR", "SAHJHANPUR",
+		// Additional locations from July 2025 receipt book
+		"CHITRAKOOT", "PRAYAGRAJ", "LALPUR", "BIHARIPURWA", "AHIRWA",
+		"MANAVATI", "JAFARGANJ", "KATHARA", "LALGANJ", "HUSAIN",
+		"DILEEP", "BAHUA", "KHAIR", "ROSHNMAU", "GAJNER", "KANCHAUSI",
+		"UGU", "JAMUKA", "FARIDPUR", "UMRI", "BADARKA", "ALIYAPUR",
+		// Additional locations from October 2025 receipt book
+		"ASHOTHAR", "PURAMEER", "BASREHAR", "AUSER", "GUJANI", "JALALABAD",
+		"SHAHNAGAR", "AMRAUDHA", "COLONELGANJ", "MAINPURI", "NADEMAU",
+		"AUNG", "GAYA", "SHIVALI", "BABARO", "BELA", "SINGHPUR", "AMAULI",
+		"RAWATPUR", "NAGAR", "KHANPUR", "KHAR", "RATH",
+		// Additional locations from October 2025 full data
+		"MUNSI", "GAO", "CHAURA", "SUMER", "KHERA",
+		// Additional locations from April 2025 PNB data
+		"LUDHIYANI", "INDERGARH",
+		// Areas of several words that are not a location and suffix word
+		"KIDWAI NAGAR", "GOVIND NAGAR", "SHASTRI NAGAR", "COLONEL GANJ",
+		"KAKA DEV", "JUHI KALAN",
+	},
+	NonLocationWords: []string{
+		"BUSINESS", "MACHINE", "STORE", "AGENCY", "TRADERS", "PHARMA", "CHEMIST",
+		"MEDICOS", "MEDICAL", "DRUG", "HOUSE", "HALL", "CENTRE", "CENTER",
+	},
+	SkipPatterns: []string{
+		`(?i)^SUB\s+TOTAL`,
+		`(?i)Continued\.\.`, // Continued..2, Continued..3, etc.
+		`(?i)^SUSPENSE\s+A/C`,
+		`(?i)^\s*$`,
+		`^-+$`,                            // Separator lines (-----)
+		`^=+$`,                            // Separator lines (=====)
+		`(?i)^TOTAL\s+[\d,.]+\s+[\d,.]+$`, // Total line
+		`(?i)^\*\*\*.*\*\*\*$`,            // *** End of Report ***
+		`(?i)^DATE\s+PARTICULARS\s+DEBIT\s+CREDIT`,       // Header line
+		`(?i)^RECEIPT\s+BOOK`,                            // Receipt book header
+		`(?i)^DURGA\s+DAWA\s+GHAR`,                       // Company name header
+		`(?i)^\d{2}-\d{2}-\d{4}\s+-\s+\d{2}-\d{2}-\d{4}`, // Date range header (with optional page number)
+		`(?i)^E-Mail\s*:`,                                // Email line
+		`(?i)^D\.?L\.?\s*No\.?\s*:`,                      // DL number line
+		`(?i)^GSTIN\s*:`,                                 // GSTIN line
+		`(?i)^\d+/\d+,`,                                  // Address line (60/33,...)
+		`(?i)^Page\s+No\.`,                               // Page number line
+		`^[\d,]+(\.\d{2})?\s+[\d,]+(\.\d{2})?$`,          // Balance lines (75901.00 75901.00, 1,25,213.00 1,25,213.00)
+		`^,`,                                             // Invoice ref continuation (,DDG)
+		// Page carry-over lines (B/F, C/F, BROUGHT FORWARD, CARRIED FORWARD)
+		`(?i)^(?:BALANCE\s+)?(?:B/F|C/F|BROUGHT\s+FORWARD|CARRIED\s+FORWARD)\b`,
+	},
+	NarrationPrefixes: []string{
+		"UPI/", "NEFT-", "NEFT_", "RTGS-", "IMPS/", "IMPS-", "MMT/", "CLG/", "INF/", "INFT/", "TRF/", "TRTR/",
+		"CHQ.", "CHEQUE", "BY CASH", "FT-MESPOS", "BIL/", "NACH-", "NACH/", "ACH/", "ECS/",
+		"AG.", "AG ", // Invoice reference lines (Ag. DDG...) - should not be party lines
+		"FROM:", // AEPS-style narration (From:XXXX8723:NAME)
+	},
+	PaymentModes: []PaymentModeRule{
+		{`(?i)\sRTGS-|^RTGS-`, "RTGS"},
+		{`(?i)\sNEFT-|^NEFT-|\sNEFT_IN:|^NEFT_IN:`, "NEFT"},
+		{`(?i)IMPS/|/IMPS/|MMT/IMPS|\sIMPS-IN/|^IMPS-IN/`, "IMPS"},
+		{`(?i)^UPI/|/UPI/|/UPI$|\sUPI/`, "UPI"},
+		{`(?i)\b(?:NACH|ACH|ECS)[/-]`, "NACH"},
+		{`(?i)\sCLG/|^CLG/`, "CLG"},
+		{`(?i)\sINF/|^INF/|^INFT/|/INFT/|\sINFT/`, "INF"},
+		{`(?i)\sTRF/|^TRF/|\sTRTR/|^TRTR/`, "TRF"},
+		{`(?i)Chq\.|Cheque|CHQ`, "CHEQUE"},
+		{`(?i)FT-MESPOS|MESPOS\s+SET|POS\s+MACHINE`, "POS"},
+		{`(?i)^BY\s+CASH|\sBY\s+CASH|CASH\s+DEP|CAM/|\sBY\s+[A-Z].+\s-\d{3,8}\s|^BY\s+[A-Z].+\s-\d{3,8}\s`, "CASH"},
+	},
+	FirmNames: []string{"DURGA DAWA GHAR"},
+}
+
+// Parser parses receipt book text with a vocabulary
+type Parser struct {
+	locations         []string
+	locationPhrases   [][]string // locations of several words, as words
+	nonLocationWords  map[string]bool
+	skipPatterns      []*regexp.Regexp
+	narrationPrefixes []string
+	paymentModes      []compiledPaymentModeRule
+	firmHeader        *regexp.Regexp // a line starting with a firm's name; nil without firm names
+}
+
+type compiledPaymentModeRule struct {
+	PaymentModeRule
+	pattern *regexp.Regexp
+}
+
+// defaultParser parses with the default vocabulary
+var defaultParser = mustNew(DefaultVocabulary)
+
+// New creates a parser for a vocabulary, failing if a skip or payment mode
+// pattern is not valid. Words and prefixes are matched in uppercase.
+func New(v Vocabulary) (*Parser, error) {
+	p := &Parser{nonLocationWords: make(map[string]bool)}
+	for _, loc := range v.Locations {
+		switch words := strings.Fields(strings.ToUpper(loc)); len(words) {
+		case 0:
+		case 1:
+			p.locations = append(p.locations, words[0])
+		default:
+			p.locationPhrases = append(p.locationPhrases, words)
+		}
+	}
+	for _, word := range v.NonLocationWords {
+		if word = strings.ToUpper(strings.TrimSpace(word)); word != "" {
+			p.nonLocationWords[word] = true
+		}
+	}
+	for _, pattern := range v.SkipPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid skip pattern %q: %w", pattern, err)
+		}
+		p.skipPatterns = append(p.skipPatterns, re)
+	}
+	for _, prefix := range v.NarrationPrefixes {
+		if prefix = strings.ToUpper(prefix); strings.TrimSpace(prefix) != "" {
+			p.narrationPrefixes = append(p.narrationPrefixes, prefix)
+		}
+	}
+	for _, rule := range v.PaymentModes {
+		c, err := compilePaymentModeRule(rule)
+		if err != nil {
+			return nil, err
+		}
+		p.paymentModes = append(p.paymentModes, c)
+	}
+	p.firmHeader = firmHeaderPattern(v.FirmNames)
+	return p, nil
+}
+
+// firmHeaderPattern matches a line starting with one of the firm names, in
+// any case and spacing, or returns nil when there are none
+func firmHeaderPattern(firmNames []string) *regexp.Regexp {
+	var names []string
+	for _, name := range firmNames {
+		words := strings.Fields(name)
+		for i, word := range words {
+			words[i] = regexp.QuoteMeta(word)
+		}
+		if len(words) > 0 {
+			names = append(names, strings.Join(words, `\s+`))
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	return regexp.MustCompile(`(?i)^(?:` + strings.Join(names, "|") + `)(?:\W|$)`)
+}
+
+// ValidatePaymentModeRule checks that a payment mode rule has a mode and a
+// valid pattern
+func ValidatePaymentModeRule(rule PaymentModeRule) error {
+	_, err := compilePaymentModeRule(rule)
+	return err
+}
+
+func compilePaymentModeRule(rule PaymentModeRule) (compiledPaymentModeRule, error) {
+	c := compiledPaymentModeRule{PaymentModeRule: rule}
+	if strings.TrimSpace(rule.Mode) == "" {
+		return c, fmt.Errorf("payment mode rule %q has no mode", rule.Pattern)
+	}
+	if strings.TrimSpace(rule.Pattern) == "" {
+		return c, fmt.Errorf("payment mode rule for %s has no pattern", rule.Mode)
+	}
+	re, err := regexp.Compile(rule.Pattern)
+	if err != nil {
+		return c, fmt.Errorf("invalid pattern for %s: %w", rule.Mode, err)
+	}
+	c.pattern = re
+	return c, nil
+}
+
+func mustNew(v Vocabulary) *Parser {
+	p, err := New(v)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
@@ -0,0 +1,175 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWidth(t *testing.T) {
+	// Digits are 556 wide in both fonts, and the period 278
+	if got := Width("1234.50", 10, Regular); got != 36.14 {
+		t.Errorf("Width(1234.50) = %v, want 36.14", got)
+	}
+	if Width("Bill", 10, Bold) <= Width("Bill", 10, Regular) {
+		t.Error("bold text is not wider than regular")
+	}
+	if got, want := Width("₹", 10, Regular), Width("?", 10, Regular); got != want {
+		t.Errorf("Width(₹) = %v, want the width of ? %v", got, want)
+	}
+}
+
+func TestFit(t *testing.T) {
+	if got := Fit("Receipt", 100, 10, Regular); got != "Receipt" {
+		t.Errorf("Fit() shortened text that fits: %q", got)
+	}
+	got := Fit("Receipt (NEFT) from a party with a very long name", 100, 10, Regular)
+	if !strings.HasSuffix(got, "...") || Width(got, 10, Regular) > 100 {
+		t.Errorf("Fit() = %q, %v wide", got, Width(got, 10, Regular))
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	d := New()
+	d.Text(40, 40, 16, Bold, "Statement (A/C) 100%")
+	d.TextRight(550, 60, 10, Regular, `₹1,000.00 \ Dr`)
+	d.Line(40, 70, 555, 70)
+	d.AddPage()
+	d.Text(40, 40, 10, Regular, "Page 2")
+	if d.Pages() != 2 {
+		t.Fatalf("Pages() = %d, want 2", d.Pages())
+	}
+
+	var buf bytes.Buffer
+	if _, err := d.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.HasPrefix(out, "%PDF-1.4\n") || !strings.HasSuffix(out, "%%EOF\n") {
+		t.Errorf("not a PDF file:\n%s", out)
+	}
+	for _, want := range []string{
+		`(Statement \(A/C\) 100%) Tj`,
+		`(?1,000.00 \\ Dr) Tj`,
+		"/Count 2",
+		"/BaseFont /Helvetica-Bold",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("PDF lacks %q", want)
+		}
+	}
+
+	// Every object is where the cross-reference table says
+	xref := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(out, -1)
+	if len(xref) != 8 {
+		t.Fatalf("cross-reference table has %d objects, want 8", len(xref))
+	}
+	for i, m := range xref {
+		offset, _ := strconv.Atoi(m[1])
+		if want := strconv.Itoa(i+1) + " 0 obj"; !strings.HasPrefix(out[offset:], want) {
+			t.Errorf("object %d is not at offset %d", i+1, offset)
+		}
+	}
+	start := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(out)
+	if offset, _ := strconv.Atoi(start[1]); !strings.HasPrefix(out[offset:], "xref\n") {
+		t.Errorf("startxref %d does not point at the cross-reference table", offset)
+	}
+}
+
+func TestExtractText(t *testing.T) {
+	// A receipt book page as the accounting software lays it out: the date,
+	// the particulars and the amount in columns
+	d := New()
+	d.Text(40, 60, 10, Bold, "RECEIPT BOOK")
+	d.Text(40, 80, 9, Regular, "May 1")
+	d.Text(90, 80, 9, Regular, "AMIT MED STORE MANIMAU")
+	d.TextRight(550, 80, 9, Regular, "6639.00")
+	d.Text(90, 92, 9, Regular, "BHAWANI MEDICAL STORE MANIMAU")
+	d.TextRight(550, 92, 9, Regular, "1856.00")
+	d.Text(90, 104, 9, Regular, "ICICI 192105002017 8495.00")
+	d.AddPage()
+	d.Text(40, 60, 9, Regular, "SUB TOTAL")
+	d.TextRight(550, 60, 9, Regular, "8495.00")
+	var buf bytes.Buffer
+	if _, err := d.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	pages, err := ExtractText(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"RECEIPT BOOK\nMay 1 AMIT MED STORE MANIMAU 6639.00\nBHAWANI MEDICAL STORE MANIMAU 1856.00\nICICI 192105002017 8495.00",
+		"SUB TOTAL 8495.00",
+	}
+	if strings.Join(pages, "\f") != strings.Join(want, "\f") {
+		t.Errorf("ExtractText() = %q, want %q", pages, want)
+	}
+
+	if _, err := ExtractText([]byte("Dec 26 SANDHYA MEDICAL STORE 5000.00")); err == nil {
+		t.Error("ExtractText() of text succeeded")
+	}
+	if _, err := ExtractText([]byte("%PDF-1.6\ntrailer << /Root 1 0 R /Encrypt 9 0 R >>")); err != ErrEncrypted {
+		t.Errorf("ExtractText() of an encrypted PDF = %v, want ErrEncrypted", err)
+	}
+}
+
+// TestExtractTextCompressed reads a PDF as newer software writes it: objects
+// packed in a compressed object stream, text in a composite font mapped to
+// Unicode, spaced by kerning and drawn in a form
+func TestExtractTextCompressed(t *testing.T) {
+	deflate := func(s string) string {
+		var b bytes.Buffer
+		w := zlib.NewWriter(&b)
+		w.Write([]byte(s))
+		w.Close()
+		return b.String()
+	}
+	cmap := "/CIDInit /ProcSet findresource begin 12 dict begin begincmap\n" +
+		"1 begincodespacerange <0000> <FFFF> endcodespacerange\n" +
+		"3 beginbfchar <0003> <0020> <0010> <20B9> <0011> <002E> endbfchar\n" +
+		"1 beginbfrange <0024> <003D> <0041> endbfrange\n" +
+		"1 beginbfrange <0013> <001C> <0030> endbfrange\n" +
+		"endcmap CMapName currentdict /CMap defineresource pop end end"
+	// SANDHYA drawn twice over for bold, LUCKNOW kerned, and ₹5000.00 and the
+	// next line drawn in a form placed 400 points to the right
+	content := "BT /F1 10 Tf 1 0 0 1 40 700 Tm <0036002400310027002B003C0024> Tj ET " +
+		"BT /F1 10 Tf 40.2 700 Td <0036002400310027002B003C0024> Tj ET " +
+		"BT /F1 10 Tf 1 0 0 1 90 700 Tm [<002F>-50<00380026002E00310032003A>] TJ ET " +
+		"q 1 0 0 1 400 0 cm /Fm1 Do Q"
+	form := "BT /F1 10 Tf 100 700 Td 12 TL <00100018001300130013001100130013> Tj T* <00310032003A> Tj ET"
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 4 0 R >> /XObject << /Fm1 8 0 R >> >> /Contents 7 0 R >>",
+		"<< /Type /Font /Subtype /Type0 /BaseFont /Arial /Encoding /Identity-H /DescendantFonts [5 0 R] /ToUnicode 6 0 R >>",
+		"<< /Type /Font /Subtype /CIDFontType2 /DW 600 /W [3 [278] 19 28 556] >>",
+	}
+	var header, body strings.Builder
+	for i, o := range objects {
+		fmt.Fprintf(&header, "%d %d ", i+1, body.Len())
+		body.WriteString(o + "\n")
+	}
+	packed := deflate(header.String() + body.String())
+	pdf := "%PDF-1.7\n" +
+		fmt.Sprintf("6 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream\nendobj\n", len(deflate(cmap)), deflate(cmap)) +
+		fmt.Sprintf("7 0 obj\n<< /Length 10 0 R /Filter [/FlateDecode] >>\nstream\n%s\nendstream\nendobj\n", deflate(content)) +
+		fmt.Sprintf("8 0 obj\n<< /Type /XObject /Subtype /Form /Resources << /Font << /F1 4 0 R >> >> /Length %d >>\nstream\n%s\nendstream\nendobj\n", len(form), form) +
+		fmt.Sprintf("9 0 obj\n<< /Type /ObjStm /N %d /First %d /Filter /FlateDecode /Length %d >>\nstream\n%s\nendstream\nendobj\n", len(objects), header.Len(), len(packed), packed) +
+		"10 0 obj\n0\nendobj\n" +
+		"trailer\n<< /Root 1 0 R >>\n%%EOF\n"
+
+	pages, err := ExtractText([]byte(pdf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "SANDHYA LUCKNOW ₹5000.00\nNOW"; len(pages) != 1 || pages[0] != want {
+		t.Errorf("ExtractText() = %q, want %q", pages, want)
+	}
+}
@@ -0,0 +1,1057 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/ascii85"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// Reading text back out of a PDF takes the objects of the file as they are
+// found in it, rather than through its cross-reference table, so files saved
+// with a broken table still read. Text is placed where the page's content
+// stream draws it and lines are rebuilt from those positions, which keeps the
+// columns of a line together where a viewer's copy and paste would split them.
+
+// ErrEncrypted is returned for a PDF whose content is encrypted
+var ErrEncrypted = errors.New("the PDF is encrypted; save an unprotected copy first")
+
+// maxStreamSize limits a stream once decompressed
+const maxStreamSize = 64 << 20
+
+// maxDepth limits nesting: of arrays and dictionaries, of references
+// followed, and of forms drawn inside forms
+const maxDepth = 32
+
+type (
+	name     string
+	keyword  string // an operator in a content stream, or a keyword such as obj
+	dict     map[string]any
+	objRef   struct{ num, gen int }
+	pdfArray []any
+	stream   struct {
+		dict dict
+		raw  []byte
+	}
+)
+
+var (
+	objPattern     = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+	rootPattern    = regexp.MustCompile(`/Root\s+(\d+)\s+\d+\s+R`)
+	encryptPattern = regexp.MustCompile(`/Encrypt\s*(?:\d+\s+\d+\s+R|<<)`)
+)
+
+// ExtractText returns the text of each page of a PDF, its lines in reading
+// order from the top of the page and the pieces of each line in order from
+// the left, separated by a space where there is a gap between them
+func ExtractText(data []byte) ([]string, error) {
+	if i := bytes.Index(data, []byte("%PDF-")); i < 0 || i > 1024 {
+		return nil, errors.New("not a PDF file")
+	}
+	if encryptPattern.Match(data) {
+		return nil, ErrEncrypted
+	}
+	r := &reader{objects: make(map[int]any), fonts: make(map[any]*font)}
+	r.scan(data)
+
+	root := r.dict(r.catalog(data))
+	if root == nil {
+		return nil, errors.New("the PDF has no catalog")
+	}
+	var pages []dict
+	r.collectPages(r.resolve(root["Pages"]), nil, &pages, 0)
+	if len(pages) == 0 {
+		return nil, errors.New("the PDF has no pages")
+	}
+
+	texts := make([]string, len(pages))
+	for i, page := range pages {
+		content, err := r.pageContent(page)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", i+1, err)
+		}
+		c := &canvas{r: r}
+		c.run(content, r.dict(page["Resources"]), identity, 0)
+		texts[i] = layout(c.glyphs)
+	}
+	return texts, nil
+}
+
+type reader struct {
+	objects map[int]any
+	fonts   map[any]*font // by the font's reference, or its dictionary's address
+}
+
+// scan reads every object of the file, later ones replacing earlier ones of
+// the same number as an incremental update does, then the objects packed in
+// object streams
+func (r *reader) scan(data []byte) {
+	end := 0
+	for _, m := range objPattern.FindAllSubmatchIndex(data, -1) {
+		if m[0] < end {
+			continue // inside the stream of the last object
+		}
+		num, _ := strconv.Atoi(string(data[m[2]:m[3]]))
+		l := &lexer{data: data, pos: m[1]}
+		v, err := l.value(0)
+		if err != nil {
+			continue
+		}
+		if d, ok := v.(dict); ok {
+			if s, ok := l.streamData(d); ok {
+				v = &stream{dict: d, raw: s}
+			}
+		}
+		r.objects[num] = v
+		end = l.pos
+	}
+
+	var objStreams []*stream
+	for _, v := range r.objects {
+		if s, ok := v.(*stream); ok && s.dict["Type"] == name("ObjStm") {
+			objStreams = append(objStreams, s)
+		}
+	}
+	for _, s := range objStreams {
+		data, err := r.decode(s)
+		if err != nil {
+			continue
+		}
+		n, _ := r.resolve(s.dict["N"]).(float64)
+		first, _ := r.resolve(s.dict["First"]).(float64)
+		l := &lexer{data: data}
+		for i := 0; i < int(n); i++ {
+			num, err1 := l.value(0)
+			offset, err2 := l.value(0)
+			if err1 != nil || err2 != nil {
+				break
+			}
+			numF, ok1 := num.(float64)
+			offsetF, ok2 := offset.(float64)
+			start := int(first) + int(offsetF)
+			if !ok1 || !ok2 || start < 0 || start >= len(data) {
+				continue
+			}
+			if _, defined := r.objects[int(numF)]; defined {
+				continue
+			}
+			obj := &lexer{data: data, pos: start}
+			if v, err := obj.value(0); err == nil {
+				r.objects[int(numF)] = v
+			}
+		}
+	}
+}
+
+// catalog finds the document catalog: the root named by the last trailer,
+// or else any catalog object
+func (r *reader) catalog(data []byte) any {
+	if all := rootPattern.FindAllSubmatch(data, -1); len(all) > 0 {
+		num, _ := strconv.Atoi(string(all[len(all)-1][1]))
+		if d := r.dict(r.objects[num]); d != nil && d["Pages"] != nil {
+			return d
+		}
+	}
+	for _, v := range r.objects {
+		if d, ok := v.(dict); ok && d["Type"] == name("Catalog") {
+			return d
+		}
+	}
+	return nil
+}
+
+// resolve follows references to the object they refer to
+func (r *reader) resolve(v any) any {
+	for i := 0; i < maxDepth; i++ {
+		ref, ok := v.(objRef)
+		if !ok {
+			return v
+		}
+		v = r.objects[ref.num]
+	}
+	return nil
+}
+
+// dict resolves v to a dictionary, or a stream's dictionary
+func (r *reader) dict(v any) dict {
+	switch v := r.resolve(v).(type) {
+	case dict:
+		return v
+	case *stream:
+		return v.dict
+	}
+	return nil
+}
+
+// collectPages appends the pages under a node of the page tree in order,
+// with the resources they inherit filled in
+func (r *reader) collectPages(node any, inherited any, pages *[]dict, depth int) {
+	d := r.dict(node)
+	if d == nil || depth > maxDepth {
+		return
+	}
+	resources := inherited
+	if d["Resources"] != nil {
+		resources = d["Resources"]
+	}
+	if kids, ok := r.resolve(d["Kids"]).(pdfArray); ok {
+		for _, kid := range kids {
+			r.collectPages(kid, resources, pages, depth+1)
+		}
+		return
+	}
+	page := dict{"Contents": d["Contents"], "Resources": resources}
+	*pages = append(*pages, page)
+}
+
+// pageContent returns a page's content streams decoded and joined
+func (r *reader) pageContent(page dict) ([]byte, error) {
+	contents := r.resolve(page["Contents"])
+	streams, ok := contents.(pdfArray)
+	if !ok {
+		streams = pdfArray{contents}
+	}
+	var content []byte
+	for _, v := range streams {
+		s, ok := r.resolve(v).(*stream)
+		if !ok {
+			continue
+		}
+		data, err := r.decode(s)
+		if err != nil {
+			return nil, err
+		}
+		content = append(content, data...)
+		content = append(content, '\n')
+	}
+	return content, nil
+}
+
+// decode undoes a stream's filters
+func (r *reader) decode(s *stream) ([]byte, error) {
+	filters := r.resolve(s.dict["Filter"])
+	list, ok := filters.(pdfArray)
+	if !ok {
+		list = pdfArray{filters}
+	}
+	data := s.raw
+	for _, f := range list {
+		var err error
+		switch f := r.resolve(f); f {
+		case nil:
+			continue
+		case name("FlateDecode"), name("Fl"):
+			var zr io.ReadCloser
+			zr, err = zlib.NewReader(bytes.NewReader(data))
+			if err == nil {
+				// A stream cut short keeps what was read of it
+				data, err = io.ReadAll(io.LimitReader(zr, maxStreamSize+1))
+				if (errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, zlib.ErrChecksum)) && len(data) > 0 {
+					err = nil
+				}
+			}
+		case name("ASCIIHexDecode"), name("AHx"):
+			data, err = decodeHex(data)
+		case name("ASCII85Decode"), name("A85"):
+			data, err = decode85(data)
+		default:
+			return nil, fmt.Errorf("unsupported stream filter %v", f)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decoding stream: %w", err)
+		}
+		if len(data) > maxStreamSize {
+			return nil, errors.New("a stream is over 64 MB")
+		}
+	}
+	return data, nil
+}
+
+func decodeHex(data []byte) ([]byte, error) {
+	if i := bytes.IndexByte(data, '>'); i >= 0 {
+		data = data[:i]
+	}
+	digits := bytes.Map(func(r rune) rune {
+		if isSpace(byte(r)) {
+			return -1
+		}
+		return r
+	}, data)
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	_, err := hex.Decode(out, digits)
+	return out, err
+}
+
+func decode85(data []byte) ([]byte, error) {
+	data = bytes.TrimPrefix(bytes.TrimSpace(data), []byte("<~"))
+	if i := bytes.Index(data, []byte("~>")); i >= 0 {
+		data = data[:i]
+	}
+	out := make([]byte, 4*len(data)/5+4)
+	n, _, err := ascii85.Decode(out, data, true)
+	return out[:n], err
+}
+
+// lexer reads PDF objects and content stream operators
+type lexer struct {
+	data []byte
+	pos  int
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+// skipSpace skips whitespace and comments
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		if !isSpace(c) {
+			return
+		}
+		l.pos++
+	}
+}
+
+// value reads the next object, or keyword. Integers followed by a generation
+// and R are a reference.
+func (l *lexer) value(depth int) (any, error) {
+	if depth > maxDepth {
+		return nil, errors.New("objects nested too deeply")
+	}
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, io.EOF
+	}
+	c := l.data[l.pos]
+	switch {
+	case c == '/':
+		return l.name(), nil
+	case c == '(':
+		return l.literal(), nil
+	case c == '<' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '<':
+		l.pos += 2
+		d := dict{}
+		for {
+			l.skipSpace()
+			if l.pos+1 < len(l.data) && l.data[l.pos] == '>' && l.data[l.pos+1] == '>' {
+				l.pos += 2
+				return d, nil
+			}
+			key, err := l.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			k, ok := key.(name)
+			if !ok {
+				return nil, fmt.Errorf("dictionary key %v is not a name", key)
+			}
+			v, err := l.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			d[string(k)] = v
+		}
+	case c == '<':
+		l.pos++
+		start := l.pos
+		for l.pos < len(l.data) && l.data[l.pos] != '>' {
+			l.pos++
+		}
+		s, _ := decodeHex(l.data[start:l.pos])
+		l.pos++
+		return s, nil
+	case c == '[':
+		l.pos++
+		var a pdfArray
+		for {
+			l.skipSpace()
+			if l.pos < len(l.data) && l.data[l.pos] == ']' {
+				l.pos++
+				return a, nil
+			}
+			v, err := l.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+		}
+	case c == ']' || c == '>' || c == ')' || c == '{' || c == '}':
+		l.pos++
+		return keyword(c), nil
+	case c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		f := l.number()
+		// Look ahead for a reference
+		if f >= 0 && f == math.Trunc(f) {
+			save := l.pos
+			l.skipSpace()
+			if l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '9' {
+				gen := l.number()
+				l.skipSpace()
+				if l.pos < len(l.data) && l.data[l.pos] == 'R' && (l.pos+1 == len(l.data) || isSpace(l.data[l.pos+1]) || isDelimiter(l.data[l.pos+1])) {
+					l.pos++
+					return objRef{num: int(f), gen: int(gen)}, nil
+				}
+			}
+			l.pos = save
+		}
+		return f, nil
+	}
+	start := l.pos
+	for l.pos < len(l.data) && !isSpace(l.data[l.pos]) && !isDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	switch word := string(l.data[start:l.pos]); word {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	default:
+		return keyword(word), nil
+	}
+}
+
+func (l *lexer) number() float64 {
+	start := l.pos
+	l.pos++
+	for l.pos < len(l.data) && (l.data[l.pos] == '.' || (l.data[l.pos] >= '0' && l.data[l.pos] <= '9')) {
+		l.pos++
+	}
+	f, _ := strconv.ParseFloat(string(l.data[start:l.pos]), 64)
+	return f
+}
+
+func (l *lexer) name() name {
+	l.pos++
+	var b []byte
+	for l.pos < len(l.data) && !isSpace(l.data[l.pos]) && !isDelimiter(l.data[l.pos]) {
+		c := l.data[l.pos]
+		if c == '#' && l.pos+2 < len(l.data) {
+			if v, err := strconv.ParseUint(string(l.data[l.pos+1:l.pos+3]), 16, 8); err == nil {
+				b = append(b, byte(v))
+				l.pos += 3
+				continue
+			}
+		}
+		b = append(b, c)
+		l.pos++
+	}
+	return name(b)
+}
+
+// literal reads a (string), with its escapes and balanced parentheses
+func (l *lexer) literal() []byte {
+	l.pos++
+	var b []byte
+	nesting := 0
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			nesting++
+		case ')':
+			if nesting == 0 {
+				return b
+			}
+			nesting--
+		case '\\':
+			if l.pos >= len(l.data) {
+				return b
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					c = byte(v)
+				} else {
+					c = e
+				}
+			}
+		}
+		b = append(b, c)
+	}
+	return b
+}
+
+// streamData reads the data of a stream following its dictionary, if one
+// does, by its length when that is given directly and right, else up to
+// endstream
+func (l *lexer) streamData(d dict) ([]byte, bool) {
+	save := l.pos
+	l.skipSpace()
+	if !bytes.HasPrefix(l.data[l.pos:], []byte("stream")) {
+		l.pos = save
+		return nil, false
+	}
+	l.pos += len("stream")
+	if l.pos < len(l.data) && l.data[l.pos] == '\r' {
+		l.pos++
+	}
+	if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+		l.pos++
+	}
+	start := l.pos
+	if n, ok := d["Length"].(float64); ok && n >= 0 && start+int(n) <= len(l.data) {
+		end := start + int(n)
+		rest := &lexer{data: l.data, pos: end}
+		rest.skipSpace()
+		if bytes.HasPrefix(l.data[rest.pos:], []byte("endstream")) {
+			l.pos = rest.pos + len("endstream")
+			return l.data[start:end], true
+		}
+	}
+	i := bytes.Index(l.data[start:], []byte("endstream"))
+	if i < 0 {
+		l.pos = len(l.data)
+		return l.data[start:], true
+	}
+	l.pos = start + i + len("endstream")
+	data := l.data[start : start+i]
+	// The end of line before endstream is not part of the data
+	if bytes.HasSuffix(data, []byte("\r\n")) {
+		return data[:len(data)-2], true
+	}
+	if bytes.HasSuffix(data, []byte("\n")) || bytes.HasSuffix(data, []byte("\r")) {
+		return data[:len(data)-1], true
+	}
+	return data, true
+}
+
+// font decodes the strings shown in a font to text, with how far each
+// character advances
+type font struct {
+	twoByte   bool // codes are two bytes, as in composite fonts
+	toUnicode map[int]string
+	glyphs    map[int]string // from the encoding's differences
+	widths    map[int]float64
+	missing   float64 // width of codes without one, in thousandths of the size
+	scale     float64 // glyph space to thousandths, for Type3 fonts
+}
+
+// loadFont reads a font from its dictionary
+func (r *reader) loadFont(v any) *font {
+	key := v
+	if _, ok := v.(objRef); !ok {
+		key = fmt.Sprintf("%p", r.dict(v))
+	}
+	if f, ok := r.fonts[key]; ok {
+		return f
+	}
+	f := &font{widths: make(map[int]float64), missing: 500, scale: 1}
+	r.fonts[key] = f
+	d := r.dict(v)
+	if d == nil {
+		return f
+	}
+
+	if d["Subtype"] == name("Type0") {
+		f.twoByte = true
+		f.missing = 1000
+		if kids, ok := r.resolve(d["DescendantFonts"]).(pdfArray); ok && len(kids) > 0 {
+			cid := r.dict(kids[0])
+			if dw, ok := r.resolve(cid["DW"]).(float64); ok {
+				f.missing = dw
+			}
+			w, _ := r.resolve(cid["W"]).(pdfArray)
+			for i := 0; i+1 < len(w); {
+				first, _ := r.resolve(w[i]).(float64)
+				if list, ok := r.resolve(w[i+1]).(pdfArray); ok {
+					for j, width := range list {
+						wf, _ := r.resolve(width).(float64)
+						f.widths[int(first)+j] = wf
+					}
+					i += 2
+					continue
+				}
+				if i+2 >= len(w) {
+					break
+				}
+				last, _ := r.resolve(w[i+1]).(float64)
+				wf, _ := r.resolve(w[i+2]).(float64)
+				for c := int(first); c <= int(last) && c-int(first) < 65536; c++ {
+					f.widths[c] = wf
+				}
+				i += 3
+			}
+		}
+	} else {
+		if m, ok := r.resolve(d["FontMatrix"]).(pdfArray); ok && len(m) > 0 {
+			if a, ok := r.resolve(m[0]).(float64); ok {
+				f.scale = a * 1000
+			}
+		}
+		first, _ := r.resolve(d["FirstChar"]).(float64)
+		widths, _ := r.resolve(d["Widths"]).(pdfArray)
+		for i, width := range widths {
+			wf, _ := r.resolve(width).(float64)
+			f.widths[int(first)+i] = wf
+		}
+		if len(widths) == 0 {
+			base, _ := r.resolve(d["BaseFont"]).(name)
+			standardWidths(f, string(base))
+		}
+		if enc := r.dict(d["Encoding"]); enc != nil {
+			f.glyphs = make(map[int]string)
+			diffs, _ := r.resolve(enc["Differences"]).(pdfArray)
+			code := 0
+			for _, v := range diffs {
+				switch v := r.resolve(v).(type) {
+				case float64:
+					code = int(v)
+				case name:
+					f.glyphs[code] = glyphText(string(v))
+					code++
+				}
+			}
+		}
+	}
+
+	if s, ok := r.resolve(d["ToUnicode"]).(*stream); ok {
+		if data, err := r.decode(s); err == nil {
+			f.toUnicode = parseCMap(data)
+		}
+	}
+	return f
+}
+
+// standardWidths fills in the widths of a standard font, which a PDF need not
+// give: Courier is fixed pitch, and the others are taken as Helvetica
+func standardWidths(f *font, base string) {
+	if strings.Contains(base, "Courier") {
+		f.missing = 600
+		return
+	}
+	widths := fontWidths[Regular]
+	if strings.Contains(base, "Bold") {
+		widths = fontWidths[Bold]
+	}
+	for i, w := range widths {
+		f.widths[32+i] = float64(w)
+	}
+}
+
+// glyphNames are the glyph names of punctuation in an encoding's differences;
+// letters and digits are named for themselves or spelled out
+var glyphNames = map[string]string{
+	"space": " ", "exclam": "!", "quotedbl": `"`, "numbersign": "#", "dollar": "$",
+	"percent": "%", "ampersand": "&", "quotesingle": "'", "quoteright": "'", "parenleft": "(",
+	"parenright": ")", "asterisk": "*", "plus": "+", "comma": ",", "hyphen": "-",
+	"minus": "-", "period": ".", "slash": "/", "colon": ":", "semicolon": ";",
+	"less": "<", "equal": "=", "greater": ">", "question": "?", "at": "@",
+	"bracketleft": "[", "backslash": `\`, "bracketright": "]", "underscore": "_",
+	"quoteleft": "'", "braceleft": "{", "bar": "|", "braceright": "}", "asciitilde": "~",
+	"zero": "0", "one": "1", "two": "2", "three": "3", "four": "4",
+	"five": "5", "six": "6", "seven": "7", "eight": "8", "nine": "9",
+	"rupee": "₹", "endash": "-", "emdash": "-", "bullet": "*",
+}
+
+// glyphText returns the text of a glyph name, as in the "uni20B9" and "u20B9"
+// conventions, or "" for names that say nothing of their character
+func glyphText(g string) string {
+	if i := strings.IndexByte(g, '.'); i > 0 {
+		g = g[:i] // "a.sc" is a small capital a
+	}
+	if len(g) == 1 {
+		return g
+	}
+	if s, ok := glyphNames[g]; ok {
+		return s
+	}
+	if hexCode, ok := strings.CutPrefix(g, "uni"); ok && len(hexCode) >= 4 {
+		if v, err := strconv.ParseUint(hexCode[:4], 16, 16); err == nil {
+			return string(rune(v))
+		}
+	}
+	if hexCode, ok := strings.CutPrefix(g, "u"); ok && len(hexCode) >= 4 && len(hexCode) <= 6 {
+		if v, err := strconv.ParseUint(hexCode, 16, 32); err == nil {
+			return string(rune(v))
+		}
+	}
+	return ""
+}
+
+// parseCMap reads the character codes and their text from a ToUnicode CMap
+func parseCMap(data []byte) map[int]string {
+	m := make(map[int]string)
+	l := &lexer{data: data}
+	var operands []any
+	for {
+		v, err := l.value(0)
+		if err != nil {
+			return m
+		}
+		kw, ok := v.(keyword)
+		if !ok {
+			operands = append(operands, v)
+			continue
+		}
+		switch kw {
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok1 := operands[i].([]byte)
+				dst, ok2 := operands[i+1].([]byte)
+				if ok1 && ok2 {
+					m[code(src)] = utf16Text(dst)
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, ok1 := operands[i].([]byte)
+				hi, ok2 := operands[i+1].([]byte)
+				if !ok1 || !ok2 {
+					continue
+				}
+				first, last := code(lo), code(hi)
+				if last-first > 65535 {
+					continue
+				}
+				switch dst := operands[i+2].(type) {
+				case []byte:
+					text := []rune(utf16Text(dst))
+					for c := first; c <= last && len(text) > 0; c++ {
+						m[c] = string(text)
+						text[len(text)-1]++
+					}
+				case pdfArray:
+					for j, d := range dst {
+						if b, ok := d.([]byte); ok && first+j <= last {
+							m[first+j] = utf16Text(b)
+						}
+					}
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+}
+
+// code reads a character code from its bytes, big endian
+func code(b []byte) int {
+	c := 0
+	for _, x := range b {
+		c = c<<8 | int(x)
+	}
+	return c
+}
+
+func utf16Text(b []byte) string {
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = uint16(b[2*i])<<8 | uint16(b[2*i+1])
+	}
+	return string(utf16.Decode(u))
+}
+
+// decode splits a shown string into its characters, each with its text,
+// width and whether it is the single-byte space that word spacing applies to
+func (f *font) decode(s []byte) []char {
+	var chars []char
+	step := 1
+	if f.twoByte {
+		step = 2
+	}
+	for i := 0; i+step <= len(s); i += step {
+		c := code(s[i : i+step])
+		ch := char{space: step == 1 && c == 32}
+		if t, ok := f.toUnicode[c]; ok {
+			ch.text = t
+		} else if t, ok := f.glyphs[c]; ok {
+			ch.text = t
+		} else if !f.twoByte && c >= 32 {
+			ch.text = string(rune(c)) // WinAnsi agrees with Latin-1 for text
+		}
+		w, ok := f.widths[c]
+		if !ok {
+			w = f.missing
+		}
+		ch.width = w * f.scale / 1000
+		chars = append(chars, ch)
+	}
+	return chars
+}
+
+type char struct {
+	text  string
+	width float64 // in text space units at size 1
+	space bool
+}
+
+// matrix is a PDF transformation matrix [a b c d e f]
+type matrix [6]float64
+
+var identity = matrix{1, 0, 0, 1, 0, 0}
+
+// mul returns m × n, m applied first
+func (m matrix) mul(n matrix) matrix {
+	return matrix{
+		m[0]*n[0] + m[1]*n[2], m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2], m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4], m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+// glyph is a piece of text placed on the page: its baseline starts at (x, y)
+// and ends at end
+type glyph struct {
+	x, y, end, size float64
+	text            string
+}
+
+// graphicsState is what q and Q save and restore
+type graphicsState struct {
+	ctm                                               matrix
+	font                                              *font
+	size, charSpace, wordSpace, hScale, leading, rise float64
+}
+
+// canvas runs content streams, placing their text
+type canvas struct {
+	r      *reader
+	glyphs []glyph
+}
+
+// run interprets a content stream drawn with ctm, collecting its text
+func (c *canvas) run(content []byte, resources dict, ctm matrix, depth int) {
+	if depth > maxDepth {
+		return
+	}
+	gs := graphicsState{ctm: ctm, hScale: 1}
+	var stack []graphicsState
+	tm, tlm := identity, identity
+	l := &lexer{data: content}
+	var operands []any
+
+	number := func(i int) float64 {
+		if i < len(operands) {
+			f, _ := operands[i].(float64)
+			return f
+		}
+		return 0
+	}
+	nextLine := func(tx, ty float64) {
+		tlm = matrix{1, 0, 0, 1, tx, ty}.mul(tlm)
+		tm = tlm
+	}
+	show := func(s []byte) {
+		if gs.font == nil {
+			gs.font = &font{widths: map[int]float64{}, missing: 500, scale: 1}
+		}
+		for _, ch := range gs.font.decode(s) {
+			trm := tm.mul(gs.ctm)
+			x, y := trm[4]+gs.rise*trm[2], trm[5]+gs.rise*trm[3]
+			size := math.Abs(gs.size) * math.Hypot(trm[2], trm[3])
+			advance := ch.width*gs.size + gs.charSpace
+			if ch.space {
+				advance += gs.wordSpace
+			}
+			advance *= gs.hScale
+			tm = matrix{1, 0, 0, 1, advance, 0}.mul(tm)
+			if strings.TrimSpace(ch.text) == "" && ch.text != " " {
+				continue
+			}
+			end := tm.mul(gs.ctm)[4]
+			c.glyphs = append(c.glyphs, glyph{x: x, y: y, end: end, size: size, text: ch.text})
+		}
+	}
+
+	for {
+		v, err := l.value(0)
+		if err != nil {
+			return
+		}
+		op, ok := v.(keyword)
+		if !ok {
+			operands = append(operands, v)
+			continue
+		}
+		switch op {
+		case "q":
+			stack = append(stack, gs)
+		case "Q":
+			if len(stack) > 0 {
+				gs = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+		case "cm":
+			gs.ctm = matrix{number(0), number(1), number(2), number(3), number(4), number(5)}.mul(gs.ctm)
+		case "BT":
+			tm, tlm = identity, identity
+		case "Tf":
+			if len(operands) < 2 {
+				break
+			}
+			if n, ok := operands[0].(name); ok {
+				fonts := c.r.dict(resources["Font"])
+				gs.font = c.r.loadFont(fonts[string(n)])
+			}
+			gs.size = number(1)
+		case "Tc":
+			gs.charSpace = number(0)
+		case "Tw":
+			gs.wordSpace = number(0)
+		case "Tz":
+			gs.hScale = number(0) / 100
+		case "TL":
+			gs.leading = number(0)
+		case "Ts":
+			gs.rise = number(0)
+		case "Td":
+			nextLine(number(0), number(1))
+		case "TD":
+			gs.leading = -number(1)
+			nextLine(number(0), number(1))
+		case "Tm":
+			tlm = matrix{number(0), number(1), number(2), number(3), number(4), number(5)}
+			tm = tlm
+		case "T*":
+			nextLine(0, -gs.leading)
+		case "Tj", "'", `"`:
+			if op == `"` && len(operands) == 3 {
+				gs.wordSpace, gs.charSpace = number(0), number(1)
+				operands = operands[2:]
+			}
+			if op != "Tj" {
+				nextLine(0, -gs.leading)
+			}
+			if len(operands) > 0 {
+				if s, ok := operands[len(operands)-1].([]byte); ok {
+					show(s)
+				}
+			}
+		case "TJ":
+			if len(operands) > 0 {
+				items, _ := operands[0].(pdfArray)
+				for _, item := range items {
+					switch item := item.(type) {
+					case []byte:
+						show(item)
+					case float64:
+						tm = matrix{1, 0, 0, 1, -item / 1000 * gs.size * gs.hScale, 0}.mul(tm)
+					}
+				}
+			}
+		case "Do":
+			if len(operands) == 0 {
+				break
+			}
+			n, _ := operands[0].(name)
+			xobjects := c.r.dict(resources["XObject"])
+			form, ok := c.r.resolve(xobjects[string(n)]).(*stream)
+			if !ok || form.dict["Subtype"] != name("Form") {
+				break
+			}
+			data, err := c.r.decode(form)
+			if err != nil {
+				break
+			}
+			formCTM := gs.ctm
+			if m, ok := c.r.resolve(form.dict["Matrix"]).(pdfArray); ok && len(m) == 6 {
+				var fm matrix
+				for i := range fm {
+					fm[i], _ = c.r.resolve(m[i]).(float64)
+				}
+				formCTM = fm.mul(gs.ctm)
+			}
+			formResources := c.r.dict(form.dict["Resources"])
+			if formResources == nil {
+				formResources = resources
+			}
+			c.run(data, formResources, formCTM, depth+1)
+		case "ID":
+			// Inline image data runs to EI
+			l.pos++
+			for l.pos+2 < len(l.data) && !(isSpace(l.data[l.pos]) && l.data[l.pos+1] == 'E' && l.data[l.pos+2] == 'I' && (l.pos+3 == len(l.data) || isSpace(l.data[l.pos+3]))) {
+				l.pos++
+			}
+			l.pos += 3
+		}
+		operands = operands[:0]
+	}
+}
+
+// layout puts the glyphs of a page into lines, top to bottom, each read from
+// left to right with a space where there is a gap
+func layout(glyphs []glyph) string {
+	sort.SliceStable(glyphs, func(i, j int) bool { return glyphs[i].y > glyphs[j].y })
+
+	var lines []string
+	for start := 0; start < len(glyphs); {
+		base := glyphs[start]
+		tolerance := math.Max(base.size*0.4, 1)
+		end := start + 1
+		for end < len(glyphs) && base.y-glyphs[end].y <= tolerance {
+			end++
+		}
+		line := glyphs[start:end]
+		sort.SliceStable(line, func(i, j int) bool { return line[i].x < line[j].x })
+
+		var b strings.Builder
+		var last glyph
+		for i, g := range line {
+			if i > 0 {
+				// Text drawn twice over itself, for bold, is read once
+				if g.text == last.text && math.Abs(g.x-last.x) < g.size*0.2 {
+					continue
+				}
+				if g.x-last.end > g.size*0.2 {
+					b.WriteByte(' ')
+				}
+			}
+			b.WriteString(g.text)
+			last = g
+		}
+		if text := strings.Join(strings.Fields(b.String()), " "); text != "" {
+			lines = append(lines, text)
+		}
+		start = end
+	}
+	return strings.Join(lines, "\n")
+}
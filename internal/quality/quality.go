@@ -0,0 +1,97 @@
+// Package quality measures how well identifiers are being learned from the
+// receipts: how many narrations give a strong identifier, and how many were
+// matched by what earlier receipts had already taught
+package quality
+
+import (
+	"sort"
+	"time"
+
+	"suspense.durgadawaghar.com/internal/extractor"
+)
+
+// Receipt is a receipt with its narration and the party it was posted to
+type Receipt struct {
+	PartyID   int64
+	Date      time.Time
+	Narration string
+}
+
+// Key is an identifier's type and value
+type Key struct {
+	Type  string
+	Value string
+}
+
+// Link is the party an identifier is linked to and the date of the first
+// receipt it turned up in, zero when none has
+type Link struct {
+	PartyID   int64
+	FirstSeen time.Time
+}
+
+// Coverage counts receipts with a narration (Receipts), those whose narration
+// gives at least one strong identifier (Covered), and those with a strong
+// identifier already linked to their party from an earlier receipt, which
+// would have matched without anyone teaching it (Matched)
+type Coverage struct {
+	Month    string // YYYY-MM, empty for the total
+	Receipts int
+	Covered  int
+	Matched  int
+}
+
+// Measure counts the coverage of receipts, in all and by month of the
+// receipt's date, the latest month first. Strong identifiers are those of a
+// type unique to one party, such as UPI IDs and account numbers, as opposed
+// to names and banks that many parties share.
+func Measure(receipts []Receipt, links map[Key]Link) (Coverage, []Coverage) {
+	var total Coverage
+	byMonth := make(map[string]*Coverage)
+	var months []string
+	for _, r := range receipts {
+		covered, matched := false, false
+		for _, id := range extractor.Extract(r.Narration) {
+			if !id.Type.Unique() {
+				continue
+			}
+			covered = true
+			link, ok := links[Key{string(id.Type), id.Value}]
+			if ok && link.PartyID == r.PartyID && !link.FirstSeen.IsZero() && link.FirstSeen.Before(r.Date) {
+				matched = true
+			}
+		}
+
+		month := r.Date.Format("2006-01")
+		m, ok := byMonth[month]
+		if !ok {
+			m = &Coverage{Month: month}
+			byMonth[month] = m
+			months = append(months, month)
+		}
+		for _, c := range []*Coverage{&total, m} {
+			c.Receipts++
+			if covered {
+				c.Covered++
+			}
+			if matched {
+				c.Matched++
+			}
+		}
+	}
+
+	sort.Sort(sort.Reverse(sort.StringSlice(months)))
+	coverage := make([]Coverage, len(months))
+	for i, month := range months {
+		coverage[i] = *byMonth[month]
+	}
+	return total, coverage
+}
+
+// Percent is part of whole as a percentage, 0 of nothing
+func Percent(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return float64(part) * 100 / float64(whole)
+}
@@ -0,0 +1,56 @@
+package quality
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMeasure(t *testing.T) {
+	day := func(m time.Month, d int) time.Time { return time.Date(2026, m, d, 0, 0, 0, 0, time.UTC) }
+	const upi = "UPI/SANDHYA ME/9450852076@YBL/PAYMENT FR/STATE BANK/450854353978"
+	links := map[Key]Link{
+		{"upi_vpa", "9450852076@YBL"}: {PartyID: 1, FirstSeen: day(time.August, 3)},
+		{"phone", "9450852076"}:       {PartyID: 1, FirstSeen: day(time.August, 3)},
+	}
+	receipts := []Receipt{
+		// The first sighting teaches the identifier
+		{PartyID: 1, Date: day(time.August, 3), Narration: upi},
+		// Known from then on
+		{PartyID: 1, Date: day(time.September, 10), Narration: upi},
+		// Posted to another party, so the link would have matched wrongly
+		{PartyID: 2, Date: day(time.September, 12), Narration: upi},
+		// Only a name, which many parties share
+		{PartyID: 3, Date: day(time.September, 15), Narration: "NEFT-SBIN0001234-RAM MEDICAL STORE"},
+		{PartyID: 3, Date: day(time.September, 16), Narration: "CASH DEPOSIT"},
+	}
+
+	total, months := Measure(receipts, links)
+	if total != (Coverage{Receipts: 5, Covered: 3, Matched: 1}) {
+		t.Errorf("total = %+v", total)
+	}
+	want := []Coverage{
+		{Month: "2026-09", Receipts: 4, Covered: 2, Matched: 1},
+		{Month: "2026-08", Receipts: 1, Covered: 1, Matched: 0},
+	}
+	if len(months) != len(want) {
+		t.Fatalf("months = %+v, want %+v", months, want)
+	}
+	for i := range want {
+		if months[i] != want[i] {
+			t.Errorf("months[%d] = %+v, want %+v", i, months[i], want[i])
+		}
+	}
+
+	if total, months := Measure(nil, links); total != (Coverage{}) || len(months) != 0 {
+		t.Errorf("Measure(nil) = %+v, %+v", total, months)
+	}
+}
+
+func TestPercent(t *testing.T) {
+	if got := Percent(1, 4); got != 25 {
+		t.Errorf("Percent(1, 4) = %v, want 25", got)
+	}
+	if got := Percent(0, 0); got != 0 {
+		t.Errorf("Percent(0, 0) = %v, want 0", got)
+	}
+}
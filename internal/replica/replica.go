@@ -0,0 +1,417 @@
+// Package replica copies a SQLite database to a second location as it
+// changes, the way Litestream does, so a failed disk or machine loses
+// seconds of work rather than everything since the last backup.
+//
+// A replica directory holds generations, each a snapshot of the database
+// file followed by numbered segments of the WAL frames committed since:
+//
+//	20261017T030000Z/snapshot.db
+//	20261017T030000Z/00000001.wal
+//	20261017T030000Z/00000002.wal
+//
+// While replicating, a read transaction is held open, so SQLite can neither
+// restart the WAL over frames not yet copied nor checkpoint them into the
+// database file before the snapshot is taken. The replicator checkpoints the
+// WAL itself once enough frames have been copied, holding the writer's
+// connection so no write slips in between.
+package replica
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// checkpointFrames is how many frames the WAL may hold before the
+	// replicator checkpoints it
+	checkpointFrames = 1000
+
+	// snapshotEvery is how often a new generation is started, so a restore
+	// replays at most a day of segments
+	snapshotEvery = 24 * time.Hour
+
+	// keepGenerations is how many generations are kept, the current one
+	// included
+	keepGenerations = 2
+)
+
+// snapshotName names the database file of a generation; a generation without
+// one was never finished and is ignored
+const snapshotName = "snapshot.db"
+
+// Replicator copies a database to a replica directory
+type Replicator struct {
+	dbPath string
+	dir    string
+	writer *sql.DB  // the application's single writer connection
+	reader *sql.DB  // holds the read transaction
+	file   *os.File // the database file, to copy snapshots from
+
+	mu         sync.Mutex
+	tx         *sql.Tx
+	generation string
+	started    time.Time
+	segments   int
+	pos        position
+	lastSync   time.Time
+	lastError  string
+}
+
+// Status is how replication stands
+type Status struct {
+	Dir        string
+	Generation string // empty until the first snapshot is taken
+	Segments   int
+	LastSync   time.Time
+	LastError  string
+}
+
+// New returns a replicator copying the database at dbPath into dir. writer
+// must be the pool every write goes through, limited to one connection, so
+// holding it stops writes while the WAL is checkpointed.
+func New(dbPath string, writer *sql.DB, dir string) (*Replicator, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating replica directory: %w", err)
+	}
+	// Closing any descriptor of the database file drops every POSIX lock
+	// this process holds on it, SQLite's included, and another process could
+	// then delete the WAL from under it. So the file is opened once and held
+	// open until Close.
+	file, err := os.Open(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+	reader, err := sql.Open("sqlite", dbPath+"?_pragma=busy_timeout(10000)&_pragma=query_only(1)")
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+	reader.SetMaxOpenConns(1)
+	return &Replicator{dbPath: dbPath, dir: dir, writer: writer, reader: reader, file: file}, nil
+}
+
+// Status reports how replication stands
+func (r *Replicator) Status() Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return Status{
+		Dir:        r.dir,
+		Generation: r.generation,
+		Segments:   r.segments,
+		LastSync:   r.lastSync,
+		LastError:  r.lastError,
+	}
+}
+
+// Sync copies the frames committed since the last sync, starting a new
+// generation first when there is none yet, the current one is a day old or
+// the WAL was restarted under it
+func (r *Replicator) Sync(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	err := r.sync(ctx)
+	if err != nil {
+		r.lastError = err.Error()
+	} else {
+		r.lastError = ""
+		r.lastSync = time.Now()
+	}
+	return err
+}
+
+func (r *Replicator) sync(ctx context.Context) error {
+	if r.generation == "" || time.Since(r.started) > snapshotEvery {
+		return r.snapshot(ctx)
+	}
+	err := r.ship()
+	if errors.Is(err, errWALReset) {
+		// Frames may have been lost, so the generation cannot be replayed
+		// any further
+		return r.snapshot(ctx)
+	}
+	if err != nil {
+		return err
+	}
+	if r.pos.header != nil && r.pos.offset >= walHeaderSize+checkpointFrames*(frameHeaderSize+r.pos.pageSize()) {
+		return r.checkpoint(ctx)
+	}
+	return nil
+}
+
+// Close stops holding the read transaction. It closes the database file
+// too, so it should only be called once the database is closed.
+func (r *Replicator) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.tx != nil {
+		r.tx.Rollback()
+		r.tx = nil
+	}
+	r.file.Close()
+	return r.reader.Close()
+}
+
+// snapshot starts a new generation with a copy of the database file, then
+// copies every frame in the WAL after it
+func (r *Replicator) snapshot(ctx context.Context) error {
+	r.generation = ""
+	if err := r.beginRead(ctx); err != nil {
+		return err
+	}
+
+	// Under the read transaction the database file only changes by frames
+	// still in the WAL being checkpointed into it, and those are shipped
+	// below, so replaying them mends any page copied mid-write
+	now := time.Now()
+	generation := now.UTC().Format("20060102T150405Z")
+	dir := filepath.Join(r.dir, generation)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating generation: %w", err)
+	}
+	info, err := r.file.Stat()
+	if err != nil {
+		return err
+	}
+	if err := writeFrom(filepath.Join(dir, snapshotName), io.NewSectionReader(r.file, 0, info.Size())); err != nil {
+		os.RemoveAll(dir)
+		return fmt.Errorf("copying database: %w", err)
+	}
+
+	r.generation, r.started, r.segments, r.pos = generation, now, 0, position{}
+	if err := r.ship(); err != nil {
+		r.generation = ""
+		return err
+	}
+	return r.prune()
+}
+
+// ship writes the frames committed since the last ship as the next segment
+func (r *Replicator) ship() error {
+	wal, err := os.Open(r.dbPath + "-wal")
+	if errors.Is(err, os.ErrNotExist) {
+		// Nothing has been written since the database was opened
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer wal.Close()
+	info, err := wal.Stat()
+	if err != nil {
+		return err
+	}
+
+	frames, next, err := readFrames(wal, info.Size(), r.pos)
+	if err != nil {
+		return err
+	}
+	if len(frames) > 0 {
+		segment := make([]byte, 0, walHeaderSize+len(frames))
+		segment = append(append(segment, next.header...), frames...)
+		name := filepath.Join(r.dir, r.generation, fmt.Sprintf("%08d.wal", r.segments+1))
+		if err := writeFile(name, segment); err != nil {
+			return fmt.Errorf("writing segment: %w", err)
+		}
+		r.segments++
+	}
+	r.pos = next
+	return nil
+}
+
+// checkpoint copies the database's last frames, then checkpoints the WAL
+// into the database file and truncates it. The writer's connection is held
+// throughout, so every frame checkpointed has been copied.
+func (r *Replicator) checkpoint(ctx context.Context) error {
+	conn, err := r.writer.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := r.ship(); err != nil {
+		return err
+	}
+
+	r.tx.Rollback()
+	r.tx = nil
+	var busy, frames, checkpointed int
+	if err := conn.QueryRowContext(ctx, "PRAGMA wal_checkpoint(TRUNCATE)").Scan(&busy, &frames, &checkpointed); err != nil {
+		return fmt.Errorf("checkpointing: %w", err)
+	}
+	if busy == 0 {
+		// The WAL is empty and starts afresh with the next write
+		r.pos = position{}
+	}
+	// Otherwise readers kept it from being restarted, so it carries on
+	return r.beginRead(ctx)
+}
+
+// beginRead (re)starts the read transaction that pins the WAL
+func (r *Replicator) beginRead(ctx context.Context) error {
+	if r.tx != nil {
+		r.tx.Rollback()
+		r.tx = nil
+	}
+	// The transaction outlives ctx, which would roll it back when done
+	tx, err := r.reader.BeginTx(context.WithoutCancel(ctx), nil)
+	if err != nil {
+		return fmt.Errorf("starting read transaction: %w", err)
+	}
+	var n int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_master").Scan(&n); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("starting read transaction: %w", err)
+	}
+	r.tx = tx
+	return nil
+}
+
+// prune deletes all but the newest generations
+func (r *Replicator) prune() error {
+	generations, err := listGenerations(r.dir, false)
+	if err != nil {
+		return err
+	}
+	for len(generations) > keepGenerations {
+		if err := os.RemoveAll(filepath.Join(r.dir, generations[0])); err != nil {
+			return fmt.Errorf("pruning generation %s: %w", generations[0], err)
+		}
+		generations = generations[1:]
+	}
+	return nil
+}
+
+// Restore rebuilds the database from the newest generation in dir into
+// path, which must not exist, and returns the generation restored
+func Restore(dir, path string) (string, error) {
+	if _, err := os.Stat(path); err == nil {
+		return "", fmt.Errorf("%s already exists; restore to a new path and move it into place", path)
+	}
+	generations, err := listGenerations(dir, true)
+	if err != nil {
+		return "", err
+	}
+	if len(generations) == 0 {
+		return "", fmt.Errorf("there is no replica in %s", dir)
+	}
+	generation := generations[len(generations)-1]
+	genDir := filepath.Join(dir, generation)
+
+	snapshot, err := os.Open(filepath.Join(genDir, snapshotName))
+	if err != nil {
+		return "", err
+	}
+	defer snapshot.Close()
+	tmp := path + ".restoring"
+	if err := writeFrom(tmp, snapshot); err != nil {
+		return "", fmt.Errorf("copying snapshot: %w", err)
+	}
+	defer os.Remove(tmp)
+	f, err := os.OpenFile(tmp, os.O_RDWR, 0)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	entries, err := os.ReadDir(genDir)
+	if err != nil {
+		return "", err
+	}
+	for _, e := range entries {
+		// ReadDir sorts by name, and segment numbers are zero-padded
+		if !strings.HasSuffix(e.Name(), ".wal") {
+			continue
+		}
+		segment, err := os.ReadFile(filepath.Join(genDir, e.Name()))
+		if err != nil {
+			return "", err
+		}
+		if err := applyFrames(f, segment); err != nil {
+			return "", fmt.Errorf("replaying %s: %w", e.Name(), err)
+		}
+	}
+	if err := f.Sync(); err != nil {
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	return generation, os.Rename(tmp, path)
+}
+
+// listGenerations lists the generations in dir, oldest first; complete
+// leaves out those whose snapshot was never finished
+func listGenerations(dir string, complete bool) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading replica directory: %w", err)
+	}
+	var generations []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		if _, err := time.Parse("20060102T150405Z", e.Name()); err != nil {
+			continue
+		}
+		if complete {
+			if _, err := os.Stat(filepath.Join(dir, e.Name(), snapshotName)); err != nil {
+				continue
+			}
+		}
+		generations = append(generations, e.Name())
+	}
+	sort.Strings(generations)
+	return generations, nil
+}
+
+// writeFile writes data to path through a temporary file, so a replica never
+// holds half a segment
+func writeFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// writeFrom copies src to dst through a temporary file
+func writeFrom(dst string, src io.Reader) error {
+	tmp := dst + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, src); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
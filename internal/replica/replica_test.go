@@ -0,0 +1,121 @@
+package replica
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestReplicateAndRestore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "suspense.db")
+	db, err := sql.Open("sqlite", dbPath+"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(10000)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec("CREATE TABLE receipts (id INTEGER PRIMARY KEY, narration TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+	insert := func(n int, size int) {
+		t.Helper()
+		tx, err := db.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < n; i++ {
+			if _, err := tx.Exec("INSERT INTO receipts (narration) VALUES (?)", strings.Repeat("x", size)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	insert(10, 10)
+
+	r, err := New(dbPath, db, filepath.Join(dir, "replica"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if err := r.Sync(ctx); err != nil {
+		t.Fatal(err)
+	}
+	generation := r.Status().Generation
+
+	// Enough pages for the WAL to be checkpointed and restarted under the
+	// generation, which must carry on over it
+	insert(20, 10)
+	if err := r.Sync(ctx); err != nil {
+		t.Fatal(err)
+	}
+	insert(1500, 4000)
+	if err := r.Sync(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if r.pos.header != nil {
+		t.Fatal("the WAL was not checkpointed")
+	}
+	insert(5, 10)
+	if _, err := db.Exec("DELETE FROM receipts WHERE id <= 3"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Sync(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if s := r.Status(); s.Generation != generation || s.Segments < 3 || s.LastError != "" {
+		t.Errorf("after a checkpoint, status is %+v, want generation %s", s, generation)
+	}
+
+	restored := filepath.Join(dir, "restored.db")
+	if got, err := Restore(filepath.Join(dir, "replica"), restored); err != nil || got != generation {
+		t.Fatalf("Restore() = %s, %v", got, err)
+	}
+	copyDB, err := sql.Open("sqlite", restored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer copyDB.Close()
+	var count, min int
+	var check string
+	if err := copyDB.QueryRow("SELECT COUNT(*), MIN(id) FROM receipts").Scan(&count, &min); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1532 || min != 4 {
+		t.Errorf("restored %d receipts from id %d, want 1532 from 4", count, min)
+	}
+	if err := copyDB.QueryRow("PRAGMA integrity_check").Scan(&check); err != nil || check != "ok" {
+		t.Errorf("integrity check of the restored database = %q, %v", check, err)
+	}
+
+	if _, err := Restore(filepath.Join(dir, "replica"), restored); err == nil {
+		t.Error("Restore() overwrote an existing database")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	for _, g := range []string{"20261015T030000Z", "20261016T030000Z", "20261017T030000Z", "notes"} {
+		os.Mkdir(filepath.Join(dir, g), 0o755)
+	}
+	r := &Replicator{dir: dir}
+	if err := r.prune(); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := os.ReadDir(dir)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if got := strings.Join(names, " "); got != "20261016T030000Z 20261017T030000Z notes" {
+		t.Errorf("after pruning, the replica holds %s", got)
+	}
+}
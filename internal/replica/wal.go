@@ -0,0 +1,153 @@
+package replica
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// The layout of a SQLite WAL file: a header, then frames of a frame header
+// and a page. See https://www.sqlite.org/fileformat2.html#walformat.
+const (
+	walHeaderSize   = 32
+	frameHeaderSize = 24
+)
+
+// errWALReset is returned when the WAL was restarted since it was last read,
+// so frames may have been overwritten before they were shipped
+var errWALReset = errors.New("the WAL was restarted since it was last read")
+
+// position is how far into the WAL frames have been shipped. The zero
+// position is before the WAL header.
+type position struct {
+	header   []byte // the WAL header, once read
+	offset   int64  // just past the last committed frame shipped
+	checksum [2]uint32
+}
+
+func (p position) pageSize() int64 {
+	return int64(binary.BigEndian.Uint32(p.header[8:]))
+}
+
+func (p position) salts() []byte {
+	return p.header[16:24]
+}
+
+// readFrames reads the frames committed in the WAL since pos, checking each
+// frame's salts and checksum, and returns them with the position after the
+// last commit. Frames after the last commit belong to a transaction still
+// being written, and frames whose checksum does not follow on are left over
+// from before the WAL was restarted; both end the read.
+func readFrames(wal io.ReaderAt, size int64, pos position) ([]byte, position, error) {
+	if pos.header == nil {
+		if size < walHeaderSize {
+			return nil, pos, nil
+		}
+		header := make([]byte, walHeaderSize)
+		if _, err := wal.ReadAt(header, 0); err != nil {
+			return nil, pos, fmt.Errorf("reading WAL header: %w", err)
+		}
+		if magic := binary.BigEndian.Uint32(header); magic&^1 != 0x377f0682 {
+			return nil, pos, fmt.Errorf("not a WAL file")
+		}
+		sum := checksum(header, [2]uint32{}, header[:24])
+		if sum[0] != binary.BigEndian.Uint32(header[24:]) || sum[1] != binary.BigEndian.Uint32(header[28:]) {
+			// A header being written; read it next time
+			return nil, pos, nil
+		}
+		pos = position{header: header, offset: walHeaderSize, checksum: sum}
+	} else {
+		if size < walHeaderSize {
+			return nil, pos, errWALReset
+		}
+		salts := make([]byte, 8)
+		if _, err := wal.ReadAt(salts, 16); err != nil {
+			return nil, pos, fmt.Errorf("reading WAL header: %w", err)
+		}
+		if string(salts) != string(pos.salts()) {
+			return nil, pos, errWALReset
+		}
+	}
+
+	frameSize := frameHeaderSize + pos.pageSize()
+	if size < pos.offset {
+		return nil, pos, errWALReset
+	}
+	n := (size - pos.offset) / frameSize
+	if n == 0 {
+		return nil, pos, nil
+	}
+	buf := make([]byte, n*frameSize)
+	if _, err := wal.ReadAt(buf, pos.offset); err != nil && err != io.EOF {
+		return nil, pos, fmt.Errorf("reading WAL frames: %w", err)
+	}
+
+	committed, next := 0, pos
+	sum := pos.checksum
+	for i := int64(0); i < n; i++ {
+		frame := buf[i*frameSize : (i+1)*frameSize]
+		if string(frame[8:16]) != string(pos.salts()) {
+			break
+		}
+		sum = checksum(pos.header, sum, frame[:8], frame[frameHeaderSize:])
+		if sum[0] != binary.BigEndian.Uint32(frame[16:]) || sum[1] != binary.BigEndian.Uint32(frame[20:]) {
+			break
+		}
+		if binary.BigEndian.Uint32(frame[4:]) != 0 {
+			committed = int(i+1) * int(frameSize)
+			next.offset = pos.offset + int64(committed)
+			next.checksum = sum
+		}
+	}
+	return buf[:committed], next, nil
+}
+
+// checksum continues the WAL checksum sum over data, read as 32-bit words in
+// the byte order the header's magic number names
+func checksum(header []byte, sum [2]uint32, data ...[]byte) [2]uint32 {
+	var order binary.ByteOrder = binary.LittleEndian
+	if header[3]&1 == 1 {
+		order = binary.BigEndian
+	}
+	s0, s1 := sum[0], sum[1]
+	for _, d := range data {
+		for i := 0; i+8 <= len(d); i += 8 {
+			s0 += order.Uint32(d[i:]) + s1
+			s1 += order.Uint32(d[i+4:]) + s0
+		}
+	}
+	return [2]uint32{s0, s1}
+}
+
+// applyFrames writes the pages of a shipped segment, a WAL header and its
+// frames, into a database file, cutting the file to the size each commit
+// leaves it
+func applyFrames(db interface {
+	io.WriterAt
+	Truncate(int64) error
+}, segment []byte) error {
+	if len(segment) < walHeaderSize {
+		return fmt.Errorf("segment is too short")
+	}
+	pageSize := int64(binary.BigEndian.Uint32(segment[8:]))
+	frameSize := frameHeaderSize + pageSize
+	frames := segment[walHeaderSize:]
+	if pageSize == 0 || int64(len(frames))%frameSize != 0 {
+		return fmt.Errorf("segment is damaged")
+	}
+	for len(frames) > 0 {
+		frame := frames[:frameSize]
+		frames = frames[frameSize:]
+		page := int64(binary.BigEndian.Uint32(frame))
+		if _, err := db.WriteAt(frame[frameHeaderSize:], (page-1)*pageSize); err != nil {
+			return err
+		}
+		if commit := int64(binary.BigEndian.Uint32(frame[4:])); commit != 0 {
+			if err := db.Truncate(commit * pageSize); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+
+	"suspense.durgadawaghar.com/internal/category"
+)
+
+// Rule classifies imported entries. All conditions that are set must match:
+// NarrationPattern and PartyPattern are case-insensitive regular expressions,
+// MinAmount and MaxAmount bound the amount (0 means no bound). The actions that
+// are set (Category, Internal, PartyName) are applied to matching entries.
+type Rule struct {
+	ID               int64
+	Name             string
+	NarrationPattern string
+	PartyPattern     string
+	MinAmount        float64
+	MaxAmount        float64
+	Category         category.Category
+	Internal         bool
+	PartyName        string
+}
+
+// Input is the part of a parsed entry that rules match against
+type Input struct {
+	PartyLine string // party name and location as printed in the receipt book
+	Narration string
+	Amount    float64
+}
+
+// Result is the outcome of applying rules to an entry
+type Result struct {
+	Category  category.Category
+	Internal  bool
+	PartyName string   // empty when no rule reassigns the party
+	Matched   []string // names of the matching rules, in order
+}
+
+// DefaultRules seed the rules table of a new database. They cover bank charges,
+// interest, transfers from the firm's own accounts, counter cash deposits and
+// Paytm settlements.
+var DefaultRules = []Rule{
+	{Name: "Bank charges", PartyPattern: `\bBANK\s+CHARGES?\b`, Category: category.BankCharge},
+	{Name: "Bank charges (narration)", NarrationPattern: `\b(?:BANK|SMS|SERVICE|PROCESSING|ANNUAL|LEDGER\s+FOLIO|DEBIT\s+CARD|ATM\s+CARD)\s+(?:CHARGES?|CHGS?|FEES?)\b|\bGST\s+ON\s+(?:CHARGES?|CHGS?)\b|\b(?:CHQ|CHEQUE)\s+RETURN\s+(?:CHARGES?|CHGS?)\b`, Category: category.BankCharge},
+	{Name: "Interest", PartyPattern: `\bINTEREST\b`, Category: category.Interest},
+	{Name: "Interest (narration)", NarrationPattern: `\bINTEREST\b|\b(?:SB|FD)\s*INT(?:EREST)?\.?\s+(?:CR|CREDIT|PAID)\b`, Category: category.Interest},
+	{Name: "Internal transfer", NarrationPattern: `\bINTERNAL\s+TRANSFER\b|\b(?:OWN|SELF)\s+(?:ACCOUNT|A/C)\b`, Category: category.InternalTransfer, Internal: true},
+	{Name: "Transfer from own account (PNB)", NarrationPattern: `-DURGA\s+DAWA\s+GHAR-`, Category: category.InternalTransfer, Internal: true},
+	{Name: "Counter cash deposit", PartyPattern: `^CASH$`, Internal: true},
+	{Name: "Paytm settlement", NarrationPattern: `\bONE\s+97\s+COMMUNICATIONS`, PartyName: "PAYTM BUSINESS"},
+}
+
+type compiledRule struct {
+	Rule
+	narration *regexp.Regexp
+	party     *regexp.Regexp
+}
+
+// Engine applies rules in order
+type Engine struct {
+	rules []compiledRule
+}
+
+// Validate checks that a rule has a condition, an action and valid patterns
+func Validate(rule Rule) error {
+	_, err := compile(rule)
+	return err
+}
+
+func compile(rule Rule) (compiledRule, error) {
+	c := compiledRule{Rule: rule}
+	if rule.NarrationPattern == "" && rule.PartyPattern == "" && rule.MinAmount == 0 && rule.MaxAmount == 0 {
+		return c, fmt.Errorf("rule %q has no conditions", rule.Name)
+	}
+	if rule.Category == "" && !rule.Internal && rule.PartyName == "" {
+		return c, fmt.Errorf("rule %q has no actions", rule.Name)
+	}
+	if rule.Category != "" && !rule.Category.Valid() {
+		return c, fmt.Errorf("rule %q: unknown category %q", rule.Name, rule.Category)
+	}
+	if rule.MinAmount > 0 && rule.MaxAmount > 0 && rule.MinAmount > rule.MaxAmount {
+		return c, fmt.Errorf("rule %q: minimum amount is greater than maximum", rule.Name)
+	}
+	var err error
+	if rule.NarrationPattern != "" {
+		if c.narration, err = regexp.Compile("(?i)" + rule.NarrationPattern); err != nil {
+			return c, fmt.Errorf("rule %q: narration pattern: %w", rule.Name, err)
+		}
+	}
+	if rule.PartyPattern != "" {
+		if c.party, err = regexp.Compile("(?i)" + rule.PartyPattern); err != nil {
+			return c, fmt.Errorf("rule %q: party pattern: %w", rule.Name, err)
+		}
+	}
+	return c, nil
+}
+
+// NewEngine compiles rules, which are applied in the given order
+func NewEngine(rules []Rule) (*Engine, error) {
+	e := &Engine{}
+	for _, rule := range rules {
+		c, err := compile(rule)
+		if err != nil {
+			return nil, err
+		}
+		e.rules = append(e.rules, c)
+	}
+	return e, nil
+}
+
+func (r compiledRule) matches(in Input) bool {
+	if r.narration != nil && !r.narration.MatchString(in.Narration) {
+		return false
+	}
+	if r.party != nil && !r.party.MatchString(in.PartyLine) {
+		return false
+	}
+	if r.MinAmount > 0 && in.Amount < r.MinAmount {
+		return false
+	}
+	if r.MaxAmount > 0 && in.Amount > r.MaxAmount {
+		return false
+	}
+	return true
+}
+
+// Apply runs all rules against in. Each action is taken from the first matching
+// rule that sets it, so a rule reassigning the party and another setting the
+// category can both apply. Entries matching no category rule are receipts.
+func (e *Engine) Apply(in Input) Result {
+	var res Result
+	for _, r := range e.rules {
+		if !r.matches(in) {
+			continue
+		}
+		res.Matched = append(res.Matched, r.Name)
+		if res.Category == "" && r.Category != "" {
+			res.Category = r.Category
+		}
+		if r.Internal {
+			res.Internal = true
+		}
+		if res.PartyName == "" && r.PartyName != "" {
+			res.PartyName = r.PartyName
+		}
+	}
+	if res.Category == "" {
+		res.Category = category.Receipt
+	}
+	return res
+}
@@ -0,0 +1,97 @@
+package rules
+
+import (
+	"testing"
+
+	"suspense.durgadawaghar.com/internal/category"
+)
+
+func TestApplyDefaultRules(t *testing.T) {
+	e, err := NewEngine(DefaultRules)
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+
+	tests := []struct {
+		name             string
+		in               Input
+		expectedCategory category.Category
+		expectedInternal bool
+		expectedParty    string
+	}{
+		{"Customer receipt", Input{"SANDHYA MEDICAL STORE LUCKNOW", "UPI/9450852076@YBL", 5000}, category.Receipt, false, ""},
+		{"Bank charges party line", Input{"BANK CHARGES HDFC", "", 59}, category.BankCharge, false, ""},
+		{"SMS charges narration", Input{"HDFC BANK", "SMS CHARGES FOR QTR ENDED SEP", 17.70}, category.BankCharge, false, ""},
+		{"GST on charges", Input{"ICICI BANK", "GST ON CHGS 18%", 3.20}, category.BankCharge, false, ""},
+		{"Interest credit", Input{"INTEREST ICICI", "", 120}, category.Interest, false, ""},
+		{"SB interest narration", Input{"ICICI BANK", "SB INT CR 01-10-2025", 120}, category.Interest, false, ""},
+		{"Own account", Input{"DURGA DAWA GHAR", "NEFT TO OWN A/C", 1000}, category.InternalTransfer, true, ""},
+		{"PNB transfer", Input{"PNB 0257002100103683", "RTGS-PUNBR52025050611851715-DURGA DAWA GHAR-0257002100103683-PUNB0025700", 460000}, category.InternalTransfer, true, ""},
+		{"Counter cash", Input{"CASH", "BY CASH -KANPUR - BIRHANA ROAD MANISHA", 226000}, category.Receipt, true, ""},
+		{"Customer cash deposit", Input{"GUPTA MEDICAL TIRWA", "BY CASH -733300 TIRWA (UP)", 15000}, category.Receipt, false, ""},
+		{"Paytm settlement", Input{"PAYTM BUSINESS", "NEFT-YESBN12025050101615715-ONE 97 COMMUNICATIONSLIMITED SETTL--001425000000", 555}, category.Receipt, false, "PAYTM BUSINESS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := e.Apply(tt.in)
+			if res.Category != tt.expectedCategory {
+				t.Errorf("Expected category %q, got %q", tt.expectedCategory, res.Category)
+			}
+			if res.Internal != tt.expectedInternal {
+				t.Errorf("Expected internal %v, got %v", tt.expectedInternal, res.Internal)
+			}
+			if res.PartyName != tt.expectedParty {
+				t.Errorf("Expected party %q, got %q", tt.expectedParty, res.PartyName)
+			}
+		})
+	}
+}
+
+func TestApplyCombinesRules(t *testing.T) {
+	e, err := NewEngine([]Rule{
+		{Name: "Small amounts", MaxAmount: 100, Category: category.Other},
+		{Name: "Rent", NarrationPattern: `\bRENT\b`, MinAmount: 5000, PartyName: "LANDLORD"},
+		{Name: "Rent category", NarrationPattern: `\bRENT\b`, Category: category.InternalTransfer},
+	})
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+
+	res := e.Apply(Input{Narration: "NEFT RENT OCT", Amount: 20000})
+	if res.PartyName != "LANDLORD" || res.Category != category.InternalTransfer {
+		t.Errorf("Expected LANDLORD/internal_transfer, got %q/%q", res.PartyName, res.Category)
+	}
+	if len(res.Matched) != 2 {
+		t.Errorf("Expected 2 matched rules, got %v", res.Matched)
+	}
+
+	// First category wins; amount range excludes the rent party rule
+	res = e.Apply(Input{Narration: "rent", Amount: 50})
+	if res.Category != category.Other || res.PartyName != "" {
+		t.Errorf("Expected other with no party, got %q/%q", res.Category, res.PartyName)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    Rule
+		wantErr bool
+	}{
+		{"Valid", Rule{Name: "ok", NarrationPattern: `FOO`, Category: category.Other}, false},
+		{"No conditions", Rule{Name: "x", Category: category.Other}, true},
+		{"No actions", Rule{Name: "x", NarrationPattern: `FOO`}, true},
+		{"Unknown category", Rule{Name: "x", NarrationPattern: `FOO`, Category: "misc"}, true},
+		{"Invalid pattern", Rule{Name: "x", PartyPattern: `(`, Internal: true}, true},
+		{"Inverted range", Rule{Name: "x", MinAmount: 10, MaxAmount: 5, Internal: true}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.rule)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
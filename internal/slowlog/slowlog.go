@@ -0,0 +1,186 @@
+// Package slowlog logs database queries that take longer than a threshold,
+// with their parameters redacted, and keeps the slowest run of each query to
+// show on an admin page
+package slowlog
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+)
+
+// queryName matches the name sqlc gives each query in its first line
+var queryName = regexp.MustCompile(`^-- name: (\w+)`)
+
+// Entry is the slow runs of one query since the server started
+type Entry struct {
+	Name    string // sqlc query name, or the start of the SQL
+	Query   string
+	Count   int
+	Total   time.Duration
+	Max     time.Duration
+	MaxArgs string // redacted parameters of the slowest run
+	Last    time.Time
+}
+
+// Average is the mean duration of the slow runs
+func (e Entry) Average() time.Duration {
+	return e.Total / time.Duration(e.Count)
+}
+
+// Recorder collects the queries slower than its threshold
+type Recorder struct {
+	threshold time.Duration
+	mu        sync.Mutex
+	entries   map[string]*Entry
+}
+
+// NewRecorder records queries taking threshold or longer; with a threshold of
+// zero nothing is recorded
+func NewRecorder(threshold time.Duration) *Recorder {
+	return &Recorder{threshold: threshold, entries: make(map[string]*Entry)}
+}
+
+// Threshold is the duration from which queries are recorded
+func (r *Recorder) Threshold() time.Duration {
+	return r.threshold
+}
+
+// Worst returns up to n queries, the slowest first
+func (r *Recorder) Worst(n int) []Entry {
+	r.mu.Lock()
+	entries := make([]Entry, 0, len(r.entries))
+	for _, e := range r.entries {
+		entries = append(entries, *e)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Max > entries[j].Max })
+	if len(entries) > n {
+		entries = entries[:n]
+	}
+	return entries
+}
+
+// record logs and keeps a run of query if it was slow
+func (r *Recorder) record(query string, args []interface{}, d time.Duration) {
+	if r.threshold <= 0 || d < r.threshold {
+		return
+	}
+	name := name(query)
+	redacted := Redact(args)
+	log.Printf("Slow query %s took %s, args %s", name, d.Round(time.Millisecond), redacted)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.entries[query]
+	if !ok {
+		e = &Entry{Name: name, Query: query}
+		r.entries[query] = e
+	}
+	e.Count++
+	e.Total += d
+	e.Last = time.Now()
+	if d > e.Max {
+		e.Max, e.MaxArgs = d, redacted
+	}
+}
+
+// name returns the sqlc name of a query, or its first words
+func name(query string) string {
+	if m := queryName.FindStringSubmatch(query); m != nil {
+		return m[1]
+	}
+	s := strings.Join(strings.Fields(query), " ")
+	if len(s) > 60 {
+		s = s[:60] + "…"
+	}
+	return s
+}
+
+// Redact formats query parameters without the names, narrations and numbers
+// they carry: in strings, letters become x and digits 9, keeping LIKE
+// wildcards and punctuation, so the shape of a search stays visible. Dates,
+// amounts and IDs are kept.
+func Redact(args []interface{}) string {
+	parts := make([]string, len(args))
+	for i, a := range args {
+		// Null types such as sql.NullString give their value or nil
+		if v, ok := a.(driver.Valuer); ok {
+			a, _ = v.Value()
+		}
+		switch v := a.(type) {
+		case nil:
+			parts[i] = "NULL"
+		case string:
+			parts[i] = redactString(v)
+		case []byte:
+			parts[i] = fmt.Sprintf("<%d bytes>", len(v))
+		case time.Time:
+			parts[i] = v.Format("2006-01-02")
+		default:
+			parts[i] = fmt.Sprintf("%v", v)
+		}
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+func redactString(s string) string {
+	return "'" + strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r):
+			return 'x'
+		case unicode.IsDigit(r):
+			return '9'
+		}
+		return r
+	}, s) + "'"
+}
+
+// DB wraps a database to time its queries. A query's time is until its first
+// row is ready; reading the remaining rows is not counted. Transactions are
+// not wrapped.
+type DB struct {
+	db       sqlc.DBTX
+	recorder *Recorder
+}
+
+// Wrap times the queries run on db, recording the slow ones in r
+func Wrap(db sqlc.DBTX, r *Recorder) *DB {
+	return &DB{db: db, recorder: r}
+}
+
+func (d *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	start := time.Now()
+	res, err := d.db.ExecContext(ctx, query, args...)
+	d.recorder.record(query, args, time.Since(start))
+	return res, err
+}
+
+func (d *DB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return d.db.PrepareContext(ctx, query)
+}
+
+func (d *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	d.recorder.record(query, args, time.Since(start))
+	return rows, err
+}
+
+func (d *DB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	start := time.Now()
+	row := d.db.QueryRowContext(ctx, query, args...)
+	d.recorder.record(query, args, time.Since(start))
+	return row
+}
@@ -0,0 +1,58 @@
+package slowlog
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestRedact(t *testing.T) {
+	date := time.Date(2025, time.March, 31, 0, 0, 0, 0, time.UTC)
+	got := Redact([]interface{}{
+		"%SANDHYA 9450852076%",
+		sql.NullString{String: "UPI/ok@ybl", Valid: true},
+		sql.NullString{},
+		sql.NullInt64{Int64: 7, Valid: true},
+		int64(42),
+		1250.5,
+		date,
+		[]byte("abc"),
+	})
+	want := "['%xxxxxxx 9999999999%', 'xxx/xx@xxx', NULL, 7, 42, 1250.5, 2025-03-31, <3 bytes>]"
+	if got != want {
+		t.Errorf("Redact() = %s, want %s", got, want)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	r := NewRecorder(100 * time.Millisecond)
+	search := "-- name: SearchReceipts :many\nSELECT 1"
+	r.record(search, []interface{}{"%ABC%"}, 300*time.Millisecond)
+	r.record(search, []interface{}{"%ABCDEF%"}, 500*time.Millisecond)
+	r.record("SELECT file FROM pragma_database_list", nil, 200*time.Millisecond)
+	r.record("-- name: GetParty :one\nSELECT 1", nil, 50*time.Millisecond)
+
+	worst := r.Worst(10)
+	if len(worst) != 2 {
+		t.Fatalf("Worst() returned %d queries, want 2 (fast ones are not recorded)", len(worst))
+	}
+	e := worst[0]
+	if e.Name != "SearchReceipts" || e.Count != 2 || e.Max != 500*time.Millisecond || e.MaxArgs != "['%xxxxxx%']" {
+		t.Errorf("slowest entry = %+v", e)
+	}
+	if e.Average() != 400*time.Millisecond {
+		t.Errorf("Average() = %s, want 400ms", e.Average())
+	}
+	if worst[1].Name != "SELECT file FROM pragma_database_list" {
+		t.Errorf("unnamed query name = %q", worst[1].Name)
+	}
+	if got := r.Worst(1); len(got) != 1 {
+		t.Errorf("Worst(1) returned %d queries", len(got))
+	}
+
+	off := NewRecorder(0)
+	off.record(search, nil, time.Hour)
+	if len(off.Worst(10)) != 0 {
+		t.Error("recorder with no threshold recorded a query")
+	}
+}
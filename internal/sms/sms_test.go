@@ -0,0 +1,102 @@
+package sms
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	for _, tt := range []struct {
+		phone, want string
+	}{
+		{"9876543210", "9876543210"},
+		{"+91 98765 43210", "9876543210"},
+		{"91-9876543210", "9876543210"},
+		{"09876543210", "9876543210"},
+		{"(0) 98765-43210", "9876543210"},
+	} {
+		if got, err := Normalize(tt.phone); err != nil || got != tt.want {
+			t.Errorf("Normalize(%q) = %q, %v, want %q", tt.phone, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"", "12345", "0512 2345678", "98765432100", "98765O3210"} {
+		if got, err := Normalize(bad); err == nil {
+			t.Errorf("Normalize(%q) = %q, want an error", bad, got)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	s, err := New("twilio://AC123:secret@+15005550006")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tw, ok := s.(*twilio); !ok || tw.from != "+15005550006" || !strings.HasSuffix(tw.api, "/Accounts/AC123/Messages.json") {
+		t.Errorf("New(twilio://) = %#v", s)
+	}
+	for _, bad := range []string{
+		"smtp://mail.example.com",
+		"twilio://AC123@+15005550006",
+		"https://sms.example.com/send?to={phone}",
+	} {
+		if _, err := New(bad); err == nil {
+			t.Errorf("New(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestTemplateSend(t *testing.T) {
+	var got *http.Request
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.WriteHeader(status)
+		w.Write([]byte("invalid key"))
+	}))
+	defer srv.Close()
+
+	s, err := New(srv.URL + "/send?key=abc&to=91{phone}&text={message}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Send(context.Background(), "9876543210", "Received ₹11,744 & thanks"); err != nil {
+		t.Fatal(err)
+	}
+	q := got.URL.Query()
+	if q.Get("key") != "abc" || q.Get("to") != "919876543210" || q.Get("text") != "Received ₹11,744 & thanks" {
+		t.Errorf("gateway got %s", got.URL)
+	}
+
+	status = http.StatusUnauthorized
+	err = s.Send(context.Background(), "9876543210", "hello")
+	if err == nil || !strings.Contains(err.Error(), "invalid key") {
+		t.Errorf("Send() = %v, want the gateway's answer", err)
+	}
+}
+
+func TestTwilioSend(t *testing.T) {
+	var user, password string
+	var form map[string][]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ = r.BasicAuth()
+		r.ParseForm()
+		form = r.PostForm
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	s, _ := New("twilio://AC123:secret@+15005550006")
+	s.(*twilio).api = srv.URL
+	if err := s.Send(context.Background(), "9876543210", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if user != "AC123" || password != "secret" {
+		t.Errorf("basic auth = %q:%q", user, password)
+	}
+	if form["To"][0] != "+919876543210" || form["From"][0] != "+15005550006" || form["Body"][0] != "hello" {
+		t.Errorf("form = %v", form)
+	}
+}
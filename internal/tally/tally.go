@@ -0,0 +1,127 @@
+// Package tally writes receipts as Tally vouchers in the XML Tally imports
+// through Gateway of Tally > Import > Vouchers. Each receipt credits the
+// party's ledger against the bills it pays, as Agst Ref bill allocations, so
+// Tally's bill-wise outstanding matches the allocations made here.
+package tally
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// Receipt is money received from a party into a bank or cash ledger
+type Receipt struct {
+	ID        string // stays the same across exports, so Tally knows a receipt imported before
+	Date      time.Time
+	Party     string // the party's ledger
+	Ledger    string // the bank or cash ledger the money went into
+	Amount    float64
+	Narration string
+	Bills     []Bill // the bills the receipt pays; the rest of it is on account
+}
+
+// Bill is part of a receipt paying a sale bill
+type Bill struct {
+	Number string
+	Amount float64
+}
+
+type envelope struct {
+	XMLName  xml.Name  `xml:"ENVELOPE"`
+	Request  string    `xml:"HEADER>TALLYREQUEST"`
+	Report   string    `xml:"BODY>IMPORTDATA>REQUESTDESC>REPORTNAME"`
+	Messages []message `xml:"BODY>IMPORTDATA>REQUESTDATA>TALLYMESSAGE"`
+}
+
+type message struct {
+	Voucher voucher `xml:"VOUCHER"`
+}
+
+type voucher struct {
+	RemoteID    string        `xml:"REMOTEID,attr"`
+	Type        string        `xml:"VCHTYPE,attr"`
+	Action      string        `xml:"ACTION,attr"`
+	Date        string        `xml:"DATE"`
+	TypeName    string        `xml:"VOUCHERTYPENAME"`
+	PartyLedger string        `xml:"PARTYLEDGERNAME"`
+	Narration   string        `xml:"NARRATION,omitempty"`
+	Entries     []ledgerEntry `xml:"ALLLEDGERENTRIES.LIST"`
+}
+
+type ledgerEntry struct {
+	Ledger         string           `xml:"LEDGERNAME"`
+	DeemedPositive string           `xml:"ISDEEMEDPOSITIVE"`
+	Amount         string           `xml:"AMOUNT"`
+	Bills          []billAllocation `xml:"BILLALLOCATIONS.LIST"`
+}
+
+type billAllocation struct {
+	Name   string `xml:"NAME,omitempty"`
+	Type   string `xml:"BILLTYPE"`
+	Amount string `xml:"AMOUNT"`
+}
+
+// WriteReceipts writes receipts as Receipt vouchers. In Tally's XML a credit
+// is positive and a debit negative, so the party's ledger gets the amount and
+// the bank ledger its negative.
+func WriteReceipts(w io.Writer, receipts []Receipt) error {
+	env := envelope{Request: "Import Data", Report: "Vouchers"}
+	for _, r := range receipts {
+		amount := paise(r.Amount)
+		party := ledgerEntry{Ledger: r.Party, DeemedPositive: "No", Amount: rupees(amount)}
+		onAccount := amount
+		for _, b := range r.Bills {
+			allocated := paise(b.Amount)
+			if allocated <= 0 {
+				continue
+			}
+			party.Bills = append(party.Bills, billAllocation{Name: b.Number, Type: "Agst Ref", Amount: rupees(allocated)})
+			onAccount -= allocated
+		}
+		if onAccount > 0 {
+			party.Bills = append(party.Bills, billAllocation{Type: "On Account", Amount: rupees(onAccount)})
+		}
+
+		env.Messages = append(env.Messages, message{voucher{
+			RemoteID:    r.ID,
+			Type:        "Receipt",
+			Action:      "Create",
+			Date:        r.Date.Format("20060102"),
+			TypeName:    "Receipt",
+			PartyLedger: r.Party,
+			Narration:   r.Narration,
+			Entries: []ledgerEntry{
+				party,
+				{Ledger: r.Ledger, DeemedPositive: "Yes", Amount: rupees(-amount)},
+			},
+		}})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", " ")
+	if err := enc.Encode(env); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// paise rounds an amount to whole paise, so the bill allocations and what is
+// on account add up to the receipt exactly
+func paise(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+func rupees(paise int64) string {
+	sign := ""
+	if paise < 0 {
+		sign, paise = "-", -paise
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, paise/100, paise%100)
+}
@@ -0,0 +1,88 @@
+package tally
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteReceipts(t *testing.T) {
+	var b strings.Builder
+	err := WriteReceipts(&b, []Receipt{
+		{
+			ID:        "suspense-1-52",
+			Date:      time.Date(2026, time.September, 5, 0, 0, 0, 0, time.UTC),
+			Party:     "SIDDHI MEDICAL HALL",
+			Ledger:    "ICICI 000105001234",
+			Amount:    21110,
+			Narration: "NEFT/SIDDHI & SONS",
+			Bills: []Bill{
+				{Number: "A260800149", Amount: 8334.189999999999},
+				{Number: "A260900055", Amount: 11538.74},
+				{Number: "A260900122", Amount: 0.004},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<ENVELOPE>
+ <HEADER>
+  <TALLYREQUEST>Import Data</TALLYREQUEST>
+ </HEADER>
+ <BODY>
+  <IMPORTDATA>
+   <REQUESTDESC>
+    <REPORTNAME>Vouchers</REPORTNAME>
+   </REQUESTDESC>
+   <REQUESTDATA>
+    <TALLYMESSAGE>
+     <VOUCHER REMOTEID="suspense-1-52" VCHTYPE="Receipt" ACTION="Create">
+      <DATE>20260905</DATE>
+      <VOUCHERTYPENAME>Receipt</VOUCHERTYPENAME>
+      <PARTYLEDGERNAME>SIDDHI MEDICAL HALL</PARTYLEDGERNAME>
+      <NARRATION>NEFT/SIDDHI &amp; SONS</NARRATION>
+      <ALLLEDGERENTRIES.LIST>
+       <LEDGERNAME>SIDDHI MEDICAL HALL</LEDGERNAME>
+       <ISDEEMEDPOSITIVE>No</ISDEEMEDPOSITIVE>
+       <AMOUNT>21110.00</AMOUNT>
+       <BILLALLOCATIONS.LIST>
+        <NAME>A260800149</NAME>
+        <BILLTYPE>Agst Ref</BILLTYPE>
+        <AMOUNT>8334.19</AMOUNT>
+       </BILLALLOCATIONS.LIST>
+       <BILLALLOCATIONS.LIST>
+        <NAME>A260900055</NAME>
+        <BILLTYPE>Agst Ref</BILLTYPE>
+        <AMOUNT>11538.74</AMOUNT>
+       </BILLALLOCATIONS.LIST>
+       <BILLALLOCATIONS.LIST>
+        <BILLTYPE>On Account</BILLTYPE>
+        <AMOUNT>1237.07</AMOUNT>
+       </BILLALLOCATIONS.LIST>
+      </ALLLEDGERENTRIES.LIST>
+      <ALLLEDGERENTRIES.LIST>
+       <LEDGERNAME>ICICI 000105001234</LEDGERNAME>
+       <ISDEEMEDPOSITIVE>Yes</ISDEEMEDPOSITIVE>
+       <AMOUNT>-21110.00</AMOUNT>
+      </ALLLEDGERENTRIES.LIST>
+     </VOUCHER>
+    </TALLYMESSAGE>
+   </REQUESTDATA>
+  </IMPORTDATA>
+ </BODY>
+</ENVELOPE>
+`
+	if got := b.String(); got != want {
+		t.Errorf("WriteReceipts() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRupees(t *testing.T) {
+	for paise, want := range map[int64]string{0: "0.00", 5: "0.05", 123456: "1234.56", -2111000: "-21110.00", -7: "-0.07"} {
+		if got := rupees(paise); got != want {
+			t.Errorf("rupees(%d) = %s, want %s", paise, got, want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Package tracing exports OpenTelemetry spans for requests, matching and
+// database queries to an OTLP endpoint
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"suspense.durgadawaghar.com/internal/db/sqlc"
+)
+
+// ServiceName identifies this server's spans
+const ServiceName = "suspense"
+
+// Tracer starts the spans of this server. Until Setup installs an exporter
+// the spans are discarded.
+var Tracer = otel.Tracer("suspense.durgadawaghar.com")
+
+// Setup exports spans to the OTLP/HTTP endpoint, e.g. http://localhost:4318,
+// and returns a function that flushes and stops the exporter
+func Setup(ctx context.Context, endpoint string) (func(context.Context) error, error) {
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("creating OTLP exporter: %w", err)
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("creating resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// End records err on span, if any, and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// queryName matches the name sqlc gives each query in its first line
+var queryName = regexp.MustCompile(`^-- name: (\w+)`)
+
+// DB wraps a database so each query runs in a span named after its sqlc
+// query. Transactions are not wrapped.
+type DB struct {
+	db sqlc.DBTX
+}
+
+// WrapDB traces the queries run on db
+func WrapDB(db sqlc.DBTX) *DB {
+	return &DB{db: db}
+}
+
+// start starts the span of a query
+func (d *DB) start(ctx context.Context, query string) (context.Context, trace.Span) {
+	name := "db.query"
+	if m := queryName.FindStringSubmatch(query); m != nil {
+		name = "db." + m[1]
+	}
+	return Tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNameSQLite,
+			attribute.String("db.query.text", query),
+		),
+	)
+}
+
+func (d *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := d.start(ctx, query)
+	res, err := d.db.ExecContext(ctx, query, args...)
+	End(span, err)
+	return res, err
+}
+
+func (d *DB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	ctx, span := d.start(ctx, query)
+	stmt, err := d.db.PrepareContext(ctx, query)
+	End(span, err)
+	return stmt, err
+}
+
+func (d *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := d.start(ctx, query)
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	End(span, err)
+	return rows, err
+}
+
+// QueryRowContext ends its span once the row is fetched; scanning errors,
+// including no rows, are not recorded
+func (d *DB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := d.start(ctx, query)
+	row := d.db.QueryRowContext(ctx, query, args...)
+	End(span, row.Err())
+	return row
+}
@@ -0,0 +1,61 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	_ "modernc.org/sqlite"
+)
+
+func TestWrapDB(t *testing.T) {
+	spans := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(spans))
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+
+	sqldb, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "suspense.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqldb.Close()
+	db := WrapDB(sqldb)
+	ctx := context.Background()
+
+	if _, err := db.ExecContext(ctx, "-- name: CreateFirms :exec\nCREATE TABLE firms (id INTEGER PRIMARY KEY, name TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM firms").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.QueryContext(ctx, "-- name: ListParties :many\nSELECT * FROM parties"); err == nil {
+		t.Fatal("query of a missing table succeeded")
+	}
+
+	got := spans.GetSpans()
+	if len(got) != 3 {
+		t.Fatalf("got %d spans, want 3", len(got))
+	}
+	for i, want := range []struct {
+		name   string
+		status codes.Code
+	}{
+		// spans are named after the sqlc query, or db.query for others
+		{"db.CreateFirms", codes.Unset},
+		{"db.query", codes.Unset},
+		{"db.ListParties", codes.Error},
+	} {
+		if got[i].Name != want.name || got[i].Status.Code != want.status {
+			t.Errorf("span %d is %s with status %v, want %s with %v", i, got[i].Name, got[i].Status.Code, want.name, want.status)
+		}
+	}
+	if len(got[2].Events) == 0 {
+		t.Errorf("failed query's error not recorded")
+	}
+}
@@ -0,0 +1,45 @@
+package views
+
+import "context"
+
+// Firm is one of the GST registrations run from the shop. RoundingTolerance is
+// how far a payment may differ from a bill and still be taken for it.
+type Firm struct {
+	ID                int64
+	Name              string
+	GSTIN             string
+	RoundingTolerance float64
+}
+
+// defaultFirm is the firm assumed when a request carries none, the one that
+// records from before firms existed belong to
+var defaultFirm = Firm{ID: 1, Name: "Durga Dawa Ghar"}
+
+type firmKey struct{}
+
+type firmContext struct {
+	current Firm
+	firms   []Firm
+}
+
+// WithFirm returns a context for working in the current firm, with the firms
+// the switcher offers
+func WithFirm(ctx context.Context, current Firm, firms []Firm) context.Context {
+	return context.WithValue(ctx, firmKey{}, firmContext{current: current, firms: firms})
+}
+
+// CurrentFirm returns the firm being worked in
+func CurrentFirm(ctx context.Context) Firm {
+	if fc, ok := ctx.Value(firmKey{}).(firmContext); ok {
+		return fc.current
+	}
+	return defaultFirm
+}
+
+// Firms returns the firms to switch between
+func Firms(ctx context.Context) []Firm {
+	if fc, ok := ctx.Value(firmKey{}).(firmContext); ok {
+		return fc.firms
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+package views
+
+import "context"
+
+type readOnlyKey struct{}
+
+// WithReadOnly returns a context for a server that only searches and reports
+func WithReadOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readOnlyKey{}, true)
+}
+
+// ReadOnly reports whether changes are turned off
+func ReadOnly(ctx context.Context) bool {
+	readOnly, _ := ctx.Value(readOnlyKey{}).(bool)
+	return readOnly
+}